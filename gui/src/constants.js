@@ -5,5 +5,6 @@ export const MESSAGE_TYPES = {
 	ROLLBACK_SUBMIT: 'rollbackSubmit',
 	ROLLBACK_CONFIRM: 'rollbackConfirm',
 	ROLLBACK_COMMIT: 'rollbackCommit',
-    ROLLBACK_RESULT: 'rollbackResult'
+    ROLLBACK_RESULT: 'rollbackResult',
+	NODE_EVENT: 'nodeEvent',
 };
@@ -0,0 +1,20 @@
+import { useState } from 'react';
+
+import { MESSAGE_TYPES } from '../constants';
+
+// latest event of each node, by node id
+export default function useNodeEvents() {
+	const [nodeEvents, setNodeEvents] = useState({});
+
+	const onWSMessage = ({ type, value }) => {
+		switch (type) {
+			case MESSAGE_TYPES.NODE_EVENT:
+				setNodeEvents((events) => ({ ...events, [value.node]: value }));
+		}
+	};
+
+	return {
+		onWSMessage,
+		nodeEvents,
+	};
+}
@@ -3,12 +3,14 @@ import { createRoot } from 'react-dom/client';
 
 import MessageGraph from './components/MessageGraph';
 import useCheckpointLog from './hooks/useCheckpointLog';
+import useNodeEvents from './hooks/useNodeEvents';
 import useRollback from './hooks/useRollback';
 
 import * as websocket from './websocket';
 
 const App = () => {
 	const { onWSMessage, checkpointLog } = useCheckpointLog();
+	const { onWSMessage: onNodeEventMessage, nodeEvents } = useNodeEvents();
 
 	const {
 		onWSMessage: onRollbackMessage,
@@ -25,6 +27,7 @@ const App = () => {
 	websocket.connect((message) => {
 		onWSMessage(message);
 		onRollbackMessage(message);
+		onNodeEventMessage(message);
 	});
 
 	return (
@@ -40,6 +43,15 @@ const App = () => {
 					<span>Click a green node to roll back to this checkpoint</span>
 				)
 			)}
+			<ul>
+				{Object.values(nodeEvents).map(({ node, kind, location, detail }) => (
+					<li key={node}>
+						{`node ${node}: ${kind}`}
+						{location && ` at ${location}`}
+						{detail && ` (${detail})`}
+					</li>
+				))}
+			</ul>
 			<MessageGraph
 				pendingRollbackOriginalCheckpoint={pendingRollbackOriginalCheckpoint}
 				onRollbackSubmit={onRollbackSubmit}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -19,8 +20,12 @@ const (
 	WRAPPED_MPI_FILE_INCLUDE = `#include "debug_mpi_wrap.h"`
 	WRAPPED_MPI_FORK_INCLUDE = `#include "debug_mpi_wrap_fork.h"`
 	WRAPPED_MPI_PATH         = "src/compiler/mpi_wrap_include"
+	WRAPPED_MPI_FORTRAN_FILE = "debug_mpi_wrap_fortran.c"
+	FORTRAN_INSERTED_LINE    = "! compiled for cc-rev-db"
 )
 
+var FORTRAN_EXTENSIONS = map[string]bool{".f": true, ".for": true, ".f90": true, ".f95": true, ".f03": true, ".f08": true}
+
 var WRAPPED_MPI_INCLUDE string = WRAPPED_MPI_FILE_INCLUDE
 
 /*
@@ -60,7 +65,11 @@ func executeWorkflow() error {
 	//remove the temporary wrapped source file
 	defer os.Remove(wrappedSource.Name())
 
-	err = compile(wrappedSource.Name(), getDestPath(inputFilePath))
+	if isFortranSource(inputFilePath) {
+		err = compileFortran(wrappedSource.Name(), getDestPath(inputFilePath), inputFilePath)
+	} else {
+		err = compile(wrappedSource.Name(), getDestPath(inputFilePath), inputFilePath)
+	}
 	if err != nil {
 		logger.Error("Compilation failed: %v ", err)
 		return err
@@ -69,8 +78,8 @@ func executeWorkflow() error {
 	return nil
 }
 
-func compile(sourcePath string, destPath string) error {
-	cmd := exec.Command("mpicc", "-g", "-no-pie", "-I", WRAPPED_MPI_PATH, "-o", destPath, sourcePath)
+func compile(sourcePath string, destPath string, originalPath string) error {
+	cmd := exec.Command("mpicc", "-g", "-no-pie", "-I", WRAPPED_MPI_PATH, originalSourceDefinition(originalPath), "-o", destPath, sourcePath)
 
 	logger.Info("compiling target")
 	logger.Verbose("%v", cmd)
@@ -89,6 +98,55 @@ func compile(sourcePath string, destPath string) error {
 	return nil
 }
 
+// Fortran sources call the MPI library through its Fortran bindings, which are
+// replaced by bindings calling the wrappers (see mpi_wrap_include/debug_mpi_wrap_fortran.c)
+func compileFortran(sourcePath string, destPath string, originalPath string) error {
+	wrapperObject := path.Join(TEMP_FOLDER, fileNameWithoutExtension(WRAPPED_MPI_FORTRAN_FILE)+".o")
+	defer os.Remove(wrapperObject)
+
+	cmd := exec.Command("mpicc", "-g", "-c", "-I", WRAPPED_MPI_PATH, originalSourceDefinition(originalPath), "-o", wrapperObject, path.Join(WRAPPED_MPI_PATH, WRAPPED_MPI_FORTRAN_FILE))
+
+	logger.Info("compiling fortran bindings of the mpi wrapper")
+	logger.Verbose("%v", cmd)
+
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return err
+	}
+
+	cmd = exec.Command("mpif90", "-g", "-no-pie", "-o", destPath, sourcePath, wrapperObject)
+
+	logger.Info("compiling target")
+	logger.Verbose("%v", cmd)
+
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return err
+	}
+
+	logger.Info("wrote compiled target to: %v", destPath)
+	logger.Info("compilation finished")
+
+	return nil
+}
+
+// The wrapped copy is removed after compiling, the path of the original source is compiled into the
+// wrapper (_MPI_WRAPPER_SOURCE_FILE) for the debugger to list it
+func originalSourceDefinition(originalPath string) string {
+	absolutePath, err := filepath.Abs(originalPath)
+	if err != nil {
+		absolutePath = originalPath
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(absolutePath)
+
+	return fmt.Sprintf(`-D_MPI_WRAPPER_ORIGINAL_SOURCE="%s"`, escaped)
+}
+
 func createWrappedCopy(inputFilePath string) (*os.File, error) {
 	filePath := fmt.Sprintf("%s/%s", TEMP_FOLDER, path.Base(inputFilePath))
 
@@ -103,12 +161,21 @@ func createWrappedCopy(inputFilePath string) (*os.File, error) {
 
 	scanner := bufio.NewScanner(source)
 
-	dest.WriteString(terminate(WRAPPED_MPI_INCLUDE))
+	fortran := isFortranSource(inputFilePath)
+
+	// the debugger expects one line to be inserted at the start of the source
+	if fortran {
+		dest.WriteString(terminate(FORTRAN_INSERTED_LINE))
+	} else {
+		dest.WriteString(terminate(WRAPPED_MPI_INCLUDE))
+	}
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		line = prefixMPICalls(line)
+		if !fortran {
+			line = prefixMPICalls(line)
+		}
 
 		dest.WriteString(terminate(line))
 	}
@@ -147,7 +214,7 @@ func ensureValidTargetExists(inputFilePath string) error {
 
 	fileExtension := path.Ext(fileInfo.Name())
 
-	if !validExtensions[fileExtension] {
+	if !validExtensions[fileExtension] && !isFortranSource(inputFilePath) {
 		return fmt.Errorf("unsupported file extension: %v", fileExtension)
 	}
 
@@ -172,6 +239,10 @@ func determineCheckpointingMode() {
 	}
 }
 
+func isFortranSource(inputFilePath string) bool {
+	return FORTRAN_EXTENSIONS[strings.ToLower(path.Ext(inputFilePath))]
+}
+
 func terminate(line string) string {
 	return fmt.Sprintf("%s\n", line)
 }
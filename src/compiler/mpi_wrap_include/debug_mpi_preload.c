@@ -0,0 +1,168 @@
+#include <mpi.h>
+
+/*
+    Shared library intercepting the MPI calls of targets not compiled with the wrapper.
+    The debugger loads it into such targets with LD_PRELOAD, so the MPI functions below take
+    precedence over those of the MPI library. They call the same wrappers as compiled targets,
+    which in turn call the MPI library through its profiling interface (PMPI).
+
+    Built with `make preload`.
+    The wrappers are hidden, so that they do not clash with those of targets compiled with the wrapper.
+*/
+
+#define MPI_Init PMPI_Init
+#define MPI_Comm_size PMPI_Comm_size
+#define MPI_Comm_rank PMPI_Comm_rank
+#define MPI_Comm_dup PMPI_Comm_dup
+#define MPI_Finalize PMPI_Finalize
+#define MPI_Send PMPI_Send
+#define MPI_Recv PMPI_Recv
+#define MPI_Abort PMPI_Abort
+#define MPI_Wtime PMPI_Wtime
+#define MPI_Isend PMPI_Isend
+#define MPI_Irecv PMPI_Irecv
+#define MPI_Wait PMPI_Wait
+#define MPI_Waitall PMPI_Waitall
+#define MPI_Test PMPI_Test
+#define MPI_Barrier PMPI_Barrier
+#define MPI_Bcast PMPI_Bcast
+#define MPI_Reduce PMPI_Reduce
+#define MPI_Allreduce PMPI_Allreduce
+#define MPI_Gather PMPI_Gather
+#define MPI_Allgather PMPI_Allgather
+#define MPI_Scatter PMPI_Scatter
+
+#include "debug_mpi_wrap.h"
+
+#undef MPI_Init
+#undef MPI_Comm_size
+#undef MPI_Comm_rank
+#undef MPI_Comm_dup
+#undef MPI_Finalize
+#undef MPI_Send
+#undef MPI_Recv
+#undef MPI_Abort
+#undef MPI_Wtime
+#undef MPI_Isend
+#undef MPI_Irecv
+#undef MPI_Wait
+#undef MPI_Waitall
+#undef MPI_Test
+#undef MPI_Barrier
+#undef MPI_Bcast
+#undef MPI_Reduce
+#undef MPI_Allreduce
+#undef MPI_Gather
+#undef MPI_Allgather
+#undef MPI_Scatter
+
+#define INTERCEPTED __attribute__((visibility("default")))
+
+INTERCEPTED int MPI_Init(int *argc, char ***argv)
+{
+    return _MPI_Init(argc, argv);
+}
+
+INTERCEPTED int MPI_Comm_size(MPI_Comm comm, int *size)
+{
+    return _MPI_Comm_size(comm, size);
+}
+
+INTERCEPTED int MPI_Comm_rank(MPI_Comm comm, int *rank)
+{
+    return _MPI_Comm_rank(comm, rank);
+}
+
+INTERCEPTED int MPI_Finalize()
+{
+    return _MPI_Finalize();
+}
+
+INTERCEPTED int MPI_Send(const void *buf, int count, MPI_Datatype datatype, int dest,
+                         int tag, MPI_Comm comm)
+{
+    return _MPI_Send(buf, count, datatype, dest, tag, comm);
+}
+
+INTERCEPTED int MPI_Recv(void *buf, int count, MPI_Datatype datatype, int source,
+                         int tag, MPI_Comm comm, MPI_Status *status)
+{
+    return _MPI_Recv(buf, count, datatype, source, tag, comm, status);
+}
+
+INTERCEPTED int MPI_Abort(MPI_Comm comm, int errorcode)
+{
+    return _MPI_Abort(comm, errorcode);
+}
+
+INTERCEPTED double MPI_Wtime()
+{
+    return _MPI_Wtime();
+}
+
+INTERCEPTED int MPI_Isend(const void *buf, int count, MPI_Datatype datatype, int dest,
+                          int tag, MPI_Comm comm, MPI_Request *request)
+{
+    return _MPI_Isend(buf, count, datatype, dest, tag, comm, request);
+}
+
+INTERCEPTED int MPI_Irecv(void *buf, int count, MPI_Datatype datatype, int source,
+                          int tag, MPI_Comm comm, MPI_Request *request)
+{
+    return _MPI_Irecv(buf, count, datatype, source, tag, comm, request);
+}
+
+INTERCEPTED int MPI_Wait(MPI_Request *request, MPI_Status *status)
+{
+    return _MPI_Wait(request, status);
+}
+
+INTERCEPTED int MPI_Waitall(int count, MPI_Request requests[], MPI_Status statuses[])
+{
+    return _MPI_Waitall(count, requests, statuses);
+}
+
+INTERCEPTED int MPI_Test(MPI_Request *request, int *flag, MPI_Status *status)
+{
+    return _MPI_Test(request, flag, status);
+}
+
+INTERCEPTED int MPI_Barrier(MPI_Comm comm)
+{
+    return _MPI_Barrier(comm);
+}
+
+INTERCEPTED int MPI_Bcast(void *buffer, int count, MPI_Datatype datatype, int root, MPI_Comm comm)
+{
+    return _MPI_Bcast(buffer, count, datatype, root, comm);
+}
+
+INTERCEPTED int MPI_Reduce(const void *sendbuf, void *recvbuf, int count, MPI_Datatype datatype,
+                           MPI_Op op, int root, MPI_Comm comm)
+{
+    return _MPI_Reduce(sendbuf, recvbuf, count, datatype, op, root, comm);
+}
+
+INTERCEPTED int MPI_Allreduce(const void *sendbuf, void *recvbuf, int count, MPI_Datatype datatype,
+                              MPI_Op op, MPI_Comm comm)
+{
+    return _MPI_Allreduce(sendbuf, recvbuf, count, datatype, op, comm);
+}
+
+INTERCEPTED int MPI_Gather(const void *sendbuf, int sendcount, MPI_Datatype sendtype,
+                           void *recvbuf, int recvcount, MPI_Datatype recvtype, int root, MPI_Comm comm)
+{
+    return _MPI_Gather(sendbuf, sendcount, sendtype, recvbuf, recvcount, recvtype, root, comm);
+}
+
+INTERCEPTED int MPI_Allgather(const void *sendbuf, int sendcount, MPI_Datatype sendtype,
+                              void *recvbuf, int recvcount, MPI_Datatype recvtype, MPI_Comm comm)
+{
+    return _MPI_Allgather(sendbuf, sendcount, sendtype, recvbuf, recvcount, recvtype, comm);
+}
+
+INTERCEPTED int MPI_Scatter(const void *sendbuf, int sendcount, MPI_Datatype sendtype,
+                            void *recvbuf, int recvcount, MPI_Datatype recvtype, int root, MPI_Comm comm)
+{
+    return _MPI_Scatter(sendbuf, sendcount, sendtype, recvbuf, recvcount, recvtype, root, comm);
+}
@@ -1,6 +1,135 @@
 #include <mpi.h>
 
 int _MPI_WRAPPER_PROC_RANK;
+int _MPI_WRAPPER_WORLD_SIZE;
+int _MPI_WRAPPER_REQUEST_SIZE = sizeof(MPI_Request); // for locating the requests of _MPI_Waitall
+MPI_Request _MPI_WRAPPER_REQUEST_NULL = MPI_REQUEST_NULL; // for telling inactive request handles apart
+
+// Predefined datatypes and their sizes, filled in by _MPI_Init for the debugger to decode message payloads.
+// The order matches mpi.PAYLOAD_DATATYPES
+#define _MPI_WRAPPER_DATATYPE_COUNT 9
+MPI_Datatype _MPI_WRAPPER_DATATYPES[_MPI_WRAPPER_DATATYPE_COUNT];
+int _MPI_WRAPPER_DATATYPE_SIZES[_MPI_WRAPPER_DATATYPE_COUNT];
+
+void _MPI_WRAPPER_RECORD_DATATYPES()
+{
+    MPI_Datatype datatypes[_MPI_WRAPPER_DATATYPE_COUNT] = {
+        MPI_CHAR, MPI_BYTE, MPI_SHORT, MPI_INT, MPI_LONG, MPI_LONG_LONG, MPI_UNSIGNED, MPI_FLOAT, MPI_DOUBLE};
+
+    for (int i = 0; i < _MPI_WRAPPER_DATATYPE_COUNT; i++)
+    {
+        _MPI_WRAPPER_DATATYPES[i] = datatypes[i];
+        MPI_Type_size(datatypes[i], &_MPI_WRAPPER_DATATYPE_SIZES[i]);
+    }
+}
+
+// Source, tag and hash of the message of the last completed receive, read by the debugger at the next MPI call
+// to record the order the messages were received in
+int _MPI_WRAPPER_RECEIVED_SOURCE = -1;
+int _MPI_WRAPPER_RECEIVED_TAG = -1;
+unsigned long _MPI_WRAPPER_RECEIVED_HASH = 0;
+
+void _MPI_WRAPPER_RECORD_RECEIVE(const void *buf, MPI_Datatype datatype, MPI_Status *status)
+{
+    int count, size;
+    MPI_Get_count(status, datatype, &count);
+    MPI_Type_size(datatype, &size);
+
+    // FNV-1a hash of the contents of the message
+    unsigned long hash = 14695981039346656037UL;
+    const unsigned char *bytes = buf;
+    for (long i = 0; i < (long)count * size; i++)
+    {
+        hash = (hash ^ bytes[i]) * 1099511628211UL;
+    }
+
+    _MPI_WRAPPER_RECEIVED_SOURCE = status->MPI_SOURCE;
+    _MPI_WRAPPER_RECEIVED_TAG = status->MPI_TAG;
+    _MPI_WRAPPER_RECEIVED_HASH = hash;
+}
+
+// Message a receive completes with instead of one of the MPI library, set by the debugger when a node rolled back alone
+// replays its receives from the message log. The debugger writes the contents of the message to the buffer
+int _MPI_WRAPPER_LOGGED_MESSAGE = 0;
+int _MPI_WRAPPER_LOGGED_SOURCE = 0;
+int _MPI_WRAPPER_LOGGED_TAG = 0;
+int _MPI_WRAPPER_LOGGED_COUNT = 0;
+
+// Vector clock of the rank, with an entry per rank of MPI_COMM_WORLD counting its MPI events. The debugger counts
+// the events of this rank at its MPI calls. The clock is piggybacked on the point-to-point messages of MPI_COMM_WORLD,
+// sent after the message on a duplicate of the communicator, and the clocks of received messages are merged into it.
+// The order of messages between two ranks with the same tag is kept, so a clock is received with its message
+#define _MPI_WRAPPER_MAX_CLOCK_RANKS 4096
+int _MPI_WRAPPER_VECTOR_CLOCK[_MPI_WRAPPER_MAX_CLOCK_RANKS];
+int _MPI_WRAPPER_RECEIVED_CLOCK[_MPI_WRAPPER_MAX_CLOCK_RANKS];
+MPI_Comm _MPI_WRAPPER_CLOCK_COMM = MPI_COMM_NULL;
+
+// Nonblocking receives of MPI_COMM_WORLD, whose clocks are received once they complete
+#define _MPI_WRAPPER_MAX_CLOCK_REQUESTS 1024
+MPI_Request _MPI_WRAPPER_CLOCK_REQUESTS[_MPI_WRAPPER_MAX_CLOCK_REQUESTS];
+int _MPI_WRAPPER_CLOCK_REQUEST_COUNT = 0;
+
+int _MPI_WRAPPER_CLOCK_ENTRIES()
+{
+    return _MPI_WRAPPER_WORLD_SIZE < _MPI_WRAPPER_MAX_CLOCK_RANKS ? _MPI_WRAPPER_WORLD_SIZE : _MPI_WRAPPER_MAX_CLOCK_RANKS;
+}
+
+void _MPI_WRAPPER_SEND_CLOCK(int dest, int tag, MPI_Comm comm)
+{
+    if (comm != MPI_COMM_WORLD || dest == MPI_PROC_NULL || _MPI_WRAPPER_CLOCK_COMM == MPI_COMM_NULL)
+    {
+        return;
+    }
+
+    // small enough to be sent eagerly, also after nonblocking sends
+    MPI_Send(_MPI_WRAPPER_VECTOR_CLOCK, _MPI_WRAPPER_CLOCK_ENTRIES(), MPI_INT, dest, tag, _MPI_WRAPPER_CLOCK_COMM);
+}
+
+void _MPI_WRAPPER_RECEIVE_CLOCK(MPI_Status *status)
+{
+    if (status->MPI_SOURCE == MPI_PROC_NULL || _MPI_WRAPPER_CLOCK_COMM == MPI_COMM_NULL)
+    {
+        return;
+    }
+
+    int entries = _MPI_WRAPPER_CLOCK_ENTRIES();
+    MPI_Recv(_MPI_WRAPPER_RECEIVED_CLOCK, entries, MPI_INT, status->MPI_SOURCE, status->MPI_TAG, _MPI_WRAPPER_CLOCK_COMM, MPI_STATUS_IGNORE);
+
+    for (int i = 0; i < entries; i++)
+    {
+        if (_MPI_WRAPPER_RECEIVED_CLOCK[i] > _MPI_WRAPPER_VECTOR_CLOCK[i])
+        {
+            _MPI_WRAPPER_VECTOR_CLOCK[i] = _MPI_WRAPPER_RECEIVED_CLOCK[i];
+        }
+    }
+}
+
+void _MPI_WRAPPER_AWAIT_CLOCK(MPI_Request request, MPI_Comm comm)
+{
+    if (comm == MPI_COMM_WORLD && _MPI_WRAPPER_CLOCK_REQUEST_COUNT < _MPI_WRAPPER_MAX_CLOCK_REQUESTS)
+    {
+        _MPI_WRAPPER_CLOCK_REQUESTS[_MPI_WRAPPER_CLOCK_REQUEST_COUNT++] = request;
+    }
+}
+
+// Receives the clock of the message of a completed request, if it was a nonblocking receive of MPI_COMM_WORLD
+void _MPI_WRAPPER_RECEIVE_REQUEST_CLOCK(MPI_Request request, MPI_Status *status)
+{
+    for (int i = 0; i < _MPI_WRAPPER_CLOCK_REQUEST_COUNT; i++)
+    {
+        if (_MPI_WRAPPER_CLOCK_REQUESTS[i] == request)
+        {
+            _MPI_WRAPPER_CLOCK_REQUESTS[i] = _MPI_WRAPPER_CLOCK_REQUESTS[--_MPI_WRAPPER_CLOCK_REQUEST_COUNT];
+            _MPI_WRAPPER_RECEIVE_CLOCK(status);
+            return;
+        }
+    }
+}
+
+// Path of the source file the target was compiled from by bin/compiler, which removes the compiled copy
+#ifdef _MPI_WRAPPER_ORIGINAL_SOURCE
+char _MPI_WRAPPER_SOURCE_FILE[] = _MPI_WRAPPER_ORIGINAL_SOURCE;
+#endif
 
 void _MPI_WRAPPER_INCLUDE() {}
 
@@ -9,6 +138,9 @@ int _MPI_Init(int *argc, char ***argv)
     int ret = MPI_Init(argc, argv);
     // Record process rank on comm_world
     MPI_Comm_rank(MPI_COMM_WORLD, &_MPI_WRAPPER_PROC_RANK);
+    MPI_Comm_size(MPI_COMM_WORLD, &_MPI_WRAPPER_WORLD_SIZE);
+    _MPI_WRAPPER_RECORD_DATATYPES();
+    MPI_Comm_dup(MPI_COMM_WORLD, &_MPI_WRAPPER_CLOCK_COMM);
     return ret;
 }
 
@@ -30,13 +162,175 @@ int _MPI_Finalize()
 int _MPI_Send(const void *buf, int count, MPI_Datatype datatype, int dest,
               int tag, MPI_Comm comm)
 {
-    return MPI_Send(buf, count, datatype, dest, tag, comm);
+    int code = MPI_Send(buf, count, datatype, dest, tag, comm);
+    if (code == MPI_SUCCESS)
+    {
+        _MPI_WRAPPER_SEND_CLOCK(dest, tag, comm);
+    }
+    return code;
 }
 
 int _MPI_Recv(void *buf, int count, MPI_Datatype datatype, int source,
               int tag, MPI_Comm comm, MPI_Status *status)
 {
-    return MPI_Recv(buf, count, datatype, source, tag, comm, status);
+    MPI_Status received;
+    if (status == MPI_STATUS_IGNORE)
+    {
+        status = &received;
+    }
+
+    int code;
+    if (_MPI_WRAPPER_LOGGED_MESSAGE)
+    {
+        _MPI_WRAPPER_LOGGED_MESSAGE = 0;
+        status->MPI_SOURCE = _MPI_WRAPPER_LOGGED_SOURCE;
+        status->MPI_TAG = _MPI_WRAPPER_LOGGED_TAG;
+        status->MPI_ERROR = MPI_SUCCESS;
+        MPI_Status_set_elements(status, datatype, _MPI_WRAPPER_LOGGED_COUNT);
+        code = MPI_SUCCESS;
+    }
+    else
+    {
+        code = MPI_Recv(buf, count, datatype, source, tag, comm, status);
+        if (code == MPI_SUCCESS && comm == MPI_COMM_WORLD)
+        {
+            _MPI_WRAPPER_RECEIVE_CLOCK(status);
+        }
+    }
+
+    if (code == MPI_SUCCESS)
+    {
+        _MPI_WRAPPER_RECORD_RECEIVE(buf, datatype, status);
+    }
+    return code;
+}
+
+int _MPI_Isend(const void *buf, int count, MPI_Datatype datatype, int dest,
+               int tag, MPI_Comm comm, MPI_Request *request)
+{
+    int code = MPI_Isend(buf, count, datatype, dest, tag, comm, request);
+    if (code == MPI_SUCCESS)
+    {
+        _MPI_WRAPPER_SEND_CLOCK(dest, tag, comm);
+    }
+    return code;
+}
+
+int _MPI_Irecv(void *buf, int count, MPI_Datatype datatype, int source,
+               int tag, MPI_Comm comm, MPI_Request *request)
+{
+    int code = MPI_Irecv(buf, count, datatype, source, tag, comm, request);
+    if (code == MPI_SUCCESS)
+    {
+        _MPI_WRAPPER_AWAIT_CLOCK(*request, comm);
+    }
+    return code;
+}
+
+int _MPI_Wait(MPI_Request *request, MPI_Status *status)
+{
+    MPI_Status completed;
+    if (status == MPI_STATUS_IGNORE)
+    {
+        status = &completed;
+    }
+
+    MPI_Request handle = *request;
+    int code = MPI_Wait(request, status);
+    if (code == MPI_SUCCESS)
+    {
+        _MPI_WRAPPER_RECEIVE_REQUEST_CLOCK(handle, status);
+    }
+    return code;
+}
+
+int _MPI_Waitall(int count, MPI_Request requests[], MPI_Status statuses[])
+{
+    MPI_Request handles[count];
+    MPI_Status completed[count];
+    if (statuses == MPI_STATUSES_IGNORE)
+    {
+        statuses = completed;
+    }
+
+    for (int i = 0; i < count; i++)
+    {
+        handles[i] = requests[i];
+    }
+
+    int code = MPI_Waitall(count, requests, statuses);
+    if (code == MPI_SUCCESS)
+    {
+        for (int i = 0; i < count; i++)
+        {
+            _MPI_WRAPPER_RECEIVE_REQUEST_CLOCK(handles[i], &statuses[i]);
+        }
+    }
+    return code;
+}
+
+// Marks the completion of a request found by _MPI_Test.
+// The tests themselves are not recorded, as they are called repeatedly while polling
+void _MPI_Test_completed(MPI_Request *request)
+{
+}
+
+int _MPI_Test(MPI_Request *request, int *flag, MPI_Status *status)
+{
+    MPI_Status completed;
+    if (status == MPI_STATUS_IGNORE)
+    {
+        status = &completed;
+    }
+
+    MPI_Request handle = *request;
+    int code = MPI_Test(request, flag, status);
+    if (*flag)
+    {
+        _MPI_Test_completed(request);
+        _MPI_WRAPPER_RECEIVE_REQUEST_CLOCK(handle, status);
+    }
+    return code;
+}
+
+int _MPI_Barrier(MPI_Comm comm)
+{
+    return MPI_Barrier(comm);
+}
+
+int _MPI_Bcast(void *buffer, int count, MPI_Datatype datatype, int root, MPI_Comm comm)
+{
+    return MPI_Bcast(buffer, count, datatype, root, comm);
+}
+
+int _MPI_Reduce(const void *sendbuf, void *recvbuf, int count, MPI_Datatype datatype,
+                MPI_Op op, int root, MPI_Comm comm)
+{
+    return MPI_Reduce(sendbuf, recvbuf, count, datatype, op, root, comm);
+}
+
+int _MPI_Allreduce(const void *sendbuf, void *recvbuf, int count, MPI_Datatype datatype,
+                   MPI_Op op, MPI_Comm comm)
+{
+    return MPI_Allreduce(sendbuf, recvbuf, count, datatype, op, comm);
+}
+
+int _MPI_Gather(const void *sendbuf, int sendcount, MPI_Datatype sendtype,
+                void *recvbuf, int recvcount, MPI_Datatype recvtype, int root, MPI_Comm comm)
+{
+    return MPI_Gather(sendbuf, sendcount, sendtype, recvbuf, recvcount, recvtype, root, comm);
+}
+
+int _MPI_Allgather(const void *sendbuf, int sendcount, MPI_Datatype sendtype,
+                   void *recvbuf, int recvcount, MPI_Datatype recvtype, MPI_Comm comm)
+{
+    return MPI_Allgather(sendbuf, sendcount, sendtype, recvbuf, recvcount, recvtype, comm);
+}
+
+int _MPI_Scatter(const void *sendbuf, int sendcount, MPI_Datatype sendtype,
+                 void *recvbuf, int recvcount, MPI_Datatype recvtype, int root, MPI_Comm comm)
+{
+    return MPI_Scatter(sendbuf, sendcount, sendtype, recvbuf, recvcount, recvtype, root, comm);
 }
 
 int _MPI_Abort(MPI_Comm comm, int errorcode) {
@@ -6,6 +6,26 @@
 void _MPI_WRAPPER_INCLUDE() {}
 
 int _MPI_CHECKPOINT_CHILD;
+int _MPI_WRAPPER_REQUEST_SIZE = sizeof(MPI_Request); // for locating the requests of _MPI_Waitall
+MPI_Request _MPI_WRAPPER_REQUEST_NULL = MPI_REQUEST_NULL; // for telling inactive request handles apart
+
+// Predefined datatypes and their sizes, filled in by _MPI_Init for the debugger to decode message payloads.
+// The order matches mpi.PAYLOAD_DATATYPES
+#define _MPI_WRAPPER_DATATYPE_COUNT 9
+MPI_Datatype _MPI_WRAPPER_DATATYPES[_MPI_WRAPPER_DATATYPE_COUNT];
+int _MPI_WRAPPER_DATATYPE_SIZES[_MPI_WRAPPER_DATATYPE_COUNT];
+
+void _MPI_WRAPPER_RECORD_DATATYPES()
+{
+    MPI_Datatype datatypes[_MPI_WRAPPER_DATATYPE_COUNT] = {
+        MPI_CHAR, MPI_BYTE, MPI_SHORT, MPI_INT, MPI_LONG, MPI_LONG_LONG, MPI_UNSIGNED, MPI_FLOAT, MPI_DOUBLE};
+
+    for (int i = 0; i < _MPI_WRAPPER_DATATYPE_COUNT; i++)
+    {
+        _MPI_WRAPPER_DATATYPES[i] = datatypes[i];
+        MPI_Type_size(datatypes[i], &_MPI_WRAPPER_DATATYPE_SIZES[i]);
+    }
+}
 
 void _MPI_WRAPPER_RECORD()
 {
@@ -20,7 +40,9 @@ void _MPI_WRAPPER_RECORD()
 
 int _MPI_Init(int *argc, char ***argv)
 {
-    return MPI_Init(argc, argv);
+    int code = MPI_Init(argc, argv);
+    _MPI_WRAPPER_RECORD_DATATYPES();
+    return code;
 }
 
 int _MPI_Comm_size(MPI_Comm comm, int *size)
@@ -53,3 +75,99 @@ int _MPI_Recv(void *buf, int count, MPI_Datatype datatype, int source,
     int code = MPI_Recv(buf, count, datatype, source, tag, comm, status);
     return code;
 }
+
+int _MPI_Isend(const void *buf, int count, MPI_Datatype datatype, int dest,
+               int tag, MPI_Comm comm, MPI_Request *request)
+{
+    _MPI_WRAPPER_RECORD();
+    int code = MPI_Isend(buf, count, datatype, dest, tag, comm, request);
+    return code;
+}
+
+int _MPI_Irecv(void *buf, int count, MPI_Datatype datatype, int source,
+               int tag, MPI_Comm comm, MPI_Request *request)
+{
+    _MPI_WRAPPER_RECORD();
+    int code = MPI_Irecv(buf, count, datatype, source, tag, comm, request);
+    return code;
+}
+
+int _MPI_Wait(MPI_Request *request, MPI_Status *status)
+{
+    return MPI_Wait(request, status);
+}
+
+int _MPI_Waitall(int count, MPI_Request requests[], MPI_Status statuses[])
+{
+    return MPI_Waitall(count, requests, statuses);
+}
+
+// Marks the completion of a request found by _MPI_Test.
+// The tests themselves are not recorded, as they are called repeatedly while polling
+void _MPI_Test_completed(MPI_Request *request)
+{
+}
+
+int _MPI_Test(MPI_Request *request, int *flag, MPI_Status *status)
+{
+    int code = MPI_Test(request, flag, status);
+    if (*flag)
+    {
+        _MPI_Test_completed(request);
+    }
+    return code;
+}
+
+int _MPI_Barrier(MPI_Comm comm)
+{
+    _MPI_WRAPPER_RECORD();
+    int code = MPI_Barrier(comm);
+    return code;
+}
+
+int _MPI_Bcast(void *buffer, int count, MPI_Datatype datatype, int root, MPI_Comm comm)
+{
+    _MPI_WRAPPER_RECORD();
+    int code = MPI_Bcast(buffer, count, datatype, root, comm);
+    return code;
+}
+
+int _MPI_Reduce(const void *sendbuf, void *recvbuf, int count, MPI_Datatype datatype,
+                MPI_Op op, int root, MPI_Comm comm)
+{
+    _MPI_WRAPPER_RECORD();
+    int code = MPI_Reduce(sendbuf, recvbuf, count, datatype, op, root, comm);
+    return code;
+}
+
+int _MPI_Allreduce(const void *sendbuf, void *recvbuf, int count, MPI_Datatype datatype,
+                   MPI_Op op, MPI_Comm comm)
+{
+    _MPI_WRAPPER_RECORD();
+    int code = MPI_Allreduce(sendbuf, recvbuf, count, datatype, op, comm);
+    return code;
+}
+
+int _MPI_Gather(const void *sendbuf, int sendcount, MPI_Datatype sendtype,
+                void *recvbuf, int recvcount, MPI_Datatype recvtype, int root, MPI_Comm comm)
+{
+    _MPI_WRAPPER_RECORD();
+    int code = MPI_Gather(sendbuf, sendcount, sendtype, recvbuf, recvcount, recvtype, root, comm);
+    return code;
+}
+
+int _MPI_Allgather(const void *sendbuf, int sendcount, MPI_Datatype sendtype,
+                   void *recvbuf, int recvcount, MPI_Datatype recvtype, MPI_Comm comm)
+{
+    _MPI_WRAPPER_RECORD();
+    int code = MPI_Allgather(sendbuf, sendcount, sendtype, recvbuf, recvcount, recvtype, comm);
+    return code;
+}
+
+int _MPI_Scatter(const void *sendbuf, int sendcount, MPI_Datatype sendtype,
+                 void *recvbuf, int recvcount, MPI_Datatype recvtype, int root, MPI_Comm comm)
+{
+    _MPI_WRAPPER_RECORD();
+    int code = MPI_Scatter(sendbuf, sendcount, sendtype, recvbuf, recvcount, recvtype, root, comm);
+    return code;
+}
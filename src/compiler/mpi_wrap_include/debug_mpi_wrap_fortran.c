@@ -0,0 +1,106 @@
+#include "debug_mpi_wrap.h"
+
+/*
+    Fortran bindings of the wrapped MPI functions, linked into Fortran targets.
+    gfortran calls the lowercase names with a trailing underscore (mpi_send_), passing
+    every argument by reference and returning the error code in the last one.
+    Defining them in the target takes precedence over the bindings of the MPI library,
+    so the calls reach the wrappers like those of C targets.
+*/
+
+void mpi_init_(MPI_Fint *ierr)
+{
+    *ierr = _MPI_Init(NULL, NULL);
+}
+
+void mpi_comm_size_(MPI_Fint *comm, MPI_Fint *size, MPI_Fint *ierr)
+{
+    int c_size;
+    *ierr = _MPI_Comm_size(MPI_Comm_f2c(*comm), &c_size);
+    *size = c_size;
+}
+
+void mpi_comm_rank_(MPI_Fint *comm, MPI_Fint *rank, MPI_Fint *ierr)
+{
+    int c_rank;
+    *ierr = _MPI_Comm_rank(MPI_Comm_f2c(*comm), &c_rank);
+    *rank = c_rank;
+}
+
+void mpi_finalize_(MPI_Fint *ierr)
+{
+    *ierr = _MPI_Finalize();
+}
+
+void mpi_send_(void *buf, MPI_Fint *count, MPI_Fint *datatype, MPI_Fint *dest,
+               MPI_Fint *tag, MPI_Fint *comm, MPI_Fint *ierr)
+{
+    *ierr = _MPI_Send(buf, *count, MPI_Type_f2c(*datatype), *dest, *tag, MPI_Comm_f2c(*comm));
+}
+
+void mpi_recv_(void *buf, MPI_Fint *count, MPI_Fint *datatype, MPI_Fint *source,
+               MPI_Fint *tag, MPI_Fint *comm, MPI_Fint *status, MPI_Fint *ierr)
+{
+    MPI_Status c_status;
+
+    *ierr = _MPI_Recv(buf, *count, MPI_Type_f2c(*datatype), *source, *tag, MPI_Comm_f2c(*comm), &c_status);
+
+    if (status != MPI_F_STATUS_IGNORE)
+    {
+        MPI_Status_c2f(&c_status, status);
+    }
+}
+
+void mpi_barrier_(MPI_Fint *comm, MPI_Fint *ierr)
+{
+    *ierr = _MPI_Barrier(MPI_Comm_f2c(*comm));
+}
+
+void mpi_bcast_(void *buffer, MPI_Fint *count, MPI_Fint *datatype, MPI_Fint *root,
+                MPI_Fint *comm, MPI_Fint *ierr)
+{
+    *ierr = _MPI_Bcast(buffer, *count, MPI_Type_f2c(*datatype), *root, MPI_Comm_f2c(*comm));
+}
+
+void mpi_reduce_(void *sendbuf, void *recvbuf, MPI_Fint *count, MPI_Fint *datatype,
+                 MPI_Fint *op, MPI_Fint *root, MPI_Fint *comm, MPI_Fint *ierr)
+{
+    *ierr = _MPI_Reduce(sendbuf, recvbuf, *count, MPI_Type_f2c(*datatype), MPI_Op_f2c(*op), *root, MPI_Comm_f2c(*comm));
+}
+
+void mpi_allreduce_(void *sendbuf, void *recvbuf, MPI_Fint *count, MPI_Fint *datatype,
+                    MPI_Fint *op, MPI_Fint *comm, MPI_Fint *ierr)
+{
+    *ierr = _MPI_Allreduce(sendbuf, recvbuf, *count, MPI_Type_f2c(*datatype), MPI_Op_f2c(*op), MPI_Comm_f2c(*comm));
+}
+
+void mpi_gather_(void *sendbuf, MPI_Fint *sendcount, MPI_Fint *sendtype, void *recvbuf,
+                 MPI_Fint *recvcount, MPI_Fint *recvtype, MPI_Fint *root, MPI_Fint *comm, MPI_Fint *ierr)
+{
+    *ierr = _MPI_Gather(sendbuf, *sendcount, MPI_Type_f2c(*sendtype), recvbuf, *recvcount, MPI_Type_f2c(*recvtype),
+                        *root, MPI_Comm_f2c(*comm));
+}
+
+void mpi_allgather_(void *sendbuf, MPI_Fint *sendcount, MPI_Fint *sendtype, void *recvbuf,
+                    MPI_Fint *recvcount, MPI_Fint *recvtype, MPI_Fint *comm, MPI_Fint *ierr)
+{
+    *ierr = _MPI_Allgather(sendbuf, *sendcount, MPI_Type_f2c(*sendtype), recvbuf, *recvcount, MPI_Type_f2c(*recvtype),
+                           MPI_Comm_f2c(*comm));
+}
+
+void mpi_scatter_(void *sendbuf, MPI_Fint *sendcount, MPI_Fint *sendtype, void *recvbuf,
+                  MPI_Fint *recvcount, MPI_Fint *recvtype, MPI_Fint *root, MPI_Fint *comm, MPI_Fint *ierr)
+{
+    *ierr = _MPI_Scatter(sendbuf, *sendcount, MPI_Type_f2c(*sendtype), recvbuf, *recvcount, MPI_Type_f2c(*recvtype),
+                         *root, MPI_Comm_f2c(*comm));
+}
+
+void mpi_abort_(MPI_Fint *comm, MPI_Fint *errorcode, MPI_Fint *ierr)
+{
+    *ierr = _MPI_Abort(MPI_Comm_f2c(*comm), *errorcode);
+}
+
+double mpi_wtime_()
+{
+    return _MPI_Wtime();
+}
@@ -53,6 +53,16 @@ var colorMap map[LoggingLevel]color = map[LoggingLevel]color{
 	debug:   blue,
 }
 
+// prints the rows of the log, front-ends waiting for input replace it to keep their prompt below the rows
+var printRow = func(row string) {
+	fmt.Print(row)
+}
+
+// Sets the function printing the rows of the log
+func SetRowPrinter(printer func(row string)) {
+	printRow = printer
+}
+
 func SetMaxLogLevel(level LoggingLevel) {
 	maxLogLevel = level
 }
@@ -94,9 +104,9 @@ func logRow(level LoggingLevel, message string, id *int) {
 	}
 
 	if id == nil {
-		fmt.Printf("%s%s %s %s\n", colorMap[level], timeString(), message, reset)
+		printRow(fmt.Sprintf("%s%s %s %s\n", colorMap[level], timeString(), message, reset))
 	} else {
-		fmt.Printf("%s%s %s -%s %s%s\n", colorMap[level], timeString(), prettyPrintId(*id), colorMap[level], message, reset)
+		printRow(fmt.Sprintf("%s%s %s -%s %s%s\n", colorMap[level], timeString(), prettyPrintId(*id), colorMap[level], message, reset))
 	}
 
 }
@@ -1,25 +1,28 @@
 package logger
 
+import (
+	"context"
+
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
 // client
 
-var remoteLoggerClient RemoteLoggerClient
+var remoteLoggerClient pb.LoggerClient
 var nodeId int
 
-type RemoteLoggerClient interface {
-	Call(methodName string, args any, reply any) error
-}
-
-func SetRemoteClient(client RemoteLoggerClient, _nodeId int) {
+func SetRemoteClient(client pb.LoggerClient, _nodeId int) {
 	remoteLoggerClient = client
 	nodeId = _nodeId
 }
 
 func logRemotely(level LoggingLevel, message string) {
-	err := remoteLoggerClient.Call("LoggerServer.Log", &RemoteLogArgs{
-		nodeId,
-		level,
-		message,
-	}, new(int))
+	_, err := remoteLoggerClient.Log(context.Background(), &pb.LogEntry{
+		NodeId:  int32(nodeId),
+		Level:   int32(level),
+		Message: message,
+	})
 
 	if err != nil {
 		panic(err)
@@ -28,16 +31,13 @@ func logRemotely(level LoggingLevel, message string) {
 
 // server
 
-type LoggerServer int
-
-type RemoteLogArgs struct {
-	Pid     int
-	Level   LoggingLevel
-	Message string
+type LoggerServer struct {
+	pb.UnimplementedLoggerServer
 }
 
-func (r *LoggerServer) Log(args RemoteLogArgs, reply *int) error {
-	logRow(args.Level, args.Message, &args.Pid)
+func (r *LoggerServer) Log(ctx context.Context, entry *pb.LogEntry) (*emptypb.Empty, error) {
+	pid := int(entry.NodeId)
+	logRow(LoggingLevel(entry.Level), entry.Message, &pid)
 
-	return nil
+	return &emptypb.Empty{}, nil
 }
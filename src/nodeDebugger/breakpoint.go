@@ -18,6 +18,16 @@ type bpointData struct {
 	function                *dwarf.Function // the pointer to the function the breakpoint was inserted at
 	isMPIBpoint             bool
 	isImmediateAfterRestore bool
+	condition               *condition      // the target is stopped only if the condition holds (nil - always)
+	hitCount                int             // number of times the breakpoint has been hit
+	marker                  string          // line reported to the orchestrator whenever the target passes it, for causal breakpoints (empty if none)
+	markerOnly              bool            // whether the breakpoint only marks the line, without stopping the target
+	causalReceive           bool            // temporary breakpoint at the return of a blocking receive, checked against the causal breakpoints
+	iteration               *iterationBreak // the iteration of a loop the breakpoint stops at (nil for other breakpoints)
+	commands                []string        // commands executed whenever the breakpoint stops the target (breakpointCommands.go)
+	trace                   *tracepoint     // line logged whenever the target passes the breakpoint (nil if none)
+	traceOnly               bool            // whether the breakpoint only logs its trace, without stopping the target
+	temporary               bool            // breakpoint of until, removed once the target stops
 }
 
 func (b *bpointData) String() string {
@@ -31,6 +41,15 @@ func (b breakpointData) New() breakpointData {
 	return make(map[uint64]*bpointData)
 }
 
+func (b breakpointData) copy() breakpointData {
+	breakpoints := make(breakpointData, len(b))
+	for address, bpoint := range b {
+		copied := *bpoint
+		breakpoints[address] = &copied
+	}
+	return breakpoints
+}
+
 func findBreakpointByAddress(ctx *processContext, address uint64) *bpointData {
 	for bPointAddress, bPoint := range ctx.bpointData {
 		if bPointAddress == address {
@@ -58,6 +77,88 @@ func insertBreakpoint(ctx *processContext, breakpointAddress uint64) (originalIn
 	return originalInstruction
 }
 
+// Inserts a caught breakpoint again, after the target has stepped past it. A trap found at the address keeps the
+// instruction the breakpoint saved, without one the breakpoint is not armed again
+func rearmBreakpoint(ctx *processContext, bpoint *bpointData) error {
+	if getOriginalInstruction(ctx, bpoint.address)[0] != 0xCC {
+		bpoint.originalInstruction = insertBreakpoint(ctx, bpoint.address)
+	} else if len(bpoint.originalInstruction) == 0 || bpoint.originalInstruction[0] == 0xCC {
+		return fmt.Errorf("no instruction saved for the breakpoint at %#x", bpoint.address)
+	}
+
+	ctx.bpointData[bpoint.address] = bpoint
+
+	return nil
+}
+
+// Keeps a breakpoint lifted out of the target at its caught instruction, to insert it again once the target has executed
+// the instruction. A breakpoint lifted before is inserted right away, the target has moved on from it
+func liftBreakpoint(ctx *processContext, bpoint *bpointData) {
+	if previous := ctx.liftedBreakpoint; previous != nil && previous.address != bpoint.address && ctx.bpointData[previous.address] == nil {
+		if err := rearmBreakpoint(ctx, previous); err != nil {
+			logger.Warn("breakpoint not inserted again: %v", err)
+		}
+	}
+
+	ctx.liftedBreakpoint = bpoint
+}
+
+// Inserts the lifted breakpoint again before the target resumes. Stopped at the breakpoint, the target is single-stepped
+// over its instruction first. Returns whether the target was stepped
+func stepOverLiftedBreakpoint(ctx *processContext) (stepped bool, exited bool, err error) {
+	lifted := ctx.liftedBreakpoint
+	ctx.liftedBreakpoint = nil
+
+	// set again meanwhile, e.g. at the line or by a restored checkpoint
+	if lifted == nil || ctx.bpointData[lifted.address] != nil {
+		return false, false, nil
+	}
+
+	if getRegs(ctx, false).Rip == lifted.address {
+		exited, err = continueExecution(ctx, true)
+		if exited || err != nil {
+			return true, exited, err
+		}
+
+		// stopped at the instruction by a fatal signal or the NaN trap, it is executed again
+		if getRegs(ctx, false).Rip == lifted.address {
+			ctx.liftedBreakpoint = lifted
+			return true, false, nil
+		}
+
+		stepped = true
+	}
+
+	if err := rearmBreakpoint(ctx, lifted); err != nil {
+		logger.Warn("breakpoint not inserted again: %v", err)
+	}
+
+	return stepped, false, nil
+}
+
+// Whether the target should stay stopped at the breakpoint. Conditions that cannot be evaluated stop the target
+func breakpointConditionHolds(ctx *processContext, bpoint *bpointData) bool {
+	if bpoint.markerOnly || bpoint.traceOnly {
+		return false
+	}
+
+	if bpoint.condition == nil {
+		return true
+	}
+
+	updateStack(ctx)
+
+	holds, err := bpoint.condition.evaluate(ctx)
+	if err != nil {
+		logger.Warn("cannot evaluate breakpoint condition %v: %v", bpoint.condition, err)
+		return true
+	}
+
+	logger.Debug("breakpoint condition %v: %v", bpoint.condition, holds)
+
+	return holds
+}
+
 func getOriginalInstruction(ctx *processContext, address uint64) (originalInstruction []byte) {
 	var interruptCode = []byte{0xCC} // code for breakpoint trap
 
@@ -84,6 +185,15 @@ func restoreCaughtBreakpoint(ctx *processContext) (caugtBpoint *bpointData, regi
 
 	if bpoint.isMPIBpoint {
 		logger.Debug("Caught auto-inserted MPI breakpoint, func: %v", bpoint.function.Name())
+	} else if bpoint.markerOnly || bpoint.causalReceive {
+		logger.Debug("Caught at a causal breakpoint check, marker: %v", bpoint.marker)
+	} else if bpoint.traceOnly {
+		logger.Debug("Caught at a tracepoint: %v", bpoint.trace)
+	} else if bpoint.temporary {
+		line, file, _, _ := ctx.dwarfData.PCToLine(regs.Rip)
+		logger.Info("Ran until line: %d, file: %v", line, filepath.Base(file))
+	} else if bpoint.iteration != nil {
+		logger.Debug("Caught at %v, hit %d times before", bpoint.iteration, bpoint.hitCount)
 	} else {
 		line, file, _, _ := ctx.dwarfData.PCToLine(regs.Rip)
 		logger.Info("Caught at a breakpoint: line: %d, file: %v", line, filepath.Base(file))
@@ -100,5 +210,93 @@ func restoreCaughtBreakpoint(ctx *processContext) (caugtBpoint *bpointData, regi
 	// remove record of breakpoint
 	delete(ctx.bpointData, bpoint.address)
 
+	// user breakpoints are inserted again once the target has stepped past them, temporary breakpoints are done
+	if !bpoint.isMPIBpoint && !bpoint.temporary && !bpoint.causalReceive {
+		liftBreakpoint(ctx, bpoint)
+	}
+
 	return bpoint, regs
 }
+
+// Reconciles the breakpoints with the memory of a restored checkpoint. The user breakpoints set before the restore
+// stay set: those set after the checkpoint was recorded are inserted into the restored memory and those removed since
+// are lifted out of it, while the MPI breakpoints are those of the checkpoint. Memory outside the restored regions
+// keeps its bytes, so every address either table names is checked for a trap left behind or missing.
+// Returns the breakpoints of the restored target
+func reconcileBreakpoints(ctx *processContext, previous breakpointData, restored breakpointData) breakpointData {
+	breakpoints := restored.copy()
+	inserted, lifted := 0, 0
+
+	for address, bpoint := range breakpoints {
+		if !bpoint.isMPIBpoint && previous[address] == nil {
+			delete(breakpoints, address)
+			lifted++
+		}
+	}
+
+	for address, bpoint := range previous {
+		if bpoint.isMPIBpoint || bpoint.causalReceive || breakpoints[address] != nil {
+			continue
+		}
+
+		// not hit before the checkpoint, it was set after it
+		copied := *bpoint
+		copied.hitCount = 0
+		copied.isImmediateAfterRestore = false
+
+		breakpoints[address] = &copied
+		inserted++
+	}
+
+	addresses := make(map[uint64]bool)
+	for _, table := range []breakpointData{previous, restored, breakpoints} {
+		for address := range table {
+			addresses[address] = true
+		}
+	}
+
+	for address := range addresses {
+		trapped := getOriginalInstruction(ctx, address)[0] == 0xCC
+		bpoint := breakpoints[address]
+
+		switch {
+		case bpoint != nil && !trapped:
+			bpoint.originalInstruction = insertBreakpoint(ctx, address)
+		case bpoint != nil && bpoint.originalInstruction[0] == 0xCC:
+			// saved while a trap of another record was inserted
+			instruction, err := savedInstruction(address, previous, restored)
+			if err != nil {
+				logger.Warn("breakpoint dropped: %v", err)
+				delete(breakpoints, address)
+				continue
+			}
+			bpoint.originalInstruction = instruction
+		case bpoint == nil && trapped:
+			instruction, err := savedInstruction(address, previous, restored)
+			if err != nil {
+				logger.Warn("trap left in place: %v", err)
+				continue
+			}
+			_, err = syscall.PtracePokeData(ctx.pid, uintptr(address), instruction)
+			utils.Must(err)
+		}
+	}
+
+	if inserted > 0 || lifted > 0 {
+		logger.Verbose("breakpoints reconciled with the restored memory: %d inserted, %d lifted", inserted, lifted)
+	}
+
+	return breakpoints
+}
+
+// Returns the instruction a breakpoint at the address replaced, as saved by a record of it. A breakpoint removed
+// while lifted out of the target leaves no record with the instruction
+func savedInstruction(address uint64, tables ...breakpointData) ([]byte, error) {
+	for _, table := range tables {
+		if bpoint := table[address]; bpoint != nil && bpoint.originalInstruction[0] != 0xCC {
+			return bpoint.originalInstruction, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no instruction saved for the breakpoint at %#x", address)
+}
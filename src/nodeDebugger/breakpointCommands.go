@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Commands attached to a breakpoint (commands <location> <command>; <command>; ...), executed each time the
+// breakpoint stops the target. A list ending with continue turns the breakpoint into a probe: the target runs on
+// after the commands, without the stop being reported
+
+// a breakpoint location given by its line, [<file>:]<line>, rather than by a function
+var lineLocationRegexp = regexp.MustCompile(`^(\S+:)?\d+$`)
+
+// Attaches a list of commands to the breakpoints at a location, setting them if not set. An empty list detaches the commands
+func setBreakpointCommands(ctx *processContext, argument string) error {
+	location, list, _ := strings.Cut(strings.TrimSpace(argument), " ")
+
+	commands := make([]string, 0)
+	for _, line := range strings.Split(list, ";") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			commands = append(commands, line)
+		}
+	}
+
+	for index, line := range commands {
+		cmd := command.ParseNodeCommand(line)
+
+		switch {
+		case cmd == nil:
+			return fmt.Errorf("%q is not a command", line)
+		case cmd.Code == command.Cont && index == len(commands)-1:
+		case cmd.IsProgressCommand() || cmd.Code == command.BreakpointCommands:
+			return fmt.Errorf("%q cannot run at a breakpoint, only continue as the last command", line)
+		}
+	}
+
+	bpoints, err := locationBreakpoints(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	for _, bpoint := range bpoints {
+		bpoint.commands = commands
+	}
+
+	if len(commands) == 0 {
+		logger.Info("commands of the breakpoint at %v removed", location)
+	} else {
+		logger.Info("%d commands attached to the breakpoint at %v", len(commands), location)
+	}
+
+	return nil
+}
+
+// Returns the breakpoints at a location, [<file>:]<line> or a function, setting them if not set
+func locationBreakpoints(ctx *processContext, location string) ([]*bpointData, error) {
+	addresses := make([]uint64, 0)
+
+	if lineLocationRegexp.MatchString(location) {
+		file, line, err := parseLineLocation(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+
+		address, err := ctx.dwarfData.LineToPC(file, line)
+		if err != nil {
+			return nil, err
+		}
+
+		if bpoint := ctx.bpointData[address]; bpoint == nil || bpoint.markerOnly {
+			if err := setBreakPoint(ctx, file, line, nil); err != nil {
+				return nil, err
+			}
+		}
+
+		addresses = append(addresses, address)
+	} else {
+		if err := setFunctionBreakpoint(ctx, location, nil); err != nil {
+			return nil, err
+		}
+
+		for _, function := range ctx.dwarfData.LookupFunctions(location) {
+			addresses = append(addresses, ctx.dwarfData.FunctionBreakpointAddress(function))
+		}
+	}
+
+	bpoints := make([]*bpointData, 0, len(addresses))
+	for _, address := range addresses {
+		if bpoint := ctx.bpointData[address]; bpoint != nil {
+			bpoints = append(bpoints, bpoint)
+		}
+	}
+
+	return bpoints, nil
+}
+
+// Executes the commands of a breakpoint the target stopped at. Returns whether the list ends with continue
+func runBreakpointCommands(ctx *processContext, bpoint *bpointData) (continues bool) {
+	updateStack(ctx)
+
+	logger.Info("Breakpoint at %v, hit %d times:", sourceLocation(ctx, bpoint.address), bpoint.hitCount)
+
+	for _, line := range bpoint.commands {
+		cmd := command.ParseNodeCommand(line)
+		if cmd.Code == command.Cont {
+			return true
+		}
+
+		handleCommand(ctx, cmd)
+
+		if cmd.Result != nil && len(cmd.Result.Error) > 0 {
+			logger.Warn("%v: %v", line, cmd.Result.Error)
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// A buffer hashed at every checkpoint (hash auto), to find where in the history its contents changed
+type hashedBuffer struct {
+	expression string // variable or address of the buffer
+	length     int64  // bytes hashed, 0 - the size of the variable
+}
+
+func (buffer hashedBuffer) String() string {
+	if buffer.length == 0 {
+		return buffer.expression
+	}
+	return fmt.Sprintf("%s %d", buffer.expression, buffer.length)
+}
+
+// Prints the FNV-1a hash of a buffer (hash <var|addr> [len]). Variables of pointer type hash the memory they point to,
+// other variables their own. The length defaults to the size of the variable
+func printBufferHash(ctx *processContext, argument string) (string, error) {
+	buffer, err := parseHashedBuffer(argument)
+	if err != nil {
+		return "", err
+	}
+
+	updateStack(ctx)
+
+	address, length, hash, err := hashBuffer(ctx, buffer)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("%s (%d bytes at %#x): %016x", buffer.expression, length, address, hash)
+	return fmt.Sprintf("%016x (%d bytes)", hash, length), nil
+}
+
+// Adds a buffer hashed at every checkpoint (hash auto <var|addr> [len]), clears them (clear) or lists them (empty argument)
+func autoHashBuffer(ctx *processContext, argument string) error {
+	argument = strings.TrimSpace(argument)
+
+	switch argument {
+	case "":
+		if len(ctx.hashedBuffers) == 0 {
+			logger.Info("no buffers hashed at checkpoints")
+		}
+		for _, buffer := range ctx.hashedBuffers {
+			logger.Info("hashing %v at every checkpoint", buffer)
+		}
+		return nil
+	case "clear":
+		ctx.hashedBuffers = nil
+		logger.Info("buffers no longer hashed at checkpoints")
+		return nil
+	}
+
+	buffer, err := parseHashedBuffer(argument)
+	if err != nil {
+		return err
+	}
+
+	// the buffer must be found in the current scope, it may not be at later checkpoints
+	updateStack(ctx)
+
+	_, _, _, err = hashBuffer(ctx, buffer)
+	if err != nil {
+		return err
+	}
+
+	ctx.hashedBuffers = append(ctx.hashedBuffers, buffer)
+	logger.Info("hashing %v at every checkpoint", buffer)
+
+	return nil
+}
+
+func parseHashedBuffer(argument string) (hashedBuffer, error) {
+	fields := strings.Fields(argument)
+	if len(fields) < 1 || len(fields) > 2 {
+		return hashedBuffer{}, fmt.Errorf("expected a variable or address, optionally followed by a length in bytes")
+	}
+
+	buffer := hashedBuffer{expression: fields[0]}
+
+	if len(fields) == 2 {
+		length, err := strconv.ParseInt(fields[1], 0, 64)
+		if err != nil || length < 1 {
+			return hashedBuffer{}, fmt.Errorf("invalid length %q", fields[1])
+		}
+		buffer.length = length
+	}
+
+	return buffer, nil
+}
+
+// Locates a buffer in the current scope and hashes its contents
+func hashBuffer(ctx *processContext, buffer hashedBuffer) (address uint64, length int64, hash uint64, err error) {
+	address, length, err = locateBuffer(ctx, buffer)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	bufferHash := fnv.New64a()
+
+	err = hashMemory(ctx, address, length, bufferHash)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("cannot read %d bytes at %#x: %v", length, address, err)
+	}
+
+	return address, length, bufferHash.Sum64(), nil
+}
+
+func locateBuffer(ctx *processContext, buffer hashedBuffer) (address uint64, length int64, err error) {
+	// an address
+	if value, err := strconv.ParseUint(buffer.expression, 0, 64); err == nil {
+		if buffer.length == 0 {
+			return 0, 0, fmt.Errorf("the length of the buffer at %s is not known, give it in bytes", buffer.expression)
+		}
+		return value, buffer.length, nil
+	}
+
+	variable, address, err := locateVariable(ctx, buffer.expression, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if variable.IsPointer() {
+		if buffer.length == 0 {
+			return 0, 0, fmt.Errorf("the length of the buffer %s points to is not known, give it in bytes", buffer.expression)
+		}
+
+		pointer := peekDataFromMemory(ctx, address, 8)
+		return binary.LittleEndian.Uint64(pointer), buffer.length, nil
+	}
+
+	length = buffer.length
+	if length == 0 && variable.IsArray() {
+		length, err = variable.ArrayByteSize()
+	} else if length == 0 {
+		length = variable.ByteSize()
+	}
+
+	return address, length, err
+}
+
+// Hashes the buffers selected with hash auto, by their expression. Buffers out of scope at the checkpoint are left out
+func hashBuffers(ctx *processContext) map[string]uint64 {
+	if len(ctx.hashedBuffers) == 0 {
+		return nil
+	}
+
+	updateStack(ctx)
+
+	hashes := make(map[string]uint64)
+
+	for _, buffer := range ctx.hashedBuffers {
+		_, _, hash, err := hashBuffer(ctx, buffer)
+		if err != nil {
+			logger.Debug("cannot hash %v at the checkpoint: %v", buffer, err)
+			continue
+		}
+
+		hashes[buffer.expression] = hash
+	}
+
+	return hashes
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+// ways the MPI calls of the target are intercepted
+const (
+	MPI_INTERCEPTION_COMPILED  = "compiled"  // the target was compiled with the wrapper
+	MPI_INTERCEPTION_PRELOADED = "preloaded" // the wrapper was preloaded into the target
+	MPI_INTERCEPTION_NONE      = "none"
+)
+
+// Returns the features supported for the target
+func getCapabilities(ctx *processContext) rpc.NodeCapabilities {
+	capabilities := rpc.NodeCapabilities{
+		ReverseExecution:        true,
+		ReverseStepInstructions: ctx.instructionCounter != nil,
+		Watchpoints:             false,
+		MPIInterception:         MPI_INTERCEPTION_NONE,
+		MultiThread:             false,
+		FunctionBreakpoints:     true,
+		ConditionalBreakpoints:  true,
+		Goroutines:              ctx.dwarfData.LookupVariable("runtime.allgs") != nil,
+	}
+
+	if ctx.nodeData != nil {
+		capabilities.NodeId = ctx.nodeData.id
+	}
+
+	if len(ctx.dwarfData.Mpi.Functions) > 0 {
+		capabilities.MPIInterception = MPI_INTERCEPTION_PRELOADED
+
+		if hasMPIWrapper(ctx.targetFile) {
+			capabilities.MPIInterception = MPI_INTERCEPTION_COMPILED
+		}
+	}
+
+	if module := mainModule(ctx); module != nil {
+		capabilities.Language = module.Language()
+	}
+
+	return capabilities
+}
+
+// Returns the module declaring the main function of the target, nil if not found
+func mainModule(ctx *processContext) *dwarf.Module {
+	// the main function of go targets is main.main
+	for _, mainFunction := range []string{MAIN_FN, "main.main"} {
+		if module, function := ctx.dwarfData.LookupFunc(mainFunction); function != nil {
+			return module
+		}
+	}
+
+	return nil
+}
+
+func printCapabilities(ctx *processContext) {
+	capabilities, err := json.MarshalIndent(getCapabilities(ctx), "", "  ")
+	if err != nil {
+		logger.Error("Failed to encode capabilities: %v", err)
+		return
+	}
+
+	fmt.Println(string(capabilities))
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+const (
+	SYSCALL_STOP_SIGNAL  = syscall.SIGTRAP | 0x80      // stop signal of syscall stops, with PTRACE_O_TRACESYSGOOD
+	SYSCALL_ENTRY_RAX    = ^uint64(syscall.ENOSYS) + 1 // rax at syscall entry stops (-ENOSYS)
+	MAX_SCANNED_WRITE    = 64 * 1024                   // bytes of a single write scanned for the patterns
+	MAX_STEPS_TO_PROGRAM = 100000                      // instructions single-stepped back to the code of the program
+)
+
+// names of the scanned file descriptors
+var outputStreams = map[uint64]string{1: "stdout", 2: "stderr"}
+
+// Output patterns the target stops at. While any are set, the target is resumed to its syscalls,
+// the writes to stdout and stderr are scanned at their entry and the target stops once a matching write returns
+type outputCatches struct {
+	patterns     []*regexp.Regexp
+	partialLines map[uint64]string // output after the last newline, by file descriptor
+	match        string            // the matching line of the write the target is in (empty if none)
+	stopped      bool              // whether the target is stopped after a matching write
+}
+
+// Adds a pattern the target stops at when a line of its stdout or stderr matches it, lists the patterns
+// (empty argument) or removes them (clear)
+func catchOutput(ctx *processContext, argument string) error {
+	argument = strings.TrimSpace(argument)
+
+	switch argument {
+	case "":
+		if ctx.outputCatches == nil {
+			logger.Info("no output patterns caught")
+			return nil
+		}
+
+		for index, pattern := range ctx.outputCatches.patterns {
+			logger.Info("%d: %v", index+1, pattern)
+		}
+		return nil
+	case "clear":
+		ctx.outputCatches = nil
+		logger.Info("output patterns cleared")
+
+		return syscall.PtraceSetOptions(ctx.pid, ptraceOptions(ctx))
+	}
+
+	pattern, err := regexp.Compile(argument)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	if ctx.outputCatches == nil {
+		ctx.outputCatches = &outputCatches{partialLines: make(map[uint64]string)}
+	}
+	ctx.outputCatches.patterns = append(ctx.outputCatches.patterns, pattern)
+
+	logger.Info("stopping at output matching %v", pattern)
+
+	return syscall.PtraceSetOptions(ctx.pid, ptraceOptions(ctx))
+}
+
+// Options the target is traced with between commands
+func ptraceOptions(ctx *processContext) int {
+	if ctx.outputCatches != nil {
+		return syscall.PTRACE_O_TRACESYSGOOD
+	}
+
+	return 0
+}
+
+// Resumes the target from the syscall stops it reports while output patterns are set, until it stops otherwise.
+// Returns whether it stopped after a write matching a pattern
+func passSyscallStops(ctx *processContext, waitStatus *syscall.WaitStatus) (caught bool, err error) {
+	for waitStatus.Stopped() && waitStatus.StopSignal() == SYSCALL_STOP_SIGNAL {
+		regs := getRegs(ctx, false)
+
+		if regs.Rax == SYSCALL_ENTRY_RAX {
+			scanWrite(ctx, regs)
+		} else if ctx.outputCatches != nil && len(ctx.outputCatches.match) > 0 {
+			return true, stopAfterCaughtOutput(ctx, regs.Rdi)
+		}
+
+		err = syscall.PtraceSyscall(ctx.pid, 0)
+		if err != nil {
+			return false, err
+		}
+
+		err = waitForStop(ctx, waitStatus)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// Matches the lines written by a write to stdout or stderr against the patterns, at its syscall entry stop
+func scanWrite(ctx *processContext, regs *syscall.PtraceRegs) {
+	catches := ctx.outputCatches
+
+	if catches == nil || regs.Orig_rax != syscall.SYS_WRITE || len(outputStreams[regs.Rdi]) == 0 {
+		return
+	}
+
+	length := regs.Rdx
+	if length > MAX_SCANNED_WRITE {
+		length = MAX_SCANNED_WRITE
+	}
+
+	data := make([]byte, length)
+
+	read, err := syscall.PtracePeekData(ctx.pid, uintptr(regs.Rsi), data)
+	if err != nil {
+		logger.Debug("cannot read the output of the target: %v", err)
+		return
+	}
+
+	lines := strings.Split(catches.partialLines[regs.Rdi]+string(data[:read]), "\n")
+	catches.partialLines[regs.Rdi] = lines[len(lines)-1]
+
+	for _, line := range lines[:len(lines)-1] {
+		for _, pattern := range catches.patterns {
+			if pattern.MatchString(line) {
+				catches.match = line
+				emitEvent(ctx, rpc.EVENT_OUTPUT, "%v", line)
+				return
+			}
+		}
+	}
+}
+
+// Stops the target after a write matching a pattern returned. The write is made within the C library, the target
+// is single-stepped back to the code of the program to inspect its state, unless it executes another syscall first
+func stopAfterCaughtOutput(ctx *processContext, fd uint64) error {
+	catches := ctx.outputCatches
+	line := catches.match
+
+	catches.match = ""
+	catches.stopped = true
+
+	var waitStatus syscall.WaitStatus
+	var deferredSignals []syscall.Signal
+
+	for steps := 0; steps < MAX_STEPS_TO_PROGRAM; steps++ {
+		regs := getRegs(ctx, false)
+
+		if ctx.dwarfData.PCToFunc(regs.Rip) != nil || findBreakpointByAddress(ctx, regs.Rip) != nil {
+			break
+		}
+
+		instruction := make([]byte, len(syscallInstruction))
+		syscall.PtracePeekData(ctx.pid, uintptr(regs.Rip), instruction)
+
+		if string(instruction) == string(syscallInstruction) {
+			break
+		}
+
+		err := resumeTarget(ctx, true, 0)
+		if err != nil {
+			return err
+		}
+
+		err = waitForStop(ctx, &waitStatus)
+		if err != nil {
+			return err
+		}
+
+		if waitStatus.StopSignal() != syscall.SIGTRAP {
+			deferredSignals = append(deferredSignals, waitStatus.StopSignal())
+		}
+	}
+
+	// signals arriving meanwhile are raised again, to be received on the next resume
+	for _, signal := range deferredSignals {
+		syscall.Kill(ctx.pid, signal)
+	}
+
+	logger.Info("output on %v matched: %s", outputStreams[fd], line)
+
+	regs := getRegs(ctx, false)
+	function := ctx.dwarfData.PCToFunc(regs.Rip)
+	sourceLine, file, err := ctx.dwarfData.PCToLineContaining(regs.Rip)
+
+	switch {
+	case function == nil || err != nil:
+		logger.Info("stopped in the C library at %#x, after the write", regs.Rip)
+	default:
+		logger.Info("stopped at line %d, file %v (func %v), after the write", sourceLine, filepath.Base(file), function.Name())
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+const RECEIVED_CLOCK_VARIABLE = "_MPI_WRAPPER_RECEIVED_CLOCK"
+
+// Causal breakpoints ("stop rank R when it receives a message sent by rank S after S passed line L") are evaluated
+// by the orchestrator. The sender reports its vector clock entry whenever it passes a marked line, the receiver
+// checks the messages its blocking receives return with, by the clock piggybacked on them
+
+// Marks a line ([<file>:]<line>), passing it is reported to the orchestrator without stopping the target
+func setLineMarker(ctx *processContext, location string) error {
+	file, line, err := parseLineLocation(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	address, err := ctx.dwarfData.LineToPC(file, line)
+	if err != nil {
+		return err
+	}
+
+	if bpoint := ctx.bpointData[address]; bpoint != nil {
+		bpoint.marker = location
+	} else {
+		ctx.bpointData[address] = &bpointData{
+			address:             address,
+			originalInstruction: insertBreakpoint(ctx, address),
+			marker:              location,
+			markerOnly:          true,
+		}
+	}
+
+	logger.Info("reporting passes of line %v", location)
+	return nil
+}
+
+// Removes the line markers, breakpoints at marked lines stay
+func clearLineMarkers(ctx *processContext) {
+	for address, bpoint := range ctx.bpointData {
+		if !bpoint.markerOnly {
+			bpoint.marker = ""
+			continue
+		}
+
+		_, err := syscall.PtracePokeData(ctx.pid, uintptr(address), bpoint.originalInstruction)
+		if err != nil {
+			logger.Warn("cannot remove the marker at %#x: %v", address, err)
+			continue
+		}
+		delete(ctx.bpointData, address)
+	}
+
+	logger.Info("line markers cleared")
+}
+
+// Parses a line location, [<file>:]<line>. The file defaults to the source file of main
+func parseLineLocation(ctx *processContext, location string) (file string, line int, err error) {
+	file = ctx.sourceFile
+
+	name, lineNumber, hasFile := strings.Cut(location, ":")
+	if !hasFile {
+		lineNumber = name
+	} else if file, err = resolveSourceFile(ctx, name); err != nil {
+		return "", 0, err
+	}
+
+	line, err = strconv.Atoi(lineNumber)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid line %v", lineNumber)
+	}
+
+	return file, line, nil
+}
+
+// Reports the target passing a marked line, with the MPI events it has made so far
+func reportLinePass(ctx *processContext, bpoint *bpointData) {
+	clock, ok := ownClockEntry(ctx)
+	if !ok {
+		logger.Debug("passed %v before MPI was initialized", bpoint.marker)
+		return
+	}
+
+	pass := rpc.LinePass{NodeId: ctx.nodeData.id, Location: bpoint.marker, Clock: clock}
+
+	_, err := ctx.nodeData.rpcClient.NodeReporter().LinePassed(context.Background(), pass.Proto())
+	if err != nil {
+		logger.Warn("cannot report passing %v: %v", bpoint.marker, err)
+	}
+}
+
+// Turns the checks of received messages on or off (on, off)
+func setCausalWatch(ctx *processContext, argument string) error {
+	switch strings.TrimSpace(argument) {
+	case "on":
+		ctx.causalWatch = true
+	case "off":
+		ctx.causalWatch = false
+	default:
+		return fmt.Errorf("invalid argument %v, want on or off", argument)
+	}
+
+	return nil
+}
+
+// Stops the target at the return of the blocking receive it is stopped in, to check its message.
+// Receives replayed from the message log carry no clock of their sender and are not checked
+func watchReceive(ctx *processContext) {
+	regs := getRegs(ctx, false)
+
+	data := make([]byte, 8)
+	_, err := syscall.PtracePeekData(ctx.pid, uintptr(regs.Rbp+8), data)
+	if err != nil {
+		logger.Debug("cannot find the return of the receive: %v", err)
+		return
+	}
+
+	address := binary.LittleEndian.Uint64(data)
+
+	// a breakpoint after the call already stops the target
+	if ctx.bpointData[address] != nil {
+		return
+	}
+
+	ctx.bpointData[address] = &bpointData{
+		address:             address,
+		originalInstruction: insertBreakpoint(ctx, address),
+		causalReceive:       true,
+	}
+}
+
+// Asks the orchestrator whether the message the target received is the one of a causal breakpoint.
+// Returns the description of the breakpoint, empty if none
+func checkCausalReceive(ctx *processContext) string {
+	source, _, err := readWrapperInt(ctx, "_MPI_WRAPPER_RECEIVED_SOURCE")
+	if err != nil || source < 0 || source >= MAX_CLOCK_RANKS {
+		return ""
+	}
+
+	_, address, err := locateVariable(ctx, RECEIVED_CLOCK_VARIABLE, true)
+	if err != nil {
+		return ""
+	}
+
+	data := make([]byte, 4)
+	_, err = syscall.PtracePeekData(ctx.pid, uintptr(address)+uintptr(4*source), data)
+	if err != nil {
+		logger.Debug("cannot read the clock of the received message: %v", err)
+		return ""
+	}
+
+	query := rpc.CausalReceive{
+		NodeId:      ctx.nodeData.id,
+		Source:      source,
+		SenderClock: int(int32(binary.LittleEndian.Uint32(data))),
+	}
+
+	reply, err := ctx.nodeData.rpcClient.NodeReporter().CausalReceive(context.Background(), query.Proto())
+	if err != nil {
+		logger.Warn("cannot check the received message: %v", err)
+		return ""
+	}
+
+	return reply.Breakpoint
+}
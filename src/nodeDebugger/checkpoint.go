@@ -10,33 +10,62 @@ import (
 
 	"github.com/ottmartens/cc-rev-db/logger"
 	"github.com/ottmartens/cc-rev-db/nodeDebugger/proc"
+	"github.com/ottmartens/cc-rev-db/rpc"
 	"github.com/ottmartens/cc-rev-db/utils"
 )
 
-type CheckpointMode int
+// Records the memory state of the target at checkpoints and restores it
+type Checkpointer interface {
+	// records the memory state of the stopped target
+	create(ctx *processContext, opName string) cPoint
+	// writes the memory state recorded at the checkpoint back to the target
+	restore(ctx *processContext, checkpoint cPoint) error
+	// frees what holds the memory state of a checkpoint no longer restorable
+	release(ctx *processContext, checkpoint cPoint)
+	// bytes taken up by the memory state of the checkpoint
+	size(ctx *processContext, checkpoint cPoint) uint64
+	// name of the backend, as given with --checkpoint-backend
+	String() string
+}
 
-const (
-	fileMode CheckpointMode = iota
-	forkMode
-)
+// Returns the checkpoint backend by its name
+func checkpointerByName(name string) (Checkpointer, error) {
+	for _, checkpointer := range []Checkpointer{fileCheckpointer{}, forkCheckpointer{}, criuCheckpointer{}} {
+		if checkpointer.String() == name {
+			return checkpointer, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown checkpoint backend %v", name)
+}
 
 type checkpointData []cPoint
 
 type cPoint struct {
-	opName string              // name of the mpi operation where checkpoint was made
-	regs   *syscall.PtraceRegs // register values at checkpoint
-	id     string              // unique id of the checkpoint
+	opName           string              // name of the mpi operation where checkpoint was made
+	regs             *syscall.PtraceRegs // register values at checkpoint
+	id               string              // unique id of the checkpoint
+	instructionCount uint64              // instructions retired by the target before the checkpoint (0 if unavailable)
+	pendingRequests  requestData         // nonblocking MPI operations not completed at checkpoint time
+	backend          Checkpointer        // the backend the memory state is recorded with
+	pruned           bool                // whether the memory state was released by the retention policy, the checkpoint cannot be restored
+	received         *receivedMessage    // for receives, the message received, once the receive completed
+	sent             *sentMessage        // for sends, the message sent, if its payload was captured
+	fpControl        *fpControl          // floating-point control and status registers at checkpoint (nil if unavailable)
+	record           *rpc.MPICallRecord  // as reported to the orchestrator, reported again to an orchestrator missing it
 
 	// file mode
 	file    string           // file in which checkpoint data is stored
 	regions []proc.MemRegion // descriptors of memory ranges
 
 	// fork mode
-	pid          int              // process id of the fork at checkpoint
-	stackRegions []proc.MemRegion // stack region addresses of checkpoint
-	stackRawData [][]byte         // raw value of stack at checkpoint
-	bpoints      breakpointData   // breakpoints at checkpoint time
+	pid int // process id of the snapshot process forked from the target at checkpoint
 
+	// criu mode
+	imagesDir string // directory of the images dumped by criu
+
+	bpoints         breakpointData // breakpoints at checkpoint time
+	detachedBpoints breakpointData // breakpoints lifted out of the target at checkpoint time, as the console was detached
 }
 
 func (c checkpointData) New() checkpointData {
@@ -51,28 +80,55 @@ func createCheckpoint(ctx *processContext, opName string) string {
 
 	logger.Verbose("creating new checkpoint (%v)", opName)
 
-	var checkpoint cPoint
-
-	if ctx.checkpointMode == fileMode {
-		checkpoint = createFileCheckpoint(ctx, opName)
-	} else {
-		checkpoint = createForkCheckpoint(ctx, opName)
-	}
+	checkpoint := ctx.checkpointer.create(ctx, opName)
 
 	checkpoint.id = utils.RandomId()
+	checkpoint.instructionCount = getInstructionCount(ctx)
+	checkpoint.pendingRequests = ctx.pendingRequests.copy()
+
+	if control, err := getFPControl(ctx); err == nil {
+		checkpoint.fpControl = &control
+	}
 
 	for address, bp := range ctx.bpointData {
+		// a receive is not pending at an MPI call, its check would stop the target after the checkpoint is restored.
+		// The breakpoints of until are removed when it stops
+		if bp.causalReceive || bp.temporary {
+			continue
+		}
+
 		checkpoint.bpoints[address] = &bpointData{
 			address:                 bp.address,
 			originalInstruction:     bp.originalInstruction,
 			function:                bp.function,
 			isMPIBpoint:             bp.isMPIBpoint,
 			isImmediateAfterRestore: false,
+			condition:               bp.condition,
+			hitCount:                bp.hitCount,
+			marker:                  bp.marker,
+			markerOnly:              bp.markerOnly,
+			iteration:               bp.iteration,
+			commands:                bp.commands,
+			trace:                   bp.trace,
+			traceOnly:               bp.traceOnly,
 		}
 	}
 
+	if ctx.detachedBreakpoints != nil {
+		checkpoint.detachedBpoints = ctx.detachedBreakpoints.copy()
+	}
+
+	if isReplaying(ctx) {
+		reportReplayDivergence(ctx, checkpoint, ctx.replayedCheckpoints[len(ctx.cpointData)])
+	}
+
 	ctx.cpointData = append(ctx.cpointData, checkpoint)
 
+	if len(ctx.replayedCheckpoints) > 0 && !isReplaying(ctx) {
+		logger.Verbose("replay finished, target passed all previously recorded events")
+		ctx.replayedCheckpoints = nil
+	}
+
 	return checkpoint.id
 }
 
@@ -94,67 +150,120 @@ func restoreCheckpoint(ctx *processContext, checkpointId string) error {
 		return err
 	}
 
+	if checkpoint.pruned {
+		err := fmt.Errorf("Checkpoint %v was pruned", checkpoint)
+		logger.Error("%v", err)
+		return err
+	}
+
 	logger.Info("restoring checkpoint %v", checkpoint)
 
-	if ctx.checkpointMode == forkMode {
-		restoreForkCheckpoint(ctx, *checkpoint)
-	} else {
-		restoreFileCheckpoint(ctx, *checkpoint)
+	// the user breakpoints set now, including the one the target is stopped at
+	previousBreakpoints := ctx.bpointData.copy()
+	if ctx.caughtBreakpoint != nil {
+		previousBreakpoints[ctx.caughtBreakpoint.address] = ctx.caughtBreakpoint
+	}
+	if ctx.liftedBreakpoint != nil {
+		previousBreakpoints[ctx.liftedBreakpoint.address] = ctx.liftedBreakpoint
+	}
+
+	err := checkpoint.backend.restore(ctx, *checkpoint)
+	if err != nil {
+		logger.Error("cannot restore checkpoint %v: %v", checkpoint, err)
+		return err
 	}
 
 	logger.Debug("restoring registers state")
 
-	err := syscall.PtraceSetRegs(ctx.pid, checkpoint.regs)
+	err = syscall.PtraceSetRegs(ctx.pid, checkpoint.regs)
 	utils.Must(err)
 
-	logger.Debug("reverting breakpoints state")
-	ctx.bpointData = checkpoint.bpoints
-
-	// remove subsequent checkpoints
-	ctx.cpointData = ctx.cpointData[:checkpointIndex+1]
+	// the target re-executes in the floating-point environment it originally executed in
+	if checkpoint.fpControl != nil {
+		err = setFPControl(ctx, *checkpoint.fpControl)
+		if err != nil {
+			logger.Warn("cannot restore the floating-point control registers: %v", err)
+		}
+	}
 
-	logger.Debug("checkpoint restore finished")
+	err = applyNaNTrap(ctx)
+	if err != nil {
+		logger.Warn("cannot restore the NaN trap: %v", err)
+	}
+	if ctx.nanTrap != nil {
+		ctx.nanTrap.stopped = false
+	}
 
-	return nil
-}
+	// the fatal signal is not delivered, the target is rolled back to before it
+	ctx.fatalSignal = nil
 
-func restoreFileCheckpoint(ctx *processContext, checkpoint cPoint) {
-	logger.Debug("restoring memory state: %v (file: %v) ", checkpoint.opName, checkpoint.file)
+	// output written after the checkpoint is written again
+	if ctx.outputCatches != nil {
+		ctx.outputCatches.stopped = false
+		ctx.outputCatches.match = ""
+		ctx.outputCatches.partialLines = make(map[uint64]string)
+	}
 
-	readMemoryContentsFromFile(checkpoint)
+	logger.Debug("reverting breakpoints state")
+	// hit breakpoints are removed, which must not remove them from the checkpoint
+	ctx.bpointData = checkpoint.bpoints.copy()
 
-	err := proc.WriteRegionsContentsToMemFile(ctx.pid, checkpoint.regions)
-	utils.Must(err)
-}
+	// the memory of a checkpoint recorded while the console was detached lacks the user breakpoints
+	for _, bpoint := range checkpoint.detachedBpoints.copy() {
+		if err := rearmBreakpoint(ctx, bpoint); err != nil {
+			logger.Warn("breakpoint not inserted again: %v", err)
+		}
+	}
 
-func restoreForkCheckpoint(ctx *processContext, checkpoint cPoint) {
-	logger.Debug("restoring checkpoint: %v (pid %v)", checkpoint.opName, checkpoint.pid)
+	// breakpoints set or removed after the checkpoint was recorded
+	ctx.bpointData = reconcileBreakpoints(ctx, previousBreakpoints, ctx.bpointData)
+	ctx.caughtBreakpoint = nil
+	ctx.liftedBreakpoint = nil
 
-	logger.Debug("fetching memory locations from checkpoint")
-	checkpointMemRegions := proc.GetForkCheckpointDataAddresses(ctx.pid, ctx.targetFile)
+	restoreInstructionCount(ctx, *checkpoint)
+	ctx.pendingRequests = checkpoint.pendingRequests.copy()
+	restoreSignals(ctx, checkpointIndex)
 
-	logger.Debug("restoring memory state from checkpoint")
-	for _, memRegion := range checkpointMemRegions {
-		// logger.Debug("from checkpoint: %v", memRegion)
-		data := memRegion.ContentsFromFile(checkpoint.pid)
+	// a node rolled back alone replays its messages from the log, the others re-execute with the nodes rolled back with them
+	ctx.messageLog, ctx.pendingMessageLog = ctx.pendingMessageLog, nil
 
-		_, err := syscall.PtracePokeData(ctx.pid, uintptr(memRegion.Start), data)
-		utils.Must(err)
+	// a forced source replaces the recorded message, the receive gets another one
+	if !applyForcedSource(ctx, checkpoint.id) {
+		forceRecordedMessage(ctx, *checkpoint)
 	}
+	ctx.pendingReceive = ""
+	awaitReceivedMessage(ctx, checkpoint.id, checkpoint.opName)
+	replayFromMessageLog(ctx, checkpoint.id, checkpoint.opName)
 
-	for index, memRegion := range checkpoint.stackRegions {
-		// logger.Debug("from stack: %v", memRegion)
-		data := checkpoint.stackRawData[index]
+	// the target re-executes the events recorded after the checkpoint
+	if len(ctx.replayedCheckpoints) < len(ctx.cpointData) {
+		ctx.replayedCheckpoints = append(checkpointData{}, ctx.cpointData...)
+	}
 
-		_, err := syscall.PtracePokeData(ctx.pid, uintptr(memRegion.Start), data)
-		utils.Must(err)
+	// remove subsequent checkpoints
+	for _, discarded := range ctx.cpointData[checkpointIndex+1:] {
+		if !discarded.pruned {
+			discarded.backend.release(ctx, discarded)
+		}
 	}
+	ctx.cpointData = ctx.cpointData[:checkpointIndex+1]
+
+	logger.Debug("checkpoint restore finished")
+
+	return nil
 }
 
-func createFileCheckpoint(ctx *processContext, opName string) cPoint {
+// Records checkpoints in files, the default backend
+type fileCheckpointer struct{}
+
+func (fileCheckpointer) String() string {
+	return "file"
+}
+
+func (c fileCheckpointer) create(ctx *processContext, opName string) cPoint {
 	regs := getRegs(ctx, false)
 
-	checkpointFile, err := os.CreateTemp(fmt.Sprintf("%v/temp", utils.GetExecutableDir()), fmt.Sprintf("%v-cp-*", filepath.Base(ctx.targetFile)))
+	checkpointFile, err := os.CreateTemp(ctx.options.storageDir, fmt.Sprintf("%v-cp-*", filepath.Base(ctx.targetFile)))
 
 	regions := proc.GetFileCheckpointDataAddresses(ctx.pid, ctx.targetFile)
 
@@ -168,26 +277,71 @@ func createFileCheckpoint(ctx *processContext, opName string) cPoint {
 		regions: regions,
 		file:    checkpointFile.Name(),
 		bpoints: make(breakpointData),
+		backend: c,
 	}
 
 	return checkpoint
 }
 
-func createForkCheckpoint(ctx *processContext, opName string) cPoint {
-	regs := getRegs(ctx, false)
+func (fileCheckpointer) restore(ctx *processContext, checkpoint cPoint) error {
+	logger.Debug("restoring memory state: %v (file: %v) ", checkpoint.opName, checkpoint.file)
 
-	stackMemRegions := proc.GetStackDataAddresses(ctx.pid)
+	readMemoryContentsFromFile(checkpoint)
 
-	checkpoint := cPoint{
-		pid:          int(getVariableFromMemory(ctx, "_MPI_CHECKPOINT_CHILD", true).(int32)),
-		opName:       opName,
-		regs:         regs,
-		stackRegions: stackMemRegions,
-		stackRawData: proc.ReadFromMemFileByRegions(ctx.pid, stackMemRegions),
-		bpoints:      make(breakpointData),
+	return proc.WriteRegionsContentsToMemFile(ctx.pid, checkpoint.regions)
+}
+
+func (fileCheckpointer) release(ctx *processContext, checkpoint cPoint) {
+	os.Remove(checkpoint.file)
+}
+
+func (fileCheckpointer) size(ctx *processContext, checkpoint cPoint) uint64 {
+	info, err := os.Stat(checkpoint.file)
+	if err != nil {
+		return 0
 	}
 
-	return checkpoint
+	return uint64(info.Size())
+}
+
+// Returns the bytes taken up by the memory state of a checkpoint
+func checkpointSize(ctx *processContext, checkpointId string) uint64 {
+	for _, checkpoint := range ctx.cpointData {
+		if checkpoint.id == checkpointId && !checkpoint.pruned {
+			return checkpoint.backend.size(ctx, checkpoint)
+		}
+	}
+
+	return 0
+}
+
+// Releases the memory state of checkpoints, keeping their records for the events replayed after a restore.
+// The checkpoints cannot be restored afterwards
+func pruneCheckpoints(ctx *processContext, checkpointIds []string) error {
+	for _, checkpointId := range checkpointIds {
+		found := false
+
+		for index, checkpoint := range ctx.cpointData {
+			if checkpoint.id != checkpointId {
+				continue
+			}
+
+			found = true
+
+			if !checkpoint.pruned {
+				logger.Debug("pruning checkpoint %v", checkpoint)
+
+				checkpoint.backend.release(ctx, checkpoint)
+				ctx.cpointData[index].pruned = true
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("checkpoint with id %v not found", checkpointId)
+		}
+	}
+
+	return nil
 }
 
 func writeCheckpointToFile(ctx *processContext, file *os.File, regions []proc.MemRegion) {
@@ -218,3 +372,19 @@ func readMemoryContentsFromFile(checkpoint cPoint) {
 		checkpoint.regions[index].Contents = buffer
 	}
 }
+
+// Compares a checkpoint recorded during replay to the one recorded at the same event originally
+func reportReplayDivergence(ctx *processContext, replayed cPoint, original cPoint) {
+	if replayed.opName != original.opName {
+		logger.Warn("replay diverged: reached %v, originally %v", replayed.opName, original.opName)
+		return
+	}
+
+	if ctx.instructionCounter == nil || replayed.instructionCount == original.instructionCount {
+		return
+	}
+
+	difference := int64(replayed.instructionCount) - int64(original.instructionCount)
+
+	logger.Verbose("replay of %v diverged by %d instructions", replayed.opName, difference)
+}
@@ -1,19 +1,22 @@
 package main
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
 	"github.com/ottmartens/cc-rev-db/utils"
 	"github.com/ottmartens/cc-rev-db/utils/command"
+	"github.com/ottmartens/cc-rev-db/utils/readline"
 )
 
 func askForInput() *command.Command {
@@ -21,62 +24,197 @@ func askForInput() *command.Command {
 
 	userInput := getUserInputLine()
 
-	command := parseCommandFromString(userInput)
+	userInput, jsonResult := command.CutJSONFlag(userInput)
 
-	if command == nil {
-		fmt.Println(`Invalid input. Type "help" to see available commands`)
+	cmd := parseCommandFromString(userInput)
+
+	if cmd == nil {
+		fmt.Fprintln(promptOutput, `Invalid input. Type "help" to see available commands`)
 		return askForInput()
 	}
 
-	return command
+	cmd.JSON = jsonResult
+
+	return cmd
+}
+
+// the editor of the lines typed in cli mode, keeping their history in the history file
+var lineEditor *readline.Editor
+
+// Returns the line editor, writing where the prompt is shown
+func consoleEditor() *readline.Editor {
+	if lineEditor == nil {
+		lineEditor = readline.NewEditor(promptOutput, readline.HistoryFile())
+	}
+
+	return lineEditor
 }
 
+// Reads a line typed in cli mode, or the next command of a script. The end of the input quits
 func getUserInputLine() string {
+	text, scripted := nextScriptLine()
 
-	reader := bufio.NewReader(os.Stdin)
+	if !scripted {
+		var err error
 
-	text, _ := reader.ReadString('\n')
+		text, err = consoleEditor().ReadLine()
+		if err != nil {
+			return "q"
+		}
 
-	text = strings.Replace(text, "\n", "", 1)
+		if isSourceCommand(text) {
+			sourceScript(text, 0)
+			printPrompt()
+			return getUserInputLine()
+		}
+	}
 
-	text = strings.ToLower(text)
+	// identifiers are case-sensitive, only the command itself is not
+	if command, arguments, found := strings.Cut(text, " "); found {
+		text = strings.ToLower(command) + " " + arguments
+	} else {
+		text = strings.ToLower(text)
+	}
 
-	return text
+	return readCommandList(text)
+}
+
+// a breakpoint command list without its commands, which follow on the next lines
+var commandListStart = regexp.MustCompile(`^commands \S+$`)
+
+// Reads the commands of a breakpoint typed on the lines following `commands <location>`, up to `end`.
+// Returns the line with the commands appended, as if they were given on it
+func readCommandList(line string) string {
+	if !commandListStart.MatchString(line) {
+		return line
+	}
+
+	fmt.Fprintln(promptOutput, `Type the commands to execute at the breakpoint, one per line, then "end"`)
+
+	commands := make([]string, 0)
+
+	// a script ending within the list ends it
+	for !batchMode || len(scriptLines) > 0 {
+		consoleEditor().SetPrompt("> ")
+
+		next := strings.TrimSpace(getUserInputLine())
+		if next == "end" {
+			break
+		}
+
+		if len(next) > 0 {
+			commands = append(commands, next)
+		}
+	}
+
+	return strings.TrimSpace(line + " " + strings.Join(commands, "; "))
 }
 
 func printPrompt() {
-	fmt.Printf("insert command > ")
+	consoleEditor().SetPrompt("insert command > ")
+}
+
+// where the prompt is printed, the standard error in json output mode
+var promptOutput io.Writer = os.Stdout
+
+// Prints the log and the prompt on the standard error, leaving the standard output to the results of the commands
+// as json and to the output of the target
+func printJSONOutput() {
+	promptOutput = os.Stderr
+	logger.SetRowPrinter(func(row string) {
+		fmt.Fprint(os.Stderr, row)
+	})
 }
 
 func printInstructions() {
 
 	fmt.Print("\nAvailable commands:\n\n")
 
-	fmt.Println("  b <lineNr> \t set breakpoint")
+	fmt.Println("  b [<file>:]<lineNr>  set breakpoint")
+	fmt.Println("  b <function> \t set breakpoint at a function (e.g. Solver::step)")
+	fmt.Println("  b <lineNr|function> if <condition>  set conditional breakpoint (e.g. $rank == 0 && $hitcount > 5)")
+	fmt.Println("  commands <location> [<command>; ...]  execute commands whenever the breakpoint at [<file>:]<line> or a function stops the target, continue last to run on; without commands they are read up to end")
+	fmt.Println(`  trace <location> "<format>" [<argument>, ...]  log a printf-formatted line of the arguments whenever the target passes [<file>:]<line> or a function, without stopping`)
+	fmt.Println("  break-iter [<file>:]<line> <n>  stop at iteration n of the loop at the line (execution n of other lines)")
+	fmt.Println("  info iteration  print how many times the current line has executed, counted by its breakpoint")
 	fmt.Println("  s  \t\t single-step forward")
+	fmt.Println("  stepi [n] \t step n machine instructions (default 1), printing the instruction stopped at with its source line, also si")
+	fmt.Println("  skip -file <glob> | -function <name>  do not descend into a source file, library or function when stepping; skip lists them, skip clear removes them")
 	fmt.Println("  c  \t\t continue execution")
+	fmt.Println("  until <location>  continue to [<file>:]<line> or a function with a temporary breakpoint, also u")
+	fmt.Println("  rsi [n] \t step back n instructions (default 1)")
+	fmt.Println("  rc  \t\t continue back to the last breakpoint hit since the last checkpoint, also reverse-continue")
+	fmt.Println("  rs  \t\t step back to the previous source line executed, also reverse-step")
+	fmt.Println("  rn  \t\t step back to the previous source line executed in the function or its callers, also reverse-next")
+	fmt.Println("  lastwrite <var>  step back to the last write to a variable (or a.b, p->x, a[i]) since the last checkpoint")
 	fmt.Println("  r <cp index> \t restore checkpoint")
-	fmt.Println("  p <var>  \t print a variable")
+	fmt.Println("  p <expr>  \t print a variable or expression, e.g. p->x * 2, *buf, a[i].y, (long)n ($rank, $size, $event, $checkpoint, $hitcount for convenience variables)")
+	fmt.Println("  p <arr>(i,j) \t print an array element, a(1,2) in Fortran, a[0][1] in C")
+	fmt.Println("  p/<f> <expr>  print the bytes of a value as hex (x), signed (d) or unsigned (u) decimal, octal (o), binary (t), a character (c) or a float (f), strings as raw bytes (r)")
+	fmt.Println("  vars <expr>  \t print a variable or expression one level deep, with handles to expand its fields or elements")
+	fmt.Println("  vars #<handle> [<start> [<count>]]  print the fields, elements or pointed-to value of an expanded value")
+	fmt.Println("  display[/<f>] <expr>  print an expression each time the target stops, without one print all displays")
+	fmt.Println("  undisplay [<n> ...]  remove displays by their numbers, all without numbers")
+	fmt.Println("  whatis <expr>  print the declared type of a variable or expression")
+	fmt.Println("  ptype <expr|type>  print a type with the members of its structures, unions and enumerations, their offsets and sizes")
+	fmt.Println("  info goroutines  list goroutines (go targets)")
+	fmt.Println("  bt  		 print the call stack")
+	fmt.Println("  goroutine <n> bt  print the call stack of a goroutine")
+	fmt.Println("  info jit  	 list the functions generated at runtime (GDB JIT interface, perf map files)")
+	fmt.Println("  capabilities  	 print the supported features as json")
+	fmt.Println("  inject <fault> [at <MPI op>] [on <ranks>]  inject drop-message, delay <ms> or error-return <code> at MPI calls")
+	fmt.Println("  inject [clear] 	 list or clear injected faults")
+	fmt.Println("  hash <var|addr> [len]  print the hash of a buffer, of the memory a pointer points to")
+	fmt.Println("  hash auto [<var|addr> [len]|clear]  hash a buffer at every checkpoint, list or clear the hashed buffers")
+	fmt.Println("  payload cap [<n>[K|M|G]]  show or change the bytes of sent messages recorded, the rest by its hash")
+	fmt.Println("  info registers  print the registers, with the floating-point control and status registers decoded")
+	fmt.Println("  fp [mask|unmask <exception>|all]  show the floating-point environment or (un)mask an exception")
+	fmt.Println("  fp round <nearest|down|up|zero>  set the floating-point rounding mode")
+	fmt.Println("  nan trap [on|off]  stop at the instruction producing a NaN")
+	fmt.Println("  catch output [<regex>|clear]  stop when a line of stdout or stderr matches, list or clear the patterns")
+	fmt.Println("  source <file>  execute the commands of a file")
 	fmt.Println("  q  \t\t quit")
 	fmt.Println("  help  \t show this again")
+	fmt.Println("  <command> -json  print the result of the command as a line of json")
 	fmt.Println()
 }
 
+type launchOptions struct {
+	disableASLR     bool          // start the target with address space layout randomization disabled
+	noStopAtMain    bool          // leave the target stopped at the trap of its exec instead of running it to main
+	watchdogTimeout time.Duration // abort replays making no progress for this long (0 - disabled)
+	checkpointer    Checkpointer  // backend recording the checkpoints
+
+	deterministicReplay bool  // force receives replayed after a restore to complete with the recorded messages
+	payloadCap          int64 // bytes of sent messages recorded with their events
+	seed                int64 // seed of the checkpoint ids, mixed with the node id (0 - random)
+
+	storageDir string // directory of the checkpoint files and images
+
+	json bool // print the results of the commands as json in cli mode
+	tui  bool // show the source, registers, breakpoints and output in panes in cli mode
+
+	commands []string // executed in cli mode before the lines typed: source <file> for each --script, the -ex commands
+	batch    bool     // quit once the commands are executed
+}
+
 // parse and validate command line arguments
-func getValuesFromArgs() (targetFilePath string, checkpointMode CheckpointMode, orchestratorAddress *url.URL, isStandaloneMode bool) {
+func getValuesFromArgs() (targetFilePath string, checkpointer Checkpointer, orchestratorAddress *url.URL, isStandaloneMode bool, options launchOptions) {
 
-	if len(os.Args) < 3 {
+	args, options := parseLaunchOptions(os.Args[1:])
+
+	if len(args) < 2 {
 		printUsage()
 	}
 
 	var err error
 
-	switch os.Args[1] {
+	switch args[0] {
 	case "hello":
 		logger.Info("loading example mpi hello binary")
 		targetFilePath, err = filepath.Abs("bin/targets/hello")
 	default:
-		targetFilePath, err = filepath.Abs(os.Args[1])
+		targetFilePath, err = filepath.Abs(args[0])
 	}
 
 	utils.Must(err)
@@ -89,19 +227,12 @@ func getValuesFromArgs() (targetFilePath string, checkpointMode CheckpointMode,
 		panic(err) // file does not exist
 	}
 
-	// fork-based checkpointing temporarily disabled
-	// if len(os.Args) == 3 && os.Args[2] == "fork" {
-	// 	checkpointMode = forkMode
-	// 	logger.Info("Checkpoint mode: fork")
-	// } else {
-	// 	checkpointMode = fileMode
-	// 	logger.Info("Checkpoint mode: file")
-	// }
+	logger.Info("Checkpoint backend: %v", options.checkpointer)
 
-	if os.Args[2] == "cli" {
+	if args[1] == "cli" {
 		isStandaloneMode = true
 	} else {
-		orchestratorAddress, err = url.ParseRequestURI(os.Args[2])
+		orchestratorAddress, err = rpc.ParseAddress(args[1])
 
 		if err != nil {
 			os.Stderr.WriteString(err.Error())
@@ -109,44 +240,117 @@ func getValuesFromArgs() (targetFilePath string, checkpointMode CheckpointMode,
 		}
 	}
 
-	return targetFilePath, fileMode, orchestratorAddress, isStandaloneMode
+	return targetFilePath, options.checkpointer, orchestratorAddress, isStandaloneMode, options
+}
+
+// separates the --option flags from positional arguments
+func parseLaunchOptions(args []string) (positionalArgs []string, options launchOptions) {
+	positionalArgs = make([]string, 0, len(args))
+
+	options.watchdogTimeout = DEFAULT_WATCHDOG_TIMEOUT
+	options.checkpointer = fileCheckpointer{}
+	options.payloadCap = DEFAULT_PAYLOAD_CAP
+	options.storageDir = fmt.Sprintf("%v/temp", utils.GetExecutableDir())
+
+	for index := 0; index < len(args); index++ {
+		arg := args[index]
+
+		switch {
+		case arg == "-ex":
+			if index+1 == len(args) {
+				printUsage()
+			}
+
+			options.commands = append(options.commands, args[index+1])
+			index++
+		case strings.HasPrefix(arg, "--script="):
+			options.commands = append(options.commands, "source "+strings.TrimPrefix(arg, "--script="))
+		case arg == "--batch":
+			options.batch = true
+		case arg == "--no-aslr":
+			options.disableASLR = true
+		case arg == "--no-stop-at-main":
+			options.noStopAtMain = true
+		case arg == "--deterministic-replay":
+			options.deterministicReplay = true
+		case arg == "--json":
+			options.json = true
+		case arg == "--tui":
+			options.tui = true
+		case strings.HasPrefix(arg, "--watchdog="):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(arg, "--watchdog="))
+			if err != nil || seconds < 0 {
+				printUsage()
+			}
+			options.watchdogTimeout = time.Duration(seconds) * time.Second
+		case strings.HasPrefix(arg, "--payload-cap="):
+			payloadCap, err := utils.ParseByteSize(strings.TrimPrefix(arg, "--payload-cap="))
+			if err != nil {
+				printUsage()
+			}
+			options.payloadCap = int64(payloadCap)
+		case strings.HasPrefix(arg, "--seed="):
+			seed, err := strconv.ParseInt(strings.TrimPrefix(arg, "--seed="), 10, 64)
+			if err != nil {
+				printUsage()
+			}
+			options.seed = seed
+		case strings.HasPrefix(arg, "--storage-dir="):
+			options.storageDir = strings.TrimPrefix(arg, "--storage-dir=")
+			if err := os.MkdirAll(options.storageDir, 0755); err != nil {
+				printUsage()
+			}
+		case strings.HasPrefix(arg, "--checkpoint-backend="):
+			checkpointer, err := checkpointerByName(strings.TrimPrefix(arg, "--checkpoint-backend="))
+			if err != nil {
+				printUsage()
+			}
+			options.checkpointer = checkpointer
+		default:
+			positionalArgs = append(positionalArgs, arg)
+		}
+	}
+
+	return positionalArgs, options
 }
 
 func printUsage() {
 	fmt.Println("Usage:")
-	fmt.Println("cli mode: node-debugger <target binary> cli")
-	fmt.Println("network mode: node-debugger <target binary> <orchestrator address>")
+	fmt.Println("cli mode: node-debugger <target binary> cli [options]")
+	fmt.Println("network mode: node-debugger <target binary> <orchestrator host:port> [options]")
+	fmt.Printf("  the session token of an orchestrator listening on the network is read from %v\n", rpc.TOKEN_ENV)
+	fmt.Println("options:")
+	fmt.Println("  --no-aslr \t\t disable address space layout randomization of the target")
+	fmt.Println("  --no-stop-at-main \t leave the target stopped where it starts, before the dynamic loader, instead of running it to main")
+	fmt.Println("  --watchdog=<seconds> \t abort replays making no progress (default 60, 0 disables)")
+	fmt.Println("  --checkpoint-backend={file,fork,criu}  record checkpoints in files (default), in forked copies of the target or with criu")
+	fmt.Println("  --deterministic-replay \t replay receives with the messages recorded originally, in the recorded order")
+	fmt.Println("  --payload-cap=<n>[K|M|G]  bytes of sent messages recorded, the rest by its hash (default 4K)")
+	fmt.Println("  --seed=<n> 		 seed of the checkpoint ids, the same commands give the same ids")
+	fmt.Println("  --storage-dir=<dir> 	 directory of the checkpoint files (default temp next to the executable)")
+	fmt.Println("  --json 		 print the result of every command as a line of json in cli mode, the log on stderr")
+	fmt.Println("  --tui 			 show the source, registers, breakpoints and output in panes in cli mode")
+	fmt.Println("  --script=<file> 	 execute the commands of a file in cli mode, before those typed")
+	fmt.Println("  -ex <command> 		 execute a command in cli mode, before those typed (repeatable)")
+	fmt.Println("  --batch 		 quit once the commands of --script and -ex are executed")
 	os.Exit(2)
 }
 
+// Parses the commands of the node debugger in cli mode: the node commands shared with the orchestrator,
+// and restoring checkpoints by their index, quitting and the help
 func parseCommandFromString(input string) (c *command.Command) {
-
-	breakPointRegexp := regexp.MustCompile(`^b \d+$`)
-	printRegexp := regexp.MustCompile(`^p [a-zA-Z_][a-zA-Z0-9_]*$`)
-	printInternalRegexp := regexp.MustCompile(`^pd [a-zA-Z_][a-zA-Z0-9_]*$`)
-
 	restoreRegexp := regexp.MustCompile(`^r .+$`)
 
 	switch {
-	case breakPointRegexp.Match([]byte(input)):
-		lineNr, _ := strconv.Atoi(strings.Split(input, " ")[1])
-
-		return &command.Command{Code: command.Bpoint, Argument: lineNr}
-
-	case input == "c":
-		return &command.Command{Code: command.Cont, Argument: nil}
-
-	case input == "s":
-		return &command.Command{Code: command.SingleStep, Argument: nil}
-
-	case printRegexp.Match([]byte(input)):
-		identifier := strings.Split(input, " ")[1]
-
-		return &command.Command{Code: command.Print, Argument: identifier}
-
 	case input == "q":
 		return &command.Command{Code: command.Quit, Argument: nil}
 
+	case input == "help":
+		return &command.Command{Code: command.Help, Argument: nil}
+
+	case input == "capabilities":
+		return &command.Command{Code: command.Capabilities, Argument: nil}
+
 	case restoreRegexp.Match([]byte(input)):
 		split := strings.Split(input, " ")
 
@@ -157,15 +361,7 @@ func parseCommandFromString(input string) (c *command.Command) {
 
 		return &command.Command{Code: command.Restore, Argument: index}
 
-	case input == "help":
-		return &command.Command{Code: command.Help, Argument: nil}
-
-	case printInternalRegexp.Match([]byte(input)):
-		varName := strings.Split(input, " ")[1]
-
-		return &command.Command{Code: command.PrintInternal, Argument: varName}
-
 	default:
-		return nil
+		return command.ParseNodeCommand(input)
 	}
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// the commands of cli mode handled by the debugger itself, completed along with the node commands
+var localCommands = map[string]string{
+	"q":            "",
+	"help":         "",
+	"capabilities": "",
+	"r":            "",
+	"source":       "",
+}
+
+// The scope of the last stop the variables are completed in. The orchestrator asks for completions on the rpc
+// server while the tracer updates the call stack, so the innermost frame is kept apart from it
+type completionScope struct {
+	sync.Mutex
+	function *dwarf.Function // nil if the target did not stop in a function with debug info yet
+	pc       uint64
+}
+
+// Completes the commands typed in cli mode with Tab: their names, the functions and source files of the target
+// for breakpoints and the variables in scope for printing
+func setCompleter(ctx *processContext) {
+	commands := make(map[string]string, len(command.NodeCommands)+len(localCommands))
+	for phrase, kind := range command.NodeCommands {
+		commands[phrase] = kind
+	}
+	for phrase, kind := range localCommands {
+		commands[phrase] = kind
+	}
+
+	consoleEditor().SetCompleter(func(line string) []string {
+		return command.CompleteWord(line, commands, func(kind string, prefix string) []string {
+			return completeArgument(ctx, kind, prefix)
+		})
+	})
+}
+
+// Names of the debug info starting with the prefix: functions and source files (by their file names, followed
+// by the colon of their line numbers) for locations, the variables in scope at the last stop for variables
+func completeArgument(ctx *processContext, kind string, prefix string) []string {
+	if ctx.dwarfData == nil {
+		return nil
+	}
+
+	switch kind {
+	case command.ARGUMENT_LOCATION:
+		if strings.Contains(prefix, ":") {
+			return nil
+		}
+
+		candidates := ctx.dwarfData.FunctionNames(prefix)
+
+		files := make(map[string]bool)
+		for _, file := range ctx.dwarfData.SourceFiles() {
+			if name := filepath.Base(file); strings.HasPrefix(name, prefix) && !files[name] {
+				files[name] = true
+				candidates = append(candidates, name+":")
+			}
+		}
+
+		sort.Strings(candidates)
+		return candidates
+
+	case command.ARGUMENT_VARIABLE:
+		ctx.completionScope.Lock()
+		function, pc := ctx.completionScope.function, ctx.completionScope.pc
+		ctx.completionScope.Unlock()
+
+		return ctx.dwarfData.VariableNames(prefix, function, pc)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// Conditions of breakpoints, e.g. `$rank == 0 && $hitcount > 5`: expressions (expression.go) holding where they
+// are not 0. Their operands include the convenience variables below
+
+// global variables set by the MPI wrapper (compiler/mpi_wrap_include)
+const (
+	MPI_RANK_VARIABLE = "_MPI_WRAPPER_PROC_RANK"
+	MPI_SIZE_VARIABLE = "_MPI_WRAPPER_WORLD_SIZE"
+)
+
+var convenienceVariables = map[string]func(ctx *processContext) interface{}{
+	// rank of the process in MPI_COMM_WORLD
+	"$rank": func(ctx *processContext) interface{} {
+		return getVariableFromMemory(ctx, MPI_RANK_VARIABLE, true)
+	},
+	// number of processes in MPI_COMM_WORLD
+	"$size": func(ctx *processContext) interface{} {
+		return getVariableFromMemory(ctx, MPI_SIZE_VARIABLE, true)
+	},
+	// number of MPI calls recorded so far
+	"$event": func(ctx *processContext) interface{} {
+		return int64(len(ctx.cpointData))
+	},
+	// id of the latest checkpoint
+	"$checkpoint": func(ctx *processContext) interface{} {
+		if len(ctx.cpointData) == 0 {
+			return ""
+		}
+		return ctx.cpointData[len(ctx.cpointData)-1].id
+	},
+	// number of times the breakpoint the target is stopped at has been hit
+	"$hitcount": func(ctx *processContext) interface{} {
+		if ctx.caughtBreakpoint == nil {
+			return int64(0)
+		}
+		return int64(ctx.caughtBreakpoint.hitCount)
+	},
+}
+
+// Retrieves the value of a convenience variable, nil if there is no such variable
+func getConvenienceVariable(ctx *processContext, identifier string) interface{} {
+	lookup, ok := convenienceVariables[identifier]
+	if !ok {
+		return nil
+	}
+
+	return lookup(ctx)
+}
+
+type condition struct {
+	source string
+	root   *expressionNode
+}
+
+func (c *condition) String() string {
+	return c.source
+}
+
+func parseCondition(source string) (*condition, error) {
+	parsed, err := parseExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &condition{source, parsed.root}, nil
+}
+
+// Evaluates the condition in the current state of the target
+func (c *condition) evaluate(ctx *processContext) (bool, error) {
+	value, err := c.root.evaluate(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	scalar, err := value.scalar(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return isTruthy(scalar), nil
+}
+
+// Evaluates a condition the program state is expected to satisfy where the target is stopped, failing if it does not
+func checkInvariant(ctx *processContext, source string) error {
+	invariant, err := parseCondition(source)
+	if err != nil {
+		return err
+	}
+
+	updateStack(ctx)
+
+	holds, err := invariant.evaluate(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot evaluate invariant %v: %v", invariant, err)
+	}
+
+	if !holds {
+		return fmt.Errorf("invariant %v violated", invariant)
+	}
+
+	logger.Verbose("invariant %v holds", invariant)
+	return nil
+}
+
+// Compares numbers, floating point if either is, and strings
+func compareValues(operator string, left interface{}, right interface{}) (expressionValue, error) {
+	var comparison int
+
+	left, right = normalizeValue(left), normalizeValue(right)
+
+	_, leftString := left.(string)
+	_, rightString := right.(string)
+
+	leftFloat, leftNumber := toFloat(left)
+	rightFloat, rightNumber := toFloat(right)
+
+	leftSigned, leftIsSigned := left.(int64)
+	rightSigned, rightIsSigned := right.(int64)
+
+	leftBits, leftInteger := toBits(left)
+	rightBits, rightInteger := toBits(right)
+
+	switch {
+	case leftString || rightString:
+		if !leftString || !rightString {
+			return expressionValue{}, fmt.Errorf("cannot compare %v with %v", left, right)
+		}
+		comparison = strings.Compare(left.(string), right.(string))
+
+	case !leftNumber || !rightNumber:
+		return expressionValue{}, fmt.Errorf("cannot compare %v with %v", left, right)
+
+	case leftIsSigned && rightIsSigned:
+		comparison = compareOrdered(leftSigned < rightSigned, leftSigned > rightSigned)
+
+	case leftInteger && rightInteger:
+		comparison = compareOrdered(leftBits < rightBits, leftBits > rightBits)
+
+	default:
+		comparison = compareOrdered(leftFloat < rightFloat, leftFloat > rightFloat)
+	}
+
+	result := map[string]bool{
+		"==": comparison == 0,
+		"!=": comparison != 0,
+		"<":  comparison < 0,
+		"<=": comparison <= 0,
+		">":  comparison > 0,
+		">=": comparison >= 0,
+	}[operator]
+
+	return expressionValue{value: boolValue(result)}, nil
+}
+
+func compareOrdered(less bool, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// Integer values of the target are compared as int64
+func normalizeValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case int32:
+		return int64(typedValue)
+	case int:
+		return int64(typedValue)
+	}
+	return value
+}
+
+func boolValue(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func isTruthy(value interface{}) bool {
+	switch typedValue := value.(type) {
+	case int64:
+		return typedValue != 0
+	case uint64:
+		return typedValue != 0
+	case float64:
+		return typedValue != 0
+	case string:
+		return len(typedValue) > 0
+	}
+	return value != nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// Records checkpoints as images of the whole target process dumped with criu (checkpoint/restore in userspace).
+// Besides memory, the images hold the state of the target's file descriptors, threads and connections.
+// Images are kept on disk, so they survive the debugger and can be copied to another host
+type criuCheckpointer struct{}
+
+func (criuCheckpointer) String() string {
+	return "criu"
+}
+
+// options the images are both dumped and restored with: the target shares the terminal and
+// the connections of the mpi job, which criu cannot dump unless told they are external
+var criuSharedOptions = []string{"--shell-job", "--ext-unix-sk", "--tcp-established"}
+
+// standard streams of the target, inherited from the debugger when restoring
+var criuInheritedFds = []int{1, 2}
+
+// Dumps the target with criu, falls back to a file if the dump fails
+func (c criuCheckpointer) create(ctx *processContext, opName string) cPoint {
+	regs := getRegs(ctx, false)
+
+	imagesDir, err := os.MkdirTemp(ctx.options.storageDir, fmt.Sprintf("%v-criu-*", filepath.Base(ctx.targetFile)))
+	if err == nil {
+		err = dumpTarget(ctx, imagesDir)
+	}
+
+	if err != nil {
+		logger.Warn("cannot dump the target with criu, recording the checkpoint in a file: %v", err)
+		os.RemoveAll(imagesDir)
+		return fileCheckpointer{}.create(ctx, opName)
+	}
+
+	checkpoint := cPoint{
+		imagesDir: imagesDir,
+		opName:    opName,
+		regs:      regs,
+		bpoints:   make(breakpointData),
+		backend:   c,
+	}
+
+	return checkpoint
+}
+
+// Replaces the target with the process restored from the images of the checkpoint
+func (criuCheckpointer) restore(ctx *processContext, checkpoint cPoint) error {
+	logger.Debug("restoring process state: %v (images: %v)", checkpoint.opName, checkpoint.imagesDir)
+
+	// the restored process takes the pid of the target, which must be free
+	syscall.Kill(ctx.pid, syscall.SIGKILL)
+
+	var waitStatus syscall.WaitStatus
+	syscall.Wait4(ctx.pid, &waitStatus, syscall.WALL, nil)
+
+	args := append([]string{"restore", "-D", checkpoint.imagesDir, "--restore-detached", "--leave-stopped"}, criuSharedOptions...)
+
+	inheritedFds, err := readInheritedFds(checkpoint.imagesDir)
+	if err != nil {
+		return err
+	}
+
+	for fd, resource := range inheritedFds {
+		args = append(args, "--inherit-fd", fmt.Sprintf("fd[%d]:%s", fd, resource))
+	}
+
+	err = runCriu(args...)
+	if err != nil {
+		return err
+	}
+
+	err = syscall.PtraceAttach(ctx.pid)
+	if err != nil {
+		return fmt.Errorf("cannot attach to the restored target: %v", err)
+	}
+
+	_, err = syscall.Wait4(ctx.pid, &waitStatus, 0, nil)
+	if err != nil {
+		return err
+	}
+
+	// the counter of the killed target is gone, the count restarts from the checkpoint
+	if ctx.instructionCounter != nil {
+		ctx.instructionCounter.Close()
+		ctx.instructionCounter = nil
+
+		startInstructionCounting(ctx)
+		ctx.instructionOffset = 0
+	}
+
+	return nil
+}
+
+func (criuCheckpointer) release(ctx *processContext, checkpoint cPoint) {
+	os.RemoveAll(checkpoint.imagesDir)
+}
+
+func (criuCheckpointer) size(ctx *processContext, checkpoint cPoint) uint64 {
+	var size uint64
+
+	filepath.WalkDir(checkpoint.imagesDir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			if info, err := entry.Info(); err == nil {
+				size += uint64(info.Size())
+			}
+		}
+		return nil
+	})
+
+	return size
+}
+
+// Dumps the images of the stopped target into a directory, leaving the target stopped and traced
+func dumpTarget(ctx *processContext, imagesDir string) error {
+	err := writeInheritedFds(ctx, imagesDir)
+	if err != nil {
+		return err
+	}
+
+	// criu attaches to the target itself, the target is detached in the stopped state
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PTRACE, syscall.PTRACE_DETACH, uintptr(ctx.pid), 0, uintptr(syscall.SIGSTOP), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	args := append([]string{"dump", "-t", fmt.Sprint(ctx.pid), "-D", imagesDir, "--leave-running"}, criuSharedOptions...)
+	dumpErr := runCriu(args...)
+
+	err = syscall.PtraceAttach(ctx.pid)
+	if err != nil {
+		panic(fmt.Sprintf("cannot reattach to the target after dumping it: %v", err))
+	}
+
+	var waitStatus syscall.WaitStatus
+	_, err = syscall.Wait4(ctx.pid, &waitStatus, 0, nil)
+	utils.Must(err)
+
+	return dumpErr
+}
+
+// Records the resources behind the standard streams of the target next to its images.
+// criu identifies external pipes by their inode, the restore replaces them with the debugger's streams
+func writeInheritedFds(ctx *processContext, imagesDir string) error {
+	lines := make([]string, 0)
+
+	for _, fd := range criuInheritedFds {
+		resource, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", ctx.pid, fd))
+		if err != nil || !strings.HasPrefix(resource, "pipe:") {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("%d %s", fd, resource))
+	}
+
+	return os.WriteFile(filepath.Join(imagesDir, "inherited-fds"), []byte(strings.Join(lines, "\n")), 0644)
+}
+
+func readInheritedFds(imagesDir string) (map[int]string, error) {
+	contents, err := os.ReadFile(filepath.Join(imagesDir, "inherited-fds"))
+	if err != nil {
+		return nil, err
+	}
+
+	inheritedFds := make(map[int]string)
+
+	for _, line := range strings.Split(string(contents), "\n") {
+		var fd int
+		var resource string
+
+		if _, err := fmt.Sscanf(line, "%d %s", &fd, &resource); err == nil {
+			inheritedFds[fd] = resource
+		}
+	}
+
+	return inheritedFds, nil
+}
+
+func runCriu(args ...string) error {
+	cmd := exec.Command("criu", args...)
+
+	// inherited by the restored target
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("criu %v failed: %v", args[0], err)
+	}
+
+	return nil
+}
@@ -1,36 +1,82 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"runtime"
 	"syscall"
+	"time"
 
 	"github.com/ottmartens/cc-rev-db/logger"
 	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/perf"
 	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"github.com/ottmartens/cc-rev-db/utils"
 	"github.com/ottmartens/cc-rev-db/utils/command"
+	"google.golang.org/grpc"
 )
 
 const MAIN_FN = "main"
 
+const RESOURCE_USAGE_REPORT_INTERVAL = 5 * time.Second
+
 type processContext struct {
-	targetFile     string           // the executing binary file
-	sourceFile     string           // source code file
-	dwarfData      *dwarf.DwarfData // dwarf debug information about the binary
-	process        *exec.Cmd        // the running binary
-	pid            int              // the process id of the running binary
-	bpointData     breakpointData   // holds the instuctions for currently replaced by breakpoints
-	cpointData     checkpointData   // holds data about currently recorded checkppoints
-	checkpointMode CheckpointMode   // whether checkpoints are recorded in files or in forked processes
-	stack          programStack     // current call stack of the target. updated after each command execution
-	nodeData       *nodeData        // data about connection with the orchestrator
+	targetFile   string           // the executing binary file
+	sourceFile   string           // source code file
+	originalFile string           // source file the target was compiled from by bin/compiler, empty if unknown
+	dwarfData    *dwarf.DwarfData // dwarf debug information about the binary
+	process      *exec.Cmd        // the running binary
+	pid          int              // the process id of the running binary
+	bpointData   breakpointData   // holds the instuctions for currently replaced by breakpoints
+	cpointData   checkpointData   // holds data about currently recorded checkppoints
+	checkpointer Checkpointer     // backend recording the memory state at checkpoints
+	snapshotPids []int            // snapshot processes forked from the target, holding the fork mode checkpoints
+	stack        programStack     // current call stack of the target. updated after each command execution
+	nodeData     *nodeData        // data about connection with the orchestrator
+	options      launchOptions    // options the debugger was launched with
+
+	replayedCheckpoints checkpointData           // checkpoints recorded before the last restore, re-executed during replay
+	instructionCounter  *perf.InstructionCounter // counter of retired instructions (nil if unavailable)
+	instructionOffset   uint64                   // instructions executed and rewound by checkpoint restores
+	signals             signalData               // signals received by the target
+	replayedSignals     signalData               // signals received after the last restored checkpoint, re-delivered during replay
+	caughtBreakpoint    *bpointData              // the user breakpoint the target is stopped at (nil if none)
+	liftedBreakpoint    *bpointData              // the caught breakpoint lifted out of the target, inserted again once stepped past (nil if none)
+	pendingRequests     requestData              // nonblocking MPI operations not completed yet
+	faultRules          faultRules               // faults injected at the MPI interception points
+	forcedSources       map[string]int           // source ranks forced on wildcard receives by their checkpoint id, applied on restore
+	pendingReceive      string                   // checkpoint id of the receive whose message is read at the next MPI call (empty if none)
+	payloadCap          int64                    // bytes of sent messages recorded with their events, the rest is recorded by its hash
+	hashedBuffers       []hashedBuffer           // buffers hashed at every checkpoint
+	pendingMessageLog   messageLog               // messages to replay after the next restore, if the node is rolled back alone
+	messageLog          messageLog               // messages the receives are replayed with, while a node rolled back alone replays (nil otherwise)
+	nanTrap             *nanTrap                 // trapping of invalid floating-point operations (nil if never enabled)
+	outputCatches       *outputCatches           // patterns of output lines the target stops at (nil if none)
+	fatalSignal         *fatalSignal             // the signal terminating the target it is stopped at, before its delivery (nil if none)
+	watchpoint          *watchpoint              // memory watched for writes while lastwrite re-executes (nil otherwise)
+	causalWatch         bool                     // whether the messages of blocking receives are checked against the causal breakpoints
+	sourceChecksums     map[string][16]byte      // MD5s of the source files recorded in the line tables, by their path (empty if none)
+	staleSources        []string                 // source files on this host not matching their recorded checksum
+	verifiedSources     int                      // source files on this host compared with their recorded checksum
+	interrupt           interruptState           // interruption of the running target by the orchestrator (all-stop)
+	jitSymbols          jitSymbols               // functions the target generated at runtime, named in backtraces
+	detachedBreakpoints breakpointData           // breakpoints lifted out of the target while the console is detached (nil if attached)
+	valueHandles        []dwarf.LocatedValue     // values expanded by front-ends, by their handle less one; released when the target moves
+	displays            displayList              // expressions evaluated and logged each time the target stops
+	skips               skipList                 // functions and files stepping does not descend into
+	completionScope     completionScope          // innermost frame of the call stack, read by completions on the rpc server
 }
 
 type nodeData struct {
-	id        int            // designated by the orchestrator
-	rpcClient *rpc.RPCClient // rpc client for communicating with the orchestrator
+	id        int                // designated by the orchestrator
+	currentId int                // id the orchestrator knows the node by, once renumbered by the rank of its target
+	rpcClient *rpc.RPCClient     // rpc client for communicating with the orchestrator
+	port      int                // port the node listens on, following the port of the orchestrator
+	host      string             // address the node listens on, of the interface it reaches the orchestrator through
+	events    chan rpc.NodeEvent // events queued for the orchestrator
 }
 
 func main() {
@@ -40,39 +86,108 @@ func main() {
 
 	precleanup()
 
-	targetFile, checkpointMode, orchestratorAddress, standaloneMode := getValuesFromArgs()
+	targetFile, checkpointer, orchestratorAddress, standaloneMode, options := getValuesFromArgs()
 
 	ctx := &processContext{
-		targetFile:     targetFile,
-		checkpointMode: checkpointMode,
-		bpointData:     breakpointData{}.New(),
-		cpointData:     checkpointData{}.New(),
+		targetFile:      targetFile,
+		checkpointer:    checkpointer,
+		options:         options,
+		payloadCap:      options.payloadCap,
+		bpointData:      breakpointData{}.New(),
+		cpointData:      checkpointData{}.New(),
+		pendingRequests: make(requestData),
+		forcedSources:   make(map[string]int),
 	}
 
 	if !standaloneMode {
+		// the token is not passed on to the target
+		rpc.SetSessionToken(os.Getenv(rpc.TOKEN_ENV))
+		os.Unsetenv(rpc.TOKEN_ENV)
+
 		// connect to orchestrator
 		ctx.nodeData = &nodeData{
 			rpcClient: rpc.Connect(orchestratorAddress),
+			host:      rpc.LocalHostTowards(orchestratorAddress),
+			events:    make(chan rpc.NodeEvent, EVENT_QUEUE_SIZE),
 		}
 
 		ctx.nodeData.id = reportAsHealthy(ctx)
-		logger.SetRemoteClient(ctx.nodeData.rpcClient, ctx.nodeData.id)
+		ctx.nodeData.currentId = ctx.nodeData.id
+		ctx.nodeData.port = rpc.NodePort(rpc.PortOf(orchestratorAddress), ctx.nodeData.id)
+		logger.SetRemoteClient(ctx.nodeData.rpcClient.Logger(), ctx.nodeData.id)
+		reconnectOnFailure(ctx)
 
 		logger.Info("Process (pid: %d) registered", os.Getpid())
 	}
 
-	// parse debugging data
-	ctx.dwarfData = dwarf.ParseDwarfData(ctx.targetFile)
-	ctx.dwarfData.ResolveMPIDebugInfo()
-	ctx.sourceFile = ctx.dwarfData.FindEntrySourceFile(MAIN_FN)
+	// the nodes of a session draw different ids from the same seed
+	if options.seed != 0 {
+		nodeId := 0
+		if ctx.nodeData != nil {
+			nodeId = ctx.nodeData.id
+		}
+		utils.SeedRandomIds(options.seed + int64(nodeId))
+	}
+
+	// targets not compiled with the MPI wrapper get it preloaded
+	preloadLibrary := mpiPreloadLibrary(ctx.targetFile)
+
+	// the interface takes over the terminal before the target writes to it
+	if standaloneMode && options.tui {
+		startTUI()
+	}
 
 	// start target binary
-	ctx.process = startBinary(ctx.targetFile)
+	ctx.process = startBinary(ctx.targetFile, ctx.options.disableASLR, preloadLibrary)
 	ctx.pid = ctx.process.Process.Pid
 
+	// launcher scripts must be followed to the binary they execute
+	if !isElfFile(ctx.targetFile) {
+		ctx.targetFile, ctx.pid = followExecToTarget(ctx)
+
+		if ctx.pid != ctx.process.Process.Pid {
+			ctx.process.Process, _ = os.FindProcess(ctx.pid)
+		}
+	}
+
+	startInstructionCounting(ctx)
+
+	// parse debugging data, or read it from the index of another rank of the binary on this host
+	dwarfData, shared, err := dwarf.LoadDwarfData(ctx.targetFile)
+	if err != nil {
+		exitWithError(ctx, err)
+	}
+	if shared {
+		logger.Verbose("debug info read from the index shared by the ranks on this host")
+	}
+	ctx.dwarfData = dwarfData
+	relocateToLoadAddress(ctx, ctx.targetFile, ctx.dwarfData)
+
+	if len(preloadLibrary) > 0 && !hasMPIWrapper(ctx.targetFile) {
+		loadPreloadedLibrary(ctx, preloadLibrary)
+	}
+
+	err = ctx.dwarfData.ResolveMPIDebugInfo()
+	if err != nil {
+		logger.Warn("%v, MPI calls are not recorded", err)
+	}
+	ctx.sourceFile = ctx.dwarfData.FindEntrySourceFile(MAIN_FN)
+	ctx.originalFile = readOriginalSourceFile(ctx)
+	ctx.staleSources, ctx.verifiedSources = verifySourceFiles(ctx)
+
+	if !standaloneMode {
+		reportMemoryLayout(ctx)
+		reportCapabilities(ctx)
+		reportFingerprint(ctx)
+	}
+
 	// set up automatic breakpoints
 	insertMPIBreakpoints(ctx)
 
+	if !ctx.options.noStopAtMain {
+		stopAtMain(ctx)
+	}
+
 	if standaloneMode {
 		handleCLIWorkflow(ctx)
 	} else {
@@ -86,14 +201,17 @@ func handleRemoteWorkflow(ctx *processContext) {
 	commandQueue := make(chan *command.Command, 10)
 
 	go func() {
-		port := 3500 + ctx.nodeData.id
-		rpc.InitializeServer(port, func(register rpc.Registrator) {
+		rpc.InitializeServer(ctx.nodeData.host, ctx.nodeData.port, func(server grpc.ServiceRegistrar) {
 			logger.Verbose("Registering debugging methods for remote use")
 
-			register(&RemoteCmdHandler{ctx, commandQueue})
+			pb.RegisterCommandHandlerServer(server, &RemoteCmdHandler{ctx: ctx, commandQueue: commandQueue})
+			pb.RegisterSourceServerServer(server, newSourceServer(ctx))
 		})
 	}()
 
+	go reportResourceUsage(ctx)
+	go streamEvents(ctx)
+
 	for {
 		cmd := <-commandQueue
 
@@ -108,14 +226,48 @@ func handleRemoteWorkflow(ctx *processContext) {
 	}
 }
 
+// Stops the target and exits, when the target cannot be debugged
+func exitWithError(ctx *processContext, err error) {
+	logger.Error("cannot debug %v: %v", ctx.targetFile, err)
+
+	if hint := utils.ErrorHint(utils.ErrorKind(err)); len(hint) > 0 {
+		logger.Error("%v", hint)
+	}
+
+	ctx.process.Process.Kill()
+	closeTUI()
+	os.Exit(1)
+}
+
 func handleCLIWorkflow(ctx *processContext) {
-	printInstructions()
+	setCompleter(ctx)
+	queueStartupCommands(ctx.options)
+
+	if tui != nil {
+		logger.Info(`Type "help" to see available commands`)
+		handleTUIWorkflow(ctx)
+		return
+	}
+
+	if ctx.options.json {
+		printJSONOutput()
+	} else {
+		printInstructions()
+	}
 
 	for {
 		cmd := askForInput()
 
 		handleCommand(ctx, cmd)
 
+		if ctx.options.json || cmd.JSON {
+			fmt.Println(command.FormatJSON(cmd))
+		}
+
+		if hint := utils.ErrorHint(cmd.Result.ErrorKind); len(hint) > 0 {
+			logger.Info("hint: %v", hint)
+		}
+
 		if cmd.Result.Exited { // binary exited
 			break
 		}
@@ -125,19 +277,35 @@ func handleCLIWorkflow(ctx *processContext) {
 type LoggerWriter struct{}
 
 func (l LoggerWriter) Write(p []byte) (n int, err error) {
-	logger.Verbose("Writing d bytes", len(p))
+	logger.Verbose("Writing %d bytes", len(p))
 	return os.Stdout.Write(p)
 }
 
 var pipe io.ReadCloser
 
-func startBinary(target string) *exec.Cmd {
+func startBinary(target string, disableASLR bool, preloadLibrary string) *exec.Cmd {
+
+	if disableASLR {
+		// the personality is inherited by the forked target
+		// (the thread is locked, so the fork happens from this thread)
+		restorePersonality := disableAddressRandomization()
+		defer restorePersonality()
+	}
 
 	cmd := exec.Command(target)
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
+	if len(preloadLibrary) > 0 {
+		preload := preloadLibrary
+		if existing := os.Getenv("LD_PRELOAD"); len(existing) > 0 {
+			preload = existing + ":" + preloadLibrary
+		}
+
+		cmd.Env = append(os.Environ(), "LD_PRELOAD="+preload)
+	}
+
 	pipe, _ = cmd.StdoutPipe()
 
 	cmd.SysProcAttr = &syscall.SysProcAttr{
@@ -155,3 +323,30 @@ func startBinary(target string) *exec.Cmd {
 
 	return cmd
 }
+
+const (
+	personalityQuery = 0xffffffff
+	addrNoRandomize  = 0x0040000 // ADDR_NO_RANDOMIZE
+)
+
+// Sets ADDR_NO_RANDOMIZE on the execution domain of the calling thread.
+// Returns a function for restoring the previous execution domain
+func disableAddressRandomization() (restore func()) {
+	oldPersonality, _, errno := syscall.RawSyscall(syscall.SYS_PERSONALITY, personalityQuery, 0, 0)
+	if errno != 0 {
+		logger.Warn("cannot query process personality, ASLR stays enabled: %v", errno)
+		return func() {}
+	}
+
+	_, _, errno = syscall.RawSyscall(syscall.SYS_PERSONALITY, oldPersonality|addrNoRandomize, 0, 0)
+	if errno != 0 {
+		logger.Warn("cannot disable ASLR: %v", errno)
+		return func() {}
+	}
+
+	logger.Verbose("ASLR disabled for the target")
+
+	return func() {
+		syscall.RawSyscall(syscall.SYS_PERSONALITY, oldPersonality, 0, 0)
+	}
+}
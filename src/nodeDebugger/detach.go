@@ -0,0 +1,51 @@
+package main
+
+import (
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// Detaching the console from the node: the target runs at full speed until the orchestrator reattaches, stopping
+// only at the MPI breakpoints, which record its events and checkpoints. The user breakpoints are lifted out of the
+// target meanwhile and inserted again when it is reattached, so the event log, the checkpoints and the breakpoints
+// continue across the gap
+
+// Removes all but the MPI breakpoints from the target, keeping them for reattachBreakpoints
+func detachBreakpoints(ctx *processContext) {
+	ctx.detachedBreakpoints = breakpointData{}.New()
+
+	for address, bpoint := range ctx.bpointData {
+		if bpoint.isMPIBpoint || bpoint.causalReceive {
+			continue
+		}
+
+		_, err := syscall.PtracePokeData(ctx.pid, uintptr(address), bpoint.originalInstruction)
+		utils.Must(err)
+
+		delete(ctx.bpointData, address)
+		ctx.detachedBreakpoints[address] = bpoint
+	}
+
+	// the caught breakpoint is inserted again with the others
+	if lifted := ctx.liftedBreakpoint; lifted != nil && ctx.bpointData[lifted.address] == nil {
+		ctx.detachedBreakpoints[lifted.address] = lifted
+	}
+	ctx.liftedBreakpoint = nil
+
+	logger.Info("detached, running with %d breakpoints lifted until reattached", len(ctx.detachedBreakpoints))
+}
+
+// Inserts the breakpoints lifted by detachBreakpoints again
+func reattachBreakpoints(ctx *processContext) {
+	for _, bpoint := range ctx.detachedBreakpoints {
+		if err := rearmBreakpoint(ctx, bpoint); err != nil {
+			logger.Warn("breakpoint not inserted again: %v", err)
+		}
+	}
+
+	logger.Info("reattached, %d breakpoints inserted again", len(ctx.detachedBreakpoints))
+
+	ctx.detachedBreakpoints = nil
+}
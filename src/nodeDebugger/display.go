@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// Displayed expressions (display[/<format>] <expr>), evaluated and logged each time the target stops after a step,
+// continue, reverse execution or restore, to follow some state while moving through the program. undisplay removes them
+
+type display struct {
+	number     int // number the display is removed by, counted from 1
+	format     byte
+	expression *expression
+}
+
+func (d *display) String() string {
+	if d.format != 0 {
+		return fmt.Sprintf("%d: /%c %v", d.number, d.format, d.expression.source)
+	}
+	return fmt.Sprintf("%d: %v", d.number, d.expression.source)
+}
+
+type displayList struct {
+	displays []*display
+	numbered int // displays added so far, the number of the last one
+}
+
+// Adds an expression displayed at every stop, printing its value now. Without an expression, the values
+// of all displays are printed. Returns the values printed
+func addDisplay(ctx *processContext, argument string) (string, error) {
+	argument = strings.TrimSpace(argument)
+
+	if len(argument) == 0 {
+		return showDisplays(ctx), nil
+	}
+
+	format, source, err := parsePrintFormat(argument)
+	if err != nil {
+		return "", err
+	}
+
+	parsed, err := parseExpression(source)
+	if err != nil {
+		return "", err
+	}
+
+	ctx.displays.numbered++
+
+	added := &display{number: ctx.displays.numbered, format: format, expression: parsed}
+	ctx.displays.displays = append(ctx.displays.displays, added)
+
+	return showDisplay(ctx, added), nil
+}
+
+// Removes the displays of the numbers, all displays without numbers
+func removeDisplays(ctx *processContext, argument string) error {
+	if len(strings.TrimSpace(argument)) == 0 {
+		logger.Info("%d displays removed", len(ctx.displays.displays))
+		ctx.displays.displays = nil
+		return nil
+	}
+
+	for _, field := range strings.Fields(argument) {
+		number, err := strconv.Atoi(field)
+		if err != nil {
+			return fmt.Errorf("invalid display number %v", field)
+		}
+
+		index := -1
+		for i, existing := range ctx.displays.displays {
+			if existing.number == number {
+				index = i
+			}
+		}
+
+		if index < 0 {
+			return fmt.Errorf("no display number %d", number)
+		}
+
+		ctx.displays.displays = append(ctx.displays.displays[:index], ctx.displays.displays[index+1:]...)
+		logger.Info("display %d removed", number)
+	}
+
+	return nil
+}
+
+// Evaluates and logs the displays where the target stopped. Returns the lines logged
+func showDisplays(ctx *processContext) string {
+	lines := make([]string, 0, len(ctx.displays.displays))
+
+	for _, shown := range ctx.displays.displays {
+		lines = append(lines, showDisplay(ctx, shown))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func showDisplay(ctx *processContext, shown *display) string {
+	var line string
+
+	value, err := shown.expression.root.evaluate(ctx)
+	if err != nil {
+		line = fmt.Sprintf("%v = <%v>", shown, err)
+	} else {
+		line = fmt.Sprintf("%v = %v", shown, formatExpressionValueAs(ctx, value, shown.format))
+	}
+
+	logger.Info("%s", line)
+
+	return line
+}
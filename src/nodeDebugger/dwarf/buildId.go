@@ -0,0 +1,66 @@
+package dwarf
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const noteTypeGnuBuildId = 3 // NT_GNU_BUILD_ID
+
+// Returns the build-id the linker stored in the binary (.note.gnu.build-id), in hex
+func BuildId(binaryFile string) (string, error) {
+	elfFile, err := elf.Open(binaryFile)
+	if err != nil {
+		return "", err
+	}
+	defer elfFile.Close()
+
+	section := elfFile.Section(".note.gnu.build-id")
+	if section == nil {
+		return "", fmt.Errorf("%v has no build-id", binaryFile)
+	}
+
+	note, err := section.Data()
+	if err != nil {
+		return "", err
+	}
+
+	// namesz(4) descsz(4) type(4) name, padded to 4 bytes, then desc
+	if len(note) < 12 {
+		return "", fmt.Errorf("malformed build-id note in %v", binaryFile)
+	}
+
+	nameSize := binary.LittleEndian.Uint32(note[0:4])
+	descSize := binary.LittleEndian.Uint32(note[4:8])
+	noteType := binary.LittleEndian.Uint32(note[8:12])
+
+	descStart := 12 + (nameSize+3)&^3
+
+	if noteType != noteTypeGnuBuildId || uint32(len(note)) < descStart+descSize {
+		return "", fmt.Errorf("malformed build-id note in %v", binaryFile)
+	}
+
+	return hex.EncodeToString(note[descStart : descStart+descSize]), nil
+}
+
+// Returns the MPI library the binary is dynamically linked against (e.g. libmpi.so.40), empty if none
+func LinkedMPILibrary(binaryFile string) string {
+	elfFile, err := elf.Open(binaryFile)
+	if err != nil {
+		return ""
+	}
+	defer elfFile.Close()
+
+	libraries, _ := elfFile.ImportedLibraries()
+
+	for _, library := range libraries {
+		if strings.HasPrefix(library, "libmpi.") || strings.HasPrefix(library, "libmpich.") {
+			return library
+		}
+	}
+
+	return ""
+}
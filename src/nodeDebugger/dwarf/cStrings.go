@@ -0,0 +1,176 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"strings"
+)
+
+// C strings: char pointers and char arrays are printed as the text they hold, up to the terminating NUL,
+// the length of the array or maxCStringLength characters
+
+const encodingUnsignedChar = 0x08
+
+const (
+	maxCStringLength = 256 // characters shown of a C string
+	cStringChunk     = 64  // bytes read at once, aligned so that reads never cross into an unmapped page
+)
+
+// Whether the type is a character of C (char, signed char, unsigned char) or Fortran (character)
+func (t *BaseType) isCharacter() bool {
+	t = t.resolved()
+	return t != nil && t.tag == 0 && t.goKind == 0 && t.byteSize == 1 && (t.encoding == encodingSignedChar || t.encoding == encodingUnsignedChar)
+}
+
+// Returns whether the variable is a C string: a pointer to characters or a one-dimensional array of them
+func (v *Variable) IsString() bool {
+	variableType := v.baseType.resolved()
+
+	switch {
+	case variableType.goKind != 0 || !variableType.elemType.isCharacter():
+		return false
+	case variableType.tag == dwarf.TagPointerType:
+		return true
+	}
+
+	return variableType.tag == dwarf.TagArrayType && len(variableType.dimensions) == 1 && !variableType.columnMajor
+}
+
+// Reads the text of a C string variable located at the address: of the pointer, or of the array
+func (v *Variable) ReadString(address uint64, readMemory ReadMemoryFunc) (string, error) {
+	if !v.IsString() {
+		return "", fmt.Errorf("%s of type %s is not a string", v.name, describeType(v.baseType))
+	}
+
+	variableType := v.baseType.resolved()
+	limit := int64(maxCStringLength)
+
+	if variableType.tag == dwarf.TagPointerType {
+		formatter := goValueFormatter{readMemory: readMemory}
+
+		pointer, err := formatter.readPointer(address)
+		if err != nil {
+			return "", err
+		}
+		if pointer == 0 {
+			return "", fmt.Errorf("%s is a null pointer", v.name)
+		}
+
+		address = pointer
+	} else if count := variableType.dimensions[0].count; count >= 0 && count < limit {
+		limit = count
+	}
+
+	text, _, err := readCString(address, limit, readMemory)
+
+	return string(text), err
+}
+
+// Formats a pointer computed by the debugger, e.g. of &x or p + 1, with the string it points to if a char pointer
+func (d *DwarfData) FormatPointer(variable *Variable, pointer uint64, readMemory ReadMemoryFunc) string {
+	pointerType := variable.baseType.resolved()
+
+	if pointerType.tag == dwarf.TagPointerType && pointerType.elemType.isCharacter() {
+		return goValueFormatter{d, readMemory, 0}.formatStringPointer(variable.baseType, pointer)
+	}
+
+	return fmt.Sprintf("(%s) %#x", describeType(variable.baseType), pointer)
+}
+
+// Formats the C string a char pointer points to, quoted, after the pointer
+func (f goValueFormatter) formatStringPointer(pointerType *BaseType, pointer uint64) string {
+	if pointer == 0 {
+		return fmt.Sprintf("(%s) 0x0", describeType(pointerType))
+	}
+
+	text, truncated, err := readCString(pointer, maxCStringLength, f.readMemory)
+	if err != nil && len(text) == 0 {
+		return fmt.Sprintf("(%s) %#x <unreadable string: %v>", describeType(pointerType), pointer, err)
+	}
+
+	return fmt.Sprintf("(%s) %#x %s", describeType(pointerType), pointer, quoteCString(text, truncated))
+}
+
+// Formats a char array as a quoted C string, up to its first NUL
+func (f goValueFormatter) formatStringArray(address uint64, count int64) string {
+	limit := count
+	if limit > maxCStringLength {
+		limit = maxCStringLength
+	}
+
+	text, truncated, err := readCString(address, limit, f.readMemory)
+	if err != nil && len(text) == 0 {
+		return unreadable(address, err)
+	}
+
+	return quoteCString(text, truncated && count > maxCStringLength)
+}
+
+// Reads the characters at the address up to a NUL, at most limit of them. Returns whether the limit cut the string short
+func readCString(address uint64, limit int64, readMemory ReadMemoryFunc) (text []byte, truncated bool, err error) {
+	text = make([]byte, 0)
+
+	for int64(len(text)) < limit {
+		chunk := make([]byte, cStringChunk-address%cStringChunk)
+		if remaining := limit - int64(len(text)); int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+
+		if _, err := readMemory(chunk, address); err != nil {
+			return text, false, err
+		}
+
+		for _, character := range chunk {
+			if character == 0 {
+				return text, false, nil
+			}
+			text = append(text, character)
+		}
+
+		address += uint64(len(chunk))
+	}
+
+	return text, true, nil
+}
+
+// Quotes the text of a C string, escaping quotes, backslashes and non-printable characters as C does
+func quoteCString(text []byte, truncated bool) string {
+	var builder strings.Builder
+
+	builder.WriteByte('"')
+
+	for _, character := range text {
+		builder.WriteString(escapeCharacter(character, '"'))
+	}
+
+	builder.WriteByte('"')
+
+	if truncated {
+		builder.WriteString("...")
+	}
+
+	return builder.String()
+}
+
+// Returns a character as written in a C literal quoted with the quote, e.g. \n, \" or \310
+func escapeCharacter(character byte, quote byte) string {
+	switch {
+	case character == quote || character == '\\':
+		return `\` + string(character)
+	case character == '\n':
+		return `\n`
+	case character == '\t':
+		return `\t`
+	case character == '\r':
+		return `\r`
+	case character < 0x20 || character >= 0x7f:
+		return fmt.Sprintf(`\%03o`, character)
+	}
+
+	return string(character)
+}
+
+// Formats a character by its code and the character, e.g. 65 'A'
+func formatCharacter(code int64) string {
+	return fmt.Sprintf("%d '%s'", code, escapeCharacter(byte(code), '\''))
+}
@@ -0,0 +1,350 @@
+package dwarf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"path"
+)
+
+// line table content types and attribute forms of the file entries of DWARF 5 line table headers
+const (
+	lnctPath           = 0x1
+	lnctDirectoryIndex = 0x2
+	lnctMD5            = 0x5
+
+	formBlock    = 0x09
+	formBlock1   = 0x0a
+	formData1    = 0x0b
+	formData2    = 0x05
+	formData4    = 0x06
+	formData8    = 0x07
+	formData16   = 0x1e
+	formString   = 0x08
+	formStrp     = 0x0e
+	formLineStrp = 0x1f
+	formUdata    = 0x0f
+	formStrx     = 0x1a
+	formStrx1    = 0x25
+	formStrx2    = 0x26
+	formStrx4    = 0x28
+)
+
+// Returns the MD5 checksums of the source files recorded in the DWARF 5 line tables of the binary, by the path
+// the line tables name the file with. Compilers record them optionally (clang does, gcc does not), files
+// without a checksum (or with a zero one) are left out
+func FileChecksums(binaryFile string) (map[string][16]byte, error) {
+	elfFile, err := elf.Open(binaryFile)
+	if err != nil {
+		return nil, err
+	}
+	defer elfFile.Close()
+
+	lineSection, err := readDebugSection(elfFile, ".debug_line")
+	if err != nil || lineSection == nil {
+		return nil, err
+	}
+
+	names := lineTableStrings{}
+	if names.lineStr, err = readDebugSection(elfFile, ".debug_line_str"); err != nil {
+		return nil, err
+	}
+	if names.str, err = readDebugSection(elfFile, ".debug_str"); err != nil {
+		return nil, err
+	}
+
+	checksums := make(map[string][16]byte)
+
+	for offset := 0; offset < len(lineSection); {
+		reader := &lineTableReader{data: lineSection, offset: offset}
+
+		unitLength := uint64(reader.uint32())
+		if unitLength == 0xffffffff {
+			reader.offsetSize = 8
+			unitLength = reader.uint64()
+		} else {
+			reader.offsetSize = 4
+		}
+
+		end := reader.offset + int(unitLength)
+		if reader.err != nil || end > len(lineSection) || end <= offset {
+			return checksums, fmt.Errorf("malformed line table at offset %#x", offset)
+		}
+
+		version := reader.uint16()
+		if version >= 5 {
+			reader.end = end
+			readFileChecksums(reader, names, checksums)
+
+			if reader.err != nil {
+				return checksums, fmt.Errorf("malformed line table at offset %#x: %v", offset, reader.err)
+			}
+		}
+
+		offset = end
+	}
+
+	return checksums, nil
+}
+
+// Returns whether the contents of a source file have the checksum of its line table entry. The checksum is
+// the MD5 digest in byte order as clang emits it, GNU as stores the digest of .file directives as a little-endian number
+func ChecksumMatches(checksum [16]byte, contents []byte) bool {
+	digest := md5.Sum(contents)
+	if digest == checksum {
+		return true
+	}
+
+	for index := range digest {
+		if digest[index] != checksum[len(checksum)-1-index] {
+			return false
+		}
+	}
+	return true
+}
+
+// Reads the file entries of a DWARF 5 line table header, after its version
+func readFileChecksums(reader *lineTableReader, names lineTableStrings, checksums map[string][16]byte) {
+	reader.skip(2) // address_size, segment_selector_size
+	reader.offsetValue()
+	reader.skip(5) // minimum_instruction_length, maximum_operations_per_instruction, default_is_stmt, line_base, line_range
+
+	opcodeBase := int(reader.uint8())
+	reader.skip(opcodeBase - 1)
+
+	directories := make([]string, 0)
+	for _, entry := range reader.entries(names) {
+		directories = append(directories, entry.path)
+	}
+
+	for _, entry := range reader.entries(names) {
+		// assemblers record zeros for the files given without a checksum
+		if !entry.hasMD5 || entry.md5 == [16]byte{} {
+			continue
+		}
+
+		name := entry.path
+		if !path.IsAbs(name) && entry.directory < len(directories) {
+			directory := directories[entry.directory]
+
+			// directories other than the compilation directory may be relative to it
+			if !path.IsAbs(directory) && len(directories) > 0 {
+				directory = path.Join(directories[0], directory)
+			}
+			name = path.Join(directory, name)
+		}
+
+		checksums[name] = entry.md5
+	}
+}
+
+type lineTableStrings struct {
+	lineStr []byte // .debug_line_str
+	str     []byte // .debug_str
+}
+
+// An entry of the directory or file name table of a line table header
+type lineTableEntry struct {
+	path      string
+	directory int
+	md5       [16]byte
+	hasMD5    bool
+}
+
+type lineTableReader struct {
+	data       []byte
+	offset     int
+	end        int
+	offsetSize int
+	err        error
+}
+
+// Reads an entry format description followed by the entries it describes
+func (reader *lineTableReader) entries(names lineTableStrings) []lineTableEntry {
+	formatCount := int(reader.uint8())
+
+	format := make([][2]uint64, formatCount)
+	for index := range format {
+		format[index] = [2]uint64{reader.uleb(), reader.uleb()}
+	}
+
+	count := int(reader.uleb())
+	entries := make([]lineTableEntry, 0)
+
+	for index := 0; index < count && reader.err == nil; index++ {
+		var entry lineTableEntry
+
+		for _, field := range format {
+			contentType, form := field[0], field[1]
+
+			switch {
+			case contentType == lnctPath:
+				entry.path = reader.stringValue(form, names)
+			case contentType == lnctDirectoryIndex:
+				entry.directory = int(reader.numberValue(form))
+			case contentType == lnctMD5 && form == formData16:
+				copy(entry.md5[:], reader.bytes(16))
+				entry.hasMD5 = true
+			default:
+				reader.skipValue(form)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func (reader *lineTableReader) stringValue(form uint64, names lineTableStrings) string {
+	switch form {
+	case formString:
+		value := reader.data[reader.offset:]
+		length := bytes.IndexByte(value, 0)
+		if length < 0 {
+			reader.fail()
+			return ""
+		}
+		reader.offset += length + 1
+		return string(value[:length])
+	case formLineStrp:
+		return stringAt(names.lineStr, reader.offsetValue())
+	case formStrp:
+		return stringAt(names.str, reader.offsetValue())
+	default:
+		// string offsets (strx) need the base of the unit, such paths are left unnamed
+		reader.skipValue(form)
+		return ""
+	}
+}
+
+func (reader *lineTableReader) numberValue(form uint64) uint64 {
+	switch form {
+	case formData1:
+		return uint64(reader.uint8())
+	case formData2:
+		return uint64(reader.uint16())
+	case formData4:
+		return uint64(reader.uint32())
+	case formData8:
+		return reader.uint64()
+	case formUdata:
+		return reader.uleb()
+	default:
+		reader.skipValue(form)
+		return 0
+	}
+}
+
+func (reader *lineTableReader) skipValue(form uint64) {
+	switch form {
+	case formString:
+		reader.stringValue(form, lineTableStrings{})
+	case formData1, formStrx1:
+		reader.skip(1)
+	case formData2, formStrx2:
+		reader.skip(2)
+	case formData4, formStrx4:
+		reader.skip(4)
+	case formData8:
+		reader.skip(8)
+	case formData16:
+		reader.skip(16)
+	case formUdata, formStrx:
+		reader.uleb()
+	case formLineStrp, formStrp:
+		reader.offsetValue()
+	case formBlock:
+		reader.skip(int(reader.uleb()))
+	case formBlock1:
+		reader.skip(int(reader.uint8()))
+	default:
+		reader.err = fmt.Errorf("unsupported form %#x in file entries", form)
+	}
+}
+
+func (reader *lineTableReader) bytes(count int) []byte {
+	limit := len(reader.data)
+	if reader.end > 0 {
+		limit = reader.end
+	}
+
+	if count < 0 {
+		reader.fail()
+		return nil
+	}
+
+	if reader.err != nil || reader.offset+count > limit {
+		reader.fail()
+		return make([]byte, count)
+	}
+
+	value := reader.data[reader.offset : reader.offset+count]
+	reader.offset += count
+	return value
+}
+
+func (reader *lineTableReader) skip(count int) {
+	reader.bytes(count)
+}
+
+func (reader *lineTableReader) uint8() uint8 {
+	return reader.bytes(1)[0]
+}
+
+func (reader *lineTableReader) uint16() uint16 {
+	return binary.LittleEndian.Uint16(reader.bytes(2))
+}
+
+func (reader *lineTableReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(reader.bytes(4))
+}
+
+func (reader *lineTableReader) uint64() uint64 {
+	return binary.LittleEndian.Uint64(reader.bytes(8))
+}
+
+// a section offset, 4 or 8 bytes in the 32-bit and 64-bit DWARF formats
+func (reader *lineTableReader) offsetValue() uint64 {
+	if reader.offsetSize == 8 {
+		return reader.uint64()
+	}
+	return uint64(reader.uint32())
+}
+
+func (reader *lineTableReader) uleb() uint64 {
+	var value uint64
+
+	for shift := uint(0); ; shift += 7 {
+		b := reader.uint8()
+		if reader.err != nil {
+			return 0
+		}
+
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value
+		}
+	}
+}
+
+func (reader *lineTableReader) fail() {
+	if reader.err == nil {
+		reader.err = fmt.Errorf("truncated at offset %#x", reader.offset)
+	}
+}
+
+func stringAt(section []byte, offset uint64) string {
+	if offset >= uint64(len(section)) {
+		return ""
+	}
+
+	value := section[offset:]
+	if end := bytes.IndexByte(value, 0); end >= 0 {
+		value = value[:end]
+	}
+
+	return string(value)
+}
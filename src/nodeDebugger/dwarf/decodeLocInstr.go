@@ -70,6 +70,7 @@ func ExecuteStackProgram(regs DwarfRegisters, instructions []byte, ptrSize int,
 		stack:          make([]int64, 0, 3),
 		DwarfRegisters: regs,
 		ptrSize:        ptrSize,
+		readMemory:     readMemory,
 	}
 
 	for tick := 0; tick < len(instructions)*arbitraryExecutionLimitFactor; tick++ {
@@ -130,27 +131,76 @@ func framebase(opcode Opcode, ctxt *context) error {
 	return nil
 }
 
+func constu(opcode Opcode, ctxt *context) error {
+	num, _ := DecodeULEB128(ctxt.buf)
+	ctxt.stack = append(ctxt.stack, int64(num))
+	return nil
+}
+
+func deref(opcode Opcode, ctxt *context) error {
+	if len(ctxt.stack) == 0 {
+		return errors.New("empty OP stack")
+	}
+	if ctxt.readMemory == nil {
+		return errors.New("could not dereference, memory of the target not available")
+	}
+
+	buf := make([]byte, ctxt.ptrSize)
+
+	_, err := ctxt.readMemory(buf, uint64(ctxt.stack[len(ctxt.stack)-1]))
+	if err != nil {
+		return err
+	}
+
+	value, err := ReadUintRaw(bytes.NewReader(buf), binary.LittleEndian, ctxt.ptrSize)
+	if err != nil {
+		return err
+	}
+
+	ctxt.stack[len(ctxt.stack)-1] = int64(value)
+	return nil
+}
+
 const (
 	DW_OP_addr           Opcode = 0x03
+	DW_OP_deref          Opcode = 0x06
+	DW_OP_constu         Opcode = 0x10
 	DW_OP_fbreg          Opcode = 0x91
 	DW_OP_nop            Opcode = 0x96
 	DW_OP_call_frame_cfa Opcode = 0x9c
+	DW_OP_addrx          Opcode = 0xa1
+	DW_OP_constx         Opcode = 0xa2
+	DW_OP_GNU_addr_index Opcode = 0xfb
 )
 
 var opcodeName = map[Opcode]string{
 	DW_OP_addr:           "DW_OP_addr",
+	DW_OP_deref:          "DW_OP_deref",
+	DW_OP_constu:         "DW_OP_constu",
 	DW_OP_fbreg:          "DW_OP_fbreg",
 	DW_OP_call_frame_cfa: "DW_OP_call_frame_cfa",
+	DW_OP_addrx:          "DW_OP_addrx",
+	DW_OP_constx:         "DW_OP_constx",
+	DW_OP_GNU_addr_index: "DW_OP_GNU_addr_index",
 }
 var opcodeArgs = map[Opcode]string{
 	DW_OP_addr:           "8",
+	DW_OP_deref:          "",
+	DW_OP_constu:         "u",
 	DW_OP_fbreg:          "s",
 	DW_OP_call_frame_cfa: "",
+	DW_OP_addrx:          "u",
+	DW_OP_constx:         "u",
+	DW_OP_GNU_addr_index: "u",
 }
 
 var oplut = map[Opcode]stackfn{
 	DW_OP_addr: addr,
 
+	DW_OP_deref: deref,
+
+	DW_OP_constu: constu,
+
 	DW_OP_fbreg: framebase,
 
 	DW_OP_call_frame_cfa: callframecfa,
@@ -199,6 +249,56 @@ func DecodeSLEB128(buf ByteReaderWithLen) (int64, uint32) {
 	return result, length
 }
 
+// DecodeULEB128 decodes an unsigned Little Endian Base 128
+// represented number.
+func DecodeULEB128(buf ByteReaderWithLen) (uint64, uint32) {
+	var (
+		result uint64
+		shift  uint64
+		length uint32
+	)
+
+	if buf.Len() == 0 {
+		return 0, 0
+	}
+
+	for {
+		b, err := buf.ReadByte()
+		if err != nil {
+			panic("Could not parse ULEB128 value")
+		}
+		length++
+
+		result |= uint64((uint(b) & 0x7f) << shift)
+
+		// If high order bit is 1.
+		if b&0x80 == 0 {
+			break
+		}
+
+		shift += 7
+	}
+
+	return result, length
+}
+
+// EncodeULEB128 encodes x to the unsigned Little Endian Base 128 format
+func EncodeULEB128(x uint64) []byte {
+	encoded := make([]byte, 0)
+
+	for {
+		b := byte(x & 0x7f)
+		x >>= 7
+		if x != 0 {
+			b |= 0x80
+		}
+		encoded = append(encoded, b)
+		if x == 0 {
+			return encoded
+		}
+	}
+}
+
 func ReadUintRaw(reader io.Reader, order binary.ByteOrder, ptrSize int) (uint64, error) {
 	switch ptrSize {
 	case 2:
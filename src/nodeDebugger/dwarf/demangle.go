@@ -0,0 +1,753 @@
+package dwarf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Demangling of C++ symbol names following the Itanium C++ ABI (as used by gcc and clang).
+// The common subset used by MPI codes is supported: namespaces, classes, templates,
+// constructors and destructors, operators, local entities and lambdas
+
+// A demangled C++ symbol
+type demangledName struct {
+	qualifiedName string // the name with its enclosing namespaces and classes, e.g. ns::Solver::step
+	signature     string // the qualified name with the parameter types, e.g. ns::Solver::step(double) const
+}
+
+// Returns the demangled signature of a C++ symbol, or the symbol itself if it is not mangled
+func Demangle(symbol string) string {
+	demangled, err := demangle(symbol)
+	if err != nil {
+		return symbol
+	}
+	return demangled.signature
+}
+
+func demangle(symbol string) (demangled demangledName, err error) {
+	if !strings.HasPrefix(symbol, "_Z") {
+		return demangled, fmt.Errorf("%s is not a mangled name", symbol)
+	}
+
+	// vendor suffixes (e.g. .constprop.0, .cold) are not part of the mangling
+	mangled := symbol[2:]
+	if index := strings.Index(mangled, "."); index > 0 {
+		mangled = mangled[:index]
+	}
+
+	d := &demangler{input: mangled}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("unable to demangle %s: %v", symbol, recovered)
+		}
+	}()
+
+	name, signature := d.encoding(true)
+
+	if d.position != len(d.input) {
+		return demangled, fmt.Errorf("unable to demangle %s: unexpected %q", symbol, d.input[d.position:])
+	}
+
+	return demangledName{name, signature}, nil
+}
+
+type demangler struct {
+	input         string
+	position      int
+	substitutions []string // components available for back-references (S_, S0_, ...)
+	templateArgs  []string // arguments of the enclosing template, referred to by T_, T0_, ...
+	typeDepth     int      // nesting of the types being parsed, template arguments within types are not referred to
+}
+
+func (d *demangler) fail(format string, args ...any) {
+	panic(fmt.Sprintf(format, args...))
+}
+
+func (d *demangler) peek() byte {
+	if d.position >= len(d.input) {
+		return 0
+	}
+	return d.input[d.position]
+}
+
+func (d *demangler) peekString(prefix string) bool {
+	return strings.HasPrefix(d.input[d.position:], prefix)
+}
+
+func (d *demangler) consume(prefix string) bool {
+	if d.peekString(prefix) {
+		d.position += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (d *demangler) expect(prefix string) {
+	if !d.consume(prefix) {
+		d.fail("expected %q at %d", prefix, d.position)
+	}
+}
+
+func (d *demangler) atEnd() bool {
+	return d.position >= len(d.input) || d.peek() == 'E' || d.peek() == '.'
+}
+
+// <encoding> ::= <name> <bare-function-type> | <name> | <special-name>
+// The return type of template functions is left out of the signature unless withReturnType is set
+func (d *demangler) encoding(withReturnType bool) (name string, signature string) {
+	if d.peek() == 'T' || d.peekString("GV") {
+		special := d.specialName()
+		return special, special
+	}
+
+	name, isTemplate, isCtorDtor, cv := d.name()
+
+	if d.atEnd() {
+		return name, name
+	}
+
+	// template functions (other than constructors and destructors) have their return type encoded
+	returnType := ""
+	if isTemplate && !isCtorDtor {
+		returnType = d.typeName() + " "
+	}
+
+	if !withReturnType {
+		returnType = ""
+	}
+
+	return name, returnType + name + d.parameters() + cv
+}
+
+// <special-name> ::= TV <type> | TT <type> | TI <type> | TS <type> | GV <name> | Th <offset> _ <encoding> | Tv ...
+func (d *demangler) specialName() string {
+	switch {
+	case d.consume("TV"):
+		return "vtable for " + d.typeName()
+	case d.consume("TT"):
+		return "VTT for " + d.typeName()
+	case d.consume("TI"):
+		return "typeinfo for " + d.typeName()
+	case d.consume("TS"):
+		return "typeinfo name for " + d.typeName()
+	case d.consume("GV"):
+		name, _, _, _ := d.name()
+		return "guard variable for " + name
+	case d.consume("Th"):
+		d.consume("n")
+		d.number()
+		d.expect("_")
+		_, signature := d.encoding(true)
+		return "non-virtual thunk to " + signature
+	case d.consume("Tv"):
+		d.consume("n")
+		d.number()
+		d.expect("_")
+		d.consume("n")
+		d.number()
+		d.expect("_")
+		_, signature := d.encoding(true)
+		return "virtual thunk to " + signature
+	}
+
+	d.fail("unsupported special name at %d", d.position)
+	return ""
+}
+
+// The parameter types of a function, v (void) stands for no parameters
+func (d *demangler) parameters() string {
+	if d.consume("v") && d.atEnd() {
+		return "()"
+	}
+
+	parameters := make([]string, 0)
+
+	for !d.atEnd() {
+		parameters = append(parameters, d.typeName())
+	}
+
+	return "(" + strings.Join(parameters, ", ") + ")"
+}
+
+// <name> ::= <nested-name> | <unscoped-name> [<template-args>] | <local-name> | <substitution> <template-args>
+func (d *demangler) name() (name string, isTemplate bool, isCtorDtor bool, cv string) {
+	switch {
+	case d.peek() == 'N':
+		return d.nestedName()
+
+	case d.peek() == 'Z':
+		return d.localName()
+
+	case d.peek() == 'S' && !d.peekString("St"):
+		name = d.substitution()
+		if d.peek() != 'I' {
+			d.fail("expected template arguments after substitution at %d", d.position)
+		}
+		return d.withTemplateArgs(name), true, false, ""
+	}
+
+	prefix := ""
+	if d.consume("St") {
+		prefix = "std::"
+	}
+
+	name, isCtorDtor = d.unqualifiedName("")
+	name = prefix + name
+
+	if d.peek() == 'I' {
+		d.substitutions = append(d.substitutions, name)
+		name = d.withTemplateArgs(name)
+		isTemplate = true
+	}
+
+	return name, isTemplate, isCtorDtor, ""
+}
+
+// <nested-name> ::= N [<CV-qualifiers>] [<ref-qualifier>] <prefix> <unqualified-name> E
+func (d *demangler) nestedName() (name string, isTemplate bool, isCtorDtor bool, cv string) {
+	d.expect("N")
+
+	cv = d.cvQualifiers()
+	if d.consume("R") {
+		cv += " &"
+	} else if d.consume("O") {
+		cv += " &&"
+	}
+
+	components := ""
+
+	for !d.consume("E") {
+		isTemplate = false
+
+		switch {
+		case d.peek() == 'S' && !d.peekString("St"):
+			components = d.substitution()
+			continue
+
+		case d.consume("St"):
+			components = "std"
+			continue
+
+		case d.peek() == 'T':
+			components = d.templateParam()
+			isCtorDtor = false
+
+		// template arguments of a constructor leave it a constructor
+		case d.peek() == 'I':
+			components = d.withTemplateArgs(components)
+			isTemplate = true
+
+		default:
+			var component string
+			component, isCtorDtor = d.unqualifiedName(lastComponent(components))
+
+			if len(components) > 0 {
+				components += "::"
+			}
+			components += component
+		}
+
+		// every prefix of the name can be referred to later, except the name itself
+		if d.peek() != 'E' {
+			d.substitutions = append(d.substitutions, components)
+		}
+	}
+
+	return components, isTemplate, isCtorDtor, cv
+}
+
+// <local-name> ::= Z <encoding> E <name> [<discriminator>] | Z <encoding> E s [<discriminator>]
+func (d *demangler) localName() (name string, isTemplate bool, isCtorDtor bool, cv string) {
+	d.expect("Z")
+
+	_, function := d.encoding(false)
+
+	d.expect("E")
+
+	if d.consume("s") {
+		d.discriminator()
+		return function + "::string literal", false, false, ""
+	}
+
+	entity, isTemplate, isCtorDtor, cv := d.name()
+
+	d.discriminator()
+
+	return function + "::" + entity, isTemplate, isCtorDtor, cv
+}
+
+// <discriminator> ::= _ <digit> | __ <number> _
+func (d *demangler) discriminator() {
+	if !d.consume("_") {
+		return
+	}
+	if d.consume("_") {
+		d.number()
+		d.expect("_")
+		return
+	}
+	d.number()
+}
+
+// <unqualified-name> ::= <operator-name> | <ctor-dtor-name> | <source-name> | <unnamed-type-name>, followed by abi tags
+func (d *demangler) unqualifiedName(enclosing string) (name string, isCtorDtor bool) {
+	c := d.peek()
+
+	switch {
+	case c >= '0' && c <= '9':
+		name = d.sourceName()
+
+	case c == 'C' && !d.peekString("Cv"):
+		d.position++
+		d.consume("I")
+		d.position++
+		name, isCtorDtor = stripTemplateArgs(enclosing), true
+
+	case c == 'D' && (d.peekString("D0") || d.peekString("D1") || d.peekString("D2")):
+		d.position += 2
+		name, isCtorDtor = "~"+stripTemplateArgs(enclosing), true
+
+	case d.consume("Ut"):
+		name = fmt.Sprintf("{unnamed type#%d}", d.optionalNumber()+1)
+
+	case d.consume("Ul"):
+		parameters := d.parameters()
+		d.expect("E")
+		name = fmt.Sprintf("{lambda%s#%d}", parameters, d.optionalNumber()+1)
+
+	case c >= 'a' && c <= 'z':
+		name = d.operatorName()
+
+	default:
+		d.fail("unexpected %q at %d", string(c), d.position)
+	}
+
+	for d.consume("B") {
+		name += "[abi:" + d.sourceName() + "]"
+	}
+
+	return name, isCtorDtor
+}
+
+// [<number>] _ used by unnamed types and lambdas, an omitted number stands for the first entity
+func (d *demangler) optionalNumber() int {
+	if d.consume("_") {
+		return 0
+	}
+	number := d.number()
+	d.expect("_")
+	return number + 1
+}
+
+// <source-name> ::= <length> <identifier>
+func (d *demangler) sourceName() string {
+	length := d.number()
+
+	if length <= 0 || d.position+length > len(d.input) {
+		d.fail("invalid identifier length at %d", d.position)
+	}
+
+	identifier := d.input[d.position : d.position+length]
+	d.position += length
+
+	if strings.HasPrefix(identifier, "_GLOBAL__N") {
+		return "(anonymous namespace)"
+	}
+
+	return identifier
+}
+
+func (d *demangler) number() int {
+	start := d.position
+	for d.peek() >= '0' && d.peek() <= '9' {
+		d.position++
+	}
+
+	number, err := strconv.Atoi(d.input[start:d.position])
+	if err != nil {
+		d.fail("expected a number at %d", start)
+	}
+
+	return number
+}
+
+var demangledOperators = map[string]string{
+	"nw": "new", "na": "new[]", "dl": "delete", "da": "delete[]",
+	"ps": "+", "ng": "-", "ad": "&", "de": "*", "co": "~",
+	"pl": "+", "mi": "-", "ml": "*", "dv": "/", "rm": "%", "an": "&", "or": "|", "eo": "^",
+	"aS": "=", "pL": "+=", "mI": "-=", "mL": "*=", "dV": "/=", "rM": "%=", "aN": "&=", "oR": "|=", "eO": "^=",
+	"ls": "<<", "rs": ">>", "lS": "<<=", "rS": ">>=",
+	"eq": "==", "ne": "!=", "lt": "<", "gt": ">", "le": "<=", "ge": ">=", "ss": "<=>",
+	"nt": "!", "aa": "&&", "oo": "||", "pp": "++", "mm": "--", "cm": ",",
+	"pm": "->*", "pt": "->", "cl": "()", "ix": "[]", "qu": "?",
+}
+
+// <operator-name> ::= <two-letter code> | cv <type> | li <source-name>
+func (d *demangler) operatorName() string {
+	if d.consume("cv") {
+		return "operator " + d.typeName()
+	}
+
+	if d.consume("li") {
+		return "operator\"\" " + d.sourceName()
+	}
+
+	if d.position+2 > len(d.input) {
+		d.fail("unexpected end of input")
+	}
+
+	code := d.input[d.position : d.position+2]
+
+	operator, ok := demangledOperators[code]
+	if !ok {
+		d.fail("unknown operator %q", code)
+	}
+
+	d.position += 2
+
+	// operators starting with a letter are separated by a space
+	if operator[0] >= 'a' && operator[0] <= 'z' {
+		return "operator " + operator
+	}
+	return "operator" + operator
+}
+
+// <CV-qualifiers> ::= [r] [V] [K]
+func (d *demangler) cvQualifiers() (cv string) {
+	if d.consume("r") {
+		cv += " restrict"
+	}
+	if d.consume("V") {
+		cv += " volatile"
+	}
+	if d.consume("K") {
+		cv += " const"
+	}
+	return cv
+}
+
+var demangledStdAbbreviations = map[string]string{
+	"Sa": "std::allocator",
+	"Sb": "std::basic_string",
+	"Ss": "std::basic_string<char, std::char_traits<char>, std::allocator<char> >",
+	"Si": "std::basic_istream<char, std::char_traits<char> >",
+	"So": "std::basic_ostream<char, std::char_traits<char> >",
+	"Sd": "std::basic_iostream<char, std::char_traits<char> >",
+}
+
+// <substitution> ::= S_ | S <seq-id> _ | Sa | Sb | Ss | Si | So | Sd
+func (d *demangler) substitution() string {
+	if d.position+2 <= len(d.input) {
+		if abbreviation, ok := demangledStdAbbreviations[d.input[d.position:d.position+2]]; ok {
+			d.position += 2
+			return abbreviation
+		}
+	}
+
+	d.expect("S")
+
+	index := 0
+
+	if !d.consume("_") {
+		// sequence ids are base 36 numbers, offset by one
+		start := d.position
+		for d.peek() != '_' {
+			if d.position >= len(d.input) {
+				d.fail("unterminated substitution at %d", start)
+			}
+			d.position++
+		}
+
+		id, err := strconv.ParseInt(d.input[start:d.position], 36, 32)
+		if err != nil {
+			d.fail("invalid substitution at %d", start)
+		}
+
+		d.position++
+		index = int(id) + 1
+	}
+
+	if index >= len(d.substitutions) {
+		d.fail("substitution %d out of range", index)
+	}
+
+	return d.substitutions[index]
+}
+
+// <template-param> ::= T_ | T <number> _
+func (d *demangler) templateParam() string {
+	d.expect("T")
+
+	index := 0
+	if !d.consume("_") {
+		index = d.number() + 1
+		d.expect("_")
+	}
+
+	if index >= len(d.templateArgs) {
+		d.fail("template parameter %d out of range", index)
+	}
+
+	return d.templateArgs[index]
+}
+
+// Appends the template arguments to the name of a template
+// <template-args> ::= I <template-arg>+ E
+func (d *demangler) withTemplateArgs(name string) string {
+	d.expect("I")
+
+	args := make([]string, 0)
+
+	for !d.consume("E") {
+		args = append(args, d.templateArg())
+	}
+
+	if d.typeDepth == 0 {
+		d.templateArgs = args
+	}
+
+	// avoid forming << or >> tokens
+	if strings.HasSuffix(name, "<") {
+		name += " "
+	}
+
+	list := "<" + strings.Join(args, ", ")
+	if strings.HasSuffix(list, ">") {
+		list += " "
+	}
+	return name + list + ">"
+}
+
+// <template-arg> ::= <type> | L <literal> E | J <template-arg>* E
+func (d *demangler) templateArg() string {
+	switch {
+	case d.peek() == 'L':
+		return d.literal()
+
+	case d.consume("J"):
+		pack := make([]string, 0)
+		for !d.consume("E") {
+			pack = append(pack, d.templateArg())
+		}
+		return strings.Join(pack, ", ")
+
+	case d.peek() == 'X':
+		d.fail("template argument expressions are not supported")
+	}
+
+	return d.typeName()
+}
+
+// <expr-primary> ::= L <type> <value> E | L _Z <encoding> E
+func (d *demangler) literal() string {
+	d.expect("L")
+
+	if d.consume("_Z") {
+		_, signature := d.encoding(true)
+		d.expect("E")
+		return signature
+	}
+
+	literalType := d.typeName()
+
+	start := d.position
+	for d.peek() != 'E' {
+		if d.position >= len(d.input) {
+			d.fail("unterminated literal at %d", start)
+		}
+		d.position++
+	}
+
+	value := strings.Replace(d.input[start:d.position], "n", "-", 1)
+	d.position++
+
+	switch literalType {
+	case "bool":
+		if value == "0" {
+			return "false"
+		}
+		return "true"
+	case "int":
+		return value
+	case "unsigned int":
+		return value + "u"
+	case "long":
+		return value + "l"
+	case "unsigned long":
+		return value + "ul"
+	}
+
+	return "(" + literalType + ")" + value
+}
+
+var demangledBuiltinTypes = map[byte]string{
+	'v': "void", 'w': "wchar_t", 'b': "bool",
+	'c': "char", 'a': "signed char", 'h': "unsigned char",
+	's': "short", 't': "unsigned short",
+	'i': "int", 'j': "unsigned int",
+	'l': "long", 'm': "unsigned long",
+	'x': "long long", 'y': "unsigned long long",
+	'n': "__int128", 'o': "unsigned __int128",
+	'f': "float", 'd': "double", 'e': "long double", 'g': "__float128",
+	'z': "...",
+}
+
+var demangledExtendedBuiltinTypes = map[string]string{
+	"Dn": "decltype(nullptr)", "Di": "char32_t", "Ds": "char16_t", "Du": "char8_t",
+	"Dd": "decimal64", "De": "decimal128", "Df": "decimal32", "Dh": "half",
+	"Da": "auto", "Dc": "decltype(auto)",
+}
+
+// <type> ::= <builtin-type> | <qualified-type> | <class-enum-type> | <array-type> | <function-type>
+//
+//	| <template-param> | <substitution> | P <type> | R <type> | O <type> | Dp <type>
+func (d *demangler) typeName() string {
+	d.typeDepth++
+	defer func() { d.typeDepth-- }()
+
+	c := d.peek()
+
+	if builtin, ok := demangledBuiltinTypes[c]; ok {
+		d.position++
+		return builtin
+	}
+
+	if d.position+2 <= len(d.input) {
+		if builtin, ok := demangledExtendedBuiltinTypes[d.input[d.position:d.position+2]]; ok {
+			d.position += 2
+			return builtin
+		}
+	}
+
+	var typeName string
+
+	switch {
+	case c == 'r' || c == 'V' || c == 'K':
+		cv := d.cvQualifiers()
+		inner := d.typeName()
+
+		// qualifiers of a function pointer apply to the pointer
+		if index := strings.Index(inner, "(*)"); index >= 0 {
+			typeName = inner[:index+2] + cv + inner[index+2:]
+		} else {
+			typeName = inner + cv
+		}
+
+	case c == 'P' || c == 'R' || c == 'O':
+		d.position++
+		symbol := map[byte]string{'P': "*", 'R': "&", 'O': "&&"}[c]
+
+		inner := d.typeName()
+
+		// pointers to functions are written around the function type, pointers to arrays before the bounds
+		if index := strings.Index(inner, "()("); index >= 0 && strings.HasSuffix(inner, ")") {
+			typeName = inner[:index+1] + symbol + inner[index+1:]
+		} else if index := strings.Index(inner, " ["); index >= 0 && strings.HasSuffix(inner, "]") {
+			typeName = inner[:index] + " (" + symbol + ")" + inner[index:]
+		} else {
+			typeName = inner + symbol
+		}
+
+	case c == 'F':
+		d.position++
+		d.consume("Y")
+
+		returnType := d.typeName()
+		parameters := d.parameters()
+		d.expect("E")
+
+		typeName = returnType + " ()" + parameters
+
+	case c == 'A':
+		d.position++
+
+		size := ""
+		if d.peek() != '_' {
+			size = strconv.Itoa(d.number())
+		}
+		d.expect("_")
+
+		typeName = d.typeName() + " [" + size + "]"
+
+	case c == 'M':
+		d.position++
+
+		class := d.typeName()
+		member := d.typeName()
+
+		// pointers to member functions are written around the function type
+		if index := strings.Index(member, "()("); index >= 0 {
+			typeName = member[:index+1] + class + "::*" + member[index+1:]
+		} else {
+			typeName = member + " " + class + "::*"
+		}
+
+	case d.peekString("Dp"):
+		d.position += 2
+		typeName = d.typeName()
+
+	case c == 'T':
+		typeName = d.templateParam()
+
+		if d.peek() == 'I' {
+			d.substitutions = append(d.substitutions, typeName)
+			typeName = d.withTemplateArgs(typeName)
+		}
+
+	case c == 'S' && !d.peekString("St"):
+		typeName = d.substitution()
+
+		if d.peek() != 'I' {
+			// a substituted type is not added again
+			return typeName
+		}
+
+		typeName = d.withTemplateArgs(typeName)
+
+	case c == 'N' || c == 'Z' || (c >= '0' && c <= '9') || d.peekString("St"):
+		// <class-enum-type> ::= <name>
+		typeName, _, _, _ = d.name()
+
+	case c == 'u':
+		// vendor extended type
+		d.position++
+		typeName = d.sourceName()
+
+	default:
+		d.fail("unsupported type %q at %d", string(c), d.position)
+	}
+
+	d.substitutions = append(d.substitutions, typeName)
+
+	return typeName
+}
+
+// The last component of a qualified name, e.g. Solver for ns::Solver
+func lastComponent(qualifiedName string) string {
+	depth := 0
+
+	for index := len(qualifiedName) - 1; index > 0; index-- {
+		switch qualifiedName[index] {
+		case '>':
+			depth++
+		case '<':
+			depth--
+		case ':':
+			if depth == 0 && qualifiedName[index-1] == ':' {
+				return qualifiedName[index+1:]
+			}
+		}
+	}
+
+	return qualifiedName
+}
+
+// The name of a class without its template arguments and abi tags, as used for its constructors
+func stripTemplateArgs(name string) string {
+	if index := strings.IndexAny(name, "<["); index > 0 {
+		return name[:index]
+	}
+	return name
+}
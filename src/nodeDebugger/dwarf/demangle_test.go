@@ -0,0 +1,74 @@
+package dwarf
+
+import "testing"
+
+func TestDemangle(t *testing.T) {
+	tests := []struct {
+		symbol        string
+		qualifiedName string
+		signature     string
+	}{
+		{"_Z3fooi", "foo", "foo(int)"},
+		{"_Z3barv", "bar", "bar()"},
+		{"_Z4sendPKvPi", "send", "send(void const*, int*)"},
+		{"_ZN2ns6Solver4stepEd", "ns::Solver::step", "ns::Solver::step(double)"},
+		{"_ZNK2ns6Solver4stepEd", "ns::Solver::step", "ns::Solver::step(double) const"},
+		{"_ZN12_GLOBAL__N_14helpEv", "(anonymous namespace)::help", "(anonymous namespace)::help()"},
+
+		// constructors and destructors
+		{"_ZN6SolverC2Ev", "Solver::Solver", "Solver::Solver()"},
+		{"_ZN6SolverD1Ev", "Solver::~Solver", "Solver::~Solver()"},
+		{"_ZN5Outer5InnerC1ERKS0_", "Outer::Inner::Inner", "Outer::Inner::Inner(Outer::Inner const&)"},
+
+		// templates and substitutions
+		{"_Z3maxIiET_S0_S0_", "max<int>", "int max<int>(int, int)"},
+		{"_Z1fRSt6vectorIiSaIiEE", "f", "f(std::vector<int, std::allocator<int> >&)"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "std::vector<int, std::allocator<int> >::push_back", "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+
+		// operators
+		{"_ZplRK1AS1_", "operator+", "operator+(A const&, A const&)"},
+		{"_ZN2ns3vecIdEixEm", "ns::vec<double>::operator[]", "ns::vec<double>::operator[](unsigned long)"},
+
+		// local entities and lambdas
+		{"_ZZ4mainE5count", "main::count", "main::count"},
+		{"_ZZ4mainENKUlvE_clEv", "main::{lambda()#1}::operator()", "main::{lambda()#1}::operator()() const"},
+
+		// pointers to functions and arrays
+		{"_Z5applyPFidEd", "apply", "apply(int (*)(double), double)"},
+		{"_Z3sumPA3_i", "sum", "sum(int (*) [3])"},
+
+		// special names
+		{"_ZTV6Solver", "vtable for Solver", "vtable for Solver"},
+
+		// vendor suffixes are left out
+		{"_Z3fooi.constprop.0", "foo", "foo(int)"},
+	}
+
+	for _, test := range tests {
+		demangled, err := demangle(test.symbol)
+		if err != nil {
+			t.Errorf("demangle(%s) failed: %v", test.symbol, err)
+			continue
+		}
+
+		if demangled.qualifiedName != test.qualifiedName {
+			t.Errorf("demangle(%s) has name %q, want %q", test.symbol, demangled.qualifiedName, test.qualifiedName)
+		}
+		if demangled.signature != test.signature {
+			t.Errorf("demangle(%s) has signature %q, want %q", test.symbol, demangled.signature, test.signature)
+		}
+	}
+}
+
+func TestDemangleInvalid(t *testing.T) {
+	for _, symbol := range []string{"main", "MPI_Send", "_Z", "_Z3fo", "_Z3fooi_", "_ZN3foo"} {
+		if demangled, err := demangle(symbol); err == nil {
+			t.Errorf("demangle(%s) = %q, want an error", symbol, demangled.signature)
+		}
+
+		// symbols that cannot be demangled are shown as they are
+		if Demangle(symbol) != symbol {
+			t.Errorf("Demangle(%s) = %q, want the symbol", symbol, Demangle(symbol))
+		}
+	}
+}
@@ -3,12 +3,18 @@ package dwarf
 import (
 	"fmt"
 	"unsafe"
+
+	"github.com/ottmartens/cc-rev-db/utils"
 )
 
 type DwarfData struct {
 	Modules []*Module
 	Types   typeMap
 	Mpi     MPIData
+
+	debugAddr      []byte               // contents of the .debug_addr section (DWARF 5)
+	goRuntimeTypes map[uint64]*BaseType // types of go targets by the address of their runtime type descriptor
+	goTypesBase    uint64               // start of the runtime type descriptors (go targets only)
 }
 
 func (m *Module) LookupFunc(functionName string) *Function {
@@ -20,21 +26,66 @@ func (m *Module) LookupFunc(functionName string) *Function {
 	return nil
 }
 
-// Retrieve a function with a matching name
+// Retrieve a function with a matching name. Functions with the exact name take precedence over
+// C++ functions matched by their qualified or mangled names
 func (d *DwarfData) LookupFunc(functionName string) (module *Module, function *Function) {
 	for _, module := range d.Modules {
 		if function := module.LookupFunc(functionName); function != nil {
 			return module, function
 		}
 	}
+
+	for _, module := range d.Modules {
+		for _, function := range module.functions {
+			if function.matches(functionName) {
+				return module, function
+			}
+		}
+	}
 	return nil, nil
 }
 
-// Retrieve a variable with a matching identifier
+// Retrieve all functions the identifier refers to, e.g. all overloads of a C++ method
+func (d *DwarfData) LookupFunctions(identifier string) []*Function {
+	functions := make([]*Function, 0)
+
+	for _, module := range d.Modules {
+		for _, function := range module.functions {
+			if function.matches(identifier) {
+				functions = append(functions, function)
+			}
+		}
+	}
+
+	return functions
+}
+
+// Address for breaking at the function: the start of its second line entry, past the prologue
+func (d *DwarfData) FunctionBreakpointAddress(function *Function) uint64 {
+	entries := 0
+
+	for _, module := range d.Modules {
+		for _, entry := range module.entries {
+			address := entry.Address + module.relocation
+
+			if address >= function.lowPC && address < function.highPC {
+				entries++
+
+				if entries == 2 {
+					return address
+				}
+			}
+		}
+	}
+
+	return function.lowPC
+}
+
+// Retrieve a module-level (global or static) variable with a matching identifier
 func (d *DwarfData) LookupVariable(idendifier string) *Variable {
 	for _, module := range d.Modules {
 		for _, variable := range module.Variables {
-			if variable.name == idendifier {
+			if variable.matches(idendifier) && variable.Function == nil {
 				return variable
 			}
 		}
@@ -43,19 +94,28 @@ func (d *DwarfData) LookupVariable(idendifier string) *Variable {
 	return nil
 }
 
-// Retrieve a variable with a matching identifier only if defined in the supplied function
-func (d *DwarfData) LookupVariableInFunction(function *Function, identifier string) *Variable {
+// Retrieve a variable with a matching identifier only if defined in the supplied function.
+// Of the variables in scope at pc, the one declared in the innermost block is returned
+func (d *DwarfData) LookupVariableInFunction(function *Function, identifier string, pc uint64) *Variable {
+	var match *Variable
+
 	for _, module := range d.Modules {
 		for _, variable := range module.Variables {
-			if variable.name == identifier {
-				if variable.Function != nil && variable.Function == function {
-					return variable
-				}
+			if !variable.matches(identifier) || variable.Function == nil || variable.Function != function {
+				continue
+			}
+
+			if !variable.block.contains(pc) {
+				continue
+			}
+
+			if match == nil || variable.block.depth() > match.block.depth() {
+				match = variable
 			}
 		}
 	}
 
-	return nil
+	return match
 }
 
 // Retrieve intruction entries for the function matching the identifier
@@ -64,6 +124,8 @@ func (d *DwarfData) GetEntriesForFunction(functionName string) []Entry {
 	module, function := d.LookupFunc(functionName)
 
 	for _, entry := range module.entries {
+		entry.Address += module.relocation
+
 		if entry.Address >= function.lowPC && entry.Address < function.highPC {
 			entries = append(entries, entry)
 		}
@@ -80,7 +142,7 @@ func (d *DwarfData) LineToPC(file string, line int) (address uint64, err error)
 				for _, entry := range module.entries {
 					if entry.line == line && module.files[entry.file] == file {
 						if entry.isStmt {
-							return entry.Address, nil
+							return entry.Address + module.relocation, nil
 						}
 					}
 				}
@@ -88,14 +150,14 @@ func (d *DwarfData) LineToPC(file string, line int) (address uint64, err error)
 		}
 	}
 
-	return 0, fmt.Errorf("unable to find suitable instruction for line %d in file %s", line, file)
+	return 0, fmt.Errorf("%w: no instruction for line %d in file %s", utils.ErrLineNotFound, line, file)
 }
 
 func (d *DwarfData) PCToLine(pc uint64) (line int, file string, function *Function, err error) {
 	for _, module := range d.Modules {
 		if pc >= module.startAddress && pc <= module.endAddress {
 			for _, entry := range module.entries {
-				if entry.Address == pc {
+				if entry.Address+module.relocation == pc {
 					function := d.PCToFunc(pc)
 
 					return entry.line, module.files[entry.file], function, nil
@@ -103,7 +165,31 @@ func (d *DwarfData) PCToLine(pc uint64) (line int, file string, function *Functi
 			}
 		}
 	}
-	return 0, "", nil, fmt.Errorf("unable to find instruction matching address %v", pc)
+	return 0, "", nil, fmt.Errorf("%w: no instruction matching address %#x", utils.ErrNoDebugInfo, pc)
+}
+
+// Returns the source line of the instruction at pc, which need not be at the start of a line entry
+func (d *DwarfData) PCToLineContaining(pc uint64) (line int, file string, err error) {
+	for _, module := range d.Modules {
+		if pc < module.startAddress || pc > module.endAddress {
+			continue
+		}
+
+		closest := -1
+
+		for index, entry := range module.entries {
+			if entry.Address+module.relocation <= pc && (closest < 0 || entry.Address > module.entries[closest].Address) {
+				closest = index
+			}
+		}
+
+		if closest >= 0 {
+			entry := module.entries[closest]
+			return entry.line, module.files[entry.file], nil
+		}
+	}
+
+	return 0, "", fmt.Errorf("%w: no line containing address %#x", utils.ErrNoDebugInfo, pc)
 }
 
 func (d *DwarfData) PCToFunc(pc uint64) *Function {
@@ -120,6 +206,23 @@ func (d *DwarfData) PCToFunc(pc uint64) *Function {
 	return nil
 }
 
+// Returns the source files named by the line tables of all modules
+func (d *DwarfData) SourceFiles() []string {
+	files := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, module := range d.Modules {
+		for _, file := range module.files {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files
+}
+
 func (d DwarfData) FindEntrySourceFile(mainFn string) (sourceFile string) {
 
 	module, function := d.LookupFunc(mainFn)
@@ -129,15 +232,20 @@ func (d DwarfData) FindEntrySourceFile(mainFn string) (sourceFile string) {
 	return sourceFile
 }
 
-func (d *DwarfData) ResolveMPIDebugInfo() {
-	mpiSignatureFunc := "_MPI_WRAPPER_INCLUDE"
+// function marking the MPI wrappers (compiler/mpi_wrap_include/debug_mpi_wrap.h)
+const MPI_SIGNATURE_FUNCTION = "_MPI_WRAPPER_INCLUDE"
 
+// Finds the wrapped MPI functions, compiled into the target or loaded with the preload library
+func (d *DwarfData) ResolveMPIDebugInfo() error {
 	mpiWrapFunctions := make([]*Function, 0)
 
-	module, sigFunc := d.LookupFunc(mpiSignatureFunc)
+	module, sigFunc := d.LookupFunc(MPI_SIGNATURE_FUNCTION)
+	if sigFunc == nil {
+		return fmt.Errorf("the MPI wrapper is neither compiled into the target nor preloaded")
+	}
 
 	for _, function := range module.functions {
-		if function.file == sigFunc.file && function != sigFunc {
+		if module.files[function.file] == module.files[sigFunc.file] && function != sigFunc {
 			function.name = function.name[1:]
 			mpiWrapFunctions = append(mpiWrapFunctions, function)
 		}
@@ -149,6 +257,7 @@ func (d *DwarfData) ResolveMPIDebugInfo() {
 			module.files[sigFunc.file],
 		}
 
+	return nil
 }
 
 // returns the pointer size of current arch
@@ -3,16 +3,24 @@ package dwarf
 import (
 	"debug/dwarf"
 	"fmt"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/utils"
 )
 
 type Module struct {
 	name         string         // name of the module
 	startAddress uint64         // the start of the address range in the module
 	endAddress   uint64         // the end of the address range in the module
-	entries      []Entry        // entries in this module
+	entries      []Entry        // entries in this module, at their link addresses (may be mapped read-only from the index)
+	relocation   uint64         // offset of the load address of the module from its link address, added to the entry addresses
 	files        map[int]string // source files of this module
 	functions    []*Function    // functions declared in this module
 	Variables    []*Variable    // variables declared in this module
+	addrBase     uint64         // offset of the module's entries in the .debug_addr section (DWARF 5)
+	hasAddrBase  bool           // the module gives DW_AT_addr_base, units without one use no indexed addresses
+	language     int64          // source language of the module (DW_AT_language)
+	producer     string         // compiler the module was built with (DW_AT_producer)
 }
 
 type typeMap map[dwarf.Offset]*BaseType
@@ -42,13 +50,17 @@ type Entry struct {
 }
 
 type Function struct {
-	name       string       // function name
-	file       int          // file the function is declared at
-	line       int64        // line nr
-	col        int64        // col nr
-	lowPC      uint64       // first PC address for the function
-	highPC     uint64       // last PC address for the function
-	Parameters []*Parameter // function parameters
+	name          string       // function name
+	linkageName   string       // mangled name of C++ functions
+	qualifiedName string       // demangled name with the enclosing namespaces and classes (C++ only)
+	signature     string       // demangled name with the parameter types (C++ only)
+	file          int          // file the function is declared at
+	line          int64        // line nr
+	col           int64        // col nr
+	lowPC         uint64       // first PC address for the function
+	highPC        uint64       // last PC address for the function
+	Parameters    []*Parameter // function parameters
+	fortran       bool         // whether the function is declared in Fortran, with case-insensitive names
 }
 
 type Parameter struct {
@@ -56,12 +68,41 @@ type Parameter struct {
 	baseType             *BaseType            // type of the variable
 	locationInstructions locationInstructions // raw dwarf location instructions
 	function             *Function            // the function the parameter is an argument for
+	fortran              bool                 // whether the parameter is declared in Fortran, with a case-insensitive name
 }
 
 type BaseType struct {
 	name     string
 	byteSize int64
 	encoding int64
+
+	tag      dwarf.Tag // tag of a composite type (structure, typedef, pointer, array), 0 for base types
+	keyword  string    // keyword the type is named with in the source, e.g. struct point, type(particle)
+	elemType *BaseType // pointed-to type of a pointer, aliased type of a typedef, element type of an array
+	members  []*Member // fields of a structure, union or class type
+
+	enumerators []enumerator // named values of an enumeration type
+
+	dimensions  []arrayDimension // dimensions of an array type, in the order of declaration
+	columnMajor bool             // whether the array is stored column by column (Fortran arrays)
+
+	goKind        int64     // kind of the type in go runtime (go targets only)
+	goKeyType     *BaseType // key type of a go map
+	goElemType    *BaseType // element type of a go map, slice or channel
+	goRuntimeType uint64    // runtime type descriptor (offset from the start of the descriptors in newer toolchains)
+}
+
+// A field of a structure type
+type Member struct {
+	name     string
+	offset   int64     // offset of the field from the start of the structure
+	baseType *BaseType // type of the field
+}
+
+// A named value of an enumeration type
+type enumerator struct {
+	name  string
+	value int64
 }
 
 type Variable struct {
@@ -69,7 +110,16 @@ type Variable struct {
 	baseType             *BaseType            // type of the variable
 	locationInstructions locationInstructions // raw dwarf location instructions
 	Function             *Function            // the function where variable is declared (might be nil)
+	block                *LexicalBlock        // the innermost block where variable is declared (nil for function scope)
 	isFnParam            bool                 // whether the variable is a function parameter
+	fortran              bool                 // whether the variable is declared in Fortran, with a case-insensitive name
+	staticBase           uint64               // offset of fixed addresses, for variables of binaries loaded at runtime-chosen addresses
+}
+
+// A block of statements inside a function (DW_TAG_lexical_block)
+type LexicalBlock struct {
+	ranges [][2]uint64   // address ranges of the block instructions
+	parent *LexicalBlock // enclosing block (nil if directly inside a function)
 }
 
 type MPIData struct {
@@ -79,6 +129,24 @@ type MPIData struct {
 
 type locationInstructions []byte
 
+// names of the source languages (DW_AT_language)
+var languageNames = map[int64]string{
+	0x01: "C", 0x02: "C", 0x0c: "C", 0x1d: "C", 0x2c: "C",
+	0x04: "C++", 0x1a: "C++", 0x21: "C++",
+	langFortran77: "Fortran", langFortran90: "Fortran", langFortran95: "Fortran",
+	langFortran03: "Fortran", langFortran08: "Fortran", langFortran18: "Fortran",
+	0x16: "Go",
+}
+
+// Returns the name of the source language of the module, empty if unknown
+func (m *Module) Language() string {
+	return languageNames[m.language]
+}
+
+func (m *Module) Producer() string {
+	return m.producer
+}
+
 func (m Module) String() string {
 	functionString := ""
 	for _, fn := range m.functions {
@@ -96,9 +164,43 @@ func (fn *Function) Name() string {
 	if fn == nil {
 		return "{nil}"
 	}
+	if len(fn.qualifiedName) > 0 {
+		return fn.qualifiedName
+	}
 	return fn.name
 }
 
+// First PC address of the function
+func (fn *Function) LowPC() uint64 {
+	return fn.lowPC
+}
+
+// The demangled signature of C++ functions, the name of others
+func (fn *Function) Signature() string {
+	if len(fn.signature) > 0 {
+		return fn.signature
+	}
+	return fn.Name()
+}
+
+// Whether the function is referred to by the identifier: its name, its mangled name,
+// its qualified name or a trailing part of it (e.g. Solver::step for ns::Solver::step), or its signature
+func (fn *Function) matches(identifier string) bool {
+	if fn.fortran {
+		return fn.matchesFortranName(identifier)
+	}
+
+	if fn.name == identifier || fn.linkageName == identifier || fn.qualifiedName == identifier {
+		return true
+	}
+
+	if len(fn.qualifiedName) > 0 && strings.HasSuffix(fn.qualifiedName, "::"+identifier) {
+		return true
+	}
+
+	return len(fn.signature) > 0 && strings.ReplaceAll(fn.signature, " ", "") == strings.ReplaceAll(identifier, " ", "")
+}
+
 func (e Entry) String() string {
 	return fmt.Sprintf("entry{address: %#x, file:%d, line: %d, col: %d, isStmt: %v}", e.Address, e.file, e.line, e.col, e.isStmt)
 }
@@ -107,6 +209,14 @@ func (p Parameter) String() string {
 	return fmt.Sprintf("%s (%s)", p.Name, p.baseType.name)
 }
 
+// Whether the parameter is referred to by the identifier, case-insensitively in Fortran
+func (p *Parameter) Matches(identifier string) bool {
+	if p.fortran {
+		return strings.EqualFold(p.Name, identifier)
+	}
+	return p.Name == identifier
+}
+
 func (p *Parameter) AsVariable() *Variable {
 	if p == nil {
 		return nil
@@ -118,9 +228,51 @@ func (p *Parameter) AsVariable() *Variable {
 		locationInstructions: p.locationInstructions,
 
 		isFnParam: true,
+		fortran:   p.fortran,
 	}
 }
 
+// Returns the type declared at the offset. Types may be referred to before their declaration,
+// a placeholder is created in that case and filled in when the declaration is parsed
+func (dMap typeMap) at(offset dwarf.Offset) *BaseType {
+	baseType, ok := dMap[offset]
+
+	if !ok {
+		baseType = &BaseType{name: "unknown type"}
+		dMap[offset] = baseType
+	}
+
+	return baseType
+}
+
+// Returns the underlying type of a typedef or a qualified type
+func (t *BaseType) resolved() *BaseType {
+	for t != nil && (t.tag == dwarf.TagTypedef || t.isQualifier()) && t.goKind == 0 && t.elemType != nil {
+		t = t.elemType
+	}
+	return t
+}
+
+// Whether the type has fields: a structure, union or class
+func (t *BaseType) isAggregate() bool {
+	return t.tag == dwarf.TagStructType || t.tag == dwarf.TagUnionType || t.tag == dwarf.TagClassType
+}
+
+// Whether the type qualifies the type it refers to (const, volatile, restrict)
+func (t *BaseType) isQualifier() bool {
+	return t.tag == dwarf.TagConstType || t.tag == dwarf.TagVolatileType || t.tag == dwarf.TagRestrictType
+}
+
+// Returns the field of a structure type with a matching name
+func (t *BaseType) member(name string) *Member {
+	for _, member := range t.resolved().members {
+		if member.name == name {
+			return member
+		}
+	}
+	return nil
+}
+
 func (dMap typeMap) String() string {
 	typesString := ""
 	for offset, dType := range dMap {
@@ -129,16 +281,76 @@ func (dMap typeMap) String() string {
 	return fmt.Sprintf("typeMap:{\n%s}", typesString)
 }
 
+// Whether the variable is referred to by the identifier, case-insensitively in Fortran
+func (v *Variable) matches(identifier string) bool {
+	if v.fortran {
+		return strings.EqualFold(v.name, identifier)
+	}
+	return v.name == identifier
+}
+
 func (v *Variable) String() string {
 	return fmt.Sprintf("{name:%v, type: %v, location: %v}", v.name, v.baseType.name, v.locationInstructions)
 }
 
-func (v *Variable) DecodeLocation(dRegisters DwarfRegisters) (address uint64, pieces []Piece, err error) {
-	return v.locationInstructions.decode(dRegisters)
+// Returns whether the instruction at pc belongs to the block. A nil block (function scope) contains all instructions
+func (b *LexicalBlock) contains(pc uint64) bool {
+	if b == nil {
+		return true
+	}
+
+	for _, addressRange := range b.ranges {
+		if pc >= addressRange[0] && pc < addressRange[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The nesting level of the block inside its function
+func (b *LexicalBlock) depth() int {
+	depth := 0
+	for block := b; block != nil; block = block.parent {
+		depth++
+	}
+	return depth
+}
+
+// Decodes the address of the variable. The memory of the target is read for locations
+// stored in memory, e.g. the arguments of Fortran procedures, which are passed by reference
+func (v *Variable) DecodeLocation(dRegisters DwarfRegisters, readMemory ReadMemoryFunc) (address uint64, pieces []Piece, err error) {
+	if len(v.locationInstructions) == 0 {
+		return 0, nil, fmt.Errorf("%w: variable %s has no location", utils.ErrOptimizedOut, v.name)
+	}
+
+	dRegisters.StaticBase = v.staticBase
+
+	return v.locationInstructions.decode(dRegisters, readMemory)
+}
+
+// Returns whether the variable resides at a fixed address (global and static variables),
+// which can be decoded without registers or a stack frame
+func (v *Variable) HasStaticLocation() bool {
+	return v.locationInstructions.isStatic()
+}
+
+// Decodes the fixed address of a global or static variable
+func (v *Variable) DecodeStaticLocation() (address uint64, err error) {
+	if len(v.locationInstructions) == 0 {
+		return 0, fmt.Errorf("%w: variable %s has no location", utils.ErrOptimizedOut, v.name)
+	}
+
+	if !v.HasStaticLocation() {
+		return 0, fmt.Errorf("location of variable %s depends on the stack frame", v.name)
+	}
+
+	address, _, err = v.locationInstructions.decode(DwarfRegisters{StaticBase: v.staticBase}, nil)
+	return address, err
 }
 
 func (v *Variable) ByteSize() int64 {
-	return v.baseType.byteSize
+	return v.baseType.resolved().byteSize
 }
 
 // func (li locationInstructions) String() string {
@@ -147,7 +359,12 @@ func (v *Variable) ByteSize() int64 {
 // 	return buf.String()
 // }
 
-func (li locationInstructions) decode(dRegisters DwarfRegisters) (address uint64, pieces []Piece, err error) {
-	addr, pieces, err := ExecuteStackProgram(dRegisters, li, ptrSize(), nil)
+// Whether the instructions consist of a single DW_OP_addr operation
+func (li locationInstructions) isStatic() bool {
+	return len(li) == 1+ptrSize() && Opcode(li[0]) == DW_OP_addr
+}
+
+func (li locationInstructions) decode(dRegisters DwarfRegisters, readMemory ReadMemoryFunc) (address uint64, pieces []Piece, err error) {
+	addr, pieces, err := ExecuteStackProgram(dRegisters, li, ptrSize(), readMemory)
 	return uint64(addr), pieces, err
 }
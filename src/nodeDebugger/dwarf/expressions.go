@@ -0,0 +1,271 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Operations on the values of the target for the expressions of the node debugger: dereferencing pointers,
+// subscripting arrays, taking addresses, reading scalars and converting them to the types of casts
+
+// names of C types as written in casts, by the names compilers declare them with
+var typeNameAliases = map[string]string{
+	"long":               "long int",
+	"unsigned":           "unsigned int",
+	"unsigned long":      "long unsigned int",
+	"long unsigned":      "long unsigned int",
+	"unsigned long long": "long long unsigned int",
+	"long long":          "long long int",
+	"short":              "short int",
+	"unsigned short":     "short unsigned int",
+	"signed":             "int",
+	"signed char":        "signed char",
+	"bool":               "_Bool",
+}
+
+// tags of the types named with a keyword in casts, e.g. struct particle
+var keywordTags = map[string]dwarf.Tag{
+	"struct": dwarf.TagStructType,
+	"union":  dwarf.TagUnionType,
+	"enum":   dwarf.TagEnumerationType,
+	"class":  dwarf.TagClassType,
+}
+
+// Returns whether the variable is a C structure, union or class, or a Fortran derived type
+func (v *Variable) IsStruct() bool {
+	variableType := v.baseType.resolved()
+	return variableType.isAggregate() && variableType.goKind == 0
+}
+
+// Returns the type of the variable as written in the source, e.g. int * for an unnamed pointer type
+func (v *Variable) DeclaredType() string {
+	return describeType(v.baseType)
+}
+
+// Returns the variable a pointer variable points to, or the first element of an array variable, at the address
+// of the array. Pointers to void and to types without debug info cannot be dereferenced
+func (v *Variable) Pointed() (*Variable, error) {
+	variableType := v.baseType.resolved()
+
+	switch {
+	case variableType.tag == dwarf.TagArrayType && len(variableType.dimensions) > 0:
+		formatter := goValueFormatter{}
+		first := formatter.subarray(LocatedValue{Name: v.name, Variable: v}, variableType, 0)
+		return first.Variable, nil
+
+	case variableType.tag == dwarf.TagPointerType:
+		pointedType := variableType.elemType.resolved()
+		if pointedType == nil || pointedType.byteSize == 0 {
+			return nil, fmt.Errorf("cannot dereference %s, a pointer to %s", v.name, describeType(variableType.elemType))
+		}
+
+		return &Variable{name: "*" + v.name, baseType: variableType.elemType, fortran: v.fortran}, nil
+	}
+
+	return nil, fmt.Errorf("%s is not a pointer", v.name)
+}
+
+// Returns a variable of a pointer to the type of the variable, holding its address
+func (v *Variable) AddressOf() *Variable {
+	return &Variable{
+		name:     "&" + v.name,
+		baseType: &BaseType{tag: dwarf.TagPointerType, byteSize: int64(ptrSize()), elemType: v.baseType},
+		fortran:  v.fortran,
+	}
+}
+
+// Returns the part of an array variable at an index of its first dimension, as subscripted in C: an element
+// of a one-dimensional array, an array of the other dimensions otherwise
+func (v *Variable) Subscript(address uint64, index int64) (*Variable, uint64, error) {
+	if !v.IsArray() {
+		return nil, 0, fmt.Errorf("%s is not an array", v.name)
+	}
+
+	arrayType := v.baseType.resolved()
+
+	if len(arrayType.dimensions) == 1 {
+		return v.Element(address, []int64{index})
+	}
+
+	if arrayType.columnMajor {
+		return nil, 0, fmt.Errorf("%s has %d dimensions, subscript them at once, e.g. %s(1,2)", v.name, len(arrayType.dimensions), v.name)
+	}
+
+	dimension := outermostDimension(arrayType)
+	if dimension.count < 0 {
+		return nil, 0, fmt.Errorf("bounds of %s are only known at runtime", v.name)
+	}
+	if index < dimension.lowerBound || index >= dimension.lowerBound+dimension.count {
+		return nil, 0, fmt.Errorf("index %d out of bounds %d:%d", index, dimension.lowerBound, dimension.lowerBound+dimension.count-1)
+	}
+
+	formatter := goValueFormatter{}
+	part := formatter.subarray(LocatedValue{Name: v.name, Variable: v, Address: address}, arrayType, int(index-dimension.lowerBound))
+
+	return part.Variable, part.Address, nil
+}
+
+// Reads the value of a scalar variable: int64 for signed integers, characters and booleans, uint64 for unsigned
+// integers and pointers, float64 for floating point numbers
+func (v *Variable) ReadScalar(address uint64, readMemory ReadMemoryFunc) (interface{}, error) {
+	variableType := v.baseType.resolved()
+
+	if !variableType.isScalar() {
+		return nil, fmt.Errorf("%s of type %s is not a number", v.name, describeType(v.baseType))
+	}
+	if variableType.byteSize <= 0 || variableType.byteSize > 8 || variableType.encoding == encodingComplexFloat {
+		return nil, fmt.Errorf("%s of type %s is not a number", v.name, describeType(v.baseType))
+	}
+
+	raw := make([]byte, variableType.byteSize)
+	if _, err := readMemory(raw, address); err != nil {
+		return nil, err
+	}
+
+	padded := make([]byte, 8)
+	copy(padded, raw)
+	value := binary.LittleEndian.Uint64(padded)
+
+	switch {
+	case variableType.tag == dwarf.TagPointerType:
+		return value, nil
+	case variableType.encoding == encodingFloat:
+		return decodeFloat(raw), nil
+	case variableType.isSigned() || variableType.encoding == encodingBoolean:
+		shift := 64 - 8*variableType.byteSize
+		return int64(value<<shift) >> shift, nil
+	}
+
+	return value, nil
+}
+
+// Converts a number to the type of the variable, as a cast does: truncated to its size and signedness,
+// to a float64 for floating point types and to a uint64 for pointers
+func (v *Variable) ConvertScalar(value interface{}) (interface{}, error) {
+	variableType := v.baseType.resolved()
+
+	if !variableType.isScalar() {
+		return nil, fmt.Errorf("cannot cast to %s", describeType(v.baseType))
+	}
+
+	var bits uint64
+	var number float64
+
+	switch typedValue := value.(type) {
+	case int64:
+		bits, number = uint64(typedValue), float64(typedValue)
+	case uint64:
+		bits, number = typedValue, float64(typedValue)
+	case float64:
+		bits, number = uint64(int64(typedValue)), typedValue
+	default:
+		return nil, fmt.Errorf("cannot cast %v to %s", value, describeType(v.baseType))
+	}
+
+	switch {
+	case variableType.tag == dwarf.TagPointerType:
+		return bits, nil
+	case variableType.encoding == encodingFloat && variableType.byteSize == 4:
+		return float64(float32(number)), nil
+	case variableType.encoding == encodingFloat:
+		return number, nil
+	case variableType.encoding == encodingBoolean:
+		if number != 0 {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case variableType.byteSize <= 0 || variableType.byteSize > 8:
+		return nil, fmt.Errorf("cannot cast to %s", describeType(v.baseType))
+	}
+
+	shift := 64 - 8*variableType.byteSize
+
+	if variableType.isSigned() {
+		return int64(bits<<shift) >> shift, nil
+	}
+	return bits << shift >> shift, nil
+}
+
+// Whether values of the type are numbers: base types, enumerations and pointers
+func (t *BaseType) isScalar() bool {
+	return t.tag == 0 || t.tag == dwarf.TagPointerType || t.tag == dwarf.TagEnumerationType
+}
+
+// Whether the numbers of the type are signed. Enumerations are signed unless their underlying type is unsigned
+func (t *BaseType) isSigned() bool {
+	if t.tag == dwarf.TagEnumerationType {
+		underlying := t.elemType.resolved()
+		return underlying == nil || underlying.encoding == encodingSigned || underlying.encoding == encodingSignedChar
+	}
+
+	return t.encoding == encodingSigned || t.encoding == encodingSignedChar
+}
+
+// Returns a variable of a type named as in a C cast, e.g. unsigned long, struct particle * or MPI_Status
+func (d *DwarfData) TypedVariable(name string, typeName string) (*Variable, error) {
+	base := strings.TrimSpace(typeName)
+	pointers := 0
+
+	for strings.HasSuffix(base, "*") {
+		base = strings.TrimSpace(strings.TrimSuffix(base, "*"))
+		pointers++
+	}
+
+	words := make([]string, 0)
+	for _, word := range strings.Fields(base) {
+		if word != "const" && word != "volatile" {
+			words = append(words, word)
+		}
+	}
+
+	var tag dwarf.Tag
+	if len(words) > 1 {
+		if keywordTag, ok := keywordTags[words[0]]; ok {
+			tag = keywordTag
+			words = words[1:]
+		}
+	}
+
+	base = strings.Join(words, " ")
+	if alias, ok := typeNameAliases[base]; ok {
+		base = alias
+	}
+
+	var baseType *BaseType
+
+	if base == "void" && pointers > 0 {
+		baseType = &BaseType{name: "void"}
+	} else {
+		baseType = d.lookupDeclaredType(base, tag)
+	}
+
+	if baseType == nil {
+		return nil, fmt.Errorf("unknown type %s", typeName)
+	}
+
+	for ; pointers > 0; pointers-- {
+		baseType = &BaseType{tag: dwarf.TagPointerType, byteSize: int64(ptrSize()), elemType: baseType}
+	}
+
+	return &Variable{name: name, baseType: baseType}, nil
+}
+
+// Returns a type declared with the name, of the tag if given. Declarations with members are preferred,
+// as forward declarations of structures have none
+func (d *DwarfData) lookupDeclaredType(name string, tag dwarf.Tag) *BaseType {
+	var found *BaseType
+
+	for _, declared := range d.Types {
+		if declared.name != name || (tag != 0 && declared.tag != tag) {
+			continue
+		}
+
+		if found == nil || (len(found.members) == 0 && len(declared.members) > 0) {
+			found = declared
+		}
+	}
+
+	return found
+}
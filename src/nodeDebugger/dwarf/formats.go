@@ -0,0 +1,74 @@
+package dwarf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Print formats of the print command of the node debugger (print/x), as in gdb: the bytes of scalars shown as
+// hex (x), signed (d) or unsigned (u) decimal, octal (o), binary (t), a character (c) or a floating point number (f),
+// or raw (r): char pointers and arrays as pointers and arrays of numbers rather than strings
+const PrintFormats = "xduotcfr"
+
+// Formats a C, Fortran or go value like FormatValue, its scalars (fields and elements included) in a print format
+func (d *DwarfData) FormatValueAs(variable *Variable, address uint64, readMemory ReadMemoryFunc, format byte) string {
+	formatter := goValueFormatter{d, readMemory, format}
+
+	return formatter.format(variable.baseType, address, 0)
+}
+
+// Formats a number computed by the debugger (int64, uint64 or float64) in a print format, as a value of the size
+// (in bytes) of its type. Floating point numbers are formatted by the bits of a double
+func FormatNumber(value interface{}, size int64, format byte) string {
+	switch typedValue := value.(type) {
+	case int64:
+		return formatBits(uint64(typedValue), size, format)
+	case uint64:
+		return formatBits(typedValue, size, format)
+	case float64:
+		if size == 4 {
+			return formatBits(uint64(math.Float32bits(float32(typedValue))), size, format)
+		}
+		return formatBits(math.Float64bits(typedValue), 8, format)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// Formats the bits of a scalar of the size (in bytes) in a print format. Floating point numbers are shown by their
+// bits as integers, and the bits of integers of 4 and 8 bytes as floating point numbers with /f
+func formatBits(bits uint64, size int64, format byte) string {
+	if size <= 0 || size > 8 {
+		return fmt.Sprintf("<cannot format %d bytes with /%c>", size, format)
+	}
+
+	shift := 64 - 8*uint64(size)
+	bits = bits << shift >> shift
+	signed := int64(bits<<shift) >> shift
+
+	switch format {
+	case 'x':
+		return fmt.Sprintf("%#x", bits)
+	case 'd':
+		return strconv.FormatInt(signed, 10)
+	case 'u':
+		return strconv.FormatUint(bits, 10)
+	case 'o':
+		return fmt.Sprintf("%#o", bits)
+	case 't':
+		return strconv.FormatUint(bits, 2)
+	case 'c':
+		return formatCharacter(int64(int8(bits)))
+	case 'f':
+		raw := make([]byte, 8)
+		binary.LittleEndian.PutUint64(raw, bits)
+
+		if size == 4 || size == 8 {
+			return fmt.Sprint(decodeFloat(raw[:size]))
+		}
+	}
+
+	return strconv.FormatInt(signed, 10)
+}
@@ -0,0 +1,110 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"strings"
+)
+
+// source languages of Fortran compile units (DW_AT_language)
+const (
+	langFortran77 = 0x07
+	langFortran90 = 0x08
+	langFortran95 = 0x0e
+	langFortran03 = 0x22
+	langFortran08 = 0x23
+	langFortran18 = 0x2d
+)
+
+// array orderings (DW_AT_ordering)
+const (
+	orderingRowMajor    = 0
+	orderingColumnMajor = 1
+)
+
+// gfortran names the procedures of Fortran modules __<module>_MOD_<procedure>
+const fortranModuleProcedureInfix = "_MOD_"
+
+// A dimension of an array type (DW_TAG_subrange_type)
+type arrayDimension struct {
+	lowerBound int64 // index of the first element
+	count      int64 // number of elements, -1 if only known at runtime (e.g. allocatable Fortran arrays)
+}
+
+func (m *Module) isFortran() bool {
+	if m == nil {
+		return false
+	}
+
+	switch m.language {
+	case langFortran77, langFortran90, langFortran95, langFortran03, langFortran08, langFortran18:
+		return true
+	}
+	return false
+}
+
+// Marks a function declared in a Fortran module. Procedures of Fortran modules are
+// referred to as <module>::<procedure>, derived from their linkage name
+func (fn *Function) setFortran() {
+	fn.fortran = true
+
+	moduleName, procedureName, found := strings.Cut(strings.TrimPrefix(fn.linkageName, "__"), fortranModuleProcedureInfix)
+	if strings.HasPrefix(fn.linkageName, "__") && found && len(moduleName) > 0 && len(procedureName) > 0 {
+		fn.qualifiedName = moduleName + "::" + procedureName
+	}
+}
+
+// Fortran names are case-insensitive and external names carry a trailing underscore,
+// so COMPUTE, compute and compute_ all refer to the same procedure
+func (fn *Function) matchesFortranName(identifier string) bool {
+	if fortranNamesEqual(fn.name, identifier) || fn.linkageName == identifier {
+		return true
+	}
+
+	return len(fn.qualifiedName) > 0 && strings.EqualFold(fn.qualifiedName, identifier)
+}
+
+func fortranNamesEqual(name string, identifier string) bool {
+	return strings.EqualFold(strings.TrimSuffix(name, "_"), strings.TrimSuffix(identifier, "_"))
+}
+
+// Adds the dimension described by a subrange entry to an array type.
+// Fortran arrays start at index 1 unless declared otherwise, C arrays at 0
+func parseArrayDimension(entry *dwarf.Entry, arrayType *BaseType, module *Module) {
+	dimension := arrayDimension{count: -1}
+
+	if module.isFortran() {
+		dimension.lowerBound = 1
+	}
+
+	// bounds are constants, or references to variables and location expressions for arrays shaped at runtime
+	if lowerBound := entry.Val(dwarf.AttrLowerBound); lowerBound != nil {
+		value, ok := lowerBound.(int64)
+		if !ok {
+			arrayType.dimensions = append(arrayType.dimensions, dimension)
+			return
+		}
+		dimension.lowerBound = value
+	}
+
+	if count, ok := entry.Val(dwarf.AttrCount).(int64); ok {
+		dimension.count = count
+	} else if upperBound, ok := entry.Val(dwarf.AttrUpperBound).(int64); ok {
+		dimension.count = upperBound - dimension.lowerBound + 1
+
+		// zero-sized Fortran arrays may be declared with an upper bound below the lower bound
+		if dimension.count < 0 {
+			dimension.count = 0
+		}
+	}
+
+	arrayType.dimensions = append(arrayType.dimensions, dimension)
+}
+
+// Fortran arrays are stored column by column, unless the debug info says otherwise
+func isColumnMajor(entry *dwarf.Entry, module *Module) bool {
+	if ordering, ok := entry.Val(dwarf.AttrOrdering).(int64); ok {
+		return ordering == orderingColumnMajor
+	}
+
+	return module.isFortran()
+}
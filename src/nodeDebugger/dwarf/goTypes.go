@@ -0,0 +1,701 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// attributes of types in debug info produced by the go toolchain
+const (
+	attrGoKind        dwarf.Attr = 0x2900
+	attrGoKey         dwarf.Attr = 0x2901
+	attrGoElem        dwarf.Attr = 0x2902
+	attrGoRuntimeType dwarf.Attr = 0x2904
+)
+
+// kinds of go runtime types (as in reflect.Kind)
+const (
+	goKindArray         = 17
+	goKindChan          = 18
+	goKindFunc          = 19
+	goKindInterface     = 20
+	goKindMap           = 21
+	goKindPointer       = 22
+	goKindSlice         = 23
+	goKindString        = 24
+	goKindStruct        = 25
+	goKindUnsafePointer = 26
+)
+
+// encodings of base types
+const (
+	encodingBoolean      = 0x02
+	encodingComplexFloat = 0x03
+	encodingFloat        = 0x04
+	encodingSigned       = 0x05
+	encodingSignedChar   = 0x06
+)
+
+const (
+	maxGoStringLength = 256  // characters shown of a string
+	maxGoElements     = 32   // elements shown of a slice, array or map
+	maxGoValueDepth   = 4    // levels of nested values shown
+	goMapGroupSlots   = 8    // slots in a bucket of a (pre go1.24) map
+	maxGoMapGroups    = 4096 // groups or buckets of a map scanned for the entries shown, empty ones included
+	maxGoReadSize     = 4096 // bytes of target memory read for a value at once
+	goMinTopHash      = 5    // smallest tophash value of an occupied bucket slot (pre go1.24)
+)
+
+func (data *DwarfData) parseGoTypeAttributes(entry *dwarf.Entry, goType *BaseType, types typeMap) {
+	goType.goKind, _ = entry.Val(attrGoKind).(int64)
+
+	if keyOffset, ok := entry.Val(attrGoKey).(dwarf.Offset); ok {
+		goType.goKeyType = types.at(keyOffset)
+	}
+
+	if elemOffset, ok := entry.Val(attrGoElem).(dwarf.Offset); ok {
+		goType.goElemType = types.at(elemOffset)
+	}
+
+	if address, ok := entry.Val(attrGoRuntimeType).(uint64); ok && address != 0 {
+		goType.goRuntimeType = address
+
+		if _, exists := data.goRuntimeTypes[address]; !exists {
+			data.goRuntimeTypes[address] = goType
+		}
+	}
+}
+
+// Returns the address of the runtime type descriptors in a go binary, 0 for other binaries.
+// Newer toolchains record the type descriptors in debug info as offsets from this address
+func lookupGoTypesBase(elfFile *elf.File) uint64 {
+	symbols, err := elfFile.Symbols()
+	if err != nil {
+		return 0
+	}
+
+	for _, symbol := range symbols {
+		if symbol.Name == "runtime.types" {
+			return symbol.Value
+		}
+	}
+
+	return 0
+}
+
+// Returns the type of a runtime type descriptor
+func (d *DwarfData) lookupGoRuntimeType(address uint64) *BaseType {
+	if d.goTypesBase != 0 && address >= d.goTypesBase {
+		if goType, ok := d.goRuntimeTypes[address-d.goTypesBase]; ok {
+			return goType
+		}
+	}
+
+	return d.goRuntimeTypes[address]
+}
+
+// Returns whether the variable is declared in a go program
+func (v *Variable) IsGoValue() bool {
+	return v.baseType.resolved().goKind != 0
+}
+
+// Formats the value of a go variable located at the address.
+// Strings, slices, maps and interfaces are decoded from their runtime representation
+func (d *DwarfData) FormatGoValue(variable *Variable, address uint64, readMemory ReadMemoryFunc) string {
+	formatter := goValueFormatter{d, readMemory, 0}
+
+	return formatter.format(variable.baseType, address, 0)
+}
+
+type goValueFormatter struct {
+	data        *DwarfData
+	readMemory  ReadMemoryFunc
+	printFormat byte // print format (one of PrintFormats), 0 for the encodings of the types and strings for char pointers and arrays
+}
+
+func (f goValueFormatter) format(goType *BaseType, address uint64, depth int) string {
+	goType = goType.resolved()
+
+	if depth > maxGoValueDepth {
+		return "..."
+	}
+
+	switch goType.goKind {
+	case goKindString:
+		return f.formatString(goType, address)
+	case goKindSlice:
+		return f.formatSlice(goType, address, depth)
+	case goKindArray:
+		return f.formatArray(goType, address, depth)
+	case goKindStruct:
+		return f.formatStruct(goType, address, depth)
+	case goKindMap:
+		return f.formatMap(goType, address, depth)
+	case goKindInterface:
+		return f.formatInterface(goType, address, depth)
+	case goKindPointer, goKindChan, goKindFunc, goKindUnsafePointer:
+		pointer, err := f.readPointer(address)
+		if err != nil {
+			return unreadable(address, err)
+		}
+		if pointer == 0 {
+			return "nil"
+		}
+		return fmt.Sprintf("(%s)(%#x)", goType.name, pointer)
+	}
+
+	// values of C and Fortran
+	switch goType.tag {
+	case dwarf.TagArrayType:
+		return f.formatCArray(goType, address)
+	case dwarf.TagStructType, dwarf.TagUnionType, dwarf.TagClassType:
+		return f.formatStruct(goType, address, depth)
+	case dwarf.TagEnumerationType:
+		if f.formatsBits() {
+			return f.formatScalar(goType, address)
+		}
+		return f.formatEnumeration(goType, address)
+	case dwarf.TagPointerType:
+		pointer, err := f.readPointer(address)
+		if err != nil {
+			return unreadable(address, err)
+		}
+		if f.formatsBits() {
+			return formatBits(pointer, goType.byteSize, f.printFormat)
+		}
+		if f.printFormat == 0 && goType.elemType.isCharacter() {
+			return f.formatStringPointer(goType, pointer)
+		}
+		return fmt.Sprintf("(%s) %#x", describeType(goType), pointer)
+	default:
+		return f.formatScalar(goType, address)
+	}
+}
+
+// Whether scalars are formatted by their bits in a print format, rather than by the encodings of their types
+func (f goValueFormatter) formatsBits() bool {
+	return f.printFormat != 0 && f.printFormat != 'r'
+}
+
+func (f goValueFormatter) formatScalar(goType *BaseType, address uint64) string {
+	if goType.byteSize <= 0 || goType.byteSize > 16 {
+		return fmt.Sprintf("<%s of unknown size>", goType.name)
+	}
+
+	raw, err := f.read(address, goType.byteSize)
+	if err != nil {
+		return unreadable(address, err)
+	}
+
+	if goType.encoding == encodingComplexFloat {
+		half := goType.byteSize / 2
+		return fmt.Sprintf("(%v+%vi)", decodeFloat(raw[:half]), decodeFloat(raw[half:]))
+	}
+
+	padded := make([]byte, 8)
+	copy(padded, raw)
+	value := binary.LittleEndian.Uint64(padded)
+
+	if f.formatsBits() {
+		return formatBits(value, goType.byteSize, f.printFormat)
+	}
+
+	if f.printFormat == 0 && goType.isCharacter() {
+		if goType.encoding == encodingSignedChar {
+			return formatCharacter(int64(int8(value)))
+		}
+		return formatCharacter(int64(value))
+	}
+
+	switch goType.encoding {
+	case encodingBoolean:
+		return strconv.FormatBool(value != 0)
+	case encodingFloat:
+		return fmt.Sprint(decodeFloat(raw))
+	case encodingSigned, encodingSignedChar:
+		shift := 64 - 8*goType.byteSize
+		return strconv.FormatInt(int64(value<<shift)>>shift, 10)
+	default:
+		return strconv.FormatUint(value, 10)
+	}
+}
+
+// Formats an enumeration by the name of its value, by the number if no enumerator has it
+func (f goValueFormatter) formatEnumeration(enumType *BaseType, address uint64) string {
+	number, err := (&Variable{baseType: enumType}).ReadScalar(address, f.readMemory)
+	if err != nil {
+		return unreadable(address, err)
+	}
+
+	var value int64
+	switch typedNumber := number.(type) {
+	case int64:
+		value = typedNumber
+	case uint64:
+		value = int64(typedNumber)
+	}
+
+	for _, enumerator := range enumType.enumerators {
+		if enumerator.value == value {
+			return enumerator.name
+		}
+	}
+
+	return fmt.Sprint(number)
+}
+
+func decodeFloat(raw []byte) float64 {
+	if len(raw) == 4 {
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(raw)))
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(raw))
+}
+
+func (f goValueFormatter) formatString(goType *BaseType, address uint64) string {
+	pointer, err := f.readPointer(address)
+	if err != nil {
+		return unreadable(address, err)
+	}
+
+	length, err := f.readUint(address+uint64(ptrSize()), int64(ptrSize()))
+	if err != nil {
+		return unreadable(address, err)
+	}
+
+	truncated := length > maxGoStringLength
+	if truncated {
+		length = maxGoStringLength
+	}
+
+	if length == 0 {
+		return `""`
+	}
+
+	contents, err := f.read(pointer, int64(length))
+	if err != nil {
+		return unreadable(pointer, err)
+	}
+
+	if truncated {
+		return strconv.Quote(string(contents)) + "..."
+	}
+
+	return strconv.Quote(string(contents))
+}
+
+func (f goValueFormatter) formatSlice(goType *BaseType, address uint64, depth int) string {
+	arrayMember, lenMember, capMember := goType.member("array"), goType.member("len"), goType.member("cap")
+	if arrayMember == nil || lenMember == nil || capMember == nil {
+		return fmt.Sprintf("<unsupported slice layout of %s>", goType.name)
+	}
+
+	pointer, err := f.readPointer(address + uint64(arrayMember.offset))
+	if err != nil {
+		return unreadable(address, err)
+	}
+
+	length, err := f.readUint(address+uint64(lenMember.offset), lenMember.baseType.resolved().byteSize)
+	if err != nil {
+		return unreadable(address, err)
+	}
+
+	capacity, err := f.readUint(address+uint64(capMember.offset), capMember.baseType.resolved().byteSize)
+	if err != nil {
+		return unreadable(address, err)
+	}
+
+	if pointer == 0 {
+		return "nil"
+	}
+
+	elemType := goType.goElemType
+	if elemType == nil {
+		elemType = arrayMember.baseType.resolved().elemType
+	}
+
+	elements := f.formatElements(elemType, pointer, length, depth)
+
+	return fmt.Sprintf("%s (len %d, cap %d)", elements, length, capacity)
+}
+
+func (f goValueFormatter) formatArray(goType *BaseType, address uint64, depth int) string {
+	elemType := goType.elemType.resolved()
+
+	if elemType == nil || elemType.byteSize == 0 {
+		return "[]"
+	}
+
+	return f.formatElements(elemType, address, uint64(goType.byteSize/elemType.byteSize), depth)
+}
+
+// Formats consecutive elements of an array
+func (f goValueFormatter) formatElements(elemType *BaseType, address uint64, count uint64, depth int) string {
+	stride := uint64(elemType.resolved().byteSize)
+
+	elements := make([]string, 0)
+
+	for index := uint64(0); index < count; index++ {
+		if index == maxGoElements {
+			elements = append(elements, "...")
+			break
+		}
+
+		elements = append(elements, f.format(elemType, address+index*stride, depth+1))
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(elements, " "))
+}
+
+func (f goValueFormatter) formatStruct(goType *BaseType, address uint64, depth int) string {
+	fields := make([]string, 0, len(goType.members))
+
+	for _, member := range goType.members {
+		value := f.format(member.baseType, address+uint64(member.offset), depth+1)
+		fields = append(fields, fmt.Sprintf("%s: %s", member.name, value))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+func (f goValueFormatter) formatMap(goType *BaseType, address uint64, depth int) string {
+	mapPointer, err := f.readPointer(address)
+	if err != nil {
+		return unreadable(address, err)
+	}
+
+	if mapPointer == 0 {
+		return "map[]"
+	}
+
+	// map variables are pointers to the runtime map structure
+	mapType := goType.elemType.resolved().elemType.resolved()
+
+	var entries []string
+
+	switch {
+	case mapType == nil:
+		err = fmt.Errorf("unknown map layout")
+	case strings.HasPrefix(mapType.name, "map<"): // swiss table maps (go1.24 and later)
+		entries, err = f.swissMapEntries(mapType, mapPointer, depth)
+	case strings.HasPrefix(mapType.name, "hash<"):
+		entries, err = f.bucketMapEntries(mapType, mapPointer, depth)
+	default:
+		err = fmt.Errorf("unknown map layout %s", mapType.name)
+	}
+
+	if err != nil {
+		return fmt.Sprintf("<%s: %v>", goType.name, err)
+	}
+
+	return fmt.Sprintf("map[%s]", strings.Join(entries, " "))
+}
+
+// Collects the entries of a map implemented as swiss tables: a directory of tables holding groups of slots,
+// or a single group for small maps
+func (f goValueFormatter) swissMapEntries(mapType *BaseType, address uint64, depth int) ([]string, error) {
+	dirPtrMember, dirLenMember := mapType.member("dirPtr"), mapType.member("dirLen")
+	if dirPtrMember == nil || dirLenMember == nil {
+		return nil, fmt.Errorf("unknown map layout %s", mapType.name)
+	}
+
+	// **table -> table
+	tableType := dirPtrMember.baseType.resolved().elemType.resolved().elemType.resolved()
+	groupsMember := tableType.member("groups")
+	if groupsMember == nil {
+		return nil, fmt.Errorf("unknown table layout %s", tableType.name)
+	}
+
+	groupDataMember, lengthMaskMember := groupsMember.baseType.member("data"), groupsMember.baseType.member("lengthMask")
+	if groupDataMember == nil || lengthMaskMember == nil {
+		return nil, fmt.Errorf("unknown group reference layout %s", groupsMember.baseType.name)
+	}
+
+	groupType := groupDataMember.baseType.resolved().elemType.resolved()
+	ctrlMember, slotsMember := groupType.member("ctrl"), groupType.member("slots")
+	if ctrlMember == nil || slotsMember == nil {
+		return nil, fmt.Errorf("unknown group layout %s", groupType.name)
+	}
+
+	slotType := slotsMember.baseType.resolved().elemType.resolved()
+	keyMember, elemMember := slotType.member("key"), slotType.member("elem")
+	if keyMember == nil || elemMember == nil || slotType.byteSize == 0 {
+		return nil, fmt.Errorf("unknown slot layout %s", slotType.name)
+	}
+
+	dirPtr, err := f.readPointer(address + uint64(dirPtrMember.offset))
+	if err != nil {
+		return nil, err
+	}
+
+	dirLen, err := f.readUint(address+uint64(dirLenMember.offset), dirLenMember.baseType.resolved().byteSize)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]uint64, 0)
+
+	if dirLen == 0 && dirPtr != 0 {
+		groups = append(groups, dirPtr)
+	}
+
+	// the directory may refer to a table multiple times
+	visitedTables := make(map[uint64]bool)
+
+	// the lengths read are not trusted, the groups of a corrupt map are scanned up to maxGoMapGroups.
+	// Each table of the directory has a group at least
+	truncated := false
+	if dirLen > maxGoMapGroups {
+		dirLen, truncated = maxGoMapGroups, true
+	}
+
+	for index := uint64(0); index < dirLen; index++ {
+		if len(groups) == maxGoMapGroups {
+			truncated = true
+			break
+		}
+
+		table, err := f.readPointer(dirPtr + index*uint64(ptrSize()))
+		if err != nil {
+			return nil, err
+		}
+
+		if visitedTables[table] {
+			continue
+		}
+		visitedTables[table] = true
+
+		groupsAddress := table + uint64(groupsMember.offset)
+
+		groupData, err := f.readPointer(groupsAddress + uint64(groupDataMember.offset))
+		if err != nil {
+			return nil, err
+		}
+
+		lengthMask, err := f.readUint(groupsAddress+uint64(lengthMaskMember.offset), lengthMaskMember.baseType.resolved().byteSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for group := uint64(0); group <= lengthMask; group++ {
+			if len(groups) == maxGoMapGroups {
+				truncated = true
+				break
+			}
+
+			groups = append(groups, groupData+group*uint64(groupType.byteSize))
+		}
+	}
+
+	slotCount := slotsMember.baseType.resolved().byteSize / slotType.byteSize
+	entries := make([]string, 0)
+
+	for _, group := range groups {
+		ctrl, err := f.readUint(group+uint64(ctrlMember.offset), 8)
+		if err != nil {
+			return nil, err
+		}
+
+		for slot := int64(0); slot < slotCount; slot++ {
+			// control bytes of empty and deleted slots have the high bit set
+			if (ctrl>>(8*slot))&0x80 != 0 {
+				continue
+			}
+
+			if len(entries) == maxGoElements {
+				return append(entries, "..."), nil
+			}
+
+			slotAddress := group + uint64(slotsMember.offset+slot*slotType.byteSize)
+			entries = append(entries, f.formatMapEntry(keyMember, elemMember, slotAddress, depth))
+		}
+	}
+
+	if truncated {
+		entries = append(entries, "...")
+	}
+
+	return entries, nil
+}
+
+// Collects the entries of a map implemented as a hash table of buckets (before go1.24)
+func (f goValueFormatter) bucketMapEntries(mapType *BaseType, address uint64, depth int) ([]string, error) {
+	bMember, bucketsMember := mapType.member("B"), mapType.member("buckets")
+	if bMember == nil || bucketsMember == nil {
+		return nil, fmt.Errorf("unknown map layout %s", mapType.name)
+	}
+
+	bucketType := bucketsMember.baseType.resolved().elemType.resolved()
+	tophashMember, keysMember, valuesMember, overflowMember := bucketType.member("tophash"), bucketType.member("keys"), bucketType.member("values"), bucketType.member("overflow")
+	if tophashMember == nil || keysMember == nil || valuesMember == nil || overflowMember == nil {
+		return nil, fmt.Errorf("unknown bucket layout %s", bucketType.name)
+	}
+
+	keyType, valueType := keysMember.baseType.resolved().elemType, valuesMember.baseType.resolved().elemType
+
+	b, err := f.readUint(address+uint64(bMember.offset), 1)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets, err := f.readPointer(address + uint64(bucketsMember.offset))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]string, 0)
+
+	// B and the overflow pointers are not trusted, the buckets of a corrupt map (or a cycle of overflow buckets)
+	// are scanned up to maxGoMapGroups
+	scanned := 0
+
+	for index := uint64(0); index < 1<<b && buckets != 0; index++ {
+		// follow the chain of overflow buckets
+		for bucket := buckets + index*uint64(bucketType.byteSize); bucket != 0; scanned++ {
+			if scanned == maxGoMapGroups {
+				return append(entries, "..."), nil
+			}
+
+			for slot := uint64(0); slot < goMapGroupSlots; slot++ {
+				tophash, err := f.readUint(bucket+uint64(tophashMember.offset)+slot, 1)
+				if err != nil {
+					return nil, err
+				}
+
+				if tophash < goMinTopHash {
+					continue
+				}
+
+				if len(entries) == maxGoElements {
+					return append(entries, "..."), nil
+				}
+
+				key := f.format(keyType, bucket+uint64(keysMember.offset)+slot*uint64(keyType.resolved().byteSize), depth+1)
+				value := f.format(valueType, bucket+uint64(valuesMember.offset)+slot*uint64(valueType.resolved().byteSize), depth+1)
+
+				entries = append(entries, fmt.Sprintf("%s:%s", key, value))
+			}
+
+			bucket, err = f.readPointer(bucket + uint64(overflowMember.offset))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func (f goValueFormatter) formatMapEntry(keyMember *Member, elemMember *Member, slotAddress uint64, depth int) string {
+	key := f.format(keyMember.baseType, slotAddress+uint64(keyMember.offset), depth+1)
+	elem := f.format(elemMember.baseType, slotAddress+uint64(elemMember.offset), depth+1)
+
+	return fmt.Sprintf("%s:%s", key, elem)
+}
+
+// Formats the dynamic value of an interface, found through the runtime type descriptor
+func (f goValueFormatter) formatInterface(goType *BaseType, address uint64, depth int) string {
+	// runtime.eface (empty interface) or runtime.iface
+	ifaceType := goType.elemType.resolved()
+	dataMember := ifaceType.member("data")
+
+	if dataMember == nil {
+		return fmt.Sprintf("<unsupported interface layout of %s>", goType.name)
+	}
+
+	var typeAddress uint64
+	var err error
+
+	if typeMember := ifaceType.member("_type"); typeMember != nil {
+		typeAddress, err = f.readPointer(address + uint64(typeMember.offset))
+	} else if tabMember := ifaceType.member("tab"); tabMember != nil {
+		var itab uint64
+
+		itab, err = f.readPointer(address + uint64(tabMember.offset))
+
+		// the type descriptor follows the interface descriptor in the itab
+		if err == nil && itab != 0 {
+			typeAddress, err = f.readPointer(itab + uint64(ptrSize()))
+		}
+	}
+
+	if err != nil {
+		return unreadable(address, err)
+	}
+
+	if typeAddress == 0 {
+		return "nil"
+	}
+
+	dataAddress := address + uint64(dataMember.offset)
+
+	dynamicType := f.data.lookupGoRuntimeType(typeAddress)
+	if dynamicType == nil {
+		return fmt.Sprintf("(unknown type %#x)", typeAddress)
+	}
+
+	// values of pointer-shaped types are stored in the data word directly, others are pointed to
+	if !dynamicType.isPointerShaped() {
+		dataAddress, err = f.readPointer(dataAddress)
+		if err != nil {
+			return unreadable(address, err)
+		}
+	}
+
+	value := f.format(dynamicType, dataAddress, depth+1)
+
+	// formatted pointers carry their type already
+	if dynamicType.resolved().goKind == goKindPointer {
+		return value
+	}
+
+	return fmt.Sprintf("%s(%s)", dynamicType.name, value)
+}
+
+func (t *BaseType) isPointerShaped() bool {
+	switch t.resolved().goKind {
+	case goKindPointer, goKindChan, goKindFunc, goKindMap, goKindUnsafePointer:
+		return true
+	}
+	return false
+}
+
+func (f goValueFormatter) read(address uint64, size int64) ([]byte, error) {
+	if size < 0 || size > maxGoReadSize {
+		return nil, fmt.Errorf("invalid read of %d bytes", size)
+	}
+
+	buffer := make([]byte, size)
+
+	_, err := f.readMemory(buffer, address)
+
+	return buffer, err
+}
+
+func (f goValueFormatter) readUint(address uint64, size int64) (uint64, error) {
+	if size <= 0 || size > 8 {
+		return 0, fmt.Errorf("invalid integer size %d", size)
+	}
+
+	raw, err := f.read(address, size)
+	if err != nil {
+		return 0, err
+	}
+
+	padded := make([]byte, 8)
+	copy(padded, raw)
+
+	return binary.LittleEndian.Uint64(padded), nil
+}
+
+func (f goValueFormatter) readPointer(address uint64) (uint64, error) {
+	return f.readUint(address, int64(ptrSize()))
+}
+
+func unreadable(address uint64, err error) string {
+	return fmt.Sprintf("<unreadable at %#x: %v>", address, err)
+}
@@ -0,0 +1,184 @@
+package dwarf
+
+import (
+	"fmt"
+)
+
+// statuses of goroutines (runtime/runtime2.go)
+var goroutineStatuses = map[uint64]string{
+	0: "idle",
+	1: "runnable",
+	2: "running",
+	3: "syscall",
+	4: "waiting",
+	6: "dead",
+	8: "copystack",
+	9: "preempted",
+}
+
+const (
+	goroutineStatusDead = 6
+	goroutineStatusScan = 0x1000 // set while the garbage collector scans the stack
+)
+
+// goroutines read from runtime.allgs at most, a larger length is taken for a corrupt or not yet initialized slice
+const maxGoroutines = 1 << 20
+
+// A goroutine of a go target, read from the runtime structures
+type Goroutine struct {
+	Address   uint64 // address of the runtime.g structure
+	Id        int64  // goroutine id
+	Status    uint64 // runtime status
+	PC        uint64 // saved instruction pointer (valid when the goroutine is not running)
+	SP        uint64 // saved stack pointer
+	BP        uint64 // saved base pointer
+	StartPC   uint64 // entry of the goroutine function
+	CreatorPC uint64 // location of the go statement that created the goroutine
+}
+
+func (g Goroutine) StatusName() string {
+	if name, ok := goroutineStatuses[g.Status&^goroutineStatusScan]; ok {
+		return name
+	}
+	return fmt.Sprintf("status %d", g.Status)
+}
+
+func (g Goroutine) IsRunning() bool {
+	return g.Status&^goroutineStatusScan == 2
+}
+
+// offsets of the fields read from runtime.g
+type goroutineLayout struct {
+	id, status, pc, sp, bp, startPC, creatorPC int64
+}
+
+// Reads the goroutines of a go target from runtime.allgs. Exited goroutines are left out
+func (d *DwarfData) ReadGoroutines(readMemory ReadMemoryFunc) ([]Goroutine, error) {
+	allgs := d.LookupVariable("runtime.allgs")
+	if allgs == nil {
+		return nil, fmt.Errorf("runtime.allgs not found, the target is not a go program")
+	}
+
+	layout, err := d.goroutineLayout()
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := allgs.DecodeStaticLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	reader := goValueFormatter{d, readMemory, 0}
+
+	array, err := reader.readPointer(address)
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := reader.readUint(address+uint64(ptrSize()), int64(ptrSize()))
+	if err != nil {
+		return nil, err
+	}
+
+	if length > maxGoroutines {
+		return nil, fmt.Errorf("runtime.allgs holds %d goroutines, more than %d: the slice is corrupt", length, maxGoroutines)
+	}
+
+	// the last element is read first, a length beyond the mapping of the array fails before anything is allocated
+	if length > 0 {
+		if _, err := reader.readPointer(array + (length-1)*uint64(ptrSize())); err != nil {
+			return nil, fmt.Errorf("runtime.allgs holds %d goroutines, beyond its array at %#x: %w", length, array, err)
+		}
+	}
+
+	goroutines := make([]Goroutine, 0, length)
+
+	for index := uint64(0); index < length; index++ {
+		gAddress, err := reader.readPointer(array + index*uint64(ptrSize()))
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make(map[int64]uint64)
+
+		for _, offset := range []int64{layout.id, layout.pc, layout.sp, layout.bp, layout.startPC, layout.creatorPC} {
+			fields[offset], err = reader.readUint(gAddress+uint64(offset), 8)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		status, err := reader.readUint(gAddress+uint64(layout.status), 4)
+		if err != nil {
+			return nil, err
+		}
+
+		if status&^goroutineStatusScan == goroutineStatusDead {
+			continue
+		}
+
+		goroutines = append(goroutines, Goroutine{
+			Address:   gAddress,
+			Id:        int64(fields[layout.id]),
+			Status:    status,
+			PC:        fields[layout.pc],
+			SP:        fields[layout.sp],
+			BP:        fields[layout.bp],
+			StartPC:   fields[layout.startPC],
+			CreatorPC: fields[layout.creatorPC],
+		})
+	}
+
+	return goroutines, nil
+}
+
+// Finds the offsets of the fields of runtime.g in the debug info, as they differ between go versions
+func (d *DwarfData) goroutineLayout() (layout goroutineLayout, err error) {
+	gType := d.lookupType("runtime.g")
+	if gType == nil {
+		return layout, fmt.Errorf("type runtime.g not found")
+	}
+
+	sched := gType.member("sched")
+	goid := gType.member("goid")
+	status := gType.member("atomicstatus")
+	startPC := gType.member("startpc")
+	creatorPC := gType.member("gopc")
+
+	if sched == nil || goid == nil || status == nil || startPC == nil || creatorPC == nil {
+		return layout, fmt.Errorf("unknown layout of runtime.g")
+	}
+
+	pc, sp, bp := sched.baseType.member("pc"), sched.baseType.member("sp"), sched.baseType.member("bp")
+	if pc == nil || sp == nil || bp == nil {
+		return layout, fmt.Errorf("unknown layout of runtime.gobuf")
+	}
+
+	layout = goroutineLayout{
+		id:        goid.offset,
+		status:    status.offset,
+		pc:        sched.offset + pc.offset,
+		sp:        sched.offset + sp.offset,
+		bp:        sched.offset + bp.offset,
+		startPC:   startPC.offset,
+		creatorPC: creatorPC.offset,
+	}
+
+	// atomicstatus is wrapped in atomic.Uint32 since go1.20
+	if value := status.baseType.member("value"); value != nil {
+		layout.status += value.offset
+	}
+
+	return layout, nil
+}
+
+// Retrieve a type with a matching name
+func (d *DwarfData) lookupType(name string) *BaseType {
+	for _, baseType := range d.Types {
+		if baseType.name == name {
+			return baseType.resolved()
+		}
+	}
+	return nil
+}
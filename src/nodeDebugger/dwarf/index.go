@@ -0,0 +1,631 @@
+package dwarf
+
+import (
+	"bufio"
+	"debug/dwarf"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// version of the index file format, part of the file name so that debuggers of other versions do not share it
+const indexFormatVersion = 4
+
+// Returns the debug info of the binary, from the index shared by the debuggers of the binary on this host.
+// The first debugger to load the binary parses it and stores the index, the others wait for it and read it,
+// instead of each parsing the binary again. The line tables, the bulk of the debug info, are stored apart
+// and memory-mapped by every debugger, so that a single copy of them is held in memory per host.
+// Binaries without a build-id are always parsed
+func LoadDwarfData(binaryFile string) (data *DwarfData, shared bool, err error) {
+	buildId, err := BuildId(binaryFile)
+	if err != nil {
+		data, err = ParseDwarfData(binaryFile)
+		return data, false, err
+	}
+
+	indexFile := filepath.Join(os.TempDir(), "cc-rev-db-dwarf", fmt.Sprintf("%s.v%d.index", buildId, indexFormatVersion))
+
+	unlock, err := lockIndex(indexFile)
+	if err != nil {
+		data, err = ParseDwarfData(binaryFile)
+		return data, false, err
+	}
+	defer unlock()
+
+	if data, err := readIndex(indexFile); err == nil {
+		return data, true, nil
+	}
+
+	data, err = ParseDwarfData(binaryFile)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// a failure to store the index only costs the other debuggers a parse
+	if writeIndex(indexFile, data) == nil {
+		mapLineTables(linesFileOf(indexFile), data)
+	}
+
+	return data, false, nil
+}
+
+// Takes an exclusive lock on the index of a binary, held while the index is read or written
+func lockIndex(indexFile string) (unlock func(), err error) {
+	err = os.MkdirAll(filepath.Dir(indexFile), 0777)
+	if err != nil {
+		return nil, err
+	}
+
+	lockFile, err := os.OpenFile(indexFile+".lock", os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		lockFile.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN)
+		lockFile.Close()
+	}, nil
+}
+
+func readIndex(indexFile string) (*DwarfData, error) {
+	file, err := os.Open(indexFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var index dwarfIndex
+
+	err = gob.NewDecoder(file).Decode(&index)
+	if err != nil {
+		return nil, err
+	}
+
+	data := index.unflatten()
+
+	err = mapLineTables(linesFileOf(indexFile), data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// The line tables are written first, the index is complete once the index file is in place
+func writeIndex(indexFile string, data *DwarfData) error {
+	err := writeAtomically(linesFileOf(indexFile), func(file *os.File) error {
+		return writeLineTables(file, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeAtomically(indexFile, func(file *os.File) error {
+		return gob.NewEncoder(file).Encode(flatten(data))
+	})
+}
+
+// Writes a file through a temporary file renamed into place, so that a partially written file is never read
+func writeAtomically(path string, write func(file *os.File) error) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	file.Close()
+
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func linesFileOf(indexFile string) string {
+	return strings.TrimSuffix(indexFile, ".index") + ".lines"
+}
+
+// size of a line table entry in the lines file, the entries are stored as laid out in memory
+const lineEntrySize = uint64(unsafe.Sizeof(Entry{}))
+
+// Writes the line tables of the modules: the entry size, the number of modules and the number of entries
+// of each module, followed by the entries of all modules
+func writeLineTables(file *os.File, data *DwarfData) error {
+	header := []uint64{lineEntrySize, uint64(len(data.Modules))}
+
+	for _, module := range data.Modules {
+		header = append(header, uint64(len(module.entries)))
+	}
+
+	writer := bufio.NewWriter(file)
+
+	err := binary.Write(writer, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+
+	for _, module := range data.Modules {
+		if len(module.entries) == 0 {
+			continue
+		}
+
+		_, err = writer.Write(unsafe.Slice((*byte)(unsafe.Pointer(&module.entries[0])), uint64(len(module.entries))*lineEntrySize))
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// Replaces the line tables of the modules with the ones in the lines file, mapped read-only into memory.
+// The mapped pages are shared by all processes mapping the file
+func mapLineTables(linesFile string, data *DwarfData) error {
+	file, err := os.Open(linesFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	size := uint64(info.Size())
+
+	if size < 16 {
+		return fmt.Errorf("%v is truncated", linesFile)
+	}
+
+	mapped, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+
+	entrySize, moduleCount := binary.LittleEndian.Uint64(mapped), binary.LittleEndian.Uint64(mapped[8:])
+
+	offset := 16 + 8*moduleCount
+
+	if entrySize != lineEntrySize || moduleCount != uint64(len(data.Modules)) || size < offset {
+		syscall.Munmap(mapped)
+		return fmt.Errorf("%v does not match the index", linesFile)
+	}
+
+	entries := make([][]Entry, moduleCount)
+
+	for index := range entries {
+		count := binary.LittleEndian.Uint64(mapped[16+8*index:])
+
+		if size-offset < count*entrySize {
+			syscall.Munmap(mapped)
+			return fmt.Errorf("%v is truncated", linesFile)
+		}
+
+		entries[index] = make([]Entry, 0)
+		if count > 0 {
+			entries[index] = unsafe.Slice((*Entry)(unsafe.Pointer(&mapped[offset])), count)
+		}
+
+		offset += count * entrySize
+	}
+
+	// the mapping is kept for the lifetime of the debugger
+	for index, module := range data.Modules {
+		module.entries = entries[index]
+	}
+
+	return nil
+}
+
+// The debug info of a binary in a serializable form: the entities referring to each other
+// are stored in tables and refer to each other by their position in the table (-1 for none)
+type dwarfIndex struct {
+	Types     []indexedType
+	Functions []indexedFunction
+	Blocks    []indexedBlock
+	Modules   []indexedModule
+
+	TypeOffsets    map[dwarf.Offset]int // types by the offset of their debug entry
+	GoRuntimeTypes map[uint64]int
+	GoTypesBase    uint64
+	DebugAddr      []byte
+}
+
+type indexedType struct {
+	Name          string
+	ByteSize      int64
+	Encoding      int64
+	Tag           dwarf.Tag
+	Keyword       string
+	ElemType      int
+	Members       []indexedMember
+	Enumerators   []indexedEnumerator
+	Dimensions    [][2]int64 // lower bound and count
+	ColumnMajor   bool
+	GoKind        int64
+	GoKeyType     int
+	GoElemType    int
+	GoRuntimeType uint64
+}
+
+type indexedMember struct {
+	Name     string
+	Offset   int64
+	BaseType int
+}
+
+type indexedEnumerator struct {
+	Name  string
+	Value int64
+}
+
+type indexedFunction struct {
+	Name, LinkageName, QualifiedName, Signature string
+	File                                        int
+	Line, Col                                   int64
+	LowPC, HighPC                               uint64
+	Parameters                                  []indexedVariable
+	Fortran                                     bool
+}
+
+type indexedBlock struct {
+	Ranges [][2]uint64
+	Parent int
+}
+
+type indexedVariable struct {
+	Name                 string
+	BaseType             int
+	LocationInstructions []byte
+	Function             int
+	Block                int
+	IsFnParam            bool
+	Fortran              bool
+}
+
+type indexedModule struct {
+	Name         string
+	StartAddress uint64
+	EndAddress   uint64
+	Files        map[int]string
+	Functions    []int
+	Variables    []indexedVariable
+	AddrBase     uint64
+	HasAddrBase  bool
+	Language     int64
+	Producer     string
+}
+
+// assigns the entities their positions in the index tables
+type flattener struct {
+	index     *dwarfIndex
+	types     map[*BaseType]int
+	functions map[*Function]int
+	blocks    map[*LexicalBlock]int
+}
+
+func flatten(data *DwarfData) *dwarfIndex {
+	f := flattener{
+		index: &dwarfIndex{
+			TypeOffsets:    make(map[dwarf.Offset]int),
+			GoRuntimeTypes: make(map[uint64]int),
+			GoTypesBase:    data.goTypesBase,
+			DebugAddr:      data.debugAddr,
+		},
+		types:     make(map[*BaseType]int),
+		functions: make(map[*Function]int),
+		blocks:    make(map[*LexicalBlock]int),
+	}
+
+	for offset, baseType := range data.Types {
+		f.index.TypeOffsets[offset] = f.typeIndex(baseType)
+	}
+
+	for address, baseType := range data.goRuntimeTypes {
+		f.index.GoRuntimeTypes[address] = f.typeIndex(baseType)
+	}
+
+	for _, module := range data.Modules {
+		indexed := indexedModule{
+			Name:         module.name,
+			StartAddress: module.startAddress,
+			EndAddress:   module.endAddress,
+			Files:        module.files,
+			AddrBase:     module.addrBase,
+			HasAddrBase:  module.hasAddrBase,
+			Language:     module.language,
+			Producer:     module.producer,
+		}
+
+		for _, function := range module.functions {
+			indexed.Functions = append(indexed.Functions, f.functionIndex(function))
+		}
+
+		for _, variable := range module.Variables {
+			indexed.Variables = append(indexed.Variables, f.variable(variable))
+		}
+
+		f.index.Modules = append(f.index.Modules, indexed)
+	}
+
+	return f.index
+}
+
+func (f *flattener) typeIndex(baseType *BaseType) int {
+	if baseType == nil {
+		return -1
+	}
+
+	if index, ok := f.types[baseType]; ok {
+		return index
+	}
+
+	index := len(f.index.Types)
+	f.types[baseType] = index
+	f.index.Types = append(f.index.Types, indexedType{})
+
+	// referred types are added after the type itself, as types may refer to themselves
+	indexed := indexedType{
+		Name:          baseType.name,
+		ByteSize:      baseType.byteSize,
+		Encoding:      baseType.encoding,
+		Tag:           baseType.tag,
+		Keyword:       baseType.keyword,
+		ElemType:      f.typeIndex(baseType.elemType),
+		ColumnMajor:   baseType.columnMajor,
+		GoKind:        baseType.goKind,
+		GoKeyType:     f.typeIndex(baseType.goKeyType),
+		GoElemType:    f.typeIndex(baseType.goElemType),
+		GoRuntimeType: baseType.goRuntimeType,
+	}
+
+	for _, member := range baseType.members {
+		indexed.Members = append(indexed.Members, indexedMember{member.name, member.offset, f.typeIndex(member.baseType)})
+	}
+
+	for _, enumerator := range baseType.enumerators {
+		indexed.Enumerators = append(indexed.Enumerators, indexedEnumerator{enumerator.name, enumerator.value})
+	}
+
+	for _, dimension := range baseType.dimensions {
+		indexed.Dimensions = append(indexed.Dimensions, [2]int64{dimension.lowerBound, dimension.count})
+	}
+
+	f.index.Types[index] = indexed
+
+	return index
+}
+
+func (f *flattener) functionIndex(function *Function) int {
+	if function == nil {
+		return -1
+	}
+
+	if index, ok := f.functions[function]; ok {
+		return index
+	}
+
+	index := len(f.index.Functions)
+	f.functions[function] = index
+	f.index.Functions = append(f.index.Functions, indexedFunction{})
+
+	indexed := indexedFunction{
+		Name:          function.name,
+		LinkageName:   function.linkageName,
+		QualifiedName: function.qualifiedName,
+		Signature:     function.signature,
+		File:          function.file,
+		Line:          function.line,
+		Col:           function.col,
+		LowPC:         function.lowPC,
+		HighPC:        function.highPC,
+		Fortran:       function.fortran,
+	}
+
+	for _, parameter := range function.Parameters {
+		indexed.Parameters = append(indexed.Parameters, indexedVariable{
+			Name:                 parameter.Name,
+			BaseType:             f.typeIndex(parameter.baseType),
+			LocationInstructions: parameter.locationInstructions,
+			Function:             f.functionIndex(parameter.function),
+			Block:                -1,
+			Fortran:              parameter.fortran,
+		})
+	}
+
+	f.index.Functions[index] = indexed
+
+	return index
+}
+
+func (f *flattener) blockIndex(block *LexicalBlock) int {
+	if block == nil {
+		return -1
+	}
+
+	if index, ok := f.blocks[block]; ok {
+		return index
+	}
+
+	parent := f.blockIndex(block.parent)
+
+	index := len(f.index.Blocks)
+	f.blocks[block] = index
+	f.index.Blocks = append(f.index.Blocks, indexedBlock{block.ranges, parent})
+
+	return index
+}
+
+func (f *flattener) variable(variable *Variable) indexedVariable {
+	return indexedVariable{
+		Name:                 variable.name,
+		BaseType:             f.typeIndex(variable.baseType),
+		LocationInstructions: variable.locationInstructions,
+		Function:             f.functionIndex(variable.Function),
+		Block:                f.blockIndex(variable.block),
+		IsFnParam:            variable.isFnParam,
+		Fortran:              variable.fortran,
+	}
+}
+
+// Restores the debug info from the index, the entities are created first and linked to each other after
+func (index *dwarfIndex) unflatten() *DwarfData {
+	types := make([]*BaseType, len(index.Types))
+	for i := range types {
+		types[i] = &BaseType{}
+	}
+
+	typeAt := func(i int) *BaseType {
+		if i < 0 {
+			return nil
+		}
+		return types[i]
+	}
+
+	for i, indexed := range index.Types {
+		baseType := types[i]
+
+		baseType.name = indexed.Name
+		baseType.byteSize = indexed.ByteSize
+		baseType.encoding = indexed.Encoding
+		baseType.tag = indexed.Tag
+		baseType.keyword = indexed.Keyword
+		baseType.elemType = typeAt(indexed.ElemType)
+		baseType.columnMajor = indexed.ColumnMajor
+		baseType.goKind = indexed.GoKind
+		baseType.goKeyType = typeAt(indexed.GoKeyType)
+		baseType.goElemType = typeAt(indexed.GoElemType)
+		baseType.goRuntimeType = indexed.GoRuntimeType
+
+		for _, member := range indexed.Members {
+			baseType.members = append(baseType.members, &Member{member.Name, member.Offset, typeAt(member.BaseType)})
+		}
+
+		for _, indexedEnumerator := range indexed.Enumerators {
+			baseType.enumerators = append(baseType.enumerators, enumerator{indexedEnumerator.Name, indexedEnumerator.Value})
+		}
+
+		for _, dimension := range indexed.Dimensions {
+			baseType.dimensions = append(baseType.dimensions, arrayDimension{dimension[0], dimension[1]})
+		}
+	}
+
+	functions := make([]*Function, len(index.Functions))
+	for i, indexed := range index.Functions {
+		functions[i] = &Function{
+			name:          indexed.Name,
+			linkageName:   indexed.LinkageName,
+			qualifiedName: indexed.QualifiedName,
+			signature:     indexed.Signature,
+			file:          indexed.File,
+			line:          indexed.Line,
+			col:           indexed.Col,
+			lowPC:         indexed.LowPC,
+			highPC:        indexed.HighPC,
+			Parameters:    make([]*Parameter, 0, len(indexed.Parameters)),
+			fortran:       indexed.Fortran,
+		}
+	}
+
+	functionAt := func(i int) *Function {
+		if i < 0 {
+			return nil
+		}
+		return functions[i]
+	}
+
+	for i, indexed := range index.Functions {
+		for _, parameter := range indexed.Parameters {
+			functions[i].Parameters = append(functions[i].Parameters, &Parameter{
+				Name:                 parameter.Name,
+				baseType:             typeAt(parameter.BaseType),
+				locationInstructions: parameter.LocationInstructions,
+				function:             functionAt(parameter.Function),
+				fortran:              parameter.Fortran,
+			})
+		}
+	}
+
+	// parents precede their nested blocks in the table
+	blocks := make([]*LexicalBlock, len(index.Blocks))
+	for i, indexed := range index.Blocks {
+		blocks[i] = &LexicalBlock{ranges: indexed.Ranges}
+		if indexed.Parent >= 0 {
+			blocks[i].parent = blocks[indexed.Parent]
+		}
+	}
+
+	data := &DwarfData{
+		Modules:        make([]*Module, 0, len(index.Modules)),
+		Types:          make(typeMap),
+		goRuntimeTypes: make(map[uint64]*BaseType),
+		goTypesBase:    index.GoTypesBase,
+		debugAddr:      index.DebugAddr,
+	}
+
+	for offset, i := range index.TypeOffsets {
+		data.Types[offset] = types[i]
+	}
+
+	for address, i := range index.GoRuntimeTypes {
+		data.goRuntimeTypes[address] = types[i]
+	}
+
+	for _, indexed := range index.Modules {
+		module := &Module{
+			name:         indexed.Name,
+			startAddress: indexed.StartAddress,
+			endAddress:   indexed.EndAddress,
+			files:        indexed.Files,
+			functions:    make([]*Function, 0, len(indexed.Functions)),
+			Variables:    make([]*Variable, 0, len(indexed.Variables)),
+			addrBase:     indexed.AddrBase,
+			hasAddrBase:  indexed.HasAddrBase,
+			language:     indexed.Language,
+			producer:     indexed.Producer,
+		}
+
+		if module.files == nil {
+			module.files = make(map[int]string)
+		}
+
+		for _, i := range indexed.Functions {
+			module.functions = append(module.functions, functions[i])
+		}
+
+		for _, variable := range indexed.Variables {
+			restored := &Variable{
+				name:                 variable.Name,
+				baseType:             typeAt(variable.BaseType),
+				locationInstructions: variable.LocationInstructions,
+				Function:             functionAt(variable.Function),
+				isFnParam:            variable.IsFnParam,
+				fortran:              variable.Fortran,
+			}
+
+			if variable.Block >= 0 {
+				restored.block = blocks[variable.Block]
+			}
+
+			module.Variables = append(module.Variables, restored)
+		}
+
+		data.Modules = append(data.Modules, module)
+	}
+
+	return data
+}
@@ -0,0 +1,125 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"strings"
+)
+
+// Layouts of types for the whatis and ptype commands of the node debugger: the declared type of a value
+// and the members of structures, unions and enumerations with their offsets and sizes
+
+// Returns the type a typedef aliases, one level deep, the declared type otherwise
+func (v *Variable) AliasedType() string {
+	if v.baseType != nil && v.baseType.tag == dwarf.TagTypedef && v.baseType.elemType != nil {
+		return describeType(v.baseType.elemType)
+	}
+
+	return describeType(v.baseType)
+}
+
+// Returns the type of the variable expanded as ptype prints it: typedefs resolved and the structure, union or
+// enumeration the type is made of (through pointers and arrays) listed member by member, e.g.
+//
+//	struct point {
+//	    /* offset 0, size 4 */      int x;
+//	    /* offset 8, size 8 */      double y;
+//	} *  /* size 16 */
+func (v *Variable) TypeLayout() string {
+	variableType := v.baseType.resolved()
+
+	// the declarators around the innermost type, e.g. * of pointers and [4] of arrays
+	declarator := ""
+	inner := variableType
+
+	for inner != nil && inner.goKind == 0 && (inner.tag == dwarf.TagPointerType || inner.tag == dwarf.TagArrayType) {
+		if inner.tag == dwarf.TagPointerType {
+			declarator = "*" + declarator
+		} else {
+			declarator += arrayBounds(inner)
+		}
+		inner = inner.elemType.resolved()
+	}
+
+	if len(declarator) > 0 {
+		declarator = " " + declarator
+	}
+
+	switch {
+	case inner == nil:
+		return "void" + declarator
+	case inner.tag == dwarf.TagEnumerationType:
+		return fmt.Sprintf("%s%s  /* size %d */", layoutEnumeration(inner), declarator, inner.byteSize)
+	case inner.isAggregate() || (inner.goKind == goKindStruct && len(inner.members) > 0):
+		return fmt.Sprintf("%s%s  /* size %d */", layoutMembers(inner), declarator, inner.byteSize)
+	}
+
+	return describeType(variableType)
+}
+
+// Lists the enumerators of an enumeration with their values
+func layoutEnumeration(enumType *BaseType) string {
+	enumerators := make([]string, 0, len(enumType.enumerators))
+	for _, enumerator := range enumType.enumerators {
+		enumerators = append(enumerators, fmt.Sprintf("%s = %d", enumerator.name, enumerator.value))
+	}
+
+	return fmt.Sprintf("%s {%s}", strings.TrimSuffix(describeType(enumType), " {...}"), strings.Join(enumerators, ", "))
+}
+
+// Lists the members of a structure, union or class by offset, the padding between them included
+func layoutMembers(aggregate *BaseType) string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%s {\n", strings.TrimSuffix(describeType(aggregate), " {...}"))
+
+	end := int64(0) // end of the members listed so far
+
+	for _, member := range aggregate.members {
+		size := member.baseType.resolved().byteSize
+		if arraySize, err := (&Variable{baseType: member.baseType}).ArrayByteSize(); err == nil {
+			size = arraySize
+		}
+
+		if aggregate.tag != dwarf.TagUnionType && member.offset > end {
+			fmt.Fprintf(&builder, "    %-28s/* %d bytes of padding */\n", fmt.Sprintf("/* offset %d, size %d */", end, member.offset-end), member.offset-end)
+		}
+
+		fmt.Fprintf(&builder, "    %-28s%s;\n", fmt.Sprintf("/* offset %d, size %d */", member.offset, size), declaration(member.baseType, member.name))
+
+		if member.offset+size > end {
+			end = member.offset + size
+		}
+	}
+
+	if aggregate.tag != dwarf.TagUnionType && aggregate.byteSize > end && len(aggregate.members) > 0 {
+		fmt.Fprintf(&builder, "    %-28s/* %d bytes of padding */\n", fmt.Sprintf("/* offset %d, size %d */", end, aggregate.byteSize-end), aggregate.byteSize-end)
+	}
+
+	builder.WriteString("}")
+
+	return builder.String()
+}
+
+// Returns the declaration of a member as written in C, e.g. double values[4], struct point *next or int (*handler)()
+func declaration(memberType *BaseType, name string) string {
+	switch {
+	case memberType == nil:
+		return "void " + name
+	case len(memberType.name) == 0 && memberType.tag == dwarf.TagArrayType:
+		return declaration(memberType.elemType, name+arrayBounds(memberType))
+	case len(memberType.name) == 0 && memberType.tag == dwarf.TagPointerType && memberType.elemType != nil && memberType.elemType.tag == dwarf.TagSubroutineType:
+		return fmt.Sprintf("%s (*%s)()", describeType(memberType.elemType.elemType), name)
+	}
+
+	typeName := describeType(memberType)
+	if strings.HasSuffix(typeName, "*") {
+		return typeName + name
+	}
+	return typeName + " " + name
+}
+
+// Returns the bounds of the dimensions of an array type, e.g. [4][3]
+func arrayBounds(arrayType *BaseType) string {
+	return strings.TrimPrefix(describeType(&BaseType{tag: dwarf.TagArrayType, dimensions: arrayType.dimensions}), "void")
+}
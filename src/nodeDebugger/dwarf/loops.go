@@ -0,0 +1,69 @@
+package dwarf
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// Returns where an iteration of the loop of a line starts. Compilers place the code of a loop header (for, while)
+// around the body: the initialization or the jump to the condition before it, the increment and the condition
+// after it, branching back to the body. The body is then entered once per iteration, the start of the header only
+// once. For other lines, isLoop is false and the address is the start of the line
+func (d *DwarfData) LoopBodyEntry(file string, line int) (address uint64, isLoop bool, err error) {
+	for _, module := range d.Modules {
+		entries := module.lineEntriesByAddress()
+
+		for index, entry := range entries {
+			if entry.line != line || module.files[entry.file] != file || !entry.isStmt {
+				continue
+			}
+
+			// the end of the first block of the line
+			next := index + 1
+			for next < len(entries) && entries[next].line == line && module.files[entries[next].file] == file {
+				next++
+			}
+
+			function := d.PCToFunc(entry.Address + module.relocation)
+
+			if next < len(entries) && function != nil && module.resumesLine(entries[next:], file, line, function.highPC-module.relocation) {
+				return entries[next].Address + module.relocation, true, nil
+			}
+
+			return entry.Address + module.relocation, false, nil
+		}
+	}
+
+	return 0, false, fmt.Errorf("%w: no instruction for line %d in file %s", utils.ErrLineNotFound, line, file)
+}
+
+// Returns the line entries of the module in the order of their addresses
+func (m *Module) lineEntriesByAddress() []Entry {
+	entries := make([]Entry, len(m.entries))
+	copy(entries, m.entries)
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Address < entries[j].Address })
+
+	return entries
+}
+
+// Returns whether the line has code again after other lines, before the end of the function
+func (m *Module) resumesLine(entries []Entry, file string, line int, end uint64) bool {
+	otherLines := false
+
+	for _, entry := range entries {
+		if entry.Address >= end {
+			return false
+		}
+
+		if entry.line != line || m.files[entry.file] != file {
+			otherLines = true
+		} else if otherLines {
+			return true
+		}
+	}
+
+	return false
+}
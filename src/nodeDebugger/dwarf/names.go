@@ -0,0 +1,63 @@
+package dwarf
+
+import (
+	"sort"
+	"strings"
+)
+
+// Names of the debug info starting with a prefix, for completing the identifiers typed in commands
+
+// Names of the functions starting with the prefix, C++ functions by their qualified names. Sorted, without duplicates
+func (d *DwarfData) FunctionNames(prefix string) []string {
+	names := make(map[string]bool)
+
+	for _, module := range d.Modules {
+		for _, function := range module.functions {
+			for _, name := range []string{function.name, function.Name()} {
+				if len(name) > 0 && strings.HasPrefix(name, prefix) {
+					names[name] = true
+				}
+			}
+		}
+	}
+
+	return sortedNames(names)
+}
+
+// Names of the global variables and of the parameters and variables of the function in scope at pc starting with the prefix.
+// Only global variables if the function is nil. Sorted, without duplicates
+func (d *DwarfData) VariableNames(prefix string, function *Function, pc uint64) []string {
+	names := make(map[string]bool)
+
+	for _, module := range d.Modules {
+		for _, variable := range module.Variables {
+			if !strings.HasPrefix(variable.name, prefix) {
+				continue
+			}
+
+			if variable.Function == nil || (variable.Function == function && variable.block.contains(pc)) {
+				names[variable.name] = true
+			}
+		}
+	}
+
+	if function != nil {
+		for _, parameter := range function.Parameters {
+			if strings.HasPrefix(parameter.Name, prefix) {
+				names[parameter.Name] = true
+			}
+		}
+	}
+
+	return sortedNames(names)
+}
+
+func sortedNames(names map[string]bool) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+
+	sort.Strings(sorted)
+	return sorted
+}
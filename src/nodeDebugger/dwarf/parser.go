@@ -1,32 +1,77 @@
 package dwarf
 
 import (
+	"bytes"
 	"debug/dwarf"
 	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
-	"reflect"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils"
 )
 
-func ParseDwarfData(targetFile string) *DwarfData {
+// linkage name attribute of producers predating DWARF 4
+const attrMIPSLinkageName dwarf.Attr = 0x2007
+
+// Parses the debug info of a binary. Fails with utils.ErrNoDebugInfo if the binary has none
+func ParseDwarfData(targetFile string) (*DwarfData, error) {
 
 	data := &DwarfData{
-		Modules: make([]*Module, 0),
-		Types:   make(typeMap),
+		Modules:        make([]*Module, 0),
+		Types:          make(typeMap),
+		goRuntimeTypes: make(map[uint64]*BaseType),
 	}
 
-	var currentModule *Module
-	var currentFunction *Function
-
 	elfFile, err := elf.Open(targetFile)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	// compressed debug sections are inflated by debug/elf
 	dwarfRawData, err := elfFile.DWARF()
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%w: unable to load debug info of %v: %v", utils.ErrNoDebugInfo, targetFile, err)
 	}
 
-	reader := dwarfRawData.Reader()
+	// DWARF 5 producers may refer to addresses indirectly through the .debug_addr table
+	data.debugAddr, err = readDebugSection(elfFile, ".debug_addr")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read .debug_addr section of %v: %v", targetFile, err)
+	}
+
+	// skeleton unit headers are needed for locating split units
+	debugInfo, err := readDebugSection(elfFile, ".debug_info")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read .debug_info section of %v: %v", targetFile, err)
+	}
+
+	data.goTypesBase = lookupGoTypesBase(elfFile)
+
+	data.parseEntries(dwarfRawData, data.Types, nil, func(skeleton *dwarf.Entry, module *Module) {
+		data.parseSplitUnit(skeleton, module, targetFile, debugInfo)
+	})
+
+	return data, nil
+}
+
+// Parses the entries of a .debug_info section.
+// Entries of a split unit (splitModule != nil) are added to the module created from its skeleton unit,
+// skeleton units of the main binary are passed to parseSplit for loading their split unit
+func (data *DwarfData) parseEntries(
+	rawData *dwarf.Data,
+	types typeMap,
+	splitModule *Module,
+	parseSplit func(skeleton *dwarf.Entry, module *Module),
+) {
+	currentModule := splitModule
+
+	// scopes of the entries being parsed, an entry with children opens a new scope
+	// that is closed by a null entry at the end of the children
+	scopes := make([]scope, 0)
+
+	reader := rawData.Reader()
 
 	for {
 		entry, err := reader.Next()
@@ -38,55 +83,169 @@ func ParseDwarfData(targetFile string) *DwarfData {
 			panic(err)
 		}
 
+		if entry.Tag == 0 {
+			if len(scopes) > 0 {
+				scopes = scopes[:len(scopes)-1]
+			}
+			continue
+		}
+
+		currentScope := scope{}
+		if len(scopes) > 0 {
+			currentScope = scopes[len(scopes)-1]
+		}
+
+		// scope for the children of the entry
+		childScope := currentScope
+
 		switch entry.Tag {
 
 		// base type declaration
 		case dwarf.TagBaseType:
-			data.Types[entry.Offset] = &BaseType{
-				name:     entry.Val(dwarf.AttrName).(string),
-				byteSize: entry.Val(dwarf.AttrByteSize).(int64),
-				encoding: entry.Val(dwarf.AttrEncoding).(int64),
+			baseType := types.at(entry.Offset)
+
+			baseType.name = entry.Val(dwarf.AttrName).(string)
+			baseType.byteSize = entry.Val(dwarf.AttrByteSize).(int64)
+			baseType.encoding = entry.Val(dwarf.AttrEncoding).(int64)
+
+			data.parseGoTypeAttributes(entry, baseType, types)
+
+		// composite type declaration, or a qualified type
+		case dwarf.TagStructType, dwarf.TagClassType, dwarf.TagUnionType, dwarf.TagEnumerationType, dwarf.TagSubroutineType,
+			dwarf.TagTypedef, dwarf.TagPointerType, dwarf.TagArrayType, dwarf.TagConstType, dwarf.TagVolatileType, dwarf.TagRestrictType:
+			compositeType := data.parseType(entry, types)
+
+			if compositeType.isAggregate() || entry.Tag == dwarf.TagEnumerationType {
+				childScope.structType = compositeType
+				compositeType.keyword = typeKeyword(entry.Tag, currentModule)
+			}
+
+			if entry.Tag == dwarf.TagArrayType {
+				compositeType.columnMajor = isColumnMajor(entry, currentModule)
+				childScope.arrayType = compositeType
+			}
+
+		// dimension of an array type
+		case dwarf.TagSubrangeType:
+			if currentScope.arrayType == nil {
+				break
+			}
+
+			parseArrayDimension(entry, currentScope.arrayType, currentModule)
+
+		// named value of an enumeration type
+		case dwarf.TagEnumerator:
+			if currentScope.structType == nil {
+				break
+			}
+
+			name, _ := entry.Val(dwarf.AttrName).(string)
+
+			var value int64
+			switch constant := entry.Val(dwarf.AttrConstValue).(type) {
+			case int64:
+				value = constant
+			case uint64:
+				value = int64(constant)
+			}
+
+			currentScope.structType.enumerators = append(currentScope.structType.enumerators, enumerator{name, value})
+
+		// field of a structure type
+		case dwarf.TagMember:
+			if currentScope.structType == nil {
+				break
 			}
 
+			offset, _ := entry.Val(dwarf.AttrDataMemberLoc).(int64)
+			name, _ := entry.Val(dwarf.AttrName).(string)
+
+			currentScope.structType.members = append(currentScope.structType.members, &Member{
+				name:     name,
+				offset:   offset,
+				baseType: lookupBaseType(entry, types),
+			})
+
 		// entering a new module
-		case dwarf.TagCompileUnit:
-			currentModule = parseModule(entry, dwarfRawData)
+		case dwarf.TagCompileUnit, dwarf.TagSkeletonUnit:
+			childScope = scope{}
+
+			// the module of a split unit has been created from the skeleton unit
+			if splitModule != nil {
+				if len(splitModule.name) == 0 {
+					splitModule.name, _ = entry.Val(dwarf.AttrName).(string)
+				}
+				break
+			}
+
+			currentModule = parseModule(entry, rawData)
 
 			data.Modules = append(data.Modules, currentModule)
 
-			currentFunction = nil
+			if isSkeletonUnit(entry) && parseSplit != nil {
+				parseSplit(entry, currentModule)
+			}
 
 		// function declaration
 		case dwarf.TagSubprogram:
-			currentFunction = parseFunction(entry, dwarfRawData)
+			childScope = scope{}
+
+			// declarations of external functions (e.g. libc) and of class methods carry no code
+			if isDeclaration(entry) {
+				childScope.abstract = true
+				break
+			}
 
-			currentModule.functions = append(currentModule.functions, currentFunction)
+			childScope.function = parseFunction(entry, rawData)
 
-		case dwarf.TagFormalParameter:
-			parameter := parseFunctionParameter(entry, data)
+			// abstract instances of inline functions and constructors, their code is described by concrete instances
+			if childScope.function == nil {
+				childScope.abstract = true
+				break
+			}
 
-			currentFunction.Parameters = append(currentFunction.Parameters, parameter)
+			if currentModule.isFortran() {
+				childScope.function.setFortran()
+			}
 
-		// variable declaration
-		case dwarf.TagVariable:
-			baseType := data.Types[entry.Val(dwarf.AttrType).(dwarf.Offset)]
+			currentModule.functions = append(currentModule.functions, childScope.function)
 
-			if baseType == nil {
-				baseType = &BaseType{
-					name: "unknown type",
-				}
+		// block of statements with its own variables
+		case dwarf.TagLexDwarfBlock:
+			ranges, err := rawData.Ranges(entry)
+			if err != nil || len(ranges) == 0 {
+				break
 			}
 
-			variable := &Variable{
-				name:     entry.Val(dwarf.AttrName).(string),
-				baseType: baseType,
-				Function: currentFunction,
+			childScope.block = &LexicalBlock{
+				ranges: ranges,
+				parent: currentScope.block,
 			}
 
-			locationInstructions := entry.Val(dwarf.AttrLocation)
+		case dwarf.TagFormalParameter:
+			if currentScope.function == nil {
+				break
+			}
 
-			if reflect.TypeOf(locationInstructions) != nil {
-				variable.locationInstructions = entry.Val(dwarf.AttrLocation).([]byte)
+			parameter := parseFunctionParameter(entry, data, types, currentModule)
+			parameter.fortran = currentModule.isFortran()
+
+			currentScope.function.Parameters = append(currentScope.function.Parameters, parameter)
+
+		// variable declaration
+		case dwarf.TagVariable:
+			name, ok := entry.Val(dwarf.AttrName).(string)
+			if !ok || isDeclaration(entry) || currentScope.abstract {
+				break
+			}
+
+			variable := &Variable{
+				name:                 name,
+				baseType:             lookupBaseType(entry, types),
+				Function:             currentScope.function,
+				block:                currentScope.block,
+				locationInstructions: parseLocation(entry, data, currentModule),
+				fortran:              currentModule.isFortran(),
 			}
 
 			currentModule.Variables = append(currentModule.Variables, variable)
@@ -95,14 +254,77 @@ func ParseDwarfData(targetFile string) *DwarfData {
 			// logger.Debug("unhandled tag type: %v", entry.Tag)
 		}
 
+		if entry.Children {
+			scopes = append(scopes, childScope)
+		}
+	}
+}
+
+// the function, the lexical block and the structure (union, class, enumeration) or array type enclosing a debug entry
+type scope struct {
+	function   *Function
+	block      *LexicalBlock
+	structType *BaseType
+	arrayType  *BaseType
+	abstract   bool // the entry is enclosed by a function without code
+}
+
+// Parses a structure, union, class, enumeration, function, typedef, pointer, array or qualified (const, volatile, restrict) type
+func (data *DwarfData) parseType(entry *dwarf.Entry, types typeMap) *BaseType {
+	compositeType := types.at(entry.Offset)
+
+	compositeType.name, _ = entry.Val(dwarf.AttrName).(string)
+	compositeType.byteSize, _ = entry.Val(dwarf.AttrByteSize).(int64)
+	compositeType.tag = entry.Tag
+
+	if entry.Tag == dwarf.TagPointerType && compositeType.byteSize == 0 {
+		compositeType.byteSize = int64(ptrSize())
+	}
+
+	// the pointed-to, aliased or element type
+	if typeOffset, ok := entry.Val(dwarf.AttrType).(dwarf.Offset); ok {
+		compositeType.elemType = types.at(typeOffset)
+	}
+
+	data.parseGoTypeAttributes(entry, compositeType, types)
+
+	return compositeType
+}
+
+// Returns the keyword a structure, union or enumeration type is named with: struct, union and enum in C,
+// type for the derived types of Fortran. Types of C++ and go are named without one
+func typeKeyword(tag dwarf.Tag, module *Module) string {
+	switch {
+	case module.isFortran():
+		return "type"
+	case module == nil || module.Language() != "C":
+		return ""
+	case tag == dwarf.TagUnionType:
+		return "union"
+	case tag == dwarf.TagEnumerationType:
+		return "enum"
+	}
+	return "struct"
+}
+
+func parseFunctionParameter(entry *dwarf.Entry, data *DwarfData, types typeMap, module *Module) *Parameter {
+	name, _ := entry.Val(dwarf.AttrName).(string)
+
+	parameter := &Parameter{
+		Name:                 name,
+		baseType:             lookupBaseType(entry, types),
+		locationInstructions: parseLocation(entry, data, module),
 	}
 
-	return data
+	return parameter
 }
 
-func parseFunctionParameter(entry *dwarf.Entry, data *DwarfData) *Parameter {
+func lookupBaseType(entry *dwarf.Entry, types typeMap) *BaseType {
+	var baseType *BaseType
 
-	baseType := data.Types[(entry.Val(dwarf.AttrType).(dwarf.Offset))]
+	if typeOffset, ok := entry.Val(dwarf.AttrType).(dwarf.Offset); ok {
+		baseType = types.at(typeOffset)
+	}
 
 	if baseType == nil {
 		baseType = &BaseType{
@@ -110,22 +332,100 @@ func parseFunctionParameter(entry *dwarf.Entry, data *DwarfData) *Parameter {
 		}
 	}
 
-	parameter := &Parameter{
-		Name:                 entry.Val(dwarf.AttrName).(string),
-		baseType:             baseType,
-		locationInstructions: entry.Val(dwarf.AttrLocation).([]byte),
+	return baseType
+}
+
+// Returns the location expression of an entry.
+// Location lists (DW_FORM_sec_offset, DW_FORM_loclistx) are not supported and yield no instructions,
+// their variables are treated as optimized out. So are the variables at indexed addresses that cannot be resolved
+func parseLocation(entry *dwarf.Entry, data *DwarfData, module *Module) locationInstructions {
+	instructions, ok := entry.Val(dwarf.AttrLocation).([]byte)
+	if !ok {
+		return nil
 	}
 
-	return parameter
+	resolved, err := data.resolveIndexedAddress(instructions, module)
+	if err != nil {
+		name, _ := entry.Val(dwarf.AttrName).(string)
+		logger.Warn("cannot locate %v in %v: %v", name, module.name, err)
+		return nil
+	}
+
+	return resolved
+}
+
+// Rewrites a DW_OP_addrx/DW_OP_constx (DWARF 5) expression into an equivalent DW_OP_addr/DW_OP_constu
+// expression, reading the address from the .debug_addr table of the module. Fails if the module gives no
+// DW_AT_addr_base: the table of each unit has a header of its own, the base cannot be told from the section
+func (d *DwarfData) resolveIndexedAddress(instructions locationInstructions, module *Module) (locationInstructions, error) {
+	if len(instructions) == 0 {
+		return instructions, nil
+	}
+
+	opcode := Opcode(instructions[0])
+
+	if opcode != DW_OP_addrx && opcode != DW_OP_constx && opcode != DW_OP_GNU_addr_index {
+		return instructions, nil
+	}
+
+	if !module.hasAddrBase {
+		return nil, errors.New("indexed address in a unit without DW_AT_addr_base")
+	}
+
+	buf := bytes.NewBuffer(instructions[1:])
+	index, _ := DecodeULEB128(buf)
+
+	size := ptrSize()
+	offset := module.addrBase + index*uint64(size)
+
+	if offset+uint64(size) > uint64(len(d.debugAddr)) {
+		return nil, fmt.Errorf("address index %d beyond .debug_addr", index)
+	}
+
+	address, err := ReadUintRaw(bytes.NewReader(d.debugAddr[offset:offset+uint64(size)]), binary.LittleEndian, size)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]byte, 0, len(instructions))
+
+	if opcode == DW_OP_constx {
+		resolved = append(resolved, byte(DW_OP_constu))
+		resolved = append(resolved, EncodeULEB128(address)...)
+	} else {
+		encodedAddress := make([]byte, size)
+		binary.LittleEndian.PutUint64(encodedAddress, address)
+
+		resolved = append(resolved, byte(DW_OP_addr))
+		resolved = append(resolved, encodedAddress...)
+	}
+
+	return append(resolved, buf.Bytes()...), nil
+}
+
+func isDeclaration(entry *dwarf.Entry) bool {
+	isDeclaration, _ := entry.Val(dwarf.AttrDeclaration).(bool)
+	return isDeclaration
 }
 
+// Parses a function with code, returns nil for functions without any
 func parseFunction(entry *dwarf.Entry, dwarfRawData *dwarf.Data) *Function {
 	function := Function{}
 
+	ranges, err := dwarfRawData.Ranges(entry)
+	if err != nil {
+		panic(err)
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+
 	for _, field := range entry.Field {
 		switch field.Attr {
 		case dwarf.AttrName:
 			function.name = field.Val.(string)
+		case dwarf.AttrLinkageName, attrMIPSLinkageName:
+			function.linkageName = field.Val.(string)
 		case dwarf.AttrDeclFile:
 			function.file = int(field.Val.(int64))
 		case dwarf.AttrDeclLine:
@@ -149,10 +449,16 @@ func parseFunction(entry *dwarf.Entry, dwarfRawData *dwarf.Data) *Function {
 
 	}
 
-	ranges, err := dwarfRawData.Ranges(entry)
-	if err != nil {
-		panic(err)
+	// definitions of methods and instances of inline functions refer to their declaration for the names
+	if len(function.name) == 0 || len(function.linkageName) == 0 {
+		resolveDeclaredNames(entry, dwarfRawData, &function)
+	}
+
+	if demangled, err := demangle(function.linkageName); err == nil {
+		function.qualifiedName = demangled.qualifiedName
+		function.signature = demangled.signature
 	}
+
 	function.lowPC = ranges[0][0]
 	function.highPC = ranges[0][1]
 	function.Parameters = make([]*Parameter, 0)
@@ -172,12 +478,14 @@ func parseModule(entry *dwarf.Entry, dwarfRawData *dwarf.Data) *Module {
 		case dwarf.AttrName:
 			module.name = field.Val.(string)
 		case dwarf.AttrLanguage:
-			// language can be inferred from the cu attributes. 22-golang, 12-clang
-			// if field.Val.(int64) == 22 {
-			// 	data.lang = golang
-			// }
+			// e.g. 22-golang, 12-clang, 14-gfortran
+			module.language, _ = field.Val.(int64)
 		case dwarf.AttrProducer:
-			// can infer arch
+			// e.g. GNU C17 11.4.0 -mtune=generic -march=x86-64 -g
+			module.producer, _ = field.Val.(string)
+		case dwarf.AttrAddrBase:
+			module.addrBase = uint64(field.Val.(int64))
+			module.hasAddrBase = true
 		}
 	}
 
@@ -186,22 +494,33 @@ func parseModule(entry *dwarf.Entry, dwarfRawData *dwarf.Data) *Module {
 	if err != nil {
 		panic(err)
 	}
-	// might be more than 1 range entry in theory
-	module.startAddress = ranges[0][0]
-	module.endAddress = ranges[0][1]
+	// might be more than 1 range entry in theory, units without code (e.g. go type units) have none
+	if len(ranges) > 0 {
+		module.startAddress = ranges[0][0]
+		module.endAddress = ranges[0][1]
+	}
 
 	lineReader, err := dwarfRawData.LineReader(entry)
 	if err != nil {
 		panic(err)
 	}
 
+	if lineReader == nil {
+		return &module
+	}
+
 	moduleFileIndexMap := make(map[string]int)
 
+	// DWARF 5 line tables index files from 0 and usually repeat the primary
+	// source file as entries 0 and 1, map each name to its first index
 	files := lineReader.Files()
 	for fileIndex, file := range files {
 		if file != nil {
 			module.files[fileIndex] = file.Name
-			moduleFileIndexMap[file.Name] = fileIndex
+
+			if _, exists := moduleFileIndexMap[file.Name]; !exists {
+				moduleFileIndexMap[file.Name] = fileIndex
+			}
 		}
 	}
 
@@ -215,6 +534,10 @@ func parseModule(entry *dwarf.Entry, dwarfRawData *dwarf.Data) *Module {
 			break
 		}
 
+		if le.File == nil {
+			continue
+		}
+
 		entry := Entry{
 			Address:       le.Address,
 			file:          moduleFileIndexMap[le.File.Name],
@@ -236,3 +559,47 @@ func parseModule(entry *dwarf.Entry, dwarfRawData *dwarf.Data) *Module {
 
 	return &module
 }
+
+// Fills in the names of a function from the declarations referred to by DW_AT_specification or DW_AT_abstract_origin
+func resolveDeclaredNames(entry *dwarf.Entry, dwarfRawData *dwarf.Data, function *Function) {
+	reader := dwarfRawData.Reader()
+
+	for depth := 0; depth < 4; depth++ {
+		offset, ok := entry.Val(dwarf.AttrSpecification).(dwarf.Offset)
+		if !ok {
+			offset, ok = entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		}
+		if !ok {
+			return
+		}
+
+		reader.Seek(offset)
+
+		declaration, err := reader.Next()
+		if err != nil || declaration == nil {
+			return
+		}
+
+		if name, ok := declaration.Val(dwarf.AttrName).(string); ok && len(function.name) == 0 {
+			function.name = name
+		}
+
+		if len(function.linkageName) == 0 {
+			function.linkageName, _ = declaration.Val(dwarf.AttrLinkageName).(string)
+		}
+		if len(function.linkageName) == 0 {
+			function.linkageName, _ = declaration.Val(attrMIPSLinkageName).(string)
+		}
+
+		if function.line == 0 {
+			if line, ok := declaration.Val(dwarf.AttrDeclLine).(int64); ok {
+				function.line = line - 1
+			}
+			if file, ok := declaration.Val(dwarf.AttrDeclFile).(int64); ok {
+				function.file = int(file)
+			}
+		}
+
+		entry = declaration
+	}
+}
@@ -0,0 +1,75 @@
+package dwarf
+
+import (
+	"debug/elf"
+	"fmt"
+)
+
+// Returns the address the binary is linked at: the start of its lowest loadable segment, page-aligned.
+// Position-independent binaries and shared libraries are linked at 0 and loaded at an address chosen at runtime
+func LinkAddress(binaryFile string) (address uint64, positionIndependent bool, err error) {
+	elfFile, err := elf.Open(binaryFile)
+	if err != nil {
+		return 0, false, err
+	}
+	defer elfFile.Close()
+
+	found := false
+
+	for _, program := range elfFile.Progs {
+		if program.Type != elf.PT_LOAD {
+			continue
+		}
+
+		if !found || program.Vaddr < address {
+			address = program.Vaddr
+			found = true
+		}
+	}
+
+	if !found {
+		return 0, false, fmt.Errorf("%v has no loadable segments", binaryFile)
+	}
+
+	address &^= pageSize - 1
+
+	return address, elfFile.Type == elf.ET_DYN, nil
+}
+
+const pageSize = 0x1000
+
+// Shifts the addresses in the debug info by the offset a binary is loaded at from its link address
+func (d *DwarfData) Relocate(offset uint64) {
+	relocatedBlocks := make(map[*LexicalBlock]bool)
+
+	for _, module := range d.Modules {
+		module.startAddress += offset
+		module.endAddress += offset
+
+		// the entries may be shared with the other debuggers of the binary, they are relocated on lookup
+		module.relocation += offset
+
+		for _, function := range module.functions {
+			function.lowPC += offset
+			function.highPC += offset
+		}
+
+		for _, variable := range module.Variables {
+			variable.staticBase = offset
+
+			for block := variable.block; block != nil && !relocatedBlocks[block]; block = block.parent {
+				for index := range block.ranges {
+					block.ranges[index][0] += offset
+					block.ranges[index][1] += offset
+				}
+
+				relocatedBlocks[block] = true
+			}
+		}
+	}
+}
+
+// Adds the modules of a shared library loaded into the target
+func (d *DwarfData) AddLibrary(library *DwarfData) {
+	d.Modules = append(d.Modules, library.Modules...)
+}
@@ -0,0 +1,66 @@
+package dwarf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// uncompressed size of a .zdebug_* section at most, a larger size in the header is taken for a corrupt section
+const maxDebugSectionSize = 1 << 32
+
+// Returns the uncompressed contents of a debug section, or nil if the binary does not contain it.
+// Sections compressed with SHF_COMPRESSED (zlib, zstd) are decompressed by debug/elf,
+// the legacy GNU format (.zdebug_* sections) is handled here
+func readDebugSection(elfFile *elf.File, name string) ([]byte, error) {
+	if section := elfFile.Section(name); section != nil {
+		return section.Data()
+	}
+
+	section := elfFile.Section(strings.Replace(name, ".debug_", ".zdebug_", 1))
+	if section == nil {
+		return nil, nil
+	}
+
+	contents, err := section.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	return decompressGnuSection(contents)
+}
+
+// .zdebug_* section contents: "ZLIB", 8-byte big-endian uncompressed size, zlib stream
+func decompressGnuSection(contents []byte) ([]byte, error) {
+	if len(contents) < 12 || string(contents[:4]) != "ZLIB" {
+		return nil, fmt.Errorf("unsupported compressed section format")
+	}
+
+	size := binary.BigEndian.Uint64(contents[4:12])
+	if size > maxDebugSectionSize {
+		return nil, fmt.Errorf("compressed section of %d bytes exceeds the limit of %d bytes", size, maxDebugSectionSize)
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(contents[12:]))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	// the buffer grows with the stream, not to the size of the header
+	var decompressed bytes.Buffer
+
+	_, err = io.Copy(&decompressed, io.LimitReader(reader, int64(size)))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing section: %v", err)
+	}
+	if uint64(decompressed.Len()) != size {
+		return nil, fmt.Errorf("error decompressing section: %d of %d bytes", decompressed.Len(), size)
+	}
+
+	return decompressed.Bytes(), nil
+}
@@ -0,0 +1,57 @@
+package dwarf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+)
+
+// .zdebug_* section contents of the data, with the uncompressed size given in the header
+func gnuSection(data []byte, size uint64) []byte {
+	var contents bytes.Buffer
+
+	contents.WriteString("ZLIB")
+	binary.Write(&contents, binary.BigEndian, size)
+
+	writer := zlib.NewWriter(&contents)
+	writer.Write(data)
+	writer.Close()
+
+	return contents.Bytes()
+}
+
+func TestDecompressGnuSection(t *testing.T) {
+	data := bytes.Repeat([]byte("debug info "), 1000)
+
+	decompressed, err := decompressGnuSection(gnuSection(data, uint64(len(data))))
+	if err != nil {
+		t.Fatalf("decompressGnuSection failed: %v", err)
+	}
+	if !bytes.Equal(decompressed, data) {
+		t.Errorf("decompressGnuSection has %d bytes, want the %d bytes compressed", len(decompressed), len(data))
+	}
+
+	empty, err := decompressGnuSection(gnuSection(nil, 0))
+	if err != nil || len(empty) != 0 {
+		t.Errorf("decompressGnuSection of an empty section = %q, %v, want no bytes", empty, err)
+	}
+}
+
+func TestDecompressGnuSectionInvalid(t *testing.T) {
+	data := []byte("debug info")
+
+	tests := map[string][]byte{
+		"too short":           []byte("ZLIB"),
+		"not zlib":            append([]byte("ZSTD"), gnuSection(data, uint64(len(data)))[4:]...),
+		"corrupt stream":      append(gnuSection(data, uint64(len(data)))[:12], "not a zlib stream"...),
+		"truncated stream":    gnuSection(data, uint64(len(data))+1),
+		"size over the limit": gnuSection(data, maxDebugSectionSize+1),
+	}
+
+	for name, contents := range tests {
+		if decompressed, err := decompressGnuSection(contents); err == nil {
+			t.Errorf("decompressGnuSection of %s = %d bytes, want an error", name, len(decompressed))
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package dwarf
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// Split DWARF (-gsplit-dwarf): the binary holds skeleton units with address ranges and line tables,
+// while types, functions and variables live in the split units of .dwo files (or a combined .dwp package)
+
+const (
+	attrGNUDwoName dwarf.Attr = 0x2130 // pre-standard (DWARF 4) split units
+
+	utSkeleton = 0x04 // DW_UT_skeleton
+
+	// header sizes of the DWARF 5 offset tables, 32-bit format
+	strOffsetsHeaderSize = 8
+	listsHeaderSize      = 12
+)
+
+// section identifiers of .dwp index tables (DWARF 5)
+const (
+	sectInfo       = 1
+	sectAbbrev     = 3
+	sectLine       = 4
+	sectLoclists   = 5
+	sectStrOffsets = 6
+	sectRnglists   = 8
+)
+
+// raw sections of a single split unit
+type splitSections struct {
+	info       []byte
+	abbrev     []byte
+	line       []byte
+	str        []byte
+	strOffsets []byte
+	rnglists   []byte
+	loclists   []byte
+}
+
+func isSkeletonUnit(entry *dwarf.Entry) bool {
+	return entry.Tag == dwarf.TagSkeletonUnit || entry.Val(dwarf.AttrDwoName) != nil || entry.Val(attrGNUDwoName) != nil
+}
+
+// Loads the split unit of the skeleton and parses its entries into the module of the skeleton
+func (data *DwarfData) parseSplitUnit(skeleton *dwarf.Entry, module *Module, targetFile string, debugInfo []byte) {
+	if skeleton.Val(attrGNUDwoName) != nil {
+		logger.Warn("pre-standard split DWARF is not supported, rebuild %v with -gdwarf-5", targetFile)
+		return
+	}
+
+	dwoName, _ := skeleton.Val(dwarf.AttrDwoName).(string)
+
+	dwoId, err := readDwoId(skeleton, debugInfo)
+	if err != nil {
+		logger.Warn("cannot locate split unit of %v: %v", dwoName, err)
+		return
+	}
+
+	sections, err := findSplitSections(skeleton, dwoId, targetFile)
+	if err != nil {
+		logger.Warn("cannot load split unit of %v: %v", dwoName, err)
+		return
+	}
+
+	// the split unit indexes its addresses from the base the skeleton gives
+	debugAddr := data.debugAddr
+	if !module.hasAddrBase {
+		logger.Warn("skeleton unit of %v has no DW_AT_addr_base, the addresses of its split unit are not resolved", dwoName)
+		debugAddr = nil
+	}
+
+	rawData, err := sections.toDwarfData(debugAddr, module.addrBase)
+	if err != nil {
+		logger.Warn("invalid split unit of %v: %v", dwoName, err)
+		return
+	}
+
+	// type offsets of the split unit are relative to its own .debug_info section
+	data.parseEntries(rawData, make(typeMap), module, nil)
+}
+
+// The DWARF 5 skeleton unit header ends with the 8-byte id of the split unit
+func readDwoId(skeleton *dwarf.Entry, debugInfo []byte) (uint64, error) {
+	headerEnd := int(skeleton.Offset)
+
+	// unit_length(4) version(2) unit_type(1) address_size(1) debug_abbrev_offset(4) dwo_id(8)
+	if headerEnd < 20 || headerEnd > len(debugInfo) {
+		return 0, errors.New("unexpected skeleton unit header")
+	}
+
+	header := debugInfo[headerEnd-20 : headerEnd]
+
+	version := binary.LittleEndian.Uint16(header[4:6])
+	unitType := header[6]
+
+	if version != 5 || unitType != utSkeleton {
+		return 0, fmt.Errorf("unsupported skeleton unit (version %d, type %d)", version, unitType)
+	}
+
+	return binary.LittleEndian.Uint64(header[12:20]), nil
+}
+
+// Looks up the split unit in a .dwp package next to the binary, then in the .dwo file named by the skeleton
+func findSplitSections(skeleton *dwarf.Entry, dwoId uint64, targetFile string) (*splitSections, error) {
+	dwpFile := targetFile + ".dwp"
+
+	if _, err := os.Stat(dwpFile); err == nil {
+		sections, err := readDwpSections(dwpFile, dwoId)
+		if err == nil {
+			return sections, nil
+		}
+		logger.Debug("split unit %#x not found in %v: %v", dwoId, dwpFile, err)
+	}
+
+	dwoName, _ := skeleton.Val(dwarf.AttrDwoName).(string)
+	compDir, _ := skeleton.Val(dwarf.AttrCompDir).(string)
+
+	candidates := []string{
+		dwoName,
+		filepath.Join(compDir, dwoName),
+		filepath.Join(filepath.Dir(targetFile), filepath.Base(dwoName)),
+	}
+
+	for _, candidate := range candidates {
+		if !filepath.IsAbs(candidate) {
+			continue
+		}
+		if _, err := os.Stat(candidate); err == nil {
+			return readDwoSections(candidate)
+		}
+	}
+
+	return nil, fmt.Errorf("unable to find %v", dwoName)
+}
+
+func readDwoSections(dwoFile string) (*splitSections, error) {
+	elfFile, err := elf.Open(dwoFile)
+	if err != nil {
+		return nil, err
+	}
+	defer elfFile.Close()
+
+	sections := &splitSections{}
+
+	targets := map[string]*[]byte{
+		".debug_info.dwo":        &sections.info,
+		".debug_abbrev.dwo":      &sections.abbrev,
+		".debug_line.dwo":        &sections.line,
+		".debug_str.dwo":         &sections.str,
+		".debug_str_offsets.dwo": &sections.strOffsets,
+		".debug_rnglists.dwo":    &sections.rnglists,
+		".debug_loclists.dwo":    &sections.loclists,
+	}
+
+	for name, target := range targets {
+		if *target, err = readDebugSection(elfFile, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return sections, nil
+}
+
+// Reads the contributions of the split unit from a .dwp package, using the .debug_cu_index table
+func readDwpSections(dwpFile string, dwoId uint64) (*splitSections, error) {
+	elfFile, err := elf.Open(dwpFile)
+	if err != nil {
+		return nil, err
+	}
+	defer elfFile.Close()
+
+	index, err := readDebugSection(elfFile, ".debug_cu_index")
+	if err != nil || index == nil {
+		return nil, errors.New("missing .debug_cu_index")
+	}
+
+	contributions, err := findDwpContributions(index, dwoId)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := readDwoSections(dwpFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// the string table is shared by all units of the package, other sections are sliced per unit
+	slices := map[uint32]*[]byte{
+		sectInfo:       &sections.info,
+		sectAbbrev:     &sections.abbrev,
+		sectLine:       &sections.line,
+		sectStrOffsets: &sections.strOffsets,
+		sectRnglists:   &sections.rnglists,
+		sectLoclists:   &sections.loclists,
+	}
+
+	for sectionId, section := range slices {
+		contribution, ok := contributions[sectionId]
+		if !ok {
+			*section = nil
+			continue
+		}
+
+		if uint64(contribution[0])+uint64(contribution[1]) > uint64(len(*section)) {
+			return nil, fmt.Errorf("invalid contribution for section %d", sectionId)
+		}
+
+		*section = (*section)[contribution[0] : contribution[0]+contribution[1]]
+	}
+
+	return sections, nil
+}
+
+// Returns the (offset, size) contributions of the unit with the given id, keyed by section identifier
+func findDwpContributions(index []byte, dwoId uint64) (map[uint32][2]uint32, error) {
+	reader := bytes.NewReader(index)
+
+	var header struct {
+		Version     uint32
+		ColumnCount uint32
+		UnitCount   uint32
+		SlotCount   uint32
+	}
+
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+
+	if header.Version&0xffff != 5 {
+		return nil, fmt.Errorf("unsupported .dwp index version %d", header.Version&0xffff)
+	}
+
+	signatures := make([]uint64, header.SlotCount)
+	rows := make([]uint32, header.SlotCount)
+	columns := make([]uint32, header.ColumnCount)
+	offsets := make([]uint32, header.UnitCount*header.ColumnCount)
+	sizes := make([]uint32, header.UnitCount*header.ColumnCount)
+
+	for _, table := range []any{signatures, rows, columns, offsets, sizes} {
+		if err := binary.Read(reader, binary.LittleEndian, table); err != nil {
+			return nil, fmt.Errorf("truncated .dwp index: %v", err)
+		}
+	}
+
+	for slot, signature := range signatures {
+		// row numbers are 1-based, 0 marks an empty slot
+		row := rows[slot]
+		if signature != dwoId || row == 0 || row > header.UnitCount {
+			continue
+		}
+
+		contributions := make(map[uint32][2]uint32)
+
+		for column, sectionId := range columns {
+			cell := (row-1)*header.ColumnCount + uint32(column)
+			contributions[sectionId] = [2]uint32{offsets[cell], sizes[cell]}
+		}
+
+		return contributions, nil
+	}
+
+	return nil, fmt.Errorf("unit %#x not in index", dwoId)
+}
+
+// Constructs the debug data of the split unit. The unit has no base attributes of its own,
+// so the offset tables are passed without their headers and .debug_addr from the skeleton's base
+func (s *splitSections) toDwarfData(debugAddr []byte, addrBase uint64) (*dwarf.Data, error) {
+	if s.info == nil || s.abbrev == nil {
+		return nil, errors.New("missing .debug_info.dwo or .debug_abbrev.dwo")
+	}
+
+	rawData, err := dwarf.New(s.abbrev, nil, nil, s.info, nil, nil, nil, s.str)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.strOffsets) > strOffsetsHeaderSize {
+		if err := rawData.AddSection(".debug_str_offsets", s.strOffsets[strOffsetsHeaderSize:]); err != nil {
+			return nil, err
+		}
+	}
+
+	if addrBase <= uint64(len(debugAddr)) {
+		if err := rawData.AddSection(".debug_addr", debugAddr[addrBase:]); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(s.rnglists) > listsHeaderSize {
+		if err := rawData.AddSection(".debug_rnglists", s.rnglists[listsHeaderSize:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return rawData, nil
+}
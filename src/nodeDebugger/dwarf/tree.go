@@ -0,0 +1,299 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"strings"
+)
+
+const maxSummaryLength = 80 // characters of the summary of a structure or array expanded level by level
+
+// A value located in the memory of the target, expanded level by level by front-ends
+type LocatedValue struct {
+	Name     string // name of the variable, or of the value in its parent
+	Variable *Variable
+	Address  uint64
+}
+
+// Returns the type name, a summary and the number of children of a value. Only the value itself is read,
+// the summary of a structure or array is cut short instead of following its elements
+func (d *DwarfData) DescribeValue(value LocatedValue, readMemory ReadMemoryFunc) (typeName string, summary string, children int) {
+	formatter := goValueFormatter{d, readMemory, 0}
+	valueType := value.Variable.baseType.resolved()
+
+	typeName = describeType(value.Variable.baseType)
+	children = formatter.countChildren(valueType, value.Address)
+
+	// arrays of structures, pointers or arrays are not formatted element by element
+	nestedArray := valueType.tag == dwarf.TagArrayType && (len(valueType.dimensions) > 1 || (valueType.elemType.resolved() != nil && valueType.elemType.resolved().tag != 0))
+
+	switch {
+	case valueType.goKind != 0:
+		// formatted as if at the deepest level shown, the nested values are elided
+		summary = formatter.format(valueType, value.Address, maxGoValueDepth)
+	case valueType.isAggregate():
+		summary = fmt.Sprintf("{...} (%d fields)", len(valueType.members))
+	case nestedArray:
+		summary = fmt.Sprintf("{...} (%d elements)", children)
+	case valueType.tag == dwarf.TagArrayType:
+		summary = d.FormatValue(value.Variable, value.Address, readMemory)
+	case valueType.tag == dwarf.TagPointerType:
+		pointer, err := formatter.readPointer(value.Address)
+		if err != nil {
+			return typeName, unreadable(value.Address, err), 0
+		}
+		summary = fmt.Sprintf("%#x", pointer)
+		if valueType.elemType.isCharacter() {
+			summary = formatter.formatStringPointer(valueType, pointer)
+		}
+	case valueType.tag == dwarf.TagEnumerationType:
+		summary = formatter.formatEnumeration(valueType, value.Address)
+	default:
+		summary = formatter.formatScalar(valueType, value.Address)
+	}
+
+	if len(summary) > maxSummaryLength {
+		summary = summary[:maxSummaryLength] + "..."
+	}
+
+	return typeName, summary, children
+}
+
+// Returns the children of a value from the child at start on, at most count of them (0 - all):
+// the fields of a structure, the elements of an array or slice, the value a pointer points to
+func (d *DwarfData) ValueChildren(value LocatedValue, readMemory ReadMemoryFunc, start int, count int) ([]LocatedValue, error) {
+	formatter := goValueFormatter{d, readMemory, 0}
+	valueType := value.Variable.baseType.resolved()
+
+	total := formatter.countChildren(valueType, value.Address)
+	if total == 0 {
+		return nil, fmt.Errorf("%s has no fields or elements", value.Name)
+	}
+
+	if start < 0 || start >= total {
+		return nil, fmt.Errorf("%s has %d children, cannot start at %d", value.Name, total, start)
+	}
+
+	end := total
+	if count > 0 && start+count < total {
+		end = start + count
+	}
+
+	children := make([]LocatedValue, 0, end-start)
+
+	for index := start; index < end; index++ {
+		child, err := formatter.child(value, valueType, index)
+		if err != nil {
+			return children, err
+		}
+		children = append(children, child)
+	}
+
+	return children, nil
+}
+
+// Returns the number of children of a value, 0 for scalars, strings, maps and values that cannot be read
+func (f goValueFormatter) countChildren(valueType *BaseType, address uint64) int {
+	switch {
+	case valueType.goKind == goKindStruct || (valueType.goKind == 0 && valueType.isAggregate()):
+		return len(valueType.members)
+	case valueType.goKind == goKindArray:
+		elemType := valueType.elemType.resolved()
+		if elemType == nil || elemType.byteSize == 0 {
+			return 0
+		}
+		return int(valueType.byteSize / elemType.byteSize)
+	case valueType.goKind == goKindSlice:
+		pointer, length, err := f.sliceData(valueType, address)
+		if err != nil || pointer == 0 {
+			return 0
+		}
+		return int(length)
+	case valueType.goKind == goKindPointer || (valueType.goKind == 0 && valueType.tag == dwarf.TagPointerType):
+		pointedType := valueType.elemType.resolved()
+		if pointedType == nil || pointedType.byteSize == 0 {
+			return 0
+		}
+
+		pointer, err := f.readPointer(address)
+		if err != nil || pointer == 0 {
+			return 0
+		}
+		return 1
+	case valueType.goKind == 0 && valueType.tag == dwarf.TagArrayType:
+		if len(valueType.dimensions) == 0 {
+			return 0
+		}
+
+		for _, dimension := range valueType.dimensions {
+			if dimension.count < 0 {
+				return 0
+			}
+		}
+		return int(outermostDimension(valueType).count)
+	}
+
+	return 0
+}
+
+// Returns the child of a value at the index
+func (f goValueFormatter) child(parent LocatedValue, valueType *BaseType, index int) (LocatedValue, error) {
+	// the variable of a child is named by its path from the variable expanded, for the errors reading it
+	childOf := func(name string, path string, childType *BaseType, address uint64) LocatedValue {
+		return LocatedValue{
+			Name:     name,
+			Variable: &Variable{name: path, baseType: childType, fortran: parent.Variable.fortran},
+			Address:  address,
+		}
+	}
+
+	switch {
+	case len(valueType.members) > 0 && (valueType.goKind == goKindStruct || valueType.isAggregate()):
+		member := valueType.members[index]
+		return childOf(member.name, parent.Variable.name+"."+member.name, member.baseType, parent.Address+uint64(member.offset)), nil
+	case valueType.goKind == goKindArray:
+		name := fmt.Sprintf("[%d]", index)
+		return childOf(name, parent.Variable.name+name, valueType.elemType, parent.Address+uint64(index)*uint64(valueType.elemType.resolved().byteSize)), nil
+	case valueType.goKind == goKindSlice:
+		pointer, _, err := f.sliceData(valueType, parent.Address)
+		if err != nil {
+			return LocatedValue{}, err
+		}
+
+		elemType := valueType.goElemType
+		if elemType == nil {
+			elemType = valueType.member("array").baseType.resolved().elemType
+		}
+		name := fmt.Sprintf("[%d]", index)
+		return childOf(name, parent.Variable.name+name, elemType, pointer+uint64(index)*uint64(elemType.resolved().byteSize)), nil
+	case valueType.tag == dwarf.TagPointerType:
+		pointer, err := f.readPointer(parent.Address)
+		if err != nil {
+			return LocatedValue{}, err
+		}
+		return childOf("*"+parent.Name, "*"+parent.Variable.name, valueType.elemType, pointer), nil
+	case valueType.tag == dwarf.TagArrayType:
+		return f.subarray(parent, valueType, index), nil
+	}
+
+	return LocatedValue{}, fmt.Errorf("%s has no children", parent.Name)
+}
+
+// Returns the part of an array at an index of its outermost dimension in memory, the first dimension of a C array
+// and the last one of a Fortran array. It is an array of the other dimensions, or an element if there are none
+func (f goValueFormatter) subarray(parent LocatedValue, arrayType *BaseType, index int) LocatedValue {
+	dimension := outermostDimension(arrayType)
+	elemType := arrayType.elemType
+
+	rest := make([]arrayDimension, 0, len(arrayType.dimensions)-1)
+	stride := uint64(elemType.resolved().byteSize)
+
+	var name string
+
+	if arrayType.columnMajor {
+		rest = append(rest, arrayType.dimensions[:len(arrayType.dimensions)-1]...)
+		name = fmt.Sprintf("(%s%d)", strings.Repeat(":,", len(rest)), dimension.lowerBound+int64(index))
+	} else {
+		rest = append(rest, arrayType.dimensions[1:]...)
+		name = fmt.Sprintf("[%d]", dimension.lowerBound+int64(index))
+	}
+
+	childType := elemType
+	if len(rest) > 0 {
+		for _, restDimension := range rest {
+			stride *= uint64(restDimension.count)
+		}
+
+		childType = &BaseType{
+			name:        arrayType.name,
+			byteSize:    int64(stride),
+			tag:         dwarf.TagArrayType,
+			elemType:    elemType,
+			dimensions:  rest,
+			columnMajor: arrayType.columnMajor,
+		}
+	}
+
+	return LocatedValue{
+		Name:     name,
+		Variable: &Variable{name: parent.Variable.name + name, baseType: childType, fortran: parent.Variable.fortran},
+		Address:  parent.Address + uint64(index)*stride,
+	}
+}
+
+func outermostDimension(arrayType *BaseType) arrayDimension {
+	if arrayType.columnMajor {
+		return arrayType.dimensions[len(arrayType.dimensions)-1]
+	}
+	return arrayType.dimensions[0]
+}
+
+// Reads the pointer to the elements and the length of a go slice
+func (f goValueFormatter) sliceData(sliceType *BaseType, address uint64) (pointer uint64, length uint64, err error) {
+	arrayMember, lenMember := sliceType.member("array"), sliceType.member("len")
+	if arrayMember == nil || lenMember == nil {
+		return 0, 0, fmt.Errorf("unsupported slice layout of %s", sliceType.name)
+	}
+
+	pointer, err = f.readPointer(address + uint64(arrayMember.offset))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	length, err = f.readUint(address+uint64(lenMember.offset), lenMember.baseType.resolved().byteSize)
+	return pointer, length, err
+}
+
+var qualifierNames = map[dwarf.Tag]string{
+	dwarf.TagConstType:    "const",
+	dwarf.TagVolatileType: "volatile",
+	dwarf.TagRestrictType: "restrict",
+}
+
+// Returns the name of a type as written in the source, unnamed pointer and array types by the types they are made of
+func describeType(t *BaseType) string {
+	if t == nil {
+		return "void"
+	}
+
+	switch {
+	case len(t.name) > 0 && t.keyword == "type":
+		return fmt.Sprintf("type(%s)", t.name)
+	case len(t.name) > 0 && len(t.keyword) > 0:
+		return t.keyword + " " + t.name
+	case len(t.name) > 0:
+		return t.name
+	}
+
+	switch t.tag {
+	case dwarf.TagConstType, dwarf.TagVolatileType, dwarf.TagRestrictType:
+		return qualifierNames[t.tag] + " " + describeType(t.elemType)
+	case dwarf.TagPointerType:
+		switch {
+		case t.elemType != nil && t.elemType.tag == dwarf.TagSubroutineType:
+			return describeType(t.elemType.elemType) + " (*)()"
+		case t.elemType != nil && len(t.elemType.name) == 0 && t.elemType.tag == dwarf.TagArrayType:
+			return describeType(t.elemType.elemType) + " (*)" + arrayBounds(t.elemType)
+		}
+		return describeType(t.elemType) + " *"
+	case dwarf.TagSubroutineType:
+		return describeType(t.elemType) + " ()"
+	case dwarf.TagArrayType:
+		bounds := ""
+		for _, dimension := range t.dimensions {
+			if dimension.count < 0 {
+				bounds += "[]"
+			} else {
+				bounds += fmt.Sprintf("[%d]", dimension.count)
+			}
+		}
+		return describeType(t.elemType) + bounds
+	case dwarf.TagStructType, dwarf.TagUnionType, dwarf.TagEnumerationType, dwarf.TagClassType:
+		if len(t.keyword) > 0 {
+			return t.keyword + " {...}"
+		}
+		return "struct {...}"
+	}
+
+	return "?"
+}
@@ -0,0 +1,179 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"strings"
+)
+
+const maxArrayElements = 32 // elements shown of each dimension of an array
+
+// Returns whether the variable is a C or Fortran array
+func (v *Variable) IsArray() bool {
+	variableType := v.baseType.resolved()
+	return variableType.tag == dwarf.TagArrayType && variableType.goKind == 0
+}
+
+// Returns whether the variable is a C or Fortran pointer
+func (v *Variable) IsPointer() bool {
+	variableType := v.baseType.resolved()
+	return variableType.tag == dwarf.TagPointerType && variableType.goKind == 0
+}
+
+// Returns whether the variable is a floating point number (a Fortran real or complex)
+func (v *Variable) IsFloat() bool {
+	variableType := v.baseType.resolved()
+	return variableType.tag == 0 && (variableType.encoding == encodingFloat || variableType.encoding == encodingComplexFloat)
+}
+
+// Formats the value of a C or Fortran variable located at the address: arrays, structures, pointers and scalars
+func (d *DwarfData) FormatValue(variable *Variable, address uint64, readMemory ReadMemoryFunc) string {
+	formatter := goValueFormatter{d, readMemory, 0}
+
+	return formatter.format(variable.baseType, address, 0)
+}
+
+// Formats a C or Fortran array, the elements of its dimensions in the order they are laid out in memory
+func (f goValueFormatter) formatCArray(arrayType *BaseType, address uint64) string {
+	for _, dimension := range arrayType.dimensions {
+		if dimension.count < 0 {
+			return fmt.Sprintf("<array %s with bounds known at runtime>", describeType(arrayType))
+		}
+	}
+
+	// dimensions in the order they are laid out in memory, the outermost first
+	dimensions := make([]arrayDimension, len(arrayType.dimensions))
+	copy(dimensions, arrayType.dimensions)
+
+	if arrayType.columnMajor {
+		for left, right := 0, len(dimensions)-1; left < right; left, right = left+1, right-1 {
+			dimensions[left], dimensions[right] = dimensions[right], dimensions[left]
+		}
+	}
+
+	return f.formatDimensions(arrayType, address, dimensions)
+}
+
+// Formats the elements of an array, nested by dimension. Fortran arrays are enclosed in parentheses, C arrays in braces
+func (f goValueFormatter) formatDimensions(arrayType *BaseType, address uint64, dimensions []arrayDimension) string {
+	elemType := arrayType.elemType.resolved()
+
+	if len(dimensions) == 0 {
+		return f.format(elemType, address, 0)
+	}
+
+	if len(dimensions) == 1 && f.printFormat == 0 && elemType.isCharacter() && !arrayType.columnMajor {
+		return f.formatStringArray(address, dimensions[0].count)
+	}
+
+	stride := uint64(elemType.byteSize)
+	for _, dimension := range dimensions[1:] {
+		stride *= uint64(dimension.count)
+	}
+
+	elements := make([]string, 0)
+
+	for index := int64(0); index < dimensions[0].count; index++ {
+		if index == maxArrayElements {
+			elements = append(elements, "...")
+			break
+		}
+
+		elements = append(elements, f.formatDimensions(arrayType, address+uint64(index)*stride, dimensions[1:]))
+	}
+
+	if arrayType.columnMajor {
+		return fmt.Sprintf("(%s)", strings.Join(elements, ", "))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(elements, ", "))
+}
+
+// Returns the bytes taken up by the elements of an array variable
+func (v *Variable) ArrayByteSize() (int64, error) {
+	if !v.IsArray() {
+		return 0, fmt.Errorf("%s is not an array", v.name)
+	}
+
+	arrayType := v.baseType.resolved()
+	size := arrayType.elemType.resolved().byteSize
+
+	for _, dimension := range arrayType.dimensions {
+		if dimension.count < 0 {
+			return 0, fmt.Errorf("bounds of %s are only known at runtime", v.name)
+		}
+		size *= dimension.count
+	}
+
+	return size, nil
+}
+
+// Returns the element of an array variable at the indices, one for each dimension in the order of declaration.
+// Indices are relative to the lower bounds of the dimensions, e.g. starting at 1 in Fortran
+func (v *Variable) Element(address uint64, indices []int64) (element *Variable, elementAddress uint64, err error) {
+	if !v.IsArray() {
+		return nil, 0, fmt.Errorf("%s is not an array", v.name)
+	}
+
+	arrayType := v.baseType.resolved()
+
+	if len(indices) != len(arrayType.dimensions) {
+		return nil, 0, fmt.Errorf("%s has %d dimensions, got %d indices", v.name, len(arrayType.dimensions), len(indices))
+	}
+
+	offset := int64(0) // offset of the element in elements
+	stride := int64(1) // elements between consecutive indices of the current dimension
+
+	for position := range indices {
+		// the first dimension varies fastest in column-major order, the last one in row-major order
+		dimensionIndex := len(indices) - 1 - position
+		if arrayType.columnMajor {
+			dimensionIndex = position
+		}
+
+		dimension := arrayType.dimensions[dimensionIndex]
+		index := indices[dimensionIndex]
+
+		if dimension.count < 0 {
+			return nil, 0, fmt.Errorf("bounds of %s are only known at runtime", v.name)
+		}
+
+		if index < dimension.lowerBound || index >= dimension.lowerBound+dimension.count {
+			return nil, 0, fmt.Errorf("index %d out of bounds %d:%d", index, dimension.lowerBound, dimension.lowerBound+dimension.count-1)
+		}
+
+		offset += (index - dimension.lowerBound) * stride
+		stride *= dimension.count
+	}
+
+	element = &Variable{
+		name:     fmt.Sprintf("%s%v", v.name, indices),
+		baseType: arrayType.elemType,
+		fortran:  v.fortran,
+	}
+
+	return element, address + uint64(offset*arrayType.elemType.resolved().byteSize), nil
+}
+
+// Returns the name the type of the variable is declared with, keeping typedef names (e.g. MPI_Status)
+func (v *Variable) TypeName() string {
+	if v.baseType == nil {
+		return ""
+	}
+	return v.baseType.name
+}
+
+// Returns the field of a structure variable with a matching name
+func (v *Variable) Field(address uint64, name string) (field *Variable, fieldAddress uint64, err error) {
+	member := v.baseType.member(name)
+	if member == nil {
+		return nil, 0, fmt.Errorf("%s has no field %s", v.name, name)
+	}
+
+	field = &Variable{
+		name:     fmt.Sprintf("%s.%s", v.name, name),
+		baseType: member.baseType,
+		fortran:  v.fortran,
+	}
+
+	return field, address + uint64(member.offset), nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+)
+
+// events waiting to be sent to the orchestrator. Events emitted while the queue is full are dropped,
+// the target is never held up by a slow orchestrator
+const EVENT_QUEUE_SIZE = 256
+
+// Queues an event of the target for the orchestrator, at the location the target is stopped at
+func emitEvent(ctx *processContext, kind string, format string, args ...any) {
+	if ctx.nodeData == nil {
+		return
+	}
+
+	event := rpc.NodeEvent{
+		NodeId:   ctx.nodeData.id,
+		Kind:     kind,
+		Time:     time.Now(),
+		Location: sourceLocation(ctx, getRegs(ctx, false).Rip),
+		Detail:   fmt.Sprintf(format, args...),
+	}
+
+	select {
+	case ctx.nodeData.events <- event:
+	default:
+		logger.Debug("%v event dropped, the orchestrator does not keep up", kind)
+	}
+}
+
+// Sends the queued events to the orchestrator, all events queued by the time the previous call returns in one call.
+// Runs in its own goroutine until the target exits
+func streamEvents(ctx *processContext) {
+	events := ctx.nodeData.events
+
+	for event := range events {
+		batch := &pb.NodeEvents{Events: []*pb.NodeEvent{event.Proto()}}
+
+		for len(events) > 0 {
+			batch.Events = append(batch.Events, (<-events).Proto())
+		}
+
+		_, err := ctx.nodeData.rpcClient.NodeReporter().Events(context.Background(), batch)
+		if err != nil {
+			logger.Debug("Failed to report %d events: %v", len(batch.Events), err)
+		}
+	}
+}
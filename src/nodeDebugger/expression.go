@@ -0,0 +1,996 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+)
+
+// Expressions over the state of the target, written in C: arithmetic (+ - * / % << >> & | ^ ~), comparisons,
+// logical operators (! && ||), member access (. ->), dereference (*), address-of (&), subscripts of arrays and
+// pointers (a[i], Fortran a(i,j)), casts ((type)value) and parentheses. Operands are integer, floating point,
+// character and string literals, variables of the target and convenience variables. Print, breakpoint conditions,
+// invariants, tracepoints and lastwrite evaluate them the same way
+
+type expression struct {
+	source string
+	root   *expressionNode
+}
+
+func (e *expression) String() string {
+	return e.source
+}
+
+type expressionNode struct {
+	operator string            // operator of the node, empty for operands
+	operands []*expressionNode // operands of the operator, unary operators have one
+	token    expressionToken   // literal or identifier of an operand, member of . and ->, type of a cast
+}
+
+type expressionToken struct {
+	kind  tokenKind
+	value string
+}
+
+type tokenKind int
+
+const (
+	tokenOperator tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdentifier
+)
+
+// operators of the expressions, the longer ones first
+var expressionOperators = []string{
+	"->", "<<", ">>", "<=", ">=", "==", "!=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "!", "~", "&", "|", "^", "(", ")", "[", "]", ".", ",",
+}
+
+// precedence of the binary operators, the higher binding tighter
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"^":  4,
+	"&":  5,
+	"==": 6, "!=": 6,
+	"<": 7, "<=": 7, ">": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+var comparisonOperators = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// words of C type names, a parenthesized name containing one is a cast
+var typeKeywords = map[string]bool{
+	"struct": true, "unsigned": true, "signed": true, "const": true, "volatile": true, "void": true, "_Bool": true,
+	"char": true, "short": true, "int": true, "long": true, "float": true, "double": true, "bool": true,
+}
+
+func parseExpression(source string) (*expression, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &expressionParser{tokens: tokens}
+
+	root, err := parser.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.position < len(tokens) {
+		return nil, fmt.Errorf("unexpected %q in %s", tokens[parser.position].value, source)
+	}
+
+	return &expression{source, root}, nil
+}
+
+func tokenizeExpression(source string) ([]expressionToken, error) {
+	tokens := make([]expressionToken, 0)
+
+	for position := 0; position < len(source); {
+		char := rune(source[position])
+
+		switch {
+		case unicode.IsSpace(char):
+			position++
+
+		case unicode.IsDigit(char) || (char == '.' && position+1 < len(source) && unicode.IsDigit(rune(source[position+1]))):
+			end := position + 1
+			for end < len(source) && (unicode.IsLetter(rune(source[end])) || unicode.IsDigit(rune(source[end])) || source[end] == '.' ||
+				((source[end] == '+' || source[end] == '-') && (source[end-1] == 'e' || source[end-1] == 'E') && !strings.HasPrefix(source[position:], "0x"))) {
+				end++
+			}
+			tokens = append(tokens, expressionToken{tokenNumber, source[position:end]})
+			position = end
+
+		case char == '"' || char == '\'':
+			end := position + 1
+			for end < len(source) && rune(source[end]) != char {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, fmt.Errorf("unterminated literal in %s", source)
+			}
+
+			literal, err := unquoteLiteral(source[position : end+1])
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, literal)
+			position = end + 1
+
+		case char == '$' || char == '_' || unicode.IsLetter(char):
+			end := position + 1
+			for end < len(source) && (source[end] == '_' || unicode.IsLetter(rune(source[end])) || unicode.IsDigit(rune(source[end]))) {
+				end++
+			}
+			tokens = append(tokens, expressionToken{tokenIdentifier, source[position:end]})
+			position = end
+
+		default:
+			matched := false
+
+			for _, operator := range expressionOperators {
+				if strings.HasPrefix(source[position:], operator) {
+					tokens = append(tokens, expressionToken{tokenOperator, operator})
+					position += len(operator)
+					matched = true
+					break
+				}
+			}
+
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q in %s", char, source)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// Decodes a string literal, or a character literal to the number of the character
+func unquoteLiteral(literal string) (expressionToken, error) {
+	if strings.HasPrefix(literal, "'") {
+		char, _, tail, err := strconv.UnquoteChar(literal[1:len(literal)-1], '\'')
+		if err != nil || len(tail) > 0 {
+			return expressionToken{}, fmt.Errorf("invalid character %s", literal)
+		}
+		return expressionToken{tokenNumber, strconv.Itoa(int(char))}, nil
+	}
+
+	value, err := strconv.Unquote(literal)
+	if err != nil {
+		return expressionToken{}, fmt.Errorf("invalid string %s", literal)
+	}
+	return expressionToken{tokenString, value}, nil
+}
+
+type expressionParser struct {
+	tokens   []expressionToken
+	position int
+}
+
+// Returns the token at an offset from the current one, an empty token past the end
+func (p *expressionParser) peek(offset int) expressionToken {
+	if p.position+offset >= len(p.tokens) {
+		return expressionToken{kind: tokenOperator}
+	}
+	return p.tokens[p.position+offset]
+}
+
+func (p *expressionParser) nextOperator(operators ...string) (string, bool) {
+	token := p.peek(0)
+	if token.kind != tokenOperator {
+		return "", false
+	}
+
+	for _, operator := range operators {
+		if token.value == operator {
+			p.position++
+			return operator, true
+		}
+	}
+
+	return "", false
+}
+
+// Parses the binary operators binding at least as tight as the precedence, left to right
+func (p *expressionParser) parseBinary(precedence int) (*expressionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := p.peek(0)
+		operatorPrecedence, ok := binaryPrecedence[token.value]
+		if token.kind != tokenOperator || !ok || operatorPrecedence < precedence {
+			return left, nil
+		}
+		p.position++
+
+		right, err := p.parseBinary(operatorPrecedence + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &expressionNode{operator: token.value, operands: []*expressionNode{left, right}}
+	}
+}
+
+func (p *expressionParser) parseUnary() (*expressionNode, error) {
+	if operator, ok := p.nextOperator("-", "+", "!", "~", "*", "&"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &expressionNode{operator: operator, operands: []*expressionNode{operand}}, nil
+	}
+
+	if typeName, length := p.castType(); length > 0 {
+		p.position += length
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &expressionNode{operator: "cast", operands: []*expressionNode{operand}, token: expressionToken{tokenIdentifier, typeName}}, nil
+	}
+
+	return p.parsePostfix()
+}
+
+// Recognizes a cast at the current token: a parenthesized type name, words followed by pointer stars. Names
+// without a word of the C types and stars, e.g. (MPI_Datatype), are casts only if an operand follows them.
+// Returns the type and the number of tokens of the cast, 0 if there is none
+func (p *expressionParser) castType() (string, int) {
+	if token := p.peek(0); token.kind != tokenOperator || token.value != "(" {
+		return "", 0
+	}
+
+	words := make([]string, 0)
+	stars := 0
+	keyword := false
+
+	length := 1
+	for token := p.peek(length); !(token.kind == tokenOperator && token.value == ")"); token = p.peek(length) {
+		switch {
+		case token.kind == tokenIdentifier && stars == 0 && !strings.HasPrefix(token.value, "$"):
+			words = append(words, token.value)
+			keyword = keyword || typeKeywords[token.value]
+		case token.kind == tokenOperator && token.value == "*" && len(words) > 0:
+			stars++
+		default:
+			return "", 0
+		}
+		length++
+	}
+
+	if len(words) == 0 {
+		return "", 0
+	}
+
+	following := p.peek(length + 1)
+	startsOperand := following.kind != tokenOperator || following.value == "("
+
+	if !keyword && stars == 0 && !startsOperand {
+		return "", 0
+	}
+
+	return strings.Join(words, " ") + strings.Repeat(" *", stars), length + 1
+}
+
+// Parses an operand followed by subscripts and member accesses
+func (p *expressionParser) parsePostfix() (*expressionNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.nextOperator("[", "(", ".", "->")
+		if !ok {
+			return node, nil
+		}
+
+		switch operator {
+		case "[":
+			index, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.nextOperator("]"); !ok {
+				return nil, fmt.Errorf("missing ] in subscript")
+			}
+			node = &expressionNode{operator: "[]", operands: []*expressionNode{node, index}}
+
+		case "(":
+			// subscripts of a Fortran array, functions of the target are not called
+			operands := []*expressionNode{node}
+			for {
+				index, err := p.parseBinary(1)
+				if err != nil {
+					return nil, err
+				}
+				operands = append(operands, index)
+
+				if _, ok := p.nextOperator(","); !ok {
+					break
+				}
+			}
+			if _, ok := p.nextOperator(")"); !ok {
+				return nil, fmt.Errorf("missing ) in subscripts")
+			}
+			node = &expressionNode{operator: "()", operands: operands}
+
+		default:
+			member := p.peek(0)
+			if member.kind != tokenIdentifier {
+				return nil, fmt.Errorf("expected a member name after %s", operator)
+			}
+			p.position++
+			node = &expressionNode{operator: operator, operands: []*expressionNode{node}, token: member}
+		}
+	}
+}
+
+func (p *expressionParser) parsePrimary() (*expressionNode, error) {
+	if _, ok := p.nextOperator("("); ok {
+		node, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.nextOperator(")"); !ok {
+			return nil, fmt.Errorf("missing )")
+		}
+		return node, nil
+	}
+
+	token := p.peek(0)
+	if token.kind == tokenOperator {
+		if len(token.value) == 0 {
+			return nil, fmt.Errorf("missing operand")
+		}
+		return nil, fmt.Errorf("unexpected %q", token.value)
+	}
+	p.position++
+
+	switch token.kind {
+	case tokenIdentifier:
+		if strings.HasPrefix(token.value, "$") {
+			if _, ok := convenienceVariables[token.value]; !ok {
+				return nil, fmt.Errorf("unknown convenience variable %s", token.value)
+			}
+		}
+	case tokenNumber:
+		if _, err := parseNumber(token.value); err != nil {
+			return nil, err
+		}
+	}
+
+	return &expressionNode{token: token}, nil
+}
+
+// Parses an integer (decimal, hexadecimal 0x or octal 0) or floating point literal, ignoring C suffixes (u, l, f)
+func parseNumber(literal string) (interface{}, error) {
+	trimmed := strings.TrimRight(strings.ToLower(literal), "ul")
+
+	if value, err := strconv.ParseInt(trimmed, 0, 64); err == nil {
+		return value, nil
+	}
+	if value, err := strconv.ParseUint(trimmed, 0, 64); err == nil {
+		return value, nil
+	}
+	if !strings.HasPrefix(trimmed, "0x") {
+		if value, err := strconv.ParseFloat(strings.TrimSuffix(trimmed, "f"), 64); err == nil {
+			return value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid number %s", literal)
+}
+
+// A value an expression evaluates to: a value stored in the target, located by its declaration and address,
+// or a value computed by the debugger. Computed pointers keep the type they point to in their declaration
+type expressionValue struct {
+	variable *dwarf.Variable // declaration of a stored value, the type of a computed pointer or cast (nil for others)
+	address  uint64          // address of a stored value in the memory of the target
+	value    interface{}     // int64, uint64, float64 or string of a computed value (nil for stored values)
+}
+
+// Whether the value is stored in the memory of the target
+func (v expressionValue) stored() bool {
+	return v.value == nil
+}
+
+// Evaluates the expression where the target is stopped, to a number or string if the value is a scalar,
+// the text of C strings, the formatted value otherwise (structures and arrays)
+func (e *expression) evaluate(ctx *processContext) (interface{}, error) {
+	value, err := e.root.evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if value.stored() && value.variable.IsString() {
+		return value.variable.ReadString(value.address, memoryReader(ctx))
+	}
+
+	if value.stored() && value.variable.IsArray() {
+		return formatExpressionValue(ctx, value), nil
+	}
+
+	if scalar, err := value.scalar(ctx); err == nil {
+		return scalar, nil
+	}
+
+	return formatExpressionValue(ctx, value), nil
+}
+
+// Evaluates the expression to a value stored in the target, e.g. a variable or a field of a structure it points to
+func locateExpression(ctx *processContext, source string) (*dwarf.Variable, uint64, error) {
+	parsed, err := parseExpression(source)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, err := parsed.root.evaluate(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !value.stored() || value.variable == nil {
+		return nil, 0, fmt.Errorf("%s is not stored in the memory of the target", source)
+	}
+
+	return value.variable, value.address, nil
+}
+
+// Formats a value for printing. Stored values are formatted by their types, MPI handles by their names
+func formatExpressionValue(ctx *processContext, value expressionValue) interface{} {
+	if !value.stored() {
+		if value.variable != nil && value.variable.IsPointer() {
+			pointer, _ := toBits(value.value)
+			return ctx.dwarfData.FormatPointer(value.variable, pointer, memoryReader(ctx))
+		}
+		return value.value
+	}
+
+	if formatted := formatMPIValue(ctx, value.variable, value.address); formatted != nil {
+		return formatted
+	}
+
+	if value.variable.IsGoValue() {
+		return ctx.dwarfData.FormatGoValue(value.variable, value.address, memoryReader(ctx))
+	}
+
+	return ctx.dwarfData.FormatValue(value.variable, value.address, memoryReader(ctx))
+}
+
+// Formats a value in a print format (print/x), the scalars of structures and arrays one by one
+func formatExpressionValueAs(ctx *processContext, value expressionValue, format byte) interface{} {
+	switch {
+	case format == 0:
+		return formatExpressionValue(ctx, value)
+	case format == 'r' && !value.stored():
+		if value.variable != nil && value.variable.IsPointer() {
+			return fmt.Sprintf("(%s) %#x", value.variable.DeclaredType(), value.value)
+		}
+		return value.value
+	case value.stored():
+		return ctx.dwarfData.FormatValueAs(value.variable, value.address, memoryReader(ctx), format)
+	case value.variable != nil:
+		return dwarf.FormatNumber(value.value, value.variable.ByteSize(), format)
+	}
+
+	return dwarf.FormatNumber(value.value, 8, format)
+}
+
+// Reads the number of a value. Arrays stand for the address of their first element, as in C
+func (v expressionValue) scalar(ctx *processContext) (interface{}, error) {
+	if !v.stored() {
+		return v.value, nil
+	}
+
+	if v.variable.IsArray() {
+		return v.address, nil
+	}
+
+	return v.variable.ReadScalar(v.address, memoryReader(ctx))
+}
+
+// Returns the declaration of what a pointer or array value points to, nil if the value is no pointer
+func (v expressionValue) pointed() *dwarf.Variable {
+	if v.variable == nil || !(v.variable.IsPointer() || (v.stored() && v.variable.IsArray())) {
+		return nil
+	}
+
+	pointed, err := v.variable.Pointed()
+	if err != nil {
+		return nil
+	}
+	return pointed
+}
+
+func (n *expressionNode) evaluate(ctx *processContext) (expressionValue, error) {
+	switch {
+	case len(n.operator) == 0:
+		return n.evaluateOperand(ctx)
+	case n.operator == "&&" || n.operator == "||":
+		return n.evaluateLogical(ctx)
+	case len(n.operands) == 1 && n.operator != "." && n.operator != "->":
+		return n.evaluateUnary(ctx)
+	}
+
+	operand, err := n.operands[0].evaluate(ctx)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	switch n.operator {
+	case ".", "->":
+		return n.evaluateMember(ctx, operand)
+	case "[]":
+		return n.evaluateSubscript(ctx, operand)
+	case "()":
+		return n.evaluateFortranSubscripts(ctx, operand)
+	}
+
+	right, err := n.operands[1].evaluate(ctx)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	return evaluateBinary(ctx, n.operator, operand, right)
+}
+
+func (n *expressionNode) evaluateOperand(ctx *processContext) (expressionValue, error) {
+	switch n.token.kind {
+	case tokenNumber:
+		value, err := parseNumber(n.token.value)
+		return expressionValue{value: value}, err
+	case tokenString:
+		return expressionValue{value: n.token.value}, nil
+	}
+
+	if strings.HasPrefix(n.token.value, "$") {
+		value := getConvenienceVariable(ctx, n.token.value)
+		if value == nil {
+			return expressionValue{}, fmt.Errorf("cannot evaluate %s", n.token.value)
+		}
+		return expressionValue{value: normalizeValue(value)}, nil
+	}
+
+	variable, address, err := locateVariable(ctx, n.token.value, true)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	return expressionValue{variable: variable, address: address}, nil
+}
+
+// && and || are short-circuited
+func (n *expressionNode) evaluateLogical(ctx *processContext) (expressionValue, error) {
+	for _, operand := range n.operands {
+		value, err := operand.evaluate(ctx)
+		if err != nil {
+			return expressionValue{}, err
+		}
+
+		scalar, err := value.scalar(ctx)
+		if err != nil {
+			return expressionValue{}, err
+		}
+
+		if isTruthy(scalar) == (n.operator == "||") {
+			return expressionValue{value: boolValue(n.operator == "||")}, nil
+		}
+	}
+
+	return expressionValue{value: boolValue(n.operator == "&&")}, nil
+}
+
+func (n *expressionNode) evaluateUnary(ctx *processContext) (expressionValue, error) {
+	operand, err := n.operands[0].evaluate(ctx)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	switch n.operator {
+	case "&":
+		if !operand.stored() || operand.variable == nil {
+			return expressionValue{}, fmt.Errorf("cannot take the address of %v, it is not stored in the target", operand.value)
+		}
+		return expressionValue{variable: operand.variable.AddressOf(), value: operand.address}, nil
+
+	case "*":
+		return dereference(ctx, operand, 0)
+
+	case "cast":
+		return evaluateCast(ctx, n.token.value, operand)
+	}
+
+	scalar, err := operand.scalar(ctx)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	switch n.operator {
+	case "!":
+		return expressionValue{value: boolValue(!isTruthy(scalar))}, nil
+	case "+":
+		return arithmetic("+", int64(0), scalar)
+	case "-":
+		return arithmetic("-", int64(0), scalar)
+	}
+
+	// ~
+	switch typedValue := scalar.(type) {
+	case int64:
+		return expressionValue{value: ^typedValue}, nil
+	case uint64:
+		return expressionValue{value: ^typedValue}, nil
+	}
+	return expressionValue{}, fmt.Errorf("cannot complement %v", scalar)
+}
+
+// Returns the value a pointer points to, at an index of elements from it. Arrays are indexed in place
+func dereference(ctx *processContext, pointer expressionValue, index int64) (expressionValue, error) {
+	pointed := pointer.pointed()
+	if pointed == nil {
+		if pointer.variable != nil {
+			_, err := pointer.variable.Pointed()
+			return expressionValue{}, err
+		}
+		return expressionValue{}, fmt.Errorf("cannot dereference %v, it is not a pointer", pointer.value)
+	}
+
+	scalar, err := pointer.scalar(ctx)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	address := scalar.(uint64)
+	if address == 0 {
+		return expressionValue{}, fmt.Errorf("cannot dereference a null pointer")
+	}
+
+	return expressionValue{variable: pointed, address: address + uint64(index*pointed.ByteSize())}, nil
+}
+
+func evaluateCast(ctx *processContext, typeName string, operand expressionValue) (expressionValue, error) {
+	target, err := ctx.dwarfData.TypedVariable("("+typeName+")", typeName)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	scalar, err := operand.scalar(ctx)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	converted, err := target.ConvertScalar(scalar)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	return expressionValue{variable: target, value: converted}, nil
+}
+
+// Accesses a member of a structure, or of a structure a pointer points to
+func (n *expressionNode) evaluateMember(ctx *processContext, operand expressionValue) (expressionValue, error) {
+	var err error
+
+	if n.operator == "->" || (operand.variable != nil && operand.variable.IsPointer()) {
+		operand, err = dereference(ctx, operand, 0)
+		if err != nil {
+			return expressionValue{}, err
+		}
+	}
+
+	if !operand.stored() || operand.variable == nil || !(operand.variable.IsStruct() || operand.variable.IsGoValue()) {
+		return expressionValue{}, fmt.Errorf("%s of %s: not a structure", n.token.value, n.operands[0].describe())
+	}
+
+	field, address, err := operand.variable.Field(operand.address, n.token.value)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	return expressionValue{variable: field, address: address}, nil
+}
+
+func (n *expressionNode) evaluateSubscript(ctx *processContext, operand expressionValue) (expressionValue, error) {
+	index, err := integerOperand(ctx, n.operands[1])
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	if operand.stored() && operand.variable != nil && operand.variable.IsArray() {
+		element, address, err := operand.variable.Subscript(operand.address, index)
+		if err != nil {
+			return expressionValue{}, err
+		}
+		return expressionValue{variable: element, address: address}, nil
+	}
+
+	return dereference(ctx, operand, index)
+}
+
+func (n *expressionNode) evaluateFortranSubscripts(ctx *processContext, operand expressionValue) (expressionValue, error) {
+	if !operand.stored() || operand.variable == nil || !operand.variable.IsArray() {
+		return expressionValue{}, fmt.Errorf("%s is not an array, functions of the target cannot be called", n.operands[0].describe())
+	}
+
+	indices := make([]int64, 0, len(n.operands)-1)
+	for _, subscript := range n.operands[1:] {
+		index, err := integerOperand(ctx, subscript)
+		if err != nil {
+			return expressionValue{}, err
+		}
+		indices = append(indices, index)
+	}
+
+	element, address, err := operand.variable.Element(operand.address, indices)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	return expressionValue{variable: element, address: address}, nil
+}
+
+// Evaluates an operand that must be an integer, e.g. a subscript
+func integerOperand(ctx *processContext, node *expressionNode) (int64, error) {
+	value, err := node.evaluate(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	scalar, err := value.scalar(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	switch typedValue := scalar.(type) {
+	case int64:
+		return typedValue, nil
+	case uint64:
+		return int64(typedValue), nil
+	}
+	return 0, fmt.Errorf("%s is not an integer", node.describe())
+}
+
+func evaluateBinary(ctx *processContext, operator string, left expressionValue, right expressionValue) (expressionValue, error) {
+	// pointer arithmetic, in elements of the type pointed to
+	if operator == "+" || operator == "-" {
+		leftPointed, rightPointed := left.pointed(), right.pointed()
+
+		switch {
+		case leftPointed != nil && rightPointed != nil && operator == "-":
+			difference, err := arithmeticOf(ctx, "-", left, right)
+			if err != nil {
+				return expressionValue{}, err
+			}
+			bits, _ := toBits(difference.value)
+			return arithmetic("/", int64(bits), leftPointed.ByteSize())
+
+		case leftPointed != nil && rightPointed == nil:
+			return offsetPointer(ctx, operator, left, leftPointed, right)
+
+		case rightPointed != nil && leftPointed == nil && operator == "+":
+			return offsetPointer(ctx, operator, right, rightPointed, left)
+		}
+	}
+
+	// C strings are compared to string literals by their text
+	if text, isString := right.value.(string); isString && comparisonOperators[operator] && left.stored() && left.variable != nil && left.variable.IsString() {
+		leftText, err := left.variable.ReadString(left.address, memoryReader(ctx))
+		if err != nil {
+			return expressionValue{}, err
+		}
+		return compareValues(operator, leftText, text)
+	}
+
+	leftScalar, rightScalar, err := scalars(ctx, left, right)
+	if err != nil {
+		// values that are not numbers, e.g. char arrays, are compared to strings by their formatted values
+		if _, isString := right.value.(string); isString && (operator == "==" || operator == "!=") {
+			return compareValues(operator, fmt.Sprint(formatExpressionValue(ctx, left)), right.value)
+		}
+		return expressionValue{}, err
+	}
+
+	if comparisonOperators[operator] {
+		return compareValues(operator, leftScalar, rightScalar)
+	}
+
+	return arithmetic(operator, leftScalar, rightScalar)
+}
+
+func scalars(ctx *processContext, left expressionValue, right expressionValue) (interface{}, interface{}, error) {
+	leftScalar, err := left.scalar(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rightScalar, err := right.scalar(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return leftScalar, rightScalar, nil
+}
+
+// Applies an arithmetic operator to the scalars of two values, e.g. the addresses of pointers
+func arithmeticOf(ctx *processContext, operator string, left expressionValue, right expressionValue) (expressionValue, error) {
+	leftScalar, rightScalar, err := scalars(ctx, left, right)
+	if err != nil {
+		return expressionValue{}, err
+	}
+	return arithmetic(operator, leftScalar, rightScalar)
+}
+
+// Moves a pointer by a number of elements of the type pointed to
+func offsetPointer(ctx *processContext, operator string, pointer expressionValue, pointed *dwarf.Variable, offset expressionValue) (expressionValue, error) {
+	base, count, err := scalars(ctx, pointer, offset)
+	if err != nil {
+		return expressionValue{}, err
+	}
+	if _, ok := toBits(count); !ok {
+		return expressionValue{}, fmt.Errorf("cannot offset a pointer by %v", count)
+	}
+
+	bytes, err := arithmetic("*", count, pointed.ByteSize())
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	moved, err := arithmetic(operator, base, bytes.value)
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	address, _ := toBits(moved.value)
+
+	return expressionValue{variable: pointed.AddressOf(), value: address}, nil
+}
+
+// Applies an arithmetic or bitwise operator to numbers. Floating point operands make the result floating point,
+// unsigned operands unsigned, as in C
+func arithmetic(operator string, left interface{}, right interface{}) (expressionValue, error) {
+	left, right = normalizeValue(left), normalizeValue(right)
+
+	_, leftFloat := left.(float64)
+	_, rightFloat := right.(float64)
+
+	if leftFloat || rightFloat {
+		leftValue, leftOk := toFloat(left)
+		rightValue, rightOk := toFloat(right)
+		if !leftOk || !rightOk {
+			return expressionValue{}, fmt.Errorf("cannot apply %s to %v and %v", operator, left, right)
+		}
+
+		switch operator {
+		case "+":
+			return expressionValue{value: leftValue + rightValue}, nil
+		case "-":
+			return expressionValue{value: leftValue - rightValue}, nil
+		case "*":
+			return expressionValue{value: leftValue * rightValue}, nil
+		case "/":
+			return expressionValue{value: leftValue / rightValue}, nil
+		}
+		return expressionValue{}, fmt.Errorf("cannot apply %s to floating point numbers", operator)
+	}
+
+	_, leftUnsigned := left.(uint64)
+	_, rightUnsigned := right.(uint64)
+
+	leftBits, leftOk := toBits(left)
+	rightBits, rightOk := toBits(right)
+	if !leftOk || !rightOk {
+		return expressionValue{}, fmt.Errorf("cannot apply %s to %v and %v", operator, left, right)
+	}
+
+	if (operator == "/" || operator == "%") && rightBits == 0 {
+		return expressionValue{}, fmt.Errorf("division by zero")
+	}
+
+	if leftUnsigned || rightUnsigned {
+		result, err := integerArithmetic(operator, leftBits, rightBits, func(a, b uint64) (uint64, uint64) { return a / b, a % b })
+		return expressionValue{value: result}, err
+	}
+
+	result, err := integerArithmetic(operator, leftBits, rightBits, func(a, b uint64) (uint64, uint64) {
+		return uint64(int64(a) / int64(b)), uint64(int64(a) % int64(b))
+	})
+	if operator == ">>" {
+		result = uint64(int64(leftBits) >> rightBits)
+	}
+	return expressionValue{value: int64(result)}, err
+}
+
+// Applies an integer operator to the bits of two's complement operands, dividing them with the division given
+func integerArithmetic(operator string, left uint64, right uint64, divide func(a, b uint64) (uint64, uint64)) (uint64, error) {
+	switch operator {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		quotient, _ := divide(left, right)
+		return quotient, nil
+	case "%":
+		_, remainder := divide(left, right)
+		return remainder, nil
+	case "<<":
+		return left << right, nil
+	case ">>":
+		return left >> right, nil
+	case "&":
+		return left & right, nil
+	case "|":
+		return left | right, nil
+	case "^":
+		return left ^ right, nil
+	}
+	return 0, fmt.Errorf("unknown operator %s", operator)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue, true
+	case int64:
+		return float64(typedValue), true
+	case uint64:
+		return float64(typedValue), true
+	}
+	return 0, false
+}
+
+func toBits(value interface{}) (uint64, bool) {
+	switch typedValue := value.(type) {
+	case int64:
+		return uint64(typedValue), true
+	case uint64:
+		return typedValue, true
+	}
+	return 0, false
+}
+
+// Renders the expression of a node for error messages
+func (n *expressionNode) describe() string {
+	switch {
+	case len(n.operator) == 0:
+		return n.token.value
+	case n.operator == "." || n.operator == "->":
+		return n.operands[0].describe() + n.operator + n.token.value
+	case n.operator == "[]":
+		return fmt.Sprintf("%s[%s]", n.operands[0].describe(), n.operands[1].describe())
+	case n.operator == "cast":
+		return fmt.Sprintf("(%s)%s", n.token.value, n.operands[0].describe())
+	case len(n.operands) == 1:
+		return n.operator + n.operands[0].describe()
+	case n.operator == "()":
+		subscripts := make([]string, 0, len(n.operands)-1)
+		for _, operand := range n.operands[1:] {
+			subscripts = append(subscripts, operand.describe())
+		}
+		return fmt.Sprintf("%s(%s)", n.operands[0].describe(), strings.Join(subscripts, ","))
+	}
+	return fmt.Sprintf("%s %s %s", n.operands[0].describe(), n.operator, n.operands[1].describe())
+}
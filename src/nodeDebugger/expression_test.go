@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Renders a parsed expression with every operation in parentheses, showing how the operands were grouped
+func parenthesized(node *expressionNode) string {
+	switch {
+	case len(node.operator) == 0 && node.token.kind == tokenString:
+		return fmt.Sprintf("%q", node.token.value)
+	case len(node.operator) == 0:
+		return node.token.value
+	case node.operator == "cast":
+		return fmt.Sprintf("((%s)%s)", node.token.value, parenthesized(node.operands[0]))
+	case node.operator == "." || node.operator == "->":
+		return fmt.Sprintf("(%s%s%s)", parenthesized(node.operands[0]), node.operator, node.token.value)
+	case node.operator == "[]":
+		return fmt.Sprintf("(%s[%s])", parenthesized(node.operands[0]), parenthesized(node.operands[1]))
+	case node.operator == "()":
+		subscripts := make([]string, 0, len(node.operands)-1)
+		for _, operand := range node.operands[1:] {
+			subscripts = append(subscripts, parenthesized(operand))
+		}
+		return fmt.Sprintf("(%s(%s))", parenthesized(node.operands[0]), strings.Join(subscripts, ","))
+	case len(node.operands) == 1:
+		return fmt.Sprintf("(%s%s)", node.operator, parenthesized(node.operands[0]))
+	}
+	return fmt.Sprintf("(%s %s %s)", parenthesized(node.operands[0]), node.operator, parenthesized(node.operands[1]))
+}
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"x", "x"},
+		{"a + b * c", "(a + (b * c))"},
+		{"a - b - c", "((a - b) - c)"},
+		{"(a + b) * c", "((a + b) * c)"},
+		{"a << 1 + 2", "(a << (1 + 2))"},
+		{"a & 1 == 0", "(a & (1 == 0))"},
+		{"x == 1 && y || !z", "(((x == 1) && y) || (!z))"},
+		{"-a * b", "((-a) * b)"},
+		{"~-x", "(~(-x))"},
+		{"*p->next", "(*(p->next))"},
+		{"&a[i].y", "(&((a[i]).y))"},
+		{"a[i][j + 1]", "((a[i])[(j + 1)])"},
+		{"a(1, j+1)", "(a(1,(j + 1)))"},
+		{"(long)n * 2", "(((long)n) * 2)"},
+		{"(unsigned char *)buf", "((unsigned char *)buf)"},
+		{"(struct point *)p->next", "((struct point *)(p->next))"},
+		{"(MPI_Datatype) t", "((MPI_Datatype)t)"},
+		{"(n) - 1", "(n - 1)"},
+		{"'A' + 1", "(65 + 1)"},
+		{`'\n'`, "10"},
+		{`name == "a\"b"`, `(name == "a\"b")`},
+		{"1.5e-3 * 0x10", "(1.5e-3 * 0x10)"},
+		{"$rank % 2", "($rank % 2)"},
+	}
+
+	for _, test := range tests {
+		parsed, err := parseExpression(test.source)
+		if err != nil {
+			t.Errorf("parseExpression(%q) failed: %v", test.source, err)
+			continue
+		}
+
+		if got := parenthesized(parsed.root); got != test.want {
+			t.Errorf("parseExpression(%q) = %s, want %s", test.source, got, test.want)
+		}
+
+		if parsed.String() != test.source {
+			t.Errorf("parseExpression(%q) has source %q", test.source, parsed.String())
+		}
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"a +",
+		"(a",
+		"a[1",
+		"a(1",
+		"p->",
+		"p->1",
+		"1 2",
+		"a )",
+		"$nope",
+		"'ab'",
+		`"abc`,
+		"a @ b",
+		"0x1g",
+	}
+
+	for _, source := range tests {
+		if parsed, err := parseExpression(source); err == nil {
+			t.Errorf("parseExpression(%q) = %s, want an error", source, parenthesized(parsed.root))
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		operator    string
+		left, right interface{}
+		want        interface{}
+	}{
+		{"+", int64(2), int64(3), int64(5)},
+		{"-", int64(2), int64(3), int64(-1)},
+		{"*", int64(-4), int64(3), int64(-12)},
+		{"/", int64(-7), int64(2), int64(-3)},
+		{"%", int64(-7), int64(2), int64(-1)},
+		{"<<", int64(1), int64(4), int64(16)},
+		{">>", int64(-8), int64(1), int64(-4)},
+		{"&", int64(6), int64(3), int64(2)},
+		{"|", int64(6), int64(3), int64(7)},
+		{"^", int64(5), int64(3), int64(6)},
+		{"&", int32(6), 3, int64(2)},
+
+		// unsigned operands make the result unsigned, as in C
+		{"-", uint64(1), int64(2), uint64(math.MaxUint64)},
+		{"/", uint64(math.MaxUint64), int64(2), uint64(math.MaxUint64 / 2)},
+		{">>", uint64(1 << 63), int64(1), uint64(1 << 62)},
+
+		// floating point operands make the result floating point
+		{"*", 1.5, int64(2), 3.0},
+		{"/", int64(1), 4.0, 0.25},
+		{"-", uint64(1), 0.5, 0.5},
+	}
+
+	for _, test := range tests {
+		result, err := arithmetic(test.operator, test.left, test.right)
+		if err != nil {
+			t.Errorf("%v %s %v failed: %v", test.left, test.operator, test.right, err)
+			continue
+		}
+
+		if result.value != test.want {
+			t.Errorf("%v %s %v = %v (%T), want %v (%T)", test.left, test.operator, test.right, result.value, result.value, test.want, test.want)
+		}
+	}
+}
+
+func TestArithmeticErrors(t *testing.T) {
+	tests := []struct {
+		operator    string
+		left, right interface{}
+	}{
+		{"/", int64(1), int64(0)},
+		{"%", uint64(1), uint64(0)},
+		{"%", 1.5, 1.0},
+		{"<<", 1.0, int64(1)},
+		{"+", "a", int64(1)},
+		{"+", int64(1), nil},
+		{"**", int64(2), int64(3)},
+	}
+
+	for _, test := range tests {
+		if result, err := arithmetic(test.operator, test.left, test.right); err == nil {
+			t.Errorf("%v %s %v = %v, want an error", test.left, test.operator, test.right, result.value)
+		}
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		operator    string
+		left, right interface{}
+		want        int64
+	}{
+		{"<", int64(-1), int64(1), 1},
+		{">", int64(-1), int64(1), 0},
+		{"==", int64(2), int64(2), 1},
+		{"!=", int64(2), int64(2), 0},
+		{"<=", int64(2), int64(2), 1},
+		{">=", int64(1), int64(2), 0},
+		{"==", int32(3), 3, 1},
+
+		// signed operands compared with unsigned ones are converted to unsigned, as in C
+		{"<", int64(-1), uint64(1), 0},
+		{">", uint64(math.MaxUint64), int64(1), 1},
+
+		// floating point if either operand is
+		{"==", int64(2), 2.0, 1},
+		{">=", 2.5, int64(2), 1},
+		{"<", 0.1, 0.2, 1},
+
+		{"==", "abc", "abc", 1},
+		{"!=", "abc", "abd", 1},
+		{"<", "abc", "abd", 1},
+		{">", "b", "abc", 1},
+	}
+
+	for _, test := range tests {
+		result, err := compareValues(test.operator, test.left, test.right)
+		if err != nil {
+			t.Errorf("%v %s %v failed: %v", test.left, test.operator, test.right, err)
+			continue
+		}
+
+		if result.value != test.want {
+			t.Errorf("%v %s %v = %v, want %v", test.left, test.operator, test.right, result.value, test.want)
+		}
+	}
+}
+
+func TestCompareValuesErrors(t *testing.T) {
+	tests := []struct {
+		left, right interface{}
+	}{
+		{"1", int64(1)},
+		{int64(1), "1"},
+		{nil, int64(1)},
+		{1.5, nil},
+	}
+
+	for _, test := range tests {
+		if result, err := compareValues("==", test.left, test.right); err == nil {
+			t.Errorf("%v == %v = %v, want an error", test.left, test.right, result.value)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+// Stops at the signals that terminate the target: segmentation faults, aborts (failed asserts among them),
+// arithmetic errors, bus errors and illegal instructions. The target is stopped before the signal is
+// delivered, so it can be inspected, or rolled back to a checkpoint instead of dying
+
+const (
+	SIGINFO_ADDRESS_OFFSET = 16   // of si_addr, the faulting address of SIGSEGV, SIGBUS, SIGFPE and SIGILL
+	maxStackScan           = 4096 // bytes of the stack searched for the return address into code with debug info
+)
+
+var fatalSignals = map[syscall.Signal]bool{
+	syscall.SIGSEGV: true,
+	syscall.SIGABRT: true,
+	syscall.SIGFPE:  true,
+	syscall.SIGBUS:  true,
+	syscall.SIGILL:  true,
+}
+
+// causes of the fatal signals, by their si_code
+var signalCodes = map[syscall.Signal]map[int32]string{
+	syscall.SIGSEGV: {1: "address not mapped", 2: "invalid permissions for mapped object"},
+	syscall.SIGBUS:  {1: "invalid address alignment", 2: "nonexistent physical address", 3: "object-specific hardware error"},
+	syscall.SIGFPE: {1: "integer divide by zero", 2: "integer overflow", 3: "floating-point divide by zero", 4: "floating-point overflow",
+		5: "floating-point underflow", 6: "floating-point inexact result", 7: "floating-point invalid operation", 8: "subscript out of range"},
+	syscall.SIGILL: {1: "illegal opcode", 2: "illegal operand", 3: "illegal addressing mode", 4: "illegal trap", 5: "privileged opcode",
+		6: "privileged register", 7: "coprocessor error", 8: "internal stack error"},
+}
+
+// A fatal signal the target is stopped at, delivered when the target is resumed
+type fatalSignal struct {
+	signal  syscall.Signal
+	code    int32  // si_code, the cause of the signal
+	address uint64 // faulting address of signals raised by an instruction (0 for others, e.g. SIGABRT of abort)
+}
+
+func (s fatalSignal) String() string {
+	description := s.signal.String()
+
+	if cause, ok := signalCodes[s.signal][s.code]; ok {
+		description = fmt.Sprintf("%s (%s)", description, cause)
+	}
+
+	if _, faulting := signalCodes[s.signal]; faulting && s.code > 0 {
+		description = fmt.Sprintf("%s at address %#x", description, s.address)
+	}
+
+	return description
+}
+
+// Reads the cause of the signal the target stopped with from its siginfo
+func readFatalSignal(ctx *processContext, signal syscall.Signal) fatalSignal {
+	stopped := fatalSignal{signal: signal}
+
+	siginfo := make([]byte, SIGINFO_SIZE)
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, PTRACE_GETSIGINFO, uintptr(ctx.pid), 0, uintptr(unsafe.Pointer(&siginfo[0])), 0, 0)
+	if errno != 0 {
+		logger.Warn("cannot read the signal info of %v: %v", signal, errno)
+		return stopped
+	}
+
+	stopped.code = int32(binary.LittleEndian.Uint32(siginfo[SIGINFO_CODE_OFFSET:]))
+
+	// signals sent by a process (si_code <= 0), e.g. by abort, have no faulting address
+	if stopped.code > 0 {
+		stopped.address = binary.LittleEndian.Uint64(siginfo[SIGINFO_ADDRESS_OFFSET:])
+	}
+
+	return stopped
+}
+
+// Reports the fatal signal the target stopped at: its cause, the source line of the innermost frame with debug info
+// and the checkpoint to roll back to. The call stack is logged once the command completes
+func reportFatalSignalStop(ctx *processContext, signal syscall.Signal) {
+	stopped := readFatalSignal(ctx, signal)
+	ctx.fatalSignal = &stopped
+
+	logger.Info("Program received %v", stopped)
+	emitEvent(ctx, rpc.EVENT_SIGNAL, "stopped by %v", signal)
+
+	if function, pc := faultingFrame(ctx); function != nil {
+		if line, file, err := ctx.dwarfData.PCToLineContaining(pc); err == nil {
+			logger.Info("in %v at %v:%d\t%s", function.Name(), filepath.Base(file), line, sourceLine(ctx, file, line))
+		}
+	}
+
+	if len(ctx.cpointData) > 0 {
+		checkpoint := ctx.cpointData[len(ctx.cpointData)-1]
+		logger.Info("continuing delivers %v to the target, roll back to checkpoint %v (%v) to re-execute from before it", signal, checkpoint.id, checkpoint.opName)
+	} else {
+		logger.Info("continuing delivers %v to the target, no checkpoint recorded to roll back to", signal)
+	}
+}
+
+// Returns the innermost function with debug info the signal was raised in, and the address within it. Signals raised
+// within libraries, e.g. by abort, are traced back to the return address of the innermost call from code with debug info,
+// found on the stack as the frames of libraries built without frame pointers cannot be unwound
+func faultingFrame(ctx *processContext) (*dwarf.Function, uint64) {
+	for index, frame := range getStack(ctx) {
+		if frame.function == nil {
+			continue
+		}
+
+		// the pc of a caller is the return address, past the call
+		if index > 0 {
+			return frame.function, frame.pc - 1
+		}
+		return frame.function, frame.pc
+	}
+
+	rsp := getRegs(ctx, false).Rsp
+	stack := make([]byte, maxStackScan)
+
+	count, _ := memoryReader(ctx)(stack, rsp)
+
+	for offset := 0; offset+8 <= count; offset += 8 {
+		address := binary.LittleEndian.Uint64(stack[offset:])
+
+		function := ctx.dwarfData.PCToFunc(address)
+		if function == nil || !followsCall(ctx, address) {
+			continue
+		}
+
+		return function, address - 1
+	}
+
+	return nil, 0
+}
+
+// Whether the instruction before the address is a call: a direct call, or an indirect call through a register or memory
+func followsCall(ctx *processContext, address uint64) bool {
+	code := make([]byte, 7)
+	if _, err := memoryReader(ctx)(code, address-7); err != nil {
+		return false
+	}
+
+	return code[2] == 0xE8 || code[1] == 0xFF || code[4] == 0xFF || code[5] == 0xFF
+}
+
+// Returns the fatal signal the target is stopped at, to deliver on resuming it, 0 if none. The signal is recorded
+// as received. Its instruction would raise a fault again, so the signal is delivered during replays as well
+func takeFatalSignal(ctx *processContext) syscall.Signal {
+	if ctx.fatalSignal == nil {
+		return 0
+	}
+
+	signal := ctx.fatalSignal.signal
+	ctx.fatalSignal = nil
+
+	handleSignalStop(ctx, signal)
+
+	return signal
+}
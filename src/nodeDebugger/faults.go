@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// faults injected at the MPI interception points
+const (
+	FAULT_DROP_MESSAGE = "drop-message" // the send returns success without sending
+	FAULT_DELAY        = "delay"        // the call is held back for a number of milliseconds
+	FAULT_ERROR_RETURN = "error-return" // the call returns an error code without being executed
+)
+
+type faultRule struct {
+	kind     string
+	argument int          // milliseconds to delay by, or the error code to return
+	opName   string       // MPI operation the fault is injected at, empty for all
+	ranks    map[int]bool // ranks the fault is injected on, nil for all
+}
+
+type faultRules []faultRule
+
+// inject <fault> [at <MPI operation>] [on <rank>[,<rank>...]]
+var faultRuleRegexp = regexp.MustCompile(`^(drop-message|delay (\d+)|error-return (-?\d+))( at (MPI_\w+))?( on (\d+(,\d+)*))?$`)
+
+// Adds a fault injection rule, clears the rules (`clear`) or lists them (empty description)
+func injectFault(ctx *processContext, description string) error {
+	description = strings.TrimSpace(description)
+
+	switch description {
+	case "":
+		printFaultRules(ctx)
+		return nil
+	case "clear":
+		ctx.faultRules = nil
+		logger.Info("fault injection disabled")
+		return nil
+	}
+
+	rule, err := parseFaultRule(description)
+	if err != nil {
+		logger.Warn("cannot inject fault: %v", err)
+		return err
+	}
+
+	ctx.faultRules = append(ctx.faultRules, *rule)
+	logger.Info("injecting %v", rule)
+
+	return nil
+}
+
+func parseFaultRule(description string) (*faultRule, error) {
+	match := faultRuleRegexp.FindStringSubmatch(description)
+	if match == nil {
+		return nil, fmt.Errorf("invalid fault %q, expected drop-message, delay <ms> or error-return <code>, optionally followed by at <MPI operation> and on <ranks>", description)
+	}
+
+	rule := faultRule{
+		kind:   strings.Fields(match[1])[0],
+		opName: match[5],
+	}
+
+	switch rule.kind {
+	case FAULT_DELAY:
+		rule.argument, _ = strconv.Atoi(match[2])
+	case FAULT_ERROR_RETURN:
+		rule.argument, _ = strconv.Atoi(match[3])
+	}
+
+	if rule.kind == FAULT_DROP_MESSAGE && len(rule.opName) > 0 && !mpi.SEND_EVENTS[rule.opName] {
+		return nil, fmt.Errorf("messages can only be dropped at send operations, not at %v", rule.opName)
+	}
+
+	if len(match[7]) > 0 {
+		rule.ranks = make(map[int]bool)
+		for _, rank := range strings.Split(match[7], ",") {
+			value, _ := strconv.Atoi(rank)
+			rule.ranks[value] = true
+		}
+	}
+
+	return &rule, nil
+}
+
+// Returns the first rule injecting a fault at the MPI operation on the rank of the target, nil if none
+func matchingFault(ctx *processContext, opName string) *faultRule {
+	for index, rule := range ctx.faultRules {
+		if len(rule.opName) > 0 && rule.opName != opName {
+			continue
+		}
+
+		if rule.kind == FAULT_DROP_MESSAGE && !mpi.SEND_EVENTS[opName] {
+			continue
+		}
+
+		if rule.ranks != nil {
+			rank, ok := getVariableFromMemory(ctx, "_MPI_WRAPPER_PROC_RANK", true).(int32)
+			if !ok || !rule.ranks[int(rank)] {
+				continue
+			}
+		}
+
+		return &ctx.faultRules[index]
+	}
+
+	return nil
+}
+
+// Injects the fault into the MPI wrapper the target is stopped in
+func applyFault(ctx *processContext, rule faultRule, opName string) {
+	logger.Info("injecting %v into %v", rule.kind, opName)
+
+	switch rule.kind {
+	case FAULT_DELAY:
+		time.Sleep(time.Duration(rule.argument) * time.Millisecond)
+	case FAULT_DROP_MESSAGE:
+		forceReturn(ctx, 0) // MPI_SUCCESS
+	case FAULT_ERROR_RETURN:
+		forceReturn(ctx, rule.argument)
+	}
+}
+
+// Returns from the MPI wrapper the target is stopped in without executing the rest of it, as if it had returned the value.
+// The wrappers are compiled without optimizations, so their frame pointer locates the caller's frame
+func forceReturn(ctx *processContext, value int) {
+	regs := getRegs(ctx, false)
+
+	frame := make([]byte, 16) // saved frame pointer, return address
+	_, err := syscall.PtracePeekData(ctx.pid, uintptr(regs.Rbp), frame)
+	utils.Must(err)
+
+	regs.Rsp = regs.Rbp + 16
+	regs.Rbp = binary.LittleEndian.Uint64(frame[0:8])
+	regs.Rip = binary.LittleEndian.Uint64(frame[8:16])
+	regs.Rax = uint64(uint32(int32(value)))
+
+	err = syscall.PtraceSetRegs(ctx.pid, regs)
+	utils.Must(err)
+}
+
+func printFaultRules(ctx *processContext) {
+	if len(ctx.faultRules) == 0 {
+		logger.Info("no faults injected")
+		return
+	}
+
+	for index, rule := range ctx.faultRules {
+		logger.Info("%d: %v", index, rule)
+	}
+}
+
+func (rule faultRule) String() string {
+	description := rule.kind
+
+	if rule.kind != FAULT_DROP_MESSAGE {
+		description = fmt.Sprintf("%s %d", description, rule.argument)
+	}
+
+	if len(rule.opName) > 0 {
+		description = fmt.Sprintf("%s at %s", description, rule.opName)
+	}
+
+	if rule.ranks != nil {
+		ranks := make([]int, 0, len(rule.ranks))
+		for rank := range rule.ranks {
+			ranks = append(ranks, rank)
+		}
+		sort.Ints(ranks)
+
+		rankList := make([]string, 0, len(ranks))
+		for _, rank := range ranks {
+			rankList = append(rankList, strconv.Itoa(rank))
+		}
+
+		description = fmt.Sprintf("%s on %s", description, strings.Join(rankList, ","))
+	}
+
+	return description
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// Returns what identifies the target and the way it is debugged
+func getFingerprint(ctx *processContext) rpc.TargetFingerprint {
+	fingerprint := rpc.TargetFingerprint{
+		Binary:            ctx.targetFile,
+		MPILibrary:        dwarf.LinkedMPILibrary(ctx.targetFile),
+		CheckpointBackend: ctx.checkpointer.String(),
+		DebuggerVersion:   utils.Version,
+		StaleSources:      ctx.staleSources,
+		VerifiedSources:   ctx.verifiedSources,
+	}
+
+	if ctx.nodeData != nil {
+		fingerprint.NodeId = ctx.nodeData.id
+	}
+
+	if host, err := os.Hostname(); err == nil {
+		fingerprint.Host = host
+	}
+
+	if binary, err := filepath.Abs(ctx.targetFile); err == nil {
+		fingerprint.Binary = binary
+	}
+
+	buildId, err := dwarf.BuildId(ctx.targetFile)
+	if err != nil {
+		logger.Debug("no build-id: %v", err)
+	}
+	fingerprint.BuildId = buildId
+
+	if module := mainModule(ctx); module != nil {
+		fingerprint.Producer = module.Producer()
+	}
+
+	return fingerprint
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/proc"
+)
+
+// Records checkpoints in copy-on-write forks of the target, kept stopped as snapshots of its memory
+type forkCheckpointer struct{}
+
+func (forkCheckpointer) String() string {
+	return "fork"
+}
+
+// Records the checkpoint in a fork of the target, falls back to a file if the target cannot be forked
+func (c forkCheckpointer) create(ctx *processContext, opName string) cPoint {
+	regs := getRegs(ctx, false)
+
+	pid, err := forkTarget(ctx)
+	if err != nil {
+		logger.Warn("cannot fork the target, recording the checkpoint in a file: %v", err)
+		return fileCheckpointer{}.create(ctx, opName)
+	}
+
+	checkpoint := cPoint{
+		pid:     pid,
+		opName:  opName,
+		regs:    regs,
+		bpoints: make(breakpointData),
+		backend: c,
+	}
+
+	return checkpoint
+}
+
+func (forkCheckpointer) restore(ctx *processContext, checkpoint cPoint) error {
+	logger.Debug("restoring memory state: %v (snapshot process: %v)", checkpoint.opName, checkpoint.pid)
+
+	return proc.WriteRegionsContentsToMemFile(ctx.pid, snapshotRegions(ctx, checkpoint.pid))
+}
+
+func (forkCheckpointer) release(ctx *processContext, checkpoint cPoint) {
+	releaseSnapshotProcess(ctx, checkpoint.pid)
+}
+
+// The size of the snapshot's writable memory. The pages not written since the fork are shared with the target,
+// so this is the most the snapshot takes up
+func (forkCheckpointer) size(ctx *processContext, checkpoint cPoint) uint64 {
+	var size uint64
+
+	for _, region := range proc.GetForkCheckpointDataAddresses(checkpoint.pid, ctx.targetFile) {
+		size += region.End - region.Start
+	}
+
+	return size
+}
+
+// the syscall instruction (0f 05), injected into the target to make it fork
+var syscallInstruction = []byte{0x0f, 0x05}
+
+// PTRACE_O_EXITKILL, missing from the syscall package
+const PTRACE_O_EXITKILL = 0x100000
+
+// Forks the stopped target by injecting a fork syscall at its instruction pointer.
+// The fork is a copy-on-write snapshot of the target's memory: it is attached to the debugger
+// and kept stopped until released, so that it never executes. Its memory is read on restore.
+// Returns the process id of the fork
+func forkTarget(ctx *processContext) (int, error) {
+	regs := getRegs(ctx, false)
+	instructionCount := getInstructionCount(ctx)
+
+	originalInstruction := make([]byte, len(syscallInstruction))
+	_, err := syscall.PtracePeekData(ctx.pid, uintptr(regs.Rip), originalInstruction)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = syscall.PtracePokeData(ctx.pid, uintptr(regs.Rip), syscallInstruction)
+	if err != nil {
+		return 0, err
+	}
+
+	injectedRegs := *regs
+	injectedRegs.Rax = syscall.SYS_FORK
+	injectedRegs.Orig_rax = ^uint64(0) // not in a syscall, nothing to restart
+
+	// the fork inherits the options, it is killed along with the debugger
+	err = syscall.PtraceSetOptions(ctx.pid, syscall.PTRACE_O_TRACEFORK|PTRACE_O_EXITKILL)
+	if err == nil {
+		err = syscall.PtraceSetRegs(ctx.pid, &injectedRegs)
+	}
+
+	var forkPid int
+	var deferredSignals []syscall.Signal
+
+	if err == nil {
+		forkPid, deferredSignals, err = stepThroughFork(ctx)
+	}
+
+	// forks made by the target itself are not followed
+	syscall.PtraceSetOptions(ctx.pid, ptraceOptions(ctx))
+
+	syscall.PtracePokeData(ctx.pid, uintptr(regs.Rip), originalInstruction)
+	syscall.PtraceSetRegs(ctx.pid, regs)
+
+	if forkPid > 0 {
+		syscall.PtracePokeData(forkPid, uintptr(regs.Rip), originalInstruction)
+	}
+
+	// the injected instruction is not part of the execution of the target
+	if ctx.instructionCounter != nil {
+		ctx.instructionOffset += getInstructionCount(ctx) - instructionCount
+	}
+
+	// signals arriving during the fork are raised again, to be received on the next resume
+	for _, signal := range deferredSignals {
+		syscall.Kill(ctx.pid, signal)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	ctx.snapshotPids = append(ctx.snapshotPids, forkPid)
+
+	logger.Debug("forked target into snapshot process %d", forkPid)
+
+	return forkPid, nil
+}
+
+// Executes the injected fork syscall. Returns the process id of the fork
+// and the signals the target received meanwhile
+func stepThroughFork(ctx *processContext) (forkPid int, deferredSignals []syscall.Signal, err error) {
+	var waitStatus syscall.WaitStatus
+
+	for forkPid == 0 {
+		err = syscall.PtraceSingleStep(ctx.pid)
+		if err != nil {
+			return 0, deferredSignals, err
+		}
+
+		_, err = syscall.Wait4(ctx.pid, &waitStatus, 0, nil)
+		if err != nil {
+			return 0, deferredSignals, err
+		}
+
+		switch {
+		case waitStatus.Exited():
+			return 0, deferredSignals, fmt.Errorf("target exited while forking")
+		case waitStatus.StopSignal() != syscall.SIGTRAP:
+			deferredSignals = append(deferredSignals, waitStatus.StopSignal())
+		case waitStatus.TrapCause() == syscall.PTRACE_EVENT_FORK:
+			msg, err := syscall.PtraceGetEventMsg(ctx.pid)
+			if err != nil {
+				return 0, deferredSignals, err
+			}
+			forkPid = int(msg)
+		default:
+			return 0, deferredSignals, fmt.Errorf("target stepped past the injected fork")
+		}
+	}
+
+	// the fork starts out stopped
+	_, err = syscall.Wait4(forkPid, &waitStatus, syscall.WALL, nil)
+	if err != nil {
+		return forkPid, deferredSignals, err
+	}
+
+	// complete the syscall in the target
+	err = syscall.PtraceSingleStep(ctx.pid)
+	if err == nil {
+		_, err = syscall.Wait4(ctx.pid, &waitStatus, 0, nil)
+	}
+
+	return forkPid, deferredSignals, err
+}
+
+// Returns the memory regions of a snapshot process to restore, limited to the regions still mapped in the target
+func snapshotRegions(ctx *processContext, snapshotPid int) []proc.MemRegion {
+	targetRegions := proc.GetForkCheckpointDataAddresses(ctx.pid, ctx.targetFile)
+
+	regions := make([]proc.MemRegion, 0)
+
+	for _, region := range proc.GetForkCheckpointDataAddresses(snapshotPid, ctx.targetFile) {
+		for _, targetRegion := range targetRegions {
+			if region.Ident != targetRegion.Ident || region.Start != targetRegion.Start {
+				continue
+			}
+
+			if targetRegion.End < region.End {
+				region.End = targetRegion.End
+			}
+
+			region.Contents = region.ContentsFromFile(snapshotPid)
+			regions = append(regions, region)
+		}
+	}
+
+	return regions
+}
+
+// Kills all snapshot processes, when the target exits
+func releaseAllSnapshots(ctx *processContext) {
+	for len(ctx.snapshotPids) > 0 {
+		releaseSnapshotProcess(ctx, ctx.snapshotPids[0])
+	}
+}
+
+func releaseSnapshotProcess(ctx *processContext, pid int) {
+	for index, snapshotPid := range ctx.snapshotPids {
+		if snapshotPid == pid {
+			ctx.snapshotPids = append(ctx.snapshotPids[:index], ctx.snapshotPids[index+1:]...)
+			break
+		}
+	}
+
+	logger.Debug("releasing snapshot process %d", pid)
+
+	err := syscall.Kill(pid, syscall.SIGKILL)
+	if err != nil {
+		logger.Warn("cannot release snapshot process %d: %v", pid, err)
+		return
+	}
+
+	var waitStatus syscall.WaitStatus
+	syscall.Wait4(pid, &waitStatus, syscall.WALL, nil)
+}
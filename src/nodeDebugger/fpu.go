@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+const (
+	PTRACE_GETFPREGS = 14
+	PTRACE_SETFPREGS = 15
+
+	FP_REGS_SIZE        = 512 // size of user_fpregs_struct (the fxsave area)
+	FP_REGS_X87_CONTROL = 0   // offsets of the registers in it
+	FP_REGS_X87_STATUS  = 2
+	FP_REGS_MXCSR       = 24
+
+	FP_EXCEPTIONS       = 0x3f // exception flags of the status registers, and masks of the x87 control word
+	MXCSR_MASK_SHIFT    = 7    // the masks of MXCSR follow its flags
+	MXCSR_DAZ           = 1 << 6
+	MXCSR_ROUND_SHIFT   = 13
+	MXCSR_FTZ           = 1 << 15
+	X87_PRECISION_SHIFT = 8
+	X87_ROUND_SHIFT     = 10
+)
+
+// floating-point exceptions by their bit in the status registers
+var fpExceptions = []string{"invalid", "denormal", "divzero", "overflow", "underflow", "inexact"}
+
+// rounding modes by the value of their rounding control bits
+var fpRoundingModes = []string{"nearest", "down", "up", "zero"}
+
+// precisions of the x87 unit by the value of its precision control bits
+var x87Precisions = []string{"single", "reserved", "double", "extended"}
+
+// The floating-point control and status registers, recorded at checkpoints so that the
+// floating-point environment the target re-executes in is the one it originally executed in
+type fpControl struct {
+	mxcsr      uint32 // SSE control and status register
+	x87Control uint16
+	x87Status  uint16
+}
+
+// Prints the floating-point control and status registers (empty argument), or changes the floating-point environment
+// of the target: masks or unmasks an exception (mask|unmask <exception>|all) or sets the rounding mode (round <mode>).
+// Changes apply to both the SSE and the x87 unit
+func setFPEnvironment(ctx *processContext, argument string) error {
+	fields := strings.Fields(argument)
+
+	if len(fields) == 0 {
+		return printFPControl(ctx)
+	}
+
+	control, err := getFPControl(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(fields) != 2 {
+		return fmt.Errorf("expected mask|unmask <exception>|all or round <mode>")
+	}
+
+	switch fields[0] {
+	case "mask", "unmask":
+		exceptions, err := parseFPExceptions(fields[1])
+		if err != nil {
+			return err
+		}
+
+		if fields[0] == "mask" {
+			control.mxcsr |= exceptions << MXCSR_MASK_SHIFT
+			control.x87Control |= uint16(exceptions)
+		} else {
+			control.mxcsr &^= exceptions << MXCSR_MASK_SHIFT
+			control.x87Control &^= uint16(exceptions)
+
+			// the x87 unit raises an unmasked exception whose flag is already set at its next instruction
+			control.x87Status &^= uint16(exceptions)
+		}
+
+		// the NaN trap restores the mask chosen here once disabled
+		if ctx.nanTrap != nil && exceptions&MXCSR_INVALID_FLAG != 0 {
+			ctx.nanTrap.programMasked = fields[0] == "mask"
+		}
+	case "round":
+		mode := indexOf(fpRoundingModes, fields[1])
+		if mode < 0 {
+			return fmt.Errorf("unknown rounding mode %q, expected one of %v", fields[1], strings.Join(fpRoundingModes, ", "))
+		}
+
+		control.mxcsr = control.mxcsr&^(3<<MXCSR_ROUND_SHIFT) | uint32(mode)<<MXCSR_ROUND_SHIFT
+		control.x87Control = control.x87Control&^(3<<X87_ROUND_SHIFT) | uint16(mode)<<X87_ROUND_SHIFT
+	default:
+		return fmt.Errorf("expected mask|unmask <exception>|all or round <mode>, got %q", argument)
+	}
+
+	err = setFPControl(ctx, control)
+	if err != nil {
+		return err
+	}
+
+	// invalid operations stay unmasked while the NaN trap is on
+	err = applyNaNTrap(ctx)
+	if err != nil {
+		return err
+	}
+
+	return printFPControl(ctx)
+}
+
+func parseFPExceptions(name string) (uint32, error) {
+	if name == "all" {
+		return FP_EXCEPTIONS, nil
+	}
+
+	index := indexOf(fpExceptions, name)
+	if index < 0 {
+		return 0, fmt.Errorf("unknown exception %q, expected all or one of %v", name, strings.Join(fpExceptions, ", "))
+	}
+
+	return 1 << index, nil
+}
+
+func printFPControl(ctx *processContext) error {
+	control, err := getFPControl(ctx)
+	if err != nil {
+		return err
+	}
+
+	mxcsrModes := ""
+	if control.mxcsr&MXCSR_FTZ != 0 {
+		mxcsrModes += ", flush-to-zero"
+	}
+	if control.mxcsr&MXCSR_DAZ != 0 {
+		mxcsrModes += ", denormals-are-zero"
+	}
+
+	logger.Info("mxcsr       0x%04x  masked: %s, raised: %s, rounding: %s%s",
+		control.mxcsr,
+		describeFPExceptions(control.mxcsr>>MXCSR_MASK_SHIFT),
+		describeFPExceptions(control.mxcsr),
+		fpRoundingModes[control.mxcsr>>MXCSR_ROUND_SHIFT&3],
+		mxcsrModes)
+
+	logger.Info("x87 control 0x%04x  masked: %s, rounding: %s, precision: %s",
+		control.x87Control,
+		describeFPExceptions(uint32(control.x87Control)),
+		fpRoundingModes[control.x87Control>>X87_ROUND_SHIFT&3],
+		x87Precisions[control.x87Control>>X87_PRECISION_SHIFT&3])
+
+	logger.Info("x87 status  0x%04x  raised: %s", control.x87Status, describeFPExceptions(uint32(control.x87Status)))
+
+	return nil
+}
+
+// Lists the exceptions set in the lowest six bits
+func describeFPExceptions(bits uint32) string {
+	exceptions := make([]string, 0)
+
+	for index, name := range fpExceptions {
+		if bits&(1<<index) != 0 {
+			exceptions = append(exceptions, name)
+		}
+	}
+
+	if len(exceptions) == 0 {
+		return "none"
+	}
+
+	return strings.Join(exceptions, ",")
+}
+
+func getFPControl(ctx *processContext) (fpControl, error) {
+	fpRegs, err := getFPRegs(ctx)
+	if err != nil {
+		return fpControl{}, err
+	}
+
+	return fpControl{
+		mxcsr:      binary.LittleEndian.Uint32(fpRegs[FP_REGS_MXCSR:]),
+		x87Control: binary.LittleEndian.Uint16(fpRegs[FP_REGS_X87_CONTROL:]),
+		x87Status:  binary.LittleEndian.Uint16(fpRegs[FP_REGS_X87_STATUS:]),
+	}, nil
+}
+
+// Writes the control and status registers, leaving the data registers of the target as they are
+func setFPControl(ctx *processContext, control fpControl) error {
+	fpRegs, err := getFPRegs(ctx)
+	if err != nil {
+		return err
+	}
+
+	binary.LittleEndian.PutUint32(fpRegs[FP_REGS_MXCSR:], control.mxcsr)
+	binary.LittleEndian.PutUint16(fpRegs[FP_REGS_X87_CONTROL:], control.x87Control)
+	binary.LittleEndian.PutUint16(fpRegs[FP_REGS_X87_STATUS:], control.x87Status)
+
+	return setFPRegs(ctx, fpRegs)
+}
+
+func getFPRegs(ctx *processContext) ([]byte, error) {
+	fpRegs := make([]byte, FP_REGS_SIZE)
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, PTRACE_GETFPREGS, uintptr(ctx.pid), 0, uintptr(unsafe.Pointer(&fpRegs[0])), 0, 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("cannot read the floating-point registers: %v", errno)
+	}
+
+	return fpRegs, nil
+}
+
+func setFPRegs(ctx *processContext, fpRegs []byte) error {
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, PTRACE_SETFPREGS, uintptr(ctx.pid), 0, uintptr(unsafe.Pointer(&fpRegs[0])), 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("cannot write the floating-point registers: %v", errno)
+	}
+
+	return nil
+}
+
+func indexOf(values []string, value string) int {
+	for index, candidate := range values {
+		if candidate == value {
+			return index
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+)
+
+const MAX_BACKTRACE_DEPTH = 64
+
+// Lists the goroutines of a go target
+func listGoroutines(ctx *processContext) error {
+	goroutines, err := ctx.dwarfData.ReadGoroutines(memoryReader(ctx))
+	if err != nil {
+		logger.Warn("cannot list goroutines: %v", err)
+		return err
+	}
+
+	current := currentGoroutine(ctx, goroutines)
+
+	for _, goroutine := range goroutines {
+		marker := " "
+		if current != nil && goroutine.Address == current.Address {
+			marker = "*"
+		}
+
+		pc, _ := goroutineRegisters(ctx, goroutine, current)
+
+		fmt.Printf("%s goroutine %d [%s] %s\n", marker, goroutine.Id, goroutine.StatusName(), describePC(ctx, pc))
+		fmt.Printf("    created by %s\n", describePC(ctx, goroutine.CreatorPC))
+	}
+
+	return nil
+}
+
+// Prints the call stack of a goroutine, found by following the frame pointers
+func printGoroutineBacktrace(ctx *processContext, goroutineId int) error {
+	goroutines, err := ctx.dwarfData.ReadGoroutines(memoryReader(ctx))
+	if err != nil {
+		logger.Warn("cannot read goroutines: %v", err)
+		return err
+	}
+
+	var goroutine *dwarf.Goroutine
+
+	for index := range goroutines {
+		if goroutines[index].Id == int64(goroutineId) {
+			goroutine = &goroutines[index]
+		}
+	}
+
+	if goroutine == nil {
+		err = fmt.Errorf("goroutine %d not found", goroutineId)
+		logger.Warn("%v", err)
+		return err
+	}
+
+	pc, bp := goroutineRegisters(ctx, *goroutine, currentGoroutine(ctx, goroutines))
+
+	fmt.Printf("goroutine %d [%s]:\n", goroutine.Id, goroutine.StatusName())
+
+	frame := make([]byte, 16)
+
+	for depth := 0; depth < MAX_BACKTRACE_DEPTH && pc != 0; depth++ {
+		fmt.Printf("  #%d %s\n", depth, describePC(ctx, pc))
+
+		if bp == 0 {
+			break
+		}
+
+		// the saved base pointer of the caller, followed by the return address
+		_, err := syscall.PtracePeekData(ctx.pid, uintptr(bp), frame)
+		if err != nil {
+			break
+		}
+
+		bp = binary.LittleEndian.Uint64(frame[:8])
+		pc = binary.LittleEndian.Uint64(frame[8:])
+	}
+
+	fmt.Printf("  created by %s\n", describePC(ctx, goroutine.CreatorPC))
+
+	return nil
+}
+
+// Returns the goroutine executing on the traced thread. Go code keeps the current goroutine in R14
+func currentGoroutine(ctx *processContext, goroutines []dwarf.Goroutine) *dwarf.Goroutine {
+	regs := getRegs(ctx, false)
+
+	for index, goroutine := range goroutines {
+		if goroutine.Address == regs.R14 && goroutine.IsRunning() {
+			return &goroutines[index]
+		}
+	}
+
+	return nil
+}
+
+// Returns the instruction and base pointer of a goroutine. The registers of a running goroutine
+// are only saved when it is descheduled, the values of the traced thread are used for the current one
+func goroutineRegisters(ctx *processContext, goroutine dwarf.Goroutine, current *dwarf.Goroutine) (pc uint64, bp uint64) {
+	if current != nil && goroutine.Address == current.Address {
+		regs := getRegs(ctx, false)
+		return regs.Rip, regs.Rbp
+	}
+
+	return goroutine.PC, goroutine.BP
+}
+
+// Describes the function and source line of an instruction
+func describePC(ctx *processContext, pc uint64) string {
+	if line, file, fn, err := ctx.dwarfData.PCToLine(pc); err == nil {
+		return fmt.Sprintf("%v (%s:%d) %#x", fn.Name(), filepath.Base(file), line, pc)
+	}
+
+	if fn := ctx.dwarfData.PCToFunc(pc); fn != nil {
+		return fmt.Sprintf("%v %#x", fn.Name(), pc)
+	}
+
+	if jitSymbol := lookupJITSymbol(ctx, pc); jitSymbol != nil {
+		return fmt.Sprintf("%v+%#x [jit] %#x", jitSymbol.symbol, pc-jitSymbol.start, pc)
+	}
+
+	return fmt.Sprintf("%#x", pc)
+}
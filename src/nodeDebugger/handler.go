@@ -1,52 +1,242 @@
 package main
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/ottmartens/cc-rev-db/logger"
 	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
 	"github.com/ottmartens/cc-rev-db/nodeDebugger/proc"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
 	"github.com/ottmartens/cc-rev-db/utils"
 	"github.com/ottmartens/cc-rev-db/utils/command"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type RemoteCmdHandler struct {
+	pb.UnimplementedCommandHandlerServer
+
 	ctx          *processContext
 	commandQueue chan<- *command.Command
 }
 
-func (r RemoteCmdHandler) Handle(cmd *command.Command, reply *int) error {
+func (r RemoteCmdHandler) Handle(_ context.Context, message *pb.Command) (*emptypb.Empty, error) {
+	cmd, err := rpc.CommandFromProto(message)
+	if err != nil {
+		return nil, err
+	}
+
 	logger.Debug("Scheduling command for execution %+v", cmd)
 	r.commandQueue <- cmd
-	return nil
+	return &emptypb.Empty{}, nil
 }
 
 func handleCommand(ctx *processContext, cmd *command.Command) {
 	var err error
 	var exited bool
+	var output string          // what a query command printed, reported with the result
+	var values []command.Value // the values a Variables command expanded
+	var steppedOut bool        // a single step into a skipped function ran on until it returned
+
+	// a bug in executing the command fails the command, the target stays traced at where the command left it
+	defer utils.RecoverPanic(func(err error, stack []byte) {
+		logger.Error("command %v failed: %v", cmd, err)
+		logger.Debug("%s", stack)
+
+		cmd.Result = &command.CommandResult{Error: err.Error(), ErrorKind: utils.ErrorKind(err)}
+	})
 
 	logger.Verbose("handling command %v", cmd)
 
+	// the handles of expanded values refer to where the target stopped
+	if cmd.IsProgressCommand() {
+		ctx.valueHandles = nil
+	}
+
 	if cmd.IsForwardProgressCommand() {
 		reportProgressCommand(ctx, cmd)
+
+		ctx.caughtBreakpoint = nil
+		setRunning(ctx, true)
 	}
 
 	switch cmd.Code {
 	case command.Bpoint:
-		err = setBreakPoint(ctx, ctx.sourceFile, cmd.Argument.(int))
+		switch location := cmd.Argument.(type) {
+		case int:
+			err = setBreakPoint(ctx, ctx.sourceFile, location, nil)
+		case string:
+			err = setConditionalBreakpoint(ctx, location)
+		}
+	case command.BreakIteration:
+		err = setIterationBreakpoint(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot set breakpoint: %v", err)
+		}
+	case command.BreakpointCommands:
+		err = setBreakpointCommands(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot attach commands: %v", err)
+		}
+	case command.Tracepoint:
+		err = setTracepoint(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot set tracepoint: %v", err)
+		}
+	case command.InfoIteration:
+		output, err = printIteration(ctx)
+		if err != nil {
+			logger.Info("%v", err)
+		}
 	case command.SingleStep:
-		exited = continueExecution(ctx, true)
+		exited, err = continueExecution(ctx, true)
+		if !exited && err == nil {
+			steppedOut, exited, err = stepOutOfSkipped(ctx)
+		}
+	case command.StepInstructions:
+		exited, err = stepInstructions(ctx, cmd.Argument.(int))
 	case command.Cont:
-		exited = continueExecution(ctx, false)
+		exited, err = continueExecution(ctx, false)
+	case command.Until:
+		exited, err = runUntil(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot run until %v: %v", cmd.Argument, err)
+		}
+	case command.Detach:
+		detachBreakpoints(ctx)
+		exited, err = continueExecution(ctx, false)
 	case command.Restore:
 		checkpointId := cmd.Argument.(string)
 		err = restoreCheckpoint(ctx, checkpointId)
+	case command.ReverseStepInstructions:
+		err = reverseStepInstructions(ctx, cmd.Argument.(int))
+		if err != nil {
+			logger.Error("cannot step back: %v", err)
+		}
+	case command.ReverseContinue:
+		err = reverseContinue(ctx)
+		if err != nil {
+			logger.Error("cannot continue back: %v", err)
+		}
+	case command.ReverseStep, command.ReverseNext:
+		err = reverseStepLine(ctx, cmd.Code == command.ReverseNext)
+		if err != nil {
+			logger.Error("cannot step back: %v", err)
+		}
+	case command.LastWrite:
+		err = lastWrite(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Error("cannot find the last write: %v", err)
+		}
 	case command.Print:
-		printVariable(ctx, cmd.Argument.(string))
+		output, err = printVariable(ctx, cmd.Argument.(string))
+	case command.WhatIs:
+		output, err = printType(ctx, cmd.Argument.(string), false)
+	case command.PType:
+		output, err = printType(ctx, cmd.Argument.(string), true)
+	case command.Display:
+		output, err = addDisplay(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot display: %v", err)
+		}
+	case command.Undisplay:
+		err = removeDisplays(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot remove display: %v", err)
+		}
+	case command.Variables:
+		values, err = expandVariables(ctx, cmd.Argument.(string))
+		output = formatValues(values)
+	case command.ListGoroutines:
+		err = listGoroutines(ctx)
+	case command.GoroutineBacktrace:
+		err = printGoroutineBacktrace(ctx, cmd.Argument.(int))
+	case command.ListJITRegions:
+		listJITRegions(ctx)
+	case command.Capabilities:
+		printCapabilities(ctx)
+	case command.InjectFault:
+		err = injectFault(ctx, cmd.Argument.(string))
+	case command.CheckInvariant:
+		err = checkInvariant(ctx, cmd.Argument.(string))
+	case command.Snapshot:
+		err = recordSnapshot(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot record snapshot: %v", err)
+		}
+	case command.ReleaseCheckpoints:
+		err = pruneCheckpoints(ctx, strings.Fields(cmd.Argument.(string)))
+		if err != nil {
+			logger.Warn("cannot prune checkpoints: %v", err)
+		}
+	case command.HashBuffer:
+		output, err = printBufferHash(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot hash buffer: %v", err)
+		}
+	case command.AutoHashBuffer:
+		err = autoHashBuffer(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot hash buffer: %v", err)
+		}
+	case command.ReplayMessageLog:
+		err = setMessageLog(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot replay from the message log: %v", err)
+		}
+	case command.InfoRegisters:
+		err = printRegs(ctx)
+		if err != nil {
+			logger.Warn("cannot print registers: %v", err)
+		}
+	case command.FPEnvironment:
+		err = setFPEnvironment(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot change the floating-point environment: %v", err)
+		}
+	case command.Skip:
+		err = setSkip(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot skip: %v", err)
+		}
+	case command.CatchOutput:
+		err = catchOutput(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot catch output: %v", err)
+		}
+	case command.TrapNaN:
+		err = trapNaN(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot trap NaNs: %v", err)
+		}
+	case command.PayloadCap:
+		err = setPayloadCap(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot change the payload cap: %v", err)
+		}
+	case command.CausalMark:
+		if cmd.Argument.(string) == "clear" {
+			clearLineMarkers(ctx)
+		} else {
+			err = setLineMarker(ctx, cmd.Argument.(string))
+		}
+		if err != nil {
+			logger.Warn("cannot mark line: %v", err)
+		}
+	case command.CausalWatch:
+		err = setCausalWatch(ctx, cmd.Argument.(string))
+	case command.ForceReceiveSource:
+		err = forceReceiveSource(ctx, cmd.Argument.(string))
+		if err != nil {
+			logger.Warn("cannot reorder messages: %v", err)
+		}
 	case command.Quit:
 		quitDebugger()
 	case command.Help:
@@ -56,9 +246,12 @@ func handleCommand(ctx *processContext, cmd *command.Command) {
 	}
 
 	if cmd.IsForwardProgressCommand() {
+		// single steps stop after the breakpoint they hit, without running on
+		stepping := (cmd.Code == command.SingleStep && !steppedOut) || cmd.Code == command.StepInstructions
 
 		for {
-			if exited {
+			// stopped at the instruction producing a NaN, after caught output, by a fatal signal or by the orchestrator, not at a breakpoint
+			if exited || err != nil || (ctx.nanTrap != nil && ctx.nanTrap.stopped) || (ctx.outputCatches != nil && ctx.outputCatches.stopped) || ctx.fatalSignal != nil || wasInterrupted(ctx) {
 				break
 			}
 
@@ -69,41 +262,159 @@ func handleCommand(ctx *processContext, cmd *command.Command) {
 			}
 
 			if bpoint.isMPIBpoint {
-				ctx.stack = getStack(ctx)
+				updateStack(ctx)
 
 				// single-step, then insert all missing mpi bpoints
-				continueExecution(ctx, true)
+				_, err = continueExecution(ctx, true)
+				if err != nil {
+					break
+				}
 				reinsertMPIBPoints(ctx)
 
 				recordMPIOperation(ctx, bpoint)
 			}
 
-			if !bpoint.isMPIBpoint || cmd.Code == command.SingleStep {
+			if bpoint.causalReceive {
+				// the temporary breakpoint is not armed again
+				if breakpoint := checkCausalReceive(ctx); len(breakpoint) > 0 {
+					logger.Info("Caught at causal breakpoint: %v", breakpoint)
+
+					ctx.caughtBreakpoint = bpoint
+					break
+				}
+
+				if stepping {
+					break
+				}
+
+				exited, err = continueExecution(ctx, false)
+				continue
+			}
+
+			if len(bpoint.marker) > 0 {
+				reportLinePass(ctx, bpoint)
+			}
+
+			if !bpoint.isMPIBpoint {
+				ctx.caughtBreakpoint = bpoint
+				bpoint.hitCount++
+
+				if bpoint.trace != nil {
+					logTracepoint(ctx, bpoint)
+				}
+
+				// conditional breakpoints, line markers and tracepoints stay armed until their condition holds
+				if !breakpointConditionHolds(ctx, bpoint) {
+					exited, err = continueExecution(ctx, stepping)
+					if stepping {
+						break
+					}
+					continue
+				}
+
+				if bpoint.iteration != nil {
+					logger.Info("Caught at %v", bpoint.iteration)
+				}
+
+				// a probe runs on after its commands
+				if len(bpoint.commands) > 0 && runBreakpointCommands(ctx, bpoint) && !stepping {
+					exited, err = continueExecution(ctx, false)
+					continue
+				}
+			}
+
+			if !bpoint.isMPIBpoint || stepping {
 				break
 			}
 
-			exited = continueExecution(ctx, false)
+			exited, err = continueExecution(ctx, false)
+		}
+
+		setRunning(ctx, false)
+
+		if !exited {
+			removeTemporaryBreakpoints(ctx)
+		}
+
+		if cmd.Code == command.Detach && !exited {
+			reattachBreakpoints(ctx)
 		}
 	}
 
-	if !exited {
-		ctx.stack = getStack(ctx)
+	if exited {
+		releaseAllSnapshots(ctx)
+	} else {
+		updateStack(ctx)
 
-		if cmd.IsProgressCommand() {
+		if cmd.IsProgressCommand() || cmd.Code == command.Backtrace {
 			logger.Info("call stack: %v", ctx.stack)
 		}
+
+		if cmd.Code == command.StepInstructions {
+			output = printInstruction(ctx)
+		}
+
+		if cmd.IsProgressCommand() {
+			showDisplays(ctx)
+		}
 	}
 
 	cmd.Result = &command.CommandResult{
-		Exited: exited,
+		Exited:      exited,
+		Breakpoint:  cmd.IsForwardProgressCommand() && !exited && ctx.caughtBreakpoint != nil,
+		Interrupted: cmd.IsForwardProgressCommand() && !exited && wasInterrupted(ctx),
+		Output:      output,
+		Values:      values,
+	}
+
+	// the call stack is also collected by the orchestrator for the tree of the call stacks of all nodes (wheretree)
+	if (cmd.IsProgressCommand() || cmd.Code == command.Backtrace) && !exited {
+		cmd.Result.Location = sourceLocation(ctx, getRegs(ctx, false).Rip)
+		cmd.Result.Backtrace = ctx.stack.String()
+	}
+
+	if cmd.Result.Breakpoint {
+		emitEvent(ctx, rpc.EVENT_BREAKPOINT, "hit %d times", ctx.caughtBreakpoint.hitCount)
 	}
 
 	if err != nil {
 		cmd.Result.Error = err.Error()
+		cmd.Result.ErrorKind = utils.ErrorKind(err)
 	}
 }
 
-func setBreakPoint(ctx *processContext, file string, line int) (err error) {
+// Sets a breakpoint from its textual description: a line number, <file>:<line> or a function, optionally followed by `if <condition>`
+func setConditionalBreakpoint(ctx *processContext, description string) (err error) {
+	location, conditionSource, hasCondition := strings.Cut(description, " if ")
+
+	var breakCondition *condition
+
+	if hasCondition {
+		breakCondition, err = parseCondition(conditionSource)
+		if err != nil {
+			logger.Warn("cannot set breakpoint: %v", err)
+			return err
+		}
+	}
+
+	if line, err := strconv.Atoi(strings.TrimSpace(location)); err == nil {
+		return setBreakPoint(ctx, ctx.sourceFile, line, breakCondition)
+	}
+
+	if location = strings.TrimSpace(location); lineLocationRegexp.MatchString(location) {
+		file, line, err := parseLineLocation(ctx, location)
+		if err != nil {
+			logger.Warn("cannot set breakpoint: %v", err)
+			return err
+		}
+
+		return setBreakPoint(ctx, file, line, breakCondition)
+	}
+
+	return setFunctionBreakpoint(ctx, strings.TrimSpace(location), breakCondition)
+}
+
+func setBreakPoint(ctx *processContext, file string, line int, breakCondition *condition) (err error) {
 	address, err := ctx.dwarfData.LineToPC(file, line)
 
 	if err != nil {
@@ -112,6 +423,15 @@ func setBreakPoint(ctx *processContext, file string, line int) (err error) {
 	}
 
 	logger.Info("setting breakpoint at line: %d", line)
+
+	// the line is marked for a causal breakpoint or traced, the target stops there from now on
+	if marker := ctx.bpointData[address]; marker != nil && (marker.markerOnly || marker.traceOnly) {
+		marker.markerOnly = false
+		marker.traceOnly = false
+		marker.condition = breakCondition
+		return nil
+	}
+
 	originalInstruction := insertBreakpoint(ctx, address)
 
 	ctx.bpointData[address] = &bpointData{
@@ -120,61 +440,216 @@ func setBreakPoint(ctx *processContext, file string, line int) (err error) {
 		function:                nil,
 		isMPIBpoint:             false,
 		isImmediateAfterRestore: false,
+		condition:               breakCondition,
 	}
 
 	return nil
 }
 
-func continueExecution(ctx *processContext, singleStep bool) (exited bool) {
+// Sets breakpoints at the functions the identifier refers to, e.g. at all overloads of a C++ method
+func setFunctionBreakpoint(ctx *processContext, identifier string, breakCondition *condition) (err error) {
+	functions := ctx.dwarfData.LookupFunctions(identifier)
+
+	if len(functions) == 0 {
+		err = fmt.Errorf("function %s not found", identifier)
+		logger.Warn("cannot set breakpoint: %v", err)
+		return err
+	}
+
+	for _, function := range functions {
+		address := ctx.dwarfData.FunctionBreakpointAddress(function)
+
+		if bpoint := ctx.bpointData[address]; bpoint != nil {
+			bpoint.traceOnly = false
+			continue
+		}
+
+		logger.Info("setting breakpoint at function: %v", function.Signature())
+		originalInstruction := insertBreakpoint(ctx, address)
+
+		ctx.bpointData[address] = &bpointData{
+			address:                 address,
+			originalInstruction:     originalInstruction,
+			function:                function,
+			isMPIBpoint:             false,
+			isImmediateAfterRestore: false,
+			condition:               breakCondition,
+		}
+	}
+
+	return nil
+}
+
+func continueExecution(ctx *processContext, singleStep bool) (exited bool, err error) {
 	var waitStatus syscall.WaitStatus
+	var signal syscall.Signal // signal to deliver on resume
+
+	// the breakpoint the target was caught at executes its instruction before it is inserted again
+	stepped, exited, err := stepOverLiftedBreakpoint(ctx)
+	if err != nil || exited || (stepped && singleStep) {
+		return exited, err
+	}
+
+	// the target runs on past the matching write it was stopped after by the output catch
+	if ctx.outputCatches != nil {
+		ctx.outputCatches.stopped = false
+	}
+
+	// the target stopped at a fatal signal dies of it, unless a handler of the program catches it
+	signal = takeFatalSignal(ctx)
+
+	// the instruction the target is stopped at by the NaN trap would raise the exception again
+	if ctx.nanTrap != nil && ctx.nanTrap.stopped {
+		exited, err = stepPastNaNTrap(ctx)
+		if err != nil || exited || singleStep {
+			return exited, err
+		}
+	}
 
 	for i := 0; i < 100; i++ {
 
-		if singleStep {
-			err := syscall.PtraceSingleStep(ctx.pid)
-			utils.Must(err)
-		} else {
-			err := syscall.PtraceCont(ctx.pid, 0)
-			utils.Must(err)
+		// during a replay, signals are re-delivered where they were originally received
+		exited, stoppedAtBreakpoint, err := replaySignals(ctx, !singleStep)
+		if err != nil || exited || stoppedAtBreakpoint {
+			return exited, err
 		}
 
-		syscall.Wait4(ctx.pid, &waitStatus, 0, nil)
+		err = resumeTarget(ctx, singleStep, signal)
+		if err != nil {
+			return false, utils.PtraceError(err)
+		}
+
+		signal = 0
+
+		err = waitForStop(ctx, &waitStatus)
+		if err != nil {
+			return false, utils.PtraceError(err)
+		}
+
+		// the syscalls of the target are stopped at while its output is scanned
+		caught, err := passSyscallStops(ctx, &waitStatus)
+		if err != nil {
+			return false, utils.PtraceError(err)
+		}
+		if caught {
+			return false, nil
+		}
 
 		if waitStatus.Exited() {
 			logger.Verbose("The binary exited with code %v", waitStatus.ExitStatus())
-			return true
+			return true, nil
+		}
+
+		if waitStatus.Signaled() {
+			logger.Info("The binary was terminated by %v", waitStatus.Signal())
+			return true, nil
 		}
 
 		if waitStatus.StopSignal() == syscall.SIGTRAP && waitStatus.TrapCause() != syscall.PTRACE_EVENT_CLONE {
 			logger.Debug("binary hit trap, execution paused (wait status: %v, trap cause: %v)", waitStatus, waitStatus.TrapCause())
-			return false
+			return false, nil
 		}
-		// else {
+
+		if isNaNTrapStop(ctx, waitStatus.StopSignal()) {
+			reportNaNTrapStop(ctx)
+			return false, nil
+		}
+
+		if fatalSignals[waitStatus.StopSignal()] {
+			reportFatalSignalStop(ctx, waitStatus.StopSignal())
+			return false, nil
+		}
+
+		// stopped by the orchestrator, the SIGSTOP is not delivered to the target
+		if waitStatus.StopSignal() == syscall.SIGSTOP {
+			if requested, interrupts := takeInterrupt(ctx); interrupts {
+				logger.Info("interrupted at %v", describeLocation(ctx))
+				return false, nil
+			} else if requested {
+				continue
+			}
+		}
+
 		// received a signal other than trap/a trap from clone event, continue and wait more
-		// }
+		if waitStatus.StopSignal() != syscall.SIGTRAP {
+			signal = handleSignalStop(ctx, waitStatus.StopSignal())
+		}
 	}
 
 	panic(fmt.Sprintf("stuck at wait with signal: %v", waitStatus.StopSignal()))
 }
 
-func printVariable(ctx *processContext, varName string) {
-	value := getVariableFromMemory(ctx, varName, false)
-	if value == nil {
-		return
+// Prints the value of an expression, in a print format if prefixed with one (/x p->flags). Returns the value printed
+func printVariable(ctx *processContext, source string) (string, error) {
+	format, source, err := parsePrintFormat(source)
+	if err != nil {
+		logger.Info("%v", err)
+		return "", err
+	}
+
+	parsed, err := parseExpression(source)
+	if err != nil {
+		logger.Info("%v", err)
+		return "", err
+	}
+
+	value, err := parsed.root.evaluate(ctx)
+	if err != nil {
+		logger.Info("%v", err)
+		return "", err
+	}
+
+	formatted := formatExpressionValueAs(ctx, value, format)
+
+	fmt.Printf("Value of variable %s: %v\n", source, formatted)
+
+	return fmt.Sprint(formatted), nil
+}
+
+// Splits the print format off an argument of print, e.g. x of /x flags. Without a format the values are printed
+// by the encodings of their types
+func parsePrintFormat(argument string) (format byte, source string, err error) {
+	if !strings.HasPrefix(argument, "/") {
+		return 0, argument, nil
+	}
+
+	letters, source, _ := strings.Cut(argument[1:], " ")
+
+	if len(letters) != 1 || strings.IndexByte(dwarf.PrintFormats, letters[0]) < 0 {
+		return 0, "", fmt.Errorf("unknown print format /%s, expected one of /%s", letters, strings.Join(strings.Split(dwarf.PrintFormats, ""), " /"))
 	}
 
-	fmt.Printf("Value of variable %s: %v\n", varName, value)
+	return letters[0], strings.TrimSpace(source), nil
 }
 
 // Retrieves the value of a variable matching the specified idendifier, if present in the target
 func getVariableFromMemory(ctx *processContext, identifier string, suppressLogging bool) (value interface{}) {
-	var variable *dwarf.Variable
+	variable, address, err := locateVariable(ctx, identifier, suppressLogging)
+	if err != nil {
+		if !suppressLogging {
+			logger.Info("%v", err)
+		}
+		return nil
+	}
+
+	return readVariable(ctx, variable, address)
+}
+
+// Finds the declaration and memory address of a variable matching the specified identifier.
+// Fails if the variable is not present in the target, or its location cannot be decoded
+func locateVariable(ctx *processContext, identifier string, suppressLogging bool) (variable *dwarf.Variable, address uint64, err error) {
 	var variableStackFunction *stackFunction
 
+	name, indices, err := splitSubscripts(identifier)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid array element %s: %v", identifier, err)
+	}
+	identifier = name
+
 	// Process the call stack to find the matching variable
 	for _, stackFunction := range ctx.stack {
 		// Look for the variable declared in the stack function
-		variable = ctx.dwarfData.LookupVariableInFunction(stackFunction.function, identifier)
+		variable = ctx.dwarfData.LookupVariableInFunction(stackFunction.function, identifier, stackFunction.pc)
 
 		if variable != nil {
 			if !suppressLogging {
@@ -211,34 +686,49 @@ func getVariableFromMemory(ctx *processContext, identifier string, suppressLoggi
 	}
 
 	if variable == nil {
-		if !suppressLogging {
-			logger.Info("Cannot locate variable: %s", identifier)
-		}
-
-		return nil
+		return nil, 0, fmt.Errorf("cannot locate variable %s", identifier)
 	}
 
-	var frameBase int64
-
 	if variableStackFunction != nil {
-		frameBase = int64(variableStackFunction.baseAddress + 16)
-	}
+		frameBase := int64(variableStackFunction.baseAddress + 16)
 
-	// Debug the variable location instructions to obtain memory address
-	address, _, err := variable.DecodeLocation(dwarf.DwarfRegisters{FrameBase: frameBase})
+		// Debug the variable location instructions to obtain memory address
+		address, _, err = variable.DecodeLocation(dwarf.DwarfRegisters{FrameBase: frameBase}, memoryReader(ctx))
+	} else {
+		// Global and static variables have fixed addresses, so no stack frame is needed
+		address, err = variable.DecodeStaticLocation()
+	}
 
 	if err != nil {
-		logger.Error("Error decoding variable: %v", err)
-		return nil
+		return nil, 0, fmt.Errorf("cannot decode location of variable %s: %w", identifier, err)
 	}
 
 	if address == 0 {
-		logger.Warn("Cannot locate this variable")
-		return nil
+		return nil, 0, fmt.Errorf("%w: variable %s is at address 0", utils.ErrAddressNotMapped, identifier)
 	}
 
 	// logger.Debug("location of variable: %d", address)
 
+	if indices != nil {
+		variable, address, err = variable.Element(address, indices)
+		if err != nil {
+			return nil, 0, fmt.Errorf("cannot locate array element: %w", err)
+		}
+	}
+
+	return variable, address, nil
+}
+
+// Reads the value of a located variable from memory
+func readVariable(ctx *processContext, variable *dwarf.Variable, address uint64) interface{} {
+	if variable.IsGoValue() {
+		return ctx.dwarfData.FormatGoValue(variable, address, memoryReader(ctx))
+	}
+
+	if variable.IsArray() || variable.IsFloat() {
+		return ctx.dwarfData.FormatValue(variable, address, memoryReader(ctx))
+	}
+
 	rawValue := peekDataFromMemory(ctx, address, variable.ByteSize())
 	// rawValue := proc.ReadFromMemFile(ctx.pid, address, int(variable.baseType.byteSize))
 	// logger.Debug("raw value of variable: %v", rawValue)
@@ -247,6 +737,47 @@ func getVariableFromMemory(ctx *processContext, identifier string, suppressLoggi
 	return convertValueToType(rawValue, variable)
 }
 
+// Splits the subscripts of an array element off the identifier, in Fortran (a(1,2)) or C (a[0][1]) notation
+func splitSubscripts(identifier string) (name string, indices []int64, err error) {
+	name = identifier
+	subscripts := ""
+
+	if start := strings.IndexAny(identifier, "(["); start > 0 {
+		name, subscripts = identifier[:start], identifier[start:]
+	}
+
+	if len(subscripts) == 0 {
+		return name, nil, nil
+	}
+
+	if strings.HasPrefix(subscripts, "(") && strings.HasSuffix(subscripts, ")") {
+		subscripts = strings.ReplaceAll(subscripts[1:len(subscripts)-1], ",", " ")
+	} else {
+		subscripts = strings.NewReplacer("[", " ", "]", " ").Replace(subscripts)
+	}
+
+	for _, subscript := range strings.Fields(subscripts) {
+		index, err := strconv.ParseInt(subscript, 10, 64)
+		if err != nil {
+			return name, nil, err
+		}
+		indices = append(indices, index)
+	}
+
+	return name, indices, nil
+}
+
+// Returns a function reading the memory of the target
+func memoryReader(ctx *processContext) dwarf.ReadMemoryFunc {
+	return func(buffer []byte, address uint64) (int, error) {
+		count, err := syscall.PtracePeekData(ctx.pid, uintptr(address), buffer)
+		if err != nil {
+			err = fmt.Errorf("cannot read %d bytes at %#x: %w", len(buffer), address, utils.PtraceError(err))
+		}
+		return count, err
+	}
+}
+
 func peekDataFromMemory(ctx *processContext, address uint64, byteCount int64) []byte {
 	data := make([]byte, byteCount)
 
@@ -288,11 +819,14 @@ func printInternalData(ctx *processContext, varName string) {
 		logger.Info("currently at line %v in %v (func %v) ip:%#x", line, filepath.Base(fileName), fn.Name(), regs.Rip)
 	case "cp":
 		logger.Info("checkpoints: %v", ctx.cpointData)
+	case "signals":
+		logger.Info("received signals: %v, to re-deliver: %v", ctx.signals, ctx.replayedSignals)
 	}
 
 }
 
 func quitDebugger() {
 	logger.Info("Exiting")
+	closeTUI()
 	os.Exit(0)
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/perf"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+const (
+	INSTRUCTION_SKID_MARGIN      = 256               // instructions left to single-step after a counter overflow interrupt
+	INSTRUCTION_INTERRUPT_SIGNAL = syscall.SIGSTKFLT // signal of the counter overflow, unused by programs
+)
+
+// Starts counting the instructions retired by the target, if hardware counters are available
+func startInstructionCounting(ctx *processContext) {
+	counter, err := perf.OpenInstructionCounter(ctx.pid)
+	if err != nil {
+		logger.Warn("instruction counting unavailable, progress is tracked by events only (%v)", err)
+		return
+	}
+
+	ctx.instructionCounter = counter
+}
+
+// Returns the number of instructions retired by the target, following checkpoint restores.
+// Returns 0 if instruction counting is unavailable
+func getInstructionCount(ctx *processContext) uint64 {
+	if ctx.instructionCounter == nil {
+		return 0
+	}
+
+	count, err := ctx.instructionCounter.Read()
+	if err != nil {
+		logger.Warn("cannot read instruction counter: %v", err)
+		return 0
+	}
+
+	return count - ctx.instructionOffset
+}
+
+// Rewinds the instruction count to the value at the restored checkpoint
+func restoreInstructionCount(ctx *processContext, checkpoint cPoint) {
+	if ctx.instructionCounter == nil {
+		return
+	}
+
+	ctx.instructionOffset += getInstructionCount(ctx) - checkpoint.instructionCount
+}
+
+// Runs the target forward until it has retired exactly the given number of instructions.
+// The target is interrupted by a counter overflow shortly before the count and single-stepped
+// the rest of the way. With stopAtBreakpoints set, the run ends at the first breakpoint hit.
+// Otherwise breakpoints are passed the same way as during the original execution,
+// so that the instructions they retire are counted identically
+func runToInstructionCount(ctx *processContext, target uint64, stopAtBreakpoints bool) (stoppedAtBreakpoint bool, err error) {
+	if ctx.instructionCounter == nil {
+		return false, fmt.Errorf("instruction counting is unavailable")
+	}
+
+	var waitStatus syscall.WaitStatus
+
+	for {
+		current := getInstructionCount(ctx)
+
+		if current == target {
+			return false, nil
+		}
+
+		if current > target {
+			return false, fmt.Errorf("target passed instruction %d (currently at %d)", target, current)
+		}
+
+		if remaining := target - current; remaining > INSTRUCTION_SKID_MARGIN {
+			interrupt, err := perf.OpenInstructionInterrupt(ctx.pid, remaining-INSTRUCTION_SKID_MARGIN, INSTRUCTION_INTERRUPT_SIGNAL)
+			if err != nil {
+				return false, err
+			}
+
+			err = syscall.PtraceCont(ctx.pid, 0)
+			utils.Must(err)
+
+			err = waitForStop(ctx, &waitStatus)
+			interrupt.Close()
+
+			if err != nil {
+				return false, err
+			}
+		} else {
+			err := syscall.PtraceSingleStep(ctx.pid)
+			utils.Must(err)
+
+			err = waitForStop(ctx, &waitStatus)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if waitStatus.Exited() {
+			return false, fmt.Errorf("target exited before instruction %d", target)
+		}
+
+		// a write to the memory watched by lastwrite, recorded on the way
+		if waitStatus.StopSignal() == syscall.SIGTRAP && recordWatchpointHit(ctx) {
+			continue
+		}
+
+		if isNaNTrapStop(ctx, waitStatus.StopSignal()) {
+			exited, err := stepPastNaNTrap(ctx)
+			if err != nil {
+				return false, err
+			}
+			if exited {
+				return false, fmt.Errorf("target exited before instruction %d", target)
+			}
+			continue
+		}
+
+		if waitStatus.StopSignal() != syscall.SIGTRAP {
+			// the overflow interrupt or another signal, suppressed on the next resume
+			continue
+		}
+
+		// a trap from a single-step or a breakpoint
+		regs := getRegs(ctx, true)
+		bpoint := findBreakpointByAddress(ctx, regs.Rip)
+
+		if bpoint == nil {
+			continue
+		}
+
+		if stopAtBreakpoints {
+			return true, nil
+		}
+
+		if bpoint.isMPIBpoint {
+			return false, fmt.Errorf("target reached %v before instruction %d", bpoint.function.Name(), target)
+		}
+
+		restoreCaughtBreakpoint(ctx)
+	}
+}
+
+// Moves the target back by the given number of instructions. The target is restored to its
+// latest checkpoint and re-executed up to the preceding instruction
+func reverseStepInstructions(ctx *processContext, count int) error {
+	if ctx.instructionCounter == nil {
+		return fmt.Errorf("reverse stepping requires instruction counting, which is unavailable")
+	}
+
+	if len(ctx.cpointData) == 0 {
+		return fmt.Errorf("no checkpoint recorded to step back from")
+	}
+
+	current := getInstructionCount(ctx)
+	checkpoint := ctx.cpointData[len(ctx.cpointData)-1]
+
+	// stepping past the checkpoint would undo an mpi operation, which requires a rollback instead
+	if uint64(count) > current-checkpoint.instructionCount {
+		return fmt.Errorf("cannot step back %d instructions, %d retired since the last checkpoint (%v)", count, current-checkpoint.instructionCount, checkpoint.opName)
+	}
+
+	err := restoreCheckpoint(ctx, checkpoint.id)
+	if err != nil {
+		return err
+	}
+
+	// the restored checkpoint is the latest, no recorded events are re-executed
+	ctx.replayedCheckpoints = nil
+
+	_, err = replayToInstructionCount(ctx, current-uint64(count), false)
+	return err
+}
+
+// Runs the target forward to the instruction count like runToInstructionCount,
+// re-delivering the recorded signals received before the instruction on the way
+func replayToInstructionCount(ctx *processContext, target uint64, stopAtBreakpoints bool) (stoppedAtBreakpoint bool, err error) {
+	for record := pendingReplayedSignal(ctx); record != nil && record.instructionCount < target; record = pendingReplayedSignal(ctx) {
+		stoppedAtBreakpoint, err = runToInstructionCount(ctx, record.instructionCount, stopAtBreakpoints)
+		if err != nil || stoppedAtBreakpoint {
+			return stoppedAtBreakpoint, err
+		}
+
+		exited, err := deliverReplayedSignal(ctx)
+		if err != nil {
+			return false, err
+		}
+		if exited {
+			return false, fmt.Errorf("target exited before instruction %d", target)
+		}
+	}
+
+	return runToInstructionCount(ctx, target, stopAtBreakpoints)
+}
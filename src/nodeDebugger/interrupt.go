@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+)
+
+// Interruption of the target while a forward progress command runs it, asked for by the orchestrator, e.g. when
+// another node stops at a breakpoint in all-stop mode. The request arrives on the rpc server while the command waits
+// for the target, so the target is stopped with a SIGSTOP, which the wait recognizes and does not deliver
+type interruptState struct {
+	sync.Mutex
+	running   bool // a forward progress command is running the target
+	requested bool // a SIGSTOP was sent and not seen by a wait yet
+	stale     bool // the command ended before the SIGSTOP was seen, it is suppressed on the next resume
+	stopped   bool // the last forward progress command was interrupted
+}
+
+// Interrupts the running command for the orchestrator, the reason is logged: another node stopped at a breakpoint
+// (all-stop), collecting the call stack (wheretree) or reattaching the console (attach)
+func (r RemoteCmdHandler) Interrupt(_ context.Context, request *pb.InterruptRequest) (*pb.InterruptReply, error) {
+	interrupted := interruptTarget(r.ctx)
+
+	if interrupted {
+		logger.Verbose("interrupting the target, %v", request.Reason)
+	}
+	return &pb.InterruptReply{Interrupted: interrupted}, nil
+}
+
+// Stops the target, if a forward progress command is running it. Returns whether it was interrupted
+func interruptTarget(ctx *processContext) bool {
+	ctx.interrupt.Lock()
+	defer ctx.interrupt.Unlock()
+
+	if !ctx.interrupt.running || ctx.interrupt.requested {
+		return false
+	}
+
+	err := syscall.Kill(ctx.pid, syscall.SIGSTOP)
+	if err != nil {
+		logger.Warn("cannot interrupt the target: %v", err)
+		return false
+	}
+
+	ctx.interrupt.requested = true
+	return true
+}
+
+// Marks the start or the end of a forward progress command, the target can only be interrupted in between
+func setRunning(ctx *processContext, running bool) {
+	ctx.interrupt.Lock()
+	defer ctx.interrupt.Unlock()
+
+	ctx.interrupt.running = running
+
+	if running {
+		ctx.interrupt.stopped = false
+	} else if ctx.interrupt.requested {
+		ctx.interrupt.stale = true
+	}
+}
+
+// Returns whether a SIGSTOP the target stopped with was sent by interruptTarget, and whether it interrupts the
+// running command. A SIGSTOP left over from an earlier command is only suppressed
+func takeInterrupt(ctx *processContext) (requested bool, interrupts bool) {
+	ctx.interrupt.Lock()
+	defer ctx.interrupt.Unlock()
+
+	if !ctx.interrupt.requested {
+		return false, false
+	}
+
+	ctx.interrupt.requested = false
+
+	if ctx.interrupt.stale {
+		ctx.interrupt.stale = false
+		return true, false
+	}
+
+	ctx.interrupt.stopped = true
+	return true, true
+}
+
+// Returns whether the last forward progress command was interrupted
+func wasInterrupted(ctx *processContext) bool {
+	ctx.interrupt.Lock()
+	defer ctx.interrupt.Unlock()
+
+	return ctx.interrupt.stopped
+}
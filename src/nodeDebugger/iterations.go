@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// A breakpoint stopping at an iteration of a loop, e.g. a time step of a simulation
+type iterationBreak struct {
+	location  string // line the breakpoint was set at, [<file>:]<line>
+	loop      bool   // whether the line is a loop header, the breakpoint is then at the start of the loop body
+	iteration int    // the iteration stopped at, counted from when the breakpoint was set
+}
+
+func (b *iterationBreak) String() string {
+	if b.loop {
+		return fmt.Sprintf("iteration %d of the loop at %v", b.iteration, b.location)
+	}
+	return fmt.Sprintf("execution %d of %v", b.iteration, b.location)
+}
+
+// Sets a breakpoint stopping at the nth iteration of a loop (break-iter [<file>:]<line> <n>). At a loop header,
+// whose code surrounds the body, the breakpoint is at the start of the body, passed once per iteration.
+// At other lines it stops at the nth execution of the line. The first n-1 hits are ignored
+func setIterationBreakpoint(ctx *processContext, argument string) error {
+	fields := strings.Fields(argument)
+	if len(fields) != 2 {
+		return fmt.Errorf("invalid argument %v, want [<file>:]<line> <n>", argument)
+	}
+
+	iteration, err := strconv.Atoi(fields[1])
+	if err != nil || iteration < 1 {
+		return fmt.Errorf("invalid iteration %v", fields[1])
+	}
+
+	file, line, err := parseLineLocation(ctx, fields[0])
+	if err != nil {
+		return err
+	}
+
+	address, isLoop, err := ctx.dwarfData.LoopBodyEntry(file, line)
+	if err != nil {
+		return err
+	}
+
+	breakCondition, err := parseCondition(fmt.Sprintf("$hitcount == %d", iteration))
+	utils.Must(err)
+
+	iterationBreak := &iterationBreak{location: fields[0], loop: isLoop, iteration: iteration}
+
+	if bpoint := ctx.bpointData[address]; bpoint != nil {
+		if !bpoint.markerOnly && !bpoint.traceOnly {
+			return fmt.Errorf("a breakpoint is already set at %v", sourceLocation(ctx, address))
+		}
+
+		// the line is marked for a causal breakpoint or traced, the breakpoint counts the iterations from now on
+		bpoint.markerOnly = false
+		bpoint.traceOnly = false
+		bpoint.hitCount = 0
+		bpoint.condition = breakCondition
+		bpoint.iteration = iterationBreak
+	} else {
+		ctx.bpointData[address] = &bpointData{
+			address:             address,
+			originalInstruction: insertBreakpoint(ctx, address),
+			condition:           breakCondition,
+			iteration:           iterationBreak,
+		}
+	}
+
+	if isLoop {
+		logger.Info("stopping at %v, the loop body starts at %v", iterationBreak, sourceLocation(ctx, address))
+	} else {
+		logger.Info("stopping at %v, not the header of a loop", iterationBreak)
+	}
+
+	return nil
+}
+
+// Prints how many times the line the target is stopped at has executed (info iteration). The executions are
+// counted by a breakpoint at the line, from when the breakpoint was set
+func printIteration(ctx *processContext) (string, error) {
+	pc := getRegs(ctx, false).Rip
+	location := sourceLocation(ctx, pc)
+
+	bpoint := ctx.caughtBreakpoint
+	if bpoint == nil || bpoint.address != pc {
+		bpoint = countingBreakpoint(ctx, location)
+	}
+
+	if bpoint == nil {
+		return "", fmt.Errorf("the executions of %v are not counted, set a breakpoint or break-iter at the line", location)
+	}
+
+	if bpoint.iteration != nil && bpoint.iteration.loop {
+		output := fmt.Sprintf("iteration %d of the loop at %v (%v)", bpoint.hitCount, bpoint.iteration.location, location)
+		fmt.Println(output)
+		return output, nil
+	}
+
+	output := fmt.Sprintf("%v executed %d times", location, bpoint.hitCount)
+	fmt.Println(output)
+	return output, nil
+}
+
+// Returns a breakpoint armed at the line, counting its executions (nil if none)
+func countingBreakpoint(ctx *processContext, location string) *bpointData {
+	if len(location) == 0 {
+		return nil
+	}
+
+	for address, bpoint := range ctx.bpointData {
+		if !bpoint.isMPIBpoint && !bpoint.causalReceive && sourceLocation(ctx, address) == location {
+			return bpoint
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/proc"
+)
+
+// Finds the code the target generated at runtime (JIT compilers, e.g. numba or the code generators of solvers),
+// which the debug info of the binary does not cover. Its regions name the generated functions in backtraces
+type JITSymbolizer interface {
+	// reads the regions of generated code the target registered so far
+	regions(ctx *processContext) ([]jitRegion, error)
+	// name of the registration mechanism
+	String() string
+}
+
+// the symbolizers asked for the generated code, in order. A region found by an earlier one takes precedence
+var jitSymbolizers = []JITSymbolizer{gdbJITInterface{}, perfMapFile{}}
+
+// A function generated at runtime
+type jitRegion struct {
+	start      uint64
+	end        uint64
+	symbol     string
+	symbolizer string // name of the symbolizer the region was found by
+}
+
+func (r jitRegion) contains(pc uint64) bool {
+	return pc >= r.start && pc < r.end
+}
+
+// The regions of generated code, read once per stop of the target, as the code can be generated at any time
+type jitSymbols struct {
+	loaded  bool
+	regions []jitRegion
+
+	descriptors map[string]uint64 // address of the GDB JIT descriptor by the mapped file defining it (0 - not defined)
+}
+
+// Forgets the regions read, the next lookup reads them again
+func (s *jitSymbols) invalidate() {
+	s.loaded = false
+	s.regions = nil
+}
+
+func loadJITRegions(ctx *processContext) []jitRegion {
+	if ctx.jitSymbols.loaded {
+		return ctx.jitSymbols.regions
+	}
+
+	regions := make([]jitRegion, 0)
+
+	for _, symbolizer := range jitSymbolizers {
+		found, err := symbolizer.regions(ctx)
+		if err != nil {
+			logger.Debug("cannot read generated code from the %v: %v", symbolizer, err)
+			continue
+		}
+
+		regions = append(regions, found...)
+	}
+
+	// earlier symbolizers first at the same address
+	sort.SliceStable(regions, func(i, j int) bool { return regions[i].start < regions[j].start })
+
+	ctx.jitSymbols.loaded = true
+	ctx.jitSymbols.regions = regions
+
+	return regions
+}
+
+// Returns the generated function containing the instruction, nil if none
+func lookupJITSymbol(ctx *processContext, pc uint64) *jitRegion {
+	regions := loadJITRegions(ctx)
+
+	for index := range regions {
+		if regions[index].contains(pc) {
+			return &regions[index]
+		}
+	}
+
+	return nil
+}
+
+// Lists the generated functions the target registered (info jit)
+func listJITRegions(ctx *processContext) {
+	regions := loadJITRegions(ctx)
+
+	if len(regions) == 0 {
+		logger.Info("no generated code registered through the GDB JIT interface or a perf map file")
+		return
+	}
+
+	for _, region := range regions {
+		fmt.Printf("%#x-%#x  %s  (%v)\n", region.start, region.end, region.symbol, region.symbolizer)
+	}
+}
+
+// Code registered through the GDB JIT interface: the JIT compiler links an in-memory ELF object per compiled unit
+// into the list of __jit_debug_descriptor, and calls __jit_debug_register_code. The symbol tables of the objects
+// give the generated functions
+type gdbJITInterface struct{}
+
+const (
+	JIT_DESCRIPTOR_SYMBOL = "__jit_debug_descriptor"
+
+	// larger objects are not read
+	MAX_JIT_OBJECT_SIZE = 64 << 20
+	// entries followed at most, the list is read from a running target
+	MAX_JIT_ENTRIES = 4096
+)
+
+func (gdbJITInterface) String() string {
+	return "GDB JIT interface"
+}
+
+func (gdbJITInterface) regions(ctx *processContext) ([]jitRegion, error) {
+	descriptor := findJITDescriptor(ctx)
+	if descriptor == 0 {
+		return nil, nil
+	}
+
+	// struct jit_descriptor { uint32_t version; uint32_t action_flag; jit_code_entry *relevant_entry; jit_code_entry *first_entry; }
+	header := make([]byte, 24)
+	_, err := syscall.PtracePeekData(ctx.pid, uintptr(descriptor), header)
+	if err != nil {
+		return nil, err
+	}
+
+	regions := make([]jitRegion, 0)
+
+	// struct jit_code_entry { jit_code_entry *next_entry; jit_code_entry *prev_entry; const char *symfile_addr; uint64_t symfile_size; }
+	entry := make([]byte, 32)
+
+	address := binary.LittleEndian.Uint64(header[16:])
+	for count := 0; address != 0 && count < MAX_JIT_ENTRIES; count++ {
+		_, err = syscall.PtracePeekData(ctx.pid, uintptr(address), entry)
+		if err != nil {
+			return regions, err
+		}
+
+		objectAddress, objectSize := binary.LittleEndian.Uint64(entry[16:]), binary.LittleEndian.Uint64(entry[24:])
+
+		if objectSize > 0 && objectSize <= MAX_JIT_OBJECT_SIZE {
+			object := make([]byte, objectSize)
+
+			_, err = syscall.PtracePeekData(ctx.pid, uintptr(objectAddress), object)
+			if err == nil {
+				regions = append(regions, objectFunctions(object)...)
+			} else {
+				logger.Debug("cannot read the generated object at %#x: %v", objectAddress, err)
+			}
+		}
+
+		address = binary.LittleEndian.Uint64(entry[:8])
+	}
+
+	return regions, nil
+}
+
+// Returns the functions in the symbol table of an in-memory object, at the addresses the JIT compiler placed them
+func objectFunctions(object []byte) []jitRegion {
+	elfFile, err := elf.NewFile(bytes.NewReader(object))
+	if err != nil {
+		logger.Debug("generated object is not an ELF file: %v", err)
+		return nil
+	}
+	defer elfFile.Close()
+
+	symbols, err := elfFile.Symbols()
+	if err != nil {
+		return nil
+	}
+
+	regions := make([]jitRegion, 0)
+
+	for _, symbol := range symbols {
+		if elf.ST_TYPE(symbol.Info) != elf.STT_FUNC || symbol.Value == 0 || symbol.Size == 0 {
+			continue
+		}
+
+		regions = append(regions, jitRegion{
+			start:      symbol.Value,
+			end:        symbol.Value + symbol.Size,
+			symbol:     dwarf.Demangle(symbol.Name),
+			symbolizer: gdbJITInterface{}.String(),
+		})
+	}
+
+	return regions
+}
+
+// Returns the address of the JIT descriptor in the target, defined by the binary or by a library the JIT compiler
+// is part of. The symbol tables of the mapped files are read once, 0 if none defines it
+func findJITDescriptor(ctx *processContext) uint64 {
+	if ctx.jitSymbols.descriptors == nil {
+		ctx.jitSymbols.descriptors = make(map[string]uint64)
+	}
+
+	for _, region := range proc.GetMemoryLayout(ctx.pid) {
+		file := region.Ident
+		if !strings.HasPrefix(file, "/") {
+			continue
+		}
+
+		address, read := ctx.jitSymbols.descriptors[file]
+		if !read {
+			address = symbolLoadAddress(ctx, file, JIT_DESCRIPTOR_SYMBOL)
+			ctx.jitSymbols.descriptors[file] = address
+		}
+
+		if address != 0 {
+			return address
+		}
+	}
+
+	return 0
+}
+
+// Returns the address of a symbol of a mapped file in the target, 0 if the file does not define it
+func symbolLoadAddress(ctx *processContext, file string, name string) uint64 {
+	elfFile, err := elf.Open(file)
+	if err != nil {
+		return 0
+	}
+	defer elfFile.Close()
+
+	symbols, err := elfFile.Symbols()
+	if err != nil {
+		// stripped libraries keep their dynamic symbols
+		symbols, err = elfFile.DynamicSymbols()
+		if err != nil {
+			return 0
+		}
+	}
+
+	for _, symbol := range symbols {
+		if symbol.Name != name || symbol.Section == elf.SHN_UNDEF {
+			continue
+		}
+
+		linkAddress, positionIndependent, err := dwarf.LinkAddress(file)
+		if err != nil || !positionIndependent {
+			return symbol.Value
+		}
+
+		loadAddress, err := proc.GetMappedAddress(ctx.pid, file)
+		if err != nil {
+			return 0
+		}
+
+		return symbol.Value + loadAddress - linkAddress
+	}
+
+	return 0
+}
+
+// Code listed in /tmp/perf-<pid>.map, which JIT compilers write for perf: a line `<start> <size> <name>` per
+// function, in hex
+type perfMapFile struct{}
+
+func (perfMapFile) String() string {
+	return "perf map file"
+}
+
+func (perfMapFile) regions(ctx *processContext) ([]jitRegion, error) {
+	file, err := os.Open(fmt.Sprintf("/tmp/perf-%d.map", ctx.pid))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	regions := make([]jitRegion, 0)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 3)
+		if len(fields) < 3 {
+			continue
+		}
+
+		start, err := strconv.ParseUint(strings.TrimPrefix(fields[0], "0x"), 16, 64)
+		if err != nil {
+			continue
+		}
+		size, err := strconv.ParseUint(strings.TrimPrefix(fields[1], "0x"), 16, 64)
+		if err != nil || size == 0 {
+			continue
+		}
+
+		regions = append(regions, jitRegion{start: start, end: start + size, symbol: fields[2], symbolizer: perfMapFile{}.String()})
+	}
+
+	return regions, scanner.Err()
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// the maximum number of exec events to follow before giving up
+const maxFollowedExecs = 10
+
+// Returns whether the file is an ELF binary (as opposed to a script run by an interpreter)
+func isElfFile(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(elf.ELFMAG))
+
+	_, err = f.Read(magic)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(magic, []byte(elf.ELFMAG))
+}
+
+// Returns whether the binary contains DWARF debug information
+func hasDebugInfo(file string) bool {
+	elfFile, err := elf.Open(file)
+	if err != nil {
+		return false
+	}
+	defer elfFile.Close()
+
+	return elfFile.Section(".debug_info") != nil || elfFile.Section(".zdebug_info") != nil
+}
+
+// Follows the exec events of a launcher script (and the interpreter running it), and of the processes it forks,
+// until one of them executes a binary with debug information. That process becomes the target, the other processes
+// and threads the launcher started are detached to run on untraced, e.g. a shell waiting for the target to exit.
+// Returns the path of the executed binary and the process id of the target
+func followExecToTarget(ctx *processContext) (string, int) {
+	var waitStatus syscall.WaitStatus
+
+	err := syscall.PtraceSetOptions(ctx.pid, launcherPtraceOptions)
+	utils.Must(err)
+
+	executable, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", ctx.pid))
+	utils.Must(err)
+
+	if hasDebugInfo(executable) {
+		logger.Info("launcher executed target binary %v", executable)
+		return executable, ctx.pid
+	}
+
+	logger.Verbose("following exec of %v", executable)
+
+	// the processes and threads of the launcher traced, and whether the initial stop of each was seen
+	traced := map[int]bool{ctx.pid: true}
+	stopped := ctx.pid // the process stopped at the event just handled, resumed before waiting for the next
+	signal := 0        // signal the stopped process is resumed with
+
+	for execs := 0; execs < maxFollowedExecs; {
+		if stopped > 0 {
+			err = syscall.PtraceCont(stopped, signal)
+			utils.Must(err)
+		}
+		stopped, signal = 0, 0
+
+		pid, err := syscall.Wait4(-1, &waitStatus, syscall.WALL, nil)
+		if err == syscall.ECHILD {
+			panic("launcher exited without executing a binary with debug info")
+		}
+		utils.Must(err)
+
+		if waitStatus.Exited() || waitStatus.Signaled() {
+			delete(traced, pid)
+
+			if len(traced) == 0 {
+				panic(fmt.Sprintf("launcher exited (%v) without executing a binary with debug info", exitDescription(waitStatus)))
+			}
+			continue
+		}
+
+		if !waitStatus.Stopped() {
+			continue
+		}
+
+		stopped = pid
+
+		switch {
+		// the initial stop of a new process or thread, reported before or after the event of its parent
+		case !traced[pid] && waitStatus.StopSignal() == syscall.SIGSTOP:
+			traced[pid] = true
+		case waitStatus.StopSignal() != syscall.SIGTRAP:
+			signal = int(waitStatus.StopSignal())
+		case isForkEvent(waitStatus.TrapCause()):
+			child, err := syscall.PtraceGetEventMsg(pid)
+			if err == nil {
+				if _, seen := traced[int(child)]; !seen {
+					traced[int(child)] = false
+				}
+			}
+		case waitStatus.TrapCause() == syscall.PTRACE_EVENT_EXEC:
+			execs++
+
+			executable, err = os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+			if err != nil || !hasDebugInfo(executable) {
+				logger.Verbose("following exec of %v (pid %d)", executable, pid)
+				continue
+			}
+
+			logger.Info("launcher executed target binary %v (pid %d)", executable, pid)
+
+			delete(traced, pid)
+			detachLauncherProcesses(traced)
+
+			// the target is traced like a binary started directly, its own forks and threads are not followed
+			err = syscall.PtraceSetOptions(pid, ptraceOptions(ctx))
+			utils.Must(err)
+
+			return executable, pid
+		}
+	}
+
+	panic(fmt.Sprintf("no binary with debug info executed after %d exec events", maxFollowedExecs))
+}
+
+// Detaches the processes and threads of the launcher, other than the target. They run, so each is stopped first,
+// except those that have not reported their initial stop yet. Processes they fork meanwhile are detached as well
+func detachLauncherProcesses(traced map[int]bool) {
+	pending := make([]int, 0, len(traced))
+
+	for pid, started := range traced {
+		if started {
+			syscall.Syscall(syscall.SYS_TKILL, uintptr(pid), uintptr(syscall.SIGSTOP), 0)
+		}
+		pending = append(pending, pid)
+	}
+
+	var waitStatus syscall.WaitStatus
+
+	for len(pending) > 0 {
+		pid := pending[0]
+		pending = pending[1:]
+
+		for {
+			_, err := syscall.Wait4(pid, &waitStatus, syscall.WALL, nil)
+			if err != nil || waitStatus.Exited() || waitStatus.Signaled() {
+				break
+			}
+
+			// detaching suppresses the stop signal, the process runs on
+			if waitStatus.StopSignal() == syscall.SIGSTOP {
+				logger.Verbose("detaching launcher process %d", pid)
+				syscall.PtraceDetach(pid)
+				break
+			}
+
+			signal := 0
+			if waitStatus.StopSignal() != syscall.SIGTRAP {
+				signal = int(waitStatus.StopSignal())
+			} else if child, err := syscall.PtraceGetEventMsg(pid); err == nil && isForkEvent(waitStatus.TrapCause()) {
+				pending = append(pending, int(child))
+			}
+
+			syscall.PtraceCont(pid, signal)
+		}
+	}
+}
+
+// options the launcher is traced with: its execs, and the processes and threads it starts
+const launcherPtraceOptions = syscall.PTRACE_O_TRACEEXEC | syscall.PTRACE_O_TRACEFORK | syscall.PTRACE_O_TRACEVFORK | syscall.PTRACE_O_TRACECLONE
+
+func isForkEvent(cause int) bool {
+	return cause == syscall.PTRACE_EVENT_FORK || cause == syscall.PTRACE_EVENT_VFORK || cause == syscall.PTRACE_EVENT_CLONE
+}
+
+// How a process ended, e.g. "code 1" or "killed by terminated"
+func exitDescription(waitStatus syscall.WaitStatus) string {
+	if waitStatus.Signaled() {
+		return fmt.Sprintf("killed by %v", waitStatus.Signal())
+	}
+	return fmt.Sprintf("code %d", waitStatus.ExitStatus())
+}
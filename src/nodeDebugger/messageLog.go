@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// Messages received by a node rolled back alone, by the id of the receive event they were originally received at.
+// The other nodes are not rolled back and do not send them again
+type messageLog map[string]rpc.LoggedMessage
+
+// Sets the messages to replay after the next restore, sent by the orchestrator as json before rolling the node back alone
+func setMessageLog(ctx *processContext, argument string) error {
+	messages := make([]rpc.LoggedMessage, 0)
+
+	err := json.Unmarshal([]byte(argument), &messages)
+	if err != nil {
+		return fmt.Errorf("invalid message log: %v", err)
+	}
+
+	ctx.pendingMessageLog = make(messageLog)
+	for _, message := range messages {
+		ctx.pendingMessageLog[message.ReceiveId] = message
+	}
+
+	logger.Verbose("%d messages to replay from the message log after the restore", len(messages))
+	return nil
+}
+
+// Returns whether the MPI call recorded originally at the event is replayed from the message log: the sends and
+// the receives of messages received originally, while a node rolled back alone replays its events
+func isReplayedFromMessageLog(ctx *processContext, originalId string, opName string) bool {
+	if ctx.messageLog == nil {
+		return false
+	}
+
+	_, logged := ctx.messageLog[originalId]
+	return mpi.SEND_EVENTS[opName] || (opName == mpi.MPI_OPS[mpi.OP_RECV] && logged)
+}
+
+// Replays the MPI call the target is stopped at from the message log, if it is replayed from it. Sends return
+// without sending, their messages were received by the nodes not rolled back. Receives complete with the logged message
+func replayFromMessageLog(ctx *processContext, originalId string, opName string) {
+	if !isReplayedFromMessageLog(ctx, originalId, opName) {
+		return
+	}
+
+	if mpi.SEND_EVENTS[opName] {
+		forceReturn(ctx, 0) // MPI_SUCCESS
+		logger.Verbose("send at %v not repeated, the message was received by the nodes not rolled back", originalId)
+		return
+	}
+
+	message := ctx.messageLog[originalId]
+
+	err := injectLoggedMessage(ctx, message)
+	if err != nil {
+		logger.Warn("cannot replay the receive at %v from the message log: %v", originalId, err)
+		return
+	}
+
+	logger.Verbose("receive at %v replayed from the message log, with the message of rank %d (tag %d)", originalId, message.Source, message.Tag)
+}
+
+// Writes the logged message to the buffer of the receive wrapper the target is stopped in,
+// and has the wrapper complete the receive with it
+func injectLoggedMessage(ctx *processContext, message rpc.LoggedMessage) error {
+	updateStack(ctx)
+
+	buffer, bufferOk := getVariableFromMemory(ctx, "buf", true).(int64)
+	count, countOk := getVariableFromMemory(ctx, "count", true).(int32)
+	if !bufferOk || !countOk {
+		return fmt.Errorf("cannot locate the buffer of the receive")
+	}
+
+	if int(count) < message.Count {
+		return fmt.Errorf("the message of %d elements does not fit the buffer of %d", message.Count, count)
+	}
+
+	if len(message.Payload) > 0 {
+		_, err := syscall.PtracePokeData(ctx.pid, uintptr(buffer), message.Payload)
+		if err != nil {
+			return fmt.Errorf("cannot write the message to the buffer: %v", err)
+		}
+	}
+
+	err := setIntVariable(ctx, "_MPI_WRAPPER_LOGGED_SOURCE", message.Source)
+	if err == nil {
+		err = setIntVariable(ctx, "_MPI_WRAPPER_LOGGED_TAG", message.Tag)
+	}
+	if err == nil {
+		err = setIntVariable(ctx, "_MPI_WRAPPER_LOGGED_COUNT", message.Count)
+	}
+	if err == nil {
+		err = setIntVariable(ctx, "_MPI_WRAPPER_LOGGED_MESSAGE", 1)
+	}
+
+	return err
+}
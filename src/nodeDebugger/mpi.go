@@ -1,7 +1,12 @@
 package main
 
 import (
+	"encoding/binary"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ottmartens/cc-rev-db/logger"
 	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
@@ -27,6 +32,73 @@ var variablesToCapture FunctionVariableMap = FunctionVariableMap{
 	mpi.MPI_OPS[mpi.OP_FINALIZE]: VariableMap{
 		"rank": "_MPI_WRAPPER_PROC_RANK",
 	},
+	mpi.MPI_OPS[mpi.OP_ISEND]: VariableMap{
+		"rank":    "_MPI_WRAPPER_PROC_RANK",
+		"tag":     "tag",
+		"dest":    "dest",
+		"request": "request",
+	},
+	mpi.MPI_OPS[mpi.OP_IRECV]: VariableMap{
+		"rank":    "_MPI_WRAPPER_PROC_RANK",
+		"tag":     "tag",
+		"source":  "source",
+		"request": "request",
+	},
+	mpi.MPI_OPS[mpi.OP_WAIT]: VariableMap{
+		"rank":    "_MPI_WRAPPER_PROC_RANK",
+		"request": "request",
+	},
+	mpi.MPI_OPS[mpi.OP_WAITALL]: VariableMap{
+		"rank":     "_MPI_WRAPPER_PROC_RANK",
+		"count":    "count",
+		"requests": "requests",
+	},
+	mpi.MPI_OPS[mpi.OP_TEST_COMPLETED]: VariableMap{
+		"rank":    "_MPI_WRAPPER_PROC_RANK",
+		"request": "request",
+	},
+	mpi.MPI_OPS[mpi.OP_BARRIER]: VariableMap{
+		"rank": "_MPI_WRAPPER_PROC_RANK",
+	},
+	mpi.MPI_OPS[mpi.OP_BCAST]: VariableMap{
+		"rank":  "_MPI_WRAPPER_PROC_RANK",
+		"root":  "root",
+		"count": "count",
+	},
+	mpi.MPI_OPS[mpi.OP_REDUCE]: VariableMap{
+		"rank":  "_MPI_WRAPPER_PROC_RANK",
+		"root":  "root",
+		"count": "count",
+	},
+	mpi.MPI_OPS[mpi.OP_ALLREDUCE]: VariableMap{
+		"rank":  "_MPI_WRAPPER_PROC_RANK",
+		"count": "count",
+	},
+	mpi.MPI_OPS[mpi.OP_GATHER]: VariableMap{
+		"rank":  "_MPI_WRAPPER_PROC_RANK",
+		"root":  "root",
+		"count": "sendcount",
+	},
+	mpi.MPI_OPS[mpi.OP_ALLGATHER]: VariableMap{
+		"rank":  "_MPI_WRAPPER_PROC_RANK",
+		"count": "sendcount",
+	},
+	mpi.MPI_OPS[mpi.OP_SCATTER]: VariableMap{
+		"rank":  "_MPI_WRAPPER_PROC_RANK",
+		"root":  "root",
+		"count": "recvcount",
+	},
+}
+
+// keys - addresses of the MPI_Request handles of pending nonblocking operations, values - ids of their events
+type requestData map[int64]string
+
+func (r requestData) copy() requestData {
+	requests := make(requestData, len(r))
+	for address, eventId := range r {
+		requests[address] = eventId
+	}
+	return requests
 }
 
 var MPI_BPOINTS map[string]*bpointData
@@ -56,6 +128,16 @@ func insertMPIBreakpoint(ctx *processContext, bpoint *bpointData, isImmediateAft
 		bpoint.function,
 		bpoint.isMPIBpoint,
 		isImmediateAfterRestore,
+		nil,
+		0,
+		"",
+		false,
+		false,
+		nil,
+		nil,
+		nil,
+		false,
+		false,
 	}
 }
 
@@ -66,6 +148,10 @@ func initMPIBreakpointsData(ctx *processContext) {
 	for _, function := range ctx.dwarfData.Mpi.Functions {
 		fName := function.Name()
 
+		if mpi.UNRECORDED_OPERATIONS[fName] {
+			continue
+		}
+
 		funcEntries := ctx.dwarfData.GetEntriesForFunction(fName)
 		breakAddress := funcEntries[1].Address
 
@@ -77,6 +163,16 @@ func initMPIBreakpointsData(ctx *processContext) {
 			function,
 			true,
 			false,
+			nil,
+			0,
+			"",
+			false,
+			false,
+			nil,
+			nil,
+			nil,
+			false,
+			false,
 		}
 	}
 }
@@ -104,13 +200,38 @@ func recordMPIOperation(ctx *processContext, bpoint *bpointData) {
 
 	logger.Info("Recording MPI operation %v", opName)
 
+	// the previous receive has completed by the time the target makes another MPI call
+	recordReceivedMessage(ctx)
+
+	var original *cPoint
+	if isReplaying(ctx) {
+		original = &ctx.replayedCheckpoints[len(ctx.cpointData)]
+	} else {
+		// the recorded events are re-executed, the messages come from the other nodes again
+		ctx.messageLog = nil
+	}
+
 	checkpointId := createCheckpoint(ctx, opName)
 
+	// ticked after the checkpoint, a rollback to it takes the clock back to before the call
+	vectorClock := tickVectorClock(ctx)
+
+	awaitReceivedMessage(ctx, checkpointId, opName)
+	if original != nil && original.opName == opName {
+		forceRecordedMessage(ctx, *original)
+	}
+
 	record := rpc.MPICallRecord{
-		Id:         checkpointId,
-		OpName:     opName,
-		Parameters: make(map[string]string),
-		NodeId:     ctx.nodeData.id,
+		Id:               checkpointId,
+		OpName:           opName,
+		Parameters:       make(map[string]string),
+		NodeId:           ctx.nodeData.id,
+		InstructionCount: getInstructionCount(ctx),
+		Location:         mpiCallerLocation(ctx),
+		Time:             time.Now(),
+		CheckpointBytes:  checkpointSize(ctx, checkpointId),
+		BufferHashes:     hashBuffers(ctx),
+		VectorClock:      vectorClock,
 	}
 
 	for varName, identifier := range variablesToCapture[opName] {
@@ -118,6 +239,109 @@ func recordMPIOperation(ctx *processContext, bpoint *bpointData) {
 		record.Parameters[varName] = fmt.Sprintf("%v", variableValue)
 	}
 
+	pairRequests(ctx, &record)
+
+	if mpi.SEND_EVENTS[opName] {
+		sent := capturePayload(ctx, &record)
+		ctx.cpointData[len(ctx.cpointData)-1].sent = sent
+
+		if original != nil && original.opName == opName {
+			reportSendDivergence(*original, sent)
+		}
+	}
+
+	// calls replayed from the message log are not executed, no faults are injected into them
+	fromMessageLog := original != nil && original.opName == opName && isReplayedFromMessageLog(ctx, original.id, opName)
+
+	fault := matchingFault(ctx, opName)
+	if fault != nil && !fromMessageLog {
+		record.Parameters["injected"] = fault.String()
+	}
+
 	logger.Debug("MPI Call record: %v", record)
 	reportMPICall(ctx, &record)
+
+	if ctx.causalWatch && opName == mpi.MPI_OPS[mpi.OP_RECV] && !fromMessageLog && fault == nil {
+		watchReceive(ctx)
+	}
+
+	if fromMessageLog {
+		replayFromMessageLog(ctx, original.id, opName)
+	} else if fault != nil {
+		applyFault(ctx, *fault, opName)
+	}
+}
+
+// Returns the source location the MPI wrapper the target is stopped in was called from, empty if unknown.
+// The wrappers are compiled without optimizations, so the return address is right above their frame pointer
+func mpiCallerLocation(ctx *processContext) string {
+	regs := getRegs(ctx, false)
+
+	returnAddress := make([]byte, 8)
+	_, err := syscall.PtracePeekData(ctx.pid, uintptr(regs.Rbp+8), returnAddress)
+	if err != nil {
+		return ""
+	}
+
+	// the return address points past the call instruction
+	return sourceLocation(ctx, binary.LittleEndian.Uint64(returnAddress)-1)
+}
+
+// Returns the source location of the instruction at pc as file:line, empty if unknown
+func sourceLocation(ctx *processContext, pc uint64) string {
+	line, file, err := ctx.dwarfData.PCToLineContaining(pc)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+}
+
+// Pairs nonblocking operations with the operations completing them, by the address of their request handle.
+// Completion events get the ids of the events of the requests they complete
+func pairRequests(ctx *processContext, record *rpc.MPICallRecord) {
+	if mpi.NONBLOCKING_OPERATIONS[record.OpName] {
+		if address, ok := requestAddress(ctx, "request"); ok {
+			ctx.pendingRequests[address] = record.Id
+		}
+		return
+	}
+
+	if !mpi.COMPLETION_OPERATIONS[record.OpName] {
+		return
+	}
+
+	addresses := make([]int64, 0)
+
+	if record.OpName == mpi.MPI_OPS[mpi.OP_WAITALL] {
+		first, ok := requestAddress(ctx, "requests")
+		count, countOk := getVariableFromMemory(ctx, "count", true).(int32)
+		size, sizeOk := getVariableFromMemory(ctx, "_MPI_WRAPPER_REQUEST_SIZE", true).(int32)
+
+		if ok && countOk && sizeOk {
+			for index := int64(0); index < int64(count); index++ {
+				addresses = append(addresses, first+index*int64(size))
+			}
+		}
+	} else if address, ok := requestAddress(ctx, "request"); ok {
+		addresses = append(addresses, address)
+	}
+
+	completedEvents := make([]string, 0, len(addresses))
+
+	for _, address := range addresses {
+		if eventId, pending := ctx.pendingRequests[address]; pending {
+			completedEvents = append(completedEvents, eventId)
+			delete(ctx.pendingRequests, address)
+		}
+	}
+
+	record.Parameters["completes"] = strings.Join(completedEvents, ",")
+}
+
+// Reads a pointer to request handles from a parameter of the MPI wrapper the target is stopped at
+func requestAddress(ctx *processContext, identifier string) (int64, bool) {
+	address, ok := getVariableFromMemory(ctx, identifier, true).(int64)
+
+	return address, ok && address != 0
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+)
+
+// Fields of MPI_Status, named as in the MPI standard
+var statusFields = []struct {
+	label string
+	field string
+}{
+	{"source", "MPI_SOURCE"},
+	{"tag", "MPI_TAG"},
+	{"error", "MPI_ERROR"},
+}
+
+// Formats variables of the MPI status and handle types by what they describe rather than their raw contents,
+// nil for variables of other types
+func formatMPIValue(ctx *processContext, variable *dwarf.Variable, address uint64) interface{} {
+	switch variable.TypeName() {
+	case "MPI_Status":
+		return formatStatus(ctx, variable, address)
+	case "MPI_Request":
+		return formatRequest(ctx, variable, address)
+	case "MPI_Datatype":
+		return formatDatatype(ctx, variable, address)
+	}
+	return nil
+}
+
+// Formats the source, tag and error of a status, e.g. {source: 1, tag: 7, error: 0}
+func formatStatus(ctx *processContext, variable *dwarf.Variable, address uint64) interface{} {
+	fields := make([]string, 0, len(statusFields))
+
+	for _, statusField := range statusFields {
+		field, fieldAddress, err := variable.Field(address, statusField.field)
+		if err != nil {
+			logger.Debug("cannot decode status: %v", err)
+			return nil
+		}
+
+		fields = append(fields, fmt.Sprintf("%s: %v", statusField.label, readVariable(ctx, field, fieldAddress)))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+// Formats a request handle along with the recorded operation it was started by, if still pending
+func formatRequest(ctx *processContext, variable *dwarf.Variable, address uint64) interface{} {
+	handle := readVariable(ctx, variable, address)
+
+	if handle == getVariableFromMemory(ctx, "_MPI_WRAPPER_REQUEST_NULL", true) {
+		return "MPI_REQUEST_NULL"
+	}
+
+	if eventId, pending := ctx.pendingRequests[int64(address)]; pending {
+		return fmt.Sprintf("%v (pending, started by event %v)", handle, eventId)
+	}
+
+	return fmt.Sprintf("%v (not started by a recorded operation)", handle)
+}
+
+// Formats a predefined datatype handle by its name, other handles are left to the default formatting
+func formatDatatype(ctx *processContext, variable *dwarf.Variable, address uint64) interface{} {
+	handle := readVariable(ctx, variable, address)
+
+	name, _ := predefinedDatatype(ctx, handle)
+	if len(name) == 0 {
+		return nil
+	}
+
+	return fmt.Sprintf("%v (%v)", name, handle)
+}
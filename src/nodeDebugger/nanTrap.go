@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+const (
+	MXCSR_INVALID_FLAG  = 1 << 0
+	MXCSR_INVALID_MASK  = 1 << 7
+	PTRACE_GETSIGINFO   = 0x4202
+	FPE_FLTINV          = 7 // si_code of SIGFPE raised by an invalid floating-point operation
+	SIGINFO_SIZE        = 128
+	SIGINFO_CODE_OFFSET = 8
+)
+
+// Trapping of invalid floating-point operations, the operations producing NaNs from non-NaN operands
+type nanTrap struct {
+	enabled       bool // whether the invalid operation exception is unmasked in the target
+	programMasked bool // whether the target itself masked the exception when the trap was first enabled
+	stopped       bool // whether the target is stopped at an instruction that raised the exception
+}
+
+// Enables (on) or disables (off) stopping at the first instruction producing a NaN, or shows the state (empty argument).
+// The invalid operation exception is unmasked in the SSE control register of the target, so the instruction raises SIGFPE
+// instead of writing a NaN. Operations of the x87 unit are not trapped, their exceptions are raised at the next x87 instruction
+func trapNaN(ctx *processContext, argument string) error {
+	switch strings.TrimSpace(argument) {
+	case "":
+		if ctx.nanTrap == nil || !ctx.nanTrap.enabled {
+			logger.Info("NaN trap off")
+		} else {
+			logger.Info("NaN trap on, the target stops at the instruction producing a NaN")
+		}
+		return nil
+	case "on":
+		if ctx.nanTrap == nil {
+			control, err := getFPControl(ctx)
+			if err != nil {
+				return err
+			}
+
+			ctx.nanTrap = &nanTrap{programMasked: control.mxcsr&MXCSR_INVALID_MASK != 0}
+		}
+
+		ctx.nanTrap.enabled = true
+	case "off":
+		if ctx.nanTrap == nil {
+			return nil
+		}
+
+		ctx.nanTrap.enabled = false
+	default:
+		return fmt.Errorf("expected on or off, got %q", argument)
+	}
+
+	err := applyNaNTrap(ctx)
+	if err != nil {
+		return err
+	}
+
+	return trapNaN(ctx, "")
+}
+
+// Sets the invalid operation exception mask of the target by the state of the NaN trap.
+// Restored checkpoints may have recorded the register with another mask
+func applyNaNTrap(ctx *processContext) error {
+	if ctx.nanTrap == nil {
+		return nil
+	}
+
+	return setInvalidMask(ctx, !ctx.nanTrap.enabled && ctx.nanTrap.programMasked, true)
+}
+
+// Whether the target stopped with SIGFPE raised by the NaN trap, rather than by the program
+func isNaNTrapStop(ctx *processContext, signal syscall.Signal) bool {
+	if signal != syscall.SIGFPE || ctx.nanTrap == nil || !ctx.nanTrap.enabled {
+		return false
+	}
+
+	siginfo := make([]byte, SIGINFO_SIZE)
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, PTRACE_GETSIGINFO, uintptr(ctx.pid), 0, uintptr(unsafe.Pointer(&siginfo[0])), 0, 0)
+	if errno != 0 {
+		logger.Warn("cannot read the signal info of %v: %v", signal, errno)
+		return false
+	}
+
+	return int32(binary.LittleEndian.Uint32(siginfo[SIGINFO_CODE_OFFSET:])) == FPE_FLTINV
+}
+
+// Reports the instruction the target stopped at by the NaN trap. The instruction has not been executed
+func reportNaNTrapStop(ctx *processContext) {
+	ctx.nanTrap.stopped = true
+
+	regs := getRegs(ctx, false)
+	line, file, err := ctx.dwarfData.PCToLineContaining(regs.Rip)
+	function := ctx.dwarfData.PCToFunc(regs.Rip)
+
+	switch {
+	case err != nil:
+		logger.Info("NaN produced by the instruction at %#x", regs.Rip)
+	case function != nil:
+		logger.Info("NaN produced by the instruction at %#x: line %d, file %v (func %v)", regs.Rip, line, filepath.Base(file), function.Name())
+	default:
+		logger.Info("NaN produced by the instruction at %#x: line %d, file %v", regs.Rip, line, filepath.Base(file))
+	}
+
+	if ctx.instructionCounter != nil {
+		logger.Verbose("stopped after %d retired instructions", getInstructionCount(ctx))
+	}
+}
+
+// Executes the instruction the target is stopped at by the NaN trap with the exception masked, so that it writes its NaN.
+// The trap is armed again for the instructions after it
+func stepPastNaNTrap(ctx *processContext) (exited bool, err error) {
+	ctx.nanTrap.stopped = false
+
+	err = setInvalidMask(ctx, true, false)
+	if err != nil {
+		return false, err
+	}
+
+	err = resumeTarget(ctx, true, 0)
+	if err != nil {
+		return false, utils.PtraceError(err)
+	}
+
+	var waitStatus syscall.WaitStatus
+
+	err = waitForStop(ctx, &waitStatus)
+	if err != nil {
+		return false, utils.PtraceError(err)
+	}
+
+	if waitStatus.Exited() {
+		logger.Verbose("The binary exited with code %v", waitStatus.ExitStatus())
+		return true, nil
+	}
+
+	return false, applyNaNTrap(ctx)
+}
+
+// Masks or unmasks the invalid operation exception. With clearFlag set, the sticky flag of an earlier invalid operation is cleared
+func setInvalidMask(ctx *processContext, masked bool, clearFlag bool) error {
+	control, err := getFPControl(ctx)
+	if err != nil {
+		return err
+	}
+
+	if masked {
+		control.mxcsr |= MXCSR_INVALID_MASK
+	} else {
+		control.mxcsr &^= MXCSR_INVALID_MASK
+	}
+
+	if clearFlag {
+		control.mxcsr &^= MXCSR_INVALID_FLAG
+	}
+
+	return setFPControl(ctx, control)
+}
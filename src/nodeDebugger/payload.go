@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"os"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// Bytes of a message payload stored with its event by default, changed with --payload-cap or payload cap
+const DEFAULT_PAYLOAD_CAP = 4096
+
+// Bytes of the message buffer hashed per read, messages beyond the payload cap may be megabytes long
+const PAYLOAD_HASH_CHUNK = 1 << 20
+
+// Digest of a sent message, the messages sent by a replay are compared to those sent originally
+type sentMessage struct {
+	size int64
+	hash uint64 // FNV-1a hash of the whole message
+}
+
+// Reads the message a send operation is about to send from the buffer, count and datatype arguments of the wrapper.
+// Up to the payload cap the message is recorded with the event, the rest only by its hash
+func capturePayload(ctx *processContext, record *rpc.MPICallRecord) *sentMessage {
+	buffer, bufferOk := getVariableFromMemory(ctx, "buf", true).(int64)
+	count, countOk := getVariableFromMemory(ctx, "count", true).(int32)
+
+	if !bufferOk || !countOk || buffer == 0 {
+		logger.Debug("cannot locate the payload of %v", record.OpName)
+		return nil
+	}
+
+	datatype, elementSize := payloadDatatype(ctx)
+	if len(datatype) == 0 {
+		logger.Debug("unknown datatype of the payload of %v", record.OpName)
+		return nil
+	}
+
+	size := int64(count) * int64(elementSize)
+
+	record.Parameters["datatype"] = datatype
+	record.Parameters["count"] = fmt.Sprint(count)
+	record.PayloadSize = size
+
+	recorded := size
+	if size > ctx.payloadCap {
+		recorded = ctx.payloadCap - ctx.payloadCap%int64(elementSize)
+		logger.Verbose("payload of %v truncated to %d of %d bytes", record.OpName, recorded, size)
+	}
+
+	record.Payload = peekDataFromMemory(ctx, uint64(buffer), recorded)
+
+	messageHash := fnv.New64a()
+	messageHash.Write(record.Payload)
+
+	if recorded < size {
+		restHash := fnv.New64a()
+
+		err := hashMemory(ctx, uint64(buffer)+uint64(recorded), size-recorded, messageHash, restHash)
+		if err != nil {
+			logger.Debug("cannot hash the payload of %v: %v", record.OpName, err)
+			return nil
+		}
+
+		record.PayloadRestHash = restHash.Sum64()
+	}
+
+	return &sentMessage{size: size, hash: messageHash.Sum64()}
+}
+
+// Feeds a range of the target's memory to the hashes, in chunks read from its memory file
+func hashMemory(ctx *processContext, address uint64, length int64, hashes ...hash.Hash64) error {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/mem", ctx.pid))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	chunk := make([]byte, PAYLOAD_HASH_CHUNK)
+
+	for offset := int64(0); offset < length; offset += int64(len(chunk)) {
+		if length-offset < int64(len(chunk)) {
+			chunk = chunk[:length-offset]
+		}
+
+		_, err = file.ReadAt(chunk, int64(address)+offset)
+		if err != nil {
+			return err
+		}
+
+		for _, h := range hashes {
+			h.Write(chunk)
+		}
+	}
+
+	return nil
+}
+
+// Warns of a replayed send sending another message than originally
+func reportSendDivergence(original cPoint, replayed *sentMessage) {
+	if original.sent == nil || replayed == nil || *original.sent == *replayed {
+		return
+	}
+
+	if original.sent.size != replayed.size {
+		logger.Warn("replay diverged: send at %v sends %d bytes, originally %d", original.id, replayed.size, original.sent.size)
+		return
+	}
+
+	logger.Warn("replay diverged: send at %v sends a message of other contents than originally", original.id)
+}
+
+// Shows the payload cap (empty argument) or changes it (payload cap <n>[K|M|G])
+func setPayloadCap(ctx *processContext, argument string) error {
+	argument = strings.TrimSpace(argument)
+
+	if len(argument) > 0 {
+		payloadCap, err := utils.ParseByteSize(argument)
+		if err != nil {
+			return err
+		}
+		ctx.payloadCap = int64(payloadCap)
+	}
+
+	logger.Info("sent messages are recorded up to %d bytes, beyond that by their hash", ctx.payloadCap)
+	return nil
+}
+
+// Finds the datatype argument among the predefined datatypes recorded by the wrapper, returning its name and size
+func payloadDatatype(ctx *processContext) (name string, size int32) {
+	return predefinedDatatype(ctx, getVariableFromMemory(ctx, "datatype", true))
+}
+
+// Finds a datatype handle among the predefined datatypes recorded by the wrapper, returning its name and size
+func predefinedDatatype(ctx *processContext, datatype interface{}) (name string, size int32) {
+	if datatype == nil {
+		return "", 0
+	}
+
+	for index, datatypeName := range mpi.PAYLOAD_DATATYPES {
+		if getVariableFromMemory(ctx, fmt.Sprintf("_MPI_WRAPPER_DATATYPES[%d]", index), true) != datatype {
+			continue
+		}
+
+		size, ok := getVariableFromMemory(ctx, fmt.Sprintf("_MPI_WRAPPER_DATATYPE_SIZES[%d]", index), true).(int32)
+		if !ok || size <= 0 {
+			return "", 0
+		}
+
+		return datatypeName, size
+	}
+
+	return "", 0
+}
@@ -0,0 +1,141 @@
+package perf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	perfTypeHardware        = 0 // PERF_TYPE_HARDWARE
+	perfCountHwInstructions = 1 // PERF_COUNT_HW_INSTRUCTIONS
+
+	flagInherit       = 1 << 1 // count threads created after opening the counter
+	flagExcludeKernel = 1 << 5
+	flagExcludeHv     = 1 << 6
+
+	fOwnerTid = 0 // F_OWNER_TID
+)
+
+// struct perf_event_attr (PERF_ATTR_SIZE_VER1)
+type perfEventAttr struct {
+	Type         uint32
+	Size         uint32
+	Config       uint64
+	SamplePeriod uint64
+	SampleType   uint64
+	ReadFormat   uint64
+	Flags        uint64
+	WakeupEvents uint32
+	BpType       uint32
+	Config1      uint64
+	Config2      uint64
+}
+
+// Counts the user space instructions retired by a process
+type InstructionCounter struct {
+	fd int
+}
+
+// Opens a hardware instruction counter for the process.
+// Fails when the hardware counters are unavailable (virtual machines, containers)
+// or restricted by kernel.perf_event_paranoid
+func OpenInstructionCounter(pid int) (*InstructionCounter, error) {
+	attr := perfEventAttr{
+		Type:   perfTypeHardware,
+		Config: perfCountHwInstructions,
+		Flags:  flagInherit | flagExcludeKernel | flagExcludeHv,
+	}
+	attr.Size = uint32(unsafe.Sizeof(attr))
+
+	fd, _, errno := syscall.Syscall6(
+		syscall.SYS_PERF_EVENT_OPEN,
+		uintptr(unsafe.Pointer(&attr)),
+		uintptr(pid),
+		^uintptr(0), // any cpu
+		^uintptr(0), // no group
+		0,
+		0,
+	)
+
+	if errno != 0 {
+		return nil, fmt.Errorf("perf_event_open: %v", errno)
+	}
+
+	return &InstructionCounter{int(fd)}, nil
+}
+
+// Opens a counter that interrupts the thread with the signal once it has retired the given number
+// of user space instructions. The signal arrives with a delay (skid) of a few instructions
+func OpenInstructionInterrupt(tid int, count uint64, signal syscall.Signal) (*InstructionCounter, error) {
+	attr := perfEventAttr{
+		Type:         perfTypeHardware,
+		Config:       perfCountHwInstructions,
+		SamplePeriod: count,
+		Flags:        flagExcludeKernel | flagExcludeHv,
+		WakeupEvents: 1,
+	}
+	attr.Size = uint32(unsafe.Sizeof(attr))
+
+	fd, _, errno := syscall.Syscall6(
+		syscall.SYS_PERF_EVENT_OPEN,
+		uintptr(unsafe.Pointer(&attr)),
+		uintptr(tid),
+		^uintptr(0), // any cpu
+		^uintptr(0), // no group
+		0,
+		0,
+	)
+
+	if errno != 0 {
+		return nil, fmt.Errorf("perf_event_open: %v", errno)
+	}
+
+	counter := &InstructionCounter{int(fd)}
+
+	// deliver the overflow notification as a signal to the counted thread
+	owner := struct {
+		kind int32
+		pid  int32
+	}{fOwnerTid, int32(tid)}
+
+	err := fcntl(counter.fd, syscall.F_SETOWN_EX, uintptr(unsafe.Pointer(&owner)))
+	if err == nil {
+		err = fcntl(counter.fd, syscall.F_SETSIG, uintptr(signal))
+	}
+	if err == nil {
+		err = fcntl(counter.fd, syscall.F_SETFL, syscall.O_ASYNC)
+	}
+
+	if err != nil {
+		counter.Close()
+		return nil, err
+	}
+
+	return counter, nil
+}
+
+func fcntl(fd int, cmd int, arg uintptr) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), uintptr(cmd), arg)
+	if errno != 0 {
+		return fmt.Errorf("fcntl: %v", errno)
+	}
+	return nil
+}
+
+// Returns the number of instructions retired since the counter was opened
+func (c *InstructionCounter) Read() (uint64, error) {
+	value := make([]byte, 8)
+
+	_, err := syscall.Read(c.fd, value)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(value), nil
+}
+
+func (c *InstructionCounter) Close() {
+	syscall.Close(c.fd)
+}
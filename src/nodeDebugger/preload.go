@@ -0,0 +1,128 @@
+package main
+
+import (
+	"debug/elf"
+	"fmt"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/proc"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// Shared library intercepting the MPI calls of targets not compiled with the wrapper,
+// built from compiler/mpi_wrap_include/debug_mpi_preload.c next to the node-debugger binary
+const MPI_PRELOAD_LIBRARY = "libmpiwrap_preload.so"
+
+// Returns the path of the preload library for a target not compiled with the MPI wrapper, empty otherwise.
+// Launcher scripts get the library as well, as the binary they execute is not known yet
+func mpiPreloadLibrary(targetFile string) string {
+	if isElfFile(targetFile) && hasMPIWrapper(targetFile) {
+		return ""
+	}
+
+	library, err := filepath.EvalSymlinks(filepath.Join(utils.GetExecutableDir(), MPI_PRELOAD_LIBRARY))
+	if err != nil {
+		logger.Warn("the preload library %v is not available (%v), MPI calls of targets not compiled with the wrapper are not recorded", MPI_PRELOAD_LIBRARY, err)
+		return ""
+	}
+
+	return library
+}
+
+// Returns whether the binary was compiled with the MPI wrapper
+func hasMPIWrapper(binaryFile string) bool {
+	elfFile, err := elf.Open(binaryFile)
+	if err != nil {
+		return false
+	}
+	defer elfFile.Close()
+
+	symbols, err := elfFile.Symbols()
+	if err != nil {
+		return false
+	}
+
+	for _, symbol := range symbols {
+		if symbol.Name == dwarf.MPI_SIGNATURE_FUNCTION {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Adds the debug info of the preload library, once the dynamic loader has mapped it into the target
+func loadPreloadedLibrary(ctx *processContext, library string) {
+	runToEntryPoint(ctx)
+
+	libraryData, _, err := dwarf.LoadDwarfData(library)
+	if err != nil {
+		logger.Warn("cannot load debug info of %v, MPI calls are not recorded: %v", library, err)
+		return
+	}
+
+	relocateToLoadAddress(ctx, library, libraryData)
+
+	ctx.dwarfData.AddLibrary(libraryData)
+
+	logger.Verbose("intercepting MPI calls with the preloaded %v", library)
+}
+
+// Shifts the debug info of a position-independent binary (or a shared library) to where it is mapped in the target
+func relocateToLoadAddress(ctx *processContext, binaryFile string, data *dwarf.DwarfData) {
+	linkAddress, positionIndependent, err := dwarf.LinkAddress(binaryFile)
+	utils.Must(err)
+
+	if !positionIndependent {
+		return
+	}
+
+	loadAddress, err := proc.GetMappedAddress(ctx.pid, binaryFile)
+	utils.Must(err)
+
+	logger.Debug("%v loaded at %#x", filepath.Base(binaryFile), loadAddress)
+
+	data.Relocate(loadAddress - linkAddress)
+}
+
+// Runs the target from its start to the entry point of the executed binary.
+// By then, the dynamic loader has mapped the preloaded and the linked libraries
+func runToEntryPoint(ctx *processContext) {
+	var waitStatus syscall.WaitStatus
+
+	entryPoint, err := proc.GetEntryPoint(ctx.pid)
+	utils.Must(err)
+
+	originalInstruction := insertBreakpoint(ctx, entryPoint)
+
+	var signal int // signal to deliver on resume
+
+	for {
+		err = syscall.PtraceCont(ctx.pid, signal)
+		utils.Must(err)
+
+		_, err = syscall.Wait4(ctx.pid, &waitStatus, 0, nil)
+		utils.Must(err)
+
+		if waitStatus.Exited() {
+			panic(fmt.Sprintf("target exited (code %d) before reaching its entry point", waitStatus.ExitStatus()))
+		}
+
+		if waitStatus.StopSignal() == syscall.SIGTRAP {
+			break
+		}
+
+		signal = int(waitStatus.StopSignal())
+	}
+
+	_, err = syscall.PtracePokeData(ctx.pid, uintptr(entryPoint), originalInstruction)
+	utils.Must(err)
+
+	regs := getRegs(ctx, true)
+
+	err = syscall.PtraceSetRegs(ctx.pid, regs)
+	utils.Must(err)
+}
@@ -0,0 +1,29 @@
+package proc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// AT_ENTRY, the entry point of the executed binary in the auxiliary vector
+const auxvEntryPoint = 9
+
+// Reads the entry point of the binary executed by the process from /proc/<pid>/auxv
+func GetEntryPoint(pid int) (uint64, error) {
+	auxv, err := os.ReadFile(fmt.Sprintf("/proc/%d/auxv", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	// pairs of key and value, each of the native word size
+	for offset := 0; offset+16 <= len(auxv); offset += 16 {
+		key := binary.LittleEndian.Uint64(auxv[offset:])
+
+		if key == auxvEntryPoint {
+			return binary.LittleEndian.Uint64(auxv[offset+8:]), nil
+		}
+	}
+
+	return 0, fmt.Errorf("no entry point in the auxiliary vector of process %d", pid)
+}
@@ -24,6 +24,7 @@ func GetFileCheckpointDataAddresses(pid int, sourceFile string) []MemRegion {
 func GetForkCheckpointDataAddresses(pid int, sourceFile string) []MemRegion {
 	idents := []string{
 		"[heap]",
+		"[stack]",
 		sourceFile,
 	}
 
@@ -75,6 +76,33 @@ func GetDataAddressesByIdents(pid int, identifiers []string) []MemRegion {
 	return regions
 }
 
+// Returns all mapped memory regions of the process
+func GetMemoryLayout(pid int) []MemRegion {
+	regions := make([]MemRegion, 0)
+
+	for _, mmap := range readMapsFile(pid) {
+		bounds := strings.Split(mmap[0], "-")
+
+		start, _ := strconv.ParseUint(bounds[0], 16, 64)
+		end, _ := strconv.ParseUint(bounds[1], 16, 64)
+
+		// anonymous mappings have no pathname
+		ident := ""
+		if len(mmap) > 5 {
+			ident = mmap[5]
+		}
+
+		regions = append(regions, MemRegion{
+			start,
+			end,
+			ident,
+			nil,
+		})
+	}
+
+	return regions
+}
+
 func readMapsFile(pid int) [][]string {
 	regions := make([][]string, 0)
 
@@ -105,3 +133,15 @@ func LogMapsFile(pid int) {
 		logger.Debug("%v", region)
 	}
 }
+
+// Returns the address the file is mapped at in the process, the start of its lowest mapping
+func GetMappedAddress(pid int, file string) (uint64, error) {
+	for _, region := range GetMemoryLayout(pid) {
+		if region.Ident == file {
+			// regions are listed in the order of their addresses
+			return region.Start, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%v is not mapped in process %d", file, pid)
+}
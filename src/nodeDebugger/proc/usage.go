@@ -0,0 +1,107 @@
+package proc
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// USER_HZ, the unit of the cpu times in /proc/<pid>/stat
+const clockTicksPerSecond = 100
+
+// Resource usage of a process, read from /proc
+type ResourceUsage struct {
+	UserTime   time.Duration // cpu time spent in user mode
+	SystemTime time.Duration // cpu time spent in kernel mode
+	RSS        uint64        // resident set size in bytes
+	Swap       uint64        // swapped out memory in bytes
+	ReadBytes  uint64        // bytes fetched from the storage layer
+	WriteBytes uint64        // bytes sent to the storage layer
+}
+
+func GetResourceUsage(pid int) (usage ResourceUsage, err error) {
+	usage.UserTime, usage.SystemTime, err = readCPUTimes(pid)
+	if err != nil {
+		return usage, err
+	}
+
+	status, err := readKeyValueFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return usage, err
+	}
+
+	// memory sizes in the status file are given in kB
+	usage.RSS = parseLeadingUint(status["VmRSS"]) * 1024
+	usage.Swap = parseLeadingUint(status["VmSwap"]) * 1024
+
+	io, err := readKeyValueFile(fmt.Sprintf("/proc/%d/io", pid))
+	if err != nil {
+		return usage, err
+	}
+
+	usage.ReadBytes = parseLeadingUint(io["read_bytes"])
+	usage.WriteBytes = parseLeadingUint(io["write_bytes"])
+
+	return usage, nil
+}
+
+func readCPUTimes(pid int) (userTime time.Duration, systemTime time.Duration, err error) {
+	contents, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// the command name in parentheses may contain spaces, fields are counted from its end
+	stat := string(contents)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+
+	// utime and stime are the 14th and 15th fields, the first of the remaining ones being the 3rd
+	if len(fields) < 13 {
+		return 0, 0, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+
+	userTicks, _ := strconv.ParseUint(fields[11], 10, 64)
+	systemTicks, _ := strconv.ParseUint(fields[12], 10, 64)
+
+	return ticksToDuration(userTicks), ticksToDuration(systemTicks), nil
+}
+
+func ticksToDuration(ticks uint64) time.Duration {
+	return time.Duration(ticks) * time.Second / clockTicksPerSecond
+}
+
+// Reads a file of "key: value" lines
+func readKeyValueFile(path string) (map[string]string, error) {
+	source, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer source.Close()
+
+	values := make(map[string]string)
+
+	scanner := bufio.NewScanner(source)
+
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if found {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return values, scanner.Err()
+}
+
+func parseLeadingUint(value string) uint64 {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+
+	number, _ := strconv.ParseUint(fields[0], 10, 64)
+	return number
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// environment variables the launchers of the supported MPI implementations give the rank of a process in
+var launcherRankVariables = []string{"OMPI_COMM_WORLD_RANK", "PMIX_RANK", "PMI_RANK", "MV2_COMM_WORLD_RANK", "SLURM_PROCID"}
+
+// Returns the MPI rank the launcher started the node as, for the orchestrator to number the node by.
+// -1 if the launcher is not known, the node is then renumbered once its target reports its rank after MPI_Init
+func launcherRank() int {
+	for _, variable := range launcherRankVariables {
+		rank, err := strconv.Atoi(os.Getenv(variable))
+		if err == nil && rank >= 0 {
+			return rank
+		}
+	}
+
+	return -1
+}
+
+// Tells the node its new id, as the orchestrator numbers the nodes by the MPI ranks of their targets. Only the
+// log lines of the node show it, the node keeps its port and reports by the id it registered with, which the
+// orchestrator translates: reports already on their way do not name the wrong node
+func (r RemoteCmdHandler) Renumber(_ context.Context, message *pb.NodeId) (*emptypb.Empty, error) {
+	nodeId := int(message.Id)
+
+	r.ctx.nodeData.currentId = nodeId
+	logger.SetRemoteClient(r.ctx.nodeData.rpcClient.Logger(), nodeId)
+	logger.Verbose("renumbered to node %d, the rank of the target", nodeId)
+
+	return &emptypb.Empty{}, nil
+}
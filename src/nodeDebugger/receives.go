@@ -0,0 +1,96 @@
+package main
+
+import (
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// The message a blocking receive completed with, recorded by the wrapper (_MPI_WRAPPER_RECORD_RECEIVE)
+type receivedMessage struct {
+	source int
+	tag    int
+	hash   uint64 // FNV-1a hash of the contents
+}
+
+// Records the message the pending receive completed with, read from the wrapper at the MPI call following it.
+// During a replay, the message is compared to the one received originally
+func recordReceivedMessage(ctx *processContext) {
+	checkpointId := ctx.pendingReceive
+	if len(checkpointId) == 0 {
+		return
+	}
+
+	ctx.pendingReceive = ""
+
+	source, sourceOk := getVariableFromMemory(ctx, "_MPI_WRAPPER_RECEIVED_SOURCE", true).(int32)
+	tag, tagOk := getVariableFromMemory(ctx, "_MPI_WRAPPER_RECEIVED_TAG", true).(int32)
+	hash, hashOk := getVariableFromMemory(ctx, "_MPI_WRAPPER_RECEIVED_HASH", true).(int64)
+
+	// wrappers compiled before receives were recorded
+	if !sourceOk || !tagOk || !hashOk {
+		return
+	}
+
+	received := &receivedMessage{source: int(source), tag: int(tag), hash: uint64(hash)}
+
+	for index := range ctx.cpointData {
+		if ctx.cpointData[index].id != checkpointId {
+			continue
+		}
+
+		ctx.cpointData[index].received = received
+
+		if index < len(ctx.replayedCheckpoints) {
+			reportReceiveDivergence(ctx.replayedCheckpoints[index], *received)
+		}
+	}
+
+	logger.Debug("receive at %v completed with the message of rank %d (tag %d, hash %x)", checkpointId, source, tag, uint64(hash))
+}
+
+// Marks a receive the target is stopped at, its message is recorded at the next MPI call
+func awaitReceivedMessage(ctx *processContext, checkpointId string, opName string) {
+	if opName == mpi.MPI_OPS[mpi.OP_RECV] {
+		ctx.pendingReceive = checkpointId
+	}
+}
+
+// Forces the receive the target is stopped at to complete with the message of the original execution,
+// by rewriting its source and tag arguments to those of the recorded message. Only in deterministic replay
+func forceRecordedMessage(ctx *processContext, original cPoint) {
+	if !ctx.options.deterministicReplay || original.received == nil || original.opName != mpi.MPI_OPS[mpi.OP_RECV] {
+		return
+	}
+
+	updateStack(ctx)
+
+	err := setIntVariable(ctx, "source", original.received.source)
+	if err == nil {
+		err = setIntVariable(ctx, "tag", original.received.tag)
+	}
+
+	if err != nil {
+		logger.Warn("cannot replay the receive at %v with the recorded message: %v", original.id, err)
+		return
+	}
+
+	logger.Verbose("receive at %v replays with the recorded message of rank %d (tag %d)", original.id, original.received.source, original.received.tag)
+}
+
+// Warns of a replayed receive completing with another message than originally
+func reportReceiveDivergence(original cPoint, replayed receivedMessage) {
+	if original.received == nil || *original.received == replayed {
+		return
+	}
+
+	recorded := *original.received
+
+	switch {
+	case recorded.source != replayed.source || recorded.tag != replayed.tag:
+		logger.Warn("replay diverged: receive at %v got the message of rank %d (tag %d), originally of rank %d (tag %d)",
+			original.id, replayed.source, replayed.tag, recorded.source, recorded.tag)
+	default:
+		logger.Warn("replay diverged: receive at %v got a message of other contents from rank %d (tag %d)",
+			original.id, replayed.source, replayed.tag)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"google.golang.org/grpc"
+)
+
+// time a node keeps redialing the orchestrator for once the connection drops, long enough for the orchestrator
+// to be restarted with --rejoin. The calls to the orchestrator wait meanwhile, and fail after it
+const RECONNECT_TIMEOUT = 2 * time.Minute
+
+// Makes the node reconnect to the orchestrator when the connection drops, and resynchronize the orchestrator with
+// its state before the reports that failed are sent again: its checkpoints, breakpoints and whether it is running
+func reconnectOnFailure(ctx *processContext) {
+	ctx.nodeData.rpcClient.ReconnectOnFailure(RECONNECT_TIMEOUT, func(connection grpc.ClientConnInterface) error {
+		return resync(ctx, pb.NewNodeReporterClient(connection))
+	})
+}
+
+// The resync handshake, made on the new connection. The orchestrator answers with the checkpoints it has no
+// records of, which are reported again in their order; an orchestrator that did not know the node at all,
+// restarted with --rejoin, gets the reports made at registration again too
+func resync(ctx *processContext, reporter pb.NodeReporterClient) error {
+	resync := rpc.NodeResync{
+		Registration: rpc.NodeRegistration{Pid: os.Getpid(), Rank: launcherRank(), Host: ctx.nodeData.host},
+		RegisteredId: ctx.nodeData.id,
+		NodeId:       ctx.nodeData.currentId,
+		Breakpoints:  breakpointLocations(ctx),
+		Detached:     ctx.detachedBreakpoints != nil,
+	}
+
+	ctx.interrupt.Lock()
+	resync.Running = ctx.interrupt.running
+	ctx.interrupt.Unlock()
+
+	records := make(map[string]*rpc.MPICallRecord)
+	for _, checkpoint := range ctx.cpointData {
+		resync.Checkpoints = append(resync.Checkpoints, checkpoint.id)
+		records[checkpoint.id] = checkpoint.record
+	}
+
+	message, err := reporter.Resync(context.Background(), resync.Proto())
+	if err != nil {
+		return err
+	}
+	reply := rpc.ResyncReplyOf(message)
+
+	// made once the debug info is loaded, if the node was still starting up
+	if reply.Rejoined && ctx.dwarfData != nil {
+		layout := memoryLayoutRecord(ctx)
+		capabilities := getCapabilities(ctx)
+		fingerprint := getFingerprint(ctx)
+
+		_, err = reporter.MemoryLayout(context.Background(), layout.Proto())
+		if err == nil {
+			_, err = reporter.Capabilities(context.Background(), capabilities.Proto())
+		}
+		if err == nil {
+			_, err = reporter.Fingerprint(context.Background(), fingerprint.Proto())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, checkpointId := range reply.MissingCheckpoints {
+		record := records[checkpointId]
+		if record == nil {
+			continue
+		}
+
+		_, err = reporter.MPICall(context.Background(), record.Proto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the source locations of the breakpoints set by the user, including those lifted while detached
+func breakpointLocations(ctx *processContext) []string {
+	if ctx.dwarfData == nil {
+		return nil
+	}
+
+	breakpoints := userBreakpoints(ctx)
+	for address, bpoint := range ctx.detachedBreakpoints {
+		breakpoints[address] = bpoint
+	}
+
+	locations := make([]string, 0, len(breakpoints))
+	for address, bpoint := range breakpoints {
+		if bpoint.causalReceive {
+			continue
+		}
+
+		line, file, _, err := ctx.dwarfData.PCToLine(address)
+		if err != nil {
+			locations = append(locations, fmt.Sprintf("%#x", address))
+		} else {
+			locations = append(locations, fmt.Sprintf("%s:%d", file, line))
+		}
+	}
+
+	sort.Strings(locations)
+	return locations
+}
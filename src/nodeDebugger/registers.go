@@ -1,8 +1,8 @@
 package main
 
 import (
-	"fmt"
 	"reflect"
+	"strings"
 	"syscall"
 
 	"github.com/ottmartens/cc-rev-db/logger"
@@ -36,7 +36,8 @@ func getRegs(ctx *processContext, rewindIP bool) *syscall.PtraceRegs {
 	return &regs
 }
 
-func printRegs(ctx *processContext) {
+// Prints the general purpose registers, followed by the floating-point control and status registers
+func printRegs(ctx *processContext) error {
 	regs := getRegs(ctx, false)
 
 	s := reflect.ValueOf(regs).Elem()
@@ -44,6 +45,8 @@ func printRegs(ctx *processContext) {
 
 	for i := 0; i < s.NumField(); i++ {
 		f := s.Field(i)
-		fmt.Printf(" %s = %#x\n", typeOfT.Field(i).Name, f.Interface())
+		logger.Info(" %s = %#x", strings.ToLower(typeOfT.Field(i).Name), f.Interface())
 	}
+
+	return printFPControl(ctx)
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// Forces the wildcard receive recorded at a checkpoint to take its message from the specified rank when the
+// checkpoint is next restored, for replaying the messages in another order. Argument: <checkpoint id> <source rank>
+func forceReceiveSource(ctx *processContext, argument string) error {
+	fields := strings.Fields(argument)
+	if len(fields) != 2 {
+		return fmt.Errorf("expected <checkpoint id> <source rank>, got %q", argument)
+	}
+
+	checkpointId := fields[0]
+
+	source, err := strconv.Atoi(fields[1])
+	if err != nil || source < 0 {
+		return fmt.Errorf("invalid source rank %q", fields[1])
+	}
+
+	for _, checkpoint := range ctx.cpointData {
+		if checkpoint.id == checkpointId {
+			ctx.forcedSources[checkpointId] = source
+			logger.Verbose("receive at %v takes its message from rank %d on restore", checkpoint, source)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("checkpoint with id %v not found", checkpointId)
+}
+
+// Rewrites the source argument of the receive the target was restored to, if a source was forced on it.
+// The target is stopped in the wrapper before the receive is executed, so the new source takes effect.
+// Returns whether a source was forced
+func applyForcedSource(ctx *processContext, checkpointId string) bool {
+	source, forced := ctx.forcedSources[checkpointId]
+	if !forced {
+		return false
+	}
+
+	// a forced source applies to one replay only
+	delete(ctx.forcedSources, checkpointId)
+
+	updateStack(ctx)
+
+	err := setIntVariable(ctx, "source", source)
+	if err != nil {
+		logger.Warn("cannot force the source of the receive at %v: %v", checkpointId, err)
+		return true
+	}
+
+	logger.Info("receive at %v replays with the message from rank %d", checkpointId, source)
+
+	return true
+}
+
+// Overwrites an int variable in the scope of the MPI wrapper the target is stopped in: an argument or a global
+func setIntVariable(ctx *processContext, identifier string, value int) error {
+	variable, address, err := locateVariable(ctx, identifier, true)
+	if err != nil {
+		return err
+	}
+
+	if variable.ByteSize() != 4 {
+		return fmt.Errorf("%v is not an int", identifier)
+	}
+
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, uint32(int32(value)))
+
+	_, err = syscall.PtracePokeData(ctx.pid, uintptr(address), data)
+	return err
+}
@@ -1,25 +1,36 @@
 package main
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/proc"
 	"github.com/ottmartens/cc-rev-db/rpc"
 	"github.com/ottmartens/cc-rev-db/utils/command"
 )
 
 func reportAsHealthy(ctx *processContext) (nodeId int) {
-	err := ctx.nodeData.rpcClient.Call("NodeReporter.Register", os.Getpid(), &nodeId)
+	registration := rpc.NodeRegistration{Pid: os.Getpid(), Rank: launcherRank(), Host: ctx.nodeData.host}
+
+	reply, err := ctx.nodeData.rpcClient.NodeReporter().Register(context.Background(), registration.Proto())
 	if err != nil {
 		logger.Error("Failed to report self as healthy: %v", err)
 		panic(err)
 	}
 
-	return nodeId
+	return int(reply.Id)
 }
 
 func reportCommandResult(ctx *processContext, cmd *command.Command) {
-	err := ctx.nodeData.rpcClient.Call("NodeReporter.CommandResult", cmd, new(int))
+	// reported by the id the node registered with, the orchestrator knows the node by it if it was renumbered
+	cmd.NodeId = ctx.nodeData.id
+
+	message, err := rpc.CommandProto(cmd)
+	if err == nil {
+		_, err = ctx.nodeData.rpcClient.NodeReporter().CommandResult(context.Background(), message)
+	}
 	if err != nil {
 		logger.Error("Failed to report command result: %v", err)
 		panic(err)
@@ -27,7 +38,12 @@ func reportCommandResult(ctx *processContext, cmd *command.Command) {
 }
 
 func reportProgressCommand(ctx *processContext, cmd *command.Command) {
-	err := ctx.nodeData.rpcClient.Call("NodeReporter.Progress", cmd, new(int))
+	cmd.NodeId = ctx.nodeData.id
+
+	message, err := rpc.CommandProto(cmd)
+	if err == nil {
+		_, err = ctx.nodeData.rpcClient.NodeReporter().Progress(context.Background(), message)
+	}
 	if err != nil {
 		logger.Error("Failed to report progresss command execution: %v", err)
 		panic(err)
@@ -35,9 +51,97 @@ func reportProgressCommand(ctx *processContext, cmd *command.Command) {
 }
 
 func reportMPICall(ctx *processContext, record *rpc.MPICallRecord) {
-	err := ctx.nodeData.rpcClient.Call("NodeReporter.MPICall", record, new(int))
+	// kept with the checkpoint, for an orchestrator the record did not reach
+	for index := len(ctx.cpointData) - 1; index >= 0; index-- {
+		if ctx.cpointData[index].id == record.Id {
+			reported := *record
+			ctx.cpointData[index].record = &reported
+			break
+		}
+	}
+
+	_, err := ctx.nodeData.rpcClient.NodeReporter().MPICall(context.Background(), record.Proto())
 	if err != nil {
 		logger.Error("Failed to report MPI call: %v", err)
 		panic(err)
 	}
 }
+
+func reportMemoryLayout(ctx *processContext) {
+	record := memoryLayoutRecord(ctx)
+
+	_, err := ctx.nodeData.rpcClient.NodeReporter().MemoryLayout(context.Background(), record.Proto())
+	if err != nil {
+		logger.Error("Failed to report memory layout: %v", err)
+		panic(err)
+	}
+}
+
+func memoryLayoutRecord(ctx *processContext) rpc.MemoryLayoutRecord {
+	record := rpc.MemoryLayoutRecord{
+		NodeId:       ctx.nodeData.id,
+		AslrDisabled: ctx.options.disableASLR,
+		Regions:      make([]rpc.MemoryRegion, 0),
+	}
+
+	for _, region := range proc.GetMemoryLayout(ctx.pid) {
+		record.Regions = append(record.Regions, rpc.MemoryRegion{
+			Start: region.Start,
+			End:   region.End,
+			Ident: region.Ident,
+		})
+	}
+
+	return record
+}
+
+func reportCapabilities(ctx *processContext) {
+	capabilities := getCapabilities(ctx)
+
+	_, err := ctx.nodeData.rpcClient.NodeReporter().Capabilities(context.Background(), capabilities.Proto())
+	if err != nil {
+		logger.Error("Failed to report capabilities: %v", err)
+		panic(err)
+	}
+}
+
+func reportFingerprint(ctx *processContext) {
+	fingerprint := getFingerprint(ctx)
+
+	_, err := ctx.nodeData.rpcClient.NodeReporter().Fingerprint(context.Background(), fingerprint.Proto())
+	if err != nil {
+		logger.Error("Failed to report fingerprint: %v", err)
+		panic(err)
+	}
+}
+
+// Periodically reports the cpu time, memory and i/o usage of the target, until the target exits.
+// Runs in its own goroutine, as /proc can be read from any thread
+func reportResourceUsage(ctx *processContext) {
+	ticker := time.NewTicker(RESOURCE_USAGE_REPORT_INTERVAL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		usage, err := proc.GetResourceUsage(ctx.pid)
+		if err != nil {
+			logger.Debug("stopped reporting resource usage: %v", err)
+			return
+		}
+
+		record := rpc.ResourceUsageRecord{
+			NodeId:     ctx.nodeData.id,
+			Timestamp:  time.Now(),
+			CPUTime:    usage.UserTime + usage.SystemTime,
+			RSS:        usage.RSS,
+			Swap:       usage.Swap,
+			ReadBytes:  usage.ReadBytes,
+			WriteBytes: usage.WriteBytes,
+		}
+
+		_, err = ctx.nodeData.rpcClient.NodeReporter().ResourceUsage(context.Background(), record.Proto())
+		if err != nil {
+			logger.Error("Failed to report resource usage: %v", err)
+			return
+		}
+	}
+}
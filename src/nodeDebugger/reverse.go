@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// Moves the target back to the last user breakpoint hit before the current position, within the interval since its
+// last checkpoint. The target is restored to the checkpoint and re-executed up to the current instruction to find
+// the breakpoints it hits, then restored again and re-executed up to the last of them. Breakpoints stay armed during
+// the re-executions, a breakpoint within a loop stops the target at its last iteration before the current position.
+// Without a breakpoint hit since the checkpoint, the target is left at the checkpoint
+func reverseContinue(ctx *processContext) error {
+	if ctx.instructionCounter == nil {
+		return fmt.Errorf("reverse-continue requires instruction counting, which is unavailable")
+	}
+
+	if len(ctx.cpointData) == 0 {
+		return fmt.Errorf("no checkpoint recorded to continue back to")
+	}
+
+	current := getInstructionCount(ctx)
+	checkpoint := ctx.cpointData[len(ctx.cpointData)-1]
+
+	// continuing past the checkpoint would undo an mpi operation, which requires a rollback instead
+	if current == checkpoint.instructionCount {
+		return fmt.Errorf("target is at its last checkpoint (%v), roll back to an earlier one to continue backwards", checkpoint.opName)
+	}
+
+	breakpoints := userBreakpoints(ctx)
+
+	err := restoreForReverseExecution(ctx, checkpoint, breakpoints)
+	if err != nil {
+		return err
+	}
+
+	// the breakpoint hits up to the current instruction, the last one whose condition holds is stopped at
+	hits, last := 0, 0
+
+	for {
+		bpoint, err := runToBreakpointBefore(ctx, current)
+		if err != nil {
+			return err
+		}
+		if bpoint == nil {
+			break
+		}
+
+		hits++
+		if breakpointConditionHolds(ctx, bpoint) {
+			last = hits
+		}
+
+		stepOverBreakpoint(ctx, bpoint)
+	}
+
+	err = restoreForReverseExecution(ctx, checkpoint, breakpoints)
+	if err != nil {
+		return err
+	}
+
+	if last == 0 {
+		logger.Info("no breakpoint hit since the last checkpoint, stopped at the checkpoint %v", checkpoint)
+		return nil
+	}
+
+	// the re-execution is deterministic, the target hits the same breakpoints again
+	for hit := 1; ; hit++ {
+		bpoint, err := runToBreakpointBefore(ctx, current)
+		if err != nil {
+			return err
+		}
+		if bpoint == nil {
+			return fmt.Errorf("replay diverged, breakpoint hit %d of %d not reached again", hit, last)
+		}
+
+		if hit < last {
+			stepOverBreakpoint(ctx, bpoint)
+			continue
+		}
+
+		// stopped at the breakpoint as if continuing forward had hit it
+		delete(ctx.bpointData, bpoint.address)
+		ctx.caughtBreakpoint = bpoint
+		liftBreakpoint(ctx, bpoint)
+
+		line, file, _, _ := ctx.dwarfData.PCToLine(bpoint.address)
+		logger.Info("Caught at a breakpoint: line: %d, file: %v", line, filepath.Base(file))
+
+		return nil
+	}
+}
+
+// Returns the user breakpoints of the target, including the one it is stopped at
+func userBreakpoints(ctx *processContext) breakpointData {
+	breakpoints := make(breakpointData)
+
+	for address, bpoint := range ctx.bpointData {
+		if !bpoint.isMPIBpoint {
+			breakpoints[address] = bpoint
+		}
+	}
+
+	if ctx.caughtBreakpoint != nil {
+		breakpoints[ctx.caughtBreakpoint.address] = ctx.caughtBreakpoint
+	}
+
+	if ctx.liftedBreakpoint != nil {
+		breakpoints[ctx.liftedBreakpoint.address] = ctx.liftedBreakpoint
+	}
+
+	return breakpoints
+}
+
+// Restores the latest checkpoint to re-execute the interval since it, with the given breakpoints armed
+// in addition to those armed at the checkpoint
+func restoreForReverseExecution(ctx *processContext, checkpoint cPoint, breakpoints breakpointData) error {
+	err := restoreCheckpoint(ctx, checkpoint.id)
+	if err != nil {
+		return err
+	}
+
+	// the restored checkpoint is the latest, no recorded events are re-executed
+	ctx.replayedCheckpoints = nil
+	ctx.caughtBreakpoint = nil
+
+	for address, bpoint := range breakpoints {
+		if ctx.bpointData[address] != nil {
+			continue
+		}
+
+		copied := *bpoint
+		copied.isImmediateAfterRestore = false
+
+		// breakpoints outside the restored memory are still inserted
+		if getOriginalInstruction(ctx, address)[0] != 0xCC {
+			copied.originalInstruction = insertBreakpoint(ctx, address)
+		}
+
+		ctx.bpointData[address] = &copied
+	}
+
+	return nil
+}
+
+// Runs the target forward until it hits a user breakpoint before the instruction count, or reaches the count.
+// Returns the breakpoint hit, with the target rewound to the breakpoint instruction, or nil if none was hit
+func runToBreakpointBefore(ctx *processContext, target uint64) (*bpointData, error) {
+	if getInstructionCount(ctx) >= target {
+		return nil, nil
+	}
+
+	stoppedAtBreakpoint, err := replayToInstructionCount(ctx, target, true)
+	if err != nil || !stoppedAtBreakpoint {
+		return nil, err
+	}
+
+	regs := getRegs(ctx, true)
+	bpoint := findBreakpointByAddress(ctx, regs.Rip)
+
+	if bpoint.isMPIBpoint {
+		return nil, fmt.Errorf("target reached %v before the current position", bpoint.function.Name())
+	}
+
+	// the breakpoint hit at the current position
+	if getInstructionCount(ctx) >= target {
+		return nil, nil
+	}
+
+	_, err = syscall.PtracePokeData(ctx.pid, uintptr(regs.Rip), bpoint.originalInstruction)
+	utils.Must(err)
+
+	err = syscall.PtraceSetRegs(ctx.pid, regs)
+	utils.Must(err)
+
+	ctx.caughtBreakpoint = bpoint
+	bpoint.hitCount++
+
+	return bpoint, nil
+}
+
+// Executes the instruction of a breakpoint the target is rewound to, then inserts the breakpoint again
+func stepOverBreakpoint(ctx *processContext, bpoint *bpointData) {
+	ctx.caughtBreakpoint = nil
+
+	err := syscall.PtraceSingleStep(ctx.pid)
+	utils.Must(err)
+
+	var waitStatus syscall.WaitStatus
+
+	err = waitForStop(ctx, &waitStatus)
+	utils.Must(err)
+
+	bpoint.originalInstruction = insertBreakpoint(ctx, bpoint.address)
+}
+
+// A transition of the target to another source line, recorded while re-executing the interval since the last checkpoint
+type lineTransition struct {
+	instructionCount uint64 // instructions retired when the target reached the start of the line
+	line             int
+	file             string
+	depth            int // frames on the call stack
+}
+
+// Moves the target back to the start of the previous source line it executed (reverse-step), or with overCalls set,
+// of the previous line it executed in the current function or a caller (reverse-next), within the interval since its
+// last checkpoint. The interval is re-executed instruction by instruction to record the source lines the target passed,
+// then re-executed again up to the start of the line
+func reverseStepLine(ctx *processContext, overCalls bool) error {
+	if ctx.instructionCounter == nil {
+		return fmt.Errorf("reverse stepping requires instruction counting, which is unavailable")
+	}
+
+	if len(ctx.cpointData) == 0 {
+		return fmt.Errorf("no checkpoint recorded to step back from")
+	}
+
+	current := getInstructionCount(ctx)
+	checkpoint := ctx.cpointData[len(ctx.cpointData)-1]
+
+	if current == checkpoint.instructionCount {
+		return fmt.Errorf("target is at its last checkpoint (%v), roll back to an earlier one to step backwards", checkpoint.opName)
+	}
+
+	err := restoreForReverseExecution(ctx, checkpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	transitions, err := recordLineTransitions(ctx, current)
+	if err != nil {
+		return err
+	}
+
+	previous := previousLine(transitions, overCalls)
+	if previous == nil {
+		return fmt.Errorf("no earlier source line executed since the last checkpoint (%v), roll back to an earlier one to step backwards", checkpoint.opName)
+	}
+
+	err = restoreForReverseExecution(ctx, checkpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = replayToInstructionCount(ctx, previous.instructionCount, false)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("stepped back to line: %d, file: %v", previous.line, filepath.Base(previous.file))
+	return nil
+}
+
+// Single-steps the target up to the instruction count, recording the starts of the source lines it reaches
+func recordLineTransitions(ctx *processContext, target uint64) ([]lineTransition, error) {
+	transitions := make([]lineTransition, 0)
+
+	for {
+		regs := getRegs(ctx, false)
+
+		// instructions without debug info, e.g. of libraries, or within a line, and skipped code
+		if line, file, _, err := ctx.dwarfData.PCToLine(regs.Rip); err == nil && !isSkipped(ctx, regs.Rip) {
+			transition := lineTransition{
+				instructionCount: getInstructionCount(ctx),
+				line:             line,
+				file:             file,
+				depth:            len(getStack(ctx)),
+			}
+
+			last := len(transitions) - 1
+			if last < 0 || transitions[last].line != line || transitions[last].file != file || transitions[last].depth != transition.depth {
+				transitions = append(transitions, transition)
+			}
+		}
+
+		count := getInstructionCount(ctx)
+		if count >= target {
+			return transitions, nil
+		}
+
+		_, err := replayToInstructionCount(ctx, count+1, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Returns the line executed before the line of the last transition, in the same function or a caller with sameFrame set
+func previousLine(transitions []lineTransition, sameFrame bool) *lineTransition {
+	if len(transitions) < 2 {
+		return nil
+	}
+
+	current := transitions[len(transitions)-1]
+
+	for index := len(transitions) - 2; index >= 0; index-- {
+		if !sameFrame || transitions[index].depth <= current.depth {
+			return &transitions[index]
+		}
+	}
+
+	return nil
+}
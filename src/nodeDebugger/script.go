@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Commands of cli mode read from script files (source <file>, --script=<file>) and given on the command line
+// (-ex <command>), executed before the lines typed. The commands of cli mode run to completion, so each line
+// sees the target where the previous one left it
+
+// scripts sourcing other scripts are limited in depth, to catch cycles
+const MAX_SCRIPT_DEPTH = 10
+
+type scriptLine struct {
+	text  string
+	depth int // scripts sourcing this one
+}
+
+// the commands queued, the next first
+var scriptLines = make([]scriptLine, 0)
+
+// quit once the queued commands are executed (--batch)
+var batchMode bool
+
+// Queues the commands given on the command line
+func queueStartupCommands(options launchOptions) {
+	batchMode = options.batch
+
+	for _, line := range options.commands {
+		scriptLines = append(scriptLines, scriptLine{text: line})
+	}
+}
+
+// Returns the next queued command, echoed after the prompt. Returns false if none is queued
+func nextScriptLine() (string, bool) {
+	for len(scriptLines) > 0 {
+		line := scriptLines[0]
+		scriptLines = scriptLines[1:]
+
+		fmt.Fprintln(promptOutput, line.text)
+		consoleEditor().SetPrompt("")
+
+		if isSourceCommand(line.text) {
+			sourceScript(line.text, line.depth)
+			printPrompt()
+			continue
+		}
+
+		return line.text, true
+	}
+
+	if batchMode {
+		logger.Info("Commands executed, quitting (--batch)")
+		return "q", true
+	}
+
+	return "", false
+}
+
+func isSourceCommand(line string) bool {
+	return strings.HasPrefix(line, "source ")
+}
+
+// Queues the commands of the script file of a source command as the next commands
+func sourceScript(line string, depth int) {
+	filePath := strings.TrimSpace(strings.TrimPrefix(line, "source "))
+
+	if depth >= MAX_SCRIPT_DEPTH {
+		logger.Warn("scripts sourced too deep, ignoring %v", filePath)
+		return
+	}
+
+	lines, err := command.ReadScript(filePath)
+	if err != nil {
+		logger.Warn("cannot read script: %v", err)
+		return
+	}
+
+	logger.Info("Executing %d commands of %v", len(lines), filePath)
+
+	script := make([]scriptLine, 0, len(lines))
+	for _, line := range lines {
+		script = append(script, scriptLine{text: line, depth: depth + 1})
+	}
+
+	scriptLines = append(script, scriptLines...)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+type signalData []signalRecord
+
+type signalRecord struct {
+	signal           syscall.Signal // the received signal
+	event            int            // number of checkpoints recorded before the signal was received
+	instructionCount uint64         // instructions retired before the signal was received (0 if unavailable)
+}
+
+func (s signalRecord) String() string {
+	return fmt.Sprintf("{%v - event %d, instruction %d}", s.signal, s.event, s.instructionCount)
+}
+
+// Handles a stop of the target by a signal, returns the signal to deliver when resuming.
+// Signals received during execution are recorded. During a replay they are suppressed instead,
+// as the recorded signals are re-delivered at their original positions
+func handleSignalStop(ctx *processContext, signal syscall.Signal) syscall.Signal {
+	// a late overflow interrupt of an instruction counter
+	if signal == INSTRUCTION_INTERRUPT_SIGNAL {
+		return 0
+	}
+
+	if isReplaying(ctx) || len(ctx.replayedSignals) > 0 {
+		logger.Debug("suppressing %v received during replay", signal)
+		return 0
+	}
+
+	record := signalRecord{
+		signal:           signal,
+		event:            len(ctx.cpointData),
+		instructionCount: getInstructionCount(ctx),
+	}
+
+	logger.Verbose("target received signal %v", record)
+
+	ctx.signals = append(ctx.signals, record)
+	emitEvent(ctx, rpc.EVENT_SIGNAL, "%v", signal)
+
+	return signal
+}
+
+// Returns the next recorded signal to re-deliver before the target reaches its next event
+func pendingReplayedSignal(ctx *processContext) *signalRecord {
+	if len(ctx.replayedSignals) == 0 || ctx.replayedSignals[0].event > len(ctx.cpointData) {
+		return nil
+	}
+
+	return &ctx.replayedSignals[0]
+}
+
+// Re-delivers the recorded signals due before the next event. The target is run up to the
+// instruction each signal was originally received at, or, if instruction counting is unavailable,
+// the signals are delivered right after the event preceding them.
+// With runToSignals unset, only the signals due at the current instruction are delivered
+func replaySignals(ctx *processContext, runToSignals bool) (exited bool, stoppedAtBreakpoint bool, err error) {
+	for record := pendingReplayedSignal(ctx); record != nil; record = pendingReplayedSignal(ctx) {
+
+		if ctx.instructionCounter != nil && getInstructionCount(ctx) < record.instructionCount {
+			if !runToSignals {
+				return false, false, nil
+			}
+
+			stoppedAtBreakpoint, err = runToInstructionCount(ctx, record.instructionCount, true)
+			if err != nil || stoppedAtBreakpoint {
+				return false, stoppedAtBreakpoint, err
+			}
+		}
+
+		exited, err = deliverReplayedSignal(ctx)
+		if err != nil || exited {
+			return exited, false, err
+		}
+	}
+
+	return false, false, nil
+}
+
+// Delivers the next recorded signal. The target is stopped again at the entry of the signal handler
+func deliverReplayedSignal(ctx *processContext) (exited bool, err error) {
+	record := ctx.replayedSignals[0]
+
+	ctx.replayedSignals = ctx.replayedSignals[1:]
+	ctx.signals = append(ctx.signals, record)
+
+	logger.Verbose("re-delivering signal %v", record)
+
+	err = resumeTarget(ctx, true, record.signal)
+	if err != nil {
+		return false, err
+	}
+
+	var waitStatus syscall.WaitStatus
+
+	err = waitForStop(ctx, &waitStatus)
+	if err != nil {
+		return false, err
+	}
+
+	if waitStatus.Exited() {
+		logger.Verbose("The binary exited with code %v", waitStatus.ExitStatus())
+		return true, nil
+	}
+
+	if waitStatus.StopSignal() != syscall.SIGTRAP {
+		logger.Warn("unexpected stop after re-delivering %v: %v", record.signal, waitStatus.StopSignal())
+	}
+
+	return false, nil
+}
+
+// Splits the recorded signals at the restored checkpoint, the signals received after it are re-delivered during the replay
+func restoreSignals(ctx *processContext, checkpointIndex int) {
+	timeline := append(append(signalData{}, ctx.signals...), ctx.replayedSignals...)
+
+	ctx.signals = signalData{}
+	ctx.replayedSignals = signalData{}
+
+	for _, record := range timeline {
+		if record.event <= checkpointIndex {
+			ctx.signals = append(ctx.signals, record)
+		} else {
+			ctx.replayedSignals = append(ctx.replayedSignals, record)
+		}
+	}
+}
+
+// Resumes the target, delivering the signal (if not 0)
+func resumeTarget(ctx *processContext, singleStep bool, signal syscall.Signal) error {
+	if !singleStep && ctx.outputCatches != nil {
+		return syscall.PtraceSyscall(ctx.pid, int(signal))
+	}
+
+	if !singleStep {
+		return syscall.PtraceCont(ctx.pid, int(signal))
+	}
+
+	// syscall.PtraceSingleStep does not accept a signal
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, syscall.PTRACE_SINGLESTEP, uintptr(ctx.pid), 0, uintptr(signal), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
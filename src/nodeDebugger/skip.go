@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/proc"
+)
+
+// Functions and files stepping does not descend into (skip -file <glob>, skip -function <name>), e.g. the MPI wrapper
+// compiled into instrumented targets or system libraries. A single step into a skipped function runs on until it
+// returns, and reverse steps pass over the lines of skipped code
+
+type skipList struct {
+	files     []string // globs of source files, and of the libraries of code without debug info
+	functions []string
+}
+
+// Adds a file or a function to the skip list, lists it without an argument, clears it with clear
+func setSkip(ctx *processContext, argument string) error {
+	argument = strings.TrimSpace(argument)
+	kind, pattern, _ := strings.Cut(argument, " ")
+	pattern = strings.TrimSpace(pattern)
+
+	switch {
+	case argument == "":
+		if len(ctx.skips.files)+len(ctx.skips.functions) == 0 {
+			logger.Info("nothing skipped")
+		}
+		for _, file := range ctx.skips.files {
+			logger.Info("skipped file: %v", file)
+		}
+		for _, function := range ctx.skips.functions {
+			logger.Info("skipped function: %v", function)
+		}
+	case argument == "clear":
+		ctx.skips = skipList{}
+		logger.Info("skip list cleared")
+	case kind == "-file" && len(pattern) > 0:
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob %v: %v", pattern, err)
+		}
+		ctx.skips.files = append(ctx.skips.files, pattern)
+		logger.Info("stepping skips files matching %v", pattern)
+	case kind == "-function" && len(pattern) > 0:
+		ctx.skips.functions = append(ctx.skips.functions, pattern)
+		logger.Info("stepping skips function %v", pattern)
+	default:
+		return fmt.Errorf("expected skip -file <glob>, skip -function <name> or skip clear")
+	}
+
+	return nil
+}
+
+// Returns whether the instruction at the address is within a skipped function or file. The files of
+// instructions without debug info are the libraries they are mapped from
+func isSkipped(ctx *processContext, pc uint64) bool {
+	if len(ctx.skips.files)+len(ctx.skips.functions) == 0 {
+		return false
+	}
+
+	if fn := ctx.dwarfData.PCToFunc(pc); fn != nil {
+		for _, function := range ctx.skips.functions {
+			if fn.Name() == function {
+				return true
+			}
+		}
+	}
+
+	file := ""
+	if _, sourceFile, err := ctx.dwarfData.PCToLineContaining(pc); err == nil {
+		file = sourceFile
+	} else {
+		for _, region := range proc.GetMemoryLayout(ctx.pid) {
+			if pc >= region.Start && pc < region.End {
+				file = region.Ident
+			}
+		}
+	}
+
+	return len(file) > 0 && isSkippedFile(ctx, file)
+}
+
+// Whether the path, or its base name, matches a skipped glob
+func isSkippedFile(ctx *processContext, file string) bool {
+	for _, pattern := range ctx.skips.files {
+		if matched, _ := filepath.Match(pattern, file); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, filepath.Base(file)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Runs the target out of the skipped function a single step called, to the return address the call pushed,
+// with a temporary breakpoint there. Breakpoints within the function stop the target on the way, MPI calls
+// are recorded.
+// Returns whether the target was stepped into a skipped function
+func stepOutOfSkipped(ctx *processContext) (steppedOut bool, exited bool, err error) {
+	regs := getRegs(ctx, false)
+
+	if !isSkipped(ctx, regs.Rip) {
+		return false, false, nil
+	}
+
+	// a single step enters a function by its call, the return address is on top of the stack. The lazy binding
+	// stub of a library function pushes two more words before entering the dynamic loader
+	returnAddress := uint64(0)
+	buffer := make([]byte, 8)
+
+	for offset := uint64(0); offset <= 16 && returnAddress == 0; offset += 8 {
+		if _, err := memoryReader(ctx)(buffer, regs.Rsp+offset); err != nil {
+			return false, false, err
+		}
+
+		if address := binary.LittleEndian.Uint64(buffer); ctx.dwarfData.PCToFunc(address) != nil && !isSkipped(ctx, address) {
+			returnAddress = address
+		}
+	}
+
+	// entered otherwise, e.g. by a jump within skipped code, the step stays where it is
+	if returnAddress == 0 {
+		logger.Verbose("no return address to step out of the skipped %#x to", regs.Rip)
+		return false, false, nil
+	}
+
+	logger.Verbose("stepping out of the skipped %v to %#x", sourceLocation(ctx, regs.Rip), returnAddress)
+
+	if ctx.bpointData[returnAddress] == nil {
+		ctx.bpointData[returnAddress] = &bpointData{
+			address:             returnAddress,
+			originalInstruction: insertBreakpoint(ctx, returnAddress),
+			temporary:           true,
+		}
+	}
+
+	exited, err = continueExecution(ctx, false)
+	return true, exited, err
+}
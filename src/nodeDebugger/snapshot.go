@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// Records a checkpoint at the location the target is stopped at, as the part of this node in a coordinated
+// global checkpoint. It is reported like an MPI operation, so that it takes its place among the events of the node
+func recordSnapshot(ctx *processContext, snapshotId string) error {
+	// the checkpoints recorded during a replay are compared to the original ones by their position
+	if isReplaying(ctx) {
+		return fmt.Errorf("the node is replaying recorded events")
+	}
+
+	logger.Info("Recording snapshot %v", snapshotId)
+
+	checkpointId := createCheckpoint(ctx, mpi.SNAPSHOT_EVENT)
+
+	record := rpc.MPICallRecord{
+		Id:               checkpointId,
+		OpName:           mpi.SNAPSHOT_EVENT,
+		Parameters:       map[string]string{"snapshot": snapshotId},
+		NodeId:           ctx.nodeData.id,
+		InstructionCount: getInstructionCount(ctx),
+		Location:         sourceLocation(ctx, getRegs(ctx, false).Rip),
+		Time:             time.Now(),
+		CheckpointBytes:  checkpointSize(ctx, checkpointId),
+		BufferHashes:     hashBuffers(ctx),
+	}
+
+	reportMPICall(ctx, &record)
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+)
+
+const (
+	SOURCE_FILE_VARIABLE = "_MPI_WRAPPER_SOURCE_FILE"
+	MAX_SOURCE_PATH      = 4096
+)
+
+// lines bin/compiler inserts at the start of the compiled copy of a source (compiler/compiler.go)
+const (
+	WRAPPED_C_INSERTED_LINE       = `#include "debug_mpi_wrap.h"`
+	WRAPPED_FORTRAN_INSERTED_LINE = "! compiled for cc-rev-db"
+)
+
+var wrappedMPICall = regexp.MustCompile(`(MPI_[^\s]*?\()`)
+
+var fortranExtensions = map[string]bool{".f": true, ".for": true, ".f90": true, ".f95": true, ".f03": true, ".f08": true}
+
+// Serves the source files of the target to the orchestrator, so that they can be listed on a console running on
+// another machine. Only files named by the debug info are served. The target is traced by the main thread,
+// the server only reads files and the debug info
+type SourceServer struct {
+	pb.UnimplementedSourceServerServer
+
+	ctx *processContext
+}
+
+func newSourceServer(ctx *processContext) *SourceServer {
+	return &SourceServer{ctx: ctx}
+}
+
+// Reads a source file, by its path or file name. An empty name reads the file of the main function
+func (server *SourceServer) Fetch(_ context.Context, request *pb.SourceRequest) (*pb.SourceFile, error) {
+	ctx := server.ctx
+
+	compiledPath, err := resolveSourceFile(ctx, request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath, contents, err := readSourceFile(ctx, compiledPath)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := rpc.SourceFile{Path: filePath, Contents: contents}
+
+	if checksum, ok := ctx.sourceChecksums[compiledPath]; ok {
+		reply.Checksum = hex.EncodeToString(checksum[:])
+		reply.Mismatch = !sourceMatches(ctx, checksum, compiledPath, filePath, contents)
+	}
+
+	return reply.Proto(), nil
+}
+
+// Completes an argument typed at the console of the orchestrator from the debug info, the variables in scope
+// by the call stack of the last stop
+func (server *SourceServer) Complete(_ context.Context, request *pb.CompletionRequest) (*pb.Completions, error) {
+	return &pb.Completions{Candidates: completeArgument(server.ctx, request.Kind, request.Prefix)}, nil
+}
+
+// Reads a source file of the debug info. The compiled copy of a target built by bin/compiler is removed,
+// its original is read instead
+func readSourceFile(ctx *processContext, compiledPath string) (filePath string, contents []byte, err error) {
+	filePath = compiledPath
+	if compiledPath == ctx.sourceFile && len(ctx.originalFile) > 0 {
+		filePath = ctx.originalFile
+	}
+
+	contents, err = os.ReadFile(filePath)
+	if err != nil {
+		return filePath, nil, fmt.Errorf("cannot read %s: %v", filePath, err)
+	}
+
+	return filePath, contents, nil
+}
+
+// Returns whether the contents read for a source file of the debug info have its checksum
+func sourceMatches(ctx *processContext, checksum [16]byte, compiledPath string, filePath string, contents []byte) bool {
+	if filePath != compiledPath {
+		contents = wrappedContents(filePath, contents)
+	}
+
+	return dwarf.ChecksumMatches(checksum, contents)
+}
+
+// Reads the checksums the line tables record for the source files and compares the files on this host with them,
+// warning of those changed since the target was built: breakpoints set by line and listed lines would not be the
+// code executed. Returns the changed files and the number of files compared; missing files are not compared
+func verifySourceFiles(ctx *processContext) (stale []string, verified int) {
+	checksums, err := dwarf.FileChecksums(ctx.targetFile)
+	if err != nil {
+		logger.Debug("cannot read the source file checksums: %v", err)
+	}
+	ctx.sourceChecksums = checksums
+
+	compiledPaths := make([]string, 0, len(checksums))
+	for compiledPath := range checksums {
+		compiledPaths = append(compiledPaths, compiledPath)
+	}
+	sort.Strings(compiledPaths)
+
+	for _, compiledPath := range compiledPaths {
+		filePath, contents, err := readSourceFile(ctx, compiledPath)
+		if err != nil {
+			logger.Debug("source file not verified: %v", err)
+			continue
+		}
+
+		verified++
+
+		if !sourceMatches(ctx, checksums[compiledPath], compiledPath, filePath, contents) {
+			stale = append(stale, filePath)
+		}
+	}
+
+	for _, filePath := range stale {
+		logger.Warn("SOURCE MISMATCH: %v has changed since %v was built, its lines are not the code being debugged",
+			filePath, filepath.Base(ctx.targetFile))
+	}
+
+	return stale, verified
+}
+
+// Finds the source file of the debug info with the path or file name
+func resolveSourceFile(ctx *processContext, name string) (string, error) {
+	if len(name) == 0 {
+		return ctx.sourceFile, nil
+	}
+
+	// the original of the compiled copy is named by the user rather than the copy
+	if len(ctx.originalFile) > 0 && matchesSourceFile(ctx.originalFile, name) {
+		return ctx.sourceFile, nil
+	}
+
+	var matches []string
+
+	for _, file := range ctx.dwarfData.SourceFiles() {
+		switch {
+		case file == name:
+			return file, nil
+		case matchesSourceFile(file, name):
+			matches = append(matches, file)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no source file %s in the debug info of the target", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%s is ambiguous, give more of its path: %s", name, strings.Join(matches, ", "))
+	}
+}
+
+// Returns whether a file name, or the end of a path, names the file at the path
+func matchesSourceFile(filePath string, name string) bool {
+	return filePath == name || strings.HasSuffix(filePath, "/"+strings.TrimPrefix(name, "./"))
+}
+
+// Returns the contents of the copy bin/compiler compiles of a source file
+func wrappedContents(filePath string, contents []byte) []byte {
+	fortran := fortranExtensions[strings.ToLower(filepath.Ext(filePath))]
+
+	var wrapped strings.Builder
+
+	if fortran {
+		wrapped.WriteString(WRAPPED_FORTRAN_INSERTED_LINE + "\n")
+	} else {
+		wrapped.WriteString(WRAPPED_C_INSERTED_LINE + "\n")
+	}
+
+	// the lines are copied terminated, MPI calls of C sources prefixed to call the wrappers
+	for _, line := range strings.SplitAfter(string(contents), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if !fortran {
+			line = wrappedMPICall.ReplaceAllString(line, "_$1")
+		}
+
+		wrapped.WriteString(line + "\n")
+	}
+
+	return []byte(wrapped.String())
+}
+
+// Reads the path of the source the target was compiled from, compiled into the MPI wrapper by bin/compiler
+func readOriginalSourceFile(ctx *processContext) string {
+	_, address, err := locateVariable(ctx, SOURCE_FILE_VARIABLE, true)
+	if err != nil {
+		return ""
+	}
+
+	// read in words, the string may end right before an unmapped page
+	data := make([]byte, 0, MAX_SOURCE_PATH)
+	word := make([]byte, 8)
+
+	for len(data) < MAX_SOURCE_PATH {
+		_, err := syscall.PtracePeekData(ctx.pid, uintptr(address)+uintptr(len(data)), word)
+		if err != nil {
+			logger.Debug("cannot read the path of the source file: %v", err)
+			return ""
+		}
+
+		data = append(data, word...)
+		if strings.IndexByte(string(word), 0) >= 0 {
+			break
+		}
+	}
+
+	filePath, _, _ := strings.Cut(string(data), "\x00")
+
+	if !filepath.IsAbs(filePath) {
+		return ""
+	}
+
+	return filePath
+}
@@ -13,15 +13,24 @@ import (
 type programStack []*stackFunction // the current call stack of the program
 
 type stackFunction struct {
-	function     *dwarf.Function // definition of the function
+	function     *dwarf.Function // definition of the function (nil for generated code)
+	jitSymbol    *jitRegion      // the function generated at runtime the frame executes (nil for functions with debug info)
 	baseAddress  uint64          // base address of the stack frame
 	stackAddress uint64
+	pc           uint64 // address of the current instruction in the function
+}
+
+func (sf stackFunction) name() string {
+	if sf.jitSymbol != nil {
+		return fmt.Sprintf("%v [jit]", sf.jitSymbol.symbol)
+	}
+	return sf.function.Name()
 }
 
 func (stack programStack) String() string {
 	str := ""
 	for index, stackFunction := range stack {
-		str = fmt.Sprintf("%s%v", str, stackFunction.function.Name())
+		str = fmt.Sprintf("%s%v", str, stackFunction.name())
 
 		if index != len(stack)-1 {
 			str = fmt.Sprintf("%s <- ", str)
@@ -32,8 +41,12 @@ func (stack programStack) String() string {
 }
 
 func (sf stackFunction) lookupParameter(varName string) *dwarf.Parameter {
+	if sf.function == nil {
+		return nil
+	}
+
 	for _, param := range sf.function.Parameters {
-		if param.Name == varName {
+		if param.Matches(varName) {
 			return param
 		}
 	}
@@ -51,6 +64,20 @@ func (stack programStack) lookupFunction(fn *dwarf.Function) *stackFunction {
 	return nil
 }
 
+// Reads the call stack of the stopped target into the context, and the innermost frame into the scope the
+// variables are completed in
+func updateStack(ctx *processContext) {
+	ctx.stack = getStack(ctx)
+
+	ctx.completionScope.Lock()
+	defer ctx.completionScope.Unlock()
+
+	ctx.completionScope.function, ctx.completionScope.pc = nil, 0
+	if len(ctx.stack) > 0 {
+		ctx.completionScope.function, ctx.completionScope.pc = ctx.stack[0].function, ctx.stack[0].pc
+	}
+}
+
 func getStack(ctx *processContext) programStack {
 	regs := getRegs(ctx, false)
 
@@ -61,20 +88,36 @@ func getStack(ctx *processContext) programStack {
 
 	ptrSize := uint64(utils.PtrSize())
 
+	// code may have been generated since the last stop
+	ctx.jitSymbols.invalidate()
+
 	fn := ctx.dwarfData.PCToFunc(regs.Rip)
 
+	var jitSymbol *jitRegion
 	if fn == nil {
+		jitSymbol = lookupJITSymbol(ctx, regs.Rip)
+	}
+
+	if fn == nil && jitSymbol == nil {
 		return nil
 	}
 
 	fnStack := programStack{
 		&stackFunction{
 			function:     fn,
+			jitSymbol:    jitSymbol,
 			baseAddress:  basePointer,
 			stackAddress: stackPointer,
+			pc:           regs.Rip,
 		},
 	}
 
+	// the frames below main belong to the c runtime, which does not maintain base pointers. The walk below only
+	// ends once it reads a return address into main, from main it would take a register of the runtime for a frame
+	if fn.Name() == MAIN_FN {
+		return fnStack
+	}
+
 	for {
 		offset = 0
 
@@ -97,7 +140,11 @@ func getStack(ctx *processContext) programStack {
 		fn = ctx.dwarfData.PCToFunc(stackContent)
 
 		if fn != nil {
-			fnStack = append(fnStack, &stackFunction{function: fn, baseAddress: basePointer, stackAddress: stackPointer})
+			// the return address points past the call instruction, which may already be outside of the caller's block
+			fnStack = append(fnStack, &stackFunction{function: fn, baseAddress: basePointer, stackAddress: stackPointer, pc: stackContent - 1})
+		} else if jitSymbol := lookupJITSymbol(ctx, stackContent-1); jitSymbol != nil {
+			// generated code keeping the base pointer is walked through like compiled code
+			fnStack = append(fnStack, &stackFunction{jitSymbol: jitSymbol, baseAddress: basePointer, stackAddress: stackPointer, pc: stackContent - 1})
 		}
 
 		for offset = 0; offset < frameSize; offset += ptrSize {
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// Stepping by machine instructions (stepi [<n>]): the target is single-stepped n instructions, and the instruction
+// it stopped at is logged disassembled, with the function and source line it belongs to
+
+const maxInstructionLength = 15 // bytes of the longest x86-64 instruction
+
+// Single-steps the target by the number of instructions. Stepping stops early at a breakpoint,
+// which the forward progress loop of the command handles like after a single step
+func stepInstructions(ctx *processContext, count int) (exited bool, err error) {
+	for i := 0; i < count; i++ {
+		atBreakpoint := ctx.bpointData[getRegs(ctx, false).Rip] != nil
+
+		exited, err = continueExecution(ctx, true)
+		if exited || err != nil || atBreakpoint || wasInterrupted(ctx) {
+			return exited, err
+		}
+	}
+
+	return false, nil
+}
+
+// Logs the instruction the target is stopped at, e.g.
+//
+//	0x401136 <main+4>: movl   $0x0,-0x4(%rbp)
+//	t.c:12	int a = 0;
+func printInstruction(ctx *processContext) string {
+	pc := getRegs(ctx, false).Rip
+
+	instruction, err := disassemble(ctx, pc)
+	if err != nil {
+		instruction = fmt.Sprintf("<%v>", err)
+	}
+
+	symbol := ""
+	if fn := ctx.dwarfData.PCToFunc(pc); fn != nil {
+		symbol = fmt.Sprintf(" <%s+%d>", fn.Name(), pc-fn.LowPC())
+	}
+
+	lines := []string{fmt.Sprintf("%#x%s: %s", pc, symbol, instruction)}
+
+	if line, file, err := ctx.dwarfData.PCToLineContaining(pc); err == nil {
+		lines = append(lines, fmt.Sprintf("%s:%d\t%s", filepath.Base(file), line, sourceLine(ctx, file, line)))
+	}
+
+	for _, line := range lines {
+		logger.Info("%s", line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Disassembles the instruction at the address with objdump, from the bytes of the target with the
+// instructions replaced by breakpoints put back. Without objdump, the bytes are returned in hex
+func disassemble(ctx *processContext, address uint64) (string, error) {
+	code := make([]byte, maxInstructionLength)
+	if _, err := memoryReader(ctx)(code, address); err != nil {
+		return "", err
+	}
+
+	for i := range code {
+		if bpoint := ctx.bpointData[address+uint64(i)]; bpoint != nil {
+			copy(code[i:], bpoint.originalInstruction)
+		}
+	}
+
+	file, err := os.CreateTemp("", "cc-rev-db-stepi-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(code)
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := exec.Command("objdump", "-D", "-b", "binary", "-m", "i386:x86-64", fmt.Sprintf("--adjust-vma=%#x", address), file.Name()).Output()
+	if err != nil {
+		return fmt.Sprintf("% x (not disassembled: objdump %v)", code, err), nil
+	}
+
+	// the first instruction line, e.g. "  401136:\tc7 45 fc 00 00 00 00 \tmovl   $0x0,-0x4(%rbp)"
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) >= 3 && strings.HasSuffix(strings.TrimSpace(fields[0]), ":") {
+			return strings.TrimSpace(fields[2]), nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot disassemble % x", code)
+}
+
+// Returns the text of a line of a source file, empty if the file cannot be read
+func sourceLine(ctx *processContext, file string, line int) string {
+	_, contents, err := readSourceFile(ctx, file)
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(string(contents), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	return strings.TrimSpace(lines[line-1])
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// Tracepoints (trace <location> "<format>" <argument>, ...) log a line formatted from the values of their
+// arguments whenever the target passes their location, then let it run on. The target is disturbed for no
+// longer than reading the arguments, which keeps the timing of the MPI calls around them close to an undebugged run
+
+type tracepoint struct {
+	format    string
+	arguments []*expression // expressions of the values formatted
+}
+
+func (t *tracepoint) String() string {
+	arguments := make([]string, 0, len(t.arguments))
+	for _, argument := range t.arguments {
+		arguments = append(arguments, argument.source)
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%q %v", t.format, strings.Join(arguments, ", ")))
+}
+
+// Sets a tracepoint at a location, [<file>:]<line> or a function. A breakpoint already set there keeps stopping
+// the target, logging the line first
+func setTracepoint(ctx *processContext, argument string) error {
+	location, rest, _ := strings.Cut(strings.TrimSpace(argument), " ")
+
+	trace, err := parseTracepoint(rest)
+	if err != nil {
+		return err
+	}
+
+	missing := unsetBreakpoints(ctx, location)
+
+	bpoints, err := locationBreakpoints(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	for _, bpoint := range bpoints {
+		bpoint.trace = trace
+
+		if missing[bpoint.address] {
+			bpoint.traceOnly = true
+		}
+	}
+
+	logger.Info("tracepoint set at %v: %v", location, trace)
+
+	return nil
+}
+
+// Returns the addresses of a location without a breakpoint (or only a causal line marker) at them
+func unsetBreakpoints(ctx *processContext, location string) map[uint64]bool {
+	missing := make(map[uint64]bool)
+
+	addresses, err := locationAddresses(ctx, location)
+	if err != nil {
+		return missing
+	}
+
+	for _, address := range addresses {
+		if bpoint := ctx.bpointData[address]; bpoint == nil || bpoint.markerOnly {
+			missing[address] = true
+		}
+	}
+
+	return missing
+}
+
+// Parses the quoted format of a tracepoint and its arguments, separated by commas
+func parseTracepoint(source string) (*tracepoint, error) {
+	source = strings.TrimSpace(source)
+
+	end := closingQuote(source)
+	if !strings.HasPrefix(source, `"`) || end < 0 {
+		return nil, fmt.Errorf(`expected trace <location> "<format>" <argument>, ...`)
+	}
+
+	format, err := strconv.Unquote(source[:end+1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid format %v: %w", source[:end+1], err)
+	}
+
+	trace := &tracepoint{format: format, arguments: make([]*expression, 0)}
+
+	rest := strings.TrimPrefix(strings.TrimSpace(source[end+1:]), ",")
+	if len(strings.TrimSpace(rest)) == 0 {
+		return trace, nil
+	}
+
+	for _, argument := range strings.Split(rest, ",") {
+		expression, err := parseExpression(strings.TrimSpace(argument))
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %q: %w", argument, err)
+		}
+
+		trace.arguments = append(trace.arguments, expression)
+	}
+
+	return trace, nil
+}
+
+// Index of the quote closing the string the source starts with, -1 if not closed
+func closingQuote(source string) int {
+	for index := 1; index < len(source); index++ {
+		switch source[index] {
+		case '\\':
+			index++
+		case '"':
+			return index
+		}
+	}
+
+	return -1
+}
+
+// Logs the line of a tracepoint the target passed: to the orchestrator in network mode, to the console in cli mode
+func logTracepoint(ctx *processContext, bpoint *bpointData) {
+	updateStack(ctx)
+
+	values := make([]interface{}, 0, len(bpoint.trace.arguments))
+
+	for _, argument := range bpoint.trace.arguments {
+		value, err := argument.evaluate(ctx)
+		if err != nil {
+			value = fmt.Sprintf("<%v>", err)
+		}
+
+		values = append(values, value)
+	}
+
+	logger.Info("[trace %v] %v", sourceLocation(ctx, bpoint.address), strings.TrimSuffix(formatTrace(bpoint.trace.format, values), "\n"))
+}
+
+// Formats values with a printf format of C: the length modifiers (l, h, z, ...) are dropped and the values
+// converted to the conversion, e.g. an integer printed with %f
+func formatTrace(format string, values []interface{}) string {
+	var builder strings.Builder
+
+	next := 0 // index of the next value
+
+	for index := 0; index < len(format); index++ {
+		if format[index] != '%' {
+			builder.WriteByte(format[index])
+			continue
+		}
+
+		end := index + 1
+		for end < len(format) && strings.IndexByte("-+ #0123456789.", format[end]) >= 0 {
+			end++
+		}
+		flags := format[index+1 : end]
+
+		for end < len(format) && strings.IndexByte("hlLqjzt", format[end]) >= 0 {
+			end++
+		}
+
+		if end >= len(format) {
+			builder.WriteString(format[index:])
+			break
+		}
+
+		verb := format[end]
+		index = end
+
+		if verb == '%' {
+			builder.WriteByte('%')
+			continue
+		}
+
+		if next >= len(values) {
+			builder.WriteString("<missing>")
+			continue
+		}
+
+		value := values[next]
+		next++
+
+		// arguments that could not be evaluated are shown by their errors
+		if text, ok := value.(string); ok && verb != 's' {
+			builder.WriteString(text)
+			continue
+		}
+
+		switch verb {
+		case 'd', 'i', 'u':
+			fmt.Fprintf(&builder, "%"+flags+"d", integerValue(value))
+		case 'x', 'X', 'o', 'c':
+			fmt.Fprintf(&builder, "%"+flags+string(verb), integerValue(value))
+		case 'f', 'F', 'e', 'E', 'g', 'G':
+			fmt.Fprintf(&builder, "%"+flags+string(verb), floatValue(value))
+		case 'p':
+			fmt.Fprintf(&builder, "%#x", integerValue(value))
+		default:
+			fmt.Fprintf(&builder, "%"+flags+"v", value)
+		}
+	}
+
+	return builder.String()
+}
+
+func integerValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case float32:
+		return int64(typedValue)
+	case float64:
+		return int64(typedValue)
+	}
+	return value
+}
+
+func floatValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case int64:
+		return float64(typedValue)
+	case int32:
+		return float64(typedValue)
+	case int:
+		return float64(typedValue)
+	}
+	return value
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/proc"
+	"github.com/ottmartens/cc-rev-db/utils"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// The full-screen interface of cli mode (--tui): the source around the current line, the registers, the breakpoints
+// and the output of the commands and the target in panes, the prompt on the last row. Drawn with ANSI escape
+// sequences, so it works in any terminal emulator without a curses library
+
+// lines of output kept for the output pane
+const TUI_OUTPUT_LINES = 1000
+
+// terminal size if it cannot be read
+const (
+	TUI_DEFAULT_WIDTH  = 100
+	TUI_DEFAULT_HEIGHT = 30
+)
+
+const (
+	ansiClear         = "\x1b[H\x1b[2J"
+	ansiAltScreen     = "\x1b[?1049h"
+	ansiMainScreen    = "\x1b[?1049l"
+	ansiReverse       = "\x1b[7m"
+	ansiReset         = "\x1b[0m"
+	ansiSaveCursor    = "\x1b7"
+	ansiRestoreCursor = "\x1b8"
+)
+
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]`)
+
+type textUI struct {
+	sync.Mutex
+	terminal *os.File // the terminal, the standard output is redirected into the output pane
+	output   []string // lines of the output pane, oldest first
+	partial  string   // output not ended by a newline yet
+
+	// where the output pane was last drawn, for redrawing it alone as output arrives
+	outputRow, outputColumn, outputWidth, outputHeight int
+
+	// resource usage of the target when last drawn, for the cpu utilization since
+	usage     *proc.ResourceUsage
+	usageTime time.Time
+}
+
+// the interface of cli mode, nil unless started with --tui
+var tui *textUI
+
+// Takes over the terminal: the standard output, including that of the target started afterwards and the log,
+// is shown in the output pane
+func startTUI() {
+	reader, writer, err := os.Pipe()
+	utils.Must(err)
+
+	tui = &textUI{terminal: os.Stdout}
+	os.Stdout = writer
+
+	// the prompt and the line typed are shown on the last row
+	promptOutput = tui.terminal
+
+	fmt.Fprint(tui.terminal, ansiAltScreen)
+
+	go func() {
+		buffer := make([]byte, 4096)
+
+		for {
+			n, err := reader.Read(buffer)
+			if n > 0 {
+				tui.appendOutput(string(buffer[:n]))
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// Gives the terminal back, before the debugger exits
+func closeTUI() {
+	if tui == nil {
+		return
+	}
+
+	fmt.Fprint(tui.terminal, ansiMainScreen)
+	os.Stdout = tui.terminal
+}
+
+// Runs the commands typed at the prompt, redrawing the panes after each
+func handleTUIWorkflow(ctx *processContext) {
+	for {
+		tui.draw(ctx)
+		printPrompt()
+
+		userInput, jsonResult := command.CutJSONFlag(getUserInputLine())
+		fmt.Printf("> %s\n", userInput)
+
+		cmd := parseCommandFromString(userInput)
+		if cmd == nil {
+			fmt.Println(`Invalid input. Type "help" to see available commands`)
+			continue
+		}
+
+		cmd.JSON = jsonResult
+
+		handleCommand(ctx, cmd)
+
+		if ctx.options.json || cmd.JSON {
+			fmt.Println(command.FormatJSON(cmd))
+		}
+
+		if hint := utils.ErrorHint(cmd.Result.ErrorKind); len(hint) > 0 {
+			logger.Info("hint: %v", hint)
+		}
+
+		if cmd.Result.Exited {
+			closeTUI()
+			break
+		}
+	}
+}
+
+func (t *textUI) appendOutput(text string) {
+	t.Lock()
+	defer t.Unlock()
+
+	text = t.partial + strings.ReplaceAll(ansiSequence.ReplaceAllString(text, ""), "\r", "")
+	lines := strings.Split(text, "\n")
+
+	t.partial = lines[len(lines)-1]
+	t.output = append(t.output, lines[:len(lines)-1]...)
+
+	if excess := len(t.output) - TUI_OUTPUT_LINES; excess > 0 {
+		t.output = t.output[excess:]
+	}
+
+	if t.outputHeight > 0 {
+		fmt.Fprint(t.terminal, ansiSaveCursor)
+		t.drawOutput()
+		fmt.Fprint(t.terminal, ansiRestoreCursor)
+	}
+}
+
+// Draws all panes and leaves the cursor at the prompt. The source and the registers are read from the target,
+// so it runs on the thread tracing the target
+func (t *textUI) draw(ctx *processContext) {
+	width, height := terminalSize(t.terminal)
+
+	leftWidth := width * 3 / 5
+	rightWidth := width - leftWidth - 1
+	topHeight := (height - 2) * 3 / 5
+	bottomHeight := height - 2 - topHeight
+
+	location, source := tuiSource(ctx, topHeight-1)
+
+	screen := new(strings.Builder)
+	screen.WriteString(ansiClear)
+
+	drawPane(screen, 1, 1, leftWidth, topHeight, "source "+location, source)
+	drawPane(screen, 1, leftWidth+2, rightWidth, topHeight, "usage and registers", append(t.usageLines(ctx), tuiRegisters(ctx)...))
+	drawPane(screen, topHeight+1, leftWidth+2, rightWidth, bottomHeight, "breakpoints", tuiBreakpoints(ctx))
+
+	t.Lock()
+	defer t.Unlock()
+
+	fmt.Fprint(t.terminal, screen.String())
+
+	t.outputRow, t.outputColumn, t.outputWidth, t.outputHeight = topHeight+1, 1, leftWidth, bottomHeight
+	t.drawOutput()
+
+	fmt.Fprintf(t.terminal, "\x1b[%d;1H", height)
+}
+
+// Draws the output pane with its latest lines. The lock must be held
+func (t *textUI) drawOutput() {
+	screen := new(strings.Builder)
+
+	lines := t.output
+	if len(t.partial) > 0 {
+		lines = append(lines[:len(lines):len(lines)], t.partial)
+	}
+	if visible := t.outputHeight - 1; len(lines) > visible {
+		lines = lines[len(lines)-visible:]
+	}
+
+	drawPane(screen, t.outputRow, t.outputColumn, t.outputWidth, t.outputHeight, "output", lines)
+
+	fmt.Fprint(t.terminal, screen.String())
+}
+
+// Writes a pane at the row and column (1-based): its title bar, then the lines cut to its size.
+// Lines starting with the reverse video sequence are highlighted over the whole width
+func drawPane(screen *strings.Builder, row int, column int, width int, height int, title string, lines []string) {
+	fmt.Fprintf(screen, "\x1b[%d;%dH%s%s%s", row, column, ansiReverse, fitWidth(" "+title, width), ansiReset)
+
+	for i := 0; i < height-1; i++ {
+		line := ""
+		if i < len(lines) {
+			line = lines[i]
+		}
+
+		highlighted := strings.HasPrefix(line, ansiReverse)
+		line = fitWidth(strings.TrimPrefix(line, ansiReverse), width)
+
+		if highlighted {
+			line = ansiReverse + line + ansiReset
+		}
+
+		fmt.Fprintf(screen, "\x1b[%d;%dH%s", row+1+i, column, line)
+	}
+}
+
+// Pads or cuts the line to the width, tabs expanded
+func fitWidth(line string, width int) string {
+	runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
+
+// The location the target is stopped at and the numbered lines of its source around it, the current line highlighted
+// and the lines with breakpoints marked
+func tuiSource(ctx *processContext, height int) (location string, lines []string) {
+	pc := getRegs(ctx, false).Rip
+
+	currentLine, file, err := ctx.dwarfData.PCToLineContaining(pc)
+	if err != nil {
+		return fmt.Sprintf("%#x", pc), []string{fmt.Sprintf("no source for the instruction at %#x", pc)}
+	}
+
+	_, contents, err := readSourceFile(ctx, file)
+	if err != nil {
+		return sourceLocation(ctx, pc), []string{err.Error()}
+	}
+
+	breakpointLines := make(map[int]bool)
+	for _, bpoint := range listedBreakpoints(ctx) {
+		if line, bpointFile, err := ctx.dwarfData.PCToLineContaining(bpoint.address); err == nil && bpointFile == file {
+			breakpointLines[line] = true
+		}
+	}
+
+	sourceLines := strings.Split(string(contents), "\n")
+
+	first := currentLine - height/2
+	if first < 1 {
+		first = 1
+	}
+
+	for lineNr := first; lineNr < first+height && lineNr <= len(sourceLines); lineNr++ {
+		marker := " "
+		if breakpointLines[lineNr] {
+			marker = "●"
+		}
+
+		line := fmt.Sprintf("%s%5d  %s", marker, lineNr, sourceLines[lineNr-1])
+		if lineNr == currentLine {
+			line = ansiReverse + line
+		}
+
+		lines = append(lines, line)
+	}
+
+	return fmt.Sprintf("%s:%d", filepath.Base(file), currentLine), lines
+}
+
+// The cpu time, utilization since the last draw, memory and storage i/o of the target, followed by an empty line
+func (t *textUI) usageLines(ctx *processContext) []string {
+	usage, err := proc.GetResourceUsage(ctx.pid)
+	if err != nil {
+		return []string{fmt.Sprintf(" usage unavailable: %v", err), ""}
+	}
+
+	now := time.Now()
+	cpuTime := usage.UserTime + usage.SystemTime
+
+	utilization := "-"
+	if t.usage != nil && now.After(t.usageTime) {
+		previous := t.usage.UserTime + t.usage.SystemTime
+		utilization = fmt.Sprintf("%.0f%%", 100*float64(cpuTime-previous)/float64(now.Sub(t.usageTime)))
+	}
+
+	t.usage, t.usageTime = &usage, now
+
+	return []string{
+		fmt.Sprintf(" cpu %v (%s)  rss %s  swap %s", cpuTime, utilization, utils.FormatByteSize(usage.RSS), utils.FormatByteSize(usage.Swap)),
+		fmt.Sprintf(" read %s  written %s", utils.FormatByteSize(usage.ReadBytes), utils.FormatByteSize(usage.WriteBytes)),
+		"",
+	}
+}
+
+// The general purpose registers, one per line
+func tuiRegisters(ctx *processContext) []string {
+	regs := reflect.ValueOf(getRegs(ctx, false)).Elem()
+
+	lines := make([]string, 0, regs.NumField())
+	for i := 0; i < regs.NumField(); i++ {
+		lines = append(lines, fmt.Sprintf(" %-8s %#018x", strings.ToLower(regs.Type().Field(i).Name), regs.Field(i).Interface()))
+	}
+
+	return lines
+}
+
+// The breakpoints set by the user, with their conditions and hit counts
+func tuiBreakpoints(ctx *processContext) []string {
+	lines := make([]string, 0)
+
+	for _, bpoint := range listedBreakpoints(ctx) {
+		line := fmt.Sprintf(" %s  hits %d", sourceLocation(ctx, bpoint.address), bpoint.hitCount)
+
+		if bpoint.function != nil {
+			line += fmt.Sprintf("  (%v)", bpoint.function.Name())
+		}
+		if bpoint.condition != nil {
+			line += fmt.Sprintf("  if %v", bpoint.condition)
+		}
+		if len(bpoint.commands) > 0 {
+			line += fmt.Sprintf("  do %v", strings.Join(bpoint.commands, "; "))
+		}
+		if bpoint.trace != nil {
+			line += fmt.Sprintf("  trace %v", bpoint.trace)
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, " none, set one with b <line|function>")
+	}
+
+	return lines
+}
+
+// The user breakpoints of the target by address, without the markers of causal breakpoints
+func listedBreakpoints(ctx *processContext) []*bpointData {
+	bpoints := make([]*bpointData, 0)
+
+	for _, bpoint := range userBreakpoints(ctx) {
+		if !bpoint.markerOnly && !bpoint.causalReceive {
+			bpoints = append(bpoints, bpoint)
+		}
+	}
+
+	sort.Slice(bpoints, func(i, j int) bool { return bpoints[i].address < bpoints[j].address })
+
+	return bpoints
+}
+
+// Reads the columns and rows of the terminal
+func terminalSize(terminal *os.File) (width int, height int) {
+	var size struct{ rows, columns, xPixels, yPixels uint16 }
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, terminal.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if errno != 0 || size.columns == 0 || size.rows == 0 {
+		return TUI_DEFAULT_WIDTH, TUI_DEFAULT_HEIGHT
+	}
+
+	return int(size.columns), int(size.rows)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+)
+
+// Type inspection (whatis <expr>, ptype <expr|type>): the declared type of a value, and for ptype the members of
+// the structures, unions and enumerations it is made of, laid out with their offsets and sizes
+
+// C types of the values the debugger computes itself, e.g. the result of n * 2
+var computedTypeNames = map[string]string{
+	"int64":   "long",
+	"uint64":  "unsigned long",
+	"float64": "double",
+	"string":  "char *",
+}
+
+// Prints the type of an expression, or of a type named as in a cast, e.g. ptype struct particle. Returns the type printed
+func printType(ctx *processContext, source string, expand bool) (string, error) {
+	variable, typeName, err := typedExpression(ctx, strings.TrimSpace(source))
+	if err != nil {
+		logger.Info("%v", err)
+		return "", err
+	}
+
+	var described string
+
+	switch {
+	case expand:
+		described = variable.TypeLayout()
+	case typeName:
+		described = variable.AliasedType()
+	default:
+		described = variable.DeclaredType()
+	}
+
+	fmt.Printf("type = %s\n", described)
+
+	return described, nil
+}
+
+// Returns a declaration of the type of an expression. Expressions that cannot be evaluated are tried as names of types
+func typedExpression(ctx *processContext, source string) (variable *dwarf.Variable, typeName bool, err error) {
+	parsed, err := parseExpression(source)
+	if err == nil {
+		var value expressionValue
+
+		if value, err = parsed.root.evaluate(ctx); err == nil {
+			if value.variable != nil {
+				return value.variable, false, nil
+			}
+
+			variable, err = ctx.dwarfData.TypedVariable(source, computedTypeNames[fmt.Sprintf("%T", value.value)])
+			return variable, false, err
+		}
+	}
+
+	if typed, typeErr := ctx.dwarfData.TypedVariable(source, source); typeErr == nil {
+		return typed, true, nil
+	}
+
+	return nil, false, err
+}
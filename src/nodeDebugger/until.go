@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+)
+
+// Running to a location (until [<file>:]<line>|<function>): the target continues with temporary breakpoints at the
+// location, removed once it stops there or anywhere else, e.g. at a breakpoint passed on the way
+
+// Sets the temporary breakpoints at a location and continues the target. The breakpoints the target did not stop
+// at are removed by removeTemporaryBreakpoints once the command completes
+func runUntil(ctx *processContext, location string) (exited bool, err error) {
+	addresses, err := locationAddresses(ctx, location)
+	if err != nil {
+		return false, err
+	}
+
+	for _, address := range addresses {
+		// a breakpoint set there stops the target anyway
+		if ctx.bpointData[address] != nil {
+			continue
+		}
+
+		ctx.bpointData[address] = &bpointData{
+			address:             address,
+			originalInstruction: insertBreakpoint(ctx, address),
+			temporary:           true,
+		}
+	}
+
+	logger.Info("running until %v", location)
+
+	return continueExecution(ctx, false)
+}
+
+// Returns the addresses of a location, [<file>:]<line> or a function
+func locationAddresses(ctx *processContext, location string) ([]uint64, error) {
+	if lineLocationRegexp.MatchString(location) {
+		file, line, err := parseLineLocation(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+
+		address, err := ctx.dwarfData.LineToPC(file, line)
+		if err != nil {
+			return nil, err
+		}
+
+		return []uint64{address}, nil
+	}
+
+	functions := ctx.dwarfData.LookupFunctions(location)
+	if len(functions) == 0 {
+		return nil, fmt.Errorf("function %s not found", location)
+	}
+
+	addresses := make([]uint64, 0, len(functions))
+	for _, function := range functions {
+		addresses = append(addresses, ctx.dwarfData.FunctionBreakpointAddress(function))
+	}
+
+	return addresses, nil
+}
+
+// Lifts the temporary breakpoints of until out of the target
+func removeTemporaryBreakpoints(ctx *processContext) {
+	for address, bpoint := range ctx.bpointData {
+		if !bpoint.temporary {
+			continue
+		}
+
+		_, err := syscall.PtracePokeData(ctx.pid, uintptr(address), bpoint.originalInstruction)
+		if err != nil {
+			logger.Warn("cannot remove the temporary breakpoint at %#x: %v", address, err)
+			continue
+		}
+		delete(ctx.bpointData, address)
+	}
+}
+
+// Runs the target from the trap of its exec to main, past the prologue, with a temporary breakpoint there,
+// so that the first command finds the target stopped at the source of the program
+func stopAtMain(ctx *processContext) {
+	var function *dwarf.Function
+
+	// the main function of go targets is main.main
+	for _, mainFunction := range []string{MAIN_FN, "main.main"} {
+		if _, function = ctx.dwarfData.LookupFunc(mainFunction); function != nil {
+			break
+		}
+	}
+
+	if function == nil {
+		logger.Warn("no main function in the debug info, the target stays stopped at its start")
+		return
+	}
+
+	address := ctx.dwarfData.FunctionBreakpointAddress(function)
+
+	if ctx.bpointData[address] == nil {
+		ctx.bpointData[address] = &bpointData{
+			address:             address,
+			function:            function,
+			originalInstruction: insertBreakpoint(ctx, address),
+			temporary:           true,
+		}
+	}
+
+	exited, err := continueExecution(ctx, false)
+	if err != nil || exited {
+		logger.Warn("the target did not reach %v: %v", function.Name(), err)
+		return
+	}
+
+	bpoint, _ := restoreCaughtBreakpoint(ctx)
+	removeTemporaryBreakpoints(ctx)
+
+	if bpoint == nil || bpoint.address != address {
+		logger.Warn("the target stopped before reaching %v, at %v", function.Name(), sourceLocation(ctx, getRegs(ctx, false).Rip))
+	}
+
+	updateStack(ctx)
+}
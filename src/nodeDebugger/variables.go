@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Looks up a variable or the value of an expression, or expands the children of a value by its handle ("#<handle> [<start> [<count>]]").
+// Values with children are given handles, valid until the target moves, so front-ends expand
+// structures, arrays and pointers one level at a time instead of the node reading them whole.
+// The values are returned to the orchestrator, which prints them for the console, or printed in cli mode
+func expandVariables(ctx *processContext, argument string) ([]command.Value, error) {
+	var values []command.Value
+
+	if command.IsChildrenArgument(argument) {
+		parent, start, count, err := parseChildrenArgument(ctx, argument)
+		if err != nil {
+			return nil, err
+		}
+
+		children, err := ctx.dwarfData.ValueChildren(parent, memoryReader(ctx), start, count)
+		for _, child := range children {
+			values = append(values, describeValue(ctx, child))
+		}
+		if err != nil {
+			return values, err
+		}
+	} else if strings.HasPrefix(argument, "$") {
+		value := getConvenienceVariable(ctx, argument)
+		if value == nil {
+			return nil, fmt.Errorf("unknown convenience variable: %s", argument)
+		}
+
+		values = append(values, command.Value{Name: argument, Type: fmt.Sprintf("%T", value), Summary: fmt.Sprint(value)})
+	} else {
+		variable, address, err := locateExpression(ctx, argument)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, describeValue(ctx, dwarf.LocatedValue{Name: argument, Variable: variable, Address: address}))
+	}
+
+	if ctx.nodeData == nil {
+		fmt.Println(formatValues(values))
+	}
+
+	return values, nil
+}
+
+// Describes a value, giving it a handle if it has children
+func describeValue(ctx *processContext, located dwarf.LocatedValue) command.Value {
+	typeName, summary, children := ctx.dwarfData.DescribeValue(located, memoryReader(ctx))
+
+	value := command.Value{
+		Name:     located.Name,
+		Type:     typeName,
+		Summary:  summary,
+		Children: children,
+	}
+
+	if children > 0 {
+		ctx.valueHandles = append(ctx.valueHandles, located)
+		value.Handle = len(ctx.valueHandles)
+	}
+
+	return value
+}
+
+func parseChildrenArgument(ctx *processContext, argument string) (parent dwarf.LocatedValue, start int, count int, err error) {
+	fields := strings.Fields(strings.TrimPrefix(argument, "#"))
+
+	numbers := make([]int, len(fields))
+	for index, field := range fields {
+		numbers[index], err = strconv.Atoi(field)
+		if err != nil || numbers[index] < 0 {
+			return parent, 0, 0, fmt.Errorf("invalid value handle argument: %s", argument)
+		}
+	}
+
+	if len(numbers) == 0 || len(numbers) > 3 {
+		return parent, 0, 0, fmt.Errorf("invalid value handle argument: %s", argument)
+	}
+
+	handle := numbers[0]
+	if handle < 1 || handle > len(ctx.valueHandles) {
+		return parent, 0, 0, fmt.Errorf("unknown value handle #%d, handles are released when the target moves", handle)
+	}
+
+	if len(numbers) > 1 {
+		start = numbers[1]
+	}
+	if len(numbers) > 2 {
+		count = numbers[2]
+	}
+
+	return ctx.valueHandles[handle-1], start, count, nil
+}
+
+// Renders expanded values a line each, compared across the nodes of a selector command
+func formatValues(values []command.Value) string {
+	lines := make([]string, len(values))
+	for index, value := range values {
+		lines[index] = value.String()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/binary"
+	"syscall"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+const (
+	VECTOR_CLOCK_VARIABLE = "_MPI_WRAPPER_VECTOR_CLOCK"
+	MAX_CLOCK_RANKS       = 4096 // entries of the clock in the wrapper (_MPI_WRAPPER_MAX_CLOCK_RANKS)
+)
+
+// Counts an MPI event of the target in its vector clock and returns the clock, nil if the wrapper keeps none.
+// The wrapper piggybacks the clock on the messages the target sends and merges in the clocks of the messages it
+// receives, the debugger ticks the entry of the rank of the target at each of its MPI calls, before the call is made
+func tickVectorClock(ctx *processContext) []int {
+	rank, rankAddress, err := readWrapperInt(ctx, MPI_RANK_VARIABLE)
+	if err != nil || rankAddress == 0 {
+		return nil
+	}
+
+	size, _, err := readWrapperInt(ctx, MPI_SIZE_VARIABLE)
+	if err != nil || size <= 0 || rank < 0 || rank >= size {
+		// MPI is not initialized yet, the clock starts at MPI_Init
+		return nil
+	}
+
+	if size > MAX_CLOCK_RANKS {
+		size = MAX_CLOCK_RANKS
+	}
+
+	_, address, err := locateVariable(ctx, VECTOR_CLOCK_VARIABLE, true)
+	if err != nil {
+		return nil
+	}
+
+	data := make([]byte, 4*size)
+
+	_, err = syscall.PtracePeekData(ctx.pid, uintptr(address), data)
+	if err != nil {
+		logger.Debug("cannot read the vector clock: %v", err)
+		return nil
+	}
+
+	entry := data[4*rank : 4*rank+4]
+	binary.LittleEndian.PutUint32(entry, binary.LittleEndian.Uint32(entry)+1)
+
+	_, err = syscall.PtracePokeData(ctx.pid, uintptr(address)+uintptr(4*rank), entry)
+	if err != nil {
+		logger.Debug("cannot update the vector clock: %v", err)
+		return nil
+	}
+
+	clock := make([]int, size)
+	for index := range clock {
+		clock[index] = int(int32(binary.LittleEndian.Uint32(data[4*index:])))
+	}
+
+	return clock
+}
+
+// Returns the entry of the rank of the target in its vector clock, its MPI events counted so far
+func ownClockEntry(ctx *processContext) (int, bool) {
+	rank, _, err := readWrapperInt(ctx, MPI_RANK_VARIABLE)
+	if err != nil || rank < 0 || rank >= MAX_CLOCK_RANKS {
+		return 0, false
+	}
+
+	size, _, err := readWrapperInt(ctx, MPI_SIZE_VARIABLE)
+	if err != nil || size <= 0 {
+		return 0, false
+	}
+
+	_, address, err := locateVariable(ctx, VECTOR_CLOCK_VARIABLE, true)
+	if err != nil {
+		return 0, false
+	}
+
+	data := make([]byte, 4)
+	_, err = syscall.PtracePeekData(ctx.pid, uintptr(address)+uintptr(4*rank), data)
+	if err != nil {
+		return 0, false
+	}
+
+	return int(int32(binary.LittleEndian.Uint32(data))), true
+}
+
+// Reads an int global of the MPI wrapper, with its address (0 if the target is not built with the wrapper)
+func readWrapperInt(ctx *processContext, name string) (int, uint64, error) {
+	_, address, err := locateVariable(ctx, name, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	data := make([]byte, 4)
+
+	_, err = syscall.PtracePeekData(ctx.pid, uintptr(address), data)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(int32(binary.LittleEndian.Uint32(data))), address, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+const (
+	DEFAULT_WATCHDOG_TIMEOUT = 60 * time.Second
+	watchdogPollInterval     = 10 * time.Millisecond
+)
+
+// Returns whether the target is re-executing previously recorded events after a checkpoint restore
+func isReplaying(ctx *processContext) bool {
+	return len(ctx.cpointData) < len(ctx.replayedCheckpoints)
+}
+
+// Waits for the target to stop. While replaying, the wait is guarded by a watchdog:
+// if the target reaches no event and retires no instructions within the timeout,
+// it is interrupted and the replay is aborted
+func waitForStop(ctx *processContext, waitStatus *syscall.WaitStatus) error {
+	timeout := ctx.options.watchdogTimeout
+
+	if !isReplaying(ctx) || timeout <= 0 {
+		_, err := syscall.Wait4(ctx.pid, waitStatus, 0, nil)
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	instructionCount := getInstructionCount(ctx)
+
+	for time.Now().Before(deadline) {
+		wpid, err := syscall.Wait4(ctx.pid, waitStatus, syscall.WNOHANG, nil)
+		if err != nil || wpid == ctx.pid {
+			return err
+		}
+
+		time.Sleep(watchdogPollInterval)
+
+		// the target is still computing, extend the deadline
+		if time.Now().After(deadline) && ctx.instructionCounter != nil {
+			if currentCount := getInstructionCount(ctx); currentCount != instructionCount {
+				instructionCount = currentCount
+				deadline = time.Now().Add(timeout)
+			}
+		}
+	}
+
+	// interrupt the hung target, the stop signal is suppressed on the next resume
+	err := syscall.Kill(ctx.pid, syscall.SIGSTOP)
+	utils.Must(err)
+
+	_, err = syscall.Wait4(ctx.pid, waitStatus, 0, nil)
+	utils.Must(err)
+
+	ctx.replayedCheckpoints = nil
+	ctx.replayedSignals = nil
+
+	err = fmt.Errorf("replay aborted: no progress within %v, target stuck at %v", timeout, describeLocation(ctx))
+	logger.Error("%v", err)
+
+	return err
+}
+
+// Describes the current location of the target (source line if available)
+func describeLocation(ctx *processContext) string {
+	regs := getRegs(ctx, false)
+
+	location := fmt.Sprintf("ip %#x", regs.Rip)
+
+	if line, file, fn, err := ctx.dwarfData.PCToLine(regs.Rip); err == nil {
+		location = fmt.Sprintf("%s, line %d in %s (func %v)", location, line, filepath.Base(file), fn.Name())
+	} else if fn := ctx.dwarfData.PCToFunc(regs.Rip); fn != nil {
+		location = fmt.Sprintf("%s (func %v)", location, fn.Name())
+	} else {
+		location = fmt.Sprintf("%s (outside of debugged code)", location)
+	}
+
+	if len(ctx.cpointData) > 0 {
+		location = fmt.Sprintf("%s, last event: %v", location, ctx.cpointData[len(ctx.cpointData)-1].opName)
+	}
+
+	return location
+}
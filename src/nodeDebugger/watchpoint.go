@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/nodeDebugger/dwarf"
+)
+
+const (
+	PTRACE_PEEKUSR = 3
+	PTRACE_POKEUSR = 6
+
+	USER_DEBUGREG_OFFSET = 848 // offset of the debug registers in struct user
+	DEBUG_REGISTERS      = 4   // address registers DR0-DR3
+	DR6_HIT_MASK         = 0xf // bits of DR6 telling which address register was hit
+	DR7_WRITE            = 1   // read/write bits of an address register, break on data writes
+)
+
+// encodings of the watched lengths in DR7
+var debugRegisterLengths = map[uint64]uint64{1: 0, 2: 1, 4: 3, 8: 2}
+
+// A range of memory watched by an address register, aligned to its length
+type watchedRange struct {
+	address uint64
+	length  uint64
+}
+
+// A write to the watched memory, recorded while re-executing
+type watchHit struct {
+	instructionCount uint64 // instructions retired when the writing instruction retired
+	oldValue         string
+	newValue         string
+}
+
+// The memory of a variable watched for writes by lastwrite
+type watchpoint struct {
+	expression string
+	variable   *dwarf.Variable
+	address    uint64
+	value      string // value after the last write
+	hits       []watchHit
+}
+
+// Reports the last write to a variable before the current position, within the interval since the last checkpoint.
+// The target is restored to the checkpoint and re-executed up to the current instruction with a hardware watchpoint
+// on the memory of the variable, then restored again and re-executed up to the instruction of the last write.
+// The memory is located at the current position: a local variable is watched at its address in the current frame
+func lastWrite(ctx *processContext, expression string) error {
+	if ctx.instructionCounter == nil {
+		return fmt.Errorf("lastwrite requires instruction counting, which is unavailable")
+	}
+
+	if len(ctx.cpointData) == 0 {
+		return fmt.Errorf("no checkpoint recorded to re-execute from")
+	}
+
+	current := getInstructionCount(ctx)
+	checkpoint := ctx.cpointData[len(ctx.cpointData)-1]
+
+	if current == checkpoint.instructionCount {
+		return fmt.Errorf("target is at its last checkpoint (%v), roll back to an earlier one to find writes before it", checkpoint.opName)
+	}
+
+	variable, address, err := locateExpression(ctx, strings.TrimSpace(expression))
+	if err != nil {
+		return err
+	}
+
+	length := variable.ByteSize()
+	if variable.IsArray() {
+		length, err = variable.ArrayByteSize()
+		if err != nil {
+			return err
+		}
+	}
+
+	ranges, err := watchedRanges(address, uint64(length))
+	if err != nil {
+		return fmt.Errorf("cannot watch %s: %v", expression, err)
+	}
+
+	err = restoreForReverseExecution(ctx, checkpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	watch := &watchpoint{expression: expression, variable: variable, address: address}
+	watch.value = fmt.Sprint(readVariable(ctx, variable, address))
+
+	ctx.watchpoint = watch
+
+	err = armWatchpoint(ctx, ranges)
+	if err == nil {
+		_, err = replayToInstructionCount(ctx, current, false)
+	}
+
+	ctx.watchpoint = nil
+	disarmWatchpoint(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if len(watch.hits) == 0 {
+		logger.Info("%s not written since the last checkpoint (%v), value: %s", expression, checkpoint.opName, watch.value)
+		return nil
+	}
+
+	// the re-execution is deterministic, the last write is made at the same instruction again
+	err = restoreForReverseExecution(ctx, checkpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	last := watch.hits[len(watch.hits)-1]
+
+	_, err = replayToInstructionCount(ctx, last.instructionCount-1, false)
+	if err != nil {
+		return err
+	}
+
+	regs := getRegs(ctx, false)
+	function := ctx.dwarfData.PCToFunc(regs.Rip)
+	line, file, err := ctx.dwarfData.PCToLineContaining(regs.Rip)
+
+	switch {
+	case function == nil || err != nil:
+		logger.Info("%s last written by the instruction at %#x, without debug info", expression, regs.Rip)
+	default:
+		logger.Info("%s last written by the instruction at %#x: line %d, file %v (func %v)", expression, regs.Rip, line, filepath.Base(file), function.Name())
+	}
+
+	logger.Info("old value: %s, new value: %s (the last of %d writes since the checkpoint %v)", last.oldValue, last.newValue, len(watch.hits), checkpoint.opName)
+	logger.Info("stopped at the writing instruction, before it is executed")
+
+	return nil
+}
+
+// Splits the memory into ranges aligned to their length, as address registers watch them
+func watchedRanges(address uint64, length uint64) ([]watchedRange, error) {
+	ranges := make([]watchedRange, 0)
+
+	for end := address + length; address < end; {
+		size := uint64(8)
+		for address%size != 0 || address+size > end {
+			size /= 2
+		}
+
+		ranges = append(ranges, watchedRange{address, size})
+		address += size
+	}
+
+	if len(ranges) > DEBUG_REGISTERS {
+		return nil, fmt.Errorf("%d bytes need %d debug registers, %d are available, watch an element instead", length, len(ranges), DEBUG_REGISTERS)
+	}
+
+	return ranges, nil
+}
+
+// Sets the debug registers of the target to trap after writes to the ranges
+func armWatchpoint(ctx *processContext, ranges []watchedRange) error {
+	control := uint64(0)
+
+	for index, watched := range ranges {
+		err := setDebugRegister(ctx, index, watched.address)
+		if err != nil {
+			return err
+		}
+
+		control |= 1 << (2 * index) // local enable
+		control |= (DR7_WRITE | debugRegisterLengths[watched.length]<<2) << (16 + 4*index)
+	}
+
+	err := setDebugRegister(ctx, 6, 0)
+	if err != nil {
+		return err
+	}
+
+	return setDebugRegister(ctx, 7, control)
+}
+
+func disarmWatchpoint(ctx *processContext) {
+	err := setDebugRegister(ctx, 7, 0)
+	if err != nil {
+		logger.Warn("cannot disarm the watchpoint: %v", err)
+	}
+}
+
+// Records a write to the watched memory, if the trap the target stopped with was raised by the watchpoint
+func recordWatchpointHit(ctx *processContext) bool {
+	if ctx.watchpoint == nil {
+		return false
+	}
+
+	status, err := getDebugRegister(ctx, 6)
+	if err != nil || status&DR6_HIT_MASK == 0 {
+		return false
+	}
+
+	setDebugRegister(ctx, 6, 0)
+
+	watch := ctx.watchpoint
+	value := fmt.Sprint(readVariable(ctx, watch.variable, watch.address))
+
+	watch.hits = append(watch.hits, watchHit{
+		instructionCount: getInstructionCount(ctx),
+		oldValue:         watch.value,
+		newValue:         value,
+	})
+	watch.value = value
+
+	logger.Debug("%s written: %s -> %s", watch.expression, watch.hits[len(watch.hits)-1].oldValue, value)
+
+	return true
+}
+
+func getDebugRegister(ctx *processContext, index int) (uint64, error) {
+	var value uint64
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, PTRACE_PEEKUSR, uintptr(ctx.pid), uintptr(USER_DEBUGREG_OFFSET+8*index), uintptr(unsafe.Pointer(&value)), 0, 0)
+	if errno != 0 {
+		return 0, fmt.Errorf("cannot read debug register %d: %v", index, errno)
+	}
+
+	return value, nil
+}
+
+func setDebugRegister(ctx *processContext, index int, value uint64) error {
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, PTRACE_POKEUSR, uintptr(ctx.pid), uintptr(USER_DEBUGREG_OFFSET+8*index), uintptr(value), 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("cannot write debug register %d: %v", index, errno)
+	}
+
+	return nil
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Held while a command of the console or a request of the http api is executed, so they take turns
+// at the nodes and the checkpoint log
+var sessionMutex sync.Mutex
+
+// A node command requested over the http api. Nodes is a target selector without the @ (all nodes if empty),
+// Wait the seconds to wait for the results (progress commands are only sent if 0)
+type apiCommandRequest struct {
+	Nodes     string  `json:"nodes"`
+	Command   string  `json:"command"`
+	Location  string  `json:"location"`  // for breakpoints
+	Condition string  `json:"condition"` // for breakpoints, optional
+	Wait      float64 `json:"wait"`
+}
+
+// The results of a node command, a nil result for each node not reporting one in time
+type apiCommandReply struct {
+	Results []command.JSONResult `json:"results"`
+	Error   string               `json:"error,omitempty"` // the nodes that could not be given the command or did not report
+}
+
+type apiRollbackRequest struct {
+	Checkpoint string `json:"checkpoint"` // id or label of the checkpoint or global checkpoint
+}
+
+// Serves the http api driving the session, for CI systems and other tools. Every request is executed
+// between the commands of the console, clients must give the session token of a session that has one
+func startAPI(address string) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/version", rpc.RequireToken(apiMethod(http.MethodGet, serveAPIVersion)))
+	mux.HandleFunc("/v1/nodes", rpc.RequireToken(apiMethod(http.MethodGet, serveAPINodes)))
+	mux.HandleFunc("/v1/command", rpc.RequireToken(apiMethod(http.MethodPost, serveAPICommand)))
+	mux.HandleFunc("/v1/breakpoints", rpc.RequireToken(apiMethod(http.MethodPost, serveAPIBreakpoint)))
+	mux.HandleFunc("/v1/continue", rpc.RequireToken(apiMethod(http.MethodPost, serveAPIProgress("continue"))))
+	mux.HandleFunc("/v1/step", rpc.RequireToken(apiMethod(http.MethodPost, serveAPIProgress("step"))))
+	mux.HandleFunc("/v1/backtrace", rpc.RequireToken(apiMethod(http.MethodGet, serveAPIBacktrace)))
+	mux.HandleFunc("/v1/checkpoints", rpc.RequireToken(serveAPICheckpoints))
+	mux.HandleFunc("/v1/rollback", rpc.RequireToken(apiMethod(http.MethodPost, serveAPIRollback)))
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		logger.Error("Cannot serve the http api at %v: %v", address, err)
+		return
+	}
+
+	logger.Info("HTTP api at http://%v/v1/", listener.Addr())
+
+	nodeconnection.RegisterFrontend("http-api")
+
+	go http.Serve(listener, mux)
+}
+
+// Refuses requests with another method than the handler serves
+func apiMethod(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != method {
+			writer.Header().Set("Allow", method)
+			apiError(writer, http.StatusMethodNotAllowed, fmt.Errorf("%v is requested with %v", request.URL.Path, method))
+			return
+		}
+
+		handler(writer, request)
+	}
+}
+
+func serveAPIVersion(writer http.ResponseWriter, request *http.Request) {
+	apiReply(writer, map[string]int{"protocol": rpc.PROTOCOL_VERSION})
+}
+
+func serveAPINodes(writer http.ResponseWriter, request *http.Request) {
+	apiReply(writer, nodeconnection.GetNodeStates())
+}
+
+// Runs any node command, as typed after the node id at the console: {"nodes": "0-3", "command": "p x"}
+func serveAPICommand(writer http.ResponseWriter, request *http.Request) {
+	var body apiCommandRequest
+	if !decodeAPIRequest(writer, request, &body) {
+		return
+	}
+
+	runAPICommand(writer, body.Nodes, body.Command, body.Wait)
+}
+
+// Sets a breakpoint: {"nodes": "all", "location": "main.c:30", "condition": "i > 3"}
+func serveAPIBreakpoint(writer http.ResponseWriter, request *http.Request) {
+	var body apiCommandRequest
+	if !decodeAPIRequest(writer, request, &body) {
+		return
+	}
+
+	line := "b " + body.Location
+	if len(body.Condition) > 0 {
+		line += " if " + body.Condition
+	}
+
+	runAPICommand(writer, body.Nodes, line, body.Wait)
+}
+
+// Continues or steps the nodes: {"nodes": "all", "wait": 30} returns once the nodes stop, or after 30 seconds
+func serveAPIProgress(line string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var body apiCommandRequest
+		if !decodeAPIRequest(writer, request, &body) {
+			return
+		}
+
+		runAPICommand(writer, body.Nodes, line, body.Wait)
+	}
+}
+
+// The call stacks of the nodes: /v1/backtrace?nodes=0-3
+func serveAPIBacktrace(writer http.ResponseWriter, request *http.Request) {
+	runAPICommand(writer, request.URL.Query().Get("nodes"), "bt", 0)
+}
+
+// The checkpoint log with GET, a global checkpoint taken with POST. The global checkpoint is recorded once all
+// nodes have taken their snapshots, it is in the log of a later GET
+func serveAPICheckpoints(writer http.ResponseWriter, request *http.Request) {
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+
+	switch request.Method {
+	case http.MethodGet:
+		apiReply(writer, checkpointmanager.GetCheckpointLog())
+	case http.MethodPost:
+		apiReply(writer, map[string]string{"snapshot": handleGlobalCheckpoint()})
+	default:
+		writer.Header().Set("Allow", "GET, POST")
+		apiError(writer, http.StatusMethodNotAllowed, errors.New("checkpoints are listed with GET and taken with POST"))
+	}
+}
+
+// Rolls all nodes back to a checkpoint: {"checkpoint": "<id or label>"}. Unlike at the console, it is not confirmed.
+// Replies with the checkpoint each node was rolled back to
+func serveAPIRollback(writer http.ResponseWriter, request *http.Request) {
+	var body apiRollbackRequest
+	if !decodeAPIRequest(writer, request, &body) {
+		return
+	}
+
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+
+	pendingRollback := checkpointmanager.SubmitForRollback(body.Checkpoint)
+	if pendingRollback == nil {
+		apiError(writer, http.StatusConflict, fmt.Errorf("cannot roll back to %v, the log of the orchestrator says why", body.Checkpoint))
+		return
+	}
+
+	restored := make(map[int]string, len(*pendingRollback))
+	for nodeId, checkpoint := range *pendingRollback {
+		restored[int(nodeId)] = checkpoint.Id
+	}
+
+	if err := nodeconnection.ExecutePendingRollback(); err != nil {
+		apiError(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	publishCheckpointLog()
+
+	apiReply(writer, map[string]map[int]string{"restored": restored})
+}
+
+// Runs a node command on the selected nodes and replies with their results
+func runAPICommand(writer http.ResponseWriter, selector string, line string, waitSeconds float64) {
+	if len(selector) == 0 {
+		selector = command.SELECTOR_ALL
+	}
+
+	cmd := command.ParseNodeCommand(line)
+	if cmd == nil {
+		apiError(writer, http.StatusBadRequest, fmt.Errorf("%q is not a node command", line))
+		return
+	}
+
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+
+	if checkpointmanager.IsReadOnly() {
+		replyRecordedResults(writer, selector, cmd)
+		return
+	}
+
+	nodeIds, err := command.SelectNodes(selector, nodeconnection.GetRegisteredIds())
+	if err == nil && len(nodeIds) == 0 {
+		err = fmt.Errorf("no nodes selected by %v", selector)
+	}
+	if err != nil {
+		apiError(writer, http.StatusBadRequest, err)
+		return
+	}
+
+	// other commands report their results right away, they are always waited for
+	wait := time.Duration(waitSeconds * float64(time.Second))
+	if wait == 0 && !cmd.IsProgressCommand() {
+		wait = nodeconnection.FANOUT_TIMEOUT
+	}
+
+	cmds := make([]*command.Command, 0, len(nodeIds))
+	for _, nodeId := range nodeIds {
+		nodeCommand := *cmd
+		nodeCommand.NodeId = nodeId
+
+		cmds = append(cmds, &nodeCommand)
+	}
+
+	results, err := nodeconnection.HandleAndWait(cmds, wait)
+
+	reply := apiCommandReply{Results: make([]command.JSONResult, 0, len(cmds))}
+	for _, nodeCommand := range cmds {
+		reply.Results = append(reply.Results, command.JSONResult{
+			Node:     nodeCommand.NodeId,
+			Command:  nodeCommand.Name(),
+			Argument: nodeCommand.Argument,
+			Result:   results[nodeCommand.NodeId],
+		})
+	}
+
+	// progress commands not waited for are only sent, the nodes not having stopped yet are no error
+	if err != nil && wait > 0 {
+		reply.Error = err.Error()
+	}
+
+	apiReply(writer, reply)
+}
+
+// Replies with the latest recorded results of the selected nodes matching the command, in a session opened in
+// the viewer
+func replyRecordedResults(writer http.ResponseWriter, selector string, cmd *command.Command) {
+	if cmd.IsProgressCommand() {
+		apiError(writer, http.StatusConflict, fmt.Errorf("%v is not available in read-only mode", cmd.Name()))
+		return
+	}
+
+	recordedIds := make([]int, 0)
+	for _, state := range nodeconnection.GetNodeStates() {
+		recordedIds = append(recordedIds, state.Id)
+	}
+
+	nodeIds, err := command.SelectNodes(selector, recordedIds)
+	if err != nil {
+		apiError(writer, http.StatusBadRequest, err)
+		return
+	}
+
+	reply := apiCommandReply{Results: make([]command.JSONResult, 0, len(nodeIds))}
+	for _, nodeId := range nodeIds {
+		reply.Results = append(reply.Results, command.JSONResult{
+			Node:     nodeId,
+			Command:  cmd.Name(),
+			Argument: cmd.Argument,
+			Result:   lastRecordedResult(nodeId, cmd),
+		})
+	}
+
+	apiReply(writer, reply)
+}
+
+// Decodes the json body of a request, replying with the error if it is not valid
+func decodeAPIRequest(writer http.ResponseWriter, request *http.Request, body any) bool {
+	err := json.NewDecoder(http.MaxBytesReader(writer, request.Body, 1<<16)).Decode(body)
+	if err != nil {
+		apiError(writer, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+
+	return true
+}
+
+func apiReply(writer http.ResponseWriter, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(writer).Encode(value)
+	if err != nil {
+		logger.Debug("Cannot encode the reply of the http api: %v", err)
+	}
+}
+
+func apiError(writer http.ResponseWriter, status int, err error) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+
+	json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+}
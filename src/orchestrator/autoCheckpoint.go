@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// Schedule of the global checkpoints taken automatically, so that a recent rollback point is always at hand.
+// Checkpoints are taken every interval and every so many events. Zero values take no checkpoints
+var autoCheckpoints = struct {
+	sync.Mutex
+	interval   time.Duration // time between the checkpoints
+	events     int           // MPI events recorded between the checkpoints
+	eventCount int           // MPI events recorded since the last checkpoint
+	schedule   int           // incremented on every change of the schedule, stopping the timer of the previous one
+}{}
+
+// Replaces the schedule of automatic global checkpoints
+func scheduleAutoCheckpoints(interval time.Duration, events int) {
+	autoCheckpoints.Lock()
+	defer autoCheckpoints.Unlock()
+
+	autoCheckpoints.interval = interval
+	autoCheckpoints.events = events
+	autoCheckpoints.eventCount = 0
+	autoCheckpoints.schedule++
+
+	if interval > 0 {
+		go runAutoCheckpointTimer(autoCheckpoints.schedule, interval)
+	}
+}
+
+// Takes a checkpoint every interval, until the schedule is changed
+func runAutoCheckpointTimer(schedule int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		autoCheckpoints.Lock()
+		current := autoCheckpoints.schedule == schedule
+		// nodes stopped since the last checkpoint are at the same state, nothing new to checkpoint
+		progressed := autoCheckpoints.eventCount > 0
+		if current && progressed {
+			autoCheckpoints.eventCount = 0
+		}
+		autoCheckpoints.Unlock()
+
+		if !current {
+			return
+		}
+
+		if progressed {
+			takeAutoCheckpoint()
+		}
+	}
+}
+
+// Counts an MPI event reported by a node, taking a checkpoint once the events of the schedule are recorded
+func countAutoCheckpointEvent(callRecord rpc.MPICallRecord) {
+	if callRecord.OpName == mpi.SNAPSHOT_EVENT {
+		return
+	}
+
+	autoCheckpoints.Lock()
+	autoCheckpoints.eventCount++
+
+	due := autoCheckpoints.events > 0 && autoCheckpoints.eventCount >= autoCheckpoints.events
+	if due {
+		autoCheckpoints.eventCount = 0
+	}
+	autoCheckpoints.Unlock()
+
+	if due {
+		// the nodes are told asynchronously, the reporting node waits for its event to be collected
+		go takeAutoCheckpoint()
+	}
+}
+
+// Takes a global checkpoint, unless the previous one is still waiting for the snapshots of the nodes
+func takeAutoCheckpoint() {
+	if len(nodeconnection.GetRegisteredIds()) == 0 {
+		return
+	}
+
+	pendingGlobalCheckpoints.Lock()
+	pending := len(pendingGlobalCheckpoints.nodeIds) > 0
+	pendingGlobalCheckpoints.Unlock()
+
+	if pending {
+		logger.Debug("Skipping automatic global checkpoint, the previous one is not complete")
+		return
+	}
+
+	logger.Verbose("Taking automatic global checkpoint")
+	handleGlobalCheckpoint()
+}
+
+// Shows the schedule of automatic global checkpoints (checkpoint auto), turns them off (checkpoint auto off)
+// or changes it (checkpoint auto [<n>s] [<m>]: every n seconds and/or every m MPI events)
+func handleAutoCheckpoint(cmd *command.Command) {
+	argument := cmd.Argument.(string)
+
+	switch argument {
+	case "":
+		printAutoCheckpointSchedule()
+		return
+	case "off":
+		scheduleAutoCheckpoints(0, 0)
+		logger.Info("Automatic global checkpoints turned off")
+		return
+	}
+
+	var interval time.Duration
+	var events int
+
+	for _, field := range strings.Fields(argument) {
+		if strings.HasSuffix(field, "s") {
+			value, err := strconv.Atoi(strings.TrimSuffix(field, "s"))
+			if err != nil || value < 1 {
+				logger.Warn("Invalid checkpoint interval: %v", field)
+				return
+			}
+			interval = time.Duration(value) * time.Second
+		} else {
+			value, err := strconv.Atoi(field)
+			if err != nil || value < 1 {
+				logger.Warn("Invalid number of MPI events between checkpoints: %v", field)
+				return
+			}
+			events = value
+		}
+	}
+
+	scheduleAutoCheckpoints(interval, events)
+	printAutoCheckpointSchedule()
+}
+
+func printAutoCheckpointSchedule() {
+	autoCheckpoints.Lock()
+	defer autoCheckpoints.Unlock()
+
+	switch {
+	case autoCheckpoints.interval > 0 && autoCheckpoints.events > 0:
+		logger.Info("Global checkpoints are taken every %v or every %d MPI events", autoCheckpoints.interval, autoCheckpoints.events)
+	case autoCheckpoints.interval > 0:
+		logger.Info("Global checkpoints are taken every %v", autoCheckpoints.interval)
+	case autoCheckpoints.events > 0:
+		logger.Info("Global checkpoints are taken every %d MPI events", autoCheckpoints.events)
+	default:
+		logger.Info("Automatic global checkpoints are off")
+	}
+}
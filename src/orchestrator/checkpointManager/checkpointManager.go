@@ -2,7 +2,11 @@ package checkpointmanager
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ottmartens/cc-rev-db/logger"
 	"github.com/ottmartens/cc-rev-db/rpc"
@@ -23,6 +27,23 @@ type checkpointRecord struct {
 	matchingEvent   *checkpointRecord // for send events, a link to the corresponding message receive event, and vice versa
 	Tag             *int              // The mpi message tag, if present
 	CurrentLocation bool
+	Instructions    uint64    // instructions retired by the node before the event (0 if unavailable)
+	Label           string    // name given to the checkpoint with checkpoint name, empty if unnamed
+	Location        string    // source location the operation was called from (file:line, empty if unknown)
+	Time            time.Time // wall clock time of the operation on the node
+	LogicalClock    int       // Lamport clock of the event, ordering the events of all nodes consistently with their messages
+	VectorClock     []int     // vector clock of the node with the event counted, by rank (nil if the wrapper keeps none)
+	Bytes           uint64    // bytes taken up by the checkpoint on the node
+	Pruned          bool      // whether the node released the checkpoint, it cannot be rolled back to
+
+	CompletionEventId *string // for nonblocking operations, the event completing the request (wait, test), once recorded
+	Collective        *int    // for collective operations, the index among the collectives of the node. Events of other nodes with the same index took part in the same collective
+
+	BufferHashes map[string]uint64 // hashes of the buffers selected with hash auto at the checkpoint, by their variable or address
+
+	payload         []byte // contents of the message, for send operations, up to the payload cap of the node
+	payloadSize     int64  // bytes of the whole message
+	payloadRestHash uint64 // hash of the bytes of the message beyond the payload (0 if not truncated)
 }
 
 type CheckpointLog map[NodeId][]*checkpointRecord
@@ -30,23 +51,128 @@ type CheckpointLog map[NodeId][]*checkpointRecord
 // Data structure for maintaining a list of recorded checkpoints by node
 var checkpointLog = make(CheckpointLog)
 
+// Guards the checkpoint log and its records: the calls of the nodes are recorded on the goroutines of their rpc
+// connections, while the dashboard, the api and the web interface serialize the log on their own
+var logLock sync.RWMutex
+
+// Returns a copy of the checkpoint log, safe to serialize while the nodes record further checkpoints
 func GetCheckpointLog() CheckpointLog {
-	return checkpointLog
+	logLock.RLock()
+	defer logLock.RUnlock()
+
+	copied := make(CheckpointLog, len(checkpointLog))
+	for nodeId, nodeCheckpoints := range checkpointLog {
+		copied[nodeId] = make([]*checkpointRecord, len(nodeCheckpoints))
+		for i, checkpoint := range nodeCheckpoints {
+			copied[nodeId][i] = checkpoint.copy()
+		}
+	}
+
+	return copied
+}
+
+// Copies the record along with its parameters, the link to the matching event is left pointing into the log
+func (record *checkpointRecord) copy() *checkpointRecord {
+	copied := *record
+
+	copied.parameters = make(map[string]string, len(record.parameters))
+	for name, value := range record.parameters {
+		copied.parameters[name] = value
+	}
+
+	return &copied
 }
 
 var nodeRanks = make(map[NodeId]*int)
 
+// Returns the MPI rank of the node, nil if it has not reported it yet
+func GetNodeRank(nodeId NodeId) *int {
+	return nodeRanks[nodeId]
+}
+
+// Exchanges the ids of two nodes in what is recorded for them, as the nodes are renumbered by their ranks
+func SwapNodeIds(nodeId NodeId, otherId NodeId) {
+	logLock.Lock()
+	defer logLock.Unlock()
+
+	swapped := map[NodeId]NodeId{nodeId: otherId, otherId: nodeId}
+
+	logs := make(CheckpointLog)
+	ranks := make(map[NodeId]*int)
+	layouts := make(map[NodeId]rpc.MemoryLayoutRecord)
+	fingerprints := make(map[NodeId]rpc.TargetFingerprint)
+
+	for id := range swapped {
+		if nodeCheckpoints, found := checkpointLog[id]; found {
+			logs[id] = nodeCheckpoints
+			delete(checkpointLog, id)
+		}
+		if rank, found := nodeRanks[id]; found {
+			ranks[id] = rank
+			delete(nodeRanks, id)
+		}
+		if layout, found := memoryLayouts[id]; found {
+			layouts[id] = layout
+			delete(memoryLayouts, id)
+		}
+		if sessionFingerprint != nil {
+			if fingerprint, found := sessionFingerprint.Nodes[int(id)]; found {
+				fingerprints[id] = fingerprint
+				delete(sessionFingerprint.Nodes, int(id))
+			}
+		}
+	}
+
+	for id, nodeCheckpoints := range logs {
+		for _, checkpoint := range nodeCheckpoints {
+			checkpoint.nodeId = swapped[id]
+		}
+		checkpointLog[swapped[id]] = nodeCheckpoints
+	}
+	for id, rank := range ranks {
+		nodeRanks[swapped[id]] = rank
+	}
+	for id, layout := range layouts {
+		layout.NodeId = int(swapped[id])
+		memoryLayouts[swapped[id]] = layout
+	}
+	for id, fingerprint := range fingerprints {
+		fingerprint.NodeId = int(swapped[id])
+		sessionFingerprint.Nodes[int(swapped[id])] = fingerprint
+	}
+}
+
 func RecordCheckpoint(mpiRecord rpc.MPICallRecord) {
-	nodeId := NodeId(mpiRecord.NodeId)
-	opName := mpiRecord.OpName
+	logLock.Lock()
+	defer logLock.Unlock()
+
+	record := newCheckpointRecord(NodeId(mpiRecord.NodeId), mpiRecord.Id, mpiRecord.OpName, mpiRecord.Parameters)
+	record.Instructions = mpiRecord.InstructionCount
+	record.payload = mpiRecord.Payload
+	record.payloadSize = mpiRecord.PayloadSize
+	record.payloadRestHash = mpiRecord.PayloadRestHash
+	record.Location = mpiRecord.Location
+	record.Time = mpiRecord.Time
+	record.Bytes = mpiRecord.CheckpointBytes
+	record.BufferHashes = mpiRecord.BufferHashes
+	record.VectorClock = mpiRecord.VectorClock
 
+	// Link the matching event from other party, if already recorded
+	record.findAndLinkMatchingMessage()
+
+	record.linkCompletedRequests()
+
+	appendToLog(record)
+}
+
+func newCheckpointRecord(nodeId NodeId, id string, opName string, parameters map[string]string) *checkpointRecord {
 	record := checkpointRecord{
-		Id:            mpiRecord.Id,
+		Id:            id,
 		nodeId:        nodeId,
 		OpName:        opName,
 		IsSend:        mpi.SEND_EVENTS[opName],
 		CanBeRestored: mpi.RESTORABLE_OPERATIONS[opName],
-		parameters:    mpiRecord.Parameters,
+		parameters:    parameters,
 	}
 
 	if nodeRanks[nodeId] == nil {
@@ -56,14 +182,22 @@ func RecordCheckpoint(mpiRecord rpc.MPICallRecord) {
 
 	record.Tag = tryEvaluateIntegerParam("tag", record)
 
-	// Link the matching event from other party, if already recorded
-	record.findAndLinkMatchingMessage()
+	return &record
+}
+
+func appendToLog(record *checkpointRecord) {
+	if checkpointLog[record.nodeId] == nil {
+		checkpointLog[record.nodeId] = make([]*checkpointRecord, 0)
+	}
 
-	if checkpointLog[nodeId] == nil {
-		checkpointLog[nodeId] = make([]*checkpointRecord, 0)
+	if mpi.COLLECTIVE_OPERATIONS[record.OpName] {
+		record.Collective = new(int)
+		*record.Collective = countCollectives(record.nodeId)
 	}
 
-	checkpointLog[nodeId] = append(checkpointLog[nodeId], &record)
+	record.LogicalClock = record.nextLogicalClock()
+
+	checkpointLog[record.nodeId] = append(checkpointLog[record.nodeId], record)
 }
 
 func findCheckpointById(checkpointId string) *checkpointRecord {
@@ -77,17 +211,38 @@ func findCheckpointById(checkpointId string) *checkpointRecord {
 	return nil
 }
 
+// Returns the ids of the checkpoints recorded for the node
+func NodeCheckpointIds(nodeId NodeId) []string {
+	ids := make([]string, 0, len(checkpointLog[nodeId]))
+	for _, checkpoint := range checkpointLog[nodeId] {
+		ids = append(ids, checkpoint.Id)
+	}
+	return ids
+}
+
+// Lists the recorded checkpoints of each node with their metadata: id, label, logical clock,
+// wall clock time, source location and the event the checkpoint was taken at
 func ListCheckpoints() {
-	for nodeId, nodeCheckpoints := range checkpointLog {
-		var str string
+	nodeIds := make([]int, 0, len(checkpointLog))
+	for nodeId := range checkpointLog {
+		nodeIds = append(nodeIds, int(nodeId))
+	}
+	sort.Ints(nodeIds)
+
+	for _, nodeId := range nodeIds {
+		if rank := nodeRanks[NodeId(nodeId)]; rank != nil {
+			logger.Info("Node %d (rank %d) checkpoints:", nodeId, *rank)
+		} else {
+			logger.Info("Node %d checkpoints:", nodeId)
+		}
 
-		for _, record := range nodeCheckpoints {
-			str = fmt.Sprintf("%s{%s - %s}", str, record.OpName, record.Id)
-			str = fmt.Sprintf("%s,", str)
+		for _, record := range checkpointLog[NodeId(nodeId)] {
+			logger.Info("  %v", record.describe())
 		}
+	}
 
-		logger.Info("Node %d checkpoints:", nodeId)
-		logger.Info(str)
+	if len(globalCheckpoints) > 0 {
+		logger.Info("Global checkpoints: %v", globalCheckpoints)
 	}
 }
 
@@ -95,16 +250,24 @@ func ListCheckpoints() {
 func (record *checkpointRecord) findAndLinkMatchingMessage() {
 	var matchingRecord *checkpointRecord
 
-	switch record.OpName {
-	case mpi.MPI_OPS[mpi.OP_SEND]:
+	if record.faultSuppressedCall() {
+		return
+	}
+
+	switch {
+	case mpi.SEND_EVENTS[record.OpName]:
 		matchingNodeRank, _ := strconv.Atoi(record.parameters["dest"])
 
-		matchingRecord = getFirstUnmatchedMessage(matchingNodeRank, mpi.MPI_OPS[mpi.OP_RECV], record.Tag)
+		matchingRecord = getFirstUnmatchedMessage(matchingNodeRank, mpi.RECEIVE_EVENTS, record.Tag, record.NodeRank)
 
-	case mpi.MPI_OPS[mpi.OP_RECV]:
-		matchingNodeRank, _ := strconv.Atoi(record.parameters["source"])
+	case mpi.RECEIVE_EVENTS[record.OpName]:
+		matchingNodeRank, err := strconv.Atoi(record.parameters["source"])
 
-		matchingRecord = getFirstUnmatchedMessage(matchingNodeRank, mpi.MPI_OPS[mpi.OP_SEND], record.Tag)
+		if err == nil && matchingNodeRank < 0 {
+			matchingRecord = record.firstUnmatchedSendTo()
+		} else {
+			matchingRecord = getFirstUnmatchedMessage(matchingNodeRank, mpi.SEND_EVENTS, record.Tag, nil)
+		}
 	}
 
 	if matchingRecord != nil {
@@ -118,37 +281,134 @@ func (record *checkpointRecord) findAndLinkMatchingMessage() {
 
 }
 
-// Finds the first message on a node with specified operation name
-func getFirstUnmatchedMessage(nodeRank int, opName string, tag *int) *checkpointRecord {
-	var nodeId *NodeId
-
-	for nId, nRank := range nodeRanks {
-		if nRank != nil && *nRank == nodeRank {
-			nodeId = &nId
-			break
-		}
-	}
-
-	if nodeId == nil {
+// Finds the first message on a node with one of the specified operation names.
+// For a send, the rank of the sender excludes receives naming another source
+func getFirstUnmatchedMessage(nodeRank int, opNames map[string]bool, tag *int, senderRank *int) *checkpointRecord {
+	nodeId, found := nodeIdOfRank(nodeRank)
+	if !found {
 		return nil
 	}
 
-	nodeCheckpoints := checkpointLog[*nodeId]
+	nodeCheckpoints := checkpointLog[nodeId]
 	if nodeCheckpoints == nil {
 		return nil
 	}
 
 	for _, checkpoint := range nodeCheckpoints {
-		if checkpoint.matchingEvent != nil || checkpoint.CurrentLocation {
+		if checkpoint.matchingEvent != nil || checkpoint.CurrentLocation || checkpoint.faultSuppressedCall() {
 			continue
 		}
-		if checkpoint.OpName == opName && tagsMatch(tag, checkpoint.Tag) {
+		if senderRank != nil && !checkpoint.receivesFrom(*senderRank) {
+			continue
+		}
+		if opNames[checkpoint.OpName] && tagsMatch(tag, checkpoint.Tag) {
 			return checkpoint
 		}
 	}
 	return nil
 }
 
+// Finds the node running the MPI rank
+func nodeIdOfRank(rank int) (NodeId, bool) {
+	for nodeId, nodeRank := range nodeRanks {
+		if nodeRank != nil && *nodeRank == rank {
+			return nodeId, true
+		}
+	}
+	return 0, false
+}
+
+// Finds the first unmatched send to the rank of a wildcard receive, checking the nodes in order
+func (record *checkpointRecord) firstUnmatchedSendTo() *checkpointRecord {
+	if record.NodeRank == nil {
+		return nil
+	}
+
+	nodeIds := make([]int, 0, len(checkpointLog))
+	for nodeId := range checkpointLog {
+		nodeIds = append(nodeIds, int(nodeId))
+	}
+	sort.Ints(nodeIds)
+
+	for _, nodeId := range nodeIds {
+		for _, checkpoint := range checkpointLog[NodeId(nodeId)] {
+			if checkpoint.matchingEvent != nil || checkpoint.CurrentLocation || checkpoint.faultSuppressedCall() || !checkpoint.IsSend {
+				continue
+			}
+			if checkpoint.parameters["dest"] == strconv.Itoa(*record.NodeRank) && tagsMatch(record.Tag, checkpoint.Tag) {
+				return checkpoint
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the number of collective operations recorded on the node
+func countCollectives(nodeId NodeId) int {
+	count := 0
+	for _, checkpoint := range checkpointLog[nodeId] {
+		if checkpoint.Collective != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Finds the events of the other nodes taking part in the same collective operation.
+// Collectives are assumed to run over MPI_COMM_WORLD, so the n-th collective of each node belongs together
+func (record *checkpointRecord) collectivePeers() []*checkpointRecord {
+	peers := make([]*checkpointRecord, 0)
+
+	if record.Collective == nil {
+		return peers
+	}
+
+	for nodeId, nodeCheckpoints := range checkpointLog {
+		if nodeId == record.nodeId {
+			continue
+		}
+
+		for _, checkpoint := range nodeCheckpoints {
+			if checkpoint.Collective != nil && *checkpoint.Collective == *record.Collective {
+				peers = append(peers, checkpoint)
+				break
+			}
+		}
+	}
+
+	return peers
+}
+
+// Links the events of the nonblocking operations completed by a completion event (wait, test) to it
+func (record *checkpointRecord) linkCompletedRequests() {
+	if !mpi.COMPLETION_OPERATIONS[record.OpName] || len(record.parameters["completes"]) == 0 {
+		return
+	}
+
+	for _, eventId := range strings.Split(record.parameters["completes"], ",") {
+		for _, checkpoint := range checkpointLog[record.nodeId] {
+			if checkpoint.Id == eventId {
+				checkpoint.CompletionEventId = &record.Id
+			}
+		}
+	}
+}
+
+// Returns whether an injected fault kept the MPI call from executing (dropped messages, error returns)
+func (record *checkpointRecord) faultSuppressedCall() bool {
+	fault := record.parameters["injected"]
+
+	return len(fault) > 0 && !strings.HasPrefix(fault, "delay")
+}
+
+// Returns whether a receive accepts messages from the rank, by its source (wildcard, or unknown)
+func (record *checkpointRecord) receivesFrom(rank int) bool {
+	source, err := strconv.Atoi(record.parameters["source"])
+
+	return err != nil || source < 0 || source == rank
+}
+
 func tagsMatch(tag1, tag2 *int) bool {
 	// tag retrieval has failed, might be false positive
 	if tag1 == nil || tag2 == nil {
@@ -178,9 +438,15 @@ func tryEvaluateIntegerParam(paramName string, record checkpointRecord) *int {
 }
 
 func RemoveSubsequentCheckpoints(cpoint checkpointRecord) {
+	logLock.Lock()
+	defer logLock.Unlock()
+
 	for nodeIndex, nodeCheckpoints := range checkpointLog {
 		for cpIndex, checkpoint := range nodeCheckpoints {
 			if checkpoint.Id == cpoint.Id {
+				unlinkRemovedCompletions(nodeCheckpoints[:cpIndex+1], nodeCheckpoints[cpIndex+1:])
+				unlinkReExecutedMessages(nodeCheckpoints[cpIndex:])
+
 				checkpointLog[nodeIndex] = checkpointLog[nodeIndex][:cpIndex+1]
 				if cpoint.matchingEvent != nil {
 					checkpointLog[nodeIndex][cpIndex].matchingEvent = nil
@@ -193,7 +459,35 @@ func RemoveSubsequentCheckpoints(cpoint checkpointRecord) {
 	}
 }
 
+// Unlinks the other parties of the messages re-executed from the checkpoint on, they are matched again once re-recorded.
+// Nodes rolled back alone keep the other parties of their messages
+func unlinkReExecutedMessages(reExecuted []*checkpointRecord) {
+	for _, checkpoint := range reExecuted {
+		if checkpoint.matchingEvent != nil && checkpoint.matchingEvent.matchingEvent == checkpoint {
+			checkpoint.matchingEvent.matchingEvent = nil
+			checkpoint.matchingEvent.MatchingEventId = nil
+		}
+	}
+}
+
+// Unlinks the nonblocking operations kept from the completion events removed
+func unlinkRemovedCompletions(kept []*checkpointRecord, removed []*checkpointRecord) {
+	removedIds := make(map[string]bool)
+	for _, checkpoint := range removed {
+		removedIds[checkpoint.Id] = true
+	}
+
+	for _, checkpoint := range kept {
+		if checkpoint.CompletionEventId != nil && removedIds[*checkpoint.CompletionEventId] {
+			checkpoint.CompletionEventId = nil
+		}
+	}
+}
+
 func RemoveCurrentCheckpointMarkersOnNode(nodeId NodeId) {
+	logLock.Lock()
+	defer logLock.Unlock()
+
 	for _, checkpoint := range checkpointLog[nodeId] {
 		if checkpoint.CurrentLocation {
 			checkpoint.CurrentLocation = false
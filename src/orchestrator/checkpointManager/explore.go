@@ -0,0 +1,113 @@
+package checkpointmanager
+
+import (
+	"sort"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// The position of a wildcard receive in the log of its node. Replays record the receives with new event ids,
+// so explored receives are told apart by their position
+type receivePosition struct {
+	nodeId NodeId
+	index  int
+}
+
+// Systematic exploration of the message interleavings of a window of history: the wildcard receives that do
+// not happen before the receive the window starts at. Runs differ in the sender of one receive at a time, and
+// only senders of valid reorderings a receive has not been matched with in any run yet are tried
+type Exploration struct {
+	start    receivePosition
+	observed map[receivePosition]map[int]bool // ranks of the senders each receive has been matched with
+	Runs     int                              // runs recorded, the original one included
+}
+
+func NewExploration(startReceiveId string) *Exploration {
+	receive := findCheckpointById(startReceiveId)
+	if receive == nil {
+		logger.Warn("Cannot find event with id %v", startReceiveId)
+		return nil
+	}
+
+	if !receive.isWildcardReceive() {
+		logger.Warn("Event %v is not a wildcard (MPI_ANY_SOURCE) receive", startReceiveId)
+		return nil
+	}
+
+	return &Exploration{
+		start:    receivePosition{receive.nodeId, checkpointIndex(receive.nodeId, receive.Id)},
+		observed: make(map[receivePosition]map[int]bool),
+	}
+}
+
+// Records the senders the receives of the window were matched with in the run the nodes stopped after
+func (e *Exploration) RecordRun() {
+	for _, receive := range e.window() {
+		if receive.matchingEvent == nil || receive.matchingEvent.NodeRank == nil {
+			continue
+		}
+
+		position := receivePosition{receive.nodeId, checkpointIndex(receive.nodeId, receive.Id)}
+
+		if e.observed[position] == nil {
+			e.observed[position] = make(map[int]bool)
+		}
+		e.observed[position][*receive.matchingEvent.NodeRank] = true
+	}
+
+	e.Runs++
+}
+
+// Finds the next receive of the window to replay with another message, and the send to take it from.
+// Receives are tried in the order of the nodes and their logs, each sender once per receive
+func (e *Exploration) NextReordering() (receiveId string, sendId string, found bool) {
+	for _, receive := range e.window() {
+		position := receivePosition{receive.nodeId, checkpointIndex(receive.nodeId, receive.Id)}
+
+		_, candidates := reorderCandidates(receive.Id)
+
+		for _, send := range candidates {
+			if send.NodeRank == nil || e.observed[position][*send.NodeRank] {
+				continue
+			}
+
+			// tried once, even if the replay does not get that far
+			if e.observed[position] == nil {
+				e.observed[position] = make(map[int]bool)
+			}
+			e.observed[position][*send.NodeRank] = true
+
+			return receive.Id, send.Id, true
+		}
+	}
+
+	return "", "", false
+}
+
+// Returns the wildcard receives of the window, ordered by node and by their position in the node log
+func (e *Exploration) window() []*checkpointRecord {
+	nodeCheckpoints := checkpointLog[e.start.nodeId]
+	if e.start.index >= len(nodeCheckpoints) {
+		return nil
+	}
+
+	start := nodeCheckpoints[e.start.index]
+
+	nodeIds := make([]int, 0, len(checkpointLog))
+	for nodeId := range checkpointLog {
+		nodeIds = append(nodeIds, int(nodeId))
+	}
+	sort.Ints(nodeIds)
+
+	receives := make([]*checkpointRecord, 0)
+
+	for _, nodeId := range nodeIds {
+		for _, checkpoint := range checkpointLog[NodeId(nodeId)] {
+			if checkpoint.isWildcardReceive() && (checkpoint == start || !happensBefore(checkpoint, start)) {
+				receives = append(receives, checkpoint)
+			}
+		}
+	}
+
+	return receives
+}
@@ -0,0 +1,156 @@
+package checkpointmanager
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+// Identifies the session, stored in exported sessions (nil for sessions exported before fingerprints were recorded)
+var sessionFingerprint *rpc.SessionFingerprint
+
+func SetSessionFingerprint(fingerprint rpc.SessionFingerprint) {
+	if fingerprint.Nodes == nil {
+		fingerprint.Nodes = make(map[int]rpc.TargetFingerprint)
+	}
+
+	sessionFingerprint = &fingerprint
+}
+
+// Returns the fingerprint of the session, nil if none was recorded
+func GetSessionFingerprint() *rpc.SessionFingerprint {
+	return sessionFingerprint
+}
+
+func RecordTargetFingerprint(fingerprint rpc.TargetFingerprint) {
+	if sessionFingerprint == nil {
+		SetSessionFingerprint(rpc.SessionFingerprint{})
+	}
+
+	sessionFingerprint.Nodes[fingerprint.NodeId] = fingerprint
+}
+
+// Returns the host a node reported to run on, empty if not reported yet
+func NodeHost(nodeId int) string {
+	if sessionFingerprint == nil {
+		return ""
+	}
+
+	return sessionFingerprint.Nodes[nodeId].Host
+}
+
+// Returns an error naming the nodes by the binary they run if their targets have different build-ids.
+// Nodes whose target has no build-id are not compared
+func CheckBinaryCompatibility() error {
+	if sessionFingerprint == nil {
+		return nil
+	}
+
+	nodesByBuildId := make(map[string][]int)
+
+	for nodeId, node := range sessionFingerprint.Nodes {
+		if len(node.BuildId) > 0 {
+			nodesByBuildId[node.BuildId] = append(nodesByBuildId[node.BuildId], nodeId)
+		}
+	}
+
+	if len(nodesByBuildId) <= 1 {
+		return nil
+	}
+
+	binaries := make([]string, 0, len(nodesByBuildId))
+
+	for buildId, nodeIds := range nodesByBuildId {
+		sort.Ints(nodeIds)
+		binaries = append(binaries, fmt.Sprintf("build-id %s on nodes %v", buildId, nodeIds))
+	}
+	sort.Strings(binaries)
+
+	return fmt.Errorf("ranks run different binaries: %s", strings.Join(binaries, ", "))
+}
+
+// Prints the session fingerprint: the target and the environment it was debugged in.
+// Values reported by the nodes are printed once if they agree, and per node otherwise
+func PrintFingerprint() {
+	if sessionFingerprint == nil {
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(writer, "\ncc-rev-db %s\n\n", sessionFingerprint.DebuggerVersion)
+	fmt.Fprintf(writer, "  target\t%s\n", sessionFingerprint.Target)
+
+	printNodeValues(writer, "build-id", func(node rpc.TargetFingerprint) string { return node.BuildId })
+	printNodeValues(writer, "compiler", func(node rpc.TargetFingerprint) string { return node.Producer })
+
+	fmt.Fprintf(writer, "  mpi\t%s\n", orUnknown(sessionFingerprint.MPIImplementation))
+
+	printNodeValues(writer, "mpi library", func(node rpc.TargetFingerprint) string { return node.MPILibrary })
+
+	fmt.Fprintf(writer, "  ranks\t%d\n", sessionFingerprint.Ranks)
+
+	printNodeValues(writer, "hosts", func(node rpc.TargetFingerprint) string { return node.Host })
+	printNodeValues(writer, "sources", sourceVerification)
+
+	printNodeValues(writer, "checkpoints", func(node rpc.TargetFingerprint) string { return node.CheckpointBackend })
+	printNodeValues(writer, "node debugger", func(node rpc.TargetFingerprint) string { return node.DebuggerVersion })
+
+	if !sessionFingerprint.Started.IsZero() {
+		fmt.Fprintf(writer, "  started\t%s\n", sessionFingerprint.Started.Format("2006-01-02 15:04:05 MST"))
+	}
+
+	writer.Flush()
+	fmt.Println()
+}
+
+func printNodeValues(writer *tabwriter.Writer, label string, value func(node rpc.TargetFingerprint) string) {
+	nodeIds := make([]int, 0, len(sessionFingerprint.Nodes))
+	distinct := make(map[string]bool)
+
+	for nodeId, node := range sessionFingerprint.Nodes {
+		nodeIds = append(nodeIds, nodeId)
+		distinct[value(node)] = true
+	}
+	sort.Ints(nodeIds)
+
+	if len(distinct) <= 1 {
+		nodeValue := ""
+		for v := range distinct {
+			nodeValue = v
+		}
+
+		fmt.Fprintf(writer, "  %s\t%s\n", label, orUnknown(nodeValue))
+		return
+	}
+
+	values := make([]string, 0, len(nodeIds))
+	for _, nodeId := range nodeIds {
+		values = append(values, fmt.Sprintf("node %d: %s", nodeId, orUnknown(value(sessionFingerprint.Nodes[nodeId]))))
+	}
+
+	fmt.Fprintf(writer, "  %s\t%s (differs across nodes)\n", label, strings.Join(values, ", "))
+}
+
+// Describes how the source files of a node compare with the checksums of its debug info
+func sourceVerification(node rpc.TargetFingerprint) string {
+	switch {
+	case len(node.StaleSources) > 0:
+		return fmt.Sprintf("CHANGED SINCE THE BUILD: %s", strings.Join(node.StaleSources, ", "))
+	case node.VerifiedSources > 0:
+		return fmt.Sprintf("%d files match the checksums of the binary", node.VerifiedSources)
+	default:
+		return "not verified, no checksums in the debug info"
+	}
+}
+
+func orUnknown(value string) string {
+	if len(value) == 0 {
+		return "unknown"
+	}
+	return value
+}
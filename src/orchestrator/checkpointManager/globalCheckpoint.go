@@ -0,0 +1,153 @@
+package checkpointmanager
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// A coordinated checkpoint of all nodes, restored as a whole
+type globalCheckpoint struct {
+	Id       string
+	Cut      RollbackMap // the checkpoint each node is restored to
+	InFlight []string    // sends in flight when the snapshot was taken, re-executed by restoring their senders before them
+}
+
+var globalCheckpoints = make([]*globalCheckpoint, 0)
+
+// Returns whether all the nodes have recorded their snapshot for the global checkpoint
+func SnapshotRecorded(snapshotId string, nodeIds []int) bool {
+	markers := snapshotMarkers(snapshotId)
+
+	for _, nodeId := range nodeIds {
+		if markers[NodeId(nodeId)] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Forms a consistent global cut of the snapshots recorded by the nodes, as in the Chandy-Lamport algorithm.
+// Instead of saving the messages in flight between the nodes (the channel state), their senders are restored
+// to before the send, so that the messages are sent again. Any further events depending on the re-executed
+// ones are rolled back along with them
+func RecordGlobalCheckpoint(snapshotId string) {
+	markers := snapshotMarkers(snapshotId)
+
+	checkpoint := &globalCheckpoint{
+		Id:       snapshotId,
+		Cut:      make(RollbackMap),
+		InFlight: make([]string, 0),
+	}
+
+	for nodeId, marker := range markers {
+		checkpoint.Cut[nodeId] = *marker
+	}
+
+	for nodeId, marker := range markers {
+		for _, send := range checkpointLog[nodeId][:checkpointIndex(nodeId, marker.Id)] {
+			if !send.IsSend || !send.inFlight(markers) {
+				continue
+			}
+
+			checkpoint.InFlight = append(checkpoint.InFlight, send.Id)
+
+			if isBefore(send.Id, checkpoint.Cut[nodeId].Id, nodeId) {
+				checkpoint.Cut[nodeId] = *send
+			}
+		}
+	}
+
+	closeCut(checkpoint.Cut)
+
+	globalCheckpoints = append(globalCheckpoints, checkpoint)
+
+	logger.Info("Recorded global checkpoint %v, restore it with r %v", checkpoint.Id, checkpoint.Id)
+	printGlobalCheckpoint(checkpoint)
+}
+
+// Returns whether the message of a send had not been received by the time its receiver recorded its snapshot
+func (send *checkpointRecord) inFlight(markers map[NodeId]*checkpointRecord) bool {
+	if send.faultSuppressedCall() {
+		return false
+	}
+
+	if send.matchingEvent != nil {
+		receiverMarker := markers[send.matchingEvent.nodeId]
+		return receiverMarker != nil && isBefore(receiverMarker.Id, send.matchingEvent.Id, send.matchingEvent.nodeId)
+	}
+
+	dest, err := strconv.Atoi(send.parameters["dest"])
+	if err != nil {
+		return false
+	}
+
+	// messages to exited nodes are not replayed
+	receiverId, found := nodeIdOfRank(dest)
+	return found && markers[receiverId] != nil
+}
+
+// Returns the snapshot events recorded for a global checkpoint so far, by node
+func snapshotMarkers(snapshotId string) map[NodeId]*checkpointRecord {
+	markers := make(map[NodeId]*checkpointRecord)
+
+	for nodeId, nodeCheckpoints := range checkpointLog {
+		for _, checkpoint := range nodeCheckpoints {
+			if checkpoint.OpName == mpi.SNAPSHOT_EVENT && checkpoint.parameters["snapshot"] == snapshotId {
+				markers[nodeId] = checkpoint
+			}
+		}
+	}
+
+	return markers
+}
+
+// Returns the rollback restoring a global checkpoint, nil if it is not one.
+// Global checkpoints are lost when a rollback removes the events they are made of
+func globalCheckpointRollback(checkpointId string) *RollbackMap {
+	for _, checkpoint := range globalCheckpoints {
+		if checkpoint.Id != checkpointId {
+			continue
+		}
+
+		rollbackMap := make(RollbackMap)
+
+		for nodeId, event := range checkpoint.Cut {
+			current := findCheckpointById(event.Id)
+			if current == nil {
+				logger.Warn("Global checkpoint %v refers to events removed by a rollback", checkpointId)
+				return nil
+			}
+
+			rollbackMap[nodeId] = *current
+		}
+
+		return &rollbackMap
+	}
+
+	return nil
+}
+
+func printGlobalCheckpoint(checkpoint *globalCheckpoint) {
+	nodeIds := make([]int, 0, len(checkpoint.Cut))
+	for nodeId := range checkpoint.Cut {
+		nodeIds = append(nodeIds, int(nodeId))
+	}
+	sort.Ints(nodeIds)
+
+	for _, nodeId := range nodeIds {
+		event := checkpoint.Cut[NodeId(nodeId)]
+		logger.Info("  node %d: %v", nodeId, event)
+	}
+
+	if len(checkpoint.InFlight) > 0 {
+		logger.Info("  messages in flight, sent again on restore: %v", checkpoint.InFlight)
+	}
+}
+
+func (checkpoint globalCheckpoint) String() string {
+	return fmt.Sprintf("%v (%d nodes, %d messages in flight)", checkpoint.Id, len(checkpoint.Cut), len(checkpoint.InFlight))
+}
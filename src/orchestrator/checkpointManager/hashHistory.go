@@ -0,0 +1,83 @@
+package checkpointmanager
+
+import (
+	"sort"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// Lists the checkpoints at which the buffers hashed by the nodes (hash auto) had changed since the previous checkpoint,
+// starting with the first one they were hashed at. Only the given buffer is listed, if any
+func PrintHashHistory(expression string) {
+	nodeIds := make([]int, 0, len(checkpointLog))
+	for nodeId := range checkpointLog {
+		nodeIds = append(nodeIds, int(nodeId))
+	}
+	sort.Ints(nodeIds)
+
+	listed := false
+
+	for _, nodeId := range nodeIds {
+		for _, buffer := range hashedBuffers(NodeId(nodeId)) {
+			if len(expression) > 0 && buffer != expression {
+				continue
+			}
+
+			printBufferHistory(NodeId(nodeId), buffer)
+			listed = true
+		}
+	}
+
+	if !listed {
+		logger.Info("No buffer hashes recorded, select buffers to hash at every checkpoint with hash auto <var|addr> [len]")
+	}
+}
+
+// Returns the buffers hashed at the checkpoints of the node
+func hashedBuffers(nodeId NodeId) []string {
+	buffers := make([]string, 0)
+
+	for _, record := range checkpointLog[nodeId] {
+		for buffer := range record.BufferHashes {
+			if !contains(buffers, buffer) {
+				buffers = append(buffers, buffer)
+			}
+		}
+	}
+
+	sort.Strings(buffers)
+	return buffers
+}
+
+func printBufferHistory(nodeId NodeId, buffer string) {
+	if rank := nodeRanks[nodeId]; rank != nil {
+		logger.Info("%s on node %d (rank %d):", buffer, nodeId, *rank)
+	} else {
+		logger.Info("%s on node %d:", buffer, nodeId)
+	}
+
+	var previous *checkpointRecord
+	hashed, changes := 0, 0
+
+	for _, record := range checkpointLog[nodeId] {
+		hash, ok := record.BufferHashes[buffer]
+		// the buffer was out of scope at the checkpoint
+		if !ok {
+			continue
+		}
+
+		hashed++
+
+		switch {
+		case previous == nil:
+			logger.Info("  %016x  first hashed at %v", hash, record.describe())
+		case previous.BufferHashes[buffer] != hash:
+			logger.Info("  %016x  changed after %v, at %v", hash, previous.Id, record.describe())
+			changes++
+		}
+
+		previous = record
+	}
+
+	logger.Info("  hashed at %d checkpoints, changed %d times", hashed, changes)
+}
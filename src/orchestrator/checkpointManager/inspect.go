@@ -0,0 +1,73 @@
+package checkpointmanager
+
+import (
+	"fmt"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// Prints the payload of a message event. Sends carry their own, receives show that of the matched send,
+// as their buffer is not filled yet when they are recorded. Nonblocking operations also show the state of their request
+func InspectMessage(eventId string) {
+	record := findCheckpointById(eventId)
+	if record == nil {
+		logger.Warn("Cannot find event with id %v", eventId)
+		return
+	}
+
+	if mpi.NONBLOCKING_OPERATIONS[record.OpName] {
+		// printed after the message, whichever way it ends
+		defer logRequestState(record)
+	}
+
+	message := record
+	if !mpi.SEND_EVENTS[record.OpName] {
+		if !mpi.RECEIVE_EVENTS[record.OpName] {
+			logger.Warn("Event %v (%v) is not a message", eventId, record.OpName)
+			return
+		}
+
+		if record.matchingEvent == nil {
+			logger.Info("The message received by %v is not recorded yet", eventId)
+			return
+		}
+
+		message = record.matchingEvent
+	}
+
+	logger.Info("%v: %v", message.Id, message.OpName)
+	logger.Info("  from rank %v to rank %v, tag %v", formatRank(message.NodeRank), message.parameters["dest"], message.parameters["tag"])
+
+	if message.payload == nil && message.payloadSize == 0 {
+		logger.Info("  payload not captured")
+		return
+	}
+
+	datatype := message.parameters["datatype"]
+
+	logger.Info("  %v x %v (%d bytes captured)", message.parameters["count"], datatype, len(message.payload))
+	logger.Info("  %v", mpi.FormatPayload(datatype, message.payload))
+
+	// beyond the payload cap, only the hash of the message is recorded
+	if message.payloadSize > int64(len(message.payload)) {
+		logger.Info("  %d more bytes not captured, hash %016x", message.payloadSize-int64(len(message.payload)), message.payloadRestHash)
+	}
+}
+
+// Prints whether the request of a nonblocking operation has been completed, and by which event
+func logRequestState(record *checkpointRecord) {
+	if record.CompletionEventId == nil {
+		logger.Info("  request of %v pending, not completed by a recorded event", record.Id)
+		return
+	}
+
+	logger.Info("  request of %v completed by event %v", record.Id, *record.CompletionEventId)
+}
+
+func formatRank(rank *int) string {
+	if rank == nil {
+		return "?"
+	}
+	return fmt.Sprint(*rank)
+}
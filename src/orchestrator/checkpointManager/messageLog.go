@@ -0,0 +1,108 @@
+package checkpointmanager
+
+import (
+	"strconv"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// Submits the rollback of a node alone to one of its checkpoints, leaving the other nodes as they are.
+// The messages the node received since the checkpoint are replayed to it from the log of recorded messages,
+// the messages it sent are not sent again. Nodes re-executing collective or nonblocking operations cannot be rolled back
+// alone, nor nodes that received messages with payloads not captured in full.
+// Returns the messages to replay, by the receive events they were received at
+func SubmitNodeRollback(nodeId NodeId, checkpointId string) (*RollbackMap, []rpc.LoggedMessage) {
+	if readOnly {
+		logger.Warn("Cannot roll back: session is opened in read-only mode")
+		return nil, nil
+	}
+
+	checkpointId = checkpointIdOf(checkpointId)
+
+	checkpoint := findCheckpointById(checkpointId)
+	if checkpoint == nil {
+		logger.Warn("Cannot find checkpoint with id %v", checkpointId)
+		return nil, nil
+	}
+
+	if checkpoint.nodeId != nodeId {
+		logger.Warn("Checkpoint %v is not of node %d", checkpointId, nodeId)
+		return nil, nil
+	}
+
+	if !checkpoint.CanBeRestored {
+		logger.Warn("Checkpoint of type %v cannot be restored", checkpoint.OpName)
+		return nil, nil
+	}
+
+	if checkpoint.Pruned {
+		logger.Warn("Cannot roll back: %v", prunedCheckpointError(checkpoint))
+		return nil, nil
+	}
+
+	messages := make([]rpc.LoggedMessage, 0)
+
+	for _, event := range checkpointLog[nodeId][checkpointIndex(nodeId, checkpoint.Id):] {
+		switch {
+		case mpi.COLLECTIVE_OPERATIONS[event.OpName]:
+			logger.Warn("Node %d re-executes the collective operation %v, the other participants must be rolled back with it: rollback %v", nodeId, event, checkpoint.Id)
+			return nil, nil
+		case mpi.NONBLOCKING_OPERATIONS[event.OpName] || mpi.COMPLETION_OPERATIONS[event.OpName]:
+			logger.Warn("Node %d re-executes the nonblocking operation %v, which is not replayed from the message log: rollback %v", nodeId, event, checkpoint.Id)
+			return nil, nil
+		case event.OpName == mpi.MPI_OPS[mpi.OP_RECV] && event.matchingEvent != nil:
+			message, ok := loggedMessage(event)
+			if !ok {
+				return nil, nil
+			}
+			messages = append(messages, message)
+		}
+	}
+
+	pendingRollback = &RollbackMap{nodeId: *checkpoint}
+
+	return pendingRollback, messages
+}
+
+// Returns the message a receive event received, as captured with the matching send
+func loggedMessage(receive *checkpointRecord) (message rpc.LoggedMessage, ok bool) {
+	send := receive.matchingEvent
+
+	if send.payload == nil && send.payloadSize == 0 {
+		logger.Warn("The payload of %v received by %v was not captured, it cannot be replayed from the message log", send.Id, receive.Id)
+		return message, false
+	}
+
+	if int64(len(send.payload)) < send.payloadSize {
+		logger.Warn("The payload of %v received by %v was captured in part (%d of %d bytes), raise the payload cap to replay it from the message log",
+			send.Id, receive.Id, len(send.payload), send.payloadSize)
+		return message, false
+	}
+
+	if send.NodeRank == nil {
+		logger.Warn("The rank of the sender of %v is not known", send.Id)
+		return message, false
+	}
+
+	tag, err := strconv.Atoi(send.parameters["tag"])
+	if err != nil {
+		logger.Warn("The tag of %v is not known", send.Id)
+		return message, false
+	}
+
+	count, err := strconv.Atoi(send.parameters["count"])
+	if err != nil {
+		logger.Warn("The element count of %v is not known", send.Id)
+		return message, false
+	}
+
+	return rpc.LoggedMessage{
+		ReceiveId: receive.Id,
+		Source:    *send.NodeRank,
+		Tag:       tag,
+		Count:     count,
+		Payload:   send.payload,
+	}, true
+}
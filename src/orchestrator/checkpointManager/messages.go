@@ -0,0 +1,154 @@
+package checkpointmanager
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// states of a message event without the other party
+const (
+	MESSAGE_PENDING = "pending" // the other party may still be recorded
+	MESSAGE_LOST    = "lost"    // the ranks it could be matched with have finalized
+	MESSAGE_DROPPED = "dropped" // an injected fault suppressed the call
+)
+
+// Prints the sends without a matching receive and the receives without a matching send recorded so far
+// (check messages), with the ranks, tag and source line of the call
+func CheckMessages() {
+	unmatched := unmatchedMessages()
+
+	if len(unmatched) == 0 {
+		logger.Info("Every recorded send has a matching receive and vice versa")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "event\toperation\tfrom\tto\ttag\tlocation\tstate\t")
+
+	for _, record := range unmatched {
+		sender, receiver := formatRank(record.NodeRank), formatRankParameter(record.parameters["dest"])
+		if !record.IsSend {
+			sender, receiver = formatRankParameter(record.parameters["source"]), formatRank(record.NodeRank)
+		}
+
+		location := record.Location
+		if len(location) == 0 {
+			location = "?"
+		}
+
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n",
+			record.Id, record.OpName, sender, receiver, formatRankParameter(record.parameters["tag"]), location, record.messageState())
+	}
+
+	writer.Flush()
+}
+
+// Returns the number of lost and dropped messages, those that will not be matched anymore
+func CountLeakedMessages() int {
+	count := 0
+	for _, record := range unmatchedMessages() {
+		if record.messageState() != MESSAGE_PENDING {
+			count++
+		}
+	}
+	return count
+}
+
+// Returns the message events of all nodes without a matching event, by node and in the order of the node.
+// Events a node was rolled back to are left out, they are matched again once re-executed
+func unmatchedMessages() []*checkpointRecord {
+	nodeIds := make([]int, 0, len(checkpointLog))
+	for nodeId := range checkpointLog {
+		nodeIds = append(nodeIds, int(nodeId))
+	}
+	sort.Ints(nodeIds)
+
+	unmatched := make([]*checkpointRecord, 0)
+
+	for _, nodeId := range nodeIds {
+		for _, record := range checkpointLog[NodeId(nodeId)] {
+			if !record.IsSend && !mpi.RECEIVE_EVENTS[record.OpName] {
+				continue
+			}
+
+			if record.matchingEvent == nil && !record.CurrentLocation {
+				unmatched = append(unmatched, record)
+			}
+		}
+	}
+
+	return unmatched
+}
+
+// Returns whether an unmatched message event can still be matched
+func (record *checkpointRecord) messageState() string {
+	if record.faultSuppressedCall() {
+		return MESSAGE_DROPPED
+	}
+
+	peer := record.parameters["dest"]
+	if !record.IsSend {
+		peer = record.parameters["source"]
+	}
+
+	rank, err := strconv.Atoi(peer)
+	if err != nil {
+		return MESSAGE_PENDING
+	}
+
+	// a wildcard receive is lost once all other ranks of the job have finalized
+	if rank < 0 {
+		if sessionFingerprint == nil {
+			return MESSAGE_PENDING
+		}
+
+		finalizedPeers := 0
+		for nodeId := range checkpointLog {
+			if nodeId != record.nodeId && finalized(nodeId) {
+				finalizedPeers++
+			}
+		}
+
+		if finalizedPeers < sessionFingerprint.Ranks-1 {
+			return MESSAGE_PENDING
+		}
+		return MESSAGE_LOST
+	}
+
+	nodeId, found := nodeIdOfRank(rank)
+	if found && finalized(nodeId) {
+		return MESSAGE_LOST
+	}
+
+	return MESSAGE_PENDING
+}
+
+// Returns whether the node has recorded MPI_Finalize, it sends and receives no more messages
+func finalized(nodeId NodeId) bool {
+	for _, record := range checkpointLog[nodeId] {
+		if record.OpName == mpi.MPI_OPS[mpi.OP_FINALIZE] {
+			return true
+		}
+	}
+	return false
+}
+
+// Formats a rank or tag parameter, the negative wildcards (MPI_ANY_SOURCE, MPI_ANY_TAG) as any
+func formatRankParameter(value string) string {
+	if len(value) == 0 {
+		return "?"
+	}
+
+	if number, err := strconv.Atoi(value); err == nil && number < 0 {
+		return "any"
+	}
+
+	return value
+}
@@ -0,0 +1,155 @@
+package checkpointmanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// Names a checkpoint, so that it can be rolled back to by the label in place of its id
+func NameCheckpoint(checkpointId string, label string) error {
+	record := findCheckpointById(checkpointIdOf(checkpointId))
+	if record == nil {
+		return fmt.Errorf("cannot find checkpoint with id %v", checkpointId)
+	}
+
+	if labeled := findCheckpointByLabel(label); labeled != nil && labeled != record {
+		return fmt.Errorf("label %v is already given to checkpoint %v", label, labeled.Id)
+	}
+
+	logLock.Lock()
+	record.Label = label
+	logLock.Unlock()
+
+	logger.Info("Checkpoint %v named %v", record.Id, label)
+
+	return nil
+}
+
+// Returns the id of the checkpoint with the label, or the argument itself if no checkpoint has the label
+func checkpointIdOf(idOrLabel string) string {
+	if record := findCheckpointByLabel(idOrLabel); record != nil {
+		return record.Id
+	}
+
+	return idOrLabel
+}
+
+func findCheckpointByLabel(label string) *checkpointRecord {
+	for _, nodeCheckpoints := range checkpointLog {
+		for _, checkpoint := range nodeCheckpoints {
+			if checkpoint.Label == label {
+				return checkpoint
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the Lamport clock of a new event of the node: it follows the previous event of the node,
+// and if that was a receive, the send of the received message as well.
+// Checkpoints are taken before the operation, so a receive only orders the events after it
+func (record *checkpointRecord) nextLogicalClock() int {
+	nodeCheckpoints := checkpointLog[record.nodeId]
+	if len(nodeCheckpoints) == 0 {
+		return 1
+	}
+
+	previous := nodeCheckpoints[len(nodeCheckpoints)-1]
+	clock := previous.LogicalClock
+
+	if mpi.RECEIVE_EVENTS[previous.OpName] && previous.matchingEvent != nil && previous.matchingEvent.LogicalClock > clock {
+		clock = previous.matchingEvent.LogicalClock
+	}
+
+	return clock + 1
+}
+
+// Returns whether the event happened before another by their vector clocks: the other node had learned of the event,
+// through a chain of messages, by the time of the other event. A receive merges the clock of its message once it
+// completes, so the events after a receive depend on the send, the receive itself does not.
+// Unknown without the clocks of both events
+func (record *checkpointRecord) happenedBefore(other *checkpointRecord) (before bool, known bool) {
+	rank := nodeRanks[record.nodeId]
+	if rank == nil || *rank >= len(record.VectorClock) || *rank >= len(other.VectorClock) {
+		return false, false
+	}
+
+	if record.nodeId == other.nodeId {
+		return isBefore(record.Id, other.Id, record.nodeId), true
+	}
+
+	return other.VectorClock[*rank] >= record.VectorClock[*rank], true
+}
+
+// Describes the event the checkpoint was taken at: the operation and its parameters
+func (record *checkpointRecord) trigger() string {
+	names := make([]string, 0, len(record.parameters))
+	for name := range record.parameters {
+		// the rank is listed with the node
+		if name != "rank" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	parameters := make([]string, 0, len(names))
+	for _, name := range names {
+		parameters = append(parameters, fmt.Sprintf("%s=%s", name, record.parameters[name]))
+	}
+
+	return fmt.Sprintf("%s(%s)", record.OpName, strings.Join(parameters, ", "))
+}
+
+// A line of the checkpoint listing
+func (record *checkpointRecord) describe() string {
+	description := fmt.Sprintf("%s  clock %-4d", record.Id, record.LogicalClock)
+
+	if !record.Time.IsZero() {
+		description = fmt.Sprintf("%s  %s", description, record.Time.Format("15:04:05.000"))
+	}
+
+	description = fmt.Sprintf("%s  %s", description, record.trigger())
+
+	if len(record.VectorClock) > 0 {
+		description = fmt.Sprintf("%s  %v", description, record.VectorClock)
+	}
+
+	if len(record.Location) > 0 {
+		description = fmt.Sprintf("%s at %s", description, record.Location)
+	}
+
+	if len(record.Label) > 0 {
+		description = fmt.Sprintf("%s  [%s]", description, record.Label)
+	}
+
+	if record.Pruned {
+		description = fmt.Sprintf("%s  (pruned)", description)
+	}
+
+	if record.CurrentLocation {
+		description = fmt.Sprintf("%s  <- current location", description)
+	}
+
+	return description
+}
+
+// Finds the event of a node its vector clock entry counted to the value, the event a message with the
+// piggybacked clock was sent at
+func EventAtClock(nodeId NodeId, clock int) (eventId string, location string, found bool) {
+	rank := nodeRanks[nodeId]
+	if rank == nil {
+		return "", "", false
+	}
+
+	for _, record := range checkpointLog[nodeId] {
+		if *rank < len(record.VectorClock) && record.VectorClock[*rank] == clock {
+			return record.Id, record.Location, true
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,178 @@
+package checkpointmanager
+
+import (
+	"strconv"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+)
+
+// Prints the sends whose messages a recorded wildcard receive could have taken instead of the one it did
+func ListReorderings(receiveId string) {
+	receive, candidates := reorderCandidates(receiveId)
+	if receive == nil {
+		return
+	}
+
+	if len(candidates) == 0 {
+		logger.Info("No concurrent messages could have been received by %v instead", receiveId)
+		return
+	}
+
+	logger.Info("%v (%v) could have received these messages instead of %v:", receiveId, receive.OpName, formatEventId(receive.MatchingEventId))
+	for _, send := range candidates {
+		logger.Info("  %v: %v from rank %v, tag %v", send.Id, send.OpName, formatRank(send.NodeRank), send.parameters["tag"])
+	}
+}
+
+// Validates that the receive could have taken the message of the send instead, and submits the rollback
+// replaying the receive. Returns the node of the receive and the rank it is to take its message from
+func SubmitReordering(receiveId string, sendId string) (nodeId NodeId, source int, rollbackMap *RollbackMap) {
+	receive, candidates := reorderCandidates(receiveId)
+	if receive == nil {
+		return 0, 0, nil
+	}
+
+	for _, send := range candidates {
+		if send.Id != sendId {
+			continue
+		}
+
+		if send.NodeRank == nil {
+			logger.Warn("The rank of the sender of %v is not known", sendId)
+			return 0, 0, nil
+		}
+
+		return receive.nodeId, *send.NodeRank, SubmitForRollback(receiveId)
+	}
+
+	logger.Warn("The message of %v cannot be received by %v, list the valid reorderings with reorder %v", sendId, receiveId, receiveId)
+	return 0, 0, nil
+}
+
+// Records the source forced on a replayed receive, so that it is matched with the send from that rank
+func ForceReceiveSource(receiveId string, source int) {
+	receive := findCheckpointById(receiveId)
+	if receive == nil {
+		return
+	}
+
+	logLock.Lock()
+	receive.parameters["source"] = strconv.Itoa(source)
+	receive.parameters["reordered"] = "true"
+	logLock.Unlock()
+}
+
+// Finds the sends a wildcard receive could have been matched with instead of the recorded one.
+// Valid alternatives are addressed to the rank of the receive with a matching tag, not yet received before it
+// and concurrent to it (the receive does not happen before them). Messages between a pair of ranks
+// do not overtake each other, so only the first such send of each other node qualifies
+func reorderCandidates(receiveId string) (receive *checkpointRecord, candidates []*checkpointRecord) {
+	receive = findCheckpointById(receiveId)
+	if receive == nil {
+		logger.Warn("Cannot find event with id %v", receiveId)
+		return nil, nil
+	}
+
+	if !mpi.RECEIVE_EVENTS[receive.OpName] {
+		logger.Warn("Event %v (%v) is not a receive", receiveId, receive.OpName)
+		return nil, nil
+	}
+
+	if !receive.isWildcardReceive() {
+		logger.Warn("Receive %v names its source, only wildcard (MPI_ANY_SOURCE) receives can be reordered", receiveId)
+		return nil, nil
+	}
+
+	if receive.NodeRank == nil {
+		logger.Warn("The rank of the node of %v is not known", receiveId)
+		return nil, nil
+	}
+
+	candidates = make([]*checkpointRecord, 0)
+
+	for nodeId, nodeCheckpoints := range checkpointLog {
+		if nodeId == receive.nodeId || (receive.matchingEvent != nil && nodeId == receive.matchingEvent.nodeId) {
+			continue
+		}
+
+		for _, checkpoint := range nodeCheckpoints {
+			if !checkpoint.IsSend || checkpoint.faultSuppressedCall() || !tagsMatch(receive.Tag, checkpoint.Tag) {
+				continue
+			}
+
+			if checkpoint.parameters["dest"] != strconv.Itoa(*receive.NodeRank) {
+				continue
+			}
+
+			// received before the wildcard receive
+			if checkpoint.matchingEvent != nil && !isBefore(receive.Id, checkpoint.matchingEvent.Id, receive.nodeId) {
+				continue
+			}
+
+			if !happensBefore(receive, checkpoint) {
+				candidates = append(candidates, checkpoint)
+			}
+			break
+		}
+	}
+
+	return receive, candidates
+}
+
+// Returns whether the event is a receive from any source (MPI_ANY_SOURCE). Receives replayed with a forced
+// source are still wildcard receives, as they are restored with their original arguments
+func (record *checkpointRecord) isWildcardReceive() bool {
+	if !mpi.RECEIVE_EVENTS[record.OpName] {
+		return false
+	}
+
+	if record.parameters["reordered"] == "true" {
+		return true
+	}
+
+	source, err := strconv.Atoi(record.parameters["source"])
+	return err == nil && source < 0
+}
+
+// Returns whether event 1 happens before event 2, following the order of events on each node,
+// messages from their send to their receive, and collective operations to all their participants
+func happensBefore(event1 *checkpointRecord, event2 *checkpointRecord) bool {
+	visited := map[string]bool{event1.Id: true}
+	queue := []*checkpointRecord{event1}
+
+	for len(queue) > 0 {
+		event := queue[0]
+		queue = queue[1:]
+
+		successors := event.collectivePeers()
+
+		if index := checkpointIndex(event.nodeId, event.Id); index+1 < len(checkpointLog[event.nodeId]) {
+			successors = append(successors, checkpointLog[event.nodeId][index+1])
+		}
+
+		if event.IsSend && event.matchingEvent != nil {
+			successors = append(successors, event.matchingEvent)
+		}
+
+		for _, successor := range successors {
+			if successor == event2 {
+				return true
+			}
+
+			if !visited[successor.Id] {
+				visited[successor.Id] = true
+				queue = append(queue, successor)
+			}
+		}
+	}
+
+	return false
+}
+
+func formatEventId(eventId *string) string {
+	if eventId == nil {
+		return "the recorded message"
+	}
+	return *eventId
+}
@@ -0,0 +1,215 @@
+package checkpointmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+)
+
+// Limits on the checkpoints kept by each node, beyond which the memory state of older checkpoints is released.
+// Zero values impose no limit
+type RetentionPolicy struct {
+	KeepLast  int    // the most recent restorable checkpoints are kept
+	KeepEvery int    // of the older restorable checkpoints, every KeepEvery-th is kept
+	MaxBytes  uint64 // the most bytes the kept checkpoints of a node take up, the oldest are pruned first
+
+	Window       time.Duration // checkpoints older than this are pruned, the reverse-execution window in time
+	WindowEvents int           // only the checkpoints of the last WindowEvents events of a node are kept
+}
+
+func (p RetentionPolicy) isSet() bool {
+	return p.KeepLast > 0 || p.KeepEvery > 0 || p.MaxBytes > 0 || p.hasWindow()
+}
+
+var retentionPolicy RetentionPolicy
+
+func SetRetentionPolicy(policy RetentionPolicy) {
+	retentionPolicy = policy
+}
+
+func HasRetentionPolicy() bool {
+	return retentionPolicy.isSet()
+}
+
+// Returns the checkpoints the retention policy prunes, by node id.
+// Checkpoints that cannot be rolled back to are pruned first, as they only take up space
+func CheckpointsToPrune() map[NodeId][]string {
+	pruned := make(map[NodeId][]string)
+
+	if !retentionPolicy.isSet() {
+		return pruned
+	}
+
+	for nodeId, nodeCheckpoints := range checkpointLog {
+		kept := make([]*checkpointRecord, 0)
+		restorable := make([]*checkpointRecord, 0)
+
+		for _, checkpoint := range nodeCheckpoints {
+			if checkpoint.Pruned {
+				continue
+			}
+
+			switch {
+			case pruneProtection(checkpoint) != nil:
+				kept = append(kept, checkpoint)
+			case outsideWindow(checkpoint):
+				pruned[nodeId] = append(pruned[nodeId], checkpoint.Id)
+			case checkpoint.CanBeRestored:
+				restorable = append(restorable, checkpoint)
+			default:
+				pruned[nodeId] = append(pruned[nodeId], checkpoint.Id)
+			}
+		}
+
+		for index, checkpoint := range restorable {
+			if retentionPolicy.retains(index, len(restorable)) {
+				kept = append(kept, checkpoint)
+			} else {
+				pruned[nodeId] = append(pruned[nodeId], checkpoint.Id)
+			}
+		}
+
+		if retentionPolicy.MaxBytes == 0 {
+			continue
+		}
+
+		var keptBytes uint64
+		for _, checkpoint := range kept {
+			keptBytes += checkpoint.Bytes
+		}
+
+		// kept in the order of the log, the oldest are pruned first
+		for _, checkpoint := range nodeCheckpoints {
+			if keptBytes <= retentionPolicy.MaxBytes {
+				break
+			}
+
+			if checkpoint.Pruned || pruneProtection(checkpoint) != nil || contains(pruned[nodeId], checkpoint.Id) {
+				continue
+			}
+
+			pruned[nodeId] = append(pruned[nodeId], checkpoint.Id)
+			keptBytes -= checkpoint.Bytes
+		}
+	}
+
+	for nodeId, checkpointIds := range pruned {
+		if len(checkpointIds) == 0 {
+			delete(pruned, nodeId)
+		}
+	}
+
+	return pruned
+}
+
+// Returns whether the policy retains the restorable checkpoint at the index, of the count of restorable checkpoints
+func (p RetentionPolicy) retains(index int, count int) bool {
+	if p.KeepLast == 0 && p.KeepEvery == 0 {
+		return true
+	}
+
+	if p.KeepLast > 0 && index >= count-p.KeepLast {
+		return true
+	}
+
+	return p.KeepEvery > 0 && index%p.KeepEvery == 0
+}
+
+// Returns why a checkpoint is never pruned, nil if it may be: rollbacks moved past pruned checkpoints
+// fall back to the first restorable checkpoint of the node (in the reverse-execution window), global checkpoints
+// are restored as a whole and named checkpoints were chosen by the user as rollback targets
+func pruneProtection(record *checkpointRecord) error {
+	if len(record.Label) > 0 {
+		return fmt.Errorf("checkpoint %v is named %v", record.Id, record.Label)
+	}
+
+	for _, checkpoint := range checkpointLog[record.nodeId] {
+		if checkpoint.CanBeRestored && !outsideWindow(checkpoint) {
+			if checkpoint == record {
+				return fmt.Errorf("checkpoint %v is the first restorable checkpoint of node %d", record.Id, record.nodeId)
+			}
+			break
+		}
+	}
+
+	// global checkpoints leave the window with the events of their cut
+	if outsideWindow(record) {
+		return nil
+	}
+
+	for _, global := range globalCheckpoints {
+		if cut, ok := global.Cut[record.nodeId]; ok && cut.Id == record.Id {
+			return fmt.Errorf("checkpoint %v is part of global checkpoint %v", record.Id, global.Id)
+		}
+	}
+
+	return nil
+}
+
+// Returns the checkpoints given by id or label, by node id. Fails if a checkpoint is not found or may not be pruned
+func SubmitForPruning(idsOrLabels []string) (map[NodeId][]string, error) {
+	pruned := make(map[NodeId][]string)
+
+	for _, idOrLabel := range idsOrLabels {
+		record := findCheckpointById(checkpointIdOf(idOrLabel))
+		if record == nil {
+			return nil, fmt.Errorf("cannot find checkpoint with id %v", idOrLabel)
+		}
+
+		if err := pruneProtection(record); err != nil {
+			return nil, err
+		}
+
+		if !record.Pruned {
+			pruned[record.nodeId] = append(pruned[record.nodeId], record.Id)
+		}
+	}
+
+	return pruned, nil
+}
+
+// Marks checkpoints as pruned, once their nodes have been told to release them
+func MarkPruned(nodeId NodeId, checkpointIds []string) {
+	var releasedBytes uint64
+
+	logLock.Lock()
+	defer logLock.Unlock()
+
+	for _, checkpoint := range checkpointLog[nodeId] {
+		if contains(checkpointIds, checkpoint.Id) {
+			checkpoint.Pruned = true
+			releasedBytes += checkpoint.Bytes
+		}
+	}
+
+	logger.Verbose("Pruned %d checkpoints of node %d (%d bytes)", len(checkpointIds), nodeId, releasedBytes)
+}
+
+// Returns the checkpoint to restore for a node to be rolled back to the event: the event itself,
+// or if it was pruned, the latest restorable checkpoint of the node before it.
+// Falls back to the first restorable checkpoint, which is never pruned
+func restorableAtOrBefore(record *checkpointRecord) *checkpointRecord {
+	if !record.Pruned {
+		return record
+	}
+
+	nodeCheckpoints := checkpointLog[record.nodeId]
+
+	for index := checkpointIndex(record.nodeId, record.Id); index >= 0; index-- {
+		if checkpoint := nodeCheckpoints[index]; checkpoint.CanBeRestored && !checkpoint.Pruned {
+			return checkpoint
+		}
+	}
+
+	return record
+}
+
+func contains(ids []string, id string) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
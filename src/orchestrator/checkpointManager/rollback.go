@@ -1,6 +1,8 @@
 package checkpointmanager
 
 import (
+	"sort"
+
 	"github.com/ottmartens/cc-rev-db/logger"
 )
 
@@ -11,8 +13,26 @@ var pendingRollback *RollbackMap
 
 // returns which (additional) checkpoints need to be rolled back
 // if the supplied checkpoint is to be restored
-// in order to maintain causal consistency
+// in order to maintain causal consistency.
+// The id of a global checkpoint restores all of its checkpoints. Named checkpoints may be given by their label
 func SubmitForRollback(checkpointId string) *RollbackMap {
+	if readOnly {
+		logger.Warn("Cannot roll back: session is opened in read-only mode")
+		return nil
+	}
+
+	checkpointId = checkpointIdOf(checkpointId)
+
+	if rollbackMap := globalCheckpointRollback(checkpointId); rollbackMap != nil {
+		if err := checkRollbackWindow(*rollbackMap); err != nil {
+			logger.Warn("Cannot roll back to global checkpoint %v: %v", checkpointId, err)
+			return nil
+		}
+
+		pendingRollback = rollbackMap
+		return pendingRollback
+	}
+
 	originalCheckpoint := findCheckpointById(checkpointId)
 
 	if originalCheckpoint == nil {
@@ -25,12 +45,34 @@ func SubmitForRollback(checkpointId string) *RollbackMap {
 		return nil
 	}
 
+	if originalCheckpoint.Pruned {
+		logger.Warn("Cannot roll back: %v", prunedCheckpointError(originalCheckpoint))
+		return nil
+	}
+
 	logger.Debug("Finding related checkpoints for rollback, original checkpoint: %v", originalCheckpoint)
 
 	rollbackPointsPerNode := RollbackMap{
 		originalCheckpoint.nodeId: *originalCheckpoint,
 	}
 
+	closeCut(rollbackPointsPerNode)
+
+	// the nodes rolled back with the checkpoint may have to go back further than their window
+	if err := checkRollbackWindow(rollbackPointsPerNode); err != nil {
+		logger.Warn("Cannot roll back to %v: %v", originalCheckpoint.Id, err)
+		return nil
+	}
+
+	pendingRollback = &rollbackPointsPerNode
+
+	return pendingRollback
+}
+
+// Moves the checkpoints of the cut back, and adds the checkpoints of further nodes, until no event left
+// to be re-executed on one node is related to an event kept on another (the other party of a message,
+// the other participants of a collective operation), nor happened before one by the vector clocks
+func closeCut(rollbackPointsPerNode RollbackMap) {
 	for {
 		updated := false
 
@@ -40,14 +82,31 @@ func SubmitForRollback(checkpointId string) *RollbackMap {
 
 				checkpoint := checkpointLog[nodeId][i]
 
-				matchingEvent := checkpoint.matchingEvent
+				// the other party of a message, and the other participants of a collective operation
+				relatedEvents := checkpoint.collectivePeers()
+				if checkpoint.matchingEvent != nil {
+					relatedEvents = append(relatedEvents, checkpoint.matchingEvent)
+				}
 
-				if matchingEvent != nil {
+				for _, relatedEvent := range relatedEvents {
+					// pruned checkpoints cannot be restored, the node is rolled back further
+					relatedEvent = restorableAtOrBefore(relatedEvent)
 
-					existingRollbackEvent, hasExistingRollbackEvent := rollbackPointsPerNode[matchingEvent.nodeId]
+					existingRollbackEvent, hasExistingRollbackEvent := rollbackPointsPerNode[relatedEvent.nodeId]
 
-					if !hasExistingRollbackEvent || isBefore(matchingEvent.Id, existingRollbackEvent.Id, matchingEvent.nodeId) {
-						rollbackPointsPerNode[matchingEvent.nodeId] = *matchingEvent
+					if !hasExistingRollbackEvent || isBefore(relatedEvent.Id, existingRollbackEvent.Id, relatedEvent.nodeId) {
+						rollbackPointsPerNode[relatedEvent.nodeId] = *relatedEvent
+						updated = true
+					}
+				}
+
+				for relatedNodeId, dependentEvent := range firstDependentEvents(checkpoint) {
+					dependentEvent = restorableAtOrBefore(dependentEvent)
+
+					existingRollbackEvent, hasExistingRollbackEvent := rollbackPointsPerNode[relatedNodeId]
+
+					if !hasExistingRollbackEvent || isBefore(dependentEvent.Id, existingRollbackEvent.Id, relatedNodeId) {
+						rollbackPointsPerNode[relatedNodeId] = *dependentEvent
 						updated = true
 					}
 				}
@@ -58,10 +117,30 @@ func SubmitForRollback(checkpointId string) *RollbackMap {
 			break
 		}
 	}
+}
 
-	pendingRollback = &rollbackPointsPerNode
+// Returns, for each other node, the checkpoint to roll it back to so that none of its kept events depend on the event:
+// the event completing the receive its first dependent event learned of it through
+func firstDependentEvents(record *checkpointRecord) map[NodeId]*checkpointRecord {
+	dependentEvents := make(map[NodeId]*checkpointRecord)
 
-	return pendingRollback
+	for nodeId, nodeCheckpoints := range checkpointLog {
+		if nodeId == record.nodeId {
+			continue
+		}
+
+		for index, checkpoint := range nodeCheckpoints {
+			if before, known := record.happenedBefore(checkpoint); known && before {
+				if index > 0 {
+					index--
+				}
+				dependentEvents[nodeId] = nodeCheckpoints[index]
+				break
+			}
+		}
+	}
+
+	return dependentEvents
 }
 
 // Returns whether checkpoint 1 happened before checkpoint 2 on the specified node
@@ -90,6 +169,24 @@ func checkpointIndex(nodeId NodeId, checkpointId string) int {
 	return 0
 }
 
+// Prints the checkpoint each node is to be restored to, and the recorded events it re-executes when continued
+func PrintRollback(rollbackMap RollbackMap) {
+	nodeIds := make([]int, 0, len(rollbackMap))
+	for nodeId := range rollbackMap {
+		nodeIds = append(nodeIds, int(nodeId))
+	}
+	sort.Ints(nodeIds)
+
+	logger.Info("Following checkpoints scheduled for rollback:")
+
+	for _, nodeId := range nodeIds {
+		checkpoint := rollbackMap[NodeId(nodeId)]
+		replayed := len(checkpointLog[NodeId(nodeId)]) - checkpointIndex(NodeId(nodeId), checkpoint.Id) - 1
+
+		logger.Info("  node %d (rank %v): %v, %d recorded events to replay", nodeId, formatRank(checkpoint.NodeRank), checkpoint, replayed)
+	}
+}
+
 func GetPendingRollback() *RollbackMap {
 	return pendingRollback
 }
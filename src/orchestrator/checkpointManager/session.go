@@ -0,0 +1,275 @@
+package checkpointmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+// A recorded debugging session, exported to a file for later inspection in the read-only viewer
+type sessionBundle struct {
+	Checkpoints   map[NodeId][]bundledCheckpoint
+	MemoryLayouts map[NodeId]rpc.MemoryLayoutRecord // memory maps of the targets at launch
+	Fingerprint   *rpc.SessionFingerprint           // the target and the environment it was debugged in
+	Events        []rpc.NodeEvent                   // events of the nodes, oldest first
+	Results       []RecordedResult                  // values printed and call stacks of the nodes, oldest first
+}
+
+// events and command results kept for the bundle, the oldest beyond are dropped
+const (
+	MAX_RECORDED_EVENTS  = 10000
+	MAX_RECORDED_RESULTS = 2000
+)
+
+// The result of a node command kept for the bundle: what it printed, e.g. the value of an expression,
+// or the location and call stack the node stopped at
+type RecordedResult struct {
+	NodeId    int
+	Command   string // name of the command, e.g. print
+	Argument  string
+	Time      time.Time
+	Location  string
+	Backtrace string
+	Output    string
+	Error     string
+}
+
+// The events and the command results of the session, reported on the goroutines of the rpc calls of the nodes
+var sessionRecord = struct {
+	sync.Mutex
+	events  []rpc.NodeEvent
+	results []RecordedResult
+}{}
+
+func RecordNodeEvent(event rpc.NodeEvent) {
+	sessionRecord.Lock()
+	defer sessionRecord.Unlock()
+
+	sessionRecord.events = append(sessionRecord.events, event)
+	if excess := len(sessionRecord.events) - MAX_RECORDED_EVENTS; excess > 0 {
+		sessionRecord.events = sessionRecord.events[excess:]
+	}
+}
+
+// Records the result of a node command if it has something to browse later: an output, a call stack or an error
+func RecordCommandResult(result RecordedResult) {
+	if len(result.Output)+len(result.Backtrace)+len(result.Error) == 0 {
+		return
+	}
+
+	sessionRecord.Lock()
+	defer sessionRecord.Unlock()
+
+	sessionRecord.results = append(sessionRecord.results, result)
+	if excess := len(sessionRecord.results) - MAX_RECORDED_RESULTS; excess > 0 {
+		sessionRecord.results = sessionRecord.results[excess:]
+	}
+}
+
+// Returns the recorded events, oldest first
+func GetRecordedEvents() []rpc.NodeEvent {
+	sessionRecord.Lock()
+	defer sessionRecord.Unlock()
+
+	return append([]rpc.NodeEvent{}, sessionRecord.events...)
+}
+
+// Returns the recorded results of the node, oldest first
+func GetRecordedResults(nodeId int) []RecordedResult {
+	sessionRecord.Lock()
+	defer sessionRecord.Unlock()
+
+	results := make([]RecordedResult, 0)
+	for _, result := range sessionRecord.results {
+		if result.NodeId == nodeId {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func (r RecordedResult) String() string {
+	lines := []string{fmt.Sprintf("%v %v %v", r.Time.Format("15:04:05"), r.Command, r.Argument)}
+
+	if len(r.Location) > 0 {
+		lines = append(lines, "  at "+r.Location)
+	}
+	for _, text := range []string{r.Output, r.Backtrace, r.Error} {
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			if len(line) > 0 {
+				lines = append(lines, "  "+line)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type bundledCheckpoint struct {
+	Id              string
+	OpName          string
+	Parameters      map[string]string
+	MatchingEventId *string
+	CurrentLocation bool
+	Instructions    uint64
+	Payload         []byte
+	PayloadSize     int64
+	PayloadRestHash uint64
+	Label           string
+	Location        string
+	Time            time.Time
+	LogicalClock    int
+	BufferHashes    map[string]uint64
+	VectorClock     []int
+}
+
+// whether the checkpoint log was loaded from a session bundle (no live processes)
+var readOnly = false
+
+func IsReadOnly() bool {
+	return readOnly
+}
+
+// memory layouts of the node targets, as reported after launch
+var memoryLayouts = make(map[NodeId]rpc.MemoryLayoutRecord)
+
+func RecordMemoryLayout(layout rpc.MemoryLayoutRecord) {
+	memoryLayouts[NodeId(layout.NodeId)] = layout
+}
+
+// Writes the current checkpoint log to the specified file
+func ExportSession(filePath string) error {
+	bundle := sessionBundle{
+		Checkpoints:   make(map[NodeId][]bundledCheckpoint),
+		MemoryLayouts: memoryLayouts,
+		Fingerprint:   sessionFingerprint,
+		Events:        GetRecordedEvents(),
+	}
+
+	sessionRecord.Lock()
+	bundle.Results = append([]RecordedResult{}, sessionRecord.results...)
+	sessionRecord.Unlock()
+
+	logLock.RLock()
+	for nodeId, nodeCheckpoints := range checkpointLog {
+		for _, checkpoint := range nodeCheckpoints {
+			bundle.Checkpoints[nodeId] = append(bundle.Checkpoints[nodeId], bundledCheckpoint{
+				Id:              checkpoint.Id,
+				OpName:          checkpoint.OpName,
+				Parameters:      checkpoint.parameters,
+				MatchingEventId: checkpoint.MatchingEventId,
+				CurrentLocation: checkpoint.CurrentLocation,
+				Instructions:    checkpoint.Instructions,
+				Payload:         checkpoint.payload,
+				PayloadSize:     checkpoint.payloadSize,
+				PayloadRestHash: checkpoint.payloadRestHash,
+				Label:           checkpoint.Label,
+				Location:        checkpoint.Location,
+				Time:            checkpoint.Time,
+				LogicalClock:    checkpoint.LogicalClock,
+				BufferHashes:    checkpoint.BufferHashes,
+				VectorClock:     checkpoint.VectorClock,
+			})
+		}
+	}
+	logLock.RUnlock()
+
+	contents, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filePath, contents, 0644)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Session exported to %v", filePath)
+	return nil
+}
+
+// Replaces the checkpoint log with the contents of an exported session bundle.
+// The session is read-only afterwards, as there are no processes to roll back
+func LoadSession(filePath string) error {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	bundle := sessionBundle{}
+
+	err = json.Unmarshal(contents, &bundle)
+	if err != nil {
+		return fmt.Errorf("invalid session bundle: %v", err)
+	}
+
+	logLock.Lock()
+	defer logLock.Unlock()
+
+	checkpointLog = make(CheckpointLog)
+	nodeRanks = make(map[NodeId]*int)
+
+	if bundle.MemoryLayouts != nil {
+		memoryLayouts = bundle.MemoryLayouts
+	}
+
+	sessionFingerprint = bundle.Fingerprint
+
+	sessionRecord.Lock()
+	sessionRecord.events, sessionRecord.results = bundle.Events, bundle.Results
+	sessionRecord.Unlock()
+
+	for nodeId, nodeCheckpoints := range bundle.Checkpoints {
+		for _, checkpoint := range nodeCheckpoints {
+			record := newCheckpointRecord(nodeId, checkpoint.Id, checkpoint.OpName, checkpoint.Parameters)
+			record.CurrentLocation = checkpoint.CurrentLocation
+			record.Instructions = checkpoint.Instructions
+			record.payload = checkpoint.Payload
+			record.payloadSize = checkpoint.PayloadSize
+			record.payloadRestHash = checkpoint.PayloadRestHash
+			record.Label = checkpoint.Label
+			record.Location = checkpoint.Location
+			record.Time = checkpoint.Time
+			record.BufferHashes = checkpoint.BufferHashes
+			record.VectorClock = checkpoint.VectorClock
+
+			appendToLog(record)
+
+			// the messages the clocks follow are linked after all events are loaded
+			record.LogicalClock = checkpoint.LogicalClock
+		}
+	}
+
+	// restore the links between matching message events
+	for _, nodeCheckpoints := range bundle.Checkpoints {
+		for _, checkpoint := range nodeCheckpoints {
+			if checkpoint.MatchingEventId == nil {
+				continue
+			}
+
+			record := findCheckpointById(checkpoint.Id)
+			record.matchingEvent = findCheckpointById(*checkpoint.MatchingEventId)
+
+			if record.matchingEvent != nil {
+				record.MatchingEventId = &record.matchingEvent.Id
+			}
+		}
+	}
+
+	// restore the links between nonblocking operations and their completions
+	for _, nodeCheckpoints := range checkpointLog {
+		for _, record := range nodeCheckpoints {
+			record.linkCompletedRequests()
+		}
+	}
+
+	readOnly = true
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package checkpointmanager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The reverse-execution window bounds how far back the nodes can be rolled back on long runs: checkpoints older than
+// the window, in time or in events of their node, are pruned like those beyond the retention policy, only named
+// checkpoints are kept. Rollbacks reaching past the window are refused, naming the oldest checkpoint still inside it
+
+func (p RetentionPolicy) hasWindow() bool {
+	return p.Window > 0 || p.WindowEvents > 0
+}
+
+// Changes the reverse-execution window, zero values lift the limits
+func SetReverseWindow(window time.Duration, events int) {
+	retentionPolicy.Window = window
+	retentionPolicy.WindowEvents = events
+}
+
+func DescribeReverseWindow() string {
+	limits := make([]string, 0, 2)
+
+	if retentionPolicy.Window > 0 {
+		limits = append(limits, fmt.Sprintf("the last %v", retentionPolicy.Window))
+	}
+	if retentionPolicy.WindowEvents > 0 {
+		limits = append(limits, fmt.Sprintf("the last %d events of each node", retentionPolicy.WindowEvents))
+	}
+
+	if len(limits) == 0 {
+		return "unbounded"
+	}
+	return strings.Join(limits, " and ")
+}
+
+// Returns whether a checkpoint has fallen out of the reverse-execution window
+func outsideWindow(record *checkpointRecord) bool {
+	if retentionPolicy.Window > 0 && !record.Time.IsZero() && time.Since(record.Time) > retentionPolicy.Window {
+		return true
+	}
+
+	if retentionPolicy.WindowEvents > 0 {
+		nodeCheckpoints := checkpointLog[record.nodeId]
+
+		for index := len(nodeCheckpoints) - 1; index >= 0 && index >= len(nodeCheckpoints)-retentionPolicy.WindowEvents; index-- {
+			if nodeCheckpoints[index] == record {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// Returns the oldest checkpoint of a node that can still be rolled back to, nil if none
+func oldestInWindow(nodeId NodeId) *checkpointRecord {
+	for _, checkpoint := range checkpointLog[nodeId] {
+		if checkpoint.CanBeRestored && !checkpoint.Pruned && !outsideWindow(checkpoint) {
+			return checkpoint
+		}
+	}
+
+	return nil
+}
+
+// Returns an error describing why a checkpoint cannot be rolled back to, as it was pruned: it has left the
+// reverse-execution window or was pruned by the retention policy
+func prunedCheckpointError(record *checkpointRecord) error {
+	if !outsideWindow(record) {
+		return fmt.Errorf("checkpoint %v was pruned, the closest earlier checkpoint of the node is %v", record.Id, restorableAtOrBefore(record).Id)
+	}
+
+	err := fmt.Errorf("checkpoint %v of node %d (%v) is outside the reverse-execution window of %v", record.Id, record.nodeId, record.Time.Format("15:04:05"), DescribeReverseWindow())
+
+	if oldest := oldestInWindow(record.nodeId); oldest != nil {
+		return fmt.Errorf("%v, the oldest checkpoint of the node in the window is %v (%v)", err, oldest.Id, oldest.Time.Format("15:04:05"))
+	}
+	return err
+}
+
+// Returns an error if a node of the rollback would be restored to a pruned checkpoint, as the rollback reaches past
+// the reverse-execution window through the events related to the checkpoint rolled back to
+func checkRollbackWindow(rollbackMap RollbackMap) error {
+	for _, checkpoint := range rollbackMap {
+		if record := findCheckpointById(checkpoint.Id); record != nil && record.Pruned {
+			return prunedCheckpointError(record)
+		}
+	}
+
+	return nil
+}
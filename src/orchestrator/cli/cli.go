@@ -1,24 +1,250 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
+	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
 	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
+	"github.com/ottmartens/cc-rev-db/rpc"
 	"github.com/ottmartens/cc-rev-db/utils"
 	"github.com/ottmartens/cc-rev-db/utils/command"
 )
 
-func ParseArgs() (numProcesses int, targetPath string) {
-	args := os.Args
+type LaunchOptions struct {
+	DisableASLR         bool     // start the targets with address space layout randomization disabled
+	NoStopAtMain        bool     // leave the targets stopped where they start instead of running them to main
+	WatchdogTimeout     string   // seconds of no progress after which replays are aborted on nodes
+	ConfigFile          string   // file of aliases and user-defined commands
+	OnComplete          string   // what to do once all nodes have exited, one of the ON_COMPLETE_* policies
+	RemoteConsole       bool     // serve the console to remote clients (bin/remote-console)
+	CheckpointBackend   string   // how nodes record checkpoints: file, fork or criu (empty - node default)
+	DeployHosts         []string // hosts to copy the node debugger to and run the nodes on over ssh (empty - run locally)
+	Launcher            string   // program starting the MPI job with the node debugger as its executable, one of the launchers
+	Port                int      // port the orchestrator listens on, the nodes listen on the ports following it
+	ListenHost          string   // address the orchestrator listens on, nodes on other hosts dial in unless it is loopback
+	AdvertiseHost       string   // address the nodes reach the orchestrator at (empty - derived from ListenHost)
+	Headless            bool     // do not start the web UI, the session is driven through the remote console
+	StorageDir          string   // directory the nodes record their checkpoints in (empty - node default)
+	RequireSameBinary   bool     // refuse to start the session if the nodes run targets with different build-ids
+	DeterministicReplay bool     // replay receives after a rollback with the messages recorded originally
+	PayloadCap          string   // bytes of sent messages recorded per message, the rest by its hash (empty - node default)
+	Seed                int64    // seed of the checkpoint ids of the orchestrator and the nodes, random if not given
+	Rejoin              bool     // take over the nodes of a session whose orchestrator exited, instead of starting the job
+	JSON                bool     // print the results of node commands as json on the standard output, the log on the standard error
+	Dashboard           string   // address the web dashboard is served at (empty - not served)
+	API                 string   // address the http api is served at (empty - not served)
+	Commands            []string // executed before the console is read: source <file> for each --script, the -ex commands, in the order given
+	Batch               bool     // quit once the commands given are executed
+
+	Retention checkpointmanager.RetentionPolicy // checkpoints kept by the nodes, older are pruned as new ones are recorded
+
+	CheckpointInterval time.Duration // time between automatic global checkpoints (0 - none)
+	CheckpointEvents   int           // MPI events between automatic global checkpoints (0 - none)
+}
+
+// Policies for when all nodes have exited
+const (
+	ON_COMPLETE_EXIT      = "exit"      // exit after a grace period
+	ON_COMPLETE_WAIT      = "wait"      // print the run summary and keep the console and the web UI open for inspection
+	ON_COMPLETE_KEEP_LOGS = "keep-logs" // export the session to a file, then exit
+	ON_COMPLETE_SUMMARY   = "summary"   // print the run summary, then exit
+	ON_COMPLETE_REPORT    = "report"    // write the run report with the session bundle, then exit
+)
+
+// address the web dashboard is served at with --dashboard
+const DEFAULT_DASHBOARD_ADDRESS = "localhost:3497"
+
+// address the http api is served at with --http-api
+const DEFAULT_API_ADDRESS = "localhost:3498"
+
+// lines of input, typed at the console or sent by remote clients
+var inputLines = make(chan string, 64)
+
+// A line of input read in the session, with the time it was read
+type InputLine struct {
+	Text string
+	Time time.Time
+}
+
+var inputHistory = make([]InputLine, 0)
+
+var readInputOnce sync.Once
+
+// a breakpoint command list without its commands, which follow on the next lines
+var commandListStart = regexp.MustCompile(`^(\d+ |@\S+ )?commands \S+$`)
+
+var onCompletePolicies = []string{ON_COMPLETE_EXIT, ON_COMPLETE_WAIT, ON_COMPLETE_KEEP_LOGS, ON_COMPLETE_SUMMARY, ON_COMPLETE_REPORT}
+
+// backends the nodes can record checkpoints with
+var checkpointBackends = []string{"file", "fork", "criu"}
+
+// programs the MPI job can be started with
+var launchers = []string{"mpirun", "srun"}
+
+func ParseArgs() (numProcesses int, targetPath string, sessionFile string, options LaunchOptions) {
+	args := make([]string, 0, len(os.Args))
+
+	options.OnComplete = ON_COMPLETE_EXIT
+	options.Port = rpc.DEFAULT_ORCHESTRATOR_PORT
+	options.ListenHost = "localhost"
+	options.Seed = time.Now().UnixNano()
+
+	for index := 0; index < len(os.Args); index++ {
+		arg := os.Args[index]
+
+		switch {
+		case arg == "-ex":
+			if index+1 == len(os.Args) {
+				panicArgs()
+			}
+
+			options.Commands = append(options.Commands, os.Args[index+1])
+			index++
+		case strings.HasPrefix(arg, "--script="):
+			options.Commands = append(options.Commands, "source "+strings.TrimPrefix(arg, "--script="))
+		case arg == "--batch":
+			options.Batch = true
+		case arg == "--no-aslr":
+			options.DisableASLR = true
+		case arg == "--no-stop-at-main":
+			options.NoStopAtMain = true
+		case arg == "--remote-console":
+			options.RemoteConsole = true
+		case arg == "--headless":
+			options.Headless = true
+		case arg == "--rejoin":
+			options.Rejoin = true
+		case arg == "--json":
+			options.JSON = true
+		case arg == "--http-api":
+			options.API = DEFAULT_API_ADDRESS
+		case strings.HasPrefix(arg, "--http-api="):
+			options.API = strings.TrimPrefix(arg, "--http-api=")
+			if len(options.API) == 0 {
+				panicArgs()
+			}
+		case arg == "--dashboard":
+			options.Dashboard = DEFAULT_DASHBOARD_ADDRESS
+		case strings.HasPrefix(arg, "--dashboard="):
+			options.Dashboard = strings.TrimPrefix(arg, "--dashboard=")
+			if len(options.Dashboard) == 0 {
+				panicArgs()
+			}
+		case strings.HasPrefix(arg, "--port="):
+			options.Port = parsePositiveInt(strings.TrimPrefix(arg, "--port="))
+		case strings.HasPrefix(arg, "--listen="):
+			options.ListenHost, options.Port = parseListenAddress(strings.TrimPrefix(arg, "--listen="), options.Port)
+		case strings.HasPrefix(arg, "--advertise="):
+			options.AdvertiseHost = strings.TrimPrefix(arg, "--advertise=")
+			if len(options.AdvertiseHost) == 0 {
+				panicArgs()
+			}
+		case strings.HasPrefix(arg, "--storage-dir="):
+			options.StorageDir = strings.TrimPrefix(arg, "--storage-dir=")
+		case arg == "--require-same-binary":
+			options.RequireSameBinary = true
+		case arg == "--deterministic-replay":
+			options.DeterministicReplay = true
+		case strings.HasPrefix(arg, "--watchdog="):
+			options.WatchdogTimeout = strings.TrimPrefix(arg, "--watchdog=")
+			if _, err := strconv.Atoi(options.WatchdogTimeout); err != nil {
+				panicArgs()
+			}
+		case strings.HasPrefix(arg, "--launcher="):
+			options.Launcher = strings.TrimPrefix(arg, "--launcher=")
+			if !isLauncher(options.Launcher) {
+				panicArgs()
+			}
+		case strings.HasPrefix(arg, "--checkpoint-backend="):
+			options.CheckpointBackend = strings.TrimPrefix(arg, "--checkpoint-backend=")
+			if !isCheckpointBackend(options.CheckpointBackend) {
+				panicArgs()
+			}
+		case strings.HasPrefix(arg, "--keep-last="):
+			options.Retention.KeepLast = parsePositiveInt(strings.TrimPrefix(arg, "--keep-last="))
+		case strings.HasPrefix(arg, "--keep-every="):
+			options.Retention.KeepEvery = parsePositiveInt(strings.TrimPrefix(arg, "--keep-every="))
+		case strings.HasPrefix(arg, "--window="):
+			options.Retention.Window = time.Duration(parsePositiveInt(strings.TrimPrefix(arg, "--window="))) * time.Second
+		case strings.HasPrefix(arg, "--window-events="):
+			options.Retention.WindowEvents = parsePositiveInt(strings.TrimPrefix(arg, "--window-events="))
+		case strings.HasPrefix(arg, "--payload-cap="):
+			payloadCap, err := utils.ParseByteSize(strings.TrimPrefix(arg, "--payload-cap="))
+			if err != nil {
+				panicArgs()
+			}
+			options.PayloadCap = fmt.Sprint(payloadCap)
+		case strings.HasPrefix(arg, "--max-checkpoint-bytes="):
+			options.Retention.MaxBytes = parseByteSize(strings.TrimPrefix(arg, "--max-checkpoint-bytes="))
+		case strings.HasPrefix(arg, "--checkpoint-interval="):
+			options.CheckpointInterval = time.Duration(parsePositiveInt(strings.TrimPrefix(arg, "--checkpoint-interval="))) * time.Second
+		case strings.HasPrefix(arg, "--checkpoint-events="):
+			options.CheckpointEvents = parsePositiveInt(strings.TrimPrefix(arg, "--checkpoint-events="))
+		case strings.HasPrefix(arg, "--seed="):
+			seed, err := strconv.ParseInt(strings.TrimPrefix(arg, "--seed="), 10, 64)
+			if err != nil || seed == 0 {
+				panicArgs()
+			}
+			options.Seed = seed
+		case strings.HasPrefix(arg, "--config="):
+			options.ConfigFile = strings.TrimPrefix(arg, "--config=")
+		case strings.HasPrefix(arg, "--on-complete="):
+			options.OnComplete = strings.TrimPrefix(arg, "--on-complete=")
+			if !isOnCompletePolicy(options.OnComplete) {
+				panicArgs()
+			}
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	if len(args) < 2 {
+		panicArgs()
+	}
 
-	if len(args) > 3 || len(args) < 2 {
+	// read-only viewer mode for an exported session
+	if args[1] == "view" && len(args) == 3 {
+		return 0, "", args[2], options
+	}
+
+	// the job started like with the launcher, orchestrator run -np <n> <target>
+	if args[1] == "run" {
+		numProcesses, targetPath = parseRunArgs(args[2:])
+
+		if len(options.Launcher) == 0 {
+			options.Launcher = defaultLauncher()
+		}
+		checkTargetFile(targetPath)
+
+		return numProcesses, targetPath, "", options
+	}
+
+	if len(options.Launcher) == 0 {
+		options.Launcher = "mpirun"
+	}
+
+	// nodes deployed to remote hosts
+	if args[1] == "deploy" && len(args) == 5 {
+		// the hosts are given to mpirun
+		if options.Launcher != "mpirun" {
+			panicArgs()
+		}
+
+		options.DeployHosts = strings.Split(args[2], ",")
+		args = append(args[:1], args[3:]...)
+	}
+
+	if len(args) != 3 {
 		panicArgs()
 	}
 
@@ -29,6 +255,12 @@ func ParseArgs() (numProcesses int, targetPath string) {
 	}
 
 	targetPath = args[2]
+	checkTargetFile(targetPath)
+
+	return numProcesses, targetPath, "", options
+}
+
+func checkTargetFile(targetPath string) {
 	file, err := os.Stat(targetPath)
 	utils.Must(err)
 	if file.IsDir() {
@@ -36,12 +268,114 @@ func ParseArgs() (numProcesses int, targetPath string) {
 	}
 
 	filepath.EvalSymlinks(targetPath)
+}
+
+// Parses the arguments of run: the number of processes (-np <n>, or -n <n> as for srun) and the target.
+// Within a Slurm allocation the number of processes defaults to the tasks of the allocation
+func parseRunArgs(args []string) (numProcesses int, targetPath string) {
+	for index := 0; index < len(args); index++ {
+		switch args[index] {
+		case "-np", "-n":
+			if index+1 == len(args) {
+				panicArgs()
+			}
+
+			numProcesses = parsePositiveInt(args[index+1])
+			index++
+		default:
+			if len(targetPath) > 0 {
+				panicArgs()
+			}
+			targetPath = args[index]
+		}
+	}
+
+	if numProcesses == 0 {
+		tasks, err := strconv.Atoi(os.Getenv("SLURM_NTASKS"))
+		if err != nil || tasks < 1 {
+			panicArgs()
+		}
+		numProcesses = tasks
+	}
+
+	if len(targetPath) == 0 {
+		panicArgs()
+	}
 
 	return numProcesses, targetPath
 }
 
+// Returns the launcher for run: srun within a Slurm allocation, mpirun otherwise
+func defaultLauncher() string {
+	if len(os.Getenv("SLURM_JOB_ID")) > 0 {
+		if _, err := exec.LookPath("srun"); err == nil {
+			return "srun"
+		}
+	}
+
+	return "mpirun"
+}
+
+func isOnCompletePolicy(policy string) bool {
+	for _, onCompletePolicy := range onCompletePolicies {
+		if policy == onCompletePolicy {
+			return true
+		}
+	}
+	return false
+}
+
+func isLauncher(launcher string) bool {
+	for _, knownLauncher := range launchers {
+		if launcher == knownLauncher {
+			return true
+		}
+	}
+	return false
+}
+
+func isCheckpointBackend(backend string) bool {
+	for _, checkpointBackend := range checkpointBackends {
+		if backend == checkpointBackend {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses the address given to --listen, a host or host:port. The port given on its own is kept if the address has none
+func parseListenAddress(address string, port int) (string, int) {
+	host, listenPort, err := net.SplitHostPort(address)
+	if err != nil {
+		return address, port
+	}
+
+	return host, parsePositiveInt(listenPort)
+}
+
+func parsePositiveInt(value string) int {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		panicArgs()
+	}
+	return number
+}
+
+// Parses a positive size in bytes, optionally suffixed with K, M or G
+func parseByteSize(value string) uint64 {
+	size, err := utils.ParseByteSize(value)
+	if err != nil || size == 0 {
+		panicArgs()
+	}
+	return size
+}
+
 func panicArgs() {
-	logger.Error("usage: orchestrator <num_processes> <target_file>")
+	logger.Error("usage: orchestrator <num_processes> <target_file> [--no-aslr] [--no-stop-at-main] [--watchdog=<seconds>] [--config=<file>] [--on-complete={%s}] [--checkpoint-backend={%s}] [--keep-last=<n>] [--keep-every=<k>] [--max-checkpoint-bytes=<n>[K|M|G]] [--window=<seconds>] [--window-events=<m>] [--checkpoint-interval=<seconds>] [--checkpoint-events=<m>] [--deterministic-replay] [--payload-cap=<n>[K|M|G]] [--require-same-binary] [--remote-console] [--seed=<n>] [--launcher={%s}] [--port=<n>] [--listen=<host>[:<port>]] [--advertise=<host>] [--headless] [--storage-dir=<dir>] [--rejoin] [--json] [--dashboard[=<host>:<port>]] [--http-api[=<host>:<port>]] [--script=<file>] [-ex <command>] [--batch]", strings.Join(onCompletePolicies, ","), strings.Join(checkpointBackends, ","), strings.Join(launchers, ","))
+	logger.Error("       orchestrator run [-np <num_processes>] <target_file> [options]")
+	logger.Error("       orchestrator deploy <host>[,<host>...] <num_processes> <target_file> [options]")
+	logger.Error("       orchestrator view <session_file>")
+	logger.Error("       orchestrator serve [--sessions-dir=<dir>], orchestrator session start|list|stop, see the readme")
 	os.Exit(2)
 }
 
@@ -49,48 +383,213 @@ func PrintInstructions() {
 
 	fmt.Print("\nAvailable commands:\n\n")
 
-	fmt.Println("  <nid> b <lineNr> \tset breakpoint")
+	fmt.Println("  <nid> b [<file>:]<lineNr>  set breakpoint")
+	fmt.Println("  <nid> b <function> \tset breakpoint at a function (e.g. Solver::step)")
+	fmt.Println("  <nid> b <lineNr|function> if <condition>  set conditional breakpoint (e.g. $rank == 0 && $hitcount > 5)")
+	fmt.Println("  [nid] break-iter [<file>:]<line> <n>  stop at iteration n of the loop at the line (all nodes without nid)")
+	fmt.Println("  <nid> info iteration  print how many times the current line has executed, counted by its breakpoint")
 	fmt.Println("  <nid> s \t\tsingle-step forward")
+	fmt.Println("  <nid> stepi [n] \tstep n machine instructions (default 1), printing the instruction stopped at with its source line, also si")
+	fmt.Println("  <nid> skip -file <glob> | -function <name>  do not descend into a source file, library or function when stepping; skip lists them, skip clear removes them")
 	fmt.Println("  <nid> c \t\tcontinue execution")
-	fmt.Println("  <nid> p <var>  \tprint a variable")
-	fmt.Println("        cp  \t\tlist recorded checkpoints")
-	fmt.Println("        r <checkpoint id>  \trollback to checkpoint")
+	fmt.Println("  <nid> until <location>  continue to [<file>:]<line> or a function with a temporary breakpoint, also u")
+	fmt.Println("  <nid> detach \tlet the node run on at full speed, recording its MPI events and checkpoints, until reattached")
+	fmt.Println("  <nid> attach \tstop a detached node where it is and insert its breakpoints again")
+	fmt.Println("  <nid> rsi [n] \tstep back n instructions (default 1)")
+	fmt.Println("  <nid> rc \t\tcontinue back to the last breakpoint hit since the last checkpoint, also reverse-continue")
+	fmt.Println("  <nid> rs \t\tstep back to the previous source line executed, also reverse-step")
+	fmt.Println("  <nid> rn \t\tstep back to the previous source line executed in the function or its callers, also reverse-next")
+	fmt.Println("  <nid> lastwrite <var>  step back to the last write to a variable (or a.b, p->x, a[i]) since the last checkpoint, with its old and new value")
+	fmt.Println("  <nid> p <expr>  \tprint a variable or expression, e.g. p->x * 2, *buf, a[i].y, (long)n ($rank, $size, $event, $checkpoint, $hitcount for convenience variables)")
+	fmt.Println("  <nid> p <arr>(i,j) \tprint an array element, a(1,2) in Fortran, a[0][1] in C")
+	fmt.Println("  <nid> p/<f> <expr>  print the bytes of a value as hex (x), signed (d) or unsigned (u) decimal, octal (o), binary (t), a character (c) or a float (f), strings as raw bytes (r)")
+	fmt.Println("  <nid> vars <expr>  \tprint a variable or expression one level deep, with handles to expand its fields or elements")
+	fmt.Println("  <nid> vars #<handle> [<start> [<count>]]  print the fields, elements or pointed-to value of an expanded value")
+	fmt.Println("  <nid> display[/<f>] <expr>  print an expression each time the node stops, without one print all displays")
+	fmt.Println("  <nid> undisplay [<n> ...]  remove displays by their numbers, all without numbers")
+	fmt.Println("  <nid> whatis <expr>  print the declared type of a variable or expression")
+	fmt.Println("  <nid> ptype <expr|type>  print a type with the members of its structures, unions and enumerations, their offsets and sizes")
+	fmt.Println("  <nid> commands <location> [<command>; ...]  execute commands whenever the breakpoint at [<file>:]<line> or a function stops the node, continue last to run on; without commands they are read up to end")
+	fmt.Println(`  <nid> trace <location> "<format>" [<argument>, ...]  log a printf-formatted line of the arguments whenever the node passes [<file>:]<line> or a function, without stopping`)
+	fmt.Println("  <nid> list [[<file>:]<line>]  list the source around the last stop or a line, read from the machine of the node, short l")
+	fmt.Println("  <nid> info goroutines  list goroutines (go targets)")
+	fmt.Println("  <nid> info jit  list the functions generated at runtime, from the GDB JIT interface and perf map files")
+	fmt.Println("  <nid> bt  \t\tprint the call stack, also backtrace")
+	fmt.Println("  <nid> goroutine <n> bt  print the call stack of a goroutine")
+	fmt.Println("  [nid] inject <fault> [at <MPI op>] [on <ranks>]  inject drop-message, delay <ms> or error-return <code> at MPI calls (all nodes without nid)")
+	fmt.Println("  [nid] inject [clear]  list or clear injected faults")
+	fmt.Println("  <nid> hash <var|addr> [len]  print the hash of a buffer, of the memory a pointer points to")
+	fmt.Println("  [nid] hash auto <var|addr> [len]  hash a buffer at every checkpoint (all nodes without nid)")
+	fmt.Println("  [nid] hash auto [clear]  list or clear the buffers hashed at checkpoints")
+	fmt.Println("  <nid> info registers  print the registers, with the floating-point control and status registers decoded")
+	fmt.Println("  [nid] fp [mask|unmask <exception>|all]  show the floating-point environment or (un)mask invalid, denormal, divzero, overflow, underflow or inexact (all nodes without nid)")
+	fmt.Println("  [nid] fp round <nearest|down|up|zero>  set the floating-point rounding mode (all nodes without nid)")
+	fmt.Println("  [nid] nan trap [on|off]  stop at the instruction producing a NaN (all nodes without nid)")
+	fmt.Println("  [nid] catch output [<regex>|clear]  stop when a line of stdout or stderr matches (all nodes without nid), list or clear the patterns")
+	fmt.Println("  [nid] payload cap [<n>[K|M|G]]  show or change the bytes of sent messages recorded, the rest by its hash (all nodes without nid)")
+	fmt.Println("        cp  \t\tlist recorded checkpoints with their metadata, also checkpoint list")
+	fmt.Println("        checkpoint name <id> <label>  name a checkpoint, to roll back to it by the label")
+	fmt.Println("        checkpoint prune [<id>...]  release checkpoints, those beyond the retention policy if none are given")
+	fmt.Println("        checkpoint auto [<n>s] [<m>] | off  take global checkpoints every n seconds and/or m MPI events")
+	fmt.Println("        window [<n>s] [<m>] | off  keep the checkpoints of the last n seconds and/or m events of each node for rollbacks")
+	fmt.Println("        wheretree  \tmerge the call stacks of all nodes into a tree counting the nodes at each frame, interrupting running nodes")
+	fmt.Println("        status  \tshow cpu, memory and i/o usage of the nodes")
+	fmt.Println("        capabilities  \tprint the supported features of the session as json")
+	fmt.Println("        checkpoint  \ttake a consistent global checkpoint of the stopped nodes")
+	fmt.Println("        rollback <checkpoint id|label>  roll all affected nodes back to a checkpoint (or global checkpoint), short r")
+	fmt.Println("        rollback --node <nid> <checkpoint id|label>  roll a node back alone, replaying the messages it received from the message log")
+	fmt.Println("        causal <nid> from <nid> after [<file>:]<line>  stop the first node at receiving a message the second sent after passing the line")
+	fmt.Println("        causal [clear]  list or clear the causal breakpoints")
+	fmt.Println("        group [<name> <nodes>]  name a group of nodes (e.g. 0-3,6), or list the groups")
+	fmt.Println("        group clear <name>  remove a group")
+	fmt.Println("        all-stop [on|off]  show or toggle interrupting the running nodes when a node stops at a breakpoint")
+	fmt.Println("        check messages  list the sends without a matching receive and vice versa, pending or lost")
+	fmt.Println("        inspect message <id>  print the payload of a message event")
+	fmt.Println("        hash history [<var|addr>]  list the checkpoints the buffers hashed at checkpoints changed at")
+	fmt.Println("        reorder <receive id>  list the messages a wildcard receive could have received instead")
+	fmt.Println("        reorder <receive id> <send id>  roll back and replay the receive with the message of another send")
+	fmt.Println("        invariant [<condition>|clear]  declare, list or clear invariants checked by explore")
+	fmt.Println("        explore <receive id> [runs]  replay the window from a wildcard receive with other message interleavings")
+	fmt.Println("        export <file>  \texport the session for the viewer")
+	fmt.Println("        report [<file>]  write a Markdown (or .html) report of the session, with the session exported next to it")
+	fmt.Println("        emit-reproducer [<file>]  write a script rebuilding the target and replaying the commands of the session from scratch")
 
 	fmt.Println("        q  \t\tquit")
+	fmt.Println("        alias <name> <command>  \tdefine an alias for a command")
+	fmt.Println("        source <file>  \texecute the commands of a file, a progress command waiting for its nodes to stop")
 	fmt.Println("     help  \t\tshow this again")
 	fmt.Println()
 	fmt.Printf("  nid (node id) in %v\n", nodeconnection.GetRegisteredIds())
+	fmt.Println("  @<nodes> <command>  run a node command on several nodes, e.g. @2 p x, @1-4 b 30, @0,3 s, @all c or @<group> c")
+	fmt.Println("  <command> -json  print the results of a node command as json, a line per node")
+
+	printUserCommands()
+	fmt.Println()
+}
+
+func PrintViewerInstructions() {
+
+	fmt.Print("\nAvailable commands (read-only session):\n\n")
+
+	fmt.Println("        cp  \t\tlist recorded checkpoints, also checkpoint list")
+	fmt.Println("        inspect message <id>  print the payload of a message event")
+	fmt.Println("        hash history [<var|addr>]  list the checkpoints the buffers hashed at checkpoints changed at")
+	fmt.Println("        check messages  list the sends without a matching receive and vice versa")
+	fmt.Println("        status  \tlist the last recorded event of each node")
+	fmt.Println("  <nid> p <expr>  \tprint the values the node printed for the expression during the session")
+	fmt.Println("  <nid> bt  \t\tprint the call stacks the node stopped with during the session")
+	fmt.Println("  <nid> <command>  print the recorded results of another node command, progress commands are not available")
+	fmt.Println("        export <file>  \texport the session again")
+	fmt.Println("        q  \t\tquit")
+	fmt.Println("     help  \t\tshow this again")
 	fmt.Println()
 }
 
 func AskForInput() *command.Command {
 	PrintPrompt()
 
-	userInput := getUserInputLine()
+	userInput, depth := nextInputLine()
+
+	userInput, ok := expandUserInput(userInput, depth)
+	if !ok {
+		return AskForInput()
+	}
+
+	userInput, jsonResult := command.CutJSONFlag(userInput)
 
-	command := parseCommandFromString(userInput)
+	userInput = readCommandList(userInput)
 
-	if command == nil {
+	cmd, hasSelector := parseSelectedCommand(userInput)
+	if !hasSelector {
+		cmd = parseCommandFromString(userInput)
+	}
+
+	if cmd == nil {
 		fmt.Println(`Invalid input. Type "help" to see available commands`)
+
+		// the rest of a user-defined command is abandoned
+		pendingLines = pendingLines[:0]
 		return AskForInput()
 	}
 
-	return command
+	cmd.JSON = jsonResult
+
+	return cmd
+}
+
+// Reads the commands of a breakpoint typed on the lines following `commands <location>`, up to `end`.
+// Returns the line with the commands appended, as if they were given on it
+func readCommandList(line string) string {
+	if !commandListStart.MatchString(line) {
+		return line
+	}
+
+	fmt.Println(`Type the commands to execute at the breakpoint, one per line, then "end"`)
+
+	commands := make([]string, 0)
+
+	// a script ending within the list ends it
+	for !batchMode || len(pendingLines) > 0 {
+		showPrompt("> ")
+
+		next, _ := nextInputLine()
+		if next = strings.TrimSpace(next); next == "end" {
+			break
+		}
+
+		if len(next) > 0 {
+			commands = append(commands, next)
+		}
+	}
+
+	return strings.TrimSpace(line + " " + strings.Join(commands, "; "))
 }
 
 func getUserInputLine() string {
+	readInputOnce.Do(func() {
+		go readStandardInput()
+	})
 
-	reader := bufio.NewReader(os.Stdin)
+	line := <-inputLines
+	hidePrompt()
 
-	text, _ := reader.ReadString('\n')
+	inputHistory = append(inputHistory, InputLine{line, time.Now()})
 
-	text = strings.Replace(text, "\n", "", 1)
+	return line
+}
+
+// Forwards the lines typed at the console to the input, until the standard input is closed
+func readStandardInput() {
+	lineEditor().SetCompleter(completeLine)
+
+	for {
+		line, err := lineEditor().ReadLine()
+		if err != nil {
+			return
+		}
+
+		inputLines <- line
+	}
+}
 
-	return text
+// Returns the lines of input that replay the session: all read so far but those quitting it or emitting reproducers
+func ReplayableInput() []InputLine {
+	lines := make([]InputLine, 0, len(inputHistory))
+
+	for _, line := range inputHistory {
+		cmd := parseCommandFromString(strings.TrimSpace(line.Text))
+		if cmd != nil && (cmd.Code == command.Quit || cmd.Code == command.EmitReproducer) {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
 }
 
 func PrintPrompt() {
-	fmt.Printf("insert command > ")
+	showPrompt("insert command > ")
 }
 
 // a command line prefixed with a pid number
@@ -102,13 +601,9 @@ func matchPidRegexp(input string, exp string) bool {
 }
 
 func AskForRollbackCommit() bool {
-	var s string
+	showPrompt("Commit rollback? (y/n): ")
 
-	fmt.Printf("Commit rollback? (y/n): ")
-	_, err := fmt.Scan(&s)
-	if err != nil {
-		panic(err)
-	}
+	s := getUserInputLine()
 
 	s = strings.TrimSpace(s)
 	s = strings.ToLower(s)
@@ -21,57 +21,192 @@ func parseCommandFromString(input string) (c *command.Command) {
 		return &command.Command{Code: command.Quit}
 	}
 
-	if input == "cp" { // list recorded checkpoints
+	if input == "cp" || input == "checkpoint list" { // list recorded checkpoints
 		return &command.Command{Code: command.ListCheckpoints}
 	}
 
+	if input == "status" { // resource usage of the nodes
+		return &command.Command{Code: command.Status}
+	}
+
+	if input == "checkpoint" { // coordinated checkpoint of all nodes
+		return &command.Command{Code: command.GlobalCheckpoint}
+	}
+
+	if input == "check messages" { // sends without a matching receive and vice versa
+		return &command.Command{Code: command.CheckMessages}
+	}
+
+	if input == "wheretree" { // call stacks of all nodes merged into a tree
+		return &command.Command{Code: command.WhereTree}
+	}
+
+	if input == "capabilities" { // supported features, as json for front-ends
+		return &command.Command{Code: command.Capabilities}
+	}
+
 	pieces := strings.Split(input, " ")
 
-	matchesGlobalRestore := regexp.MustCompile("^r .+").Match([]byte(input))
+	matchesGlobalInject := regexp.MustCompile("^inject( .+)?$").Match([]byte(input))
+	if matchesGlobalInject { // fault injection on all nodes
+		return &command.Command{NodeId: command.AllNodes, Code: command.InjectFault, Argument: strings.TrimPrefix(input, "inject")}
+	}
+
+	matchesGlobalBreakIteration := regexp.MustCompile(`^break-iter (\S+:)?\d+ \d+$`).Match([]byte(input))
+	if matchesGlobalBreakIteration { // breakpoint at an iteration of a loop, on all nodes
+		return &command.Command{NodeId: command.AllNodes, Code: command.BreakIteration, Argument: strings.TrimPrefix(input, "break-iter ")}
+	}
+
+	matchesGlobalPayloadCap := regexp.MustCompile(`^payload cap( \S+)?$`).Match([]byte(input))
+	if matchesGlobalPayloadCap { // bytes of sent messages recorded, on all nodes
+		return &command.Command{NodeId: command.AllNodes, Code: command.PayloadCap, Argument: strings.TrimPrefix(input, "payload cap")}
+	}
+
+	matchesGlobalNaNTrap := regexp.MustCompile(`^nan trap( on| off)?$`).Match([]byte(input))
+	if matchesGlobalNaNTrap { // stop at the instruction producing a NaN, on all nodes
+		return &command.Command{NodeId: command.AllNodes, Code: command.TrapNaN, Argument: strings.TrimPrefix(input, "nan trap")}
+	}
+
+	matchesGlobalCatchOutput := regexp.MustCompile(`^catch output( .+)?$`).Match([]byte(input))
+	if matchesGlobalCatchOutput { // stop at output lines matching a pattern, on all nodes
+		return &command.Command{NodeId: command.AllNodes, Code: command.CatchOutput, Argument: strings.TrimPrefix(input, "catch output")}
+	}
+
+	matchesGlobalFPEnvironment := regexp.MustCompile(`^fp( .+)?$`).Match([]byte(input))
+	if matchesGlobalFPEnvironment { // floating-point exception masks and rounding mode, on all nodes
+		return &command.Command{NodeId: command.AllNodes, Code: command.FPEnvironment, Argument: strings.TrimPrefix(input, "fp")}
+	}
+
+	matchesGlobalAutoHash := regexp.MustCompile(`^hash auto( .+)?$`).Match([]byte(input))
+	if matchesGlobalAutoHash { // buffers hashed at every checkpoint, on all nodes
+		return &command.Command{NodeId: command.AllNodes, Code: command.AutoHashBuffer, Argument: strings.TrimPrefix(input, "hash auto")}
+	}
+
+	matchesHashHistory := regexp.MustCompile(`^hash history( \S+)?$`).Match([]byte(input))
+	if matchesHashHistory { // checkpoints the hashed buffers changed at
+		return &command.Command{Code: command.HashHistory, Argument: strings.TrimSpace(strings.TrimPrefix(input, "hash history"))}
+	}
+
+	matchesNodeRestore := regexp.MustCompile(`^(r|rollback) --node \d+ \S+$`).Match([]byte(input))
+	if matchesNodeRestore { // rollback of one node alone, its received messages are replayed from the message log
+		return &command.Command{Code: command.NodeRollback, Argument: strings.Join(pieces[2:], " ")}
+	}
+
+	matchesGlobalRestore := regexp.MustCompile("^(r|rollback) .+").Match([]byte(input))
 	if matchesGlobalRestore { // rollback operation (across n>=1 nodes)
 		checkpointId := pieces[1]
 		return &command.Command{Code: command.GlobalRollback, Argument: checkpointId}
 	}
 
-	// Node-specific commands (relayed to designated node for execution)
+	matchesNameCheckpoint := regexp.MustCompile(`^checkpoint name \S+ \S+$`).Match([]byte(input))
+	if matchesNameCheckpoint { // label a checkpoint, to refer to it by the label
+		return &command.Command{Code: command.NameCheckpoint, Argument: strings.TrimPrefix(input, "checkpoint name ")}
+	}
 
-	matchesPidRegexp := regexp.MustCompile(`^\d+ .+`).Match([]byte(input))
+	matchesPruneCheckpoints := regexp.MustCompile(`^checkpoint prune( \S+)*$`).Match([]byte(input))
+	if matchesPruneCheckpoints { // release checkpoints, those beyond the retention policy if none are given
+		return &command.Command{Code: command.PruneCheckpoints, Argument: strings.TrimSpace(strings.TrimPrefix(input, "checkpoint prune"))}
+	}
 
-	if !matchesPidRegexp {
-		logger.Warn("error parsing break command - no pid specified")
-		return nil
+	matchesAutoCheckpoint := regexp.MustCompile(`^checkpoint auto( \S+)*$`).Match([]byte(input))
+	if matchesAutoCheckpoint { // show or change the schedule of automatic global checkpoints
+		return &command.Command{Code: command.AutoCheckpoint, Argument: strings.TrimSpace(strings.TrimPrefix(input, "checkpoint auto"))}
 	}
 
-	pid, _ := strconv.Atoi(pieces[0])
+	matchesReverseWindow := regexp.MustCompile(`^window( \S+)*$`).Match([]byte(input))
+	if matchesReverseWindow { // show or change the reverse-execution window
+		return &command.Command{Code: command.ReverseWindow, Argument: strings.TrimSpace(strings.TrimPrefix(input, "window"))}
+	}
 
-	switch {
+	matchesInspectMessage := regexp.MustCompile(`^inspect message \S+$`).Match([]byte(input))
+	if matchesInspectMessage { // print the payload of a message event
+		return &command.Command{Code: command.InspectMessage, Argument: pieces[2]}
+	}
 
-	case matchPidRegexp(input, `[b|B] \d+`): // breakpoint
+	matchesReorder := regexp.MustCompile(`^reorder \S+( \S+)?$`).Match([]byte(input))
+	if matchesReorder { // list or replay alternative message orders of a wildcard receive
+		return &command.Command{Code: command.ReorderMessages, Argument: strings.TrimPrefix(input, "reorder ")}
+	}
 
-		lineNr, _ := strconv.Atoi(pieces[2])
+	matchesExplore := regexp.MustCompile(`^explore \S+( \d+)?$`).Match([]byte(input))
+	if matchesExplore { // replay a window of history with other message interleavings
+		return &command.Command{Code: command.Explore, Argument: strings.TrimPrefix(input, "explore ")}
+	}
 
-		return &command.Command{NodeId: pid, Code: command.Bpoint, Argument: lineNr}
+	matchesInvariant := regexp.MustCompile("^invariant( .+)?$").Match([]byte(input))
+	if matchesInvariant { // conditions checked after each run of an exploration
+		return &command.Command{Code: command.Invariant, Argument: strings.TrimPrefix(input, "invariant")}
+	}
 
-	case matchPidRegexp(input, "[c|C]"): // continue
-		return &command.Command{NodeId: pid, Code: command.Cont}
+	matchesReport := regexp.MustCompile(`^report( \S+)?$`).Match([]byte(input))
+	if matchesReport { // timeline, breakpoints, invariant violations and backtraces of the session
+		return &command.Command{Code: command.RunReport, Argument: strings.TrimSpace(strings.TrimPrefix(input, "report"))}
+	}
+
+	matchesCausal := regexp.MustCompile(`^causal( clear| \d+ from \d+ after \S+)?$`).Match([]byte(input))
+	if matchesCausal { // stop a node receiving a message sent after the sender passed a line, list or clear
+		return &command.Command{Code: command.CausalBreakpoint, Argument: strings.TrimSpace(strings.TrimPrefix(input, "causal"))}
+	}
+
+	matchesAllStop := regexp.MustCompile(`^all-stop( on| off)?$`).Match([]byte(input))
+	if matchesAllStop { // interrupt the running nodes when a node stops at a breakpoint
+		return &command.Command{Code: command.AllStop, Argument: strings.TrimSpace(strings.TrimPrefix(input, "all-stop"))}
+	}
 
-	case matchPidRegexp(input, "[s|S]"): // single step
-		return &command.Command{NodeId: pid, Code: command.SingleStep}
+	matchesNodeGroup := regexp.MustCompile(`^group( clear \S+| \S+ \S+)?$`).Match([]byte(input))
+	if matchesNodeGroup { // name a group of nodes for target selectors (@<group>), list or remove groups
+		return &command.Command{Code: command.NodeGroup, Argument: strings.TrimSpace(strings.TrimPrefix(input, "group"))}
+	}
 
-	case matchPidRegexp(input, `[p|P] [a-zA-Z_][a-zA-Z0-9_]*`): // print variable
-		identifier := strings.Split(input, " ")[2]
+	matchesEmitReproducer := regexp.MustCompile(`^emit-reproducer( \S+)?$`).Match([]byte(input))
+	if matchesEmitReproducer { // script replaying the session from scratch
+		return &command.Command{Code: command.EmitReproducer, Argument: strings.TrimSpace(strings.TrimPrefix(input, "emit-reproducer"))}
+	}
 
-		return &command.Command{NodeId: pid, Code: command.Print, Argument: identifier}
+	matchesExport := regexp.MustCompile("^export .+").Match([]byte(input))
+	if matchesExport { // write the recorded session to a file
+		filePath := pieces[1]
+		return &command.Command{Code: command.ExportSession, Argument: filePath}
+	}
 
+	// Node-specific commands (relayed to designated node for execution)
+
+	matchesPidRegexp := regexp.MustCompile(`^\d+ .+`).Match([]byte(input))
+
+	if !matchesPidRegexp {
+		logger.Warn("error parsing break command - no pid specified")
+		return nil
+	}
+
+	pid, _ := strconv.Atoi(pieces[0])
+	nodeInput := strings.SplitN(input, " ", 2)[1]
+
+	// the commands executed by the node, parsed as by the node debugger in cli mode
+	if cmd := command.ParseNodeCommand(nodeInput); cmd != nil {
+		cmd.NodeId = pid
+		return cmd
+	}
+
+	// the commands handled by the orchestrator for the node
+	switch {
 	case matchPidRegexp(input, `[r|R] .+`): // restore checkpoint with supplied id
 		checkpointId := pieces[2]
 
 		return &command.Command{NodeId: pid, Code: command.Restore, Argument: checkpointId}
 
-	case matchPidRegexp(input, `pd [a-zA-Z_][a-zA-Z0-9_]*`): // debug print
-		varName := strings.Split(input, " ")[2]
+	case matchPidRegexp(input, `detach`): // run on with the recording only, until reattached
+		return &command.Command{NodeId: pid, Code: command.Detach}
+
+	case matchPidRegexp(input, `attach`): // stop a detached node and restore its breakpoints
+		return &command.Command{NodeId: pid, Code: command.Attach}
+
+	case matchPidRegexp(input, `(l|list)( (\S+:)?\d+)?`): // source around a line, fetched from the node
+		location := ""
+		if len(pieces) > 2 {
+			location = pieces[2]
+		}
 
-		return &command.Command{NodeId: pid, Code: command.PrintInternal, Argument: varName}
+		return &command.Command{NodeId: pid, Code: command.ListSource, Argument: location}
 
 	default:
 		return nil
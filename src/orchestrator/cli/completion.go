@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// the commands executed by the orchestrator, with the kind of their first argument (empty if it is not completed)
+var globalCommands = map[string]string{
+	"help":             "",
+	"q":                "",
+	"cp":               "",
+	"checkpoint":       "",
+	"checkpoint list":  "",
+	"checkpoint name":  "",
+	"checkpoint prune": "",
+	"checkpoint auto":  "",
+	"status":           "",
+	"check messages":   "",
+	"wheretree":        "",
+	"capabilities":     "",
+	"inject":           "",
+	"break-iter":       command.ARGUMENT_LOCATION,
+	"payload cap":      "",
+	"nan trap":         "",
+	"catch output":     "",
+	"fp":               "",
+	"hash auto":        command.ARGUMENT_VARIABLE,
+	"hash history":     "",
+	"r":                "",
+	"rollback":         "",
+	"window":           "",
+	"inspect message":  "",
+	"reorder":          "",
+	"explore":          "",
+	"invariant":        "",
+	"report":           "",
+	"causal":           "",
+	"all-stop":         "",
+	"group":            "",
+	"emit-reproducer":  "",
+	"export":           "",
+	"alias":            "",
+	"source":           "",
+}
+
+// the commands handled by the orchestrator for a node, following the node id
+var orchestratedNodeCommands = map[string]string{
+	"r":      "",
+	"detach": "",
+	"attach": "",
+	"l":      command.ARGUMENT_LOCATION,
+	"list":   command.ARGUMENT_LOCATION,
+}
+
+var nodeIdPrefix = regexp.MustCompile(`^(\d+) `)
+
+// Completes the commands typed at the console with Tab: the global commands, aliases and user-defined commands,
+// and the commands following a node id or target selector. Functions, source files and variables are completed
+// by the node the command is for, the first selected or registered node otherwise
+func completeLine(line string) []string {
+	nodeId, rest := -1, line
+
+	if match := nodeIdPrefix.FindStringSubmatch(line); match != nil {
+		nodeId, _ = strconv.Atoi(match[1])
+		rest = line[len(match[0]):]
+	} else if selector, selected, found := strings.Cut(line, " "); found && strings.HasPrefix(selector, command.SELECTOR_PREFIX) {
+		nodeIds, err := command.SelectNodes(strings.TrimPrefix(selector, command.SELECTOR_PREFIX), nodeconnection.GetRegisteredIds())
+		if err == nil && len(nodeIds) > 0 {
+			nodeId = nodeIds[0]
+		}
+		rest = selected
+	}
+
+	completeArgument := func(kind string, prefix string) []string {
+		return nodeconnection.CompleteArgument(nodeId, kind, prefix)
+	}
+
+	if rest != line {
+		return command.CompleteWord(rest, nodeCommands(), completeArgument)
+	}
+
+	return command.CompleteWord(line, consoleCommands(), completeArgument)
+}
+
+// The commands following a node id
+func nodeCommands() map[string]string {
+	commands := make(map[string]string, len(command.NodeCommands)+len(orchestratedNodeCommands))
+	for phrase, kind := range command.NodeCommands {
+		commands[phrase] = kind
+	}
+	for phrase, kind := range orchestratedNodeCommands {
+		commands[phrase] = kind
+	}
+
+	return commands
+}
+
+// The commands typed without a node id: the global commands, aliases and user-defined commands
+func consoleCommands() map[string]string {
+	commands := make(map[string]string, len(globalCommands)+len(aliases)+len(userCommands))
+	for phrase, kind := range globalCommands {
+		commands[phrase] = kind
+	}
+	for name := range aliases {
+		commands[name] = ""
+	}
+	for name := range userCommands {
+		commands[name] = ""
+	}
+
+	return commands
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/readline"
+)
+
+// the prompt displayed while the console waits for input, empty while a command executes
+var prompt struct {
+	sync.Mutex
+	text string
+}
+
+// Renders the output of asynchronous events (nodes stopping at breakpoints or reporting errors, completed
+// checkpoints, commands from the web UI) as soon as it arrives. While the console waits for input, the prompt
+// is cleared, the output printed in its place and the prompt drawn again below it.
+// Input typed before the output arrived is still read, but no longer displayed
+func RenderEventsAbovePrompt() {
+	logger.SetRowPrinter(printAbovePrompt)
+}
+
+func printAbovePrompt(text string) {
+	prompt.Lock()
+	defer prompt.Unlock()
+
+	// the line editor draws the prompt and the line typed so far again
+	if lineEditor().Interactive() {
+		fmt.Printf("\r\033[K%s", text)
+		lineEditor().Redraw()
+		return
+	}
+
+	if len(prompt.text) == 0 {
+		fmt.Print(text)
+		return
+	}
+
+	// return to the start of the prompt line and clear it
+	fmt.Printf("\r\033[K%s%s", text, prompt.text)
+}
+
+// Prints the log and the prompt on the standard error, leaving the standard output to the results of node commands
+// as json (--json) and to the output of the job
+func PrintJSONOutput() {
+	promptOutput = os.Stderr
+	logger.SetRowPrinter(func(text string) {
+		fmt.Fprint(os.Stderr, text)
+	})
+}
+
+// where the prompt is shown, the standard error in json output mode
+var promptOutput io.Writer
+
+func showPrompt(text string) {
+	prompt.Lock()
+	defer prompt.Unlock()
+
+	if promptOutput == nil {
+		prompt.text = text
+	}
+
+	lineEditor().SetPrompt(text)
+}
+
+// Once a line is read, output is printed as is until the prompt is shown again
+func hidePrompt() {
+	prompt.Lock()
+	defer prompt.Unlock()
+
+	prompt.text = ""
+	lineEditor().SetPrompt("")
+}
+
+var editor *readline.Editor
+
+var createEditorOnce sync.Once
+
+// The editor of the lines typed at the console, keeping their history in the history file
+func lineEditor() *readline.Editor {
+	createEditorOnce.Do(func() {
+		editor = readline.NewEditor(consoleWriter{}, readline.HistoryFile())
+	})
+
+	return editor
+}
+
+// Writes the prompt and the line being edited where the prompt is shown, the standard output as it is
+// at the time of writing (it is redirected once the console output is captured)
+type consoleWriter struct{}
+
+func (w consoleWriter) Write(data []byte) (int, error) {
+	if promptOutput != nil {
+		return promptOutput.Write(data)
+	}
+
+	return os.Stdout.Write(data)
+}
+
+// Gives the terminal back its mode while a line is edited, before the orchestrator exits
+func RestoreTerminal() {
+	lineEditor().Restore()
+}
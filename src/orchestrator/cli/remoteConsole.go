@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"github.com/ottmartens/cc-rev-db/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Console output kept for remote clients, the oldest output is dropped beyond this size
+const CONSOLE_BUFFER_SIZE = 1 << 20
+
+var consoleOutput = struct {
+	sync.Mutex
+	text    []byte
+	start   int           // offset of the first kept byte in all output
+	written chan struct{} // closed once more output is written, replaced by a new one then
+}{written: make(chan struct{})}
+
+// The console served over the rpc api to remote clients (bin/remote-console).
+// Lines sent by clients are executed as if typed at the console, and the console output is streamed to them
+type RemoteConsole struct {
+	pb.UnimplementedRemoteConsoleServer
+}
+
+func (c RemoteConsole) Input(ctx context.Context, input *pb.ConsoleInput) (*emptypb.Empty, error) {
+	SubmitInput(input.Line)
+	return &emptypb.Empty{}, nil
+}
+
+// Streams the output from the offset on, as it is written, until the client goes away
+func (c RemoteConsole) Output(request *pb.OutputRequest, stream grpc.ServerStreamingServer[pb.ConsoleOutput]) error {
+	offset := int(request.Offset)
+
+	for {
+		output, written := outputAndWait(offset)
+
+		if len(output.Text) > 0 {
+			if err := stream.Send(output.Proto()); err != nil {
+				return err
+			}
+		}
+		offset = output.Offset
+
+		select {
+		case <-written:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// Executes a line sent by a remote client (the remote console, the dashboard) as if typed at the console
+func SubmitInput(line string) {
+	fmt.Println(line)
+
+	inputLines <- line
+}
+
+// Returns the console output from the offset on. Clients falling behind the kept output resume from its start
+func OutputFrom(offset int) rpc.ConsoleOutput {
+	output, _ := outputAndWait(offset)
+	return output
+}
+
+// Returns the console output from the offset on, and the channel closed once more is written after it
+func outputAndWait(offset int) (rpc.ConsoleOutput, <-chan struct{}) {
+	consoleOutput.Lock()
+	defer consoleOutput.Unlock()
+
+	end := consoleOutput.start + len(consoleOutput.text)
+
+	if offset < consoleOutput.start || offset > end {
+		offset = consoleOutput.start
+	}
+
+	return rpc.ConsoleOutput{
+		Text:   string(consoleOutput.text[offset-consoleOutput.start:]),
+		Offset: end,
+	}, consoleOutput.written
+}
+
+// Duplicates the standard output, including that of the MPI job started afterwards, into the console output
+func CaptureConsoleOutput() {
+	reader, writer, err := os.Pipe()
+	utils.Must(err)
+
+	terminal := os.Stdout
+	os.Stdout = writer
+
+	go func() {
+		buffer := make([]byte, 4096)
+
+		for {
+			n, err := reader.Read(buffer)
+			if n > 0 {
+				terminal.Write(buffer[:n])
+				appendConsoleOutput(buffer[:n])
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func appendConsoleOutput(data []byte) {
+	consoleOutput.Lock()
+	defer consoleOutput.Unlock()
+
+	consoleOutput.text = append(consoleOutput.text, data...)
+
+	if excess := len(consoleOutput.text) - CONSOLE_BUFFER_SIZE; excess > 0 {
+		consoleOutput.text = consoleOutput.text[excess:]
+		consoleOutput.start += excess
+	}
+
+	close(consoleOutput.written)
+	consoleOutput.written = make(chan struct{})
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Commands read from script files (source <file>, --script=<file>) and given on the command line (-ex <command>).
+// They are executed like lines typed at the console, except that a progress command waits for its nodes to stop
+// before the next line is read, so the inspections following it see the nodes where they stopped
+
+// time a scripted progress command waits for its nodes to stop, the script then goes on
+const SCRIPT_STOP_TIMEOUT = 10 * time.Minute
+
+// whether the last line of input was read from a script
+var scriptedLine bool
+
+// quit once the commands given on the command line are executed (--batch)
+var batchMode bool
+
+// Queues the commands given on the command line, executed before the console is read. In batch mode
+// the orchestrator quits once they are executed, or one of them is invalid
+func QueueStartupCommands(lines []string, batch bool) {
+	batchMode = batch
+
+	for _, line := range lines {
+		pendingLines = append(pendingLines, pendingLine{line: line, scripted: true})
+	}
+}
+
+// Whether the last command read came from a script or the command line, its progress waiting for the nodes to stop
+func ScriptedInput() bool {
+	return scriptedLine
+}
+
+// Queues the commands of a script file as the next input (source <file>)
+func sourceScript(filePath string, depth int) error {
+	if len(filePath) == 0 {
+		return fmt.Errorf("expected source <file>")
+	}
+
+	lines, err := command.ReadScript(filePath)
+	if err != nil {
+		return fmt.Errorf("cannot read script: %w", err)
+	}
+
+	logger.Info("Executing %d commands of %v", len(lines), filePath)
+
+	script := make([]pendingLine, 0, len(lines))
+	for _, line := range lines {
+		script = append(script, pendingLine{line: line, depth: depth + 1, scripted: true})
+	}
+
+	pendingLines = append(script, pendingLines...)
+	return nil
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Parses a line prefixed with a target selector (@1-4 b 30) into the node-specific command, with the nodes
+// selected as its targets. Returns false if the line is not prefixed with a selector, a nil command if it is invalid
+func parseSelectedCommand(line string) (*command.Command, bool) {
+	selector, rest, ok := command.SplitSelector(line)
+	if !ok {
+		return nil, false
+	}
+
+	if len(rest) == 0 || regexp.MustCompile(`^\d+( |$)`).MatchString(rest) {
+		logger.Warn("expected @<nodes> <command> without a node id, e.g. @1-4 b 30")
+		return nil, true
+	}
+
+	nodeIds, err := command.SelectNodes(selector, nodeconnection.GetRegisteredIds())
+	if err != nil {
+		logger.Warn("%v", err)
+		return nil, true
+	}
+
+	if len(nodeIds) == 0 {
+		logger.Warn("no nodes selected by @%v", selector)
+		return nil, true
+	}
+
+	cmd := parseCommandFromString(fmt.Sprintf("%d %s", nodeIds[0], rest))
+
+	// global commands are not prefixed with a selector
+	if cmd == nil || cmd.NodeId != nodeIds[0] {
+		return nil, true
+	}
+
+	cmd.Targets = nodeIds
+	return cmd, true
+}
@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Aliases and user-defined commands, loaded from the config file:
+//
+//	# comment
+//	alias <name> <command>
+//	define <name>
+//	    <command>     (may refer to the arguments as $arg0, $arg1, ... and to their count as $argc)
+//	    ...
+//	end
+//
+// Aliases can also be added at the prompt with `alias <name> <command>`
+
+const DEFAULT_CONFIG_FILE = ".cc-rev-db"
+
+// user-defined commands expanding to other user-defined commands are limited in depth, to catch cycles
+const maxExpansionDepth = 10
+
+var aliases = make(map[string]string)
+
+var userCommands = make(map[string][]string)
+
+// an input line produced by expanding a user-defined command, or read from a script
+type pendingLine struct {
+	line     string
+	depth    int
+	scripted bool // from a script or the command line, see scripts.go
+}
+
+var pendingLines = make([]pendingLine, 0)
+
+// the config file the aliases and user-defined commands were loaded from, empty if none
+var loadedConfigFile string
+
+func LoadedConfigFile() string {
+	return loadedConfigFile
+}
+
+// Loads aliases and user-defined commands from the config file.
+// Without an explicitly supplied file, ~/.cc-rev-db is read if it exists
+func LoadUserCommands(configFile string) {
+	if len(configFile) == 0 {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return
+		}
+
+		configFile = filepath.Join(homeDir, DEFAULT_CONFIG_FILE)
+
+		if _, err := os.Stat(configFile); err != nil {
+			return
+		}
+	}
+
+	err := parseConfigFile(configFile)
+	if err != nil {
+		logger.Error("Failed to load config file %v: %v", configFile, err)
+		os.Exit(2)
+	}
+
+	loadedConfigFile = configFile
+
+	logger.Verbose("loaded %d aliases and %d user-defined commands from %v", len(aliases), len(userCommands), configFile)
+}
+
+func parseConfigFile(configFile string) error {
+	file, err := os.Open(configFile)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	lineNr := 0
+	definedCommand := "" // name of the command whose body is being read
+
+	for scanner.Scan() {
+		lineNr++
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if len(definedCommand) > 0 {
+			if line == "end" {
+				definedCommand = ""
+			} else {
+				userCommands[definedCommand] = append(userCommands[definedCommand], line)
+			}
+			continue
+		}
+
+		keyword, definition, _ := strings.Cut(line, " ")
+		definition = strings.TrimSpace(definition)
+
+		switch keyword {
+		case "alias":
+			if err := addAlias(definition); err != nil {
+				return fmt.Errorf("line %d: %v", lineNr, err)
+			}
+
+		case "define":
+			if len(definition) == 0 || strings.Contains(definition, " ") {
+				return fmt.Errorf("line %d: expected define <name>", lineNr)
+			}
+
+			definedCommand = definition
+			userCommands[definedCommand] = make([]string, 0)
+
+		default:
+			return fmt.Errorf("line %d: unknown keyword %q", lineNr, keyword)
+		}
+	}
+
+	if len(definedCommand) > 0 {
+		return fmt.Errorf("missing end of define %v", definedCommand)
+	}
+
+	return scanner.Err()
+}
+
+// Adds an alias from its definition `<name> <command>`
+func addAlias(definition string) error {
+	name, expansion, _ := strings.Cut(definition, " ")
+	expansion = strings.TrimSpace(expansion)
+
+	if len(name) == 0 || len(expansion) == 0 {
+		return fmt.Errorf("expected alias <name> <command>")
+	}
+
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("alias name %v is a node id", name)
+	}
+
+	aliases[name] = expansion
+	return nil
+}
+
+// Expands an input line. Aliases are replaced in place, both as the first word and following a node id.
+// The lines of a user-defined command are queued as the next input.
+// Returns false if the line was consumed by the expansion (a user-defined command or adding an alias)
+func expandUserInput(line string, depth int) (expanded string, ok bool) {
+	if depth > maxExpansionDepth {
+		logger.Warn("user-defined commands nested too deep, ignoring %q", line)
+		pendingLines = pendingLines[:0]
+		return "", false
+	}
+
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return line, true
+	}
+
+	if words[0] == "alias" {
+		err := addAlias(strings.TrimSpace(strings.TrimPrefix(line, "alias")))
+		if err != nil {
+			logger.Warn("%v", err)
+		}
+		return "", false
+	}
+
+	if words[0] == "source" {
+		err := sourceScript(strings.TrimSpace(strings.TrimPrefix(line, "source")), depth)
+		if err != nil {
+			logger.Warn("%v", err)
+		}
+		return "", false
+	}
+
+	// the command word follows the node id or the target selector of node-specific commands
+	commandIndex := 0
+	if _, err := strconv.Atoi(words[0]); (err == nil || strings.HasPrefix(words[0], command.SELECTOR_PREFIX)) && len(words) > 1 {
+		commandIndex = 1
+	}
+
+	// aliases are not expanded recursively, so that an alias may shadow a command
+	if expansion, isAlias := aliases[words[commandIndex]]; isAlias {
+		words[commandIndex] = expansion
+		line = strings.Join(words, " ")
+		words = strings.Fields(line)
+	}
+
+	if body, isUserCommand := userCommands[words[0]]; isUserCommand {
+		expansion := make([]pendingLine, 0, len(body))
+
+		for _, bodyLine := range body {
+			expansion = append(expansion, pendingLine{substituteArguments(bodyLine, words[1:]), depth + 1, scriptedLine})
+		}
+
+		pendingLines = append(expansion, pendingLines...)
+		return "", false
+	}
+
+	return line, true
+}
+
+// Replaces $arg0, $arg1, ... and $argc in a line of a user-defined command
+func substituteArguments(line string, args []string) string {
+	// higher indices first, so that $arg1 does not replace the start of $arg10
+	for index := len(args) - 1; index >= 0; index-- {
+		line = strings.ReplaceAll(line, fmt.Sprintf("$arg%d", index), args[index])
+	}
+
+	return strings.ReplaceAll(line, "$argc", strconv.Itoa(len(args)))
+}
+
+// Returns the next input line, either from an expanded user-defined command, a script or from the user
+func nextInputLine() (line string, depth int) {
+	if len(pendingLines) > 0 {
+		pending := pendingLines[0]
+		pendingLines = pendingLines[1:]
+
+		fmt.Println(pending.line)
+		hidePrompt()
+
+		scriptedLine = pending.scripted
+		return pending.line, pending.depth
+	}
+
+	scriptedLine = false
+
+	if batchMode {
+		fmt.Println("q")
+		hidePrompt()
+
+		logger.Info("Commands executed, quitting (--batch)")
+		return "q", 0
+	}
+
+	return getUserInputLine(), 0
+}
+
+func printUserCommands() {
+	if len(aliases) == 0 && len(userCommands) == 0 {
+		return
+	}
+
+	fmt.Print("\nUser-defined commands:\n\n")
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("  %v \t\talias for %v\n", name, aliases[name])
+	}
+
+	names = names[:0]
+	for name := range userCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("  %v \t\t%v\n", name, strings.Join(userCommands[name], "; "))
+	}
+}
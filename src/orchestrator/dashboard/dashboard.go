@@ -0,0 +1,188 @@
+package dashboard
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/orchestrator/cli"
+	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+//go:embed dashboard.html
+var page []byte
+
+// The checkpoint log as json, taken whenever it changes on the goroutine changing it,
+// so the requests of the dashboard do not read the log while it is being written
+var checkpoints = struct {
+	sync.Mutex
+	encoded   []byte
+	listeners map[chan []byte]bool
+}{encoded: []byte("{}"), listeners: make(map[chan []byte]bool)}
+
+// Serves the dashboard at the address: the state of every rank as its events arrive, the checkpoint timeline
+// with the messages between the ranks, the console output and a command box. Clients must give the session token
+// of a session that has one
+func Start(address string) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", rpc.RequireToken(servePage))
+	mux.HandleFunc("/api/nodes", rpc.RequireToken(serveNodes))
+	mux.HandleFunc("/api/checkpoints", rpc.RequireToken(serveCheckpoints))
+	mux.HandleFunc("/api/console", rpc.RequireToken(serveConsole))
+	mux.HandleFunc("/api/command", rpc.RequireToken(serveCommand))
+	mux.HandleFunc("/api/events", rpc.RequireToken(serveEvents))
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		logger.Error("Cannot serve the dashboard at %v: %v", address, err)
+		return
+	}
+
+	url := fmt.Sprintf("http://%v/", listener.Addr())
+	if len(rpc.SessionToken()) > 0 {
+		url += "?token=" + rpc.SessionToken()
+	}
+	logger.Info("Dashboard at %v", url)
+
+	nodeconnection.RegisterFrontend("dashboard")
+
+	go http.Serve(listener, mux)
+}
+
+// Records the checkpoint log for the dashboard and sends it to the connected clients. Takes a copy of the log from
+// checkpointmanager.GetCheckpointLog, never the log the nodes record into
+func UpdateCheckpoints(checkpointLog checkpointmanager.CheckpointLog) {
+	encoded, err := json.Marshal(checkpointLog)
+	if err != nil {
+		logger.Debug("Cannot encode the checkpoint log for the dashboard: %v", err)
+		return
+	}
+
+	checkpoints.Lock()
+	defer checkpoints.Unlock()
+
+	checkpoints.encoded = encoded
+
+	for listener := range checkpoints.listeners {
+		select {
+		case listener <- encoded:
+		default:
+		}
+	}
+}
+
+func servePage(writer http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != "/" {
+		http.NotFound(writer, request)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Write(page)
+}
+
+func serveNodes(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, nodeconnection.GetNodeStates())
+}
+
+func serveCheckpoints(writer http.ResponseWriter, request *http.Request) {
+	checkpoints.Lock()
+	encoded := checkpoints.encoded
+	checkpoints.Unlock()
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Write(encoded)
+}
+
+// The console output from the offset given on
+func serveConsole(writer http.ResponseWriter, request *http.Request) {
+	offset, _ := strconv.Atoi(request.URL.Query().Get("offset"))
+
+	writeJSON(writer, cli.OutputFrom(offset))
+}
+
+// Executes the line posted as if typed at the console
+func serveCommand(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "commands are sent with POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	line, err := io.ReadAll(io.LimitReader(request.Body, 4096))
+	if err != nil || len(strings.TrimSpace(string(line))) == 0 {
+		http.Error(writer, "expected a command line", http.StatusBadRequest)
+		return
+	}
+
+	cli.SubmitInput(strings.TrimSpace(string(line)))
+	writer.WriteHeader(http.StatusAccepted)
+}
+
+// Streams the events of the nodes and the changes of the checkpoint log as server-sent events, until the client
+// disconnects: `event: node` with a node event, `event: checkpoints` with the whole checkpoint log
+func serveEvents(writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		http.Error(writer, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := nodeconnection.SubscribeEvents()
+	defer unsubscribe()
+
+	checkpointUpdates := make(chan []byte, 1)
+
+	checkpoints.Lock()
+	checkpoints.listeners[checkpointUpdates] = true
+	checkpoints.Unlock()
+
+	defer func() {
+		checkpoints.Lock()
+		delete(checkpoints.listeners, checkpointUpdates)
+		checkpoints.Unlock()
+	}()
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	for {
+		select {
+		case event, open := <-events:
+			if !open {
+				return
+			}
+
+			encoded, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(writer, "event: node\ndata: %s\n\n", encoded)
+		case encoded := <-checkpointUpdates:
+			fmt.Fprintf(writer, "event: checkpoints\ndata: %s\n\n", encoded)
+		case <-request.Context().Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writeJSON(writer http.ResponseWriter, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(writer).Encode(value)
+	if err != nil {
+		logger.Debug("Cannot encode the reply of the dashboard: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// directory on the remote hosts the node debugger and the target are copied to
+const REMOTE_DEPLOY_DIR = "/tmp/cc-rev-db"
+
+// time the deployed nodes have to register and report the hosts they run on
+const DEPLOY_REGISTRATION_TIMEOUT = 60 * time.Second
+
+// ssh processes forwarding the ports between the orchestrator and the remote hosts
+var tunnels []*exec.Cmd
+
+// Copies the node debugger, the preloaded MPI wrapper (if built) and the target to the hosts.
+// Returns the paths of the node debugger and the target on the hosts
+func deployAgents(hosts []string, targetPath string) (remoteDebuggerPath string, remoteTargetPath string) {
+	files := []string{NODE_DEBUGGER_PATH, targetPath}
+
+	preloadLibrary := filepath.Join(utils.GetExecutableDir(), "libmpiwrap_preload.so")
+	if _, err := os.Stat(preloadLibrary); err == nil {
+		files = append(files, preloadLibrary)
+	}
+
+	deployed := make(map[string]bool)
+
+	// a host listed more than once runs a node per listing
+	for _, host := range hosts {
+		if deployed[host] {
+			continue
+		}
+		deployed[host] = true
+
+		logger.Info("deploying the node debugger to %v", host)
+
+		// checkpoint files are written to temp next to the node debugger
+		err := runSSH(host, "mkdir", "-p", REMOTE_DEPLOY_DIR+"/temp")
+		utils.Must(err)
+
+		scpArgs := append(append([]string{"-q"}, files...), fmt.Sprintf("%s:%s/", host, REMOTE_DEPLOY_DIR))
+
+		output, err := exec.Command("scp", scpArgs...).CombinedOutput()
+		if err != nil {
+			panic(fmt.Sprintf("copying to %v failed: %v %s", host, err, output))
+		}
+
+		// nodes reach the orchestrator through the forwarded port, unless they dial in over the network
+		if !listensOnNetwork() {
+			openTunnel(host, "-R", fmt.Sprintf("%d:localhost:%d", orchestratorPort, orchestratorPort))
+		}
+	}
+
+	// wait for the tunnels to be set up
+	if len(tunnels) > 0 {
+		time.Sleep(time.Second)
+	}
+
+	return REMOTE_DEPLOY_DIR + "/node-debugger", REMOTE_DEPLOY_DIR + "/" + filepath.Base(targetPath)
+}
+
+// Waits for the deployed nodes to register, then forwards the port of each node from the host it runs on.
+// Nodes dialing in over the network are reached at the addresses they registered with instead
+func connectDeployedNodes(hosts []string, numProcesses int) {
+	deadline := time.Now().Add(DEPLOY_REGISTRATION_TIMEOUT)
+
+	for !allNodesReportedHosts(numProcesses) {
+		if time.Now().After(deadline) {
+			panic(fmt.Sprintf("%d nodes registered in %v, want %d", len(nodeconnection.GetRegisteredIds()), DEPLOY_REGISTRATION_TIMEOUT, numProcesses))
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if listensOnNetwork() {
+		return
+	}
+
+	forwardedPorts := make(map[string][]string)
+
+	for _, nodeId := range nodeconnection.GetRegisteredIds() {
+		host := deployHostOf(checkpointmanager.NodeHost(nodeId), hosts)
+		port := rpc.NodePort(orchestratorPort, nodeId)
+
+		forwardedPorts[host] = append(forwardedPorts[host], "-L", fmt.Sprintf("%d:localhost:%d", port, port))
+	}
+
+	for host, ports := range forwardedPorts {
+		logger.Verbose("forwarding the ports of the nodes on %v", host)
+		openTunnel(host, ports...)
+	}
+
+	// wait for the tunnels to be set up
+	time.Sleep(time.Second)
+}
+
+// Returns the deployment host a node reported to run on, as named on the command line.
+// Host names are matched without their domain, as hosts may be given by their short names
+func deployHostOf(reportedHost string, hosts []string) string {
+	shortName, _, _ := strings.Cut(reportedHost, ".")
+
+	for _, host := range hosts {
+		if host == reportedHost {
+			return host
+		}
+
+		if hostShortName, _, _ := strings.Cut(host, "."); hostShortName == shortName {
+			return host
+		}
+	}
+
+	return reportedHost
+}
+
+func allNodesReportedHosts(numProcesses int) bool {
+	nodeIds := nodeconnection.GetRegisteredIds()
+
+	if len(nodeIds) < numProcesses {
+		return false
+	}
+
+	for _, nodeId := range nodeIds {
+		if len(checkpointmanager.NodeHost(nodeId)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Starts an ssh process forwarding ports to or from a host, kept running until the orchestrator exits
+func openTunnel(host string, forwards ...string) {
+	args := append([]string{"-N", "-o", "ExitOnForwardFailure=yes"}, forwards...)
+
+	tunnel := exec.Command("ssh", append(args, host)...)
+	tunnel.Stderr = os.Stderr
+
+	err := tunnel.Start()
+	utils.Must(err)
+
+	tunnels = append(tunnels, tunnel)
+}
+
+func closeTunnels() {
+	for _, tunnel := range tunnels {
+		tunnel.Process.Kill()
+	}
+}
+
+func runSSH(host string, remoteCommand ...string) error {
+	output, err := exec.Command("ssh", append([]string{host}, remoteCommand...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ssh %v %v failed: %v %s", host, strings.Join(remoteCommand, " "), err, output)
+	}
+	return nil
+}
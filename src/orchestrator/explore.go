@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// runs of an exploration without an explicit limit
+const DEFAULT_EXPLORATION_RUNS = 10
+
+// time a replayed run has to reach the breakpoints ending the window, after which it is taken to be deadlocked
+const EXPLORATION_RUN_TIMEOUT = 30 * time.Second
+
+// conditions checked on every node after each run of an exploration
+var invariants = make([]string, 0)
+
+// Declares an invariant (invariant <condition>), clears them (invariant clear) or lists them (invariant)
+func handleInvariant(cmd *command.Command) {
+	condition := strings.TrimSpace(cmd.Argument.(string))
+
+	switch condition {
+	case "":
+		if len(invariants) == 0 {
+			logger.Info("No invariants declared")
+		}
+		for index, invariant := range invariants {
+			logger.Info("%d: %v", index+1, invariant)
+		}
+	case "clear":
+		invariants = invariants[:0]
+		logger.Info("Invariants cleared")
+	default:
+		invariants = append(invariants, condition)
+		logger.Info("Invariant %d: %v", len(invariants), condition)
+	}
+}
+
+// Replays the window of history starting at a wildcard receive with the other valid interleavings of its messages
+// (explore <receive id> [runs]). Each run lets the replayed nodes continue to their next breakpoint, which bounds
+// the window, and checks the invariants on all nodes. Stops at the first run violating an invariant,
+// leaving the nodes in that interleaving for inspection
+func handleExplore(cmd *command.Command) {
+	arguments := strings.Fields(cmd.Argument.(string))
+
+	maxRuns := DEFAULT_EXPLORATION_RUNS
+	if len(arguments) > 1 {
+		maxRuns, _ = strconv.Atoi(arguments[1])
+	}
+
+	exploration := checkpointmanager.NewExploration(arguments[0])
+	if exploration == nil {
+		return
+	}
+
+	if len(invariants) == 0 {
+		logger.Warn("No invariants declared, runs are only checked for reaching the end of the window")
+	}
+
+	// the run the nodes are stopped after
+	exploration.RecordRun()
+
+	if !invariantsHold(exploration.Runs) {
+		return
+	}
+
+	for exploration.Runs < maxRuns {
+		receiveId, sendId, found := exploration.NextReordering()
+		if !found {
+			logger.Info("Exploration finished: all %d valid interleavings of the window hold the invariants", exploration.Runs)
+			return
+		}
+
+		logger.Info("Run %d: %v receives the message of %v", exploration.Runs+1, receiveId, sendId)
+
+		nodeId, source, rollbackMap := checkpointmanager.SubmitReordering(receiveId, sendId)
+		if rollbackMap == nil {
+			return
+		}
+
+		replayedNodes := make([]*command.Command, 0, len(*rollbackMap))
+		for replayedNode := range *rollbackMap {
+			replayedNodes = append(replayedNodes, &command.Command{NodeId: int(replayedNode), Code: command.Cont})
+		}
+
+		if replayReordered(nodeId, receiveId, sendId, source) != nil {
+			return
+		}
+
+		results, err := nodeconnection.HandleAndWait(replayedNodes, EXPLORATION_RUN_TIMEOUT)
+		if err != nil {
+			logger.Warn("Run %d did not reach the end of the window, possibly deadlocked: %v", exploration.Runs+1, err)
+			return
+		}
+
+		for replayedNode, result := range results {
+			if result.Exited {
+				logger.Warn("Node %d exited during run %d. Bound the window with breakpoints before the end of the program", replayedNode, exploration.Runs+1)
+				return
+			}
+		}
+
+		exploration.RecordRun()
+
+		if !invariantsHold(exploration.Runs) {
+			return
+		}
+	}
+
+	logger.Info("Exploration stopped after %d runs, continue with explore <receive id> <runs>", exploration.Runs)
+}
+
+// Checks the invariants on all nodes, returning false if any of them is violated
+func invariantsHold(run int) bool {
+	checks := make([]*command.Command, 0)
+
+	for _, invariant := range invariants {
+		for _, nodeId := range nodeconnection.GetRegisteredIds() {
+			checks = append(checks, &command.Command{NodeId: nodeId, Code: command.CheckInvariant, Argument: invariant})
+		}
+
+		results, err := nodeconnection.HandleAndWait(checks, EXPLORATION_RUN_TIMEOUT)
+		if err != nil {
+			logger.Warn("Cannot check invariant %v: %v", invariant, err)
+			return false
+		}
+
+		for nodeId, result := range results {
+			if len(result.Error) > 0 {
+				logger.Warn("Run %d violates an invariant on node %d: %v. The nodes are left in this interleaving", run, nodeId, result.Error)
+				return false
+			}
+		}
+
+		checks = checks[:0]
+	}
+
+	return true
+}
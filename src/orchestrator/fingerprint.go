@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// Returns the part of the session fingerprint known to the orchestrator, the nodes report the rest
+func newSessionFingerprint(targetPath string, numProcesses int, launcher string) rpc.SessionFingerprint {
+	target, err := filepath.Abs(targetPath)
+	if err != nil {
+		target = targetPath
+	}
+
+	return rpc.SessionFingerprint{
+		Target:            target,
+		Ranks:             numProcesses,
+		MPIImplementation: mpiImplementation(launcher),
+		DebuggerVersion:   utils.Version,
+		Started:           time.Now(),
+	}
+}
+
+// Returns the MPI implementation and version the job is started with, as reported by its launcher
+// (e.g. "mpirun (Open MPI) 4.1.2", or "slurm 23.02.6" for srun), empty if unavailable
+func mpiImplementation(launcher string) string {
+	output, err := exec.Command(launcher, "--version").CombinedOutput()
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+
+	// MPICH's launcher lists its build details, the version among them
+	if strings.HasPrefix(lines[0], "HYDRA build details") {
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); strings.HasPrefix(line, "Version:") {
+				return "MPICH (Hydra) " + strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+			}
+		}
+	}
+
+	return strings.TrimSpace(lines[0])
+}
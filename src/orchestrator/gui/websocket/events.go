@@ -3,8 +3,9 @@ package websocket
 import (
 	"github.com/ottmartens/cc-rev-db/logger"
 	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
-	"github.com/ottmartens/cc-rev-db/orchestrator/cli"
 	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils/command"
 )
 
 type MessageType string
@@ -14,6 +15,17 @@ const (
 	RollbackSubmit   MessageType = "rollbackSubmit"
 	RollbackConfirm  MessageType = "rollbackConfirm"
 	RollbackResult   MessageType = "rollbackResult"
+
+	CapabilitiesQuery MessageType = "capabilitiesQuery"
+	Capabilities      MessageType = "capabilities"
+
+	VariablesQuery MessageType = "variablesQuery"
+	Variables      MessageType = "variables"
+
+	NodeEvent MessageType = "nodeEvent"
+
+	NodeStatesQuery MessageType = "nodeStatesQuery"
+	NodeStates      MessageType = "nodeStates"
 )
 
 type CheckpointUpdateMessage struct {
@@ -41,6 +53,47 @@ type RollbackResultMessage struct {
 	Value checkpointmanager.CheckpointLog
 }
 
+type CapabilitiesMessage struct {
+	Type  MessageType
+	Value rpc.SessionCapabilities
+}
+
+// A variable of a node looked up, or a value expanded by its handle from the child at Start on (Count 0 - all)
+type VariablesRequest struct {
+	Node     int
+	Variable string // name of the variable, empty when expanding by handle
+	Handle   int
+	Start    int
+	Count    int
+}
+
+type VariablesQueryMessage struct {
+	Type  MessageType
+	Value VariablesRequest
+}
+
+// The values answering a variables query, with the request they answer
+type VariablesReply struct {
+	Request VariablesRequest
+	Values  []command.Value
+	Error   string
+}
+
+type VariablesMessage struct {
+	Type  MessageType
+	Value VariablesReply
+}
+
+type NodeEventMessage struct {
+	Type  MessageType
+	Value rpc.NodeEvent
+}
+
+type NodeStatesMessage struct {
+	Type  MessageType
+	Value []nodeconnection.NodeState
+}
+
 func SendCheckpointUpdateMessage(checkpointLog checkpointmanager.CheckpointLog) {
 	SendMessage(CheckpointUpdateMessage{
 		Type:  CheckpointUpdate,
@@ -55,8 +108,72 @@ func sendRollbackConfirm(rollbackMap checkpointmanager.RollbackMap) {
 	})
 }
 
+func sendCapabilities() {
+	SendMessage(CapabilitiesMessage{
+		Type:  Capabilities,
+		Value: nodeconnection.GetSessionCapabilities(),
+	})
+}
+
+func sendNodeStates() {
+	SendMessage(NodeStatesMessage{
+		Type:  NodeStates,
+		Value: nodeconnection.GetNodeStates(),
+	})
+}
+
+// Sends the events recorded in a session opened in the viewer, oldest first
+func SendRecordedEvents() {
+	for _, event := range checkpointmanager.GetRecordedEvents() {
+		SendMessage(NodeEventMessage{
+			Type:  NodeEvent,
+			Value: event,
+		})
+	}
+}
+
+// Forwards the events of the nodes to the client as they are reported, while one is connected
+func forwardNodeEvents() {
+	events, _ := nodeconnection.SubscribeEvents()
+
+	for event := range events {
+		if connection == nil {
+			continue
+		}
+
+		SendMessage(NodeEventMessage{
+			Type:  NodeEvent,
+			Value: event,
+		})
+	}
+}
+
+func handleVariablesQuery(request VariablesRequest) {
+	argument := request.Variable
+	if len(argument) == 0 {
+		argument = command.ChildrenArgument(request.Handle, request.Start, request.Count)
+	}
+
+	reply := VariablesReply{Request: request}
+
+	values, err := nodeconnection.ExpandValue(request.Node, argument)
+	reply.Values = values
+	if err != nil {
+		reply.Error = err.Error()
+	}
+
+	SendMessage(VariablesMessage{
+		Type:  Variables,
+		Value: reply,
+	})
+}
+
 func handleRollbackSubmit(checkpointId string) {
 	rollbackMap := checkpointmanager.SubmitForRollback(checkpointId)
+	if rollbackMap == nil {
+		return
+	}
+
 	sendRollbackConfirm(*rollbackMap)
 }
 
@@ -71,6 +188,4 @@ func handleRollbackCommit(execute bool) {
 		logger.Verbose("Cancelling pending rollback")
 		checkpointmanager.ResetPendingRollback()
 	}
-
-	cli.PrintPrompt()
 }
@@ -3,6 +3,7 @@ package websocket
 import (
 	"encoding/json"
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/websocket"
 	"github.com/ottmartens/cc-rev-db/logger"
@@ -11,6 +12,9 @@ import (
 
 var connection *websocket.Conn
 
+// held while writing a message, the connection takes one writer at a time
+var writeMutex sync.Mutex
+
 const ADDRESS = "localhost:3496"
 
 var upgrader = websocket.Upgrader{
@@ -23,6 +27,9 @@ func SendMessage(value interface{}) {
 		return
 	}
 
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
 	err := connection.WriteJSON(value)
 	if err != nil {
 		logger.Warn("Error sending ws message: %v", err)
@@ -53,6 +60,33 @@ func handler() func(http.ResponseWriter, *http.Request) {
 				break
 			}
 
+			// queries carry no value, they are told apart by their type
+			query := &struct{ Type MessageType }{}
+			err = json.Unmarshal(message, query)
+			if err == nil && query.Type == CapabilitiesQuery {
+				logger.Verbose("received capabilities query")
+				sendCapabilities()
+
+				continue
+			}
+
+			if err == nil && query.Type == NodeStatesQuery {
+				logger.Verbose("received node states query")
+				sendNodeStates()
+
+				continue
+			}
+
+			// nodes are waited for without blocking the messages that follow
+			variablesQuery := &VariablesQueryMessage{}
+			err = json.Unmarshal(message, variablesQuery)
+			if err == nil && variablesQuery.Type == VariablesQuery {
+				logger.Verbose("received variables query")
+				go handleVariablesQuery(variablesQuery.Value)
+
+				continue
+			}
+
 			rollbackSubmitMessage := &RollbackSubmitMessage{}
 			err = json.Unmarshal(message, rollbackSubmitMessage)
 			if err == nil {
@@ -83,6 +117,7 @@ func InitServer() {
 
 	logger.Verbose("starting websocket server for gui")
 	go http.ListenAndServe(ADDRESS, nil)
+	go forwardNodeEvents()
 }
 
 func WaitForClientConnection() {
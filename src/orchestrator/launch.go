@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+// Returns the arguments of the launcher starting the given number of processes, the node debugger and its
+// arguments follow. Unless the orchestrator listens on the network, srun places the tasks on the host of the
+// orchestrator, which the nodes connect to on localhost. mpirun passes the session token on to the nodes it starts,
+// srun passes on the whole environment
+func launcherArgs(launcher string, numProcesses int, networked bool) []string {
+	switch {
+	case launcher == "srun" && networked:
+		return []string{fmt.Sprintf("--ntasks=%d", numProcesses)}
+	case launcher == "srun":
+		host, err := os.Hostname()
+		if err != nil {
+			return []string{"--nodes=1", fmt.Sprintf("--ntasks=%d", numProcesses)}
+		}
+		return []string{"--nodes=1", fmt.Sprintf("--nodelist=%s", host), fmt.Sprintf("--ntasks=%d", numProcesses)}
+	case networked:
+		return []string{"-np", fmt.Sprintf("%d", numProcesses), "-x", rpc.TOKEN_ENV}
+	default:
+		return []string{"-np", fmt.Sprintf("%d", numProcesses)}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/cli"
+	"github.com/ottmartens/cc-rev-db/rpc"
+)
+
+// address the orchestrator listens on, loopback unless nodes on other hosts dial in
+var listenHost = "localhost"
+
+// Whether the orchestrator listens beyond the loopback interface, for nodes on other hosts to dial in without tunnels
+func listensOnNetwork() bool {
+	return !rpc.IsLoopback(listenHost)
+}
+
+// Sets up the session token the nodes and remote consoles dial in with when the orchestrator listens on the network.
+// A token given in the environment is shared with the remote consoles beforehand, otherwise one is generated
+func setUpSessionToken() {
+	if !listensOnNetwork() {
+		return
+	}
+
+	token := os.Getenv(rpc.TOKEN_ENV)
+	if len(token) == 0 {
+		token = rpc.NewSessionToken()
+		logger.Info("session token: %v (give it to remote consoles in %v)", token, rpc.TOKEN_ENV)
+	}
+
+	rpc.SetSessionToken(token)
+}
+
+// Returns the address the nodes reach the orchestrator at: the advertised host if given, otherwise the host
+// listened on, or the name of this host when listening on every interface
+func orchestratorAddress(options cli.LaunchOptions) string {
+	host := options.AdvertiseHost
+
+	if len(host) == 0 {
+		host = listenHost
+
+		if rpc.IsWildcard(listenHost) {
+			hostname, err := os.Hostname()
+			if err != nil {
+				panic(fmt.Sprintf("cannot determine the address of this host for the nodes, give it with --advertise: %v", err))
+			}
+			host = hostname
+		}
+	}
+
+	return net.JoinHostPort(host, fmt.Sprint(orchestratorPort))
+}
@@ -0,0 +1,58 @@
+package nodeconnection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+)
+
+// whether a node stopping at a user breakpoint interrupts the other nodes, for inspecting a roughly consistent
+// global state (all-stop mode). Off by default, the other nodes run on until their own stops
+var allStop bool
+
+// Turns all-stop mode on or off for the session
+func SetAllStop(enabled bool) {
+	allStop = enabled
+
+	if enabled {
+		logger.Info("All-stop on: a node stopping at a breakpoint interrupts the running nodes")
+	} else {
+		logger.Info("All-stop off: the other nodes run on when a node stops at a breakpoint")
+	}
+}
+
+func AllStopEnabled() bool {
+	return allStop
+}
+
+// Interrupts the nodes running a forward progress command, as the node stopped at a breakpoint.
+// The interrupted nodes report their stop like the end of the command
+func stopRunningNodes(stoppedNodeId int) {
+	interrupted := make([]int, 0)
+
+	for _, nodeId := range GetRegisteredIds() {
+		node := getNode(nodeId)
+		// detached nodes run on until reattached
+		if node == nil || nodeId == stoppedNodeId || !node.running || node.detached || node.client == nil {
+			continue
+		}
+
+		reply, err := node.client.CommandHandler().Interrupt(context.Background(), &pb.InterruptRequest{
+			Reason: fmt.Sprintf("node %d stopped at a breakpoint", stoppedNodeId),
+		})
+		if err != nil {
+			logger.Warn("Failed to interrupt node %d: %v", nodeId, err)
+			continue
+		}
+
+		if reply.Interrupted {
+			interrupted = append(interrupted, nodeId)
+		}
+	}
+
+	if len(interrupted) > 0 {
+		logger.Info("All-stop: node %d stopped at a breakpoint, interrupted nodes %v", stoppedNodeId, interrupted)
+	}
+}
@@ -0,0 +1,75 @@
+package nodeconnection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// front-ends served by the orchestrator, the console and the rpc server are always available
+var frontends = []string{"console", "rpc"}
+
+// Adds a front-end started for the session (e.g. the websocket server of the gui)
+func RegisterFrontend(name string) {
+	frontends = append(frontends, name)
+}
+
+// Returns the features of the session, for front-ends to adapt their ui to
+func GetSessionCapabilities() rpc.SessionCapabilities {
+	capabilities := rpc.SessionCapabilities{
+		Frontends:      frontends,
+		GlobalRollback: true,
+		SessionExport:  true,
+		Nodes:          make(map[int]rpc.NodeCapabilities),
+	}
+
+	for _, node := range getNodes() {
+		if node.capabilities != nil {
+			capabilities.Nodes[node.id] = *node.capabilities
+		}
+	}
+
+	return capabilities
+}
+
+func PrintCapabilities() {
+	capabilities, err := json.MarshalIndent(GetSessionCapabilities(), "", "  ")
+	if err != nil {
+		logger.Error("Failed to encode capabilities: %v", err)
+		return
+	}
+
+	fmt.Println(string(capabilities))
+}
+
+// Queries of front-ends connecting over rpc
+type Session struct {
+	pb.UnimplementedSessionServer
+}
+
+func (s Session) Capabilities(context.Context, *emptypb.Empty) (*pb.SessionCapabilities, error) {
+	return GetSessionCapabilities().Proto(), nil
+}
+
+// Streams the events of the nodes until the client goes away
+func (s Session) WatchEvents(_ *emptypb.Empty, stream grpc.ServerStreamingServer[pb.NodeEvent]) error {
+	events, unsubscribe := SubscribeEvents()
+	defer unsubscribe()
+
+	for {
+		select {
+		case event := <-events:
+			if err := stream.Send(event.Proto()); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package nodeconnection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Stops the receiving node when it receives a message the sending node sent after it passed the line
+type causalBreakpoint struct {
+	receiver int    // node id
+	sender   int    // node id
+	location string // line of the sender, [<file>:]<line>
+	passes   []int  // vector clock entries of the sender when it passed the line, the MPI events before each pass
+	hits     int
+}
+
+func (breakpoint *causalBreakpoint) String() string {
+	return fmt.Sprintf("node %d receives a message of node %d sent after it passed %v", breakpoint.receiver, breakpoint.sender, breakpoint.location)
+}
+
+var causalBreakpoints []*causalBreakpoint
+
+// the nodes report passes and receives while the console adds breakpoints
+var causalLock sync.Mutex
+
+// Adds a causal breakpoint (causal <nid> from <nid> after [<file>:]<line>): the sender reports passing the line,
+// the receiver the messages of its blocking receives. Like breakpoints, they are set on stopped nodes
+func AddCausalBreakpoint(receiver int, sender int, location string) error {
+	for _, nodeId := range []int{receiver, sender} {
+		if getNode(nodeId) == nil {
+			return fmt.Errorf("Node %d not found", nodeId)
+		}
+	}
+
+	if receiver == sender {
+		return fmt.Errorf("the receiving and sending node are the same")
+	}
+
+	causalLock.Lock()
+	breakpoint := &causalBreakpoint{receiver: receiver, sender: sender, location: location}
+	causalBreakpoints = append(causalBreakpoints, breakpoint)
+	causalLock.Unlock()
+
+	err := HandleRemotely(&command.Command{NodeId: sender, Code: command.CausalMark, Argument: location})
+	if err == nil {
+		err = HandleRemotely(&command.Command{NodeId: receiver, Code: command.CausalWatch, Argument: "on"})
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Causal breakpoint %d: %v", len(causalBreakpoints), breakpoint)
+	return nil
+}
+
+// Lists the causal breakpoints, with the passes of their lines
+func ListCausalBreakpoints() {
+	causalLock.Lock()
+	defer causalLock.Unlock()
+
+	if len(causalBreakpoints) == 0 {
+		logger.Info("No causal breakpoints")
+		return
+	}
+
+	for index, breakpoint := range causalBreakpoints {
+		passed := "not passed yet"
+		if len(breakpoint.passes) > 0 {
+			passed = fmt.Sprintf("passed after MPI events %v", formatPasses(breakpoint.passes))
+		}
+
+		logger.Info("%d: %v (%v, hit %d times)", index+1, breakpoint, passed, breakpoint.hits)
+	}
+}
+
+// Removes the causal breakpoints, with the line markers and receive checks of their nodes
+func ClearCausalBreakpoints() {
+	causalLock.Lock()
+	cleared := causalBreakpoints
+	causalBreakpoints = nil
+	causalLock.Unlock()
+
+	senders, receivers := make(map[int]bool), make(map[int]bool)
+	for _, breakpoint := range cleared {
+		senders[breakpoint.sender] = true
+		receivers[breakpoint.receiver] = true
+	}
+
+	for nodeId := range senders {
+		if getNode(nodeId) != nil {
+			HandleRemotely(&command.Command{NodeId: nodeId, Code: command.CausalMark, Argument: "clear"})
+		}
+	}
+	for nodeId := range receivers {
+		if getNode(nodeId) != nil {
+			HandleRemotely(&command.Command{NodeId: nodeId, Code: command.CausalWatch, Argument: "off"})
+		}
+	}
+
+	logger.Info("Causal breakpoints cleared")
+}
+
+func (r NodeReporter) LinePassed(ctx context.Context, message *pb.LinePass) (*emptypb.Empty, error) {
+	pass := rpc.LinePassOf(message)
+	pass.NodeId = currentNodeId(pass.NodeId)
+
+	causalLock.Lock()
+	defer causalLock.Unlock()
+
+	for _, breakpoint := range causalBreakpoints {
+		if breakpoint.sender == pass.NodeId && breakpoint.location == pass.Location {
+			breakpoint.passes = append(breakpoint.passes, pass.Clock)
+		}
+	}
+
+	logger.Debug("Node %d passed %v after %d MPI events", pass.NodeId, pass.Location, pass.Clock)
+	return &emptypb.Empty{}, nil
+}
+
+// Checks a message received by a node against the causal breakpoints. The sender reported passing the line
+// before it sent the message, so the pass is known by the time the message is received
+func (r NodeReporter) CausalReceive(ctx context.Context, message *pb.ReceivedMessage) (*pb.CausalBreakpoint, error) {
+	received := rpc.CausalReceiveOf(message)
+	received.NodeId = currentNodeId(received.NodeId)
+
+	causalLock.Lock()
+	defer causalLock.Unlock()
+
+	for index, breakpoint := range causalBreakpoints {
+		if breakpoint.receiver != received.NodeId {
+			continue
+		}
+
+		senderRank := checkpointmanager.GetNodeRank(checkpointmanager.NodeId(breakpoint.sender))
+		if senderRank == nil || *senderRank != received.Source {
+			continue
+		}
+
+		// the clock of the message counts the send, a pass after the same number of events preceded it
+		for _, pass := range breakpoint.passes {
+			if pass >= received.SenderClock {
+				continue
+			}
+
+			breakpoint.hits++
+
+			send := fmt.Sprintf("its MPI event %d", received.SenderClock)
+			if eventId, location, found := checkpointmanager.EventAtClock(checkpointmanager.NodeId(breakpoint.sender), received.SenderClock); found {
+				send = fmt.Sprintf("event %v at %v", eventId, location)
+			}
+
+			logger.Info("Causal breakpoint %d hit: %v, sent at %v", index+1, breakpoint, send)
+			return &pb.CausalBreakpoint{
+				Breakpoint: fmt.Sprintf("%d (message sent at %v, after passing %v after MPI event %d)", index+1, send, breakpoint.location, pass),
+			}, nil
+		}
+	}
+
+	return &pb.CausalBreakpoint{}, nil
+}
+
+// Forgets the passes a rollback of the node undid, those after the restored checkpoint was taken.
+// The node is back before the call of the checkpoint, which its vector clock does not count yet
+func forgetUndoneLinePasses(nodeId int, restoredClock []int) {
+	causalLock.Lock()
+	defer causalLock.Unlock()
+
+	rank := checkpointmanager.GetNodeRank(checkpointmanager.NodeId(nodeId))
+
+	for _, breakpoint := range causalBreakpoints {
+		if breakpoint.sender != nodeId {
+			continue
+		}
+
+		kept := make([]int, 0, len(breakpoint.passes))
+		for _, pass := range breakpoint.passes {
+			if rank != nil && *rank < len(restoredClock) && pass < restoredClock[*rank] {
+				kept = append(kept, pass)
+			}
+		}
+		breakpoint.passes = kept
+	}
+}
+
+func formatPasses(passes []int) string {
+	formatted := make([]string, 0, len(passes))
+	for _, pass := range passes {
+		formatted = append(formatted, fmt.Sprint(pass))
+	}
+	return strings.Join(formatted, ", ")
+}
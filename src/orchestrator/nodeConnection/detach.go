@@ -0,0 +1,81 @@
+package nodeconnection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Detaches the console from a node: the node runs its target with only the MPI breakpoints, which keep recording
+// its events and checkpoints, until it is reattached. Commands sent to the node meanwhile wait for the reattach
+func Detach(nodeId int) {
+	node := getNode(nodeId)
+	if node == nil {
+		logger.Warn("Node %d not found", nodeId)
+		return
+	}
+
+	if node.detached {
+		logger.Warn("Node %d is already detached", nodeId)
+		return
+	}
+
+	err := HandleRemotely(&command.Command{NodeId: nodeId, Code: command.Detach})
+	if err != nil {
+		return
+	}
+
+	node.detached = true
+
+	recordTimelineEvent(nodeId, TIMELINE_DETACH, "detached")
+	logger.Info("Node %d detached, reattach with %d attach", nodeId, nodeId)
+}
+
+// Reattaches the console to a detached node: its target is interrupted wherever it is and its breakpoints are
+// inserted again. The node reports the stop like the end of a continue
+func Attach(nodeId int) {
+	node := getNode(nodeId)
+	if node == nil {
+		logger.Warn("Node %d not found", nodeId)
+		return
+	}
+
+	if !node.detached {
+		logger.Warn("Node %d is not detached", nodeId)
+		return
+	}
+
+	reply, err := node.client.CommandHandler().Interrupt(context.Background(), &pb.InterruptRequest{Reason: "reattaching the console"})
+	if err != nil {
+		logger.Warn("Failed to reattach node %d: %v", nodeId, err)
+		return
+	}
+
+	if !reply.Interrupted {
+		logger.Info("Node %d is stopping on its own, it is reattached once it reports the stop", nodeId)
+	}
+}
+
+// Marks the end of the detached run of a node, as it reports its result
+func reattached(nodeId int, cmd *command.Command) {
+	node := getNode(nodeId)
+	if node == nil || !node.detached {
+		return
+	}
+
+	node.detached = false
+
+	if !cmd.Result.Exited {
+		logger.Info("Node %d reattached at %v", nodeId, describeResultLocation(cmd.Result))
+	}
+}
+
+func describeResultLocation(result *command.CommandResult) string {
+	if len(result.Location) == 0 {
+		return "an unknown location"
+	}
+	return fmt.Sprintf("%v (%v)", result.Location, result.Backtrace)
+}
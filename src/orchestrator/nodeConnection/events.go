@@ -0,0 +1,73 @@
+package nodeconnection
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// events a subscriber may fall behind by, the events beyond are dropped for it
+const EVENT_SUBSCRIBER_BUFFER = 256
+
+// Subscribers to the events of the nodes, e.g. the web UI showing the live state of each rank
+var eventSubscribers = struct {
+	sync.Mutex
+	channels map[chan rpc.NodeEvent]bool
+}{channels: make(map[chan rpc.NodeEvent]bool)}
+
+// Returns a feed of the events of all nodes, as they are reported, and the function ending the subscription
+func SubscribeEvents() (events <-chan rpc.NodeEvent, unsubscribe func()) {
+	channel := make(chan rpc.NodeEvent, EVENT_SUBSCRIBER_BUFFER)
+
+	eventSubscribers.Lock()
+	eventSubscribers.channels[channel] = true
+	eventSubscribers.Unlock()
+
+	return channel, func() {
+		eventSubscribers.Lock()
+		defer eventSubscribers.Unlock()
+
+		if eventSubscribers.channels[channel] {
+			delete(eventSubscribers.channels, channel)
+			close(channel)
+		}
+	}
+}
+
+// Records the event as the latest of its node and passes it to the subscribers, without waiting for slow ones
+func publishEvent(event rpc.NodeEvent) {
+	if node := getNode(event.NodeId); node != nil {
+		node.lastEvent = &event
+	}
+	checkpointmanager.RecordNodeEvent(event)
+
+	eventSubscribers.Lock()
+	defer eventSubscribers.Unlock()
+
+	for channel := range eventSubscribers.channels {
+		select {
+		case channel <- event:
+		default:
+		}
+	}
+}
+
+// Publishes an event the orchestrator learned of from the reports of a node
+func publishNodeEvent(nodeId int, kind string, location string, detail string) {
+	publishEvent(rpc.NodeEvent{NodeId: nodeId, Kind: kind, Time: time.Now(), Location: location, Detail: detail})
+}
+
+// Events of breakpoints, signals and output lines, sent by the nodes as they happen
+func (r NodeReporter) Events(ctx context.Context, message *pb.NodeEvents) (*emptypb.Empty, error) {
+	for _, eventMessage := range message.Events {
+		event := rpc.NodeEventOf(eventMessage)
+		event.NodeId = currentNodeId(event.NodeId)
+		publishEvent(event)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,165 @@
+package nodeconnection
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// how long the nodes are given to report the results of a command run on several of them
+const FANOUT_TIMEOUT = 10 * time.Second
+
+// whether the results of node commands are printed as json (--json)
+var jsonOutput bool
+
+func SetJSONOutput(enabled bool) {
+	jsonOutput = enabled
+}
+
+// Nodes reporting the same result for a command run on several nodes
+type resultGroup struct {
+	result  string
+	nodeIds []int
+}
+
+// Runs a node-specific command on all of its target nodes at once. Progress commands are only sent, each node
+// reports its stop when it gets there. For the others the results are awaited and printed grouped, the nodes
+// reporting the same value, call stack or error together
+func FanOut(cmd *command.Command) {
+	cmds := make([]*command.Command, 0, len(cmd.Targets))
+
+	for _, nodeId := range cmd.Targets {
+		nodeCommand := *cmd
+		nodeCommand.NodeId = nodeId
+		nodeCommand.Targets = nil
+
+		cmds = append(cmds, &nodeCommand)
+	}
+
+	if cmd.IsProgressCommand() {
+		printDispatchFailures(cmd, dispatchConcurrently(cmds))
+		return
+	}
+
+	results, failed, err := handleAndWait(cmds, FANOUT_TIMEOUT)
+	if err != nil {
+		logger.Warn("%v", err)
+	}
+
+	// the results reported are printed as they arrive, those of the other nodes once given up on
+	if jsonOutput || cmd.JSON {
+		printMissingResultsJSON(cmd, results, failed)
+		return
+	}
+
+	printGroupedResults(cmd, results)
+}
+
+// Prints the results of the nodes not reporting one as json: the error of the nodes not given the command,
+// no result for those not reporting in time
+func printMissingResultsJSON(cmd *command.Command, results map[int]*command.CommandResult, failed map[int]error) {
+	for _, nodeId := range cmd.Targets {
+		_, reported := results[nodeId]
+		if _, notDispatched := failed[nodeId]; reported && !notDispatched {
+			continue
+		}
+
+		nodeCommand := *cmd
+		nodeCommand.NodeId = nodeId
+		nodeCommand.Targets = nil
+		nodeCommand.Result = results[nodeId]
+
+		fmt.Println(command.FormatJSON(&nodeCommand))
+	}
+}
+
+// Prints the nodes a command could not be dispatched to, grouped by their errors, nothing if it reached all nodes
+func printDispatchFailures(cmd *command.Command, failed map[int]error) {
+	if len(failed) == 0 {
+		return
+	}
+
+	results := make(map[int]*command.CommandResult)
+	failedCommand := *cmd
+	failedCommand.Targets = make([]int, 0, len(failed))
+
+	for nodeId, err := range failed {
+		results[nodeId] = dispatchResult(err)
+		failedCommand.Targets = append(failedCommand.Targets, nodeId)
+	}
+
+	sort.Ints(failedCommand.Targets)
+
+	if jsonOutput || cmd.JSON {
+		printMissingResultsJSON(&failedCommand, results, failed)
+		return
+	}
+
+	printGroupedResults(&failedCommand, results)
+}
+
+func printGroupedResults(cmd *command.Command, results map[int]*command.CommandResult) {
+	groups := make([]*resultGroup, 0)
+	missing := make([]int, 0)
+
+	for _, nodeId := range cmd.Targets {
+		result, reported := results[nodeId]
+		if !reported {
+			missing = append(missing, nodeId)
+			continue
+		}
+
+		summary := summarizeResult(result)
+
+		var group *resultGroup
+		for _, existing := range groups {
+			if existing.result == summary {
+				group = existing
+				break
+			}
+		}
+
+		if group == nil {
+			group = &resultGroup{result: summary}
+			groups = append(groups, group)
+		}
+
+		group.nodeIds = append(group.nodeIds, nodeId)
+	}
+
+	fmt.Printf("\n%v on nodes %v:\n", cmd, command.FormatSelector(cmd.Targets))
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+	for _, group := range groups {
+		fmt.Fprintf(writer, "  [%v]\t%v\n", command.FormatSelector(group.nodeIds), group.result)
+	}
+
+	if len(missing) > 0 {
+		fmt.Fprintf(writer, "  [%v]\tno result within %v\n", command.FormatSelector(missing), FANOUT_TIMEOUT)
+	}
+
+	writer.Flush()
+	fmt.Println()
+}
+
+// The part of a result compared across nodes: the error, else what the command printed or the call stack
+func summarizeResult(result *command.CommandResult) string {
+	switch {
+	case len(result.Error) > 0:
+		return fmt.Sprintf("error: %v", result.Error)
+	case result.Exited:
+		return "exited"
+	case len(result.Output) > 0:
+		return result.Output
+	case len(result.Backtrace) > 0:
+		return result.Backtrace
+	default:
+		return "done"
+	}
+}
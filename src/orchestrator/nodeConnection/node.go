@@ -2,8 +2,9 @@ package nodeconnection
 
 import (
 	"fmt"
-	"net/url"
+	"net"
 	"sort"
+	"sync"
 
 	"github.com/ottmartens/cc-rev-db/logger"
 	"github.com/ottmartens/cc-rev-db/rpc"
@@ -13,14 +14,39 @@ import (
 type node struct {
 	id             int
 	pid            int
+	registeredId   int    // id the node registered with, it listens on the port of and reports by it
+	host           string // address the node listens on, reported when registering
 	client         *rpc.RPCClient
 	pendingCommand *command.Command
+	running        bool // a forward progress command runs the target, from its progress report until its result
+	detached       bool // the console is detached from the node, its target runs until reattached
+
+	usage         *rpc.ResourceUsageRecord // latest resource usage reported by the node
+	previousUsage *rpc.ResourceUsageRecord // the report preceding it, for computing the cpu utilization
+
+	capabilities *rpc.NodeCapabilities // features supported by the node for its target
+	lastEvent    *rpc.NodeEvent        // latest event of the node (nil if none yet)
 }
 
 func (n node) getConnection() *rpc.RPCClient {
-	nodeAddress, _ := url.Parse(fmt.Sprintf("localhost:%d", 3500+n.id))
+	host := n.host
+	if len(host) == 0 {
+		host = "localhost"
+	}
 
-	return rpc.Connect(nodeAddress)
+	nodeAddress, _ := rpc.ParseAddress(net.JoinHostPort(host, fmt.Sprint(rpc.NodePort(orchestratorPort, n.registeredId))))
+
+	client := rpc.Connect(nodeAddress)
+	client.ReconnectOnFailure(NODE_RECONNECT_TIMEOUT, nil)
+
+	return client
+}
+
+// port of the orchestrator, the ports of the nodes follow it
+var orchestratorPort = rpc.DEFAULT_ORCHESTRATOR_PORT
+
+func SetOrchestratorPort(port int) {
+	orchestratorPort = port
 }
 
 // keys - node ids
@@ -28,17 +54,51 @@ type nodeMap map[int]*node
 
 var registeredNodes nodeMap = make(nodeMap)
 
+// guards registeredNodes, renumberedIds and nodeStatistics. Nodes register, resync, get renumbered and exit on the
+// goroutines of their rpc calls, while the console, the status and the selectors read the maps
+var nodesLock sync.RWMutex
+
+// Returns the node with the id, nil if there is none
+func getNode(nodeId int) *node {
+	nodesLock.RLock()
+	defer nodesLock.RUnlock()
+
+	return registeredNodes[nodeId]
+}
+
+// Returns the registered nodes, for iterating over them while nodes register and exit
+func getNodes() []*node {
+	nodesLock.RLock()
+	defer nodesLock.RUnlock()
+
+	nodes := make([]*node, 0, len(registeredNodes))
+	for _, node := range registeredNodes {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func registeredNodeCount() int {
+	nodesLock.RLock()
+	defer nodesLock.RUnlock()
+
+	return len(registeredNodes)
+}
+
 func GetRegisteredIds() []int {
+	nodesLock.RLock()
 	nodeIds := make([]int, 0, len(registeredNodes))
 	for nodeId := range registeredNodes {
 		nodeIds = append(nodeIds, nodeId)
 	}
+	nodesLock.RUnlock()
+
 	sort.Sort(sort.IntSlice(nodeIds))
 	return nodeIds
 }
 
 func ConnectToAllNodes(desiredNodeCount int) {
-	for _, node := range registeredNodes {
+	for _, node := range getNodes() {
 
 		if node.client == nil {
 			node.client = node.getConnection()
@@ -47,9 +107,9 @@ func ConnectToAllNodes(desiredNodeCount int) {
 		node.client.Heartbeat()
 	}
 
-	if desiredNodeCount == len(registeredNodes) {
+	if nodeCount := registeredNodeCount(); desiredNodeCount == nodeCount {
 		logger.Info("Connected to all nodes")
 	} else {
-		panic(fmt.Sprintf("%d nodes connected, want %d", len(registeredNodes), desiredNodeCount))
+		panic(fmt.Sprintf("%d nodes connected, want %d", nodeCount, desiredNodeCount))
 	}
 }
@@ -1,11 +1,15 @@
 package nodeconnection
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils"
 	"github.com/ottmartens/cc-rev-db/utils/command"
 
 	"github.com/ottmartens/cc-rev-db/logger"
@@ -14,7 +18,7 @@ import (
 func HandleRemotely(cmd *command.Command) error {
 	nodeId := cmd.NodeId
 
-	node := registeredNodes[nodeId]
+	node := getNode(nodeId)
 
 	if node == nil {
 		err := fmt.Errorf("Node %d not found", nodeId)
@@ -22,7 +26,19 @@ func HandleRemotely(cmd *command.Command) error {
 		return err
 	}
 
-	err := node.client.Call("RemoteCmdHandler.Handle", cmd, new(int))
+	message, err := rpc.CommandProto(cmd)
+	if err == nil {
+		_, err = node.client.CommandHandler().Handle(context.Background(), message)
+	}
+
+	if rpc.IsTimeout(err) {
+		logger.Debug("%v", err)
+		err = fmt.Errorf("%w from node %d within %v", utils.ErrNoReply, nodeId, rpc.DEFAULT_CALL_TIMEOUT)
+	}
+
+	if rpc.IsConnectionLost(err) {
+		err = fmt.Errorf("%v; node %d did not reconnect within %v, the command is not sent again once it does", err, nodeId, NODE_RECONNECT_TIMEOUT)
+	}
 
 	if err != nil {
 		logger.Error("Error dispatching command: %v", err)
@@ -57,6 +73,9 @@ func ExecutePendingRollback() (err error) {
 		}
 
 		checkpointmanager.RemoveSubsequentCheckpoints(checkpoint)
+		forgetUndoneLinePasses(int(nodeId), checkpoint.VectorClock)
+
+		recordTimelineEvent(int(nodeId), TIMELINE_ROLLBACK, "rolled back to checkpoint %v (%v)", checkpoint.Id, checkpoint.OpName)
 	}
 
 	time.Sleep(time.Second)
@@ -72,8 +91,155 @@ func ExecutePendingRollback() (err error) {
 	return err
 }
 
+// results of the commands executed by the nodes, for HandleAndWait
+var commandResults = make(chan *command.Command, 64)
+
+// Executes the commands on their nodes and waits for the nodes to report the results, by node id.
+// The nodes the commands could not be dispatched to get their dispatch error as the result, the others are
+// still waited for. Fails if a dispatch failed or not all results are reported within the timeout
+func HandleAndWait(cmds []*command.Command, timeout time.Duration) (results map[int]*command.CommandResult, err error) {
+	results, _, err = handleAndWait(cmds, timeout)
+	return results, err
+}
+
+// HandleAndWait, also returning the errors of the nodes the commands could not be dispatched to
+func handleAndWait(cmds []*command.Command, timeout time.Duration) (results map[int]*command.CommandResult, failed map[int]error, err error) {
+	// results of earlier commands
+	for len(commandResults) > 0 {
+		<-commandResults
+	}
+
+	results = make(map[int]*command.CommandResult)
+	awaited := make(map[int]command.CommandCode)
+
+	for _, cmd := range cmds {
+		awaited[cmd.NodeId] = cmd.Code
+	}
+
+	failed = dispatchConcurrently(cmds)
+
+	for nodeId, dispatchErr := range failed {
+		results[nodeId] = dispatchResult(dispatchErr)
+		delete(awaited, nodeId)
+	}
+
+	deadline := time.After(timeout)
+
+	for len(results) < len(cmds) {
+		select {
+		case cmd := <-commandResults:
+			if code, ok := awaited[cmd.NodeId]; ok && code == cmd.Code {
+				results[cmd.NodeId] = cmd.Result
+			}
+		case <-deadline:
+			return results, failed, fmt.Errorf("%d of %d nodes did not finish within %v", len(cmds)-len(results), len(cmds), timeout)
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, failed, fmt.Errorf("%d of %d nodes could not be given the command", len(failed), len(cmds))
+	}
+
+	return results, failed, nil
+}
+
+// Runs dispatch, sending a progress command to the nodes, and waits for the nodes to report its results: stopped
+// at a breakpoint, done stepping or exited. Fails if some of them do not within the timeout
+func AwaitStop(nodeIds []int, timeout time.Duration, dispatch func()) error {
+	// results of earlier commands
+	for len(commandResults) > 0 {
+		<-commandResults
+	}
+
+	dispatch()
+
+	awaited := make(map[int]bool)
+	for _, nodeId := range nodeIds {
+		if getNode(nodeId) != nil {
+			awaited[nodeId] = true
+		}
+	}
+
+	deadline := time.After(timeout)
+
+	for len(awaited) > 0 {
+		select {
+		case cmd := <-commandResults:
+			if cmd.IsProgressCommand() || cmd.Result.Exited {
+				delete(awaited, cmd.NodeId)
+			}
+		case <-deadline:
+			running := make([]int, 0, len(awaited))
+			for nodeId := range awaited {
+				running = append(running, nodeId)
+			}
+			sort.Ints(running)
+
+			return fmt.Errorf("nodes %v did not stop within %v", running, timeout)
+		}
+	}
+
+	return nil
+}
+
+// Sends the commands to their nodes at once, without waiting for the results. Returns one of the dispatch errors
+func HandleConcurrently(cmds []*command.Command) (err error) {
+	for _, dispatchErr := range dispatchConcurrently(cmds) {
+		return dispatchErr
+	}
+
+	return nil
+}
+
+// Sends the commands to their nodes at once, returning the errors of the nodes they could not be dispatched to,
+// by node id. A hung node fails its dispatch once the call deadline passes, without holding up the others
+func dispatchConcurrently(cmds []*command.Command) map[int]error {
+	type dispatch struct {
+		nodeId int
+		err    error
+	}
+
+	dispatched := make(chan dispatch, len(cmds))
+
+	for _, cmd := range cmds {
+		go func(cmd *command.Command) {
+			dispatched <- dispatch{cmd.NodeId, HandleRemotely(cmd)}
+		}(cmd)
+	}
+
+	failed := make(map[int]error)
+
+	for range cmds {
+		if result := <-dispatched; result.err != nil {
+			failed[result.nodeId] = result.err
+		}
+	}
+
+	return failed
+}
+
+// The result of a command that could not be dispatched to its node
+func dispatchResult(err error) *command.CommandResult {
+	return &command.CommandResult{Error: err.Error(), ErrorKind: utils.ErrorKind(err)}
+}
+
+// Executes the command on every node at once. The nodes that could not be given the command are printed with
+// their errors, the others report their results as they execute it
+func HandleOnAllNodes(cmd *command.Command) {
+	cmds := make([]*command.Command, 0)
+
+	for _, nodeId := range GetRegisteredIds() {
+		nodeCommand := *cmd
+		nodeCommand.NodeId = nodeId
+
+		cmds = append(cmds, &nodeCommand)
+	}
+
+	printDispatchFailures(cmd, dispatchConcurrently(cmds))
+}
+
 func StopAllNodes() {
-	for _, node := range registeredNodes {
+	for _, node := range getNodes() {
 		if node.client != nil {
 			logger.Debug("Stopping node %v", node.id)
 			HandleRemotely(&command.Command{NodeId: node.id, Code: command.Quit})
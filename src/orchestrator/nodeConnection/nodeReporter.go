@@ -1,40 +1,61 @@
 package nodeconnection
 
 import (
-	"time"
+	"context"
+	"fmt"
 
 	"github.com/ottmartens/cc-rev-db/utils/command"
 
 	"github.com/ottmartens/cc-rev-db/logger"
 	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
 	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"github.com/ottmartens/cc-rev-db/utils"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type NodeReporter struct {
+	pb.UnimplementedNodeReporterServer
+
 	checkpointRecordChan chan<- rpc.MPICallRecord
-	quit                 func()
+	onComplete           func() // called once all nodes have exited
 }
 
-func NewNodeReporter(checkpointRecordChan chan<- rpc.MPICallRecord, quit func()) *NodeReporter {
-	return &NodeReporter{checkpointRecordChan, quit}
+func NewNodeReporter(checkpointRecordChan chan<- rpc.MPICallRecord, onComplete func()) *NodeReporter {
+	return &NodeReporter{checkpointRecordChan: checkpointRecordChan, onComplete: onComplete}
 }
 
-func (r NodeReporter) Register(pid *int, reply *int) error {
+func (r NodeReporter) Register(ctx context.Context, message *pb.NodeRegistration) (*pb.NodeId, error) {
+	registration := rpc.NodeRegistrationOf(message)
 
+	nodesLock.Lock()
 	node := node{
-		id:  len(registeredNodes),
-		pid: *pid,
+		id:   registrationId(registration.Rank),
+		pid:  registration.Pid,
+		host: registration.Host,
 	}
+	node.registeredId = node.id
 
 	registeredNodes[node.id] = &node
+	nodesLock.Unlock()
+
+	getRunStatistics(node.id)
 
-	logger.Verbose("added process %d (pid: %d) to process list", node.id, node.pid)
+	logger.Verbose("added process %d (pid: %d, address: %v) to process list", node.id, node.pid, node.host)
 
-	*reply = node.id
-	return nil
+	return &pb.NodeId{Id: int32(node.id)}, nil
 }
 
-func (r NodeReporter) CommandResult(cmd *command.Command, reply *int) error {
+func (r NodeReporter) CommandResult(ctx context.Context, message *pb.Command) (*emptypb.Empty, error) {
+	cmd, err := rpc.CommandFromProto(message)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Result == nil {
+		return nil, fmt.Errorf("result of command %v not given", cmd)
+	}
+
+	cmd.NodeId = currentNodeId(cmd.NodeId)
 	nodeId := cmd.NodeId
 
 	if len(cmd.Result.Error) > 0 {
@@ -42,33 +63,123 @@ func (r NodeReporter) CommandResult(cmd *command.Command, reply *int) error {
 			"Node %v reported an error while executing command: %v",
 			nodeId, cmd.Result.Error,
 		)
+
+		if hint := utils.ErrorHint(cmd.Result.ErrorKind); len(hint) > 0 {
+			logger.Info("hint: %v", hint)
+		}
 	} else {
 		logger.Verbose("Node %v successfully executed command %v", nodeId, cmd)
 	}
 
+	if node := getNode(nodeId); node != nil && cmd.IsForwardProgressCommand() {
+		node.running = false
+	}
+
+	if cmd.Code == command.Detach {
+		reattached(nodeId, cmd)
+	}
+
+	recordCommandResult(nodeId, cmd)
+
+	if jsonOutput || cmd.JSON {
+		fmt.Println(command.FormatJSON(cmd))
+	}
+
+	switch {
+	case cmd.Result.Exited:
+		publishNodeEvent(nodeId, rpc.EVENT_EXITED, "", "")
+	case cmd.IsProgressCommand() && !cmd.Result.Breakpoint:
+		publishNodeEvent(nodeId, rpc.EVENT_STOPPED, cmd.Result.Location, cmd.String())
+	}
+
+	if cmd.Result.Breakpoint && allStop {
+		go stopRunningNodes(nodeId)
+	}
+
+	// results nobody waits for are dropped
+	select {
+	case commandResults <- cmd:
+	default:
+	}
+
 	if cmd.Result.Exited {
 		logger.Info("Node %v exited", nodeId)
 
+		nodesLock.Lock()
 		delete(registeredNodes, nodeId)
+		remaining := len(registeredNodes)
+		nodesLock.Unlock()
 
-		if len(registeredNodes) == 0 {
-			go func() {
-				logger.Info("All nodes exited. Exiting in 10s")
-				time.Sleep(time.Second * 10)
-				r.quit()
-			}()
+		if remaining == 0 {
+			go r.onComplete()
 		}
 	}
 
-	return nil
+	return &emptypb.Empty{}, nil
 }
 
-func (r NodeReporter) Progress(cmd *command.Command, reply *int) error {
+func (r NodeReporter) Progress(ctx context.Context, message *pb.Command) (*emptypb.Empty, error) {
+	cmd, err := rpc.CommandFromProto(message)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.NodeId = currentNodeId(cmd.NodeId)
+
+	if node := getNode(cmd.NodeId); node != nil {
+		node.running = true
+	}
+	publishNodeEvent(cmd.NodeId, rpc.EVENT_RUNNING, "", cmd.String())
+
 	checkpointmanager.RemoveCurrentCheckpointMarkersOnNode(checkpointmanager.NodeId(cmd.NodeId))
-	return nil
+	return &emptypb.Empty{}, nil
 }
 
-func (r NodeReporter) MPICall(callRecord rpc.MPICallRecord, reply *int) error {
+func (r NodeReporter) MPICall(ctx context.Context, message *pb.MPICallRecord) (*emptypb.Empty, error) {
+	callRecord := rpc.MPICallRecordOf(message)
+	getRunStatistics(currentNodeId(callRecord.NodeId)).checkpoints++
+	publishNodeEvent(currentNodeId(callRecord.NodeId), rpc.EVENT_MPI, callRecord.Location, fmt.Sprintf("%v, checkpoint %v", callRecord.OpName, callRecord.Id))
+
 	r.checkpointRecordChan <- callRecord
-	return nil
+	return &emptypb.Empty{}, nil
+}
+
+func (r NodeReporter) MemoryLayout(ctx context.Context, message *pb.MemoryLayoutRecord) (*emptypb.Empty, error) {
+	layout := rpc.MemoryLayoutRecordOf(message)
+	layout.NodeId = currentNodeId(layout.NodeId)
+	logger.Debug("Node %v reported memory layout (%d regions, ASLR disabled: %v)", layout.NodeId, len(layout.Regions), layout.AslrDisabled)
+
+	checkpointmanager.RecordMemoryLayout(layout)
+	return &emptypb.Empty{}, nil
+}
+
+func (r NodeReporter) ResourceUsage(ctx context.Context, message *pb.ResourceUsageRecord) (*emptypb.Empty, error) {
+	usage := rpc.ResourceUsageRecordOf(message)
+	usage.NodeId = currentNodeId(usage.NodeId)
+	node := getNode(usage.NodeId)
+	if node == nil {
+		return &emptypb.Empty{}, nil
+	}
+
+	node.recordUsage(&usage)
+	return &emptypb.Empty{}, nil
+}
+
+func (r NodeReporter) Fingerprint(ctx context.Context, message *pb.TargetFingerprint) (*emptypb.Empty, error) {
+	fingerprint := rpc.TargetFingerprintOf(message)
+	fingerprint.NodeId = currentNodeId(fingerprint.NodeId)
+	checkpointmanager.RecordTargetFingerprint(fingerprint)
+	return &emptypb.Empty{}, nil
+}
+
+func (r NodeReporter) Capabilities(ctx context.Context, message *pb.NodeCapabilities) (*emptypb.Empty, error) {
+	capabilities := rpc.NodeCapabilitiesOf(message)
+	capabilities.NodeId = currentNodeId(capabilities.NodeId)
+	node := getNode(capabilities.NodeId)
+	if node == nil {
+		return &emptypb.Empty{}, nil
+	}
+
+	node.capabilities = &capabilities
+	return &emptypb.Empty{}, nil
 }
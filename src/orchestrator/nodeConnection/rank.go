@@ -0,0 +1,134 @@
+package nodeconnection
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+)
+
+// Nodes are numbered by the MPI ranks of their targets, so node 3 is rank 3 in every display and selector.
+// A node registers with the rank its launcher gave it, if the launcher is known. Otherwise it gets the lowest free
+// id and is renumbered when its target first reports its rank, which the wrapper records after MPI_Init.
+// Renumbered nodes keep reporting by the id they registered with, translated to their current id on arrival
+
+// current ids of the renumbered nodes, by the id they registered with
+var renumberedIds = make(map[int]int)
+
+// Returns the current id of the node that registered with the id
+func currentNodeId(registeredId int) int {
+	nodesLock.RLock()
+	defer nodesLock.RUnlock()
+
+	return renumberedId(registeredId)
+}
+
+// Returns the current id of the node that registered with the id, the caller holds nodesLock
+func renumberedId(registeredId int) int {
+	if nodeId, renumbered := renumberedIds[registeredId]; renumbered {
+		return nodeId
+	}
+	return registeredId
+}
+
+// Returns the id for a registering node: its rank if known and free, else the lowest free id.
+// The caller holds nodesLock for writing, until the node is added
+func registrationId(rank int) int {
+	if _, taken := registeredNodes[rank]; rank >= 0 && !taken {
+		return rank
+	}
+
+	nodeId := 0
+	for registeredNodes[nodeId] != nil {
+		nodeId++
+	}
+	return nodeId
+}
+
+// Renumbers the node of an MPI call by the rank its target reported, before the call is recorded.
+// The node holding the id of the rank, not knowing its own rank yet, takes the id of the renumbered node
+func ResolveRank(callRecord *rpc.MPICallRecord) {
+	callRecord.NodeId = currentNodeId(callRecord.NodeId)
+
+	rank, err := strconv.Atoi(callRecord.Parameters["rank"])
+	if err != nil || rank < 0 || rank == callRecord.NodeId {
+		return
+	}
+
+	nodeId := callRecord.NodeId
+	node := getNode(nodeId)
+	if node == nil {
+		return
+	}
+
+	displaced := getNode(rank)
+
+	swapNodeIds(nodeId, rank)
+	tellNodeId(node)
+
+	if displaced != nil {
+		tellNodeId(displaced)
+		logger.Info("Node %d runs rank %d, renumbered to %d (node %d renumbered to %d)", nodeId, rank, rank, rank, nodeId)
+	} else {
+		logger.Info("Node %d runs rank %d, renumbered to %d", nodeId, rank, rank)
+	}
+
+	callRecord.NodeId = rank
+}
+
+// Exchanges the ids of two nodes, along with what is recorded under them
+func swapNodeIds(nodeId int, otherId int) {
+	nodesLock.Lock()
+	registeredNodes[nodeId], registeredNodes[otherId] = registeredNodes[otherId], registeredNodes[nodeId]
+	nodeStatistics[nodeId], nodeStatistics[otherId] = nodeStatistics[otherId], nodeStatistics[nodeId]
+	fetchedSources[nodeId], fetchedSources[otherId] = fetchedSources[otherId], fetchedSources[nodeId]
+
+	// no entries are kept for ids without a node
+	for _, id := range []int{nodeId, otherId} {
+		if registeredNodes[id] == nil {
+			delete(registeredNodes, id)
+			delete(nodeStatistics, id)
+			delete(fetchedSources, id)
+			continue
+		}
+
+		registeredNodes[id].id = id
+		renumberedIds[registeredNodes[id].registeredId] = id
+	}
+	nodesLock.Unlock()
+
+	timelineLock.Lock()
+	for index := range timeline {
+		timeline[index].nodeId = otherIdOf(timeline[index].nodeId, nodeId, otherId)
+	}
+	timelineLock.Unlock()
+
+	checkpointmanager.SwapNodeIds(checkpointmanager.NodeId(nodeId), checkpointmanager.NodeId(otherId))
+}
+
+// Returns the id a node has after two ids are exchanged
+func otherIdOf(id int, nodeId int, otherId int) int {
+	switch id {
+	case nodeId:
+		return otherId
+	case otherId:
+		return nodeId
+	default:
+		return id
+	}
+}
+
+// Tells a node debugger its new id, which it reports and logs with from then on
+func tellNodeId(node *node) {
+	if node.client == nil {
+		return
+	}
+
+	_, err := node.client.CommandHandler().Renumber(context.Background(), &pb.NodeId{Id: int32(node.id)})
+	if err != nil {
+		logger.Warn("Failed to renumber node %d: %v", node.id, err)
+	}
+}
@@ -0,0 +1,111 @@
+package nodeconnection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+)
+
+// time the orchestrator keeps redialing a node for once the connection drops, before the command sent fails.
+// Kept short, as a node that exited does not come back. A failed command is not sent again once the node
+// reconnects, it may have reached the node before the connection dropped
+const NODE_RECONNECT_TIMEOUT = 5 * time.Second
+
+// whether nodes unknown to the orchestrator are taken over when they resync, as it was restarted with --rejoin
+var rejoining bool
+
+// Makes the orchestrator take over the nodes of a session whose orchestrator exited. The nodes keep redialing the
+// port of the orchestrator, and register again with the resync handshake once it listens
+func AcceptRejoins() {
+	rejoining = true
+}
+
+// Waits for the nodes of a session to rejoin the restarted orchestrator, until the timeout
+func WaitForRejoins(desiredNodeCount int, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+
+	logger.Info("waiting for %d nodes to rejoin", desiredNodeCount)
+
+	for registeredNodeCount() < desiredNodeCount && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// A node reconnecting after its connection dropped, or rejoining an orchestrator restarted with --rejoin. The
+// orchestrator takes over the state of the node: whether it runs or is detached, and the checkpoints it holds,
+// answering with those it has no records of. The reports that failed are sent again by the node after it
+func (r NodeReporter) Resync(ctx context.Context, message *pb.NodeResync) (*pb.ResyncReply, error) {
+	resync := rpc.NodeResyncOf(message)
+	reply := rpc.ResyncReply{}
+
+	nodesLock.Lock()
+	resynced := registeredNodes[renumberedId(resync.RegisteredId)]
+
+	if resynced == nil || resynced.pid != resync.Registration.Pid {
+		if !rejoining {
+			nodesLock.Unlock()
+			return nil, fmt.Errorf("node %d (pid: %d) is not part of this session", resync.NodeId, resync.Registration.Pid)
+		}
+
+		resynced = &node{
+			id:           resync.NodeId,
+			registeredId: resync.RegisteredId,
+			pid:          resync.Registration.Pid,
+			host:         resync.Registration.Host,
+		}
+
+		registeredNodes[resynced.id] = resynced
+
+		if resync.NodeId != resync.RegisteredId {
+			renumberedIds[resync.RegisteredId] = resync.NodeId
+		}
+
+		reply.Rejoined = true
+	}
+
+	resynced.running = resync.Running
+	resynced.detached = resync.Detached
+	nodesLock.Unlock()
+
+	getRunStatistics(resynced.id)
+
+	recorded := make(map[string]bool)
+	for _, checkpointId := range checkpointmanager.NodeCheckpointIds(checkpointmanager.NodeId(resynced.id)) {
+		recorded[checkpointId] = true
+	}
+
+	for _, checkpointId := range resync.Checkpoints {
+		if !recorded[checkpointId] {
+			reply.MissingCheckpoints = append(reply.MissingCheckpoints, checkpointId)
+		}
+	}
+
+	breakpoints := "no breakpoints"
+	if len(resync.Breakpoints) > 0 {
+		breakpoints = "breakpoints at " + strings.Join(resync.Breakpoints, ", ")
+	}
+
+	state := "stopped"
+	if resync.Running {
+		state = "running"
+	}
+	if resync.Detached {
+		state = "detached"
+	}
+
+	verb := "reconnected"
+	if reply.Rejoined {
+		verb = "rejoined"
+	}
+
+	logger.Info("Node %d %v (%v): %d checkpoints, %d of them reported again, %v",
+		resynced.id, verb, state, len(resync.Checkpoints), len(reply.MissingCheckpoints), breakpoints)
+
+	return reply.Proto(), nil
+}
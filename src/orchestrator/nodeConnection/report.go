@@ -0,0 +1,288 @@
+package nodeconnection
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Kinds of the events on the timeline of the session
+const (
+	TIMELINE_STOP       = "stop"
+	TIMELINE_BREAKPOINT = "breakpoint"
+	TIMELINE_ROLLBACK   = "rollback"
+	TIMELINE_VIOLATION  = "invariant violation"
+	TIMELINE_EXIT       = "exit"
+	TIMELINE_DETACH     = "detach"
+)
+
+// An event of the session, reported in the run report
+type timelineEvent struct {
+	time        time.Time
+	nodeId      int
+	kind        string
+	description string
+}
+
+var timeline = make([]timelineEvent, 0)
+
+// the nodes report their command results concurrently
+var timelineLock sync.Mutex
+
+func recordTimelineEvent(nodeId int, kind string, format string, args ...interface{}) {
+	timelineLock.Lock()
+	defer timelineLock.Unlock()
+
+	timeline = append(timeline, timelineEvent{time.Now(), nodeId, kind, fmt.Sprintf(format, args...)})
+}
+
+// Records where a node stopped after a command, for the run report
+func recordCommandResult(nodeId int, cmd *command.Command) {
+	statistics := getRunStatistics(nodeId)
+	result := cmd.Result
+
+	argument := ""
+	if cmd.Argument != nil {
+		argument = fmt.Sprint(cmd.Argument)
+	}
+
+	checkpointmanager.RecordCommandResult(checkpointmanager.RecordedResult{
+		NodeId:    nodeId,
+		Command:   cmd.Name(),
+		Argument:  argument,
+		Time:      time.Now(),
+		Location:  result.Location,
+		Backtrace: result.Backtrace,
+		Output:    result.Output,
+		Error:     result.Error,
+	})
+
+	if len(result.Backtrace) > 0 {
+		statistics.location = result.Location
+		statistics.backtrace = result.Backtrace
+	}
+
+	switch {
+	case cmd.Code == command.CheckInvariant && len(result.Error) > 0:
+		recordTimelineEvent(nodeId, TIMELINE_VIOLATION, "%v", result.Error)
+	case result.Exited:
+		statistics.exited = true
+		recordTimelineEvent(nodeId, TIMELINE_EXIT, "exited")
+	case result.Breakpoint:
+		statistics.breakpointHits++
+		statistics.breakpointLocations[result.Location]++
+		recordTimelineEvent(nodeId, TIMELINE_BREAKPOINT, "stopped at a breakpoint at %v", result.Location)
+	case cmd.Code == command.Detach:
+		recordTimelineEvent(nodeId, TIMELINE_DETACH, "reattached at %v", result.Location)
+	case result.Interrupted:
+		recordTimelineEvent(nodeId, TIMELINE_STOP, "interrupted at %v by the orchestrator (all-stop or wheretree)", result.Location)
+	case cmd.IsProgressCommand() && len(result.Error) == 0:
+		recordTimelineEvent(nodeId, TIMELINE_STOP, "stopped at %v after %v", result.Location, cmd)
+	}
+}
+
+// Writes a report of the session to a file, as HTML for .html files and Markdown otherwise: the timeline of stops
+// and rollbacks, the breakpoints hit, the invariant violations and the last stop of each node. The session is
+// exported next to it as a bundle for the viewer, the report links to it
+func WriteRunReport(filePath string) error {
+	bundlePath := strings.TrimSuffix(filePath, filepath.Ext(filePath)) + "-session.json"
+
+	err := checkpointmanager.ExportSession(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	report := newRunReport(filepath.Base(bundlePath))
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".html", ".htm":
+		report.writeHTML(file)
+	default:
+		report.writeMarkdown(file)
+	}
+
+	logger.Info("Run report written to %v", filePath)
+	return nil
+}
+
+// The contents of a run report, by section. Each row is a list of cells
+type runReport struct {
+	title    string
+	overview []string
+	bundle   string // file name of the session bundle, relative to the report
+	sections []reportSection
+}
+
+type reportSection struct {
+	title   string
+	empty   string // text in place of an empty table
+	columns []string
+	rows    [][]string
+}
+
+func newRunReport(bundle string) runReport {
+	report := runReport{title: "Run report", bundle: bundle}
+
+	if fingerprint := checkpointmanager.GetSessionFingerprint(); fingerprint != nil {
+		report.title = fmt.Sprintf("Run report: %v", filepath.Base(fingerprint.Target))
+		report.overview = append(report.overview,
+			fmt.Sprintf("target: %v, %d ranks", fingerprint.Target, fingerprint.Ranks),
+			fmt.Sprintf("MPI: %v, debugger: %v", fingerprint.MPIImplementation, fingerprint.DebuggerVersion),
+			fmt.Sprintf("session started: %v", fingerprint.Started.Format(time.RFC3339)))
+	}
+	report.overview = append(report.overview, fmt.Sprintf("report generated: %v", time.Now().Format(time.RFC3339)))
+
+	nodeIds := statisticsNodeIds()
+
+	timelineLock.Lock()
+	events := append([]timelineEvent{}, timeline...)
+	timelineLock.Unlock()
+
+	timelineSection := reportSection{title: "Timeline", empty: "No stops or rollbacks.", columns: []string{"time", "node", "event"}}
+	violations := reportSection{title: "Invariant violations", empty: "No invariant violated.", columns: []string{"time", "node", "violation"}}
+
+	for _, event := range events {
+		row := []string{event.time.Format("15:04:05"), nodeLabel(event.nodeId), event.description}
+
+		if event.kind == TIMELINE_VIOLATION {
+			violations.rows = append(violations.rows, row)
+			row = []string{row[0], row[1], "invariant violated: " + event.description}
+		}
+
+		timelineSection.rows = append(timelineSection.rows, row)
+	}
+
+	breakpoints := reportSection{title: "Breakpoints hit", empty: "No breakpoint hit.", columns: []string{"node", "location", "hits"}}
+	finalState := reportSection{title: "Final state", columns: []string{"node", "events", "checkpoints", "state", "location", "backtrace"}}
+
+	checkpointLog := checkpointmanager.GetCheckpointLog()
+
+	for _, nodeId := range nodeIds {
+		statistics := getRunStatistics(nodeId)
+
+		locations := make([]string, 0, len(statistics.breakpointLocations))
+		for location := range statistics.breakpointLocations {
+			locations = append(locations, location)
+		}
+		sort.Strings(locations)
+
+		for _, location := range locations {
+			breakpoints.rows = append(breakpoints.rows, []string{nodeLabel(nodeId), location, fmt.Sprint(statistics.breakpointLocations[location])})
+		}
+
+		state := "stopped"
+		if statistics.exited {
+			state = "exited"
+		}
+
+		finalState.rows = append(finalState.rows, []string{
+			nodeLabel(nodeId),
+			fmt.Sprint(len(checkpointLog[checkpointmanager.NodeId(nodeId)])),
+			fmt.Sprint(statistics.checkpoints),
+			state,
+			statistics.location,
+			statistics.backtrace,
+		})
+	}
+
+	report.sections = []reportSection{timelineSection, breakpoints, violations, finalState}
+
+	return report
+}
+
+// Names a node by its id and rank
+func nodeLabel(nodeId int) string {
+	if rank := checkpointmanager.GetNodeRank(checkpointmanager.NodeId(nodeId)); rank != nil {
+		return fmt.Sprintf("%d (rank %d)", nodeId, *rank)
+	}
+
+	return fmt.Sprint(nodeId)
+}
+
+func (report runReport) writeMarkdown(writer io.Writer) {
+	fmt.Fprintf(writer, "# %s\n\n", report.title)
+
+	for _, line := range report.overview {
+		fmt.Fprintf(writer, "- %s\n", line)
+	}
+	fmt.Fprintf(writer, "- session bundle: [%s](%s), open it with `orchestrator view %s`\n", report.bundle, report.bundle, report.bundle)
+
+	// cells may not contain the separators of the table
+	escape := strings.NewReplacer("|", "\\|", "\n", " ").Replace
+
+	for _, section := range report.sections {
+		fmt.Fprintf(writer, "\n## %s\n\n", section.title)
+
+		if len(section.rows) == 0 {
+			fmt.Fprintf(writer, "%s\n", section.empty)
+			continue
+		}
+
+		fmt.Fprintf(writer, "| %s |\n", strings.Join(section.columns, " | "))
+		fmt.Fprintf(writer, "|%s\n", strings.Repeat(" --- |", len(section.columns)))
+
+		for _, row := range section.rows {
+			cells := make([]string, len(row))
+			for index, cell := range row {
+				cells[index] = escape(cell)
+			}
+
+			fmt.Fprintf(writer, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+}
+
+func (report runReport) writeHTML(writer io.Writer) {
+	fmt.Fprintf(writer, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(report.title))
+	fmt.Fprint(writer, "<style>body { font-family: sans-serif; } table { border-collapse: collapse; } td, th { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }</style>\n")
+	fmt.Fprintf(writer, "</head>\n<body>\n<h1>%s</h1>\n<ul>\n", html.EscapeString(report.title))
+
+	for _, line := range report.overview {
+		fmt.Fprintf(writer, "<li>%s</li>\n", html.EscapeString(line))
+	}
+	fmt.Fprintf(writer, "<li>session bundle: <a href=\"%s\">%s</a>, open it with <code>orchestrator view %s</code></li>\n</ul>\n",
+		html.EscapeString(report.bundle), html.EscapeString(report.bundle), html.EscapeString(report.bundle))
+
+	for _, section := range report.sections {
+		fmt.Fprintf(writer, "<h2>%s</h2>\n", html.EscapeString(section.title))
+
+		if len(section.rows) == 0 {
+			fmt.Fprintf(writer, "<p>%s</p>\n", html.EscapeString(section.empty))
+			continue
+		}
+
+		fmt.Fprint(writer, "<table>\n<tr>")
+		for _, column := range section.columns {
+			fmt.Fprintf(writer, "<th>%s</th>", html.EscapeString(column))
+		}
+		fmt.Fprint(writer, "</tr>\n")
+
+		for _, row := range section.rows {
+			fmt.Fprint(writer, "<tr>")
+			for _, cell := range row {
+				fmt.Fprintf(writer, "<td>%s</td>", html.EscapeString(cell))
+			}
+			fmt.Fprint(writer, "</tr>\n")
+		}
+
+		fmt.Fprint(writer, "</table>\n")
+	}
+
+	fmt.Fprint(writer, "</body>\n</html>\n")
+}
@@ -0,0 +1,140 @@
+package nodeconnection
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+)
+
+// lines printed by a listing, centred on the line listed
+const LISTED_LINES = 10
+
+// time a node is given to complete an argument typed at the console, before Tab completes nothing
+const COMPLETION_TIMEOUT = 2 * time.Second
+
+// keys - node id, then the file name asked for. The sources of a target do not change during the session
+var fetchedSources = make(map[int]map[string]*rpc.SourceFile)
+
+// Reads a source file of the target of a node, from the machine of the node. An empty name reads the file of
+// the main function
+func FetchSourceFile(nodeId int, name string) (*rpc.SourceFile, error) {
+	if sourceFile := fetchedSources[nodeId][name]; sourceFile != nil {
+		return sourceFile, nil
+	}
+
+	node := getNode(nodeId)
+	if node == nil {
+		return nil, fmt.Errorf("Node %d not found", nodeId)
+	}
+
+	message, err := node.client.SourceServer().Fetch(context.Background(), &pb.SourceRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	sourceFile := new(rpc.SourceFile)
+	*sourceFile = rpc.SourceFileOf(message)
+
+	if fetchedSources[nodeId] == nil {
+		fetchedSources[nodeId] = make(map[string]*rpc.SourceFile)
+	}
+	fetchedSources[nodeId][name] = sourceFile
+
+	return sourceFile, nil
+}
+
+// Prints the source around a location of a node (list [<file>:]<line>), around its last stop without one
+func ListSource(nodeId int, location string) {
+	fileName, line, current := "", 0, 0
+
+	if stop := getRunStatistics(nodeId).location; len(stop) > 0 {
+		stopFile, stopLine, _ := strings.Cut(stop, ":")
+		current, _ = strconv.Atoi(stopLine)
+
+		fileName, line = stopFile, current
+	}
+
+	if len(location) > 0 {
+		file, lineNumber, hasFile := strings.Cut(location, ":")
+		if !hasFile {
+			file, lineNumber = fileName, location
+		} else if file != fileName {
+			current = 0
+		}
+
+		fileName = file
+		line, _ = strconv.Atoi(lineNumber)
+	}
+
+	sourceFile, err := FetchSourceFile(nodeId, fileName)
+	if err != nil {
+		logger.Error("Failed to fetch the source of node %d: %v", nodeId, err)
+		return
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(sourceFile.Contents), "\n"), "\n")
+
+	if line <= 0 {
+		line = 1
+	}
+	if line > len(lines) {
+		logger.Warn("%v has %d lines", sourceFile.Path, len(lines))
+		return
+	}
+
+	first := line - LISTED_LINES/2
+	if first < 1 {
+		first = 1
+	}
+	last := first + LISTED_LINES - 1
+	if last > len(lines) {
+		last = len(lines)
+	}
+
+	if sourceFile.Mismatch {
+		logger.Warn("SOURCE MISMATCH: %v on node %d does not match the MD5 recorded in the binary (%v), the lines listed are not the code executed",
+			sourceFile.Path, nodeId, sourceFile.Checksum)
+	}
+
+	fmt.Printf("%v:\n", sourceFile.Path)
+	for lineNumber := first; lineNumber <= last; lineNumber++ {
+		marker := " "
+		if lineNumber == current {
+			marker = ">"
+		}
+
+		fmt.Printf("%s %4d  %s\n", marker, lineNumber, strings.TrimSuffix(lines[lineNumber-1], "\r"))
+	}
+}
+
+// Completes an argument of a node command from the debug info of the target of a node, the lowest registered node
+// if the node is not. Returns the candidates for the whole argument, none if the node cannot be asked
+func CompleteArgument(nodeId int, kind string, prefix string) []string {
+	node := getNode(nodeId)
+	if node == nil {
+		if nodeIds := GetRegisteredIds(); len(nodeIds) > 0 {
+			node = getNode(nodeIds[0])
+		}
+	}
+
+	if node == nil || node.client == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), COMPLETION_TIMEOUT)
+	defer cancel()
+
+	completions, err := node.client.SourceServer().Complete(ctx, &pb.CompletionRequest{Kind: kind, Prefix: prefix})
+	if err != nil {
+		logger.Debug("Failed to complete %v on node %d: %v", prefix, nodeId, err)
+		return nil
+	}
+
+	return completions.Candidates
+}
@@ -0,0 +1,217 @@
+package nodeconnection
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// The state of a node, as shown by the dashboard
+type NodeState struct {
+	Id        int            `json:"id"`
+	Pid       int            `json:"pid"`
+	Host      string         `json:"host"`
+	Running   bool           `json:"running"`
+	Detached  bool           `json:"detached"`
+	LastEvent *rpc.NodeEvent `json:"lastEvent"` // nil if the node reported no event yet
+	Usage     *NodeUsage     `json:"usage"`     // nil if the node reported no resource usage yet
+}
+
+// The latest resource usage of the target of a node
+type NodeUsage struct {
+	CPUTime     time.Duration `json:"cpuTime"`
+	Utilization float64       `json:"utilization"` // share of a cpu used since the report before, -1 if unknown
+	RSS         uint64        `json:"rss"`
+	Swap        uint64        `json:"swap"`
+	ReadBytes   uint64        `json:"readBytes"`
+	WriteBytes  uint64        `json:"writeBytes"`
+}
+
+// guards the resource usage of the nodes, reported on the goroutines of the rpc calls
+var usageLock sync.Mutex
+
+// Records a resource usage report of the node, keeping the one before it for the utilization
+func (n *node) recordUsage(usage *rpc.ResourceUsageRecord) {
+	usageLock.Lock()
+	defer usageLock.Unlock()
+
+	n.previousUsage, n.usage = n.usage, usage
+}
+
+// Returns the latest resource usage of the node, nil if it reported none yet
+func (n *node) getUsage() *NodeUsage {
+	usageLock.Lock()
+	defer usageLock.Unlock()
+
+	if n.usage == nil {
+		return nil
+	}
+
+	return &NodeUsage{
+		CPUTime:     n.usage.CPUTime,
+		Utilization: utilization(n.previousUsage, n.usage),
+		RSS:         n.usage.RSS,
+		Swap:        n.usage.Swap,
+		ReadBytes:   n.usage.ReadBytes,
+		WriteBytes:  n.usage.WriteBytes,
+	}
+}
+
+// Returns the state of every registered node, by ascending node id
+func GetNodeStates() []NodeState {
+	if checkpointmanager.IsReadOnly() {
+		return recordedNodeStates()
+	}
+
+	states := make([]NodeState, 0, registeredNodeCount())
+
+	for _, nodeId := range GetRegisteredIds() {
+		node := getNode(nodeId)
+		if node == nil {
+			continue
+		}
+
+		states = append(states, NodeState{
+			Id:        node.id,
+			Pid:       node.pid,
+			Host:      node.host,
+			Running:   node.running,
+			Detached:  node.detached,
+			LastEvent: node.lastEvent,
+			Usage:     node.getUsage(),
+		})
+	}
+
+	return states
+}
+
+// Prints the latest resource usage reported by each node
+func PrintStatus() {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	if checkpointmanager.IsReadOnly() {
+		printRecordedStatus(writer)
+		return
+	}
+
+	fmt.Fprintln(writer, "node\tpid\tcpu time\tcpu %\trss\tswap\tread\twritten\tlast event")
+
+	for _, nodeId := range GetRegisteredIds() {
+		node := getNode(nodeId)
+		if node == nil {
+			continue
+		}
+
+		usage := node.getUsage()
+		if usage == nil {
+			fmt.Fprintf(writer, "%d\t%d\t-\t-\t-\t-\t-\t-\t%s\n", node.id, node.pid, formatEvent(node.lastEvent))
+			continue
+		}
+
+		fmt.Fprintf(writer, "%d\t%d\t%v\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			node.id,
+			node.pid,
+			usage.CPUTime,
+			formatUtilization(usage.Utilization),
+			utils.FormatByteSize(usage.RSS),
+			utils.FormatByteSize(usage.Swap),
+			utils.FormatByteSize(usage.ReadBytes),
+			utils.FormatByteSize(usage.WriteBytes),
+			formatEvent(node.lastEvent),
+		)
+	}
+
+	writer.Flush()
+}
+
+// The last recorded event of each node, in a session opened in the viewer
+func recordedNodeStates() []NodeState {
+	lastEvents := make(map[int]*rpc.NodeEvent)
+
+	for nodeId := range checkpointmanager.GetCheckpointLog() {
+		lastEvents[int(nodeId)] = nil
+	}
+
+	events := checkpointmanager.GetRecordedEvents()
+	for index := range events {
+		lastEvents[events[index].NodeId] = &events[index]
+	}
+
+	states := make([]NodeState, 0, len(lastEvents))
+	for nodeId, event := range lastEvents {
+		states = append(states, NodeState{Id: nodeId, LastEvent: event})
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Id < states[j].Id })
+
+	return states
+}
+
+// Prints the last recorded event of each node and the number of events recorded, in a session opened in the viewer
+func printRecordedStatus(writer *tabwriter.Writer) {
+	counts := make(map[int]int)
+	for _, event := range checkpointmanager.GetRecordedEvents() {
+		counts[event.NodeId]++
+	}
+
+	fmt.Fprintln(writer, "node\tevents\tlast event\ttime\t")
+
+	for _, state := range recordedNodeStates() {
+		if state.LastEvent == nil {
+			fmt.Fprintf(writer, "%d\t0\t-\t-\t\n", state.Id)
+			continue
+		}
+
+		description := state.LastEvent.Kind
+		if len(state.LastEvent.Location) > 0 {
+			description += " " + state.LastEvent.Location
+		}
+
+		fmt.Fprintf(writer, "%d\t%d\t%s\t%s\t\n", state.Id, counts[state.Id], description, state.LastEvent.Time.Format("15:04:05"))
+	}
+
+	writer.Flush()
+}
+
+// The kind, location and age of an event, e.g. "breakpoint sr.c:20 (3s ago)"
+func formatEvent(event *rpc.NodeEvent) string {
+	if event == nil {
+		return "-"
+	}
+
+	description := event.Kind
+	if len(event.Location) > 0 {
+		description += " " + event.Location
+	}
+
+	return fmt.Sprintf("%s (%v ago)", description, time.Since(event.Time).Round(time.Second))
+}
+
+// Share of a cpu the target used between two reports, -1 if unknown
+func utilization(previous *rpc.ResourceUsageRecord, current *rpc.ResourceUsageRecord) float64 {
+	if previous == nil {
+		return -1
+	}
+
+	elapsed := current.Timestamp.Sub(previous.Timestamp)
+	if elapsed <= 0 {
+		return -1
+	}
+
+	return float64(current.CPUTime-previous.CPUTime) / float64(elapsed)
+}
+
+func formatUtilization(utilization float64) string {
+	if utilization < 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.0f%%", 100*utilization)
+}
@@ -0,0 +1,89 @@
+package nodeconnection
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+)
+
+// Counters of a node over the whole run, kept after the node exits
+type runStatistics struct {
+	checkpoints    int // checkpoints taken, including those of re-executions after rollbacks
+	breakpointHits int // stops at user breakpoints
+	exited         bool
+
+	breakpointLocations map[string]int // stops at user breakpoints by their source location
+	location            string         // source location of the last stop (file:line, empty if unknown)
+	backtrace           string         // call stack at the last stop
+}
+
+// keys - node ids
+var nodeStatistics = make(map[int]*runStatistics)
+
+func getRunStatistics(nodeId int) *runStatistics {
+	nodesLock.Lock()
+	defer nodesLock.Unlock()
+
+	if nodeStatistics[nodeId] == nil {
+		nodeStatistics[nodeId] = &runStatistics{breakpointLocations: make(map[string]int)}
+	}
+
+	return nodeStatistics[nodeId]
+}
+
+// Returns the ids of the nodes with statistics, those that exited among them, in order
+func statisticsNodeIds() []int {
+	nodesLock.RLock()
+	nodeIds := make([]int, 0, len(nodeStatistics))
+	for nodeId := range nodeStatistics {
+		nodeIds = append(nodeIds, nodeId)
+	}
+	nodesLock.RUnlock()
+
+	sort.Ints(nodeIds)
+	return nodeIds
+}
+
+// Prints the events recorded, checkpoints taken and breakpoints hit by each rank during the run
+func PrintRunSummary() {
+	checkpointLog := checkpointmanager.GetCheckpointLog()
+
+	nodeIds := statisticsNodeIds()
+
+	fmt.Print("\nRun summary:\n\n")
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(writer, "node\trank\tevents\tcheckpoints\tbreakpoint hits\t")
+
+	total := runStatistics{}
+	totalEvents := 0
+
+	for _, nodeId := range nodeIds {
+		statistics := getRunStatistics(nodeId)
+		events := len(checkpointLog[checkpointmanager.NodeId(nodeId)])
+
+		rank := "-"
+		if nodeRank := checkpointmanager.GetNodeRank(checkpointmanager.NodeId(nodeId)); nodeRank != nil {
+			rank = fmt.Sprint(*nodeRank)
+		}
+
+		fmt.Fprintf(writer, "%d\t%s\t%d\t%d\t%d\t\n", nodeId, rank, events, statistics.checkpoints, statistics.breakpointHits)
+
+		totalEvents += events
+		total.checkpoints += statistics.checkpoints
+		total.breakpointHits += statistics.breakpointHits
+	}
+
+	fmt.Fprintf(writer, "total\t\t%d\t%d\t%d\t\n", totalEvents, total.checkpoints, total.breakpointHits)
+
+	writer.Flush()
+	fmt.Println()
+
+	if leaked := checkpointmanager.CountLeakedMessages(); leaked > 0 {
+		fmt.Printf("%d messages were never matched, list them with check messages\n\n", leaked)
+	}
+}
@@ -0,0 +1,46 @@
+package nodeconnection
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// time a node has to look up a variable or expand a value
+const VARIABLES_TIMEOUT = 5 * time.Second
+
+// Looks up a variable of a node, or expands the children of a value by its handle (command.ChildrenArgument).
+// Front-ends expand the values they show one level at a time with it
+func ExpandValue(nodeId int, argument string) ([]command.Value, error) {
+	cmd := &command.Command{NodeId: nodeId, Code: command.Variables, Argument: argument}
+
+	results, err := HandleAndWait([]*command.Command{cmd}, VARIABLES_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+
+	result := results[nodeId]
+	if len(result.Error) > 0 {
+		return result.Values, errors.New(result.Error)
+	}
+
+	return result.Values, nil
+}
+
+// Prints a variable of a node one level deep, or the children of a value expanded before.
+// Errors of the node are printed as it reports them
+func PrintValues(nodeId int, argument string) {
+	cmd := &command.Command{NodeId: nodeId, Code: command.Variables, Argument: argument}
+
+	results, err := HandleAndWait([]*command.Command{cmd}, VARIABLES_TIMEOUT)
+	if err != nil {
+		logger.Warn("%v", err)
+		return
+	}
+
+	for _, value := range results[nodeId].Values {
+		logger.Info("%d: %v", nodeId, value)
+	}
+}
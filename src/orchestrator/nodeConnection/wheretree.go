@@ -0,0 +1,175 @@
+package nodeconnection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// how long the nodes are given to report their call stacks
+const WHERETREE_TIMEOUT = 10 * time.Second
+
+// name of the frame of nodes whose call stack is empty, stopped outside of code with debug info
+const UNKNOWN_FRAME = "??"
+
+// A frame of the merged call stacks, with the nodes executing it
+type callTreeFrame struct {
+	name     string
+	nodeIds  []int
+	children []*callTreeFrame
+}
+
+func (f *callTreeFrame) child(name string) *callTreeFrame {
+	for _, child := range f.children {
+		if child.name == name {
+			return child
+		}
+	}
+
+	child := &callTreeFrame{name: name}
+	f.children = append(f.children, child)
+
+	return child
+}
+
+// Collects the call stacks of all nodes at once and prints them merged into a tree from main down, with how many
+// nodes are at each frame: the nodes of a hung job gather in a few branches, the odd ones out stand apart.
+// Nodes running a continue or a step are interrupted for it and stay stopped
+func PrintWhereTree() {
+	backtraces, missing, detached := collectBacktraces(WHERETREE_TIMEOUT)
+
+	if len(detached) > 0 {
+		logger.Info("Detached nodes are left out: %v", command.FormatSelector(detached))
+	}
+
+	if len(backtraces) == 0 {
+		logger.Warn("No call stacks reported by the nodes")
+		return
+	}
+
+	root := &callTreeFrame{}
+
+	for _, nodeId := range GetRegisteredIds() {
+		backtrace, reported := backtraces[nodeId]
+		if !reported {
+			continue
+		}
+
+		frames := strings.Split(backtrace, " <- ")
+		if len(backtrace) == 0 {
+			frames = []string{UNKNOWN_FRAME}
+		}
+
+		// the backtraces list the innermost frame first
+		frame := root
+		for index := len(frames) - 1; index >= 0; index-- {
+			frame = frame.child(frames[index])
+			frame.nodeIds = append(frame.nodeIds, nodeId)
+		}
+	}
+
+	fmt.Println()
+	for index, child := range root.children {
+		printCallTreeFrame(child, "", index == len(root.children)-1)
+	}
+
+	if len(missing) > 0 {
+		fmt.Printf("\n%d nodes did not report their call stack within %v: %v\n", len(missing), WHERETREE_TIMEOUT, command.FormatSelector(missing))
+	}
+	fmt.Println()
+}
+
+func printCallTreeFrame(frame *callTreeFrame, indent string, last bool) {
+	branch, childIndent := "├─ ", "│  "
+	if last {
+		branch, childIndent = "└─ ", "   "
+	}
+
+	nodes := "nodes"
+	if len(frame.nodeIds) == 1 {
+		nodes = "node"
+	}
+
+	fmt.Printf("%s%s%s  [%d %s: %s]\n", indent, branch, frame.name, len(frame.nodeIds), nodes, command.FormatSelector(frame.nodeIds))
+
+	for index, child := range frame.children {
+		printCallTreeFrame(child, indent+childIndent, index == len(frame.children)-1)
+	}
+}
+
+// Asks every node for its call stack: running nodes are interrupted, which ends their command with the call stack
+// in its result, the stopped ones execute a backtrace command. Returns the call stacks by node id, the nodes that
+// could not be asked or did not report within the timeout and the detached nodes, which are not asked
+func collectBacktraces(timeout time.Duration) (backtraces map[int]string, missing []int, detached []int) {
+	// results of earlier commands
+	for len(commandResults) > 0 {
+		<-commandResults
+	}
+
+	backtraces = make(map[int]string)
+	awaited := make(map[int]bool)
+
+	for _, nodeId := range GetRegisteredIds() {
+		node := getNode(nodeId)
+		if node == nil || node.client == nil {
+			continue
+		}
+
+		// interrupting a detached node would reattach it
+		if node.detached {
+			detached = append(detached, nodeId)
+			continue
+		}
+
+		if node.running {
+			reply, err := node.client.CommandHandler().Interrupt(context.Background(), &pb.InterruptRequest{Reason: "collecting its call stack"})
+			if err != nil {
+				logger.Warn("Failed to interrupt node %d: %v", nodeId, err)
+			}
+
+			if err == nil && reply.Interrupted {
+				awaited[nodeId] = true
+				continue
+			}
+		}
+
+		// a stopped node, or one finishing its command, reports the backtrace once done
+		if HandleRemotely(&command.Command{NodeId: nodeId, Code: command.Backtrace}) == nil {
+			awaited[nodeId] = true
+		} else {
+			missing = append(missing, nodeId)
+		}
+	}
+
+	deadline := time.After(timeout)
+
+	for len(backtraces) < len(awaited) {
+		select {
+		case cmd := <-commandResults:
+			if awaited[cmd.NodeId] && cmd.Result.Exited {
+				delete(awaited, cmd.NodeId)
+				continue
+			}
+
+			if !awaited[cmd.NodeId] || (cmd.Code != command.Backtrace && !cmd.Result.Interrupted) {
+				continue
+			}
+
+			backtraces[cmd.NodeId] = cmd.Result.Backtrace
+		case <-deadline:
+			for nodeId := range awaited {
+				if _, reported := backtraces[nodeId]; !reported {
+					missing = append(missing, nodeId)
+				}
+			}
+			return backtraces, missing, detached
+		}
+	}
+
+	return backtraces, missing, detached
+}
@@ -1,29 +1,75 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ottmartens/cc-rev-db/logger"
 	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
 	"github.com/ottmartens/cc-rev-db/orchestrator/cli"
+	"github.com/ottmartens/cc-rev-db/orchestrator/dashboard"
 	"github.com/ottmartens/cc-rev-db/orchestrator/gui"
 	"github.com/ottmartens/cc-rev-db/orchestrator/gui/websocket"
 	nodeconnection "github.com/ottmartens/cc-rev-db/orchestrator/nodeConnection"
 	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
 	"github.com/ottmartens/cc-rev-db/utils"
 	"github.com/ottmartens/cc-rev-db/utils/command"
+	"github.com/ottmartens/cc-rev-db/utils/mpi"
+	"google.golang.org/grpc"
 )
 
+// time the nodes have to record their snapshots for a global checkpoint
+const SNAPSHOT_TIMEOUT = 10 * time.Second
+
+// time the nodes of a session have to rejoin an orchestrator restarted with --rejoin, as long as they keep redialing
+const REJOIN_TIMEOUT = 2 * time.Minute
+
 var NODE_DEBUGGER_PATH = fmt.Sprintf("%s/node-debugger", utils.GetExecutableDir())
 
-const ORCHESTRATOR_PORT = 3490
+// port the orchestrator listens on, another one for the sessions of the session service
+var orchestratorPort = rpc.DEFAULT_ORCHESTRATOR_PORT
 
 func main() {
 	logger.SetMaxLogLevel(logger.Levels.Verbose)
-	numProcesses, targetPath := cli.ParseArgs()
+
+	// the session service and its clients
+	if len(os.Args) > 1 && (os.Args[1] == "serve" || os.Args[1] == "session") {
+		runSessionService(os.Args[1:])
+		return
+	}
+
+	numProcesses, targetPath, sessionFile, options := cli.ParseArgs()
+
+	cli.LoadUserCommands(options.ConfigFile)
+
+	if len(sessionFile) > 0 {
+		runViewer(sessionFile, options)
+		return
+	}
+
+	launchedSession.targetPath, launchedSession.options, launchedSession.started = targetPath, options, time.Now()
+
+	orchestratorPort, listenHost = options.Port, options.ListenHost
+	nodeconnection.SetOrchestratorPort(options.Port)
+	setUpSessionToken()
+
+	if options.RemoteConsole || len(options.Dashboard) > 0 {
+		cli.CaptureConsoleOutput()
+	}
+
+	if options.JSON {
+		cli.PrintJSONOutput()
+		nodeconnection.SetJSONOutput(true)
+	} else {
+		cli.RenderEventsAbovePrompt()
+	}
 
 	// start goroutine for collecting checkpoint results
 	checkpointRecordChan := make(chan rpc.MPICallRecord)
@@ -31,41 +77,164 @@ func main() {
 
 	// start rpc server in separate goroutine
 	go func() {
-		rpc.InitializeServer(ORCHESTRATOR_PORT, func(register rpc.Registrator) {
-			register(new(logger.LoggerServer))
-			register(nodeconnection.NewNodeReporter(checkpointRecordChan, quit))
+		rpc.InitializeServer(listenHost, orchestratorPort, func(server grpc.ServiceRegistrar) {
+			pb.RegisterLoggerServer(server, new(logger.LoggerServer))
+			pb.RegisterNodeReporterServer(server, nodeconnection.NewNodeReporter(checkpointRecordChan, func() { complete(options.OnComplete) }))
+			pb.RegisterSessionServer(server, nodeconnection.Session{})
+
+			if options.RemoteConsole {
+				pb.RegisterRemoteConsoleServer(server, cli.RemoteConsole{})
+			}
 		})
 	}()
 
-	logger.Info("executing %v as an mpi job with %d processes", targetPath, numProcesses)
+	utils.SeedRandomIds(options.Seed)
 
-	// Start the MPI job
-	mpiProcess := exec.Command(
-		"mpirun",
-		"-np",
-		fmt.Sprintf("%d", numProcesses),
-		NODE_DEBUGGER_PATH,
-		targetPath,
-		fmt.Sprintf("localhost:%d", ORCHESTRATOR_PORT),
-	)
-
-	mpiProcess.Stdout = os.Stdout
-	mpiProcess.Stderr = os.Stderr
+	checkpointmanager.SetSessionFingerprint(newSessionFingerprint(targetPath, numProcesses, options.Launcher))
+	checkpointmanager.SetRetentionPolicy(options.Retention)
+	scheduleAutoCheckpoints(options.CheckpointInterval, options.CheckpointEvents)
 
-	err := mpiProcess.Start()
-	utils.Must(err)
+	if options.Rejoin {
+		nodeconnection.AcceptRejoins()
+	} else {
+		launchJob(numProcesses, targetPath, options)
+	}
 
 	defer quit()
 
+	if len(options.Dashboard) > 0 {
+		dashboard.Start(options.Dashboard)
+	}
+
+	if len(options.API) > 0 {
+		startAPI(options.API)
+	}
+
 	// start the graphical user interface
 	// when running with docker, gui must be started on the host
-	if !utils.IsRunningInContainer() {
+	if !utils.IsRunningInContainer() && !options.Headless {
 		gui.Start()
 
 		websocket.InitServer()
+		nodeconnection.RegisterFrontend("websocket")
 		websocket.WaitForClientConnection()
 	}
 
+	// wait for nodes to finish startup sequence
+	if options.Rejoin {
+		nodeconnection.WaitForRejoins(numProcesses, REJOIN_TIMEOUT)
+	} else if len(options.DeployHosts) > 0 {
+		connectDeployedNodes(options.DeployHosts, numProcesses)
+	} else {
+		time.Sleep(time.Second)
+	}
+	nodeconnection.ConnectToAllNodes(numProcesses)
+
+	if err := checkpointmanager.CheckBinaryCompatibility(); err != nil {
+		if options.RequireSameBinary {
+			logger.Error("%v", err)
+			quit()
+		}
+		logger.Warn("%v", err)
+	}
+
+	time.Sleep(time.Second)
+
+	checkpointmanager.PrintFingerprint()
+
+	cli.PrintInstructions()
+
+	cli.QueueStartupCommands(options.Commands, options.Batch)
+
+	for {
+		cmd := cli.AskForInput()
+
+		sessionMutex.Lock()
+		if cli.ScriptedInput() && cmd.IsProgressCommand() && cmd.Code != command.Detach {
+			handleScriptedProgress(cmd)
+		} else {
+			handleCommand(cmd)
+		}
+		sessionMutex.Unlock()
+	}
+}
+
+// Executes a progress command of a script and waits for its nodes to stop, for the commands of the script following it
+func handleScriptedProgress(cmd *command.Command) {
+	nodeIds := cmd.Targets
+	if len(nodeIds) == 0 {
+		nodeIds = []int{cmd.NodeId}
+	}
+
+	err := nodeconnection.AwaitStop(nodeIds, cli.SCRIPT_STOP_TIMEOUT, func() { handleCommand(cmd) })
+	if err != nil {
+		logger.Warn("%v, going on with the script", err)
+	}
+}
+
+// Starts the MPI job with the node debugger as its executable, debugging the target
+func launchJob(numProcesses int, targetPath string, options cli.LaunchOptions) {
+	logger.Info("executing %v as an mpi job with %d processes (%v)", targetPath, numProcesses, options.Launcher)
+
+	debuggerPath, nodeTargetPath := NODE_DEBUGGER_PATH, targetPath
+
+	mpiArgs := launcherArgs(options.Launcher, numProcesses, listensOnNetwork())
+
+	if len(options.DeployHosts) > 0 {
+		debuggerPath, nodeTargetPath = deployAgents(options.DeployHosts, targetPath)
+		mpiArgs = append(mpiArgs, "--host", strings.Join(options.DeployHosts, ","))
+	}
+
+	mpiArgs = append(mpiArgs,
+		debuggerPath,
+		nodeTargetPath,
+		orchestratorAddress(options),
+	)
+
+	if options.DisableASLR {
+		mpiArgs = append(mpiArgs, "--no-aslr")
+	}
+
+	if options.NoStopAtMain {
+		mpiArgs = append(mpiArgs, "--no-stop-at-main")
+	}
+
+	if len(options.WatchdogTimeout) > 0 {
+		mpiArgs = append(mpiArgs, fmt.Sprintf("--watchdog=%s", options.WatchdogTimeout))
+	}
+
+	if options.DeterministicReplay {
+		mpiArgs = append(mpiArgs, "--deterministic-replay")
+	}
+
+	if len(options.PayloadCap) > 0 {
+		mpiArgs = append(mpiArgs, fmt.Sprintf("--payload-cap=%s", options.PayloadCap))
+	}
+
+	if len(options.CheckpointBackend) > 0 {
+		mpiArgs = append(mpiArgs, fmt.Sprintf("--checkpoint-backend=%s", options.CheckpointBackend))
+	}
+
+	if len(options.StorageDir) > 0 {
+		mpiArgs = append(mpiArgs, fmt.Sprintf("--storage-dir=%s", options.StorageDir))
+	}
+
+	mpiArgs = append(mpiArgs, fmt.Sprintf("--seed=%d", options.Seed))
+
+	// Start the MPI job
+	mpiProcess := exec.Command(options.Launcher, mpiArgs...)
+
+	// the token reaches the nodes in their environment
+	if len(rpc.SessionToken()) > 0 {
+		mpiProcess.Env = append(os.Environ(), fmt.Sprintf("%s=%s", rpc.TOKEN_ENV, rpc.SessionToken()))
+	}
+
+	mpiProcess.Stdout = os.Stdout
+	mpiProcess.Stderr = os.Stderr
+
+	err := mpiProcess.Start()
+	utils.Must(err)
+
 	// asyncronously wait for the MPI job to finish
 	go func() {
 		mpiProcess.Wait()
@@ -75,36 +244,221 @@ func main() {
 			os.Exit(1)
 		}
 	}()
+}
 
-	// wait for nodes to finish startup sequence
-	time.Sleep(time.Second)
-	nodeconnection.ConnectToAllNodes(numProcesses)
-
-	time.Sleep(time.Second)
+// Executes a command read from the console. The session outlives bugs in the execution of a command
+func handleCommand(cmd *command.Command) {
+	defer utils.RecoverPanic(func(err error, stack []byte) {
+		logger.Error("Command %v failed: %v", cmd, err)
+		logger.Info("hint: %v", utils.ErrorHint(utils.ErrorKind(err)))
+		logger.Debug("%s", stack)
+	})
 
-	cli.PrintInstructions()
+	if checkpointmanager.IsReadOnly() && handleRecordedCommand(cmd) {
+		return
+	}
 
-	for {
-		cmd := cli.AskForInput()
+	if len(cmd.Targets) > 0 {
+		handleOnTargets(cmd)
+		return
+	}
 
-		switch cmd.Code {
-		case command.Quit:
-			quit()
-		case command.Help:
-			cli.PrintInstructions()
-			break
-		case command.ListCheckpoints:
-			checkpointmanager.ListCheckpoints()
-			break
-		case command.GlobalRollback:
-			handleRollbackSubmission(cmd)
-			break
-		default:
+	switch cmd.Code {
+	case command.Quit:
+		quit()
+	case command.Help:
+		cli.PrintInstructions()
+		break
+	case command.ListCheckpoints:
+		checkpointmanager.ListCheckpoints()
+		break
+	case command.NameCheckpoint:
+		handleNameCheckpoint(cmd)
+		break
+	case command.PruneCheckpoints:
+		handlePruneCheckpoints(cmd)
+		break
+	case command.AutoCheckpoint:
+		handleAutoCheckpoint(cmd)
+		break
+	case command.ReverseWindow:
+		handleReverseWindow(cmd)
+		break
+	case command.Status:
+		nodeconnection.PrintStatus()
+		break
+	case command.Capabilities:
+		nodeconnection.PrintCapabilities()
+		break
+	case command.WhereTree:
+		nodeconnection.PrintWhereTree()
+		break
+	case command.CausalBreakpoint:
+		handleCausalBreakpoint(cmd)
+		break
+	case command.AllStop:
+		handleAllStop(cmd)
+		break
+	case command.NodeGroup:
+		handleNodeGroup(cmd)
+		break
+	case command.CheckMessages:
+		checkpointmanager.CheckMessages()
+		break
+	case command.InspectMessage:
+		checkpointmanager.InspectMessage(cmd.Argument.(string))
+		break
+	case command.GlobalRollback:
+		handleRollbackSubmission(cmd)
+		break
+	case command.NodeRollback:
+		handleNodeRollbackSubmission(cmd)
+		break
+	case command.GlobalCheckpoint:
+		handleGlobalCheckpoint()
+		break
+	case command.Invariant:
+		handleInvariant(cmd)
+		break
+	case command.Explore:
+		handleExplore(cmd)
+		break
+	case command.ReorderMessages:
+		handleReorder(cmd)
+		break
+	case command.ExportSession:
+		err := checkpointmanager.ExportSession(cmd.Argument.(string))
+		if err != nil {
+			logger.Error("Failed to export session: %v", err)
+		}
+		break
+	case command.RunReport:
+		writeRunReport(cmd.Argument.(string))
+		break
+	case command.EmitReproducer:
+		emitReproducer(cmd.Argument.(string))
+		break
+	case command.HashHistory:
+		checkpointmanager.PrintHashHistory(cmd.Argument.(string))
+		break
+	case command.ListSource:
+		nodeconnection.ListSource(cmd.NodeId, cmd.Argument.(string))
+		break
+	case command.Detach:
+		nodeconnection.Detach(cmd.NodeId)
+		break
+	case command.Attach:
+		nodeconnection.Attach(cmd.NodeId)
+		break
+	case command.Variables:
+		nodeconnection.PrintValues(cmd.NodeId, cmd.Argument.(string))
+		break
+	case command.InjectFault, command.PayloadCap, command.AutoHashBuffer, command.TrapNaN, command.FPEnvironment, command.CatchOutput, command.BreakIteration:
+		if cmd.NodeId == command.AllNodes {
+			nodeconnection.HandleOnAllNodes(cmd)
+		} else {
 			nodeconnection.HandleRemotely(cmd)
-			time.Sleep(time.Second)
-			break
 		}
+		break
+	default:
+		// the results are rendered once the node reports them
+		nodeconnection.HandleRemotely(cmd)
+		break
+	}
+}
+
+// Runs a command prefixed with a target selector on the nodes selected. The commands handled by the orchestrator
+// run node by node, the others are sent to the nodes at once
+func handleOnTargets(cmd *command.Command) {
+	switch cmd.Code {
+	case command.ListSource, command.Detach, command.Attach:
+		for _, nodeId := range cmd.Targets {
+			nodeCommand := *cmd
+			nodeCommand.NodeId = nodeId
+			nodeCommand.Targets = nil
+
+			handleCommand(&nodeCommand)
+		}
+	default:
+		nodeconnection.FanOut(cmd)
+	}
+}
+
+// Adds, lists or clears causal breakpoints (causal <receiver nid> from <sender nid> after [<file>:]<line>)
+func handleCausalBreakpoint(cmd *command.Command) {
+	argument := cmd.Argument.(string)
+
+	switch argument {
+	case "":
+		nodeconnection.ListCausalBreakpoints()
+		return
+	case "clear":
+		nodeconnection.ClearCausalBreakpoints()
+		return
+	}
+
+	var receiver, sender int
+	var location string
+
+	_, err := fmt.Sscanf(argument, "%d from %d after %s", &receiver, &sender, &location)
+	if err == nil {
+		err = nodeconnection.AddCausalBreakpoint(receiver, sender, location)
+	}
+
+	if err != nil {
+		logger.Error("Failed to set the causal breakpoint: %v", err)
+	}
+}
+
+// Shows or toggles all-stop mode (all-stop [on|off])
+func handleAllStop(cmd *command.Command) {
+	switch cmd.Argument.(string) {
+	case "on":
+		nodeconnection.SetAllStop(true)
+	case "off":
+		nodeconnection.SetAllStop(false)
+	default:
+		if nodeconnection.AllStopEnabled() {
+			logger.Info("All-stop is on, a node stopping at a breakpoint interrupts the running nodes")
+		} else {
+			logger.Info("All-stop is off, turn it on with all-stop on")
+		}
+	}
+}
+
+// Defines, lists or removes groups of nodes (group <name> <nodes>), selected by @<name>
+func handleNodeGroup(cmd *command.Command) {
+	argument := cmd.Argument.(string)
+
+	if len(argument) == 0 {
+		names := command.GroupNames()
+		if len(names) == 0 {
+			logger.Info("No groups of nodes, define one with group <name> <nodes>")
+			return
+		}
+
+		for _, name := range names {
+			logger.Info("%v: nodes %v", name, command.GroupMembers(name))
+		}
+		return
 	}
+
+	name, selector, _ := strings.Cut(argument, " ")
+
+	if name == "clear" {
+		if !command.RemoveGroup(selector) {
+			logger.Warn("No group %v", selector)
+		}
+		return
+	}
+
+	err := command.DefineGroup(name, selector, nodeconnection.GetRegisteredIds())
+	if err != nil {
+		logger.Warn("Cannot define group %v: %v", name, err)
+		return
+	}
+
+	logger.Info("Group %v: nodes %v", name, command.GroupMembers(name))
 }
 
 func handleRollbackSubmission(cmd *command.Command) {
@@ -113,8 +467,7 @@ func handleRollbackSubmission(cmd *command.Command) {
 		return
 	}
 
-	logger.Info("Following checkpoints scheduled for rollback:")
-	logger.Info("%v", pendingRollback)
+	checkpointmanager.PrintRollback(*pendingRollback)
 
 	commit := cli.AskForRollbackCommit()
 
@@ -124,25 +477,291 @@ func handleRollbackSubmission(cmd *command.Command) {
 		return
 	}
 
-	nodeconnection.ExecutePendingRollback()
+	if nodeconnection.ExecutePendingRollback() == nil {
+		logger.Info("Nodes stopped at the restored checkpoints, continuing them replays the recorded events")
+	}
+}
+
+// Rolls a node back alone (rollback --node <nid> <checkpoint>), the messages it received since the checkpoint
+// are replayed to it from the message log
+func handleNodeRollbackSubmission(cmd *command.Command) {
+	nodeIdArgument, checkpointId, _ := strings.Cut(cmd.Argument.(string), " ")
+	nodeId, _ := strconv.Atoi(nodeIdArgument)
+
+	pendingRollback, messages := checkpointmanager.SubmitNodeRollback(checkpointmanager.NodeId(nodeId), checkpointId)
+	if pendingRollback == nil {
+		return
+	}
+
+	checkpointmanager.PrintRollback(*pendingRollback)
+	logger.Info("The other nodes are not rolled back, %d received messages are replayed from the message log", len(messages))
+
+	commit := cli.AskForRollbackCommit()
+
+	if !commit {
+		logger.Verbose("Cancelling pending rollback")
+		checkpointmanager.ResetPendingRollback()
+		return
+	}
+
+	messageLog, err := json.Marshal(messages)
+	utils.Must(err)
+
+	err = nodeconnection.HandleRemotely(&command.Command{
+		NodeId:   nodeId,
+		Code:     command.ReplayMessageLog,
+		Argument: string(messageLog),
+	})
+	if err != nil {
+		logger.Error("Failed to send the message log to node %d: %v", nodeId, err)
+		checkpointmanager.ResetPendingRollback()
+		return
+	}
+
+	if nodeconnection.ExecutePendingRollback() == nil {
+		logger.Info("Node %d stopped at the restored checkpoint, continuing it replays the recorded events", nodeId)
+	}
+}
+
+// Names a checkpoint (checkpoint name <id> <label>)
+func handleNameCheckpoint(cmd *command.Command) {
+	checkpointId, label, _ := strings.Cut(cmd.Argument.(string), " ")
+
+	err := checkpointmanager.NameCheckpoint(checkpointId, label)
+	if err != nil {
+		logger.Warn("Failed to name checkpoint: %v", err)
+		return
+	}
+
+	publishCheckpointLog()
+}
+
+// Prunes checkpoints (checkpoint prune [ids...]): the given checkpoints, or those beyond the retention policy
+func handlePruneCheckpoints(cmd *command.Command) {
+	idsOrLabels := strings.Fields(cmd.Argument.(string))
+
+	if len(idsOrLabels) == 0 {
+		if !checkpointmanager.HasRetentionPolicy() {
+			logger.Warn("No retention policy set, give the checkpoints to prune or start with --keep-last, --keep-every, --max-checkpoint-bytes or --window")
+			return
+		}
+
+		pruneCheckpoints(checkpointmanager.CheckpointsToPrune())
+		return
+	}
+
+	pruned, err := checkpointmanager.SubmitForPruning(idsOrLabels)
+	if err != nil {
+		logger.Warn("Failed to prune checkpoints: %v", err)
+		return
+	}
+
+	pruneCheckpoints(pruned)
+}
+
+// Marks the checkpoints pruned and tells their nodes to release them.
+// The nodes are told asynchronously, as they may be running until their next stop
+func pruneCheckpoints(pruned map[checkpointmanager.NodeId][]string) {
+	if len(pruned) == 0 {
+		return
+	}
+
+	for nodeId, checkpointIds := range pruned {
+		checkpointmanager.MarkPruned(nodeId, checkpointIds)
+
+		go nodeconnection.HandleRemotely(&command.Command{
+			NodeId:   int(nodeId),
+			Code:     command.ReleaseCheckpoints,
+			Argument: strings.Join(checkpointIds, " "),
+		})
+	}
+
+	publishCheckpointLog()
+}
+
+// global checkpoints waiting for the snapshots of the nodes, by snapshot id: the nodes taking part
+var pendingGlobalCheckpoints = struct {
+	sync.Mutex
+	nodeIds map[string][]int
+}{nodeIds: make(map[string][]int)}
+
+// Takes a coordinated checkpoint: every node records a snapshot where it is stopped, then the snapshots are
+// formed into a consistent global cut. Nodes still running reach their snapshot once they stop.
+// The console stays available meanwhile, the global checkpoint is recorded once the last snapshot is reported
+func handleGlobalCheckpoint() (snapshotId string) {
+	snapshotId = utils.RandomId()
+
+	pendingGlobalCheckpoints.Lock()
+	pendingGlobalCheckpoints.nodeIds[snapshotId] = nodeconnection.GetRegisteredIds()
+	pendingGlobalCheckpoints.Unlock()
+
+	nodeconnection.HandleOnAllNodes(&command.Command{Code: command.Snapshot, Argument: snapshotId})
+
+	time.AfterFunc(SNAPSHOT_TIMEOUT, func() {
+		pendingGlobalCheckpoints.Lock()
+		defer pendingGlobalCheckpoints.Unlock()
+
+		if _, pending := pendingGlobalCheckpoints.nodeIds[snapshotId]; pending {
+			delete(pendingGlobalCheckpoints.nodeIds, snapshotId)
+			logger.Warn("Global checkpoint failed: not all nodes recorded a snapshot within %v", SNAPSHOT_TIMEOUT)
+		}
+	})
+
+	return snapshotId
+}
+
+// Records the global checkpoint of a snapshot, if the snapshots of all its nodes have been reported
+func completeGlobalCheckpoint(snapshotId string) {
+	pendingGlobalCheckpoints.Lock()
+	defer pendingGlobalCheckpoints.Unlock()
+
+	nodeIds, pending := pendingGlobalCheckpoints.nodeIds[snapshotId]
+	if !pending || !checkpointmanager.SnapshotRecorded(snapshotId, nodeIds) {
+		return
+	}
+
+	delete(pendingGlobalCheckpoints.nodeIds, snapshotId)
+
+	checkpointmanager.RecordGlobalCheckpoint(snapshotId)
+}
+
+// Lists the messages a wildcard receive could have received instead (reorder <receive id>),
+// or rolls back to the receive and replays it with the message of another send (reorder <receive id> <send id>)
+func handleReorder(cmd *command.Command) {
+	ids := strings.Fields(cmd.Argument.(string))
+
+	if len(ids) == 1 {
+		checkpointmanager.ListReorderings(ids[0])
+		return
+	}
+
+	receiveId, sendId := ids[0], ids[1]
+
+	nodeId, source, pendingRollback := checkpointmanager.SubmitReordering(receiveId, sendId)
+	if pendingRollback == nil {
+		return
+	}
+
+	checkpointmanager.PrintRollback(*pendingRollback)
+
+	commit := cli.AskForRollbackCommit()
+
+	if !commit {
+		logger.Verbose("Cancelling pending rollback")
+		checkpointmanager.ResetPendingRollback()
+		return
+	}
+
+	replayReordered(nodeId, receiveId, sendId, source)
+}
+
+// Executes the pending rollback to a receive, having the receive take its message from the source rank when replayed
+func replayReordered(nodeId checkpointmanager.NodeId, receiveId string, sendId string, source int) error {
+	err := nodeconnection.HandleRemotely(&command.Command{
+		NodeId:   int(nodeId),
+		Code:     command.ForceReceiveSource,
+		Argument: fmt.Sprintf("%v %d", receiveId, source),
+	})
+	if err != nil {
+		logger.Error("Failed to reorder messages on node %d: %v", nodeId, err)
+		checkpointmanager.ResetPendingRollback()
+		return err
+	}
+
+	err = nodeconnection.ExecutePendingRollback()
+	if err != nil {
+		return err
+	}
+
+	checkpointmanager.ForceReceiveSource(receiveId, source)
+	logger.Info("%v replays with the message of %v", receiveId, sendId)
+
+	return nil
+}
+
+// Sends the checkpoint log to the web UI and the dashboard, each a copy of its own as they encode it on their
+// own goroutines while the nodes record further checkpoints
+func publishCheckpointLog() {
+	websocket.SendCheckpointUpdateMessage(checkpointmanager.GetCheckpointLog())
+	dashboard.UpdateCheckpoints(checkpointmanager.GetCheckpointLog())
 }
 
 func startCheckpointRecordCollector(
 	channel <-chan rpc.MPICallRecord,
 ) {
-	for {
-		callRecord := <-channel
+	for callRecord := range channel {
+		collectCheckpointRecord(callRecord)
+	}
+}
+
+// Records an MPI call reported by a node. A bug in recording a call leaves the other calls collected
+func collectCheckpointRecord(callRecord rpc.MPICallRecord) {
+	defer utils.RecoverPanic(func(err error, stack []byte) {
+		logger.Error("Failed to record MPI call %v of node %v: %v", callRecord.OpName, callRecord.NodeId, err)
+		logger.Debug("%s", stack)
+	})
+
+	// the first call with the rank of the target numbers the node by it
+	nodeconnection.ResolveRank(&callRecord)
+
+	logger.Debug("Node %v reported MPI call: %v", callRecord.NodeId, callRecord.OpName)
 
-		logger.Debug("Node %v reported MPI call: %v", callRecord.NodeId, callRecord.OpName)
+	checkpointmanager.RecordCheckpoint(callRecord)
+	publishCheckpointLog()
+
+	if checkpointmanager.HasRetentionPolicy() {
+		pruneCheckpoints(checkpointmanager.CheckpointsToPrune())
+	}
+
+	if callRecord.OpName == mpi.SNAPSHOT_EVENT {
+		completeGlobalCheckpoint(callRecord.Parameters["snapshot"])
+	}
+
+	countAutoCheckpointEvent(callRecord)
+}
+
+// Applies the --on-complete policy once all nodes have exited
+func complete(policy string) {
+	switch policy {
+	case cli.ON_COMPLETE_WAIT:
+		nodeconnection.PrintRunSummary()
+		logger.Info("All nodes exited. The session stays open for inspection, quit with q")
+		return
+	case cli.ON_COMPLETE_SUMMARY:
+		nodeconnection.PrintRunSummary()
+	case cli.ON_COMPLETE_REPORT:
+		writeRunReport("")
+	case cli.ON_COMPLETE_KEEP_LOGS:
+		sessionFile := fmt.Sprintf("session-%s.json", time.Now().Format("20060102-150405"))
+
+		err := checkpointmanager.ExportSession(sessionFile)
+		if err != nil {
+			logger.Error("Failed to export session: %v", err)
+		}
+	}
+
+	logger.Info("All nodes exited. Exiting in 10s")
+	time.Sleep(time.Second * 10)
+	quit()
+}
+
+// Writes the run report to the file, or to report-<timestamp>.md without one
+func writeRunReport(filePath string) {
+	if len(filePath) == 0 {
+		filePath = fmt.Sprintf("report-%s.md", time.Now().Format("20060102-150405"))
+	}
 
-		checkpointmanager.RecordCheckpoint(callRecord)
-		websocket.SendCheckpointUpdateMessage(checkpointmanager.GetCheckpointLog())
+	err := nodeconnection.WriteRunReport(filePath)
+	if err != nil {
+		logger.Error("Failed to write the run report: %v", err)
 	}
 }
 
 func quit() {
+	cli.RestoreTerminal()
 	nodeconnection.StopAllNodes()
 	gui.Stop()
+	closeTunnels()
 
 	time.Sleep(time.Second)
 	logger.Info("👋 exiting")
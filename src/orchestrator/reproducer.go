@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/orchestrator/cli"
+	"github.com/ottmartens/cc-rev-db/utils"
+)
+
+// longest pause between two replayed commands, the time the user spent reading the output is not replayed
+const MAX_REPLAY_DELAY = 10 * time.Second
+
+// environment of the orchestrator passed on to the MPI job, kept in reproducers
+var reproducedEnvironmentPrefixes = []string{"OMPI_MCA_", "PMIX_MCA_", "MPICH_", "HYDRA_", "I_MPI_", "UCX_", "OMP_"}
+var reproducedEnvironment = []string{"PATH", "LD_LIBRARY_PATH"}
+
+// how the session was started
+var launchedSession struct {
+	targetPath string
+	options    cli.LaunchOptions
+	started    time.Time
+}
+
+// Writes a shell script reproducing the session from scratch (emit-reproducer [<file>]): it checks the target
+// against the build-id of the session, rebuilds it first if SOURCE is given, starts the orchestrator with the
+// options, environment and seed of the session and feeds it the commands read so far, with the pauses between them.
+// Breakpoints and checkpoint ids are reproduced as long as the program and its MPI runs behave the same
+func emitReproducer(filePath string) {
+	if len(filePath) == 0 {
+		filePath = fmt.Sprintf("reproduce-%s.sh", time.Now().Format("20060102-150405"))
+	}
+
+	script, err := reproducerScript()
+	if err == nil {
+		err = os.WriteFile(filePath, []byte(script), 0755)
+	}
+
+	if err != nil {
+		logger.Error("Failed to write the reproducer: %v", err)
+		return
+	}
+
+	logger.Info("Reproducer written to %v, run it from anywhere to replay the session", filePath)
+}
+
+func reproducerScript() (string, error) {
+	var script strings.Builder
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	target, err := filepath.Abs(launchedSession.targetPath)
+	if err != nil {
+		return "", err
+	}
+
+	// bin/compiler and bin/orchestrator are run from the root of the repository
+	root := filepath.Dir(utils.GetExecutableDir())
+
+	fmt.Fprintln(&script, "#!/bin/bash")
+	fmt.Fprintf(&script, "# Reproduces a cc-rev-db session of %s, emitted %s\n", filepath.Base(target), time.Now().Format(time.RFC3339))
+	fmt.Fprintln(&script, "#")
+	fmt.Fprintln(&script, "# SOURCE=<file> rebuilds the target from its source with bin/compiler first.")
+	fmt.Fprintln(&script, "# The commands of the session are replayed, then the console is handed over if run in a terminal.")
+
+	fingerprint := checkpointmanager.GetSessionFingerprint()
+	buildId, producer := "", ""
+
+	if fingerprint != nil {
+		fmt.Fprintf(&script, "#\n# MPI: %s, debugger: %s\n", fingerprint.MPIImplementation, fingerprint.DebuggerVersion)
+
+		for _, node := range fingerprint.Nodes {
+			buildId, producer = node.BuildId, node.Producer
+			break
+		}
+
+		if len(producer) > 0 {
+			fmt.Fprintf(&script, "# compiled with: %s\n", producer)
+		}
+	}
+
+	fmt.Fprintln(&script, "\nset -e")
+	fmt.Fprintf(&script, "\ncd %s\n\n", shellQuote(root))
+
+	fmt.Fprintf(&script, "TARGET=%s\n", shellQuote(target))
+	fmt.Fprintln(&script, `if [ -n "$SOURCE" ]; then`)
+	fmt.Fprintln(&script, `    bin/compiler "$SOURCE"`)
+	fmt.Fprintf(&script, "    TARGET=%s\n", shellQuote(filepath.Join(root, "bin/targets", filepath.Base(target))))
+	fmt.Fprintln(&script, "fi")
+
+	if len(buildId) > 0 {
+		fmt.Fprintf(&script, "\nBUILD_ID=%s\n", buildId)
+		fmt.Fprintln(&script, `if command -v readelf > /dev/null && [ "$(readelf -n "$TARGET" | awk '/Build ID/ { print $3 }')" != "$BUILD_ID" ]; then`)
+		fmt.Fprintln(&script, `    echo "warning: $TARGET is not the binary of the session (build-id $BUILD_ID), the replay may diverge" >&2`)
+		fmt.Fprintln(&script, "fi")
+	}
+
+	fmt.Fprintln(&script)
+	for _, variable := range reproducerEnvironment() {
+		fmt.Fprintf(&script, "export %s\n", variable)
+	}
+
+	arguments := orchestratorArguments()
+
+	if configFile := cli.LoadedConfigFile(); len(configFile) > 0 {
+		config, err := os.ReadFile(configFile)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintln(&script, "\n# aliases and user-defined commands of the session")
+		fmt.Fprintln(&script, "CONFIG=$(mktemp)")
+		fmt.Fprintln(&script, `trap 'rm -f "$CONFIG"' EXIT`)
+		fmt.Fprintf(&script, "cat > \"$CONFIG\" <<'CC_REV_DB_CONFIG'\n%sCC_REV_DB_CONFIG\n", ensureTrailingNewline(string(config)))
+
+		arguments = append(arguments, `--config="$CONFIG"`)
+	}
+
+	fmt.Fprintln(&script, "\ncommands() {")
+
+	previous := launchedSession.started
+	for _, line := range cli.ReplayableInput() {
+		delay := line.Time.Sub(previous)
+		if delay > MAX_REPLAY_DELAY {
+			delay = MAX_REPLAY_DELAY
+		}
+		previous = line.Time
+
+		fmt.Fprintf(&script, "    sleep %.1f\n", delay.Seconds())
+		fmt.Fprintf(&script, "    echo %s\n", shellQuote(line.Text))
+	}
+
+	fmt.Fprintln(&script, `    if [ -t 0 ]; then cat; else echo q; fi`)
+	fmt.Fprintln(&script, "}")
+
+	fmt.Fprintf(&script, "\ncommands | %s %s\n", shellQuote(executable), strings.Join(arguments, " "))
+
+	return script.String(), nil
+}
+
+// The arguments the orchestrator was started with, with the target replaced by $TARGET and the seed of the session
+func orchestratorArguments() []string {
+	arguments := make([]string, 0, len(os.Args))
+
+	for _, argument := range os.Args[1:] {
+		switch {
+		case strings.HasPrefix(argument, "--seed="), strings.HasPrefix(argument, "--config="):
+			continue
+		case argument == launchedSession.targetPath:
+			arguments = append(arguments, `"$TARGET"`)
+		default:
+			arguments = append(arguments, shellQuote(argument))
+		}
+	}
+
+	return append(arguments, fmt.Sprintf("--seed=%d", launchedSession.options.Seed))
+}
+
+// The variables of the environment affecting the MPI job, as NAME='value'
+func reproducerEnvironment() []string {
+	variables := make([]string, 0)
+
+	for _, variable := range os.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+
+		reproduced := false
+		for _, prefix := range reproducedEnvironmentPrefixes {
+			reproduced = reproduced || strings.HasPrefix(name, prefix)
+		}
+		for _, reproducedName := range reproducedEnvironment {
+			reproduced = reproduced || name == reproducedName
+		}
+
+		if reproduced {
+			variables = append(variables, fmt.Sprintf("%s=%s", name, shellQuote(value)))
+		}
+	}
+
+	sort.Strings(variables)
+	return variables
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func ensureTrailingNewline(text string) string {
+	if len(text) > 0 && !strings.HasSuffix(text, "\n") {
+		return text + "\n"
+	}
+	return text
+}
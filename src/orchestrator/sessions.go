@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"github.com/ottmartens/cc-rev-db/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// The session service (orchestrator serve) lets a team share one long-lived orchestrator on a cluster login node.
+// Each session runs an orchestrator of its own, headless and with a remote console on a port of the session, so its
+// nodes, checkpoints and commands are kept apart from those of the other sessions. The session directory holds its
+// console log, the checkpoint files of its nodes and the files it writes (exported sessions, reports)
+
+// sessions are given the ports from the default port on in these steps, their nodes the ports following it
+const SESSION_PORT_STEP = 100
+
+const MAX_SESSIONS = 50
+
+// how long a stopped session is given to quit before it is killed
+const SESSION_STOP_TIMEOUT = 10 * time.Second
+
+type serviceSession struct {
+	rpc.SessionInfo
+	process *exec.Cmd
+}
+
+var sessionService = struct {
+	sync.Mutex
+	dir      string // directory of the session directories
+	sessions map[int]*serviceSession
+}{sessions: make(map[int]*serviceSession)}
+
+type SessionService struct {
+	pb.UnimplementedSessionServiceServer
+}
+
+// Starts an orchestrator for the session in a directory of its own
+func (s SessionService) Start(ctx context.Context, message *pb.SessionRequest) (*pb.SessionInfo, error) {
+	request := rpc.SessionRequestOf(message)
+
+	sessionService.Lock()
+	defer sessionService.Unlock()
+
+	id := 1
+	for session := sessionService.sessions[id]; session != nil && !session.Exited; session = sessionService.sessions[id] {
+		id++
+	}
+	if id > MAX_SESSIONS {
+		return nil, fmt.Errorf("%d sessions running, stop one first", MAX_SESSIONS)
+	}
+
+	session := &serviceSession{SessionInfo: rpc.SessionInfo{
+		Id:      id,
+		Owner:   request.Owner,
+		Args:    request.Args,
+		Port:    rpc.DEFAULT_ORCHESTRATOR_PORT + id*SESSION_PORT_STEP,
+		Started: time.Now(),
+	}}
+	session.Dir = filepath.Join(sessionService.dir, fmt.Sprintf("%d-%v-%v", id, request.Owner, session.Started.Format("20060102-150405")))
+
+	err := os.MkdirAll(filepath.Join(session.Dir, "checkpoints"), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	consoleLog, err := os.Create(filepath.Join(session.Dir, "console.log"))
+	if err != nil {
+		return nil, err
+	}
+
+	executable, err := os.Executable()
+	utils.Must(err)
+
+	args := append(append([]string{}, request.Args...),
+		"--remote-console",
+		"--headless",
+		fmt.Sprintf("--port=%d", session.Port),
+		fmt.Sprintf("--storage-dir=%s", filepath.Join(session.Dir, "checkpoints")),
+	)
+
+	session.process = exec.Command(executable, args...)
+	session.process.Dir = session.Dir
+	session.process.Stdout = consoleLog
+	session.process.Stderr = consoleLog
+	// the session is stopped with its MPI job
+	session.process.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	err = session.process.Start()
+	if err != nil {
+		consoleLog.Close()
+		return nil, err
+	}
+
+	sessionService.sessions[id] = session
+	logger.Info("Session %d of %v started on port %d: %v", id, request.Owner, session.Port, strings.Join(request.Args, " "))
+
+	go func() {
+		session.process.Wait()
+		consoleLog.Close()
+
+		sessionService.Lock()
+		session.Exited = true
+		sessionService.Unlock()
+
+		logger.Info("Session %d exited", id)
+	}()
+
+	return session.SessionInfo.Proto(), nil
+}
+
+func (s SessionService) List(context.Context, *emptypb.Empty) (*pb.SessionList, error) {
+	sessionService.Lock()
+	defer sessionService.Unlock()
+
+	sessions := make([]rpc.SessionInfo, 0, len(sessionService.sessions))
+	for _, session := range sessionService.sessions {
+		sessions = append(sessions, session.SessionInfo)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Id < sessions[j].Id })
+
+	reply := &pb.SessionList{}
+	for _, session := range sessions {
+		reply.Sessions = append(reply.Sessions, session.Proto())
+	}
+	return reply, nil
+}
+
+// Quits a session through its console, kills it with its MPI job if it does not exit in time
+func (s SessionService) Stop(ctx context.Context, message *pb.SessionId) (*emptypb.Empty, error) {
+	id := int(message.Id)
+
+	sessionService.Lock()
+	session := sessionService.sessions[id]
+	sessionService.Unlock()
+
+	if session == nil {
+		return nil, fmt.Errorf("no session %d", id)
+	}
+	if session.Exited {
+		return nil, fmt.Errorf("session %d has already exited", id)
+	}
+
+	address, _ := rpc.ParseAddress(fmt.Sprintf("localhost:%d", session.Port))
+	client := rpc.Connect(address)
+	client.RemoteConsole().Input(context.Background(), &pb.ConsoleInput{Line: "q"})
+
+	deadline := time.Now().Add(SESSION_STOP_TIMEOUT)
+	for time.Now().Before(deadline) {
+		sessionService.Lock()
+		exited := session.Exited
+		sessionService.Unlock()
+
+		if exited {
+			return &emptypb.Empty{}, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	logger.Warn("Session %d did not quit in %v, killing it", id, SESSION_STOP_TIMEOUT)
+	if err := syscall.Kill(-session.process.Process.Pid, syscall.SIGKILL); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Runs the session service (serve [--sessions-dir=<dir>]) or a command of its clients (session start|list|stop)
+func runSessionService(args []string) {
+	if args[0] == "serve" {
+		serveSessions(args[1:])
+		return
+	}
+
+	address, _ := rpc.ParseAddress(fmt.Sprintf("localhost:%d", rpc.DEFAULT_ORCHESTRATOR_PORT))
+	client := rpc.Connect(address)
+
+	var err error
+
+	switch {
+	case len(args) > 2 && args[1] == "start":
+		err = startSession(client, args[2:])
+	case len(args) == 2 && args[1] == "list":
+		err = listSessions(client)
+	case len(args) == 3 && args[1] == "stop":
+		id, parseErr := strconv.Atoi(args[2])
+		if parseErr != nil {
+			printSessionUsage()
+		}
+		_, err = client.SessionService().Stop(context.Background(), &pb.SessionId{Id: int32(id)})
+	default:
+		printSessionUsage()
+	}
+
+	if err != nil {
+		logger.Error("%v", err)
+		os.Exit(1)
+	}
+}
+
+func serveSessions(args []string) {
+	home, err := os.UserHomeDir()
+	utils.Must(err)
+
+	sessionService.dir = filepath.Join(home, ".cc-rev-db", "sessions")
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--sessions-dir=") {
+			printSessionUsage()
+		}
+		sessionService.dir = strings.TrimPrefix(arg, "--sessions-dir=")
+	}
+
+	utils.Must(os.MkdirAll(sessionService.dir, 0755))
+
+	logger.Info("Session service listening on port %d, sessions in %v", rpc.DEFAULT_ORCHESTRATOR_PORT, sessionService.dir)
+
+	rpc.InitializeServer("localhost", rpc.DEFAULT_ORCHESTRATOR_PORT, func(server grpc.ServiceRegistrar) {
+		pb.RegisterSessionServiceServer(server, SessionService{})
+	})
+}
+
+// Starts a session with the arguments of the orchestrator, the files they name resolved in the current directory
+func startSession(client *rpc.RPCClient, args []string) error {
+	request := rpc.SessionRequest{Args: make([]string, 0, len(args)), Owner: "unknown"}
+
+	if currentUser, err := user.Current(); err == nil {
+		request.Owner = currentUser.Username
+	}
+
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--config="):
+			path, err := filepath.Abs(strings.TrimPrefix(arg, "--config="))
+			utils.Must(err)
+			arg = "--config=" + path
+		case !strings.HasPrefix(arg, "-"):
+			if _, err := os.Stat(arg); err == nil {
+				path, err := filepath.Abs(arg)
+				utils.Must(err)
+				arg = path
+			}
+		}
+
+		request.Args = append(request.Args, arg)
+	}
+
+	reply, err := client.SessionService().Start(context.Background(), request.Proto())
+	if err != nil {
+		return err
+	}
+	session := rpc.SessionInfoOf(reply)
+
+	logger.Info("Session %d started, attach with: remote-console localhost:%d", session.Id, session.Port)
+	logger.Info("console log and checkpoints in %v", session.Dir)
+	return nil
+}
+
+func listSessions(client *rpc.RPCClient) error {
+	reply, err := client.SessionService().List(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+
+	sessions := make([]rpc.SessionInfo, 0, len(reply.Sessions))
+	for _, session := range reply.Sessions {
+		sessions = append(sessions, rpc.SessionInfoOf(session))
+	}
+
+	if len(sessions) == 0 {
+		fmt.Println("no sessions")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "id\towner\tport\tstarted\tstate\targuments")
+
+	for _, session := range sessions {
+		state := "running"
+		if session.Exited {
+			state = "exited"
+		}
+
+		fmt.Fprintf(writer, "%d\t%v\t%d\t%v\t%v\t%v\n", session.Id, session.Owner, session.Port, session.Started.Format("2006-01-02 15:04"), state, strings.Join(session.Args, " "))
+	}
+
+	return writer.Flush()
+}
+
+func printSessionUsage() {
+	logger.Error("usage: orchestrator serve [--sessions-dir=<dir>]")
+	logger.Error("       orchestrator session start <num_processes> <target_file> [options]")
+	logger.Error("       orchestrator session start run [-np <num_processes>] <target_file> [options]")
+	logger.Error("       orchestrator session list")
+	logger.Error("       orchestrator session stop <id>")
+	os.Exit(2)
+}
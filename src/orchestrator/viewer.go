@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/orchestrator/cli"
+	"github.com/ottmartens/cc-rev-db/orchestrator/dashboard"
+	"github.com/ottmartens/cc-rev-db/orchestrator/gui"
+	"github.com/ottmartens/cc-rev-db/orchestrator/gui/websocket"
+	"github.com/ottmartens/cc-rev-db/utils"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Opens an exported session bundle for inspection, without starting any processes. Commands go through the
+// handlers of a live session, node commands are answered with what the nodes printed during the session
+func runViewer(sessionFile string, options cli.LaunchOptions) {
+	err := checkpointmanager.LoadSession(sessionFile)
+	if err != nil {
+		logger.Error("Failed to open session %v: %v", sessionFile, err)
+		os.Exit(1)
+	}
+
+	logger.Info("opened session %v in read-only mode", sessionFile)
+
+	checkpointmanager.PrintFingerprint()
+
+	if options.RemoteConsole || len(options.Dashboard) > 0 {
+		cli.CaptureConsoleOutput()
+	}
+
+	if len(options.Dashboard) > 0 {
+		dashboard.Start(options.Dashboard)
+		dashboard.UpdateCheckpoints(checkpointmanager.GetCheckpointLog())
+	}
+
+	if len(options.API) > 0 {
+		startAPI(options.API)
+	}
+
+	if !utils.IsRunningInContainer() && !options.Headless {
+		gui.Start()
+
+		websocket.InitServer()
+		websocket.WaitForClientConnection()
+		websocket.SendCheckpointUpdateMessage(checkpointmanager.GetCheckpointLog())
+		websocket.SendRecordedEvents()
+	}
+
+	cli.PrintViewerInstructions()
+
+	cli.QueueStartupCommands(options.Commands, options.Batch)
+
+	for {
+		cmd := cli.AskForInput()
+
+		sessionMutex.Lock()
+		handleCommand(cmd)
+		sessionMutex.Unlock()
+	}
+}
+
+// Handles a command of a session opened in the viewer that needs running nodes: node commands are answered from
+// the recorded results, the others refused. Returns false for the commands handled as in a live session
+func handleRecordedCommand(cmd *command.Command) bool {
+	switch cmd.Code {
+	case command.Quit:
+		gui.Stop()
+		logger.Info("👋 exiting")
+		os.Exit(0)
+	case command.Help:
+		cli.PrintViewerInstructions()
+		return true
+	case command.ListCheckpoints, command.InspectMessage, command.HashHistory, command.CheckMessages, command.Status, command.ExportSession:
+		return false
+	}
+
+	if cmd.Code < command.Bpoint || cmd.IsProgressCommand() {
+		logger.Warn("Command %v is not available in read-only mode", cmd)
+		return true
+	}
+
+	nodeIds := cmd.Targets
+	if len(nodeIds) == 0 {
+		nodeIds = []int{cmd.NodeId}
+	}
+
+	for _, nodeId := range nodeIds {
+		results := recordedResults(nodeId, cmd)
+		if len(results) == 0 {
+			logger.Info("node %d: no recorded result of %v", nodeId, cmd)
+			continue
+		}
+
+		for _, result := range results {
+			fmt.Printf("node %d: %v\n", nodeId, result)
+		}
+	}
+
+	return true
+}
+
+// The recorded results of the node matching the command, oldest first. A backtrace is matched by the call stacks
+// the node stopped with, an argument given by the results of the command with the same argument
+func recordedResults(nodeId int, cmd *command.Command) []checkpointmanager.RecordedResult {
+	matching := make([]checkpointmanager.RecordedResult, 0)
+
+	for _, result := range checkpointmanager.GetRecordedResults(nodeId) {
+		if cmd.Code == command.Backtrace {
+			if len(result.Backtrace) > 0 {
+				matching = append(matching, result)
+			}
+			continue
+		}
+
+		if result.Command != cmd.Name() {
+			continue
+		}
+		if cmd.Argument != nil && fmt.Sprint(cmd.Argument) != result.Argument {
+			continue
+		}
+
+		matching = append(matching, result)
+	}
+
+	return matching
+}
+
+// The latest recorded result of the node matching the command as a command result, nil if there is none
+func lastRecordedResult(nodeId int, cmd *command.Command) *command.CommandResult {
+	results := recordedResults(nodeId, cmd)
+	if len(results) == 0 {
+		return nil
+	}
+
+	last := results[len(results)-1]
+
+	return &command.CommandResult{
+		Error:     last.Error,
+		Location:  last.Location,
+		Backtrace: last.Backtrace,
+		Output:    last.Output,
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	checkpointmanager "github.com/ottmartens/cc-rev-db/orchestrator/checkpointmanager"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+)
+
+// Shows the reverse-execution window (window), lifts it (window off) or changes it (window [<n>s] [<m>]: the
+// checkpoints of the last n seconds and/or of the last m events of each node). Checkpoints already outside
+// a narrowed window are pruned right away
+func handleReverseWindow(cmd *command.Command) {
+	argument := cmd.Argument.(string)
+
+	switch argument {
+	case "":
+		logger.Info("Reverse-execution window: %v", checkpointmanager.DescribeReverseWindow())
+		return
+	case "off":
+		checkpointmanager.SetReverseWindow(0, 0)
+		logger.Info("Reverse-execution window lifted, pruned checkpoints stay pruned")
+		return
+	}
+
+	var window time.Duration
+	var events int
+
+	for _, field := range strings.Fields(argument) {
+		if strings.HasSuffix(field, "s") {
+			value, err := strconv.Atoi(strings.TrimSuffix(field, "s"))
+			if err != nil || value < 1 {
+				logger.Warn("Invalid window length: %v", field)
+				return
+			}
+			window = time.Duration(value) * time.Second
+		} else {
+			value, err := strconv.Atoi(field)
+			if err != nil || value < 1 {
+				logger.Warn("Invalid number of events in the window: %v", field)
+				return
+			}
+			events = value
+		}
+	}
+
+	checkpointmanager.SetReverseWindow(window, events)
+	logger.Info("Reverse-execution window: %v", checkpointmanager.DescribeReverseWindow())
+
+	pruneCheckpoints(checkpointmanager.CheckpointsToPrune())
+}
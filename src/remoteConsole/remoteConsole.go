@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+)
+
+const DEFAULT_ORCHESTRATOR_ADDRESS = "localhost:3490"
+
+// Console client of an orchestrator started with --remote-console, for driving a session from another machine
+// (Linux, macOS or Windows). By default the orchestrator only listens on localhost, so it is reached through an
+// ssh tunnel:
+//
+//	ssh -L 3490:localhost:3490 <orchestrator host>
+//
+// An orchestrator listening on the network (--listen) is connected to directly, with the session token it printed
+// given in CC_REV_DB_TOKEN.
+//
+// Typed lines are executed by the orchestrator as if typed at its console, and its output is streamed back.
+// End of input (Ctrl-D, Ctrl-Z on Windows) disconnects, leaving the session running
+func main() {
+	address := DEFAULT_ORCHESTRATOR_ADDRESS
+	if len(os.Args) > 1 {
+		address = os.Args[1]
+	}
+
+	orchestratorAddress, err := rpc.ParseAddress(address)
+	if err != nil {
+		logger.Error("usage: remote-console [<host>:<port>] (default %v)", DEFAULT_ORCHESTRATOR_ADDRESS)
+		os.Exit(2)
+	}
+
+	rpc.SetSessionToken(os.Getenv(rpc.TOKEN_ENV))
+
+	client := rpc.Connect(orchestratorAddress)
+
+	go printConsoleOutput(client)
+
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		_, err = client.RemoteConsole().Input(context.Background(), &pb.ConsoleInput{Line: strings.TrimRight(line, "\r\n")})
+		if err != nil {
+			logger.Error("Lost connection to the orchestrator: %v", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func printConsoleOutput(client *rpc.RPCClient) {
+	stream, err := client.RemoteConsole().Output(context.Background(), &pb.OutputRequest{Offset: 0})
+
+	for err == nil {
+		var output *pb.ConsoleOutput
+
+		output, err = stream.Recv()
+		if err == nil {
+			fmt.Print(output.Text)
+		}
+	}
+
+	logger.Error("Lost connection to the orchestrator: %v", err)
+	os.Exit(1)
+}
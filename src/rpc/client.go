@@ -1,47 +1,236 @@
 package rpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"net/rpc"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// deadline of a call, unless the client is given another one. A server that does not answer by then
+// fails the call, instead of blocking the caller
+const DEFAULT_CALL_TIMEOUT = 30 * time.Second
+
+// delays between the attempts to reconnect a dropped connection, doubling from the first up to the last
+const FIRST_RECONNECT_DELAY = 250 * time.Millisecond
+const MAX_RECONNECT_DELAY = 8 * time.Second
+
+// A connection to the grpc server of the orchestrator or a node. The clients of its services are made on it,
+// e.g. client.NodeReporter(), and their calls go through it: it adds the session token, the deadline of the
+// client and reconnects when the connection drops
 type RPCClient struct {
-	connection *rpc.Client
+	connection *grpc.ClientConn
 	address    *url.URL
+
+	callTimeout time.Duration // deadline of the unary calls (0 - none)
+
+	mutex        sync.Mutex // guards the generation
+	reconnecting sync.Mutex // held while reconnecting, the calls failing meanwhile wait for the new connection
+	generation   int        // connections the client has been reconnected over, the handshake is made once on each
+
+	reconnectTimeout time.Duration                                   // time to wait for a dropped connection for (0 - calls fail at once)
+	handshake        func(connection grpc.ClientConnInterface) error // made on a new connection before the calls waiting for it are retried
 }
 
 func Connect(serverAddress *url.URL) *RPCClient {
 	logger.Debug("connecting to rpc server at %v", serverAddress)
 
-	connection, err := rpc.DialHTTP("tcp", serverAddress.String())
+	connection, err := grpc.NewClient(serverAddress.String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MAX_MESSAGE_SIZE), grpc.MaxCallSendMsgSize(MAX_MESSAGE_SIZE)),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: backoff.Config{
+				BaseDelay:  FIRST_RECONNECT_DELAY,
+				Multiplier: 2,
+				MaxDelay:   MAX_RECONNECT_DELAY,
+			},
+		}),
+	)
 	if err != nil {
 		logger.Error("Failed to connect to rpc server at %v", serverAddress)
 		panic(err)
 	}
-	logger.Debug("connected")
 
 	client := RPCClient{
-		connection: connection,
-		address:    serverAddress,
+		connection:  connection,
+		address:     serverAddress,
+		callTimeout: DEFAULT_CALL_TIMEOUT,
 	}
 
+	// the connection is made by the first call
+	client.checkVersion()
+
+	logger.Debug("connected")
+
 	return &client
 }
 
-func (r *RPCClient) Call(methodName string, args any, reply any) error {
-	if r.connection == nil {
-		return errors.New("Not connected to rpc server")
+// Fails if the server cannot be reached or refuses the session token, and warns if it speaks another version of
+// the protocol, e.g. nodes started from another build of the debugger than the orchestrator
+func (r *RPCClient) checkVersion() {
+	version, err := r.Health().Version(context.Background(), &emptypb.Empty{})
+
+	switch {
+	case status.Code(err) == codes.Unauthenticated:
+		logger.Error("The server at %v was not given the session token of this client, or expects one in %v", r.address, TOKEN_ENV)
+		panic(err)
+	case err != nil:
+		logger.Error("Failed to connect to rpc server at %v", r.address)
+		panic(err)
+	case version.Version != PROTOCOL_VERSION:
+		logger.Warn("rpc server at %v speaks protocol version %d, this client version %d; calls may fail", r.address, version.Version, PROTOCOL_VERSION)
 	}
+}
+
+// Makes the client wait for the connection to come back when it drops, for up to the timeout. The handshake is
+// made on the new connection before the calls that failed are retried on it. Its calls are not retried, and must
+// not be logged remotely
+func (r *RPCClient) ReconnectOnFailure(timeout time.Duration, handshake func(connection grpc.ClientConnInterface) error) {
+	r.reconnectTimeout = timeout
+	r.handshake = handshake
+}
 
-	return r.connection.Call(methodName, args, reply)
+// Sets the deadline of the unary calls, 0 for none
+func (r *RPCClient) SetCallTimeout(timeout time.Duration) {
+	r.callTimeout = timeout
+}
+
+// Makes a unary call, failing once the context is done or the deadline of the client passes without a reply.
+// The deadline holds for each attempt, a dropped connection is reconnected and the call made again
+func (r *RPCClient) Invoke(ctx context.Context, method string, args any, reply any, options ...grpc.CallOption) error {
+	generation := r.currentGeneration()
+
+	err := r.invoke(ctx, method, args, reply, options...)
+	if err == nil || r.reconnectTimeout == 0 || !IsConnectionLost(err) {
+		return err
+	}
+
+	reconnectErr := r.reconnect(generation)
+	if reconnectErr != nil {
+		return fmt.Errorf("%v (%v)", err, reconnectErr)
+	}
+
+	return r.invoke(ctx, method, args, reply, options...)
+}
+
+// Opens a stream, which lasts until the context is done without the deadline of the client
+func (r *RPCClient) NewStream(ctx context.Context, description *grpc.StreamDesc, method string, options ...grpc.CallOption) (grpc.ClientStream, error) {
+	return r.connection.NewStream(withToken(ctx), description, method, options...)
+}
+
+// Makes the call once, with the token and the deadline of the client. Errors returned by the method called are
+// passed on as they are, without the grpc status
+func (r *RPCClient) invoke(ctx context.Context, method string, args any, reply any, options ...grpc.CallOption) error {
+	if r.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.callTimeout)
+		defer cancel()
+	}
+
+	err := r.connection.Invoke(withToken(ctx), method, args, reply, options...)
+
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.DeadlineExceeded:
+		return fmt.Errorf("%v: no reply from %v: %w", method, r.address, context.DeadlineExceeded)
+	case codes.Unknown:
+		return errors.New(status.Convert(err).Message())
+	default:
+		return err
+	}
+}
+
+// Returns whether a call failed for the server not answering in time
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func (r *RPCClient) currentGeneration() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.generation
+}
+
+// Waits for the connection to come back and makes the handshake on it, unless another call already did since the
+// connection of the generation failed
+func (r *RPCClient) reconnect(failed int) error {
+	r.reconnecting.Lock()
+	defer r.reconnecting.Unlock()
+
+	if r.currentGeneration() != failed {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.reconnectTimeout)
+	defer cancel()
+
+	for {
+		// not logged, the log of a node goes through the connection being replaced
+		err := r.awaitConnection(ctx)
+		if err == nil && r.handshake != nil {
+			err = r.handshake(handshakeConnection{r})
+		}
+
+		if err == nil {
+			r.mutex.Lock()
+			r.generation++
+			r.mutex.Unlock()
+			return nil
+		}
+
+		// the server refused the handshake, or the timeout passed
+		if !IsConnectionLost(err) {
+			return err
+		}
+	}
+}
+
+// Waits for the connection to be ready, redialing with the backoff of the client
+func (r *RPCClient) awaitConnection(ctx context.Context) error {
+	for state := r.connection.GetState(); state != connectivity.Ready; state = r.connection.GetState() {
+		r.connection.Connect()
+
+		if !r.connection.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("no connection to %v within %v", r.address, r.reconnectTimeout)
+		}
+	}
+	return nil
+}
+
+// The calls of the handshake, made once each without waiting for the connection to come back
+type handshakeConnection struct {
+	client *RPCClient
+}
+
+func (c handshakeConnection) Invoke(ctx context.Context, method string, args any, reply any, options ...grpc.CallOption) error {
+	return c.client.invoke(ctx, method, args, reply, options...)
+}
+
+func (c handshakeConnection) NewStream(ctx context.Context, description *grpc.StreamDesc, method string, options ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.client.NewStream(ctx, description, method, options...)
+}
+
+// Returns whether a call failed because the connection to the server was lost, rather than in the method called
+func IsConnectionLost(err error) bool {
+	return status.Code(err) == codes.Unavailable
 }
 
 func (r *RPCClient) Heartbeat() {
-	err := r.Call("Health.Heartbeat", new(int), new(int))
+	_, err := r.Health().Heartbeat(context.Background(), &emptypb.Empty{})
 
 	if err != nil {
 		panic(fmt.Sprintf("Heartbeat error: %v", err))
@@ -49,3 +238,37 @@ func (r *RPCClient) Heartbeat() {
 
 	logger.Debug("Heartbeat ok (server %v)", r.address)
 }
+
+// Clients of the services of the server, making their calls through the client
+
+func (r *RPCClient) Health() pb.HealthClient {
+	return pb.NewHealthClient(r)
+}
+
+func (r *RPCClient) Logger() pb.LoggerClient {
+	return pb.NewLoggerClient(r)
+}
+
+func (r *RPCClient) NodeReporter() pb.NodeReporterClient {
+	return pb.NewNodeReporterClient(r)
+}
+
+func (r *RPCClient) Session() pb.SessionClient {
+	return pb.NewSessionClient(r)
+}
+
+func (r *RPCClient) RemoteConsole() pb.RemoteConsoleClient {
+	return pb.NewRemoteConsoleClient(r)
+}
+
+func (r *RPCClient) CommandHandler() pb.CommandHandlerClient {
+	return pb.NewCommandHandlerClient(r)
+}
+
+func (r *RPCClient) SourceServer() pb.SourceServerClient {
+	return pb.NewSourceServerClient(r)
+}
+
+func (r *RPCClient) SessionService() pb.SessionServiceClient {
+	return pb.NewSessionServiceClient(r)
+}
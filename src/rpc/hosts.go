@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"net"
+	"net/url"
+	"os"
+)
+
+// Parses an address given as host:port. Unlike url.ParseRequestURI, it accepts ip addresses as the host
+func ParseAddress(address string) (*url.URL, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, err
+	}
+
+	return &url.URL{Opaque: address}, nil
+}
+
+// Returns the host in an address given as host:port
+func HostOf(address *url.URL) string {
+	host, _, err := net.SplitHostPort(address.String())
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+func IsLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Whether listening on the host accepts connections on every interface
+func IsWildcard(host string) bool {
+	if len(host) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
+
+// Returns the address of the interface this host reaches an address through, for the other side to connect back to.
+// Loopback addresses are reached on localhost
+func LocalHostTowards(address *url.URL) string {
+	host := HostOf(address)
+
+	if IsLoopback(host) {
+		return "localhost"
+	}
+
+	// no packets are sent, dialing udp only picks the route
+	connection, err := net.Dial("udp", address.String())
+	if err != nil {
+		hostname, _ := os.Hostname()
+		return hostname
+	}
+	defer connection.Close()
+
+	return connection.LocalAddr().(*net.UDPAddr).IP.String()
+}
@@ -0,0 +1,2696 @@
+// The control plane of the debugger: the services the orchestrator and the node debuggers call each other by, and the
+// remote console and the session service. Served over grpc, so front-ends in other languages generate their clients
+// from this file (e.g. grpc_tools.protoc for Python).
+//
+// Fields are only ever added, under new numbers. An incompatible change goes into a new package (ccrevdb.v2) and
+// raises the version returned by Health.Version. Calls carry the session token in the x-session-token metadata,
+// if the orchestrator has one.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.27.1
+// source: control.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ProtocolVersion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProtocolVersion) Reset() {
+	*x = ProtocolVersion{}
+	mi := &file_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProtocolVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProtocolVersion) ProtoMessage() {}
+
+func (x *ProtocolVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProtocolVersion.ProtoReflect.Descriptor instead.
+func (*ProtocolVersion) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ProtocolVersion) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type NodeId struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeId) Reset() {
+	*x = NodeId{}
+	mi := &file_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeId) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeId) ProtoMessage() {}
+
+func (x *NodeId) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeId.ProtoReflect.Descriptor instead.
+func (*NodeId) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *NodeId) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type LogEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        int32                  `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Level         int32                  `protobuf:"varint,2,opt,name=level,proto3" json:"level,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LogEntry) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *LogEntry) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *LogEntry) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// A command of the console, executed by a node
+type Command struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	NodeId int32                  `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Code   int32                  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	// Number for the line of a breakpoint and the counts and ids of stepi, rsi and goroutine, text for the others:
+	// locations, expressions, checkpoint ids
+	//
+	// Types that are valid to be assigned to Argument:
+	//
+	//	*Command_Number
+	//	*Command_Text
+	Argument      isCommand_Argument `protobuf_oneof:"argument"`
+	Result        *CommandResult     `protobuf:"bytes,5,opt,name=result,proto3" json:"result,omitempty"`
+	Targets       []int32            `protobuf:"varint,6,rep,packed,name=targets,proto3" json:"targets,omitempty"` // nodes a command prefixed with a target selector runs on at once
+	Json          bool               `protobuf:"varint,7,opt,name=json,proto3" json:"json,omitempty"`              // the result is printed as json
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Command) Reset() {
+	*x = Command{}
+	mi := &file_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Command) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Command) ProtoMessage() {}
+
+func (x *Command) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Command.ProtoReflect.Descriptor instead.
+func (*Command) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Command) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *Command) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Command) GetArgument() isCommand_Argument {
+	if x != nil {
+		return x.Argument
+	}
+	return nil
+}
+
+func (x *Command) GetNumber() int64 {
+	if x != nil {
+		if x, ok := x.Argument.(*Command_Number); ok {
+			return x.Number
+		}
+	}
+	return 0
+}
+
+func (x *Command) GetText() string {
+	if x != nil {
+		if x, ok := x.Argument.(*Command_Text); ok {
+			return x.Text
+		}
+	}
+	return ""
+}
+
+func (x *Command) GetResult() *CommandResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *Command) GetTargets() []int32 {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+func (x *Command) GetJson() bool {
+	if x != nil {
+		return x.Json
+	}
+	return false
+}
+
+type isCommand_Argument interface {
+	isCommand_Argument()
+}
+
+type Command_Number struct {
+	Number int64 `protobuf:"varint,3,opt,name=number,proto3,oneof"`
+}
+
+type Command_Text struct {
+	Text string `protobuf:"bytes,4,opt,name=text,proto3,oneof"`
+}
+
+func (*Command_Number) isCommand_Argument() {}
+
+func (*Command_Text) isCommand_Argument() {}
+
+type CommandResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	ErrorKind     string                 `protobuf:"bytes,2,opt,name=error_kind,json=errorKind,proto3" json:"error_kind,omitempty"`
+	Exited        bool                   `protobuf:"varint,3,opt,name=exited,proto3" json:"exited,omitempty"`
+	Breakpoint    bool                   `protobuf:"varint,4,opt,name=breakpoint,proto3" json:"breakpoint,omitempty"`   // the command stopped at a user breakpoint
+	Interrupted   bool                   `protobuf:"varint,5,opt,name=interrupted,proto3" json:"interrupted,omitempty"` // the command was interrupted by the orchestrator, as another node stopped (all-stop)
+	Location      string                 `protobuf:"bytes,6,opt,name=location,proto3" json:"location,omitempty"`        // source location the target stopped at after a progress command (file:line)
+	Backtrace     string                 `protobuf:"bytes,7,opt,name=backtrace,proto3" json:"backtrace,omitempty"`
+	Output        string                 `protobuf:"bytes,8,opt,name=output,proto3" json:"output,omitempty"` // what a query command printed
+	Values        []*Value               `protobuf:"bytes,9,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandResult) Reset() {
+	*x = CommandResult{}
+	mi := &file_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResult) ProtoMessage() {}
+
+func (x *CommandResult) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResult.ProtoReflect.Descriptor instead.
+func (*CommandResult) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CommandResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *CommandResult) GetErrorKind() string {
+	if x != nil {
+		return x.ErrorKind
+	}
+	return ""
+}
+
+func (x *CommandResult) GetExited() bool {
+	if x != nil {
+		return x.Exited
+	}
+	return false
+}
+
+func (x *CommandResult) GetBreakpoint() bool {
+	if x != nil {
+		return x.Breakpoint
+	}
+	return false
+}
+
+func (x *CommandResult) GetInterrupted() bool {
+	if x != nil {
+		return x.Interrupted
+	}
+	return false
+}
+
+func (x *CommandResult) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *CommandResult) GetBacktrace() string {
+	if x != nil {
+		return x.Backtrace
+	}
+	return ""
+}
+
+func (x *CommandResult) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *CommandResult) GetValues() []*Value {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// A variable, or a field, element or pointed-to value of one, expanded by a vars command
+type Value struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Summary       string                 `protobuf:"bytes,3,opt,name=summary,proto3" json:"summary,omitempty"`
+	Handle        int64                  `protobuf:"varint,4,opt,name=handle,proto3" json:"handle,omitempty"` // handle the children of the value are requested by (0 if it has none)
+	Children      int64                  `protobuf:"varint,5,opt,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Value) Reset() {
+	*x = Value{}
+	mi := &file_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Value) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Value) ProtoMessage() {}
+
+func (x *Value) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Value.ProtoReflect.Descriptor instead.
+func (*Value) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Value) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Value) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Value) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *Value) GetHandle() int64 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+func (x *Value) GetChildren() int64 {
+	if x != nil {
+		return x.Children
+	}
+	return 0
+}
+
+type MPICallRecord struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OpName           string                 `protobuf:"bytes,2,opt,name=op_name,json=opName,proto3" json:"op_name,omitempty"`
+	Parameters       map[string]string      `protobuf:"bytes,3,rep,name=parameters,proto3" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	NodeId           int32                  `protobuf:"varint,4,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	InstructionCount uint64                 `protobuf:"varint,5,opt,name=instruction_count,json=instructionCount,proto3" json:"instruction_count,omitempty"`
+	Payload          []byte                 `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"` // contents of the message buffer of send operations, up to the payload cap
+	PayloadSize      int64                  `protobuf:"varint,7,opt,name=payload_size,json=payloadSize,proto3" json:"payload_size,omitempty"`
+	PayloadRestHash  uint64                 `protobuf:"varint,8,opt,name=payload_rest_hash,json=payloadRestHash,proto3" json:"payload_rest_hash,omitempty"` // FNV-1a hash of the bytes of the message beyond the payload (0 if not truncated)
+	Location         string                 `protobuf:"bytes,9,opt,name=location,proto3" json:"location,omitempty"`
+	Time             *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=time,proto3" json:"time,omitempty"`
+	CheckpointBytes  uint64                 `protobuf:"varint,11,opt,name=checkpoint_bytes,json=checkpointBytes,proto3" json:"checkpoint_bytes,omitempty"`
+	BufferHashes     map[string]uint64      `protobuf:"bytes,12,rep,name=buffer_hashes,json=bufferHashes,proto3" json:"buffer_hashes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	VectorClock      []int32                `protobuf:"varint,13,rep,packed,name=vector_clock,json=vectorClock,proto3" json:"vector_clock,omitempty"` // by rank, empty if the wrapper keeps none
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MPICallRecord) Reset() {
+	*x = MPICallRecord{}
+	mi := &file_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MPICallRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MPICallRecord) ProtoMessage() {}
+
+func (x *MPICallRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MPICallRecord.ProtoReflect.Descriptor instead.
+func (*MPICallRecord) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MPICallRecord) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *MPICallRecord) GetOpName() string {
+	if x != nil {
+		return x.OpName
+	}
+	return ""
+}
+
+func (x *MPICallRecord) GetParameters() map[string]string {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *MPICallRecord) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *MPICallRecord) GetInstructionCount() uint64 {
+	if x != nil {
+		return x.InstructionCount
+	}
+	return 0
+}
+
+func (x *MPICallRecord) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *MPICallRecord) GetPayloadSize() int64 {
+	if x != nil {
+		return x.PayloadSize
+	}
+	return 0
+}
+
+func (x *MPICallRecord) GetPayloadRestHash() uint64 {
+	if x != nil {
+		return x.PayloadRestHash
+	}
+	return 0
+}
+
+func (x *MPICallRecord) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *MPICallRecord) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *MPICallRecord) GetCheckpointBytes() uint64 {
+	if x != nil {
+		return x.CheckpointBytes
+	}
+	return 0
+}
+
+func (x *MPICallRecord) GetBufferHashes() map[string]uint64 {
+	if x != nil {
+		return x.BufferHashes
+	}
+	return nil
+}
+
+func (x *MPICallRecord) GetVectorClock() []int32 {
+	if x != nil {
+		return x.VectorClock
+	}
+	return nil
+}
+
+type NodeRegistration struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pid           int32                  `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	Rank          int32                  `protobuf:"varint,2,opt,name=rank,proto3" json:"rank,omitempty"` // -1 if unknown
+	Host          string                 `protobuf:"bytes,3,opt,name=host,proto3" json:"host,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeRegistration) Reset() {
+	*x = NodeRegistration{}
+	mi := &file_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeRegistration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeRegistration) ProtoMessage() {}
+
+func (x *NodeRegistration) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeRegistration.ProtoReflect.Descriptor instead.
+func (*NodeRegistration) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *NodeRegistration) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *NodeRegistration) GetRank() int32 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+func (x *NodeRegistration) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+type NodeResync struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Registration  *NodeRegistration      `protobuf:"bytes,1,opt,name=registration,proto3" json:"registration,omitempty"`
+	RegisteredId  int32                  `protobuf:"varint,2,opt,name=registered_id,json=registeredId,proto3" json:"registered_id,omitempty"`
+	NodeId        int32                  `protobuf:"varint,3,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Checkpoints   []string               `protobuf:"bytes,4,rep,name=checkpoints,proto3" json:"checkpoints,omitempty"`
+	Breakpoints   []string               `protobuf:"bytes,5,rep,name=breakpoints,proto3" json:"breakpoints,omitempty"`
+	Running       bool                   `protobuf:"varint,6,opt,name=running,proto3" json:"running,omitempty"`
+	Detached      bool                   `protobuf:"varint,7,opt,name=detached,proto3" json:"detached,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeResync) Reset() {
+	*x = NodeResync{}
+	mi := &file_control_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeResync) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeResync) ProtoMessage() {}
+
+func (x *NodeResync) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeResync.ProtoReflect.Descriptor instead.
+func (*NodeResync) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *NodeResync) GetRegistration() *NodeRegistration {
+	if x != nil {
+		return x.Registration
+	}
+	return nil
+}
+
+func (x *NodeResync) GetRegisteredId() int32 {
+	if x != nil {
+		return x.RegisteredId
+	}
+	return 0
+}
+
+func (x *NodeResync) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *NodeResync) GetCheckpoints() []string {
+	if x != nil {
+		return x.Checkpoints
+	}
+	return nil
+}
+
+func (x *NodeResync) GetBreakpoints() []string {
+	if x != nil {
+		return x.Breakpoints
+	}
+	return nil
+}
+
+func (x *NodeResync) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *NodeResync) GetDetached() bool {
+	if x != nil {
+		return x.Detached
+	}
+	return false
+}
+
+type ResyncReply struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	MissingCheckpoints []string               `protobuf:"bytes,1,rep,name=missing_checkpoints,json=missingCheckpoints,proto3" json:"missing_checkpoints,omitempty"`
+	Rejoined           bool                   `protobuf:"varint,2,opt,name=rejoined,proto3" json:"rejoined,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ResyncReply) Reset() {
+	*x = ResyncReply{}
+	mi := &file_control_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResyncReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResyncReply) ProtoMessage() {}
+
+func (x *ResyncReply) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResyncReply.ProtoReflect.Descriptor instead.
+func (*ResyncReply) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ResyncReply) GetMissingCheckpoints() []string {
+	if x != nil {
+		return x.MissingCheckpoints
+	}
+	return nil
+}
+
+func (x *ResyncReply) GetRejoined() bool {
+	if x != nil {
+		return x.Rejoined
+	}
+	return false
+}
+
+type LinePass struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        int32                  `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Location      string                 `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	Clock         int32                  `protobuf:"varint,3,opt,name=clock,proto3" json:"clock,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinePass) Reset() {
+	*x = LinePass{}
+	mi := &file_control_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinePass) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinePass) ProtoMessage() {}
+
+func (x *LinePass) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinePass.ProtoReflect.Descriptor instead.
+func (*LinePass) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *LinePass) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *LinePass) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *LinePass) GetClock() int32 {
+	if x != nil {
+		return x.Clock
+	}
+	return 0
+}
+
+// A message received by a node watched by causal breakpoints
+type ReceivedMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        int32                  `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Source        int32                  `protobuf:"varint,2,opt,name=source,proto3" json:"source,omitempty"`
+	SenderClock   int32                  `protobuf:"varint,3,opt,name=sender_clock,json=senderClock,proto3" json:"sender_clock,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReceivedMessage) Reset() {
+	*x = ReceivedMessage{}
+	mi := &file_control_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceivedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceivedMessage) ProtoMessage() {}
+
+func (x *ReceivedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceivedMessage.ProtoReflect.Descriptor instead.
+func (*ReceivedMessage) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ReceivedMessage) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *ReceivedMessage) GetSource() int32 {
+	if x != nil {
+		return x.Source
+	}
+	return 0
+}
+
+func (x *ReceivedMessage) GetSenderClock() int32 {
+	if x != nil {
+		return x.SenderClock
+	}
+	return 0
+}
+
+type CausalBreakpoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Breakpoint    string                 `protobuf:"bytes,1,opt,name=breakpoint,proto3" json:"breakpoint,omitempty"` // empty if the message completes none
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CausalBreakpoint) Reset() {
+	*x = CausalBreakpoint{}
+	mi := &file_control_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CausalBreakpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CausalBreakpoint) ProtoMessage() {}
+
+func (x *CausalBreakpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CausalBreakpoint.ProtoReflect.Descriptor instead.
+func (*CausalBreakpoint) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CausalBreakpoint) GetBreakpoint() string {
+	if x != nil {
+		return x.Breakpoint
+	}
+	return ""
+}
+
+type MemoryLayoutRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        int32                  `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	AslrDisabled  bool                   `protobuf:"varint,2,opt,name=aslr_disabled,json=aslrDisabled,proto3" json:"aslr_disabled,omitempty"`
+	Regions       []*MemoryRegion        `protobuf:"bytes,3,rep,name=regions,proto3" json:"regions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MemoryLayoutRecord) Reset() {
+	*x = MemoryLayoutRecord{}
+	mi := &file_control_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemoryLayoutRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemoryLayoutRecord) ProtoMessage() {}
+
+func (x *MemoryLayoutRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemoryLayoutRecord.ProtoReflect.Descriptor instead.
+func (*MemoryLayoutRecord) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *MemoryLayoutRecord) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *MemoryLayoutRecord) GetAslrDisabled() bool {
+	if x != nil {
+		return x.AslrDisabled
+	}
+	return false
+}
+
+func (x *MemoryLayoutRecord) GetRegions() []*MemoryRegion {
+	if x != nil {
+		return x.Regions
+	}
+	return nil
+}
+
+type MemoryRegion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         uint64                 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           uint64                 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	Ident         string                 `protobuf:"bytes,3,opt,name=ident,proto3" json:"ident,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MemoryRegion) Reset() {
+	*x = MemoryRegion{}
+	mi := &file_control_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemoryRegion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemoryRegion) ProtoMessage() {}
+
+func (x *MemoryRegion) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemoryRegion.ProtoReflect.Descriptor instead.
+func (*MemoryRegion) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *MemoryRegion) GetStart() uint64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *MemoryRegion) GetEnd() uint64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *MemoryRegion) GetIdent() string {
+	if x != nil {
+		return x.Ident
+	}
+	return ""
+}
+
+type ResourceUsageRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        int32                  `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	CpuTime       *durationpb.Duration   `protobuf:"bytes,3,opt,name=cpu_time,json=cpuTime,proto3" json:"cpu_time,omitempty"`
+	Rss           uint64                 `protobuf:"varint,4,opt,name=rss,proto3" json:"rss,omitempty"`
+	Swap          uint64                 `protobuf:"varint,5,opt,name=swap,proto3" json:"swap,omitempty"`
+	ReadBytes     uint64                 `protobuf:"varint,6,opt,name=read_bytes,json=readBytes,proto3" json:"read_bytes,omitempty"`
+	WriteBytes    uint64                 `protobuf:"varint,7,opt,name=write_bytes,json=writeBytes,proto3" json:"write_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceUsageRecord) Reset() {
+	*x = ResourceUsageRecord{}
+	mi := &file_control_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceUsageRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceUsageRecord) ProtoMessage() {}
+
+func (x *ResourceUsageRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceUsageRecord.ProtoReflect.Descriptor instead.
+func (*ResourceUsageRecord) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ResourceUsageRecord) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *ResourceUsageRecord) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ResourceUsageRecord) GetCpuTime() *durationpb.Duration {
+	if x != nil {
+		return x.CpuTime
+	}
+	return nil
+}
+
+func (x *ResourceUsageRecord) GetRss() uint64 {
+	if x != nil {
+		return x.Rss
+	}
+	return 0
+}
+
+func (x *ResourceUsageRecord) GetSwap() uint64 {
+	if x != nil {
+		return x.Swap
+	}
+	return 0
+}
+
+func (x *ResourceUsageRecord) GetReadBytes() uint64 {
+	if x != nil {
+		return x.ReadBytes
+	}
+	return 0
+}
+
+func (x *ResourceUsageRecord) GetWriteBytes() uint64 {
+	if x != nil {
+		return x.WriteBytes
+	}
+	return 0
+}
+
+type NodeCapabilities struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	NodeId                  int32                  `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	ReverseExecution        bool                   `protobuf:"varint,2,opt,name=reverse_execution,json=reverseExecution,proto3" json:"reverse_execution,omitempty"`
+	ReverseStepInstructions bool                   `protobuf:"varint,3,opt,name=reverse_step_instructions,json=reverseStepInstructions,proto3" json:"reverse_step_instructions,omitempty"`
+	Watchpoints             bool                   `protobuf:"varint,4,opt,name=watchpoints,proto3" json:"watchpoints,omitempty"`
+	MpiInterception         string                 `protobuf:"bytes,5,opt,name=mpi_interception,json=mpiInterception,proto3" json:"mpi_interception,omitempty"` // compiled, preloaded or none
+	MultiThread             bool                   `protobuf:"varint,6,opt,name=multi_thread,json=multiThread,proto3" json:"multi_thread,omitempty"`
+	FunctionBreakpoints     bool                   `protobuf:"varint,7,opt,name=function_breakpoints,json=functionBreakpoints,proto3" json:"function_breakpoints,omitempty"`
+	ConditionalBreakpoints  bool                   `protobuf:"varint,8,opt,name=conditional_breakpoints,json=conditionalBreakpoints,proto3" json:"conditional_breakpoints,omitempty"`
+	Goroutines              bool                   `protobuf:"varint,9,opt,name=goroutines,proto3" json:"goroutines,omitempty"`
+	Language                string                 `protobuf:"bytes,10,opt,name=language,proto3" json:"language,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *NodeCapabilities) Reset() {
+	*x = NodeCapabilities{}
+	mi := &file_control_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeCapabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeCapabilities) ProtoMessage() {}
+
+func (x *NodeCapabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeCapabilities.ProtoReflect.Descriptor instead.
+func (*NodeCapabilities) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *NodeCapabilities) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *NodeCapabilities) GetReverseExecution() bool {
+	if x != nil {
+		return x.ReverseExecution
+	}
+	return false
+}
+
+func (x *NodeCapabilities) GetReverseStepInstructions() bool {
+	if x != nil {
+		return x.ReverseStepInstructions
+	}
+	return false
+}
+
+func (x *NodeCapabilities) GetWatchpoints() bool {
+	if x != nil {
+		return x.Watchpoints
+	}
+	return false
+}
+
+func (x *NodeCapabilities) GetMpiInterception() string {
+	if x != nil {
+		return x.MpiInterception
+	}
+	return ""
+}
+
+func (x *NodeCapabilities) GetMultiThread() bool {
+	if x != nil {
+		return x.MultiThread
+	}
+	return false
+}
+
+func (x *NodeCapabilities) GetFunctionBreakpoints() bool {
+	if x != nil {
+		return x.FunctionBreakpoints
+	}
+	return false
+}
+
+func (x *NodeCapabilities) GetConditionalBreakpoints() bool {
+	if x != nil {
+		return x.ConditionalBreakpoints
+	}
+	return false
+}
+
+func (x *NodeCapabilities) GetGoroutines() bool {
+	if x != nil {
+		return x.Goroutines
+	}
+	return false
+}
+
+func (x *NodeCapabilities) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+type SessionCapabilities struct {
+	state          protoimpl.MessageState      `protogen:"open.v1"`
+	Frontends      []string                    `protobuf:"bytes,1,rep,name=frontends,proto3" json:"frontends,omitempty"`
+	GlobalRollback bool                        `protobuf:"varint,2,opt,name=global_rollback,json=globalRollback,proto3" json:"global_rollback,omitempty"`
+	SessionExport  bool                        `protobuf:"varint,3,opt,name=session_export,json=sessionExport,proto3" json:"session_export,omitempty"`
+	Nodes          map[int32]*NodeCapabilities `protobuf:"bytes,4,rep,name=nodes,proto3" json:"nodes,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SessionCapabilities) Reset() {
+	*x = SessionCapabilities{}
+	mi := &file_control_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionCapabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionCapabilities) ProtoMessage() {}
+
+func (x *SessionCapabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionCapabilities.ProtoReflect.Descriptor instead.
+func (*SessionCapabilities) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SessionCapabilities) GetFrontends() []string {
+	if x != nil {
+		return x.Frontends
+	}
+	return nil
+}
+
+func (x *SessionCapabilities) GetGlobalRollback() bool {
+	if x != nil {
+		return x.GlobalRollback
+	}
+	return false
+}
+
+func (x *SessionCapabilities) GetSessionExport() bool {
+	if x != nil {
+		return x.SessionExport
+	}
+	return false
+}
+
+func (x *SessionCapabilities) GetNodes() map[int32]*NodeCapabilities {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type TargetFingerprint struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	NodeId            int32                  `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Binary            string                 `protobuf:"bytes,2,opt,name=binary,proto3" json:"binary,omitempty"`
+	BuildId           string                 `protobuf:"bytes,3,opt,name=build_id,json=buildId,proto3" json:"build_id,omitempty"`
+	Producer          string                 `protobuf:"bytes,4,opt,name=producer,proto3" json:"producer,omitempty"`
+	MpiLibrary        string                 `protobuf:"bytes,5,opt,name=mpi_library,json=mpiLibrary,proto3" json:"mpi_library,omitempty"`
+	CheckpointBackend string                 `protobuf:"bytes,6,opt,name=checkpoint_backend,json=checkpointBackend,proto3" json:"checkpoint_backend,omitempty"`
+	DebuggerVersion   string                 `protobuf:"bytes,7,opt,name=debugger_version,json=debuggerVersion,proto3" json:"debugger_version,omitempty"`
+	Host              string                 `protobuf:"bytes,8,opt,name=host,proto3" json:"host,omitempty"`
+	StaleSources      []string               `protobuf:"bytes,9,rep,name=stale_sources,json=staleSources,proto3" json:"stale_sources,omitempty"`
+	VerifiedSources   int32                  `protobuf:"varint,10,opt,name=verified_sources,json=verifiedSources,proto3" json:"verified_sources,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *TargetFingerprint) Reset() {
+	*x = TargetFingerprint{}
+	mi := &file_control_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TargetFingerprint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TargetFingerprint) ProtoMessage() {}
+
+func (x *TargetFingerprint) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TargetFingerprint.ProtoReflect.Descriptor instead.
+func (*TargetFingerprint) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TargetFingerprint) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *TargetFingerprint) GetBinary() string {
+	if x != nil {
+		return x.Binary
+	}
+	return ""
+}
+
+func (x *TargetFingerprint) GetBuildId() string {
+	if x != nil {
+		return x.BuildId
+	}
+	return ""
+}
+
+func (x *TargetFingerprint) GetProducer() string {
+	if x != nil {
+		return x.Producer
+	}
+	return ""
+}
+
+func (x *TargetFingerprint) GetMpiLibrary() string {
+	if x != nil {
+		return x.MpiLibrary
+	}
+	return ""
+}
+
+func (x *TargetFingerprint) GetCheckpointBackend() string {
+	if x != nil {
+		return x.CheckpointBackend
+	}
+	return ""
+}
+
+func (x *TargetFingerprint) GetDebuggerVersion() string {
+	if x != nil {
+		return x.DebuggerVersion
+	}
+	return ""
+}
+
+func (x *TargetFingerprint) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *TargetFingerprint) GetStaleSources() []string {
+	if x != nil {
+		return x.StaleSources
+	}
+	return nil
+}
+
+func (x *TargetFingerprint) GetVerifiedSources() int32 {
+	if x != nil {
+		return x.VerifiedSources
+	}
+	return 0
+}
+
+type SourceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourceRequest) Reset() {
+	*x = SourceRequest{}
+	mi := &file_control_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceRequest) ProtoMessage() {}
+
+func (x *SourceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceRequest.ProtoReflect.Descriptor instead.
+func (*SourceRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SourceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type SourceFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Contents      []byte                 `protobuf:"bytes,2,opt,name=contents,proto3" json:"contents,omitempty"`
+	Checksum      string                 `protobuf:"bytes,3,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Mismatch      bool                   `protobuf:"varint,4,opt,name=mismatch,proto3" json:"mismatch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourceFile) Reset() {
+	*x = SourceFile{}
+	mi := &file_control_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourceFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceFile) ProtoMessage() {}
+
+func (x *SourceFile) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceFile.ProtoReflect.Descriptor instead.
+func (*SourceFile) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SourceFile) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SourceFile) GetContents() []byte {
+	if x != nil {
+		return x.Contents
+	}
+	return nil
+}
+
+func (x *SourceFile) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *SourceFile) GetMismatch() bool {
+	if x != nil {
+		return x.Mismatch
+	}
+	return false
+}
+
+type CompletionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"` // location or variable
+	Prefix        string                 `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompletionRequest) Reset() {
+	*x = CompletionRequest{}
+	mi := &file_control_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompletionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompletionRequest) ProtoMessage() {}
+
+func (x *CompletionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompletionRequest.ProtoReflect.Descriptor instead.
+func (*CompletionRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CompletionRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *CompletionRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+type Completions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Candidates    []string               `protobuf:"bytes,1,rep,name=candidates,proto3" json:"candidates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Completions) Reset() {
+	*x = Completions{}
+	mi := &file_control_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Completions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Completions) ProtoMessage() {}
+
+func (x *Completions) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Completions.ProtoReflect.Descriptor instead.
+func (*Completions) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Completions) GetCandidates() []string {
+	if x != nil {
+		return x.Candidates
+	}
+	return nil
+}
+
+type SessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Args          []string               `protobuf:"bytes,1,rep,name=args,proto3" json:"args,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionRequest) Reset() {
+	*x = SessionRequest{}
+	mi := &file_control_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionRequest) ProtoMessage() {}
+
+func (x *SessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionRequest.ProtoReflect.Descriptor instead.
+func (*SessionRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SessionRequest) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *SessionRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+type SessionInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Args          []string               `protobuf:"bytes,3,rep,name=args,proto3" json:"args,omitempty"`
+	Port          int32                  `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
+	Dir           string                 `protobuf:"bytes,5,opt,name=dir,proto3" json:"dir,omitempty"`
+	Started       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=started,proto3" json:"started,omitempty"`
+	Exited        bool                   `protobuf:"varint,7,opt,name=exited,proto3" json:"exited,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionInfo) Reset() {
+	*x = SessionInfo{}
+	mi := &file_control_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInfo) ProtoMessage() {}
+
+func (x *SessionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInfo.ProtoReflect.Descriptor instead.
+func (*SessionInfo) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SessionInfo) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SessionInfo) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *SessionInfo) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *SessionInfo) GetDir() string {
+	if x != nil {
+		return x.Dir
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetStarted() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Started
+	}
+	return nil
+}
+
+func (x *SessionInfo) GetExited() bool {
+	if x != nil {
+		return x.Exited
+	}
+	return false
+}
+
+type SessionList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*SessionInfo         `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionList) Reset() {
+	*x = SessionList{}
+	mi := &file_control_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionList) ProtoMessage() {}
+
+func (x *SessionList) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionList.ProtoReflect.Descriptor instead.
+func (*SessionList) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SessionList) GetSessions() []*SessionInfo {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type SessionId struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionId) Reset() {
+	*x = SessionId{}
+	mi := &file_control_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionId) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionId) ProtoMessage() {}
+
+func (x *SessionId) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionId.ProtoReflect.Descriptor instead.
+func (*SessionId) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *SessionId) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ConsoleInput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          string                 `protobuf:"bytes,1,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsoleInput) Reset() {
+	*x = ConsoleInput{}
+	mi := &file_control_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsoleInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsoleInput) ProtoMessage() {}
+
+func (x *ConsoleInput) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsoleInput.ProtoReflect.Descriptor instead.
+func (*ConsoleInput) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ConsoleInput) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+type OutputRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        int64                  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputRequest) Reset() {
+	*x = OutputRequest{}
+	mi := &file_control_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputRequest) ProtoMessage() {}
+
+func (x *OutputRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputRequest.ProtoReflect.Descriptor instead.
+func (*OutputRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *OutputRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ConsoleOutput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"` // offset of the end of the output
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsoleOutput) Reset() {
+	*x = ConsoleOutput{}
+	mi := &file_control_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsoleOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsoleOutput) ProtoMessage() {}
+
+func (x *ConsoleOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsoleOutput.ProtoReflect.Descriptor instead.
+func (*ConsoleOutput) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ConsoleOutput) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ConsoleOutput) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type InterruptRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InterruptRequest) Reset() {
+	*x = InterruptRequest{}
+	mi := &file_control_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InterruptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InterruptRequest) ProtoMessage() {}
+
+func (x *InterruptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InterruptRequest.ProtoReflect.Descriptor instead.
+func (*InterruptRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *InterruptRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type InterruptReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interrupted   bool                   `protobuf:"varint,1,opt,name=interrupted,proto3" json:"interrupted,omitempty"` // false if the target was not running
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InterruptReply) Reset() {
+	*x = InterruptReply{}
+	mi := &file_control_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InterruptReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InterruptReply) ProtoMessage() {}
+
+func (x *InterruptReply) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InterruptReply.ProtoReflect.Descriptor instead.
+func (*InterruptReply) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *InterruptReply) GetInterrupted() bool {
+	if x != nil {
+		return x.Interrupted
+	}
+	return false
+}
+
+type NodeEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        int32                  `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"` // breakpoint, signal, output, mpi, running, stopped or exited
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Location      string                 `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
+	Detail        string                 `protobuf:"bytes,5,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeEvent) Reset() {
+	*x = NodeEvent{}
+	mi := &file_control_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeEvent) ProtoMessage() {}
+
+func (x *NodeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeEvent.ProtoReflect.Descriptor instead.
+func (*NodeEvent) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *NodeEvent) GetNodeId() int32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *NodeEvent) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *NodeEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *NodeEvent) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *NodeEvent) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+type NodeEvents struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*NodeEvent           `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeEvents) Reset() {
+	*x = NodeEvents{}
+	mi := &file_control_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeEvents) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeEvents) ProtoMessage() {}
+
+func (x *NodeEvents) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeEvents.ProtoReflect.Descriptor instead.
+func (*NodeEvents) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *NodeEvents) GetEvents() []*NodeEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+var File_control_proto protoreflect.FileDescriptor
+
+const file_control_proto_rawDesc = "" +
+	"\n" +
+	"\rcontrol.proto\x12\n" +
+	"ccrevdb.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"+\n" +
+	"\x0fProtocolVersion\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x05R\aversion\"\x18\n" +
+	"\x06NodeId\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"S\n" +
+	"\bLogEntry\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x05R\x06nodeId\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\x05R\x05level\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\xd3\x01\n" +
+	"\aCommand\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x05R\x06nodeId\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x18\n" +
+	"\x06number\x18\x03 \x01(\x03H\x00R\x06number\x12\x14\n" +
+	"\x04text\x18\x04 \x01(\tH\x00R\x04text\x121\n" +
+	"\x06result\x18\x05 \x01(\v2\x19.ccrevdb.v1.CommandResultR\x06result\x12\x18\n" +
+	"\atargets\x18\x06 \x03(\x05R\atargets\x12\x12\n" +
+	"\x04json\x18\a \x01(\bR\x04jsonB\n" +
+	"\n" +
+	"\bargument\"\x9b\x02\n" +
+	"\rCommandResult\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"error_kind\x18\x02 \x01(\tR\terrorKind\x12\x16\n" +
+	"\x06exited\x18\x03 \x01(\bR\x06exited\x12\x1e\n" +
+	"\n" +
+	"breakpoint\x18\x04 \x01(\bR\n" +
+	"breakpoint\x12 \n" +
+	"\vinterrupted\x18\x05 \x01(\bR\vinterrupted\x12\x1a\n" +
+	"\blocation\x18\x06 \x01(\tR\blocation\x12\x1c\n" +
+	"\tbacktrace\x18\a \x01(\tR\tbacktrace\x12\x16\n" +
+	"\x06output\x18\b \x01(\tR\x06output\x12)\n" +
+	"\x06values\x18\t \x03(\v2\x11.ccrevdb.v1.ValueR\x06values\"}\n" +
+	"\x05Value\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\asummary\x18\x03 \x01(\tR\asummary\x12\x16\n" +
+	"\x06handle\x18\x04 \x01(\x03R\x06handle\x12\x1a\n" +
+	"\bchildren\x18\x05 \x01(\x03R\bchildren\"\x9e\x05\n" +
+	"\rMPICallRecord\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\aop_name\x18\x02 \x01(\tR\x06opName\x12I\n" +
+	"\n" +
+	"parameters\x18\x03 \x03(\v2).ccrevdb.v1.MPICallRecord.ParametersEntryR\n" +
+	"parameters\x12\x17\n" +
+	"\anode_id\x18\x04 \x01(\x05R\x06nodeId\x12+\n" +
+	"\x11instruction_count\x18\x05 \x01(\x04R\x10instructionCount\x12\x18\n" +
+	"\apayload\x18\x06 \x01(\fR\apayload\x12!\n" +
+	"\fpayload_size\x18\a \x01(\x03R\vpayloadSize\x12*\n" +
+	"\x11payload_rest_hash\x18\b \x01(\x04R\x0fpayloadRestHash\x12\x1a\n" +
+	"\blocation\x18\t \x01(\tR\blocation\x12.\n" +
+	"\x04time\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12)\n" +
+	"\x10checkpoint_bytes\x18\v \x01(\x04R\x0fcheckpointBytes\x12P\n" +
+	"\rbuffer_hashes\x18\f \x03(\v2+.ccrevdb.v1.MPICallRecord.BufferHashesEntryR\fbufferHashes\x12!\n" +
+	"\fvector_clock\x18\r \x03(\x05R\vvectorClock\x1a=\n" +
+	"\x0fParametersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a?\n" +
+	"\x11BufferHashesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"L\n" +
+	"\x10NodeRegistration\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\x05R\x03pid\x12\x12\n" +
+	"\x04rank\x18\x02 \x01(\x05R\x04rank\x12\x12\n" +
+	"\x04host\x18\x03 \x01(\tR\x04host\"\x86\x02\n" +
+	"\n" +
+	"NodeResync\x12@\n" +
+	"\fregistration\x18\x01 \x01(\v2\x1c.ccrevdb.v1.NodeRegistrationR\fregistration\x12#\n" +
+	"\rregistered_id\x18\x02 \x01(\x05R\fregisteredId\x12\x17\n" +
+	"\anode_id\x18\x03 \x01(\x05R\x06nodeId\x12 \n" +
+	"\vcheckpoints\x18\x04 \x03(\tR\vcheckpoints\x12 \n" +
+	"\vbreakpoints\x18\x05 \x03(\tR\vbreakpoints\x12\x18\n" +
+	"\arunning\x18\x06 \x01(\bR\arunning\x12\x1a\n" +
+	"\bdetached\x18\a \x01(\bR\bdetached\"Z\n" +
+	"\vResyncReply\x12/\n" +
+	"\x13missing_checkpoints\x18\x01 \x03(\tR\x12missingCheckpoints\x12\x1a\n" +
+	"\brejoined\x18\x02 \x01(\bR\brejoined\"U\n" +
+	"\bLinePass\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x05R\x06nodeId\x12\x1a\n" +
+	"\blocation\x18\x02 \x01(\tR\blocation\x12\x14\n" +
+	"\x05clock\x18\x03 \x01(\x05R\x05clock\"e\n" +
+	"\x0fReceivedMessage\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x05R\x06nodeId\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\x05R\x06source\x12!\n" +
+	"\fsender_clock\x18\x03 \x01(\x05R\vsenderClock\"2\n" +
+	"\x10CausalBreakpoint\x12\x1e\n" +
+	"\n" +
+	"breakpoint\x18\x01 \x01(\tR\n" +
+	"breakpoint\"\x86\x01\n" +
+	"\x12MemoryLayoutRecord\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x05R\x06nodeId\x12#\n" +
+	"\raslr_disabled\x18\x02 \x01(\bR\faslrDisabled\x122\n" +
+	"\aregions\x18\x03 \x03(\v2\x18.ccrevdb.v1.MemoryRegionR\aregions\"L\n" +
+	"\fMemoryRegion\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x04R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x04R\x03end\x12\x14\n" +
+	"\x05ident\x18\x03 \x01(\tR\x05ident\"\x84\x02\n" +
+	"\x13ResourceUsageRecord\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x05R\x06nodeId\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x124\n" +
+	"\bcpu_time\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\acpuTime\x12\x10\n" +
+	"\x03rss\x18\x04 \x01(\x04R\x03rss\x12\x12\n" +
+	"\x04swap\x18\x05 \x01(\x04R\x04swap\x12\x1d\n" +
+	"\n" +
+	"read_bytes\x18\x06 \x01(\x04R\treadBytes\x12\x1f\n" +
+	"\vwrite_bytes\x18\a \x01(\x04R\n" +
+	"writeBytes\"\xac\x03\n" +
+	"\x10NodeCapabilities\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x05R\x06nodeId\x12+\n" +
+	"\x11reverse_execution\x18\x02 \x01(\bR\x10reverseExecution\x12:\n" +
+	"\x19reverse_step_instructions\x18\x03 \x01(\bR\x17reverseStepInstructions\x12 \n" +
+	"\vwatchpoints\x18\x04 \x01(\bR\vwatchpoints\x12)\n" +
+	"\x10mpi_interception\x18\x05 \x01(\tR\x0fmpiInterception\x12!\n" +
+	"\fmulti_thread\x18\x06 \x01(\bR\vmultiThread\x121\n" +
+	"\x14function_breakpoints\x18\a \x01(\bR\x13functionBreakpoints\x127\n" +
+	"\x17conditional_breakpoints\x18\b \x01(\bR\x16conditionalBreakpoints\x12\x1e\n" +
+	"\n" +
+	"goroutines\x18\t \x01(\bR\n" +
+	"goroutines\x12\x1a\n" +
+	"\blanguage\x18\n" +
+	" \x01(\tR\blanguage\"\x9d\x02\n" +
+	"\x13SessionCapabilities\x12\x1c\n" +
+	"\tfrontends\x18\x01 \x03(\tR\tfrontends\x12'\n" +
+	"\x0fglobal_rollback\x18\x02 \x01(\bR\x0eglobalRollback\x12%\n" +
+	"\x0esession_export\x18\x03 \x01(\bR\rsessionExport\x12@\n" +
+	"\x05nodes\x18\x04 \x03(\v2*.ccrevdb.v1.SessionCapabilities.NodesEntryR\x05nodes\x1aV\n" +
+	"\n" +
+	"NodesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x05R\x03key\x122\n" +
+	"\x05value\x18\x02 \x01(\v2\x1c.ccrevdb.v1.NodeCapabilitiesR\x05value:\x028\x01\"\xda\x02\n" +
+	"\x11TargetFingerprint\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x05R\x06nodeId\x12\x16\n" +
+	"\x06binary\x18\x02 \x01(\tR\x06binary\x12\x19\n" +
+	"\bbuild_id\x18\x03 \x01(\tR\abuildId\x12\x1a\n" +
+	"\bproducer\x18\x04 \x01(\tR\bproducer\x12\x1f\n" +
+	"\vmpi_library\x18\x05 \x01(\tR\n" +
+	"mpiLibrary\x12-\n" +
+	"\x12checkpoint_backend\x18\x06 \x01(\tR\x11checkpointBackend\x12)\n" +
+	"\x10debugger_version\x18\a \x01(\tR\x0fdebuggerVersion\x12\x12\n" +
+	"\x04host\x18\b \x01(\tR\x04host\x12#\n" +
+	"\rstale_sources\x18\t \x03(\tR\fstaleSources\x12)\n" +
+	"\x10verified_sources\x18\n" +
+	" \x01(\x05R\x0fverifiedSources\"#\n" +
+	"\rSourceRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"t\n" +
+	"\n" +
+	"SourceFile\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1a\n" +
+	"\bcontents\x18\x02 \x01(\fR\bcontents\x12\x1a\n" +
+	"\bchecksum\x18\x03 \x01(\tR\bchecksum\x12\x1a\n" +
+	"\bmismatch\x18\x04 \x01(\bR\bmismatch\"?\n" +
+	"\x11CompletionRequest\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x16\n" +
+	"\x06prefix\x18\x02 \x01(\tR\x06prefix\"-\n" +
+	"\vCompletions\x12\x1e\n" +
+	"\n" +
+	"candidates\x18\x01 \x03(\tR\n" +
+	"candidates\":\n" +
+	"\x0eSessionRequest\x12\x12\n" +
+	"\x04args\x18\x01 \x03(\tR\x04args\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\"\xbb\x01\n" +
+	"\vSessionInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x12\n" +
+	"\x04args\x18\x03 \x03(\tR\x04args\x12\x12\n" +
+	"\x04port\x18\x04 \x01(\x05R\x04port\x12\x10\n" +
+	"\x03dir\x18\x05 \x01(\tR\x03dir\x124\n" +
+	"\astarted\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\astarted\x12\x16\n" +
+	"\x06exited\x18\a \x01(\bR\x06exited\"B\n" +
+	"\vSessionList\x123\n" +
+	"\bsessions\x18\x01 \x03(\v2\x17.ccrevdb.v1.SessionInfoR\bsessions\"\x1b\n" +
+	"\tSessionId\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"\"\n" +
+	"\fConsoleInput\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\tR\x04line\"'\n" +
+	"\rOutputRequest\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x03R\x06offset\";\n" +
+	"\rConsoleOutput\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\"*\n" +
+	"\x10InterruptRequest\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\"2\n" +
+	"\x0eInterruptReply\x12 \n" +
+	"\vinterrupted\x18\x01 \x01(\bR\vinterrupted\"\x9c\x01\n" +
+	"\tNodeEvent\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x05R\x06nodeId\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x1a\n" +
+	"\blocation\x18\x04 \x01(\tR\blocation\x12\x16\n" +
+	"\x06detail\x18\x05 \x01(\tR\x06detail\";\n" +
+	"\n" +
+	"NodeEvents\x12-\n" +
+	"\x06events\x18\x01 \x03(\v2\x15.ccrevdb.v1.NodeEventR\x06events2\x85\x01\n" +
+	"\x06Health\x12;\n" +
+	"\tHeartbeat\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x12>\n" +
+	"\aVersion\x12\x16.google.protobuf.Empty\x1a\x1b.ccrevdb.v1.ProtocolVersion2=\n" +
+	"\x06Logger\x123\n" +
+	"\x03Log\x12\x14.ccrevdb.v1.LogEntry\x1a\x16.google.protobuf.Empty2\x9c\x06\n" +
+	"\fNodeReporter\x12<\n" +
+	"\bRegister\x12\x1c.ccrevdb.v1.NodeRegistration\x1a\x12.ccrevdb.v1.NodeId\x12<\n" +
+	"\rCommandResult\x12\x13.ccrevdb.v1.Command\x1a\x16.google.protobuf.Empty\x127\n" +
+	"\bProgress\x12\x13.ccrevdb.v1.Command\x1a\x16.google.protobuf.Empty\x12<\n" +
+	"\aMPICall\x12\x19.ccrevdb.v1.MPICallRecord\x1a\x16.google.protobuf.Empty\x12F\n" +
+	"\fMemoryLayout\x12\x1e.ccrevdb.v1.MemoryLayoutRecord\x1a\x16.google.protobuf.Empty\x12H\n" +
+	"\rResourceUsage\x12\x1f.ccrevdb.v1.ResourceUsageRecord\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\vFingerprint\x12\x1d.ccrevdb.v1.TargetFingerprint\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\fCapabilities\x12\x1c.ccrevdb.v1.NodeCapabilities\x1a\x16.google.protobuf.Empty\x12:\n" +
+	"\n" +
+	"LinePassed\x12\x14.ccrevdb.v1.LinePass\x1a\x16.google.protobuf.Empty\x12J\n" +
+	"\rCausalReceive\x12\x1b.ccrevdb.v1.ReceivedMessage\x1a\x1c.ccrevdb.v1.CausalBreakpoint\x129\n" +
+	"\x06Resync\x12\x16.ccrevdb.v1.NodeResync\x1a\x17.ccrevdb.v1.ResyncReply\x128\n" +
+	"\x06Events\x12\x16.ccrevdb.v1.NodeEvents\x1a\x16.google.protobuf.Empty2\x92\x01\n" +
+	"\aSession\x12G\n" +
+	"\fCapabilities\x12\x16.google.protobuf.Empty\x1a\x1f.ccrevdb.v1.SessionCapabilities\x12>\n" +
+	"\vWatchEvents\x12\x16.google.protobuf.Empty\x1a\x15.ccrevdb.v1.NodeEvent0\x012\x8c\x01\n" +
+	"\rRemoteConsole\x129\n" +
+	"\x05Input\x12\x18.ccrevdb.v1.ConsoleInput\x1a\x16.google.protobuf.Empty\x12@\n" +
+	"\x06Output\x12\x19.ccrevdb.v1.OutputRequest\x1a\x19.ccrevdb.v1.ConsoleOutput0\x012\xc6\x01\n" +
+	"\x0eCommandHandler\x125\n" +
+	"\x06Handle\x12\x13.ccrevdb.v1.Command\x1a\x16.google.protobuf.Empty\x126\n" +
+	"\bRenumber\x12\x12.ccrevdb.v1.NodeId\x1a\x16.google.protobuf.Empty\x12E\n" +
+	"\tInterrupt\x12\x1c.ccrevdb.v1.InterruptRequest\x1a\x1a.ccrevdb.v1.InterruptReply2\x8e\x01\n" +
+	"\fSourceServer\x12:\n" +
+	"\x05Fetch\x12\x19.ccrevdb.v1.SourceRequest\x1a\x16.ccrevdb.v1.SourceFile\x12B\n" +
+	"\bComplete\x12\x1d.ccrevdb.v1.CompletionRequest\x1a\x17.ccrevdb.v1.Completions2\xbe\x01\n" +
+	"\x0eSessionService\x12<\n" +
+	"\x05Start\x12\x1a.ccrevdb.v1.SessionRequest\x1a\x17.ccrevdb.v1.SessionInfo\x127\n" +
+	"\x04List\x12\x16.google.protobuf.Empty\x1a\x17.ccrevdb.v1.SessionList\x125\n" +
+	"\x04Stop\x12\x15.ccrevdb.v1.SessionId\x1a\x16.google.protobuf.EmptyB(Z&github.com/ottmartens/cc-rev-db/rpc/pbb\x06proto3"
+
+var (
+	file_control_proto_rawDescOnce sync.Once
+	file_control_proto_rawDescData []byte
+)
+
+func file_control_proto_rawDescGZIP() []byte {
+	file_control_proto_rawDescOnce.Do(func() {
+		file_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_control_proto_rawDesc), len(file_control_proto_rawDesc)))
+	})
+	return file_control_proto_rawDescData
+}
+
+var file_control_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
+var file_control_proto_goTypes = []any{
+	(*ProtocolVersion)(nil),       // 0: ccrevdb.v1.ProtocolVersion
+	(*NodeId)(nil),                // 1: ccrevdb.v1.NodeId
+	(*LogEntry)(nil),              // 2: ccrevdb.v1.LogEntry
+	(*Command)(nil),               // 3: ccrevdb.v1.Command
+	(*CommandResult)(nil),         // 4: ccrevdb.v1.CommandResult
+	(*Value)(nil),                 // 5: ccrevdb.v1.Value
+	(*MPICallRecord)(nil),         // 6: ccrevdb.v1.MPICallRecord
+	(*NodeRegistration)(nil),      // 7: ccrevdb.v1.NodeRegistration
+	(*NodeResync)(nil),            // 8: ccrevdb.v1.NodeResync
+	(*ResyncReply)(nil),           // 9: ccrevdb.v1.ResyncReply
+	(*LinePass)(nil),              // 10: ccrevdb.v1.LinePass
+	(*ReceivedMessage)(nil),       // 11: ccrevdb.v1.ReceivedMessage
+	(*CausalBreakpoint)(nil),      // 12: ccrevdb.v1.CausalBreakpoint
+	(*MemoryLayoutRecord)(nil),    // 13: ccrevdb.v1.MemoryLayoutRecord
+	(*MemoryRegion)(nil),          // 14: ccrevdb.v1.MemoryRegion
+	(*ResourceUsageRecord)(nil),   // 15: ccrevdb.v1.ResourceUsageRecord
+	(*NodeCapabilities)(nil),      // 16: ccrevdb.v1.NodeCapabilities
+	(*SessionCapabilities)(nil),   // 17: ccrevdb.v1.SessionCapabilities
+	(*TargetFingerprint)(nil),     // 18: ccrevdb.v1.TargetFingerprint
+	(*SourceRequest)(nil),         // 19: ccrevdb.v1.SourceRequest
+	(*SourceFile)(nil),            // 20: ccrevdb.v1.SourceFile
+	(*CompletionRequest)(nil),     // 21: ccrevdb.v1.CompletionRequest
+	(*Completions)(nil),           // 22: ccrevdb.v1.Completions
+	(*SessionRequest)(nil),        // 23: ccrevdb.v1.SessionRequest
+	(*SessionInfo)(nil),           // 24: ccrevdb.v1.SessionInfo
+	(*SessionList)(nil),           // 25: ccrevdb.v1.SessionList
+	(*SessionId)(nil),             // 26: ccrevdb.v1.SessionId
+	(*ConsoleInput)(nil),          // 27: ccrevdb.v1.ConsoleInput
+	(*OutputRequest)(nil),         // 28: ccrevdb.v1.OutputRequest
+	(*ConsoleOutput)(nil),         // 29: ccrevdb.v1.ConsoleOutput
+	(*InterruptRequest)(nil),      // 30: ccrevdb.v1.InterruptRequest
+	(*InterruptReply)(nil),        // 31: ccrevdb.v1.InterruptReply
+	(*NodeEvent)(nil),             // 32: ccrevdb.v1.NodeEvent
+	(*NodeEvents)(nil),            // 33: ccrevdb.v1.NodeEvents
+	nil,                           // 34: ccrevdb.v1.MPICallRecord.ParametersEntry
+	nil,                           // 35: ccrevdb.v1.MPICallRecord.BufferHashesEntry
+	nil,                           // 36: ccrevdb.v1.SessionCapabilities.NodesEntry
+	(*timestamppb.Timestamp)(nil), // 37: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 38: google.protobuf.Duration
+	(*emptypb.Empty)(nil),         // 39: google.protobuf.Empty
+}
+var file_control_proto_depIdxs = []int32{
+	4,  // 0: ccrevdb.v1.Command.result:type_name -> ccrevdb.v1.CommandResult
+	5,  // 1: ccrevdb.v1.CommandResult.values:type_name -> ccrevdb.v1.Value
+	34, // 2: ccrevdb.v1.MPICallRecord.parameters:type_name -> ccrevdb.v1.MPICallRecord.ParametersEntry
+	37, // 3: ccrevdb.v1.MPICallRecord.time:type_name -> google.protobuf.Timestamp
+	35, // 4: ccrevdb.v1.MPICallRecord.buffer_hashes:type_name -> ccrevdb.v1.MPICallRecord.BufferHashesEntry
+	7,  // 5: ccrevdb.v1.NodeResync.registration:type_name -> ccrevdb.v1.NodeRegistration
+	14, // 6: ccrevdb.v1.MemoryLayoutRecord.regions:type_name -> ccrevdb.v1.MemoryRegion
+	37, // 7: ccrevdb.v1.ResourceUsageRecord.timestamp:type_name -> google.protobuf.Timestamp
+	38, // 8: ccrevdb.v1.ResourceUsageRecord.cpu_time:type_name -> google.protobuf.Duration
+	36, // 9: ccrevdb.v1.SessionCapabilities.nodes:type_name -> ccrevdb.v1.SessionCapabilities.NodesEntry
+	37, // 10: ccrevdb.v1.SessionInfo.started:type_name -> google.protobuf.Timestamp
+	24, // 11: ccrevdb.v1.SessionList.sessions:type_name -> ccrevdb.v1.SessionInfo
+	37, // 12: ccrevdb.v1.NodeEvent.time:type_name -> google.protobuf.Timestamp
+	32, // 13: ccrevdb.v1.NodeEvents.events:type_name -> ccrevdb.v1.NodeEvent
+	16, // 14: ccrevdb.v1.SessionCapabilities.NodesEntry.value:type_name -> ccrevdb.v1.NodeCapabilities
+	39, // 15: ccrevdb.v1.Health.Heartbeat:input_type -> google.protobuf.Empty
+	39, // 16: ccrevdb.v1.Health.Version:input_type -> google.protobuf.Empty
+	2,  // 17: ccrevdb.v1.Logger.Log:input_type -> ccrevdb.v1.LogEntry
+	7,  // 18: ccrevdb.v1.NodeReporter.Register:input_type -> ccrevdb.v1.NodeRegistration
+	3,  // 19: ccrevdb.v1.NodeReporter.CommandResult:input_type -> ccrevdb.v1.Command
+	3,  // 20: ccrevdb.v1.NodeReporter.Progress:input_type -> ccrevdb.v1.Command
+	6,  // 21: ccrevdb.v1.NodeReporter.MPICall:input_type -> ccrevdb.v1.MPICallRecord
+	13, // 22: ccrevdb.v1.NodeReporter.MemoryLayout:input_type -> ccrevdb.v1.MemoryLayoutRecord
+	15, // 23: ccrevdb.v1.NodeReporter.ResourceUsage:input_type -> ccrevdb.v1.ResourceUsageRecord
+	18, // 24: ccrevdb.v1.NodeReporter.Fingerprint:input_type -> ccrevdb.v1.TargetFingerprint
+	16, // 25: ccrevdb.v1.NodeReporter.Capabilities:input_type -> ccrevdb.v1.NodeCapabilities
+	10, // 26: ccrevdb.v1.NodeReporter.LinePassed:input_type -> ccrevdb.v1.LinePass
+	11, // 27: ccrevdb.v1.NodeReporter.CausalReceive:input_type -> ccrevdb.v1.ReceivedMessage
+	8,  // 28: ccrevdb.v1.NodeReporter.Resync:input_type -> ccrevdb.v1.NodeResync
+	33, // 29: ccrevdb.v1.NodeReporter.Events:input_type -> ccrevdb.v1.NodeEvents
+	39, // 30: ccrevdb.v1.Session.Capabilities:input_type -> google.protobuf.Empty
+	39, // 31: ccrevdb.v1.Session.WatchEvents:input_type -> google.protobuf.Empty
+	27, // 32: ccrevdb.v1.RemoteConsole.Input:input_type -> ccrevdb.v1.ConsoleInput
+	28, // 33: ccrevdb.v1.RemoteConsole.Output:input_type -> ccrevdb.v1.OutputRequest
+	3,  // 34: ccrevdb.v1.CommandHandler.Handle:input_type -> ccrevdb.v1.Command
+	1,  // 35: ccrevdb.v1.CommandHandler.Renumber:input_type -> ccrevdb.v1.NodeId
+	30, // 36: ccrevdb.v1.CommandHandler.Interrupt:input_type -> ccrevdb.v1.InterruptRequest
+	19, // 37: ccrevdb.v1.SourceServer.Fetch:input_type -> ccrevdb.v1.SourceRequest
+	21, // 38: ccrevdb.v1.SourceServer.Complete:input_type -> ccrevdb.v1.CompletionRequest
+	23, // 39: ccrevdb.v1.SessionService.Start:input_type -> ccrevdb.v1.SessionRequest
+	39, // 40: ccrevdb.v1.SessionService.List:input_type -> google.protobuf.Empty
+	26, // 41: ccrevdb.v1.SessionService.Stop:input_type -> ccrevdb.v1.SessionId
+	39, // 42: ccrevdb.v1.Health.Heartbeat:output_type -> google.protobuf.Empty
+	0,  // 43: ccrevdb.v1.Health.Version:output_type -> ccrevdb.v1.ProtocolVersion
+	39, // 44: ccrevdb.v1.Logger.Log:output_type -> google.protobuf.Empty
+	1,  // 45: ccrevdb.v1.NodeReporter.Register:output_type -> ccrevdb.v1.NodeId
+	39, // 46: ccrevdb.v1.NodeReporter.CommandResult:output_type -> google.protobuf.Empty
+	39, // 47: ccrevdb.v1.NodeReporter.Progress:output_type -> google.protobuf.Empty
+	39, // 48: ccrevdb.v1.NodeReporter.MPICall:output_type -> google.protobuf.Empty
+	39, // 49: ccrevdb.v1.NodeReporter.MemoryLayout:output_type -> google.protobuf.Empty
+	39, // 50: ccrevdb.v1.NodeReporter.ResourceUsage:output_type -> google.protobuf.Empty
+	39, // 51: ccrevdb.v1.NodeReporter.Fingerprint:output_type -> google.protobuf.Empty
+	39, // 52: ccrevdb.v1.NodeReporter.Capabilities:output_type -> google.protobuf.Empty
+	39, // 53: ccrevdb.v1.NodeReporter.LinePassed:output_type -> google.protobuf.Empty
+	12, // 54: ccrevdb.v1.NodeReporter.CausalReceive:output_type -> ccrevdb.v1.CausalBreakpoint
+	9,  // 55: ccrevdb.v1.NodeReporter.Resync:output_type -> ccrevdb.v1.ResyncReply
+	39, // 56: ccrevdb.v1.NodeReporter.Events:output_type -> google.protobuf.Empty
+	17, // 57: ccrevdb.v1.Session.Capabilities:output_type -> ccrevdb.v1.SessionCapabilities
+	32, // 58: ccrevdb.v1.Session.WatchEvents:output_type -> ccrevdb.v1.NodeEvent
+	39, // 59: ccrevdb.v1.RemoteConsole.Input:output_type -> google.protobuf.Empty
+	29, // 60: ccrevdb.v1.RemoteConsole.Output:output_type -> ccrevdb.v1.ConsoleOutput
+	39, // 61: ccrevdb.v1.CommandHandler.Handle:output_type -> google.protobuf.Empty
+	39, // 62: ccrevdb.v1.CommandHandler.Renumber:output_type -> google.protobuf.Empty
+	31, // 63: ccrevdb.v1.CommandHandler.Interrupt:output_type -> ccrevdb.v1.InterruptReply
+	20, // 64: ccrevdb.v1.SourceServer.Fetch:output_type -> ccrevdb.v1.SourceFile
+	22, // 65: ccrevdb.v1.SourceServer.Complete:output_type -> ccrevdb.v1.Completions
+	24, // 66: ccrevdb.v1.SessionService.Start:output_type -> ccrevdb.v1.SessionInfo
+	25, // 67: ccrevdb.v1.SessionService.List:output_type -> ccrevdb.v1.SessionList
+	39, // 68: ccrevdb.v1.SessionService.Stop:output_type -> google.protobuf.Empty
+	42, // [42:69] is the sub-list for method output_type
+	15, // [15:42] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_control_proto_init() }
+func file_control_proto_init() {
+	if File_control_proto != nil {
+		return
+	}
+	file_control_proto_msgTypes[3].OneofWrappers = []any{
+		(*Command_Number)(nil),
+		(*Command_Text)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_control_proto_rawDesc), len(file_control_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   37,
+			NumExtensions: 0,
+			NumServices:   8,
+		},
+		GoTypes:           file_control_proto_goTypes,
+		DependencyIndexes: file_control_proto_depIdxs,
+		MessageInfos:      file_control_proto_msgTypes,
+	}.Build()
+	File_control_proto = out.File
+	file_control_proto_goTypes = nil
+	file_control_proto_depIdxs = nil
+}
@@ -0,0 +1,1626 @@
+// The control plane of the debugger: the services the orchestrator and the node debuggers call each other by, and the
+// remote console and the session service. Served over grpc, so front-ends in other languages generate their clients
+// from this file (e.g. grpc_tools.protoc for Python).
+//
+// Fields are only ever added, under new numbers. An incompatible change goes into a new package (ccrevdb.v2) and
+// raises the version returned by Health.Version. Calls carry the session token in the x-session-token metadata,
+// if the orchestrator has one.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v5.27.1
+// source: control.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Health_Heartbeat_FullMethodName = "/ccrevdb.v1.Health/Heartbeat"
+	Health_Version_FullMethodName   = "/ccrevdb.v1.Health/Version"
+)
+
+// HealthClient is the client API for Health service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Served by the orchestrator and the nodes alike
+type HealthClient interface {
+	Heartbeat(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Version of the protocol, compared by clients on connecting
+	Version(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ProtocolVersion, error)
+}
+
+type healthClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHealthClient(cc grpc.ClientConnInterface) HealthClient {
+	return &healthClient{cc}
+}
+
+func (c *healthClient) Heartbeat(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Health_Heartbeat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *healthClient) Version(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ProtocolVersion, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProtocolVersion)
+	err := c.cc.Invoke(ctx, Health_Version_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HealthServer is the server API for Health service.
+// All implementations must embed UnimplementedHealthServer
+// for forward compatibility.
+//
+// Served by the orchestrator and the nodes alike
+type HealthServer interface {
+	Heartbeat(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// Version of the protocol, compared by clients on connecting
+	Version(context.Context, *emptypb.Empty) (*ProtocolVersion, error)
+	mustEmbedUnimplementedHealthServer()
+}
+
+// UnimplementedHealthServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedHealthServer struct{}
+
+func (UnimplementedHealthServer) Heartbeat(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedHealthServer) Version(context.Context, *emptypb.Empty) (*ProtocolVersion, error) {
+	return nil, status.Error(codes.Unimplemented, "method Version not implemented")
+}
+func (UnimplementedHealthServer) mustEmbedUnimplementedHealthServer() {}
+func (UnimplementedHealthServer) testEmbeddedByValue()                {}
+
+// UnsafeHealthServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HealthServer will
+// result in compilation errors.
+type UnsafeHealthServer interface {
+	mustEmbedUnimplementedHealthServer()
+}
+
+func RegisterHealthServer(s grpc.ServiceRegistrar, srv HealthServer) {
+	// If the following call panics, it indicates UnimplementedHealthServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Health_ServiceDesc, srv)
+}
+
+func _Health_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HealthServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Health_Heartbeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HealthServer).Heartbeat(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Health_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HealthServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Health_Version_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HealthServer).Version(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Health_ServiceDesc is the grpc.ServiceDesc for Health service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Health_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ccrevdb.v1.Health",
+	HandlerType: (*HealthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Heartbeat",
+			Handler:    _Health_Heartbeat_Handler,
+		},
+		{
+			MethodName: "Version",
+			Handler:    _Health_Version_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "control.proto",
+}
+
+const (
+	Logger_Log_FullMethodName = "/ccrevdb.v1.Logger/Log"
+)
+
+// LoggerClient is the client API for Logger service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Log lines of the nodes, printed by the orchestrator
+type LoggerClient interface {
+	Log(ctx context.Context, in *LogEntry, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type loggerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLoggerClient(cc grpc.ClientConnInterface) LoggerClient {
+	return &loggerClient{cc}
+}
+
+func (c *loggerClient) Log(ctx context.Context, in *LogEntry, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Logger_Log_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LoggerServer is the server API for Logger service.
+// All implementations must embed UnimplementedLoggerServer
+// for forward compatibility.
+//
+// Log lines of the nodes, printed by the orchestrator
+type LoggerServer interface {
+	Log(context.Context, *LogEntry) (*emptypb.Empty, error)
+	mustEmbedUnimplementedLoggerServer()
+}
+
+// UnimplementedLoggerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLoggerServer struct{}
+
+func (UnimplementedLoggerServer) Log(context.Context, *LogEntry) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Log not implemented")
+}
+func (UnimplementedLoggerServer) mustEmbedUnimplementedLoggerServer() {}
+func (UnimplementedLoggerServer) testEmbeddedByValue()                {}
+
+// UnsafeLoggerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LoggerServer will
+// result in compilation errors.
+type UnsafeLoggerServer interface {
+	mustEmbedUnimplementedLoggerServer()
+}
+
+func RegisterLoggerServer(s grpc.ServiceRegistrar, srv LoggerServer) {
+	// If the following call panics, it indicates UnimplementedLoggerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Logger_ServiceDesc, srv)
+}
+
+func _Logger_Log_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogEntry)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoggerServer).Log(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Logger_Log_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoggerServer).Log(ctx, req.(*LogEntry))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Logger_ServiceDesc is the grpc.ServiceDesc for Logger service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Logger_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ccrevdb.v1.Logger",
+	HandlerType: (*LoggerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Log",
+			Handler:    _Logger_Log_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "control.proto",
+}
+
+const (
+	NodeReporter_Register_FullMethodName      = "/ccrevdb.v1.NodeReporter/Register"
+	NodeReporter_CommandResult_FullMethodName = "/ccrevdb.v1.NodeReporter/CommandResult"
+	NodeReporter_Progress_FullMethodName      = "/ccrevdb.v1.NodeReporter/Progress"
+	NodeReporter_MPICall_FullMethodName       = "/ccrevdb.v1.NodeReporter/MPICall"
+	NodeReporter_MemoryLayout_FullMethodName  = "/ccrevdb.v1.NodeReporter/MemoryLayout"
+	NodeReporter_ResourceUsage_FullMethodName = "/ccrevdb.v1.NodeReporter/ResourceUsage"
+	NodeReporter_Fingerprint_FullMethodName   = "/ccrevdb.v1.NodeReporter/Fingerprint"
+	NodeReporter_Capabilities_FullMethodName  = "/ccrevdb.v1.NodeReporter/Capabilities"
+	NodeReporter_LinePassed_FullMethodName    = "/ccrevdb.v1.NodeReporter/LinePassed"
+	NodeReporter_CausalReceive_FullMethodName = "/ccrevdb.v1.NodeReporter/CausalReceive"
+	NodeReporter_Resync_FullMethodName        = "/ccrevdb.v1.NodeReporter/Resync"
+	NodeReporter_Events_FullMethodName        = "/ccrevdb.v1.NodeReporter/Events"
+)
+
+// NodeReporterClient is the client API for NodeReporter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Served by the orchestrator to its nodes, which report their state and the results of commands
+type NodeReporterClient interface {
+	// Returns the id of the node
+	Register(ctx context.Context, in *NodeRegistration, opts ...grpc.CallOption) (*NodeId, error)
+	CommandResult(ctx context.Context, in *Command, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// A forward progress command started running the target
+	Progress(ctx context.Context, in *Command, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	MPICall(ctx context.Context, in *MPICallRecord, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	MemoryLayout(ctx context.Context, in *MemoryLayoutRecord, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ResourceUsage(ctx context.Context, in *ResourceUsageRecord, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Fingerprint(ctx context.Context, in *TargetFingerprint, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Capabilities(ctx context.Context, in *NodeCapabilities, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	LinePassed(ctx context.Context, in *LinePass, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Returns the causal breakpoint the message completes, if any
+	CausalReceive(ctx context.Context, in *ReceivedMessage, opts ...grpc.CallOption) (*CausalBreakpoint, error)
+	Resync(ctx context.Context, in *NodeResync, opts ...grpc.CallOption) (*ResyncReply, error)
+	Events(ctx context.Context, in *NodeEvents, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type nodeReporterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNodeReporterClient(cc grpc.ClientConnInterface) NodeReporterClient {
+	return &nodeReporterClient{cc}
+}
+
+func (c *nodeReporterClient) Register(ctx context.Context, in *NodeRegistration, opts ...grpc.CallOption) (*NodeId, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NodeId)
+	err := c.cc.Invoke(ctx, NodeReporter_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) CommandResult(ctx context.Context, in *Command, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NodeReporter_CommandResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) Progress(ctx context.Context, in *Command, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NodeReporter_Progress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) MPICall(ctx context.Context, in *MPICallRecord, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NodeReporter_MPICall_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) MemoryLayout(ctx context.Context, in *MemoryLayoutRecord, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NodeReporter_MemoryLayout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) ResourceUsage(ctx context.Context, in *ResourceUsageRecord, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NodeReporter_ResourceUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) Fingerprint(ctx context.Context, in *TargetFingerprint, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NodeReporter_Fingerprint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) Capabilities(ctx context.Context, in *NodeCapabilities, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NodeReporter_Capabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) LinePassed(ctx context.Context, in *LinePass, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NodeReporter_LinePassed_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) CausalReceive(ctx context.Context, in *ReceivedMessage, opts ...grpc.CallOption) (*CausalBreakpoint, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CausalBreakpoint)
+	err := c.cc.Invoke(ctx, NodeReporter_CausalReceive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) Resync(ctx context.Context, in *NodeResync, opts ...grpc.CallOption) (*ResyncReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResyncReply)
+	err := c.cc.Invoke(ctx, NodeReporter_Resync_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeReporterClient) Events(ctx context.Context, in *NodeEvents, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, NodeReporter_Events_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NodeReporterServer is the server API for NodeReporter service.
+// All implementations must embed UnimplementedNodeReporterServer
+// for forward compatibility.
+//
+// Served by the orchestrator to its nodes, which report their state and the results of commands
+type NodeReporterServer interface {
+	// Returns the id of the node
+	Register(context.Context, *NodeRegistration) (*NodeId, error)
+	CommandResult(context.Context, *Command) (*emptypb.Empty, error)
+	// A forward progress command started running the target
+	Progress(context.Context, *Command) (*emptypb.Empty, error)
+	MPICall(context.Context, *MPICallRecord) (*emptypb.Empty, error)
+	MemoryLayout(context.Context, *MemoryLayoutRecord) (*emptypb.Empty, error)
+	ResourceUsage(context.Context, *ResourceUsageRecord) (*emptypb.Empty, error)
+	Fingerprint(context.Context, *TargetFingerprint) (*emptypb.Empty, error)
+	Capabilities(context.Context, *NodeCapabilities) (*emptypb.Empty, error)
+	LinePassed(context.Context, *LinePass) (*emptypb.Empty, error)
+	// Returns the causal breakpoint the message completes, if any
+	CausalReceive(context.Context, *ReceivedMessage) (*CausalBreakpoint, error)
+	Resync(context.Context, *NodeResync) (*ResyncReply, error)
+	Events(context.Context, *NodeEvents) (*emptypb.Empty, error)
+	mustEmbedUnimplementedNodeReporterServer()
+}
+
+// UnimplementedNodeReporterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNodeReporterServer struct{}
+
+func (UnimplementedNodeReporterServer) Register(context.Context, *NodeRegistration) (*NodeId, error) {
+	return nil, status.Error(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedNodeReporterServer) CommandResult(context.Context, *Command) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method CommandResult not implemented")
+}
+func (UnimplementedNodeReporterServer) Progress(context.Context, *Command) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Progress not implemented")
+}
+func (UnimplementedNodeReporterServer) MPICall(context.Context, *MPICallRecord) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method MPICall not implemented")
+}
+func (UnimplementedNodeReporterServer) MemoryLayout(context.Context, *MemoryLayoutRecord) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method MemoryLayout not implemented")
+}
+func (UnimplementedNodeReporterServer) ResourceUsage(context.Context, *ResourceUsageRecord) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResourceUsage not implemented")
+}
+func (UnimplementedNodeReporterServer) Fingerprint(context.Context, *TargetFingerprint) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Fingerprint not implemented")
+}
+func (UnimplementedNodeReporterServer) Capabilities(context.Context, *NodeCapabilities) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Capabilities not implemented")
+}
+func (UnimplementedNodeReporterServer) LinePassed(context.Context, *LinePass) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method LinePassed not implemented")
+}
+func (UnimplementedNodeReporterServer) CausalReceive(context.Context, *ReceivedMessage) (*CausalBreakpoint, error) {
+	return nil, status.Error(codes.Unimplemented, "method CausalReceive not implemented")
+}
+func (UnimplementedNodeReporterServer) Resync(context.Context, *NodeResync) (*ResyncReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Resync not implemented")
+}
+func (UnimplementedNodeReporterServer) Events(context.Context, *NodeEvents) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedNodeReporterServer) mustEmbedUnimplementedNodeReporterServer() {}
+func (UnimplementedNodeReporterServer) testEmbeddedByValue()                      {}
+
+// UnsafeNodeReporterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NodeReporterServer will
+// result in compilation errors.
+type UnsafeNodeReporterServer interface {
+	mustEmbedUnimplementedNodeReporterServer()
+}
+
+func RegisterNodeReporterServer(s grpc.ServiceRegistrar, srv NodeReporterServer) {
+	// If the following call panics, it indicates UnimplementedNodeReporterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&NodeReporter_ServiceDesc, srv)
+}
+
+func _NodeReporter_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeRegistration)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).Register(ctx, req.(*NodeRegistration))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_CommandResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Command)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).CommandResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_CommandResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).CommandResult(ctx, req.(*Command))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_Progress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Command)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).Progress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_Progress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).Progress(ctx, req.(*Command))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_MPICall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MPICallRecord)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).MPICall(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_MPICall_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).MPICall(ctx, req.(*MPICallRecord))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_MemoryLayout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MemoryLayoutRecord)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).MemoryLayout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_MemoryLayout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).MemoryLayout(ctx, req.(*MemoryLayoutRecord))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_ResourceUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResourceUsageRecord)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).ResourceUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_ResourceUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).ResourceUsage(ctx, req.(*ResourceUsageRecord))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_Fingerprint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TargetFingerprint)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).Fingerprint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_Fingerprint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).Fingerprint(ctx, req.(*TargetFingerprint))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeCapabilities)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).Capabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_Capabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).Capabilities(ctx, req.(*NodeCapabilities))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_LinePassed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinePass)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).LinePassed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_LinePassed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).LinePassed(ctx, req.(*LinePass))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_CausalReceive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReceivedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).CausalReceive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_CausalReceive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).CausalReceive(ctx, req.(*ReceivedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_Resync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeResync)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).Resync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_Resync_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).Resync(ctx, req.(*NodeResync))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeReporter_Events_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeEvents)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeReporterServer).Events(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeReporter_Events_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeReporterServer).Events(ctx, req.(*NodeEvents))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NodeReporter_ServiceDesc is the grpc.ServiceDesc for NodeReporter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NodeReporter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ccrevdb.v1.NodeReporter",
+	HandlerType: (*NodeReporterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _NodeReporter_Register_Handler,
+		},
+		{
+			MethodName: "CommandResult",
+			Handler:    _NodeReporter_CommandResult_Handler,
+		},
+		{
+			MethodName: "Progress",
+			Handler:    _NodeReporter_Progress_Handler,
+		},
+		{
+			MethodName: "MPICall",
+			Handler:    _NodeReporter_MPICall_Handler,
+		},
+		{
+			MethodName: "MemoryLayout",
+			Handler:    _NodeReporter_MemoryLayout_Handler,
+		},
+		{
+			MethodName: "ResourceUsage",
+			Handler:    _NodeReporter_ResourceUsage_Handler,
+		},
+		{
+			MethodName: "Fingerprint",
+			Handler:    _NodeReporter_Fingerprint_Handler,
+		},
+		{
+			MethodName: "Capabilities",
+			Handler:    _NodeReporter_Capabilities_Handler,
+		},
+		{
+			MethodName: "LinePassed",
+			Handler:    _NodeReporter_LinePassed_Handler,
+		},
+		{
+			MethodName: "CausalReceive",
+			Handler:    _NodeReporter_CausalReceive_Handler,
+		},
+		{
+			MethodName: "Resync",
+			Handler:    _NodeReporter_Resync_Handler,
+		},
+		{
+			MethodName: "Events",
+			Handler:    _NodeReporter_Events_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "control.proto",
+}
+
+const (
+	Session_Capabilities_FullMethodName = "/ccrevdb.v1.Session/Capabilities"
+	Session_WatchEvents_FullMethodName  = "/ccrevdb.v1.Session/WatchEvents"
+)
+
+// SessionClient is the client API for Session service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Served by the orchestrator to front-ends
+type SessionClient interface {
+	Capabilities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SessionCapabilities, error)
+	// Streams the events of all nodes as they are reported. Events a slow client cannot keep up with are dropped
+	WatchEvents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NodeEvent], error)
+}
+
+type sessionClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSessionClient(cc grpc.ClientConnInterface) SessionClient {
+	return &sessionClient{cc}
+}
+
+func (c *sessionClient) Capabilities(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SessionCapabilities, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionCapabilities)
+	err := c.cc.Invoke(ctx, Session_Capabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionClient) WatchEvents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NodeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Session_ServiceDesc.Streams[0], Session_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, NodeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Session_WatchEventsClient = grpc.ServerStreamingClient[NodeEvent]
+
+// SessionServer is the server API for Session service.
+// All implementations must embed UnimplementedSessionServer
+// for forward compatibility.
+//
+// Served by the orchestrator to front-ends
+type SessionServer interface {
+	Capabilities(context.Context, *emptypb.Empty) (*SessionCapabilities, error)
+	// Streams the events of all nodes as they are reported. Events a slow client cannot keep up with are dropped
+	WatchEvents(*emptypb.Empty, grpc.ServerStreamingServer[NodeEvent]) error
+	mustEmbedUnimplementedSessionServer()
+}
+
+// UnimplementedSessionServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSessionServer struct{}
+
+func (UnimplementedSessionServer) Capabilities(context.Context, *emptypb.Empty) (*SessionCapabilities, error) {
+	return nil, status.Error(codes.Unimplemented, "method Capabilities not implemented")
+}
+func (UnimplementedSessionServer) WatchEvents(*emptypb.Empty, grpc.ServerStreamingServer[NodeEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedSessionServer) mustEmbedUnimplementedSessionServer() {}
+func (UnimplementedSessionServer) testEmbeddedByValue()                 {}
+
+// UnsafeSessionServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SessionServer will
+// result in compilation errors.
+type UnsafeSessionServer interface {
+	mustEmbedUnimplementedSessionServer()
+}
+
+func RegisterSessionServer(s grpc.ServiceRegistrar, srv SessionServer) {
+	// If the following call panics, it indicates UnimplementedSessionServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Session_ServiceDesc, srv)
+}
+
+func _Session_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionServer).Capabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Session_Capabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionServer).Capabilities(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Session_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SessionServer).WatchEvents(m, &grpc.GenericServerStream[emptypb.Empty, NodeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Session_WatchEventsServer = grpc.ServerStreamingServer[NodeEvent]
+
+// Session_ServiceDesc is the grpc.ServiceDesc for Session service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Session_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ccrevdb.v1.Session",
+	HandlerType: (*SessionServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Capabilities",
+			Handler:    _Session_Capabilities_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _Session_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "control.proto",
+}
+
+const (
+	RemoteConsole_Input_FullMethodName  = "/ccrevdb.v1.RemoteConsole/Input"
+	RemoteConsole_Output_FullMethodName = "/ccrevdb.v1.RemoteConsole/Output"
+)
+
+// RemoteConsoleClient is the client API for RemoteConsole service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Served by an orchestrator started with --remote-console
+type RemoteConsoleClient interface {
+	// Executes the line as if typed at the console
+	Input(ctx context.Context, in *ConsoleInput, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Streams the console output from the offset on, as it is written
+	Output(ctx context.Context, in *OutputRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConsoleOutput], error)
+}
+
+type remoteConsoleClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRemoteConsoleClient(cc grpc.ClientConnInterface) RemoteConsoleClient {
+	return &remoteConsoleClient{cc}
+}
+
+func (c *remoteConsoleClient) Input(ctx context.Context, in *ConsoleInput, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, RemoteConsole_Input_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteConsoleClient) Output(ctx context.Context, in *OutputRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConsoleOutput], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RemoteConsole_ServiceDesc.Streams[0], RemoteConsole_Output_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[OutputRequest, ConsoleOutput]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteConsole_OutputClient = grpc.ServerStreamingClient[ConsoleOutput]
+
+// RemoteConsoleServer is the server API for RemoteConsole service.
+// All implementations must embed UnimplementedRemoteConsoleServer
+// for forward compatibility.
+//
+// Served by an orchestrator started with --remote-console
+type RemoteConsoleServer interface {
+	// Executes the line as if typed at the console
+	Input(context.Context, *ConsoleInput) (*emptypb.Empty, error)
+	// Streams the console output from the offset on, as it is written
+	Output(*OutputRequest, grpc.ServerStreamingServer[ConsoleOutput]) error
+	mustEmbedUnimplementedRemoteConsoleServer()
+}
+
+// UnimplementedRemoteConsoleServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRemoteConsoleServer struct{}
+
+func (UnimplementedRemoteConsoleServer) Input(context.Context, *ConsoleInput) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Input not implemented")
+}
+func (UnimplementedRemoteConsoleServer) Output(*OutputRequest, grpc.ServerStreamingServer[ConsoleOutput]) error {
+	return status.Error(codes.Unimplemented, "method Output not implemented")
+}
+func (UnimplementedRemoteConsoleServer) mustEmbedUnimplementedRemoteConsoleServer() {}
+func (UnimplementedRemoteConsoleServer) testEmbeddedByValue()                       {}
+
+// UnsafeRemoteConsoleServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RemoteConsoleServer will
+// result in compilation errors.
+type UnsafeRemoteConsoleServer interface {
+	mustEmbedUnimplementedRemoteConsoleServer()
+}
+
+func RegisterRemoteConsoleServer(s grpc.ServiceRegistrar, srv RemoteConsoleServer) {
+	// If the following call panics, it indicates UnimplementedRemoteConsoleServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RemoteConsole_ServiceDesc, srv)
+}
+
+func _RemoteConsole_Input_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConsoleInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteConsoleServer).Input(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteConsole_Input_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteConsoleServer).Input(ctx, req.(*ConsoleInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteConsole_Output_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(OutputRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RemoteConsoleServer).Output(m, &grpc.GenericServerStream[OutputRequest, ConsoleOutput]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteConsole_OutputServer = grpc.ServerStreamingServer[ConsoleOutput]
+
+// RemoteConsole_ServiceDesc is the grpc.ServiceDesc for RemoteConsole service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RemoteConsole_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ccrevdb.v1.RemoteConsole",
+	HandlerType: (*RemoteConsoleServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Input",
+			Handler:    _RemoteConsole_Input_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Output",
+			Handler:       _RemoteConsole_Output_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "control.proto",
+}
+
+const (
+	CommandHandler_Handle_FullMethodName    = "/ccrevdb.v1.CommandHandler/Handle"
+	CommandHandler_Renumber_FullMethodName  = "/ccrevdb.v1.CommandHandler/Renumber"
+	CommandHandler_Interrupt_FullMethodName = "/ccrevdb.v1.CommandHandler/Interrupt"
+)
+
+// CommandHandlerClient is the client API for CommandHandler service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Served by the nodes to the orchestrator
+type CommandHandlerClient interface {
+	// Schedules the command on the node, its result is reported with NodeReporter.CommandResult
+	Handle(ctx context.Context, in *Command, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Gives the node the id of its rank
+	Renumber(ctx context.Context, in *NodeId, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Stops the running target, for the reason logged by the node
+	Interrupt(ctx context.Context, in *InterruptRequest, opts ...grpc.CallOption) (*InterruptReply, error)
+}
+
+type commandHandlerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCommandHandlerClient(cc grpc.ClientConnInterface) CommandHandlerClient {
+	return &commandHandlerClient{cc}
+}
+
+func (c *commandHandlerClient) Handle(ctx context.Context, in *Command, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, CommandHandler_Handle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commandHandlerClient) Renumber(ctx context.Context, in *NodeId, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, CommandHandler_Renumber_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commandHandlerClient) Interrupt(ctx context.Context, in *InterruptRequest, opts ...grpc.CallOption) (*InterruptReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InterruptReply)
+	err := c.cc.Invoke(ctx, CommandHandler_Interrupt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CommandHandlerServer is the server API for CommandHandler service.
+// All implementations must embed UnimplementedCommandHandlerServer
+// for forward compatibility.
+//
+// Served by the nodes to the orchestrator
+type CommandHandlerServer interface {
+	// Schedules the command on the node, its result is reported with NodeReporter.CommandResult
+	Handle(context.Context, *Command) (*emptypb.Empty, error)
+	// Gives the node the id of its rank
+	Renumber(context.Context, *NodeId) (*emptypb.Empty, error)
+	// Stops the running target, for the reason logged by the node
+	Interrupt(context.Context, *InterruptRequest) (*InterruptReply, error)
+	mustEmbedUnimplementedCommandHandlerServer()
+}
+
+// UnimplementedCommandHandlerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCommandHandlerServer struct{}
+
+func (UnimplementedCommandHandlerServer) Handle(context.Context, *Command) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Handle not implemented")
+}
+func (UnimplementedCommandHandlerServer) Renumber(context.Context, *NodeId) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Renumber not implemented")
+}
+func (UnimplementedCommandHandlerServer) Interrupt(context.Context, *InterruptRequest) (*InterruptReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Interrupt not implemented")
+}
+func (UnimplementedCommandHandlerServer) mustEmbedUnimplementedCommandHandlerServer() {}
+func (UnimplementedCommandHandlerServer) testEmbeddedByValue()                        {}
+
+// UnsafeCommandHandlerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CommandHandlerServer will
+// result in compilation errors.
+type UnsafeCommandHandlerServer interface {
+	mustEmbedUnimplementedCommandHandlerServer()
+}
+
+func RegisterCommandHandlerServer(s grpc.ServiceRegistrar, srv CommandHandlerServer) {
+	// If the following call panics, it indicates UnimplementedCommandHandlerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CommandHandler_ServiceDesc, srv)
+}
+
+func _CommandHandler_Handle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Command)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommandHandlerServer).Handle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CommandHandler_Handle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommandHandlerServer).Handle(ctx, req.(*Command))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommandHandler_Renumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommandHandlerServer).Renumber(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CommandHandler_Renumber_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommandHandlerServer).Renumber(ctx, req.(*NodeId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommandHandler_Interrupt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InterruptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommandHandlerServer).Interrupt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CommandHandler_Interrupt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommandHandlerServer).Interrupt(ctx, req.(*InterruptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CommandHandler_ServiceDesc is the grpc.ServiceDesc for CommandHandler service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CommandHandler_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ccrevdb.v1.CommandHandler",
+	HandlerType: (*CommandHandlerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Handle",
+			Handler:    _CommandHandler_Handle_Handler,
+		},
+		{
+			MethodName: "Renumber",
+			Handler:    _CommandHandler_Renumber_Handler,
+		},
+		{
+			MethodName: "Interrupt",
+			Handler:    _CommandHandler_Interrupt_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "control.proto",
+}
+
+const (
+	SourceServer_Fetch_FullMethodName    = "/ccrevdb.v1.SourceServer/Fetch"
+	SourceServer_Complete_FullMethodName = "/ccrevdb.v1.SourceServer/Complete"
+)
+
+// SourceServerClient is the client API for SourceServer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Served by the nodes to the orchestrator, from the sources and the debug info of their targets
+type SourceServerClient interface {
+	Fetch(ctx context.Context, in *SourceRequest, opts ...grpc.CallOption) (*SourceFile, error)
+	Complete(ctx context.Context, in *CompletionRequest, opts ...grpc.CallOption) (*Completions, error)
+}
+
+type sourceServerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSourceServerClient(cc grpc.ClientConnInterface) SourceServerClient {
+	return &sourceServerClient{cc}
+}
+
+func (c *sourceServerClient) Fetch(ctx context.Context, in *SourceRequest, opts ...grpc.CallOption) (*SourceFile, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SourceFile)
+	err := c.cc.Invoke(ctx, SourceServer_Fetch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sourceServerClient) Complete(ctx context.Context, in *CompletionRequest, opts ...grpc.CallOption) (*Completions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Completions)
+	err := c.cc.Invoke(ctx, SourceServer_Complete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SourceServerServer is the server API for SourceServer service.
+// All implementations must embed UnimplementedSourceServerServer
+// for forward compatibility.
+//
+// Served by the nodes to the orchestrator, from the sources and the debug info of their targets
+type SourceServerServer interface {
+	Fetch(context.Context, *SourceRequest) (*SourceFile, error)
+	Complete(context.Context, *CompletionRequest) (*Completions, error)
+	mustEmbedUnimplementedSourceServerServer()
+}
+
+// UnimplementedSourceServerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSourceServerServer struct{}
+
+func (UnimplementedSourceServerServer) Fetch(context.Context, *SourceRequest) (*SourceFile, error) {
+	return nil, status.Error(codes.Unimplemented, "method Fetch not implemented")
+}
+func (UnimplementedSourceServerServer) Complete(context.Context, *CompletionRequest) (*Completions, error) {
+	return nil, status.Error(codes.Unimplemented, "method Complete not implemented")
+}
+func (UnimplementedSourceServerServer) mustEmbedUnimplementedSourceServerServer() {}
+func (UnimplementedSourceServerServer) testEmbeddedByValue()                      {}
+
+// UnsafeSourceServerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SourceServerServer will
+// result in compilation errors.
+type UnsafeSourceServerServer interface {
+	mustEmbedUnimplementedSourceServerServer()
+}
+
+func RegisterSourceServerServer(s grpc.ServiceRegistrar, srv SourceServerServer) {
+	// If the following call panics, it indicates UnimplementedSourceServerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SourceServer_ServiceDesc, srv)
+}
+
+func _SourceServer_Fetch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SourceServerServer).Fetch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SourceServer_Fetch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SourceServerServer).Fetch(ctx, req.(*SourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SourceServer_Complete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompletionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SourceServerServer).Complete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SourceServer_Complete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SourceServerServer).Complete(ctx, req.(*CompletionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SourceServer_ServiceDesc is the grpc.ServiceDesc for SourceServer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SourceServer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ccrevdb.v1.SourceServer",
+	HandlerType: (*SourceServerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Fetch",
+			Handler:    _SourceServer_Fetch_Handler,
+		},
+		{
+			MethodName: "Complete",
+			Handler:    _SourceServer_Complete_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "control.proto",
+}
+
+const (
+	SessionService_Start_FullMethodName = "/ccrevdb.v1.SessionService/Start"
+	SessionService_List_FullMethodName  = "/ccrevdb.v1.SessionService/List"
+	SessionService_Stop_FullMethodName  = "/ccrevdb.v1.SessionService/Stop"
+)
+
+// SessionServiceClient is the client API for SessionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Served by orchestrator serve
+type SessionServiceClient interface {
+	Start(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*SessionInfo, error)
+	List(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SessionList, error)
+	Stop(ctx context.Context, in *SessionId, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type sessionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSessionServiceClient(cc grpc.ClientConnInterface) SessionServiceClient {
+	return &sessionServiceClient{cc}
+}
+
+func (c *sessionServiceClient) Start(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*SessionInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionInfo)
+	err := c.cc.Invoke(ctx, SessionService_Start_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionServiceClient) List(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SessionList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionList)
+	err := c.cc.Invoke(ctx, SessionService_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sessionServiceClient) Stop(ctx context.Context, in *SessionId, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, SessionService_Stop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SessionServiceServer is the server API for SessionService service.
+// All implementations must embed UnimplementedSessionServiceServer
+// for forward compatibility.
+//
+// Served by orchestrator serve
+type SessionServiceServer interface {
+	Start(context.Context, *SessionRequest) (*SessionInfo, error)
+	List(context.Context, *emptypb.Empty) (*SessionList, error)
+	Stop(context.Context, *SessionId) (*emptypb.Empty, error)
+	mustEmbedUnimplementedSessionServiceServer()
+}
+
+// UnimplementedSessionServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSessionServiceServer struct{}
+
+func (UnimplementedSessionServiceServer) Start(context.Context, *SessionRequest) (*SessionInfo, error) {
+	return nil, status.Error(codes.Unimplemented, "method Start not implemented")
+}
+func (UnimplementedSessionServiceServer) List(context.Context, *emptypb.Empty) (*SessionList, error) {
+	return nil, status.Error(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedSessionServiceServer) Stop(context.Context, *SessionId) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Stop not implemented")
+}
+func (UnimplementedSessionServiceServer) mustEmbedUnimplementedSessionServiceServer() {}
+func (UnimplementedSessionServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeSessionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SessionServiceServer will
+// result in compilation errors.
+type UnsafeSessionServiceServer interface {
+	mustEmbedUnimplementedSessionServiceServer()
+}
+
+func RegisterSessionServiceServer(s grpc.ServiceRegistrar, srv SessionServiceServer) {
+	// If the following call panics, it indicates UnimplementedSessionServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SessionService_ServiceDesc, srv)
+}
+
+func _SessionService_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionServiceServer).Start(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SessionService_Start_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionServiceServer).Start(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SessionService_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionServiceServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SessionService_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionServiceServer).List(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SessionService_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SessionServiceServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SessionService_Stop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SessionServiceServer).Stop(ctx, req.(*SessionId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SessionService_ServiceDesc is the grpc.ServiceDesc for SessionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SessionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ccrevdb.v1.SessionService",
+	HandlerType: (*SessionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Start",
+			Handler:    _SessionService_Start_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _SessionService_List_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _SessionService_Stop_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "control.proto",
+}
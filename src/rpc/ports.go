@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// port the orchestrator listens on, unless started on another one for a session of the session service
+const DEFAULT_ORCHESTRATOR_PORT = 3490
+
+// The nodes listen on the ports following the port of their orchestrator, from this offset on,
+// so the nodes of sessions on other ports do not collide
+const NODE_PORT_OFFSET = 10
+
+func NodePort(orchestratorPort int, nodeId int) int {
+	return orchestratorPort + NODE_PORT_OFFSET + nodeId
+}
+
+// Returns the port in an address given as host:port, the default orchestrator port if none
+func PortOf(address *url.URL) int {
+	_, port, err := net.SplitHostPort(address.String())
+	if err != nil {
+		return DEFAULT_ORCHESTRATOR_PORT
+	}
+
+	number, err := strconv.Atoi(port)
+	if err != nil {
+		return DEFAULT_ORCHESTRATOR_PORT
+	}
+	return number
+}
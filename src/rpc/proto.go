@@ -0,0 +1,430 @@
+package rpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"github.com/ottmartens/cc-rev-db/utils/command"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Conversions between the types exchanged over rpc and their protobuf messages
+
+func timestampOf(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timeOf(timestamp *timestamppb.Timestamp) time.Time {
+	if timestamp == nil {
+		return time.Time{}
+	}
+	return timestamp.AsTime().Local()
+}
+
+func int32s(values []int) []int32 {
+	if values == nil {
+		return nil
+	}
+
+	converted := make([]int32, len(values))
+	for i, value := range values {
+		converted[i] = int32(value)
+	}
+	return converted
+}
+
+func ints(values []int32) []int {
+	if values == nil {
+		return nil
+	}
+
+	converted := make([]int, len(values))
+	for i, value := range values {
+		converted[i] = int(value)
+	}
+	return converted
+}
+
+// Returns the command as a message, failing for an argument neither an integer nor a string
+func CommandProto(cmd *command.Command) (*pb.Command, error) {
+	message := &pb.Command{
+		NodeId:  int32(cmd.NodeId),
+		Code:    int32(cmd.Code),
+		Targets: int32s(cmd.Targets),
+		Json:    cmd.JSON,
+	}
+
+	switch argument := cmd.Argument.(type) {
+	case nil:
+	case int:
+		message.Argument = &pb.Command_Number{Number: int64(argument)}
+	case string:
+		message.Argument = &pb.Command_Text{Text: argument}
+	default:
+		return nil, fmt.Errorf("argument %v of command %v is of type %T, commands take an integer or a string", argument, cmd, argument)
+	}
+
+	if cmd.Result != nil {
+		message.Result = commandResultProto(cmd.Result)
+	}
+
+	return message, nil
+}
+
+// Returns the command of a message, failing if it is given an argument of a type the command does not take
+func CommandFromProto(message *pb.Command) (*command.Command, error) {
+	cmd := &command.Command{
+		NodeId:  int(message.NodeId),
+		Code:    command.CommandCode(message.Code),
+		Targets: ints(message.Targets),
+		JSON:    message.Json,
+	}
+
+	var err error
+
+	switch argument := message.Argument.(type) {
+	case *pb.Command_Number:
+		number := int(argument.Number)
+		err = cmd.SetTypedArgument(&number, nil)
+	case *pb.Command_Text:
+		err = cmd.SetTypedArgument(nil, &argument.Text)
+	default:
+		err = cmd.SetTypedArgument(nil, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if message.Result != nil {
+		cmd.Result = commandResultOf(message.Result)
+	}
+
+	return cmd, nil
+}
+
+func commandResultProto(result *command.CommandResult) *pb.CommandResult {
+	message := &pb.CommandResult{
+		Error:       result.Error,
+		ErrorKind:   result.ErrorKind,
+		Exited:      result.Exited,
+		Breakpoint:  result.Breakpoint,
+		Interrupted: result.Interrupted,
+		Location:    result.Location,
+		Backtrace:   result.Backtrace,
+		Output:      result.Output,
+	}
+
+	for _, value := range result.Values {
+		message.Values = append(message.Values, &pb.Value{
+			Name:     value.Name,
+			Type:     value.Type,
+			Summary:  value.Summary,
+			Handle:   int64(value.Handle),
+			Children: int64(value.Children),
+		})
+	}
+
+	return message
+}
+
+func commandResultOf(message *pb.CommandResult) *command.CommandResult {
+	result := &command.CommandResult{
+		Error:       message.Error,
+		ErrorKind:   message.ErrorKind,
+		Exited:      message.Exited,
+		Breakpoint:  message.Breakpoint,
+		Interrupted: message.Interrupted,
+		Location:    message.Location,
+		Backtrace:   message.Backtrace,
+		Output:      message.Output,
+	}
+
+	for _, value := range message.Values {
+		result.Values = append(result.Values, command.Value{
+			Name:     value.Name,
+			Type:     value.Type,
+			Summary:  value.Summary,
+			Handle:   int(value.Handle),
+			Children: int(value.Children),
+		})
+	}
+
+	return result
+}
+
+func (r MPICallRecord) Proto() *pb.MPICallRecord {
+	return &pb.MPICallRecord{
+		Id:               r.Id,
+		OpName:           r.OpName,
+		Parameters:       r.Parameters,
+		NodeId:           int32(r.NodeId),
+		InstructionCount: r.InstructionCount,
+		Payload:          r.Payload,
+		PayloadSize:      r.PayloadSize,
+		PayloadRestHash:  r.PayloadRestHash,
+		Location:         r.Location,
+		Time:             timestampOf(r.Time),
+		CheckpointBytes:  r.CheckpointBytes,
+		BufferHashes:     r.BufferHashes,
+		VectorClock:      int32s(r.VectorClock),
+	}
+}
+
+func MPICallRecordOf(message *pb.MPICallRecord) MPICallRecord {
+	return MPICallRecord{
+		Id:               message.Id,
+		OpName:           message.OpName,
+		Parameters:       message.Parameters,
+		NodeId:           int(message.NodeId),
+		InstructionCount: message.InstructionCount,
+		Payload:          message.Payload,
+		PayloadSize:      message.PayloadSize,
+		PayloadRestHash:  message.PayloadRestHash,
+		Location:         message.Location,
+		Time:             timeOf(message.Time),
+		CheckpointBytes:  message.CheckpointBytes,
+		BufferHashes:     message.BufferHashes,
+		VectorClock:      ints(message.VectorClock),
+	}
+}
+
+func (r NodeRegistration) Proto() *pb.NodeRegistration {
+	return &pb.NodeRegistration{Pid: int32(r.Pid), Rank: int32(r.Rank), Host: r.Host}
+}
+
+func NodeRegistrationOf(message *pb.NodeRegistration) NodeRegistration {
+	if message == nil {
+		return NodeRegistration{Rank: -1}
+	}
+	return NodeRegistration{Pid: int(message.Pid), Rank: int(message.Rank), Host: message.Host}
+}
+
+func (r NodeResync) Proto() *pb.NodeResync {
+	return &pb.NodeResync{
+		Registration: r.Registration.Proto(),
+		RegisteredId: int32(r.RegisteredId),
+		NodeId:       int32(r.NodeId),
+		Checkpoints:  r.Checkpoints,
+		Breakpoints:  r.Breakpoints,
+		Running:      r.Running,
+		Detached:     r.Detached,
+	}
+}
+
+func NodeResyncOf(message *pb.NodeResync) NodeResync {
+	return NodeResync{
+		Registration: NodeRegistrationOf(message.Registration),
+		RegisteredId: int(message.RegisteredId),
+		NodeId:       int(message.NodeId),
+		Checkpoints:  message.Checkpoints,
+		Breakpoints:  message.Breakpoints,
+		Running:      message.Running,
+		Detached:     message.Detached,
+	}
+}
+
+func (r ResyncReply) Proto() *pb.ResyncReply {
+	return &pb.ResyncReply{MissingCheckpoints: r.MissingCheckpoints, Rejoined: r.Rejoined}
+}
+
+func ResyncReplyOf(message *pb.ResyncReply) ResyncReply {
+	return ResyncReply{MissingCheckpoints: message.MissingCheckpoints, Rejoined: message.Rejoined}
+}
+
+func (p LinePass) Proto() *pb.LinePass {
+	return &pb.LinePass{NodeId: int32(p.NodeId), Location: p.Location, Clock: int32(p.Clock)}
+}
+
+func LinePassOf(message *pb.LinePass) LinePass {
+	return LinePass{NodeId: int(message.NodeId), Location: message.Location, Clock: int(message.Clock)}
+}
+
+func (r CausalReceive) Proto() *pb.ReceivedMessage {
+	return &pb.ReceivedMessage{NodeId: int32(r.NodeId), Source: int32(r.Source), SenderClock: int32(r.SenderClock)}
+}
+
+func CausalReceiveOf(message *pb.ReceivedMessage) CausalReceive {
+	return CausalReceive{NodeId: int(message.NodeId), Source: int(message.Source), SenderClock: int(message.SenderClock)}
+}
+
+func (r MemoryLayoutRecord) Proto() *pb.MemoryLayoutRecord {
+	message := &pb.MemoryLayoutRecord{NodeId: int32(r.NodeId), AslrDisabled: r.AslrDisabled}
+	for _, region := range r.Regions {
+		message.Regions = append(message.Regions, &pb.MemoryRegion{Start: region.Start, End: region.End, Ident: region.Ident})
+	}
+	return message
+}
+
+func MemoryLayoutRecordOf(message *pb.MemoryLayoutRecord) MemoryLayoutRecord {
+	record := MemoryLayoutRecord{NodeId: int(message.NodeId), AslrDisabled: message.AslrDisabled}
+	for _, region := range message.Regions {
+		record.Regions = append(record.Regions, MemoryRegion{Start: region.Start, End: region.End, Ident: region.Ident})
+	}
+	return record
+}
+
+func (r ResourceUsageRecord) Proto() *pb.ResourceUsageRecord {
+	return &pb.ResourceUsageRecord{
+		NodeId:     int32(r.NodeId),
+		Timestamp:  timestampOf(r.Timestamp),
+		CpuTime:    durationpb.New(r.CPUTime),
+		Rss:        r.RSS,
+		Swap:       r.Swap,
+		ReadBytes:  r.ReadBytes,
+		WriteBytes: r.WriteBytes,
+	}
+}
+
+func ResourceUsageRecordOf(message *pb.ResourceUsageRecord) ResourceUsageRecord {
+	return ResourceUsageRecord{
+		NodeId:     int(message.NodeId),
+		Timestamp:  timeOf(message.Timestamp),
+		CPUTime:    message.CpuTime.AsDuration(),
+		RSS:        message.Rss,
+		Swap:       message.Swap,
+		ReadBytes:  message.ReadBytes,
+		WriteBytes: message.WriteBytes,
+	}
+}
+
+func (c NodeCapabilities) Proto() *pb.NodeCapabilities {
+	return &pb.NodeCapabilities{
+		NodeId:                  int32(c.NodeId),
+		ReverseExecution:        c.ReverseExecution,
+		ReverseStepInstructions: c.ReverseStepInstructions,
+		Watchpoints:             c.Watchpoints,
+		MpiInterception:         c.MPIInterception,
+		MultiThread:             c.MultiThread,
+		FunctionBreakpoints:     c.FunctionBreakpoints,
+		ConditionalBreakpoints:  c.ConditionalBreakpoints,
+		Goroutines:              c.Goroutines,
+		Language:                c.Language,
+	}
+}
+
+func NodeCapabilitiesOf(message *pb.NodeCapabilities) NodeCapabilities {
+	return NodeCapabilities{
+		NodeId:                  int(message.NodeId),
+		ReverseExecution:        message.ReverseExecution,
+		ReverseStepInstructions: message.ReverseStepInstructions,
+		Watchpoints:             message.Watchpoints,
+		MPIInterception:         message.MpiInterception,
+		MultiThread:             message.MultiThread,
+		FunctionBreakpoints:     message.FunctionBreakpoints,
+		ConditionalBreakpoints:  message.ConditionalBreakpoints,
+		Goroutines:              message.Goroutines,
+		Language:                message.Language,
+	}
+}
+
+func (c SessionCapabilities) Proto() *pb.SessionCapabilities {
+	message := &pb.SessionCapabilities{
+		Frontends:      c.Frontends,
+		GlobalRollback: c.GlobalRollback,
+		SessionExport:  c.SessionExport,
+		Nodes:          make(map[int32]*pb.NodeCapabilities, len(c.Nodes)),
+	}
+	for nodeId, capabilities := range c.Nodes {
+		message.Nodes[int32(nodeId)] = capabilities.Proto()
+	}
+	return message
+}
+
+func (f TargetFingerprint) Proto() *pb.TargetFingerprint {
+	return &pb.TargetFingerprint{
+		NodeId:            int32(f.NodeId),
+		Binary:            f.Binary,
+		BuildId:           f.BuildId,
+		Producer:          f.Producer,
+		MpiLibrary:        f.MPILibrary,
+		CheckpointBackend: f.CheckpointBackend,
+		DebuggerVersion:   f.DebuggerVersion,
+		Host:              f.Host,
+		StaleSources:      f.StaleSources,
+		VerifiedSources:   int32(f.VerifiedSources),
+	}
+}
+
+func TargetFingerprintOf(message *pb.TargetFingerprint) TargetFingerprint {
+	return TargetFingerprint{
+		NodeId:            int(message.NodeId),
+		Binary:            message.Binary,
+		BuildId:           message.BuildId,
+		Producer:          message.Producer,
+		MPILibrary:        message.MpiLibrary,
+		CheckpointBackend: message.CheckpointBackend,
+		DebuggerVersion:   message.DebuggerVersion,
+		Host:              message.Host,
+		StaleSources:      message.StaleSources,
+		VerifiedSources:   int(message.VerifiedSources),
+	}
+}
+
+func (f SourceFile) Proto() *pb.SourceFile {
+	return &pb.SourceFile{Path: f.Path, Contents: f.Contents, Checksum: f.Checksum, Mismatch: f.Mismatch}
+}
+
+func SourceFileOf(message *pb.SourceFile) SourceFile {
+	return SourceFile{Path: message.Path, Contents: message.Contents, Checksum: message.Checksum, Mismatch: message.Mismatch}
+}
+
+func (r SessionRequest) Proto() *pb.SessionRequest {
+	return &pb.SessionRequest{Args: r.Args, Owner: r.Owner}
+}
+
+func SessionRequestOf(message *pb.SessionRequest) SessionRequest {
+	return SessionRequest{Args: message.Args, Owner: message.Owner}
+}
+
+func (s SessionInfo) Proto() *pb.SessionInfo {
+	return &pb.SessionInfo{
+		Id:      int32(s.Id),
+		Owner:   s.Owner,
+		Args:    s.Args,
+		Port:    int32(s.Port),
+		Dir:     s.Dir,
+		Started: timestampOf(s.Started),
+		Exited:  s.Exited,
+	}
+}
+
+func SessionInfoOf(message *pb.SessionInfo) SessionInfo {
+	return SessionInfo{
+		Id:      int(message.Id),
+		Owner:   message.Owner,
+		Args:    message.Args,
+		Port:    int(message.Port),
+		Dir:     message.Dir,
+		Started: timeOf(message.Started),
+		Exited:  message.Exited,
+	}
+}
+
+func (o ConsoleOutput) Proto() *pb.ConsoleOutput {
+	return &pb.ConsoleOutput{Text: o.Text, Offset: int64(o.Offset)}
+}
+
+func (e NodeEvent) Proto() *pb.NodeEvent {
+	return &pb.NodeEvent{
+		NodeId:   int32(e.NodeId),
+		Kind:     e.Kind,
+		Time:     timestampOf(e.Time),
+		Location: e.Location,
+		Detail:   e.Detail,
+	}
+}
+
+func NodeEventOf(message *pb.NodeEvent) NodeEvent {
+	return NodeEvent{
+		NodeId:   int(message.NodeId),
+		Kind:     message.Kind,
+		Time:     timeOf(message.Time),
+		Location: message.Location,
+		Detail:   message.Detail,
+	}
+}
@@ -1,27 +1,45 @@
 package rpc
 
 import (
+	"context"
 	"fmt"
 	"net"
-	"net/http"
-	"net/rpc"
 
 	"github.com/ottmartens/cc-rev-db/logger"
+	"github.com/ottmartens/cc-rev-db/rpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
-type Registrator func(any) error
+// Version of the services and messages of control.proto, raised on incompatible changes to them.
+// Clients compare it with the version of the server on connecting
+const PROTOCOL_VERSION = 2
+
+// size of the messages sent and received, above the default of grpc: source files and the payloads of large
+// MPI messages take more
+const MAX_MESSAGE_SIZE = 1 << 30
+
+// Serves the components on the host and port over grpc, registered on the server by the function.
+// Only clients giving the session token are served, if one is set
+func InitializeServer(host string, port int, registerComponents func(server grpc.ServiceRegistrar)) {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(requireTokenUnary),
+		grpc.StreamInterceptor(requireTokenStream),
+		grpc.MaxRecvMsgSize(MAX_MESSAGE_SIZE),
+		grpc.MaxSendMsgSize(MAX_MESSAGE_SIZE),
+	)
 
-func InitializeServer(port int, registerComponents func(Registrator)) {
 	// register components
-	registerComponents(rpc.Register)
+	registerComponents(server)
 
 	// register heartbeat
-	rpc.Register(new(Health))
+	pb.RegisterHealthServer(server, Health{})
 
-	//serve
-	rpc.HandleHTTP()
+	// front-ends and tools such as grpcurl list the services without the proto file
+	reflection.Register(server)
 
-	serverAddress := fmt.Sprintf("localhost:%d", port)
+	serverAddress := net.JoinHostPort(host, fmt.Sprint(port))
 
 	listener, err := net.Listen("tcp", serverAddress)
 	if err != nil {
@@ -31,11 +49,17 @@ func InitializeServer(port int, registerComponents func(Registrator)) {
 		logger.Verbose("rpc server listening on address: %v", serverAddress)
 	}
 
-	http.Serve(listener, nil)
+	server.Serve(listener)
 }
 
-type Health int
+type Health struct {
+	pb.UnimplementedHealthServer
+}
+
+func (h Health) Heartbeat(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
 
-func (h *Health) Heartbeat(args *int, reply *int) error {
-	return nil
+func (h Health) Version(context.Context, *emptypb.Empty) (*pb.ProtocolVersion, error) {
+	return &pb.ProtocolVersion{Version: PROTOCOL_VERSION}, nil
 }
@@ -0,0 +1,105 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Environment variable the session token is passed to the nodes and the remote console in,
+// as the command lines of processes can be read by other users of the host
+const TOKEN_ENV = "CC_REV_DB_TOKEN"
+
+// Header http clients not passing the session token in the url give it in
+const TOKEN_HEADER = "X-Session-Token"
+
+// Metadata grpc clients give the session token in with each call
+const TOKEN_METADATA = "x-session-token"
+
+// Token shared by the orchestrator and its nodes, the rpc servers only serve clients giving it (empty - none needed)
+var sessionToken string
+
+func SetSessionToken(token string) {
+	sessionToken = token
+}
+
+func SessionToken() string {
+	return sessionToken
+}
+
+func NewSessionToken() string {
+	bytes := make([]byte, 16)
+
+	_, err := rand.Read(bytes)
+	if err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(bytes)
+}
+
+// Wraps an http handler to refuse requests not giving the session token, in the token parameter of the url
+// or the token header, if the session has one
+func RequireToken(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		token := request.URL.Query().Get("token")
+		if len(token) == 0 {
+			token = request.Header.Get(TOKEN_HEADER)
+		}
+
+		if !validToken(token) {
+			http.Error(writer, "the session token is missing or wrong", http.StatusUnauthorized)
+			return
+		}
+
+		handler(writer, request)
+	}
+}
+
+// Compares a token given by a client with the session token in constant time, so that the time taken to refuse it
+// does not tell how much of it matched
+func validToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(sessionToken)) == 1
+}
+
+// Refuses the calls not giving the session token in their metadata, if the session has one
+func checkToken(ctx context.Context) error {
+	if len(sessionToken) == 0 {
+		return nil
+	}
+
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	if tokens := incoming.Get(TOKEN_METADATA); len(tokens) != 1 || !validToken(tokens[0]) {
+		return status.Error(codes.Unauthenticated, "the session token is missing or wrong")
+	}
+	return nil
+}
+
+func requireTokenUnary(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := checkToken(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, request)
+}
+
+func requireTokenStream(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := checkToken(stream.Context()); err != nil {
+		return err
+	}
+	return handler(server, stream)
+}
+
+// Adds the session token to the metadata of an outgoing call, if the session has one
+func withToken(ctx context.Context) context.Context {
+	if len(sessionToken) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, TOKEN_METADATA, sessionToken)
+}
@@ -1,8 +1,188 @@
 package rpc
 
+import "time"
+
 type MPICallRecord struct {
-	Id         string
-	OpName     string
-	Parameters map[string]string
+	Id               string
+	OpName           string
+	Parameters       map[string]string
+	NodeId           int
+	InstructionCount uint64    // instructions retired by the target before the call (0 if unavailable)
+	Payload          []byte    // contents of the message buffer of send operations, up to the payload cap of the node
+	PayloadSize      int64     // bytes of the whole message of send operations
+	PayloadRestHash  uint64    // FNV-1a hash of the bytes of the message beyond the payload (0 if not truncated)
+	Location         string    // source location the operation was called from (file:line, empty if unknown)
+	Time             time.Time // wall clock time of the call on the node
+	CheckpointBytes  uint64    // bytes taken up by the checkpoint recorded at the call
+
+	BufferHashes map[string]uint64 // hashes of the buffers selected with hash auto, by their variable or address
+	VectorClock  []int             // vector clock of the node after the call was counted, by rank (nil if not kept)
+}
+
+// A node debugger announcing itself to the orchestrator
+type NodeRegistration struct {
+	Pid  int
+	Rank int    // MPI rank the launcher gave the node in its environment, -1 if unknown
+	Host string // address the node listens on for the orchestrator
+}
+
+// A node reconnecting to the orchestrator after its connection dropped, or to an orchestrator restarted with
+// --rejoin, with the state of the node the orchestrator resynchronizes to
+type NodeResync struct {
+	Registration NodeRegistration
+	RegisteredId int      // id the node registered with, it listens on the port of and reports by it
+	NodeId       int      // id the orchestrator knows the node by, differs from the registered id once renumbered
+	Checkpoints  []string // ids of the checkpoints the node holds, in the order recorded
+	Breakpoints  []string // source locations of the breakpoints set on the node
+	Running      bool     // a forward progress command is running the target
+	Detached     bool     // the console is detached from the node
+}
+
+// The answer of the orchestrator to a resync
+type ResyncReply struct {
+	MissingCheckpoints []string // checkpoints of the node the orchestrator has no records of, for the node to report again
+	Rejoined           bool     // the orchestrator did not know the node, the node reports its layout, capabilities and fingerprint again
+}
+
+// A node passing a line marked for a causal breakpoint
+type LinePass struct {
+	NodeId   int
+	Location string // the marked line, as given to the node
+	Clock    int    // entry of the rank of the node in its vector clock, the MPI events counted before the line
+}
+
+// A message a node watched by causal breakpoints received, for the orchestrator to check against the line passes
+type CausalReceive struct {
+	NodeId      int
+	Source      int // rank of the sender
+	SenderClock int // entry of the sender in the vector clock piggybacked on the message, its events up to the send
+}
+
+// A message received by a node, replayed from the message log of the orchestrator when the node is rolled back alone
+type LoggedMessage struct {
+	ReceiveId string // the receive event the message was originally received at
+	Source    int
+	Tag       int
+	Count     int // elements of the message
+	Payload   []byte
+}
+
+type MemoryLayoutRecord struct {
+	NodeId       int
+	AslrDisabled bool
+	Regions      []MemoryRegion
+}
+
+type MemoryRegion struct {
+	Start uint64
+	End   uint64
+	Ident string
+}
+
+type ResourceUsageRecord struct {
 	NodeId     int
+	Timestamp  time.Time
+	CPUTime    time.Duration // user and system time consumed by the target
+	RSS        uint64        // resident set size in bytes
+	Swap       uint64        // swapped out memory in bytes
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+// Features supported by a node debugger for its target, for front-ends to adapt to
+type NodeCapabilities struct {
+	NodeId                  int    `json:"-"`
+	ReverseExecution        bool   `json:"reverseExecution"`        // restoring checkpoints recorded at MPI calls
+	ReverseStepInstructions bool   `json:"reverseStepInstructions"` // stepping back by instructions and lines, reverse-continue (needs hardware counters)
+	Watchpoints             bool   `json:"watchpoints"`
+	MPIInterception         string `json:"mpiInterception"` // how MPI calls are intercepted: compiled, preloaded or none
+	MultiThread             bool   `json:"multiThread"`     // debugging the threads of a target individually
+	FunctionBreakpoints     bool   `json:"functionBreakpoints"`
+	ConditionalBreakpoints  bool   `json:"conditionalBreakpoints"`
+	Goroutines              bool   `json:"goroutines"` // listing goroutines (go targets)
+	Language                string `json:"language"`   // source language of the target's main function
+}
+
+// Features of a debugging session: the front-ends served by the orchestrator and the capabilities of each node
+type SessionCapabilities struct {
+	Frontends      []string                 `json:"frontends"`
+	GlobalRollback bool                     `json:"globalRollback"` // causally consistent rollback of all nodes
+	SessionExport  bool                     `json:"sessionExport"`
+	Nodes          map[int]NodeCapabilities `json:"nodes"`
+}
+
+// Identifies the binary a node debugs and how, for reproducing a session
+type TargetFingerprint struct {
+	NodeId            int
+	Binary            string // absolute path of the target
+	BuildId           string // .note.gnu.build-id of the target, empty if absent
+	Producer          string // compiler of the main function (DW_AT_producer)
+	MPILibrary        string // MPI library the target is linked against, empty if statically linked
+	CheckpointBackend string // file or fork
+	DebuggerVersion   string
+	Host              string // host name of the machine the node runs on
+
+	StaleSources    []string // source files on the host of the node not matching the checksums of the debug info
+	VerifiedSources int      // source files compared with the checksums of the debug info (0 without checksums)
+}
+
+// A source file of the target, read by a node for listing it on the console
+type SourceFile struct {
+	Path     string // path of the file on the host of the node
+	Contents []byte
+	Checksum string // MD5 of the file recorded in the line tables, in hex (empty if none)
+	Mismatch bool   // whether the contents do not have the recorded checksum, the file is not the one compiled
+}
+
+// Identifies a debugging session: printed on start and stored in exported sessions
+type SessionFingerprint struct {
+	Target            string
+	Ranks             int
+	MPIImplementation string // first line of `mpirun --version` (or of the version of another launcher)
+	DebuggerVersion   string
+	Started           time.Time
+	Nodes             map[int]TargetFingerprint
+}
+
+// A session to start on the session service, by the arguments of its orchestrator
+type SessionRequest struct {
+	Args  []string // as given to the orchestrator, with the paths made absolute
+	Owner string   // user starting the session
+}
+
+// A session of the session service
+type SessionInfo struct {
+	Id      int
+	Owner   string
+	Args    []string
+	Port    int    // port of the orchestrator of the session, for the remote console
+	Dir     string // directory of the console log, the checkpoints and the files written by the session
+	Started time.Time
+	Exited  bool
+}
+
+// Output of the orchestrator console, for remote clients
+type ConsoleOutput struct {
+	Text   string // output from the requested offset on
+	Offset int    // offset of the end of the output, to request the next output from
+}
+
+// Kinds of the events of a node, as shown by front-ends
+const (
+	EVENT_BREAKPOINT = "breakpoint" // stopped at a user breakpoint
+	EVENT_SIGNAL     = "signal"     // the target received a signal
+	EVENT_OUTPUT     = "output"     // the target wrote a line matching a catch output pattern
+	EVENT_MPI        = "mpi"        // the target made an MPI call, a checkpoint was taken at it
+	EVENT_RUNNING    = "running"    // a forward progress command started running the target
+	EVENT_STOPPED    = "stopped"    // a command stopped the target, other than at a breakpoint
+	EVENT_EXITED     = "exited"
+)
+
+// Something that happened on a node, streamed to front-ends as it happens
+type NodeEvent struct {
+	NodeId   int       `json:"node"`
+	Kind     string    `json:"kind"`
+	Time     time.Time `json:"time"`
+	Location string    `json:"location,omitempty"` // source location of the target (file:line, empty if unknown)
+	Detail   string    `json:"detail,omitempty"`   // the signal, the output line, the MPI operation and its checkpoint
 }
@@ -7,13 +7,22 @@ type Command struct {
 	Code     CommandCode
 	Argument interface{}
 	Result   *CommandResult
+	Targets  []int // nodes a command prefixed with a target selector runs on at once (nil - the node of NodeId)
+	JSON     bool  // the result is printed as json, the command was suffixed with -json
 }
 
 type CommandCode int
 
 type CommandResult struct {
-	Error  string
-	Exited bool
+	Error       string
+	ErrorKind   string // kind of the error (utils.ErrorKind), empty if the error is of no known kind
+	Exited      bool
+	Breakpoint  bool    // the command stopped at a user breakpoint
+	Interrupted bool    // the command was interrupted by the orchestrator, as another node stopped (all-stop)
+	Location    string  // source location the target stopped at after a progress command (file:line, empty if unknown)
+	Backtrace   string  // call stack of the target after a progress command
+	Output      string  // what a query command printed (print, hash, info iteration), compared across nodes (empty if none)
+	Values      []Value // the variable or the children of a value a Variables command expanded
 }
 
 const (
@@ -23,6 +32,27 @@ const (
 	// Global commands - executed on orchestrator
 	ListCheckpoints
 	GlobalRollback
+	ExportSession
+	Status
+	Capabilities
+	InspectMessage
+	ReorderMessages
+	GlobalCheckpoint
+	Explore
+	Invariant
+	NameCheckpoint
+	PruneCheckpoints
+	AutoCheckpoint
+	HashHistory
+	NodeRollback
+	RunReport
+	EmitReproducer
+	CheckMessages
+	CausalBreakpoint
+	AllStop
+	NodeGroup
+	WhereTree
+	ReverseWindow
 
 	// Node-specific commands - executed on designated node
 	Bpoint
@@ -31,31 +61,175 @@ const (
 	Restore
 	Print
 	PrintInternal
+	ReverseStepInstructions
+	ListGoroutines
+	GoroutineBacktrace
+	InjectFault
+	ForceReceiveSource
+	Snapshot
+	CheckInvariant
+	ReleaseCheckpoints
+	PayloadCap
+	HashBuffer
+	AutoHashBuffer
+	ReplayMessageLog
+	TrapNaN
+	ReverseContinue
+	InfoRegisters
+	FPEnvironment
+	ReverseStep
+	ReverseNext
+	CatchOutput
+	LastWrite
+	ListSource
+	CausalMark
+	CausalWatch
+	ListJITRegions
+	BreakIteration
+	InfoIteration
+	Backtrace
+	Detach
+	Attach
+	Variables
+	BreakpointCommands
+	Tracepoint
+	WhatIs
+	PType
+	Display
+	Undisplay
+	Until
+	StepInstructions
+	Skip
 )
 
-func (c Command) String() string {
-	codeStr := map[CommandCode]string{
-		Bpoint:          "breakpoint",
-		SingleStep:      "single-step",
-		Cont:            "continue",
-		Restore:         "restore",
-		Print:           "print",
-		Help:            "help",
-		PrintInternal:   "print-internal",
-		ListCheckpoints: "list-checkpoints",
+// NodeId of commands executed on every node
+const AllNodes = -1
+
+// Name of the command, e.g. "breakpoint"
+func (c Command) Name() string {
+	return map[CommandCode]string{
+		Bpoint:           "breakpoint",
+		SingleStep:       "single-step",
+		Cont:             "continue",
+		Restore:          "restore",
+		Print:            "print",
+		Help:             "help",
+		PrintInternal:    "print-internal",
+		ListCheckpoints:  "list-checkpoints",
+		GlobalRollback:   "global-rollback",
+		ExportSession:    "export-session",
+		Status:           "status",
+		Capabilities:     "capabilities",
+		InspectMessage:   "inspect-message",
+		ReorderMessages:  "reorder-messages",
+		GlobalCheckpoint: "global-checkpoint",
+		Explore:          "explore",
+		Invariant:        "invariant",
+		NameCheckpoint:   "name-checkpoint",
+		PruneCheckpoints: "prune-checkpoints",
+		AutoCheckpoint:   "auto-checkpoint",
+		HashHistory:      "hash-history",
+		NodeRollback:     "node-rollback",
+		RunReport:        "run-report",
+		EmitReproducer:   "emit-reproducer",
+		CheckMessages:    "check-messages",
+		CausalBreakpoint: "causal-breakpoint",
+		AllStop:          "all-stop",
+		NodeGroup:        "node-group",
+		WhereTree:        "where-tree",
+		ReverseWindow:    "reverse-window",
+
+		ReverseStepInstructions: "reverse-stepi",
+		ListGoroutines:          "list-goroutines",
+		GoroutineBacktrace:      "goroutine-backtrace",
+		InjectFault:             "inject-fault",
+		ForceReceiveSource:      "force-receive-source",
+		Snapshot:                "snapshot",
+		CheckInvariant:          "check-invariant",
+		ReleaseCheckpoints:      "release-checkpoints",
+		PayloadCap:              "payload-cap",
+		HashBuffer:              "hash-buffer",
+		AutoHashBuffer:          "auto-hash-buffer",
+		ReplayMessageLog:        "replay-message-log",
+		TrapNaN:                 "trap-nan",
+		ReverseContinue:         "reverse-continue",
+		InfoRegisters:           "info-registers",
+		FPEnvironment:           "fp-environment",
+		ReverseStep:             "reverse-step",
+		ReverseNext:             "reverse-next",
+		CatchOutput:             "catch-output",
+		LastWrite:               "last-write",
+		ListSource:              "list-source",
+		CausalMark:              "causal-mark",
+		CausalWatch:             "causal-watch",
+		ListJITRegions:          "list-jit-regions",
+		BreakIteration:          "break-iteration",
+		InfoIteration:           "info-iteration",
+		Backtrace:               "backtrace",
+		Detach:                  "detach",
+		Attach:                  "attach",
+		Variables:               "variables",
+		BreakpointCommands:      "breakpoint-commands",
+		Tracepoint:              "tracepoint",
+		WhatIs:                  "whatis",
+		PType:                   "ptype",
+		Display:                 "display",
+		Undisplay:               "undisplay",
+		Until:                   "until",
+		StepInstructions:        "step-instructions",
+		Skip:                    "skip",
 	}[c.Code]
+}
 
+func (c Command) String() string {
 	if c.Argument == nil {
-		return fmt.Sprintf("{%v}", codeStr)
+		return fmt.Sprintf("{%v}", c.Name())
 	} else {
-		return fmt.Sprintf("{%v,%v}", codeStr, c.Argument)
+		return fmt.Sprintf("{%v,%v}", c.Name(), c.Argument)
 	}
 }
 
+// commands whose argument is an integer, the others take a string or none
+var numberArguments = map[CommandCode]bool{
+	StepInstructions:        true,
+	ReverseStepInstructions: true,
+	GoroutineBacktrace:      true,
+}
+
+// Sets the argument of a command received over rpc, which comes as either a number or a text: the line of a
+// breakpoint and the counts and ids of stepi, rsi and goroutine are numbers, locations, expressions and checkpoint
+// ids text. Fails if the command is given none of the type it takes
+func (cmd *Command) SetTypedArgument(number *int, text *string) error {
+	switch {
+	case number != nil && text != nil:
+		return fmt.Errorf("command %d is given both a number and a text argument", cmd.Code)
+	case number != nil && (numberArguments[cmd.Code] || cmd.Code == Bpoint):
+		cmd.Argument = *number
+	case number != nil:
+		return fmt.Errorf("command %d takes no number argument", cmd.Code)
+	case text != nil && numberArguments[cmd.Code]:
+		return fmt.Errorf("command %d takes a number argument", cmd.Code)
+	case text != nil:
+		cmd.Argument = *text
+	case numberArguments[cmd.Code] || cmd.Code == Bpoint:
+		return fmt.Errorf("command %d takes an argument", cmd.Code)
+	default:
+		cmd.Argument = nil
+	}
+
+	return nil
+}
+
+// Commands running the target forward, a detached target runs until it is reattached
 func (cmd *Command) IsForwardProgressCommand() bool {
-	return cmd.Code == SingleStep || cmd.Code == Cont
+	return cmd.Code == SingleStep || cmd.Code == Cont || cmd.Code == Detach || cmd.Code == Until || cmd.Code == StepInstructions
 }
 
 func (cmd *Command) IsProgressCommand() bool {
-	return cmd.IsForwardProgressCommand() || cmd.Code == Restore
+	return cmd.IsForwardProgressCommand() || cmd.IsReverseProgressCommand() || cmd.Code == Restore
+}
+
+// Commands moving the target back within the interval since its last checkpoint
+func (cmd *Command) IsReverseProgressCommand() bool {
+	return cmd.Code == ReverseStepInstructions || cmd.Code == ReverseContinue || cmd.Code == ReverseStep || cmd.Code == ReverseNext || cmd.Code == LastWrite
 }
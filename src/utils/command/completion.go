@@ -0,0 +1,105 @@
+package command
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kinds of the arguments of commands, completed from the debug info of the target
+const (
+	ARGUMENT_LOCATION = "location" // a function, or a source file followed by :<line>
+	ARGUMENT_VARIABLE = "variable"
+)
+
+// The node commands as typed, by their words, with the kind of their first argument (empty if it is not completed)
+var NodeCommands = map[string]string{
+	"b":                ARGUMENT_LOCATION,
+	"break":            ARGUMENT_LOCATION,
+	"break-iter":       ARGUMENT_LOCATION,
+	"commands":         ARGUMENT_LOCATION,
+	"trace":            ARGUMENT_LOCATION,
+	"until":            ARGUMENT_LOCATION,
+	"info iteration":   "",
+	"info goroutines":  "",
+	"info jit":         "",
+	"info registers":   "",
+	"c":                "",
+	"cont":             "",
+	"continue":         "",
+	"s":                "",
+	"step":             "",
+	"stepi":            "",
+	"si":               "",
+	"skip":             "",
+	"rsi":              "",
+	"rc":               "",
+	"reverse-continue": "",
+	"rs":               "",
+	"reverse-step":     "",
+	"rn":               "",
+	"reverse-next":     "",
+	"lastwrite":        ARGUMENT_VARIABLE,
+	"p":                ARGUMENT_VARIABLE,
+	"print":            ARGUMENT_VARIABLE,
+	"vars":             ARGUMENT_VARIABLE,
+	"whatis":           ARGUMENT_VARIABLE,
+	"ptype":            ARGUMENT_VARIABLE,
+	"display":          ARGUMENT_VARIABLE,
+	"undisplay":        "",
+	"bt":               "",
+	"backtrace":        "",
+	"goroutine":        "",
+	"inject":           "",
+	"hash":             ARGUMENT_VARIABLE,
+	"hash auto":        ARGUMENT_VARIABLE,
+	"payload cap":      "",
+	"fp":               "",
+	"nan trap":         "",
+	"catch output":     "",
+	"pd":               "",
+}
+
+// Completes the last word of the input: a word of the commands, or the first argument of a command, completed
+// by its kind with completeArgument. Commands are matched case-insensitively. Returns the candidates for the whole
+// word, sorted
+func CompleteWord(input string, commands map[string]string, completeArgument func(kind string, prefix string) []string) []string {
+	words := strings.Split(input, " ")
+	typed, current := words[:len(words)-1], words[len(words)-1]
+
+	candidates := make(map[string]bool)
+
+	for phrase, kind := range commands {
+		phraseWords := strings.Fields(phrase)
+
+		switch {
+		case len(typed) < len(phraseWords) && wordsMatch(typed, phraseWords):
+			if next := phraseWords[len(typed)]; strings.HasPrefix(next, strings.ToLower(current)) {
+				candidates[next] = true
+			}
+
+		case len(typed) == len(phraseWords) && wordsMatch(typed, phraseWords) && len(kind) > 0 && completeArgument != nil:
+			for _, candidate := range completeArgument(kind, current) {
+				candidates[candidate] = true
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(candidates))
+	for candidate := range candidates {
+		sorted = append(sorted, candidate)
+	}
+
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Whether the typed words are the first words of a command
+func wordsMatch(typed []string, phraseWords []string) bool {
+	for index, word := range typed {
+		if !strings.EqualFold(word, phraseWords[index]) {
+			return false
+		}
+	}
+
+	return true
+}
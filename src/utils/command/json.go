@@ -0,0 +1,39 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Suffix of an input line asking for the result of the command as json, e.g. `1 bt -json`
+const JSON_FLAG = "-json"
+
+// The result of a command in json output mode, printed as one object per line
+type JSONResult struct {
+	Node     int            `json:"node"`
+	Command  string         `json:"command"`
+	Argument any            `json:"argument,omitempty"`
+	Result   *CommandResult `json:"result"` // nil if the node gave no result
+}
+
+// Removes the json flag from the end of an input line, returning whether it was given
+func CutJSONFlag(input string) (string, bool) {
+	trimmed := strings.TrimSpace(input)
+
+	if trimmed != JSON_FLAG && !strings.HasSuffix(trimmed, " "+JSON_FLAG) {
+		return input, false
+	}
+
+	return strings.TrimSpace(strings.TrimSuffix(trimmed, JSON_FLAG)), true
+}
+
+// Renders the result of a command as a line of json
+func FormatJSON(cmd *Command) string {
+	encoded, err := json.Marshal(JSONResult{Node: cmd.NodeId, Command: cmd.Name(), Argument: cmd.Argument, Result: cmd.Result})
+	if err != nil {
+		return fmt.Sprintf(`{"node": %d, "error": %q}`, cmd.NodeId, err.Error())
+	}
+
+	return string(encoded)
+}
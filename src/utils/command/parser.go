@@ -0,0 +1,154 @@
+package command
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parses a command executed by a node debugger: typed at a node debugger in cli mode, or following the node id
+// or target selector at the orchestrator. Only the command itself is case-insensitive, identifiers are not.
+// Returns nil if the input is no such command
+func ParseNodeCommand(input string) *Command {
+	if command, arguments, found := strings.Cut(input, " "); found {
+		input = strings.ToLower(command) + " " + arguments
+	} else {
+		input = strings.ToLower(input)
+	}
+
+	pieces := strings.Split(input, " ")
+
+	// the arguments following a command of several words, e.g. "catch output"
+	argumentsOf := func(command string) string {
+		return strings.TrimPrefix(input, command)
+	}
+
+	switch {
+	case matches(input, `(b|break) \d+`): // breakpoint
+		lineNr, _ := strconv.Atoi(pieces[1])
+
+		return &Command{Code: Bpoint, Argument: lineNr}
+
+	case matches(input, `(b|break) [a-zA-Z_~].*`), matches(input, `(b|break) \S+ if .+`): // breakpoint at a function, conditional breakpoint
+		return &Command{Code: Bpoint, Argument: strings.SplitN(input, " ", 2)[1]}
+
+	case matches(input, `break-iter (\S+:)?\d+ \d+`): // breakpoint at an iteration of a loop
+		return &Command{Code: BreakIteration, Argument: argumentsOf("break-iter ")}
+
+	case matches(input, `commands \S+( .+)?`): // commands executed whenever a breakpoint stops the target
+		return &Command{Code: BreakpointCommands, Argument: argumentsOf("commands ")}
+
+	case matches(input, `trace \S+ ".*`): // log a formatted line whenever the target passes a location
+		return &Command{Code: Tracepoint, Argument: argumentsOf("trace ")}
+
+	case input == "info iteration": // executions of the current line, counted by its breakpoint
+		return &Command{Code: InfoIteration}
+
+	case matches(input, `(c|cont|continue)`):
+		return &Command{Code: Cont}
+
+	case matches(input, `(u|until) \S+`): // continue to a location, with a temporary breakpoint there
+		return &Command{Code: Until, Argument: pieces[1]}
+
+	case matches(input, `(s|step)`):
+		return &Command{Code: SingleStep}
+
+	case matches(input, `(si|stepi)( \d+)?`): // step instructions, printing the instruction stopped at
+		count := 1
+		if len(pieces) > 1 {
+			count, _ = strconv.Atoi(pieces[1])
+		}
+
+		return &Command{Code: StepInstructions, Argument: count}
+
+	case matches(input, `skip( -file \S+| -function \S+| clear)?`): // functions and files stepping does not descend into
+		return &Command{Code: Skip, Argument: argumentsOf("skip")}
+
+	case matches(input, `rsi( \d+)?`): // reverse-step instructions
+		count := 1
+		if len(pieces) > 1 {
+			count, _ = strconv.Atoi(pieces[1])
+		}
+
+		return &Command{Code: ReverseStepInstructions, Argument: count}
+
+	case matches(input, `(rc|reverse-continue)`): // continue back to the last breakpoint hit
+		return &Command{Code: ReverseContinue}
+
+	case matches(input, `(rs|reverse-step)`): // step back to the previous source line
+		return &Command{Code: ReverseStep}
+
+	case matches(input, `(rn|reverse-next)`): // step back to the previous source line, over calls
+		return &Command{Code: ReverseNext}
+
+	case matches(input, `lastwrite .+`): // step back to the last write to a variable
+		return &Command{Code: LastWrite, Argument: argumentsOf("lastwrite ")}
+
+	case matches(input, `(p|print)(/[a-z])? .+`): // print the value of an expression, optionally in a format (p/x)
+		return &Command{Code: Print, Argument: strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(input, "print"), "p"))}
+
+	case matches(input, `display(/[a-z])?( .+)?`): // print an expression each time the target stops
+		return &Command{Code: Display, Argument: strings.TrimSpace(strings.TrimPrefix(input, "display"))}
+
+	case matches(input, `undisplay( \d+)*`):
+		return &Command{Code: Undisplay, Argument: argumentsOf("undisplay")}
+
+	case matches(input, `whatis .+`): // print the declared type of an expression
+		return &Command{Code: WhatIs, Argument: argumentsOf("whatis ")}
+
+	case matches(input, `ptype .+`): // print the type of an expression or a type name, with the layout of its members
+		return &Command{Code: PType, Argument: argumentsOf("ptype ")}
+
+	case matches(input, `vars (#\d+( \d+){0,2}|[^#].*)`): // expand a value level by level
+		return &Command{Code: Variables, Argument: argumentsOf("vars ")}
+
+	case input == "info goroutines": // list goroutines of a go target
+		return &Command{Code: ListGoroutines}
+
+	case matches(input, `(bt|backtrace)`): // call stack
+		return &Command{Code: Backtrace}
+
+	case matches(input, `goroutine \d+ bt`): // call stack of a goroutine
+		goroutineId, _ := strconv.Atoi(pieces[1])
+
+		return &Command{Code: GoroutineBacktrace, Argument: goroutineId}
+
+	case input == "info jit": // functions generated at runtime, named in backtraces
+		return &Command{Code: ListJITRegions}
+
+	case input == "info registers": // general purpose and floating-point control registers
+		return &Command{Code: InfoRegisters}
+
+	case matches(input, `inject( .+)?`): // fault injection
+		return &Command{Code: InjectFault, Argument: argumentsOf("inject")}
+
+	case matches(input, `hash auto( .+)?`): // buffers hashed at every checkpoint
+		return &Command{Code: AutoHashBuffer, Argument: argumentsOf("hash auto")}
+
+	case matches(input, `hash \S+( \S+)?`): // hash of a buffer
+		return &Command{Code: HashBuffer, Argument: argumentsOf("hash ")}
+
+	case matches(input, `payload cap( \S+)?`): // bytes of sent messages recorded
+		return &Command{Code: PayloadCap, Argument: argumentsOf("payload cap")}
+
+	case matches(input, `fp( .+)?`): // floating-point exception masks and rounding mode
+		return &Command{Code: FPEnvironment, Argument: argumentsOf("fp")}
+
+	case matches(input, `nan trap( on| off)?`): // stop at the instruction producing a NaN
+		return &Command{Code: TrapNaN, Argument: argumentsOf("nan trap")}
+
+	case matches(input, `catch output( .+)?`): // stop at output lines matching a pattern
+		return &Command{Code: CatchOutput, Argument: argumentsOf("catch output")}
+
+	case matches(input, `pd [a-zA-Z_][a-zA-Z0-9_]*`): // debug print
+		return &Command{Code: PrintInternal, Argument: pieces[1]}
+
+	default:
+		return nil
+	}
+}
+
+// Returns whether the whole input matches the expression
+func matches(input string, expression string) bool {
+	return regexp.MustCompile("^" + expression + "$").MatchString(input)
+}
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"os"
+	"strings"
+)
+
+// Reads the commands of a script file, a command per line. Blank lines and lines starting with # are skipped
+func ReadScript(filePath string) ([]string, error) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0)
+
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+
+		if len(line) > 0 && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,166 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Target selectors run a command on several nodes: `@2 p x`, `@1-4 b 30`, `@0,2,5-7 c`, `@all c` or `@<group> c`.
+// A selector is a comma-separated list of node ids, ranges of them and named groups of nodes
+
+const SELECTOR_PREFIX = "@"
+
+// selects every node
+const SELECTOR_ALL = "all"
+
+// named groups of nodes, by the group name
+var groups = make(map[string][]int)
+
+// Splits an input line into its target selector (without the @) and the command following it.
+// Returns false if the line is not prefixed with a selector
+func SplitSelector(input string) (selector string, rest string, ok bool) {
+	if !strings.HasPrefix(input, SELECTOR_PREFIX) {
+		return "", input, false
+	}
+
+	selector, rest, _ = strings.Cut(strings.TrimPrefix(input, SELECTOR_PREFIX), " ")
+
+	return selector, strings.TrimSpace(rest), true
+}
+
+// Returns the ids of the nodes a selector picks, in ascending order. Every id must be one of nodeIds
+func SelectNodes(selector string, nodeIds []int) ([]int, error) {
+	registered := make(map[int]bool, len(nodeIds))
+	for _, nodeId := range nodeIds {
+		registered[nodeId] = true
+	}
+
+	selected := make(map[int]bool)
+
+	for _, element := range strings.Split(selector, ",") {
+		switch {
+		case element == SELECTOR_ALL:
+			for _, nodeId := range nodeIds {
+				selected[nodeId] = true
+			}
+
+		case len(groups[element]) > 0:
+			for _, nodeId := range groups[element] {
+				selected[nodeId] = true
+			}
+
+		default:
+			first, last, err := parseRange(element)
+			if err != nil {
+				return nil, err
+			}
+
+			for nodeId := first; nodeId <= last; nodeId++ {
+				selected[nodeId] = true
+			}
+		}
+	}
+
+	selectedIds := make([]int, 0, len(selected))
+	for nodeId := range selected {
+		if !registered[nodeId] {
+			return nil, fmt.Errorf("no node with id %d", nodeId)
+		}
+
+		selectedIds = append(selectedIds, nodeId)
+	}
+
+	sort.Ints(selectedIds)
+
+	return selectedIds, nil
+}
+
+// Formats node ids as a selector, collapsing consecutive ids into ranges (0-3,6)
+func FormatSelector(nodeIds []int) string {
+	sorted := append([]int(nil), nodeIds...)
+	sort.Ints(sorted)
+
+	elements := make([]string, 0)
+
+	for start := 0; start < len(sorted); {
+		end := start
+		for end+1 < len(sorted) && sorted[end+1] == sorted[end]+1 {
+			end++
+		}
+
+		if end == start {
+			elements = append(elements, strconv.Itoa(sorted[start]))
+		} else {
+			elements = append(elements, fmt.Sprintf("%d-%d", sorted[start], sorted[end]))
+		}
+
+		start = end + 1
+	}
+
+	return strings.Join(elements, ",")
+}
+
+// Parses a node id or a range of them (<first>-<last>)
+func parseRange(element string) (first int, last int, err error) {
+	firstStr, lastStr, isRange := strings.Cut(element, "-")
+
+	first, err = strconv.Atoi(firstStr)
+	if err != nil || first < 0 {
+		return 0, 0, fmt.Errorf("%q is neither a node id, a range of node ids nor a group", element)
+	}
+
+	if !isRange {
+		return first, first, nil
+	}
+
+	last, err = strconv.Atoi(lastStr)
+	if err != nil || last < first {
+		return 0, 0, fmt.Errorf("invalid range of node ids %q", element)
+	}
+
+	return first, last, nil
+}
+
+// Names the nodes a selector picks, to refer to them as @<name>. A group defined earlier is replaced
+func DefineGroup(name string, selector string, nodeIds []int) error {
+	// names start with a letter, to tell them from node ids
+	if len(name) == 0 || !unicode.IsLetter(rune(name[0])) || name == SELECTOR_ALL || name == "clear" || strings.ContainsAny(name, ",@") {
+		return fmt.Errorf("invalid group name %q", name)
+	}
+
+	members, err := SelectNodes(selector, nodeIds)
+	if err != nil {
+		return err
+	}
+
+	groups[name] = members
+	return nil
+}
+
+// Removes a group, returns false if it was not defined
+func RemoveGroup(name string) bool {
+	_, defined := groups[name]
+	delete(groups, name)
+
+	return defined
+}
+
+// Returns the names of the groups in alphabetical order
+func GroupNames() []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Returns the ids of the nodes in a group
+func GroupMembers(name string) []int {
+	return groups[name]
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A value of a variable on a node, expanded one level at a time. Front-ends request the children of a value by its
+// handle, so the node reads structures, arrays and pointers from the target only as far as they are expanded
+type Value struct {
+	Name     string // name of the variable, or of the field, element ("[3]") or pointed-to value ("*p") in its parent
+	Type     string // type of the value, as declared
+	Summary  string // the value of a scalar, a shortened rendering of a structure, array or pointer
+	Handle   int    // handle the children of the value are requested by (0 if it has none)
+	Children int    // number of fields, elements or pointed-to values
+}
+
+func (v Value) String() string {
+	if v.Handle == 0 {
+		return fmt.Sprintf("%s (%s) = %s", v.Name, v.Type, v.Summary)
+	}
+	if v.Children == 1 {
+		return fmt.Sprintf("%s (%s) = %s  [#%d, 1 child]", v.Name, v.Type, v.Summary, v.Handle)
+	}
+	return fmt.Sprintf("%s (%s) = %s  [#%d, %d children]", v.Name, v.Type, v.Summary, v.Handle, v.Children)
+}
+
+// Argument of a Variables command expanding the children of a value, from the child at start on (count 0 - all)
+func ChildrenArgument(handle int, start int, count int) string {
+	return fmt.Sprintf("#%d %d %d", handle, start, count)
+}
+
+// Whether the argument of a Variables command names the handle of a value to expand, instead of a variable
+func IsChildrenArgument(argument string) bool {
+	return strings.HasPrefix(argument, "#")
+}
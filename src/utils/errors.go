@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"syscall"
+)
+
+// Errors the node debugger fails with, wrapped with the details of the failure (fmt.Errorf("%w: ...", ErrLineNotFound)).
+// Errors lose their type over rpc, front-ends tell them apart by their kind
+var (
+	ErrNoDebugInfo      = errors.New("no debug info")
+	ErrLineNotFound     = errors.New("line not found")
+	ErrProcessExited    = errors.New("process exited")
+	ErrAddressNotMapped = errors.New("address not mapped")
+	ErrOptimizedOut     = errors.New("optimized out")
+	ErrInternal         = errors.New("internal error")
+	ErrNoReply          = errors.New("no reply")
+)
+
+// advice shown along with errors of a kind
+var errorHints = map[error]string{
+	ErrNoDebugInfo:      "compile the target with -g, debug info is missing for this code",
+	ErrLineNotFound:     "no code was generated for the line, choose a line with a statement (optimizations may merge or remove lines)",
+	ErrProcessExited:    "the target has exited, roll it back to a checkpoint to continue inspecting it",
+	ErrAddressNotMapped: "the memory is not mapped in the target, the pointer is invalid or the memory was freed",
+	ErrOptimizedOut:     "the value is not kept by the optimized code here, compile the target with -O0",
+	ErrInternal:         "this is a bug in the debugger, the session continues; the stack of the failure is in the debug log",
+	ErrNoReply:          "the node did not answer in time, it may be hung; check it with status, roll it back or detach it",
+}
+
+// Returns the kind of an error: the message of the error it wraps, empty for errors of no known kind
+func ErrorKind(err error) string {
+	for knownError := range errorHints {
+		if errors.Is(err, knownError) {
+			return knownError.Error()
+		}
+	}
+	return ""
+}
+
+// Returns advice on resolving errors of the kind, empty for unknown kinds
+func ErrorHint(kind string) string {
+	for knownError, hint := range errorHints {
+		if knownError.Error() == kind {
+			return hint
+		}
+	}
+	return ""
+}
+
+// Returns the error a ptrace or wait request failed with as an error of a known kind, if the failure has one
+func PtraceError(err error) error {
+	switch {
+	case errors.Is(err, syscall.ESRCH), errors.Is(err, syscall.ECHILD):
+		return fmt.Errorf("%w: %v", ErrProcessExited, err)
+	case errors.Is(err, syscall.EIO), errors.Is(err, syscall.EFAULT):
+		return fmt.Errorf("%w: %v", ErrAddressNotMapped, err)
+	}
+	return err
+}
+
+// Recovers from a panic of the calling goroutine, passing it to the handler as an internal error
+// along with the stack it was raised at. Deferred by code that must outlive bugs in the debugger
+func RecoverPanic(handle func(err error, stack []byte)) {
+	if recovered := recover(); recovered != nil {
+		handle(fmt.Errorf("%w: %v", ErrInternal, recovered), debug.Stack())
+	}
+}
@@ -14,20 +14,104 @@ const (
 	OP_SEND
 	OP_RECV
 	OP_FINALIZE
+	OP_ISEND
+	OP_IRECV
+	OP_WAIT
+	OP_WAITALL
+	OP_TEST
+	OP_TEST_COMPLETED
+	OP_BARRIER
+	OP_BCAST
+	OP_REDUCE
+	OP_ALLREDUCE
+	OP_GATHER
+	OP_ALLGATHER
+	OP_SCATTER
 )
 
 var MPI_OPS = map[MPI_OPCODE]string{
-	OP_INIT:     "MPI_Init",
-	OP_SEND:     "MPI_Send",
-	OP_RECV:     "MPI_Recv",
-	OP_FINALIZE: "MPI_Finalize",
+	OP_INIT:           "MPI_Init",
+	OP_SEND:           "MPI_Send",
+	OP_RECV:           "MPI_Recv",
+	OP_FINALIZE:       "MPI_Finalize",
+	OP_ISEND:          "MPI_Isend",
+	OP_IRECV:          "MPI_Irecv",
+	OP_WAIT:           "MPI_Wait",
+	OP_WAITALL:        "MPI_Waitall",
+	OP_TEST:           "MPI_Test",
+	OP_TEST_COMPLETED: "MPI_Test_completed", // a test that found its request completed
+	OP_BARRIER:        "MPI_Barrier",
+	OP_BCAST:          "MPI_Bcast",
+	OP_REDUCE:         "MPI_Reduce",
+	OP_ALLREDUCE:      "MPI_Allreduce",
+	OP_GATHER:         "MPI_Gather",
+	OP_ALLGATHER:      "MPI_Allgather",
+	OP_SCATTER:        "MPI_Scatter",
 }
 
 var SEND_EVENTS = map[string]bool{
-	MPI_OPS[OP_SEND]: true,
+	MPI_OPS[OP_SEND]:  true,
+	MPI_OPS[OP_ISEND]: true,
 }
 
+var RECEIVE_EVENTS = map[string]bool{
+	MPI_OPS[OP_RECV]:  true,
+	MPI_OPS[OP_IRECV]: true,
+}
+
+// Event of the checkpoint a node records for a coordinated global checkpoint, at wherever the node is stopped.
+// Not an MPI operation, but it takes its place among the recorded events of the node
+const SNAPSHOT_EVENT = "Snapshot"
+
 var RESTORABLE_OPERATIONS = map[string]bool{
-	MPI_OPS[OP_SEND]: true,
-	MPI_OPS[OP_RECV]: true,
+	SNAPSHOT_EVENT:        true,
+	MPI_OPS[OP_SEND]:      true,
+	MPI_OPS[OP_RECV]:      true,
+	MPI_OPS[OP_ISEND]:     true,
+	MPI_OPS[OP_IRECV]:     true,
+	MPI_OPS[OP_BARRIER]:   true,
+	MPI_OPS[OP_BCAST]:     true,
+	MPI_OPS[OP_REDUCE]:    true,
+	MPI_OPS[OP_ALLREDUCE]: true,
+	MPI_OPS[OP_GATHER]:    true,
+	MPI_OPS[OP_ALLGATHER]: true,
+	MPI_OPS[OP_SCATTER]:   true,
+}
+
+// Operations all ranks of the communicator take part in
+var COLLECTIVE_OPERATIONS = map[string]bool{
+	MPI_OPS[OP_BARRIER]:   true,
+	MPI_OPS[OP_BCAST]:     true,
+	MPI_OPS[OP_REDUCE]:    true,
+	MPI_OPS[OP_ALLREDUCE]: true,
+	MPI_OPS[OP_GATHER]:    true,
+	MPI_OPS[OP_ALLGATHER]: true,
+	MPI_OPS[OP_SCATTER]:   true,
+}
+
+// Operations starting a request, completed by a later completion operation
+var NONBLOCKING_OPERATIONS = map[string]bool{
+	MPI_OPS[OP_ISEND]: true,
+	MPI_OPS[OP_IRECV]: true,
+}
+
+// Operations completing requests, whose events list the events of the completed requests
+var COMPLETION_OPERATIONS = map[string]bool{
+	MPI_OPS[OP_WAIT]:           true,
+	MPI_OPS[OP_WAITALL]:        true,
+	MPI_OPS[OP_TEST_COMPLETED]: true,
+}
+
+// Functions of the wrapper not recorded: tests, as they are called repeatedly while polling, and helpers of the wrapper
+var UNRECORDED_OPERATIONS = map[string]bool{
+	MPI_OPS[OP_TEST]:               true,
+	"MPI_WRAPPER_RECORD_DATATYPES": true,
+	"MPI_WRAPPER_RECORD_RECEIVE":   true,
+
+	// vector clock piggybacking of the wrapper
+	"MPI_WRAPPER_CLOCK_ENTRIES":         true,
+	"MPI_WRAPPER_SEND_CLOCK":            true,
+	"MPI_WRAPPER_RECEIVE_CLOCK":         true,
+	"MPI_WRAPPER_AWAIT_CLOCK":           true,
+	"MPI_WRAPPER_RECEIVE_REQUEST_CLOCK": true,
 }
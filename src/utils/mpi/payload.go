@@ -0,0 +1,61 @@
+package mpi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Predefined datatypes whose handles the wrapper records at MPI_Init, in the order of _MPI_WRAPPER_DATATYPES
+var PAYLOAD_DATATYPES = []string{
+	"MPI_CHAR",
+	"MPI_BYTE",
+	"MPI_SHORT",
+	"MPI_INT",
+	"MPI_LONG",
+	"MPI_LONG_LONG",
+	"MPI_UNSIGNED",
+	"MPI_FLOAT",
+	"MPI_DOUBLE",
+}
+
+// Returns the payload of a message as a list of values of its datatype, in hex for other datatypes
+func FormatPayload(datatype string, payload []byte) string {
+	if datatype == "MPI_CHAR" {
+		return strconv.Quote(string(payload))
+	}
+
+	elementSize, decode := payloadDecoder(datatype)
+	if decode == nil {
+		return fmt.Sprintf("% x", payload)
+	}
+
+	values := make([]string, 0, len(payload)/elementSize)
+
+	for offset := 0; offset+elementSize <= len(payload); offset += elementSize {
+		values = append(values, decode(payload[offset:offset+elementSize]))
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(values, ", "))
+}
+
+func payloadDecoder(datatype string) (elementSize int, decode func(data []byte) string) {
+	switch datatype {
+	case "MPI_SHORT":
+		return 2, func(data []byte) string { return fmt.Sprint(int16(binary.LittleEndian.Uint16(data))) }
+	case "MPI_INT":
+		return 4, func(data []byte) string { return fmt.Sprint(int32(binary.LittleEndian.Uint32(data))) }
+	case "MPI_UNSIGNED":
+		return 4, func(data []byte) string { return fmt.Sprint(binary.LittleEndian.Uint32(data)) }
+	case "MPI_LONG", "MPI_LONG_LONG":
+		return 8, func(data []byte) string { return fmt.Sprint(int64(binary.LittleEndian.Uint64(data))) }
+	case "MPI_FLOAT":
+		return 4, func(data []byte) string { return fmt.Sprint(math.Float32frombits(binary.LittleEndian.Uint32(data))) }
+	case "MPI_DOUBLE":
+		return 8, func(data []byte) string { return fmt.Sprint(math.Float64frombits(binary.LittleEndian.Uint64(data))) }
+	}
+
+	return 0, nil
+}
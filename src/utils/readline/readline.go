@@ -0,0 +1,617 @@
+package readline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unicode"
+)
+
+// Line editing of the consoles: moving in the line, arrow-key history, reverse search of the history (Ctrl-R),
+// completion of the word at the cursor (Tab) and the history kept in a file across sessions. Lines are edited in raw mode while the standard input is a
+// terminal; otherwise they are read as they are, so scripts and pipes work as before
+
+// lines of history kept, the oldest are dropped
+const HISTORY_SIZE = 1000
+
+// file in the home directory the history of the consoles is kept in
+const HISTORY_FILE_NAME = ".ccrevdb_history"
+
+// width the candidates of a completion are listed in
+const COMPLETION_LIST_WIDTH = 80
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyBackspace = 8
+	keyTab       = 9
+	keyNewline   = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// keys sent as escape sequences, numbered beyond the runes
+const (
+	keyUp = unicode.MaxRune + 1 + iota
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyForwardDelete
+	keyUnknown
+)
+
+type Editor struct {
+	sync.Mutex
+	input       *bufio.Reader
+	output      io.Writer
+	interactive bool // whether the input is a terminal, edited in raw mode
+
+	prompt  string
+	line    []rune
+	cursor  int  // position of the cursor in the line
+	reading bool // whether a line is being read
+
+	history     []string
+	historyFile string // empty if the history is not kept
+	browsed     int    // index of the history line shown while browsing with the arrow keys, len(history) for the new line
+	pending     []rune // the new line, while the history is browsed
+
+	search *historySearch // reverse search in progress, nil otherwise
+
+	completer func(line string) []string // candidates for the word before the cursor, nil if not completed
+	tabbed    bool                       // whether the previous key was a Tab completing nothing further
+
+	restore func() // gives the terminal back its mode before raw mode, nil if not in raw mode
+}
+
+// An incremental search back in the history (Ctrl-R)
+type historySearch struct {
+	query   []rune
+	match   int    // index of the matching history line, -1 if none
+	initial []rune // the line before the search, restored if it is cancelled
+}
+
+// Returns an editor of the lines of the standard input, written to output. The history is read from
+// and appended to the history file, unless it is empty
+func NewEditor(output io.Writer, historyFile string) *Editor {
+	editor := &Editor{
+		input:       bufio.NewReader(os.Stdin),
+		output:      output,
+		interactive: isTerminal(os.Stdin),
+		historyFile: historyFile,
+	}
+
+	editor.loadHistory()
+
+	return editor
+}
+
+// The history file in the home directory, shared by the consoles of the orchestrator and the node debugger.
+// Empty if there is no home directory
+func HistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, HISTORY_FILE_NAME)
+}
+
+// Whether lines are edited, the standard input being a terminal
+func (e *Editor) Interactive() bool {
+	return e.interactive
+}
+
+func (e *Editor) SetOutput(output io.Writer) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.output = output
+}
+
+// Changes the prompt. The line being read is drawn again with it; if the input is not a terminal, the prompt is
+// printed as is
+func (e *Editor) SetPrompt(prompt string) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.prompt = prompt
+
+	if !e.interactive {
+		fmt.Fprint(e.output, prompt)
+		return
+	}
+
+	if e.reading {
+		e.redraw()
+	}
+}
+
+// Sets the completion of the word before the cursor with Tab. The completer is given the line up to the cursor
+// and returns the candidates for its last word, whole
+func (e *Editor) SetCompleter(completer func(line string) []string) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.completer = completer
+}
+
+// Draws the prompt and the line being read again, after output overwrote them
+func (e *Editor) Redraw() {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.interactive && e.reading {
+		e.redraw()
+	}
+}
+
+// Gives the terminal back its mode, for exiting while a line is read
+func (e *Editor) Restore() {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.restore != nil {
+		e.restore()
+		e.restore = nil
+	}
+}
+
+// Reads a line, with the prompt set. Returns io.EOF once the input ends, or Ctrl-D is typed on an empty line
+func (e *Editor) ReadLine() (string, error) {
+	if !e.interactive {
+		line, err := e.input.ReadString('\n')
+		if err != nil && len(line) == 0 {
+			return "", err
+		}
+
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	e.Lock()
+	restore, err := makeRaw(os.Stdin)
+	if err != nil {
+		e.interactive = false
+		e.Unlock()
+		return e.ReadLine()
+	}
+
+	e.restore = restore
+	e.line, e.cursor, e.reading = e.line[:0], 0, true
+	e.browsed, e.pending = len(e.history), nil
+	e.redraw()
+	e.Unlock()
+
+	defer e.Restore()
+
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			e.finishLine()
+			return "", err
+		}
+
+		e.Lock()
+		line, done, err := e.handleKey(key)
+		e.Unlock()
+
+		if done {
+			return line, err
+		}
+	}
+}
+
+// Executes a key typed while a line is read. Returns the line once it is complete. The lock must be held
+func (e *Editor) handleKey(key rune) (line string, done bool, err error) {
+	if e.search != nil && e.handleSearchKey(key) {
+		return "", false, nil
+	}
+
+	tabbed := e.tabbed
+	e.tabbed = false
+
+	switch key {
+	case keyEnter, keyNewline:
+		line = string(e.line)
+		e.reading = false
+		fmt.Fprint(e.output, "\r\n")
+
+		// a prompt asks for one line
+		e.prompt = ""
+
+		e.addHistory(line)
+		return line, true, nil
+
+	case keyCtrlC:
+		// interrupts the program as the terminal would
+		e.reading = false
+		fmt.Fprint(e.output, "^C\r\n")
+		if e.restore != nil {
+			e.restore()
+			e.restore = nil
+		}
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+		return "", true, io.EOF
+
+	case keyCtrlD:
+		if len(e.line) == 0 {
+			e.reading = false
+			fmt.Fprint(e.output, "\r\n")
+			return "", true, io.EOF
+		}
+		e.deleteAt(e.cursor)
+
+	case keyBackspace, keyDelete:
+		if e.cursor > 0 {
+			e.cursor--
+			e.deleteAt(e.cursor)
+		}
+
+	case keyForwardDelete:
+		e.deleteAt(e.cursor)
+
+	case keyLeft, keyCtrlB:
+		if e.cursor > 0 {
+			e.cursor--
+		}
+
+	case keyRight, keyCtrlF:
+		if e.cursor < len(e.line) {
+			e.cursor++
+		}
+
+	case keyHome, keyCtrlA:
+		e.cursor = 0
+
+	case keyEnd, keyCtrlE:
+		e.cursor = len(e.line)
+
+	case keyCtrlK:
+		e.line = e.line[:e.cursor]
+
+	case keyCtrlU:
+		e.line = append(e.line[:0], e.line[e.cursor:]...)
+		e.cursor = 0
+
+	case keyCtrlW:
+		start := e.cursor
+		for start > 0 && e.line[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && e.line[start-1] != ' ' {
+			start--
+		}
+		e.line = append(e.line[:start], e.line[e.cursor:]...)
+		e.cursor = start
+
+	case keyUp, keyCtrlP:
+		e.browseHistory(-1)
+
+	case keyDown, keyCtrlN:
+		e.browseHistory(1)
+
+	case keyCtrlR:
+		e.search = &historySearch{match: -1, initial: append([]rune(nil), e.line...)}
+
+	case keyCtrlL:
+		fmt.Fprint(e.output, "\x1b[H\x1b[2J")
+
+	case keyTab:
+		e.complete(tabbed)
+
+	case keyCtrlG, keyUnknown:
+
+	default:
+		if unicode.IsPrint(key) {
+			e.line = append(e.line[:e.cursor], append([]rune{key}, e.line[e.cursor:]...)...)
+			e.cursor++
+		}
+	}
+
+	e.redraw()
+	return "", false, nil
+}
+
+// Executes a key typed during a reverse search. Returns false for keys ending the search, the matching line
+// is then edited with the key
+func (e *Editor) handleSearchKey(key rune) bool {
+	search := e.search
+
+	switch {
+	case key == keyCtrlR:
+		e.findInHistory(search.match - 1)
+	case key == keyBackspace || key == keyDelete:
+		if len(search.query) > 0 {
+			search.query = search.query[:len(search.query)-1]
+			e.findInHistory(len(e.history) - 1)
+		}
+	case key == keyCtrlG:
+		e.line, e.cursor = search.initial, len(search.initial)
+		e.search = nil
+	case key < keyUp && unicode.IsPrint(key):
+		search.query = append(search.query, key)
+		e.findInHistory(search.match)
+	default:
+		e.search = nil
+		return false
+	}
+
+	e.redraw()
+	return true
+}
+
+// Shows the latest line of history containing the query, at or before the index. Keeps the line if none does
+func (e *Editor) findInHistory(from int) {
+	search := e.search
+
+	if from >= len(e.history) || from < 0 {
+		from = len(e.history) - 1
+	}
+
+	for index := from; index >= 0; index-- {
+		if strings.Contains(e.history[index], string(search.query)) {
+			search.match = index
+			e.line = []rune(e.history[index])
+			e.cursor = len(e.line)
+			return
+		}
+	}
+}
+
+// Completes the word before the cursor: the only candidate is inserted followed by a space, otherwise the prefix
+// common to all candidates. A second Tab completing nothing further lists the candidates
+func (e *Editor) complete(tabbed bool) {
+	if e.completer == nil {
+		return
+	}
+
+	before := string(e.line[:e.cursor])
+	word := []rune(before[strings.LastIndex(before, " ")+1:])
+
+	candidates := e.completer(before)
+	if len(candidates) == 0 {
+		fmt.Fprint(e.output, "\a")
+		return
+	}
+
+	// a candidate ending with a colon is continued, e.g. a file name with its line number
+	completion := []rune(commonPrefix(candidates))
+	if len(candidates) == 1 && !strings.HasSuffix(candidates[0], ":") {
+		completion = append(completion, ' ')
+	}
+
+	if len(completion) > len(word) {
+		start := e.cursor - len(word)
+		e.line = append(append(e.line[:start:start], completion...), e.line[e.cursor:]...)
+		e.cursor = start + len(completion)
+		return
+	}
+
+	if !tabbed {
+		e.tabbed = true
+		fmt.Fprint(e.output, "\a")
+		return
+	}
+
+	e.listCandidates(candidates)
+}
+
+// Prints the candidates of a completion in columns below the line, which is drawn again after them
+func (e *Editor) listCandidates(candidates []string) {
+	width := 0
+	for _, candidate := range candidates {
+		if length := len([]rune(candidate)) + 2; length > width {
+			width = length
+		}
+	}
+
+	columns := COMPLETION_LIST_WIDTH / width
+	if columns == 0 {
+		columns = 1
+	}
+
+	listing := new(strings.Builder)
+	for index, candidate := range candidates {
+		if index%columns == 0 {
+			listing.WriteString("\r\n")
+		}
+		fmt.Fprintf(listing, "%-*s", width, candidate)
+	}
+
+	fmt.Fprintf(e.output, "%s\r\n", listing.String())
+}
+
+// The longest prefix of all the strings
+func commonPrefix(candidates []string) string {
+	prefix := []rune(candidates[0])
+
+	for _, candidate := range candidates[1:] {
+		runes := []rune(candidate)
+
+		length := 0
+		for length < len(prefix) && length < len(runes) && prefix[length] == runes[length] {
+			length++
+		}
+
+		prefix = prefix[:length]
+	}
+
+	return string(prefix)
+}
+
+// Replaces the line with the previous (-1) or next (1) line of the history
+func (e *Editor) browseHistory(direction int) {
+	next := e.browsed + direction
+	if next < 0 || next > len(e.history) {
+		return
+	}
+
+	if e.browsed == len(e.history) {
+		e.pending = append([]rune(nil), e.line...)
+	}
+
+	e.browsed = next
+
+	if next == len(e.history) {
+		e.line = e.pending
+	} else {
+		e.line = []rune(e.history[next])
+	}
+
+	e.cursor = len(e.line)
+}
+
+func (e *Editor) deleteAt(position int) {
+	if position < len(e.line) {
+		e.line = append(e.line[:position], e.line[position+1:]...)
+	}
+}
+
+// Draws the prompt and the line in place of the current row, the cursor at its position. The lock must be held
+func (e *Editor) redraw() {
+	prompt, line, cursor := e.prompt, string(e.line), e.cursor
+
+	if e.search != nil {
+		prompt = fmt.Sprintf("(reverse-i-search)`%s': ", string(e.search.query))
+	}
+
+	fmt.Fprintf(e.output, "\r\x1b[K%s%s", prompt, line)
+
+	if back := len(e.line) - cursor; back > 0 {
+		fmt.Fprintf(e.output, "\x1b[%dD", back)
+	}
+}
+
+// Ends a line abandoned by an error of the input
+func (e *Editor) finishLine() {
+	e.Lock()
+	defer e.Unlock()
+
+	e.reading = false
+	fmt.Fprint(e.output, "\r\n")
+}
+
+// Reads a key: a rune, or an escape sequence of the cursor and editing keys
+func (e *Editor) readKey() (rune, error) {
+	key, _, err := e.input.ReadRune()
+	if err != nil || key != keyEscape {
+		return key, err
+	}
+
+	introducer, _, err := e.input.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if introducer != '[' && introducer != 'O' {
+		return keyUnknown, nil
+	}
+
+	// parameters, then the final byte of the sequence
+	parameters := ""
+	for {
+		next, _, err := e.input.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+
+		if next >= '@' && next <= '~' {
+			return escapeSequenceKey(parameters, next), nil
+		}
+
+		parameters += string(next)
+	}
+}
+
+func escapeSequenceKey(parameters string, final rune) rune {
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		return keyRight
+	case 'D':
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case '~':
+		switch parameters {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyForwardDelete
+		}
+	}
+
+	return keyUnknown
+}
+
+// Reads the latest lines of the history file
+func (e *Editor) loadHistory() {
+	if len(e.historyFile) == 0 {
+		return
+	}
+
+	contents, err := os.ReadFile(e.historyFile)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(contents), "\n") {
+		if len(strings.TrimSpace(line)) > 0 {
+			e.history = append(e.history, line)
+		}
+	}
+
+	if excess := len(e.history) - HISTORY_SIZE; excess > 0 {
+		e.history = e.history[excess:]
+	}
+}
+
+// Adds a line to the history and appends it to the history file, unless it is empty or repeats the previous line
+func (e *Editor) addHistory(line string) {
+	if len(strings.TrimSpace(line)) == 0 || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+
+	e.history = append(e.history, line)
+	if excess := len(e.history) - HISTORY_SIZE; excess > 0 {
+		e.history = e.history[excess:]
+	}
+
+	if len(e.historyFile) == 0 {
+		return
+	}
+
+	file, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	fmt.Fprintln(file, line)
+}
@@ -0,0 +1,53 @@
+package readline
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func isTerminal(file *os.File) bool {
+	_, err := getTermios(file)
+	return err == nil
+}
+
+// Switches the terminal to reading key by key without echoing them, returning the function switching it back.
+// Output is still processed, newlines start new rows
+func makeRaw(file *os.File) (restore func(), err error) {
+	original, err := getTermios(file)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := *original
+	raw.Iflag &^= syscall.ICRNL | syscall.IXON | syscall.ISTRIP | syscall.INPCK | syscall.BRKINT
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := setTermios(file, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() { setTermios(file, original) }, nil
+}
+
+func getTermios(file *os.File) (*syscall.Termios, error) {
+	termios := new(syscall.Termios)
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return nil, errno
+	}
+
+	return termios, nil
+}
+
+func setTermios(file *os.File, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package readline
+
+import (
+	"errors"
+	"os"
+)
+
+// Line editing needs the terminal modes of linux, elsewhere lines are read as they are
+func isTerminal(file *os.File) bool {
+	return false
+}
+
+func makeRaw(file *os.File) (restore func(), err error) {
+	return nil, errors.New("line editing is not supported on this platform")
+}
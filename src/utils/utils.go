@@ -6,6 +6,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 	"unsafe"
 )
@@ -35,15 +38,29 @@ func PrintPSInfo(pid int) {
 	Must(err)
 }
 
+// source of the random ids, seeded with --seed to give a replayed session the ids of the original one
+var idSource = struct {
+	sync.Mutex
+	random *rand.Rand
+}{random: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+func SeedRandomIds(seed int64) {
+	idSource.Lock()
+	defer idSource.Unlock()
+
+	idSource.random = rand.New(rand.NewSource(seed))
+}
+
 func RandomId() string {
-	rand.Seed(time.Now().UnixNano())
+	idSource.Lock()
+	defer idSource.Unlock()
 
 	length := 10
 	var letters = []rune("0123456789abcdefghijklmnopqrstuvwxyz")
 
 	runes := make([]rune, length)
 	for i := range runes {
-		runes[i] = letters[rand.Intn(len(letters))]
+		runes[i] = letters[idSource.random.Intn(len(letters))]
 	}
 	return string(runes)
 }
@@ -63,3 +80,42 @@ func IsRunningInContainer() bool {
 	}
 	return true
 }
+
+// Parses a size in bytes, optionally suffixed with K, M or G
+func ParseByteSize(value string) (uint64, error) {
+	multiplier := uint64(1)
+
+	switch {
+	case strings.HasSuffix(value, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(value, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(value, "G"):
+		multiplier = 1 << 30
+	}
+
+	size, err := strconv.ParseUint(strings.TrimRight(value, "KMG"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q, expected a number of bytes, optionally suffixed with K, M or G", value)
+	}
+
+	return size * multiplier, nil
+}
+
+// Formats a size in bytes in binary units, e.g. 12.5 MiB
+func FormatByteSize(bytes uint64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+	value := float64(bytes)
+	unit := 0
+
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -0,0 +1,4 @@
+package utils
+
+// Version of the debugger, set at build time by the Makefile (-ldflags "-X github.com/ottmartens/cc-rev-db/utils.Version=...")
+var Version = "dev"